@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// userAliases returns the command aliases from ccb.config (see
+// config.Aliases). Built-in commands and provider names cannot be
+// redefined; such entries are dropped.
+func userAliases() map[string][]string {
+	cwd, _ := os.Getwd()
+	aliases := config.Aliases(cwd)
+	providers := make(map[string]bool)
+	for _, p := range config.KnownProviders() {
+		providers[p] = true
+	}
+	for name := range aliases {
+		if knownSubcommands[name] || providers[name] || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t,") {
+			delete(aliases, name)
+		}
+	}
+	return aliases
+}
+
+// expandAlias replaces the first positional argument with its expansion
+// when it names an alias: with rv = "ask codex --template review",
+// "ccb --json rv file.go" runs "ccb --json ask codex --template review
+// file.go". Aliases are expanded once, so they cannot recurse.
+func expandAlias(args []string, aliases map[string][]string) []string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		expansion, ok := aliases[arg]
+		if !ok {
+			return args
+		}
+		out := append([]string{}, args[:i]...)
+		out = append(out, expansion...)
+		return append(out, args[i+1:]...)
+	}
+	return args
+}
+
+// addAliasCmds lists the aliases as subcommands so they show up in help
+// and completion. main expands them before cobra runs; the commands only
+// run when cobra is reached another way.
+func addAliasCmds(rootCmd *cobra.Command, aliases map[string][]string) {
+	for name, expansion := range aliases {
+		expansion := expansion
+		rootCmd.AddCommand(&cobra.Command{
+			Use:                name,
+			Short:              "Alias for 'ccb " + strings.Join(expansion, " ") + "'",
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				root := buildRootCmd()
+				root.SetArgs(append(append([]string{}, expansion...), args...))
+				if err := root.Execute(); err != nil {
+					os.Exit(1)
+				}
+			},
+		})
+	}
+}
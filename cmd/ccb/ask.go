@@ -0,0 +1,536 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/clipboard"
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// askOptions holds the flags shared by "ask" and the provider shortcuts.
+type askOptions struct {
+	timeout   float64
+	quiet     bool
+	quick     bool
+	clipboard bool
+	queue     bool
+	async     bool
+	deliverAt string
+	ttl       time.Duration
+	stream    bool
+	symbols   []string
+	stdin     bool
+	files     []string
+	template  string
+	vars      []string
+	edit      bool
+	output    string
+	record    bool
+	priority  string
+	full      bool
+	maxLines  int
+	failOn    map[int]*bool
+}
+
+// addAskFlags registers the ask flags on cmd.
+func addAskFlags(cmd *cobra.Command, opts *askOptions) {
+	cmd.Flags().Float64VarP(&opts.timeout, "timeout", "t", 0, "Timeout in seconds (default: the provider's \"timeouts\" entry in ccb.config, else 120)")
+	cmd.Flags().BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress progress output")
+	cmd.Flags().BoolVar(&opts.quick, "quick", false, "Read the reply from the pane only (no log discovery, short timeout)")
+	cmd.Flags().BoolVar(&opts.clipboard, "clipboard", false, "Use the clipboard as the message, or attach it when a message is given")
+	cmd.Flags().BoolVar(&opts.queue, "queue", false, "If the provider is offline, queue the ask and deliver it when the provider comes back")
+	cmd.Flags().BoolVar(&opts.async, "async", false, "Submit the ask as a background job and print its id; collect the reply with 'ccb jobs result'")
+	cmd.Flags().StringVar(&opts.deliverAt, "deliver-at", "", "Schedule the ask: RFC 3339, \"2006-01-02 15:04\", \"15:04\" or +duration (e.g. +2h)")
+	cmd.Flags().DurationVar(&opts.ttl, "ttl", 0, "Drop a queued or scheduled ask not delivered within this long (implies --queue)")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "Write the reply to this file inside the project (atomically) and print only a summary line")
+	cmd.Flags().BoolVar(&opts.record, "record", false, "Record the text typed into the pane and pane snapshots; replay with 'ccb replay-io <req_id>'")
+	cmd.Flags().StringVar(&opts.priority, "priority", "", "interactive or background: the daemon runs waiting interactive asks first (default: CCB_PRIORITY, else interactive)")
+	cmd.Flags().BoolVar(&opts.stream, "stream", false, "Print reply lines as the provider writes them (NDJSON chunk events with --json)")
+	cmd.Flags().BoolVar(&opts.stdin, "stdin", false, "Read the message from stdin; with a message given, stdin is appended to it")
+	cmd.Flags().StringArrayVar(&opts.files, "file", nil, "Attach a file's contents (path or path:start-end; repeatable). Limits: CCB_ATTACH_MAX_BYTES per file, CCB_ATTACH_MAX_TOTAL_BYTES overall")
+	cmd.Flags().StringVar(&opts.template, "template", "", "Expand a prompt template from ~/.ccb/templates/<name>.tmpl; the message becomes {{.Message}}")
+	cmd.Flags().StringArrayVar(&opts.vars, "var", nil, "Set a template variable (key=value, repeatable)")
+	cmd.Flags().BoolVar(&opts.edit, "edit", false, "Compose the message in $VISUAL/$EDITOR, prefilled with the message or --template")
+	cmd.Flags().BoolVar(&opts.full, "full", false, "Print the whole reply even when it is longer than --max-lines")
+	cmd.Flags().IntVar(&opts.maxLines, "max-lines", 0, "At a terminal, show only the first and last lines of longer replies (default: CCB_DISPLAY_MAX_LINES, 0 = no limit)")
+	cmd.Flags().StringArrayVar(&opts.symbols, "symbol", nil, "Attach a Go symbol's definition and references (pkg.Name or Type.Method; repeatable; resolved by type-checking the module, which needs the go command)")
+	addFailOnFlags(cmd, opts)
+}
+
+// runAsk sends the message to provider, prints the reply and exits with
+// the result's exit code, as filtered by the --fail-on-* switches.
+func runAsk(cmd *cobra.Command, provider string, words []string, opts *askOptions) error {
+	req, err := buildAskRequest(cmd, provider, words, opts)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(provider, ",") {
+		if opts.async {
+			return fmt.Errorf("--async takes a single provider")
+		}
+		if opts.stream {
+			return fmt.Errorf("--stream takes a single provider (use 'ccb chat --stream' for several)")
+		}
+		if opts.output != "" {
+			return fmt.Errorf("--output takes a single provider")
+		}
+		return runBroadcast(req, client.SplitProviders(provider), opts)
+	}
+
+	if opts.stream && opts.output != "" {
+		return fmt.Errorf("--stream and --output cannot be combined")
+	}
+	if opts.stream && opts.quick {
+		return fmt.Errorf("--stream and --quick cannot be combined (quick asks read the pane only once the reply is done)")
+	}
+	if opts.async {
+		if opts.stream {
+			return fmt.Errorf("--stream and --async cannot be combined")
+		}
+		return runAskAsync(req, opts)
+	}
+
+	var stream *lineStream
+	if opts.stream {
+		stream = &lineStream{provider: provider}
+		req.OnChunk = stream.onChunk
+	}
+
+	req.ReqID = protocol.MakeReqID()
+	stop := cancelOnInterrupt(provider, req.ReqID, opts)
+	result, err := client.Ask(req)
+	stop()
+	if err != nil {
+		code := client.ExitCode(err)
+		if jsonOutput {
+			output.PrintJSON(&client.AskResult{Provider: provider, ExitCode: code, Error: err.Error()})
+		} else {
+			output.Errorf("%s", err)
+		}
+		os.Exit(opts.exitCode(code))
+	}
+	if jsonOutput {
+		output.PrintJSON(result)
+		os.Exit(opts.exitCode(result.ExitCode))
+	}
+
+	if result.Queued {
+		if !req.DeliverAt.IsZero() {
+			fmt.Fprintf(os.Stderr, "[scheduled] the ask will be delivered to %s at %s (req_id %s). Run 'ccb pend %s' after the notification.\n", provider, req.DeliverAt.Format("2006-01-02 15:04"), result.ReqID, provider)
+		} else {
+			fmt.Fprintf(os.Stderr, "[queued] %s is offline; the ask will be delivered when it comes back (req_id %s). Run 'ccb pend %s' after the notification.\n", provider, result.ReqID, provider)
+		}
+		if req.OutputPath != "" {
+			fmt.Fprintf(os.Stderr, "[queued] the reply will be written to %s\n", req.OutputPath)
+		}
+		return nil
+	}
+	if result.Error != "" && result.ExitCode != 0 {
+		output.Errorf("%s", result.Error)
+	}
+	if result.OutputPath != "" {
+		fmt.Println(outputSummary(provider, result))
+		os.Exit(opts.exitCode(result.ExitCode))
+	}
+	if stream != nil {
+		stream.finish(result.Reply)
+	} else if result.Reply != "" {
+		hint := fmt.Sprintf("use --full, --output FILE or 'ccb pend %s'", provider)
+		reply, _ := output.TruncateLines(result.Reply, displayMaxLines(opts), hint)
+		fmt.Println(reply)
+	}
+	if !opts.quiet && result.DoneHeuristic {
+		fmt.Fprintf(os.Stderr, "[no CCB_DONE marker; reply accepted by %s heuristic]\n", result.DoneReason)
+	}
+	if !opts.quiet && result.ReqID != "" {
+		fmt.Fprintf(os.Stderr, "[req_id %s]\n", result.ReqID)
+	}
+	if !opts.quiet && result.Recording != "" {
+		fmt.Fprintf(os.Stderr, "[recorded; replay with 'ccb replay-io %s']\n", result.ReqID)
+	}
+	os.Exit(opts.exitCode(result.ExitCode))
+	return nil
+}
+
+// runAskAsync submits req as a background job and prints its id.
+func runAskAsync(req client.AskRequest, opts *askOptions) error {
+	jobID, err := client.SubmitAsk(req)
+	if err != nil {
+		output.Errorf("%s", err)
+		os.Exit(client.ExitCode(err))
+	}
+	if jsonOutput {
+		output.PrintJSON(map[string]string{"job_id": jobID, "provider": req.Provider})
+		return nil
+	}
+	fmt.Println(jobID)
+	if opts.quiet {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "[job submitted to %s; check with 'ccb jobs status %s', collect with 'ccb jobs result %s']\n", req.Provider, jobID, jobID)
+	return nil
+}
+
+// cancelOnInterrupt makes Ctrl+C cancel the ask in the daemon (which
+// interrupts the provider pane) before exiting, rather than leaving the
+// provider working on it. The returned func stops watching for Ctrl+C.
+func cancelOnInterrupt(provider, reqID string, opts *askOptions) func() {
+	return onInterrupt(func() {
+		cancelAsk(reqID)
+		if jsonOutput {
+			output.PrintJSON(&client.AskResult{Provider: provider, ExitCode: output.ExitCanceled, ReqID: reqID, Error: "canceled"})
+		} else if !opts.quiet {
+			fmt.Fprintf(os.Stderr, "\n[canceled req_id %s]\n", reqID)
+		}
+		os.Exit(opts.exitCode(output.ExitCanceled))
+	})
+}
+
+// cancelAsk asks the daemon to cancel reqID, which interrupts the provider.
+func cancelAsk(reqID string) {
+	if _, err := client.CancelRequest(reqID); err != nil {
+		output.Debugf("cancel %s: %v", reqID, err)
+	}
+}
+
+// onInterrupt runs fn on Ctrl+C until the returned func is called.
+func onInterrupt(fn func()) func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigCh:
+			fn()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// buildAskRequest assembles the message (stdin, clipboard, files, symbols) and flags into
+// a client request.
+func buildAskRequest(cmd *cobra.Command, provider string, words []string, opts *askOptions) (client.AskRequest, error) {
+	message := strings.Join(words, " ")
+
+	// Read from stdin if message is "-" or --stdin is set
+	if opts.edit && (message == "-" || opts.stdin) {
+		return client.AskRequest{}, fmt.Errorf("--edit needs the terminal; it cannot be combined with stdin")
+	}
+	if message == "-" || opts.stdin {
+		data, err := readStdin()
+		if err != nil {
+			return client.AskRequest{}, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		text := output.DecodeStdinBytes(data)
+		if message == "-" || strings.TrimSpace(message) == "" {
+			message = text
+		} else {
+			message = strings.TrimRight(message, "\n") + "\n\n" + strings.TrimRight(text, "\n")
+		}
+	}
+
+	if opts.template != "" {
+		vars, err := prompt.ParseVars(opts.vars)
+		if err != nil {
+			return client.AskRequest{}, err
+		}
+		if message, err = prompt.RenderTemplate(prompt.TemplateDir(), opts.template, vars, message, provider); err != nil {
+			return client.AskRequest{}, err
+		}
+	} else if len(opts.vars) > 0 {
+		return client.AskRequest{}, fmt.Errorf("--var needs --template")
+	}
+	if opts.edit {
+		edited, err := prompt.Edit(message, provider)
+		if err != nil {
+			return client.AskRequest{}, err
+		}
+		if edited == "" {
+			return client.AskRequest{}, fmt.Errorf("empty message; nothing sent")
+		}
+		message = edited
+	}
+
+	if opts.clipboard {
+		clip, err := clipboard.Read()
+		if err != nil {
+			return client.AskRequest{}, fmt.Errorf("failed to read clipboard: %w", err)
+		}
+		message = attachClipboard(message, clip)
+	}
+	if len(opts.files) > 0 {
+		files, err := prompt.AttachFiles(opts.files, prompt.AttachLimits{
+			MaxFileBytes:  config.EnvInt("CCB_ATTACH_MAX_BYTES", prompt.DefaultMaxFileBytes),
+			MaxTotalBytes: config.EnvInt("CCB_ATTACH_MAX_TOTAL_BYTES", prompt.DefaultMaxTotalBytes),
+		})
+		if err != nil {
+			return client.AskRequest{}, err
+		}
+		message = strings.TrimSpace(message + "\n\n" + files)
+	}
+	if len(opts.symbols) > 0 {
+		var err error
+		if message, err = attachSymbols(message, opts.symbols); err != nil {
+			return client.AskRequest{}, err
+		}
+	}
+	if strings.TrimSpace(message) == "" {
+		return client.AskRequest{}, fmt.Errorf("empty message")
+	}
+
+	var deliverAt time.Time
+	if opts.deliverAt != "" {
+		t, err := client.ParseDeliverAt(opts.deliverAt, time.Now())
+		if err != nil {
+			return client.AskRequest{}, err
+		}
+		deliverAt = t
+	}
+
+	outputPath := opts.output
+	if outputPath != "" {
+		// The daemon runs elsewhere; send it an absolute path.
+		abs, err := filepath.Abs(outputPath)
+		if err != nil {
+			return client.AskRequest{}, err
+		}
+		outputPath = abs
+	}
+
+	switch opts.priority {
+	case "", "interactive", "background":
+	default:
+		return client.AskRequest{}, fmt.Errorf("--priority %q: want interactive or background", opts.priority)
+	}
+
+	timeout := opts.timeout
+	if opts.quick && !cmd.Flags().Changed("timeout") {
+		timeout = comm.DefaultQuickTimeout.Seconds()
+	}
+
+	return client.AskRequest{
+		Provider:  provider,
+		Message:   message,
+		TimeoutS:  timeout,
+		Quiet:     opts.quiet,
+		Quick:     opts.quick,
+		Queue:     opts.queue || opts.ttl > 0,
+		DeliverAt: deliverAt,
+		TTL:       opts.ttl,
+
+		OutputPath: outputPath,
+		Record:     opts.record,
+		Priority:   opts.priority,
+	}, nil
+}
+
+// outputSummary is the line printed instead of the reply with --output.
+func outputSummary(provider string, result *client.AskResult) string {
+	lines := 0
+	if result.Reply != "" {
+		lines = strings.Count(strings.TrimRight(result.Reply, "\n"), "\n") + 1
+	}
+	f := i18n.GetFormatter()
+	return fmt.Sprintf("%s reply written to %s (%s lines, %s, req_id %s)",
+		provider, result.OutputPath, f.Count(int64(lines)), f.Bytes(int64(len(result.Reply))), result.ReqID)
+}
+
+// runBroadcast asks several providers at once and prints each reply under
+// its provider's name. The exit code is the first non-zero provider code
+// that the --fail-on-* switches keep.
+func runBroadcast(req client.AskRequest, providers []string, opts *askOptions) error {
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers specified")
+	}
+	started := time.Now()
+	req.ReqID = protocol.MakeReqID()
+	stop := onInterrupt(func() {
+		// Ctrl+C cancels every provider's ask, as it does a single one.
+		canceled := make([]*client.AskResult, len(providers))
+		for i, provider := range providers {
+			reqID := client.BroadcastReqID(req.ReqID, provider)
+			cancelAsk(reqID)
+			canceled[i] = &client.AskResult{Provider: provider, ExitCode: output.ExitCanceled, ReqID: reqID, Error: "canceled"}
+		}
+		if jsonOutput {
+			output.PrintJSON(client.NewMultiResult("broadcast", req.ReqID, started, canceled))
+		} else if !opts.quiet {
+			fmt.Fprintf(os.Stderr, "\n[canceled req_id %s-*]\n", req.ReqID)
+		}
+		os.Exit(opts.exitCode(output.ExitCanceled))
+	})
+	results := client.Broadcast(req, providers)
+	stop()
+	sections, exitCode := resultSections(results, opts)
+	if jsonOutput {
+		output.PrintJSON(client.NewMultiResult("broadcast", req.ReqID, started, results))
+	} else {
+		fmt.Print(output.RenderSections(truncateSections(sections, displayMaxLines(opts))))
+	}
+	os.Exit(exitCode)
+	return nil
+}
+
+// resultSections labels each result with its provider, folding errors into
+// the body. It also returns the first non-zero exit code left by
+// opts.exitCode.
+func resultSections(results []*client.AskResult, opts *askOptions) ([]output.Section, int) {
+	exitCode := output.ExitOK
+	sections := make([]output.Section, 0, len(results))
+	for _, r := range results {
+		body := r.Reply
+		if r.Queued {
+			body = "[queued] offline; will be delivered when it comes back (req_id " + r.ReqID + ")"
+		}
+		if r.ExitCode != 0 {
+			if exitCode == output.ExitOK {
+				exitCode = opts.exitCode(r.ExitCode)
+			}
+			if r.Error != "" {
+				body = strings.TrimSpace(body + "\n[error] " + r.Error)
+			}
+		}
+		sections = append(sections, output.Section{Label: r.Provider, Body: body})
+	}
+	return sections, exitCode
+}
+
+// displayMaxLines returns how many lines of a reply to print, 0 for all.
+// Replies are only truncated at a terminal and without --full; the limit
+// is --max-lines, else CCB_DISPLAY_MAX_LINES.
+func displayMaxLines(opts *askOptions) int {
+	if opts.full {
+		return 0
+	}
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+	if opts.maxLines > 0 {
+		return opts.maxLines
+	}
+	return config.EnvInt("CCB_DISPLAY_MAX_LINES", 0)
+}
+
+// truncateSections truncates each provider's reply to maxLines for display,
+// pointing at 'ccb pend' for the full text.
+func truncateSections(sections []output.Section, maxLines int) []output.Section {
+	out := make([]output.Section, len(sections))
+	for i, s := range sections {
+		s.Body, _ = output.TruncateLines(s.Body, maxLines, fmt.Sprintf("use --full or 'ccb pend %s'", s.Label))
+		out[i] = s
+	}
+	return out
+}
+
+// readStdin reads all of stdin. It works with pipes, redirects and
+// consoles on every platform; at an interactive terminal it says how to
+// end the input.
+func readStdin() ([]byte, error) {
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		eof := "Ctrl-D"
+		if goruntime.GOOS == "windows" {
+			eof = "Ctrl-Z then Enter"
+		}
+		fmt.Fprintf(os.Stderr, "Reading the message from stdin; end it with %s.\n", eof)
+	}
+	return io.ReadAll(os.Stdin)
+}
+
+// attachClipboard uses clip as the message, or appends it as a fenced
+// attachment when the user also typed a message.
+func attachClipboard(message, clip string) string {
+	clip = strings.TrimRight(clip, "\n")
+	if strings.TrimSpace(message) == "" {
+		return clip
+	}
+	return message + "\n\nClipboard contents:\n```\n" + clip + "\n```"
+}
+
+// attachSymbols appends the Go context of each symbol, resolved from the
+// module containing the working directory.
+func attachSymbols(message string, symbols []string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	index, err := prompt.LoadSymbols(prompt.FindModuleRoot(cwd))
+	if err != nil {
+		return "", err
+	}
+	parts := []string{message}
+	for _, sym := range symbols {
+		ctx, err := index.Context(sym, prompt.DefaultMaxSymbolRefs)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, ctx)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n")), nil
+}
+
+// lineStream prints streamed reply lines as they arrive, optionally
+// labeled, then whatever part of the final reply was not streamed.
+type lineStream struct {
+	provider string
+	label    bool        // prefix lines with "provider| "
+	mu       *sync.Mutex // shared when several streams write to stdout
+	printed  []string
+}
+
+func (l *lineStream) onChunk(lines []string) {
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if jsonOutput {
+		output.PrintJSON(map[string]interface{}{"event": "chunk", "provider": l.provider, "lines": lines})
+		return
+	}
+	l.print(lines)
+}
+
+// finish prints the lines of reply after those it shares with what was
+// already streamed. The final reply is cleaned up (echoed prompt, done
+// marker), so it is matched by content rather than by line count.
+func (l *lineStream) finish(reply string) {
+	if reply == "" {
+		return
+	}
+	lines := strings.Split(reply, "\n")
+	n := 0
+	for n < len(lines) && n < len(l.printed) && lines[n] == l.printed[n] {
+		n++
+	}
+	l.print(lines[n:])
+}
+
+func (l *lineStream) print(lines []string) {
+	for _, line := range lines {
+		if l.label {
+			fmt.Printf("%s| %s\n", l.provider, line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	l.printed = append(l.printed, lines...)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+)
+
+// newAskfCmd builds "ccb askf", an ask that embeds a numbered file range
+// (with surrounding context) ahead of the question.
+func newAskfCmd() *cobra.Command {
+	opts := &askOptions{}
+	var contextLines int
+	cmd := &cobra.Command{
+		Use:   "askf <provider> <file[:start-end]> [message...]",
+		Short: "Ask about a file or line range, embedding the code in the prompt",
+		Example: `  ccb askf codex internal/client/client.go:120-180 "why does this leak?"
+  ccb askf claude main.go "review this file"`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fr, err := prompt.ParseFileRange(args[1])
+			if err != nil {
+				return err
+			}
+			code, err := prompt.RenderFileRange(fr, contextLines)
+			if err != nil {
+				return err
+			}
+			message := code
+			if question := strings.Join(args[2:], " "); question != "" {
+				message += "\n\n" + question
+			}
+			return runAsk(cmd, args[0], []string{message}, opts)
+		},
+	}
+	addAskFlags(cmd, opts)
+	cmd.Flags().IntVarP(&contextLines, "context", "C", prompt.DefaultContextLines, "Lines of context around the range")
+	return cmd
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newAttachCmd builds "ccb attach", which brings a provider's pane to the
+// front: tmux selects it (switching session if needed), WezTerm activates
+// it and PowerShell raises its window.
+func newAttachCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:               "attach <provider>",
+		Short:             "Focus a provider's pane",
+		Example:           "  ccb attach codex\n  ccb attach claude --dir ~/src/app",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeLiveProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := strings.ToLower(args[0])
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			paneID, err := focusProviderPane(provider, workDir)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]string{"provider": provider, "pane_id": paneID})
+				return
+			}
+			fmt.Printf("%s: focused pane %s\n", provider, paneID)
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory whose pane to focus (default: current directory)")
+	return cmd
+}
+
+// focusProviderPane focuses provider's registered pane for workDir and
+// returns its ID.
+func focusProviderPane(provider, workDir string) (string, error) {
+	backend, err := terminal.DetectBackend()
+	if err != nil {
+		return "", err
+	}
+	reg := session.NewPaneRegistry(session.RegistryPath())
+	entry := reg.GetEntry(provider, config.ComputeCCBProjectID(workDir))
+	if entry == nil || entry.PaneID == "" {
+		return "", fmt.Errorf("%s has no pane registered for this project (start it with 'ccb %s')", provider, provider)
+	}
+	if !backend.IsAlive(entry.PaneID) {
+		return "", fmt.Errorf("%s's pane %s is gone (bring it back with 'ccb restart %s')", provider, entry.PaneID, provider)
+	}
+	if err := backend.FocusPane(entry.PaneID); err != nil {
+		return "", fmt.Errorf("cannot focus %s's pane %s: %w", provider, entry.PaneID, err)
+	}
+	return entry.PaneID, nil
+}
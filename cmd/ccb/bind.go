@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/launcher"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newBindCmd builds "ccb bind", which tells ccb which pane runs a provider
+// when auto-detection fails.
+func newBindCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:   "bind <provider> <pane-id>",
+		Short: "Bind an existing pane to a provider for this project",
+		Example: `  ccb bind codex %3          # tmux pane id (tmux display -p '#{pane_id}')
+  ccb bind gemini 12         # WezTerm pane id (wezterm cli list)`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, paneID := args[0], args[1]
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			backend, err := terminal.DetectBackend()
+			if err != nil {
+				backend = nil
+				fmt.Fprintln(os.Stderr, "note: no terminal backend detected; pane not verified")
+			}
+			previous, err := launcher.Bind(provider, paneID, workDir, backend)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				return output.PrintJSON(map[string]string{"provider": provider, "pane_id": paneID, "work_dir": workDir, "previous_pane_id": previous})
+			}
+			msg := fmt.Sprintf("Bound %s to pane %s", provider, paneID)
+			if previous != "" && previous != paneID {
+				msg += fmt.Sprintf(" (was %s)", previous)
+			}
+			fmt.Println(msg)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory to bind for (default: current directory)")
+	return cmd
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newCancelCmd builds "ccb cancel", which cancels in-flight asks (the
+// daemon also interrupts the provider pane) or drops queued ones.
+func newCancelCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "cancel <req_id>...",
+		Short:   "Cancel an in-flight ask and interrupt its provider",
+		Example: "  ccb cancel 20260125-143000-123-12345",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runCancel(args)
+		},
+	}
+}
+
+// runCancel asks the daemon to cancel each req_id, printing its state.
+func runCancel(reqIDs []string) {
+	results := []map[string]string{}
+	failed := false
+	for _, reqID := range reqIDs {
+		state, err := client.CancelRequest(reqID)
+		if err != nil {
+			output.Errorf("%s", err)
+			failed = true
+			continue
+		}
+		results = append(results, map[string]string{"req_id": reqID, "state": state})
+		if !jsonOutput {
+			fmt.Printf("%s: %s\n", reqID, state)
+		}
+	}
+	if jsonOutput {
+		output.PrintJSON(results)
+	}
+	if failed {
+		os.Exit(output.ExitError)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newChatCmd builds "ccb chat", an interactive loop over one persistent
+// daemon connection.
+func newChatCmd() *cobra.Command {
+	var timeout float64
+	var quick, stream bool
+	cmd := &cobra.Command{
+		Use:   "chat <provider[,provider...]>",
+		Short: "Chat interactively with one or more providers (@name or @all to address, /exit to quit)",
+		Example: `  ccb chat codex
+  ccb chat codex,claude
+    > @codex draft the parser
+    > @claude review it
+    > @all any edge cases left?`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers := client.SplitProviders(args[0])
+			if len(providers) == 0 {
+				return fmt.Errorf("no providers specified")
+			}
+			if quick && stream {
+				return fmt.Errorf("--stream and --quick cannot be combined (quick asks read the pane only once the reply is done)")
+			}
+			if quick && !cmd.Flags().Changed("timeout") {
+				timeout = comm.DefaultQuickTimeout.Seconds()
+			}
+			base := client.AskRequest{TimeoutS: timeout, Quick: quick}
+			session := newChatSession(providers, base)
+			session.stream = stream
+			return session.run()
+		},
+	}
+	cmd.Flags().Float64VarP(&timeout, "timeout", "t", 0, "Timeout in seconds per message (default: per provider from ccb.config, else 120)")
+	cmd.Flags().BoolVar(&quick, "quick", false, "Read replies from the pane only (no log discovery, short timeout)")
+	cmd.Flags().BoolVar(&stream, "stream", false, "Print reply lines as they arrive, prefixed with the provider when several answer")
+	return cmd
+}
+
+// chatSession holds the providers in a chat and the daemon connection
+// their asks share; several providers can answer the same message
+// concurrently over it.
+type chatSession struct {
+	providers []string
+	targets   []string // who un-addressed messages go to
+	base      client.AskRequest
+	conn      *client.Conn
+	stream    bool
+	mu        sync.Mutex // guards stdout while replies arrive
+}
+
+func newChatSession(providers []string, base client.AskRequest) *chatSession {
+	return &chatSession{
+		providers: providers,
+		targets:   providers,
+		base:      base,
+	}
+}
+
+// run reads messages from stdin until EOF or /exit. A leading @name or
+// @all picks the recipients and sticks for later un-addressed messages.
+func (c *chatSession) run() error {
+	defer func() {
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	}()
+
+	if len(c.providers) == 1 {
+		fmt.Fprintf(os.Stderr, "Chatting with %s. Type /exit or press Ctrl+D to quit.\n", c.providers[0])
+	} else {
+		fmt.Fprintf(os.Stderr, "Chatting with %s. Address with @name or @all; /exit or Ctrl+D to quit.\n", strings.Join(c.providers, ", "))
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		fmt.Fprintf(os.Stderr, "%s> ", strings.Join(c.targets, ","))
+		if !scanner.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		}
+
+		targets, message, err := c.address(line)
+		if err != nil {
+			output.Errorf("%s", err)
+			continue
+		}
+		c.targets = targets
+		if message == "" {
+			continue
+		}
+		c.send(targets, message)
+	}
+}
+
+// address splits a leading "@name[,name]" or "@all" off line.
+func (c *chatSession) address(line string) ([]string, string, error) {
+	if !strings.HasPrefix(line, "@") {
+		return c.targets, line, nil
+	}
+	word, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	if word == "@all" {
+		return c.providers, rest, nil
+	}
+	var targets []string
+	for _, name := range client.SplitProviders(word[1:]) {
+		if !c.inChat(name) {
+			return nil, "", fmt.Errorf("%s is not in this chat (have: %s)", name, strings.Join(c.providers, ", "))
+		}
+		targets = append(targets, name)
+	}
+	if len(targets) == 0 {
+		return nil, "", fmt.Errorf("empty address %q", word)
+	}
+	return targets, rest, nil
+}
+
+func (c *chatSession) inChat(name string) bool {
+	for _, p := range c.providers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// send asks every target concurrently and prints each reply as it
+// arrives, labeled by provider when more than one is asked.
+func (c *chatSession) send(targets []string, message string) {
+	conn, err := c.connect()
+	if err != nil {
+		output.Errorf("%s", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range targets {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			req := c.base
+			req.Provider = name
+			req.Message = message
+			var stream *lineStream
+			if c.stream {
+				stream = &lineStream{provider: name, label: len(targets) > 1, mu: &c.mu}
+				req.OnChunk = stream.onChunk
+			}
+			result, err := conn.Ask(req)
+			if err != nil {
+				result = &client.AskResult{Provider: name, ExitCode: client.ExitCode(err), Error: err.Error()}
+			}
+			c.print(result, len(targets) > 1, stream)
+		}(name)
+	}
+	wg.Wait()
+}
+
+// print writes one reply, serialized against concurrent replies. A
+// streamed reply only prints what wasn't streamed already.
+func (c *chatSession) print(result *client.AskResult, labeled bool, stream *lineStream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if jsonOutput {
+		output.PrintJSON(result)
+		return
+	}
+	if stream != nil {
+		if result.ExitCode != 0 && result.Error != "" {
+			output.Errorf("%s: %s", result.Provider, result.Error)
+		}
+		stream.finish(result.Reply)
+		return
+	}
+	if labeled {
+		sections, _ := resultSections([]*client.AskResult{result}, &askOptions{})
+		fmt.Print(output.RenderSections(sections))
+		return
+	}
+	if result.ExitCode != 0 && result.Error != "" {
+		output.Errorf("%s", result.Error)
+	}
+	if result.Reply != "" {
+		fmt.Println(result.Reply)
+	}
+}
+
+// connect returns the session's connection, dialing it on first use and
+// again once it has failed; asks lost with a failed one are not resent.
+func (c *chatSession) connect() (*client.Conn, error) {
+	if c.conn != nil && c.conn.Err() == nil {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	conn, err := client.Dial()
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
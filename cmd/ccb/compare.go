@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/textdiff"
+)
+
+// newCompareCmd builds "ccb compare", which asks several providers the
+// same question and lays the answers out for side-by-side reading.
+func newCompareCmd() *cobra.Command {
+	opts := &askOptions{}
+	var layout string
+	var width int
+	var diff bool
+	cmd := &cobra.Command{
+		Use:   "compare <provider,provider...> [message...]",
+		Short: "Send one prompt to several providers and compare the answers",
+		Example: `  ccb compare codex,claude,gemini "best way to cache this query?"
+  ccb compare --layout columns codex,claude "rename suggestions for Foo"
+  ccb compare --diff codex,claude - < prompt.txt`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers := client.SplitProviders(args[0])
+			if len(providers) < 2 {
+				return fmt.Errorf("compare needs at least two providers, got %q", args[0])
+			}
+			req, err := buildAskRequest(cmd, args[0], args[1:], opts)
+			if err != nil {
+				return err
+			}
+
+			started := time.Now()
+			req.ReqID = protocol.MakeReqID()
+			results := client.Broadcast(req, providers)
+			sections, exitCode := resultSections(results, opts)
+			switch {
+			case jsonOutput:
+				output.PrintJSON(client.NewMultiResult("compare", req.ReqID, started, results))
+			case diff:
+				fmt.Print(renderReplyDiffs(sections))
+			case layout == "columns":
+				fmt.Print(output.RenderColumns(truncateSections(sections, displayMaxLines(opts)), width))
+			case layout == "sections":
+				fmt.Print(output.RenderSections(truncateSections(sections, displayMaxLines(opts))))
+			default:
+				return fmt.Errorf("unknown layout %q (want sections or columns)", layout)
+			}
+			os.Exit(exitCode)
+			return nil
+		},
+	}
+	addAskFlags(cmd, opts)
+	cmd.Flags().StringVar(&layout, "layout", "sections", "Output layout: sections or columns")
+	cmd.Flags().IntVar(&width, "width", terminalWidth(), "Total width for the columns layout")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Show each reply as a unified diff against the first")
+	return cmd
+}
+
+// renderReplyDiffs prints the first reply in full, then every other reply
+// as a diff against it.
+func renderReplyDiffs(sections []output.Section) string {
+	var b strings.Builder
+	base := sections[0]
+	b.WriteString(output.RenderSections([]output.Section{base}))
+	for _, s := range sections[1:] {
+		d := textdiff.Unified(base.Label, s.Label, base.Body, s.Body, 3)
+		if d == "" {
+			d = "(identical)\n"
+		}
+		b.WriteString("\n")
+		b.WriteString(output.RenderSections([]output.Section{{Label: base.Label + " -> " + s.Label, Body: d}}))
+	}
+	return b.String()
+}
+
+// terminalWidth returns $COLUMNS or a wide default.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 160
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// completeProviders completes the provider (or comma-separated providers)
+// in a command's first argument; later arguments (messages, files) get the
+// shell's default completion.
+func completeProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return client.CompleteProviderList(toComplete, config.KnownProviders()), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRegisteredProviders completes providers with a pane registered
+// for the project (--dir, else the current directory), alive or not.
+func completeRegisteredProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return client.CompleteProviderList(toComplete, registeredProviders(cmd, false)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLiveProviders completes providers whose registered pane for the
+// project (--dir, else the current directory) is alive.
+func completeLiveProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return client.CompleteProviderList(toComplete, registeredProviders(cmd, true)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// registeredProviders lists the providers with a pane in the registry for
+// the command's project, keeping only live panes when alive is set. Without
+// a terminal backend, liveness cannot be checked and every registered
+// provider is kept.
+func registeredProviders(cmd *cobra.Command, alive bool) []string {
+	workDir := ""
+	if f := cmd.Flags().Lookup("dir"); f != nil {
+		workDir = f.Value.String()
+	}
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	projectID := config.ComputeCCBProjectID(workDir)
+	reg := session.NewPaneRegistry(session.RegistryPath())
+
+	var backend terminal.Backend
+	if alive {
+		backend, _ = terminal.DetectBackend()
+	}
+	var providers []string
+	for _, p := range config.KnownProviders() {
+		entry := reg.GetEntry(p, projectID)
+		if entry == nil || entry.PaneID == "" {
+			continue
+		}
+		if backend != nil && !backend.IsAlive(entry.PaneID) {
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
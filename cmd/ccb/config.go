@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+)
+
+// newConfigCmd builds "ccb config", which shows and changes ccb.config:
+// the project file (.ccb_config/ccb.config) or, with --global, the one in
+// ~/.ccb.
+func newConfigCmd() *cobra.Command {
+	var global bool
+	// target returns the file set, edit and init work on.
+	target := func() string {
+		cwd, _ := os.Getwd()
+		project, globalPath := config.StartConfigPaths(cwd)
+		if global {
+			return globalPath
+		}
+		return project
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show which ccb.config is in effect and its resolved settings",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			showConfig()
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key in ccb.config (dotted keys for nested values)",
+		Example: `  ccb config set providers codex,claude
+  ccb config set timeouts.gemini 300
+  ccb config --global set timeouts.startup 90`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := target()
+			if err := config.SetStartConfigValue(path, args[0], args[1]); err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]string{"path": path, "key": args[0], "value": args[1]})
+				return
+			}
+			fmt.Printf("Set %s in %s\n", args[0], path)
+		},
+	}
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open ccb.config in $VISUAL/$EDITOR, creating it with the defaults first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path := target()
+			if _, err := config.EnsureStartConfig(path); err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			editor := prompt.EditorCommand()
+			c := exec.Command(editor[0], append(editor[1:], path)...)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := c.Run(); err != nil {
+				output.Errorf("editor %s: %s", editor[0], err)
+				os.Exit(output.ExitError)
+			}
+		},
+	}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create ccb.config with the default providers if it does not exist",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path := target()
+			created, err := config.EnsureStartConfig(path)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]interface{}{"path": path, "created": created})
+				return
+			}
+			if created {
+				fmt.Printf("Created %s\n", path)
+			} else {
+				fmt.Printf("%s already exists\n", path)
+			}
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show, set or edit ccb.config (project, or ~/.ccb with --global)",
+		Args:  cobra.NoArgs,
+		Run:   showCmd.Run,
+	}
+	cmd.PersistentFlags().BoolVar(&global, "global", false, "Use ~/.ccb/ccb.config instead of the project's .ccb_config/ccb.config")
+	cmd.AddCommand(showCmd, setCmd, editCmd, initCmd)
+	return cmd
+}
+
+// showConfig prints the project and global config files, which one is in
+// effect and the settings resolved from it.
+func showConfig() {
+	cwd, _ := os.Getwd()
+	project, global := config.StartConfigPaths(cwd)
+	cfg := config.LoadStartConfig(cwd)
+	scope := "default"
+	switch cfg.Path {
+	case project:
+		scope = "project"
+	case global:
+		scope = "global"
+	}
+
+	if jsonOutput {
+		output.PrintJSON(map[string]interface{}{
+			"path":         cfg.Path,
+			"scope":        scope,
+			"project_path": project,
+			"global_path":  global,
+			"providers":    cfg.GetProviders(),
+			"cmd":          cfg.CmdEnabled(),
+			"data":         cfg.Data,
+		})
+		return
+	}
+
+	state := func(path string) string {
+		if path == cfg.Path {
+			return "in effect"
+		}
+		if _, err := os.Stat(path); err == nil {
+			// Timeouts are looked up in both files (see config.AskTimeout).
+			return "present; only its timeouts apply"
+		}
+		return "not found"
+	}
+	fmt.Printf("Project:   %s (%s)\n", project, state(project))
+	fmt.Printf("Global:    %s (%s)\n", global, state(global))
+	providers := strings.Join(cfg.GetProviders(), ", ")
+	if scope == "default" {
+		providers += " (default)"
+	}
+	fmt.Printf("Providers: %s\n", providers)
+	fmt.Printf("cmd:       %v\n", cfg.CmdEnabled())
+	if len(cfg.Data) > 0 {
+		data, _ := json.MarshalIndent(cfg.Data, "", "  ")
+		fmt.Printf("\n%s\n", data)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/clipboard"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// newCopyCmd builds "ccb copy", which puts a provider's last reply, or
+// the last reply from any provider, on the system clipboard.
+func newCopyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "copy [provider]",
+		Short: "Copy the latest reply (from any provider, or the one given) to the clipboard",
+		Example: `  ccb copy
+  ccb copy codex`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var reply, provider string
+			var err error
+			if len(args) == 1 {
+				provider = args[0]
+				reply, err = client.Pend(provider)
+			} else {
+				reply, provider, err = client.PendLatest()
+			}
+			if err != nil {
+				return err
+			}
+			if reply == "" {
+				fmt.Println("(no reply)")
+				os.Exit(output.ExitNoReply)
+			}
+			reply = protocol.StripTrailingMarkers(reply)
+			if err := clipboard.Write(reply); err != nil {
+				return fmt.Errorf("failed to write clipboard: %w", err)
+			}
+			fmt.Printf("Copied %d bytes from %s\n", len(reply), provider)
+			return nil
+		},
+	}
+}
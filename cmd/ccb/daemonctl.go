@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// daemonStopTimeout is how long "daemon restart" waits for the old daemon
+// to exit, on top of the time it may spend draining asks in flight, before
+// giving up.
+const daemonStopTimeout = 5 * time.Second
+
+// newDaemonCtlCmds builds "ccb daemon restart|reload|register|unregister|logs|events".
+func newDaemonCtlCmds() []*cobra.Command {
+	restartCmd := &cobra.Command{
+		Use:   "restart",
+		Short: "Stop the daemon, wait for it to exit and start it again in the background",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cwd, _ := os.Getwd()
+			drain := time.Duration(config.DrainTimeout(cwd) * float64(time.Second))
+			oldPID, newPID, err := client.RestartDaemon(daemonStopTimeout + drain)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]int{"old_pid": oldPID, "pid": newPID})
+				return
+			}
+			if oldPID == 0 {
+				fmt.Printf("Daemon was not running; started (pid %d)\n", newPID)
+				return
+			}
+			fmt.Printf("Daemon restarted (pid %d -> %d)\n", oldPID, newPID)
+		},
+	}
+
+	reloadCmd := &cobra.Command{
+		Use:   "reload",
+		Short: "Re-read providers and limits from ccb.config without restarting (like SIGHUP)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			resp, err := client.ReloadDaemon()
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(resp)
+				return
+			}
+			fmt.Printf("Daemon reloaded; providers: %s\n", strings.Join(resp.Providers, ", "))
+			if len(resp.Added) > 0 {
+				fmt.Printf("  added:   %s\n", strings.Join(resp.Added, ", "))
+			}
+			if len(resp.Removed) > 0 {
+				fmt.Printf("  removed: %s\n", strings.Join(resp.Removed, ", "))
+			}
+		},
+	}
+
+	registerCmd := &cobra.Command{
+		Use:               "register <provider>",
+		Short:             "Make the running daemon serve a provider (e.g. one launched after it started)",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			cwd, _ := os.Getwd()
+			printRegistration(client.RegisterProvider(args[0], cwd))
+		},
+	}
+	unregisterCmd := &cobra.Command{
+		Use:               "unregister <provider>",
+		Short:             "Make the daemon stop taking asks for a provider (asks in flight finish)",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			printRegistration(client.UnregisterProvider(args[0]))
+		},
+	}
+
+	var lines int
+	var follow bool
+	logsCmd := &cobra.Command{
+		Use:     "logs",
+		Short:   "Show the tail of the daemon log (-f to follow)",
+		Example: "  ccb daemon logs\n  ccb daemon logs -f -n 100",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path := runtime.LogPath("askd")
+			_, err := os.Stat(path)
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("no daemon log at %s (start the daemon with 'ccb daemon start')", path)
+			} else if err == nil {
+				err = tailLog(path, lines, follow, true)
+			}
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+		},
+	}
+	logsCmd.Flags().IntVarP(&lines, "lines", "n", 20, "Number of lines to show")
+	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing lines as they are written")
+
+	var kinds string
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "Print the daemon's state changes as they happen (asks, providers, panes, shutdown)",
+		Long: `Subscribe to the running daemon and print one line per state change:
+provider_registered, provider_unregistered, pane_died, request_started,
+request_finished and shutting_down. With --json each line is the event's
+JSON object, for status bars and dashboards.`,
+		Example: "  ccb daemon events\n  ccb daemon events --events request_started,request_finished --json",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var only []string
+			for _, k := range strings.Split(kinds, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					only = append(only, k)
+				}
+			}
+			err := client.Subscribe(only, func(ev protocol.StateEvent) error {
+				if jsonOutput {
+					return output.PrintJSON(ev)
+				}
+				_, err := fmt.Println(formatStateEvent(ev))
+				return err
+			})
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(client.ExitCode(err))
+			}
+		},
+	}
+	eventsCmd.Flags().StringVar(&kinds, "events", "", "Comma-separated event kinds to print (default all)")
+
+	return []*cobra.Command{restartCmd, reloadCmd, registerCmd, unregisterCmd, logsCmd, eventsCmd}
+}
+
+// formatStateEvent renders ev as one line, e.g.
+// "14:03:07 request_finished codex req=20261018-... exit=0 4.2s".
+func formatStateEvent(ev protocol.StateEvent) string {
+	at := ev.Time
+	if t, err := time.Parse(time.RFC3339Nano, ev.Time); err == nil {
+		at = t.Local().Format("15:04:05")
+	}
+	parts := []string{at, ev.Event}
+	if ev.Provider != "" {
+		parts = append(parts, ev.Provider)
+	}
+	if ev.ReqID != "" {
+		parts = append(parts, "req="+ev.ReqID)
+	}
+	if ev.Event == "pane_died" && ev.WorkDir != "" {
+		parts = append(parts, "work_dir="+ev.WorkDir)
+	}
+	if ev.ExitCode != nil {
+		parts = append(parts, fmt.Sprintf("exit=%d", *ev.ExitCode),
+			(time.Duration(ev.DurationMs) * time.Millisecond).Round(100*time.Millisecond).String())
+	}
+	if ev.Error != "" {
+		parts = append(parts, "("+ev.Error+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// printRegistration reports the outcome of "daemon register|unregister".
+func printRegistration(resp *protocol.RegistrationResponse, err error) {
+	if err != nil {
+		output.Errorf("%s", err)
+		os.Exit(output.ExitError)
+	}
+	if jsonOutput {
+		output.PrintJSON(resp)
+		return
+	}
+	if !resp.Changed {
+		fmt.Printf("No change; providers: %s\n", strings.Join(resp.Providers, ", "))
+		return
+	}
+	fmt.Printf("Daemon providers: %s\n", strings.Join(resp.Providers, ", "))
+}
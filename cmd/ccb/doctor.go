@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/doctor"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newDoctorCmd builds "ccb doctor", which checks the environment CCB
+// depends on and suggests fixes.
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check terminal backend, daemon, provider CLIs, session files and logs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, _ := os.Getwd()
+			backend, backendErr := terminal.DetectBackend()
+			checks := doctor.Run(doctor.Env{
+				WorkDir:      cwd,
+				Providers:    config.LoadStartConfig(cwd).GetProviders(),
+				Backend:      backend,
+				BackendErr:   backendErr,
+				StateFile:    runtime.StateFilePath("askd"),
+				RegistryPath: session.RegistryPath(),
+				LookPath:     exec.LookPath,
+				LogRoot:      session.ProviderLogRoot,
+			})
+
+			if jsonOutput {
+				output.PrintJSON(checks)
+			} else {
+				for _, c := range checks {
+					fmt.Printf("[%s] %-22s %s\n", statusLabel(c.Status), c.Name, c.Detail)
+					if c.Hint != "" {
+						fmt.Printf("       %-22s fix: %s\n", "", c.Hint)
+					}
+				}
+			}
+			if doctor.Failed(checks) {
+				os.Exit(output.ExitError)
+			}
+			return nil
+		},
+	}
+}
+
+func statusLabel(s doctor.Status) string {
+	switch s {
+	case doctor.Pass:
+		return " ok "
+	case doctor.Warn:
+		return "warn"
+	default:
+		return "FAIL"
+	}
+}
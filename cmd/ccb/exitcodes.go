@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// failOnFlags lists the --fail-on-<name> switches and the exit code each
+// one controls.
+var failOnFlags = []struct {
+	name string
+	code int
+	what string
+}{
+	{"timeout", output.ExitTimeout, "no reply arrives in time"},
+	{"pane-dead", output.ExitPaneDead, "the provider's pane is gone"},
+	{"no-session", output.ExitNoSession, "the provider has no session"},
+	{"daemon-down", output.ExitDaemonDown, "the daemon cannot be reached"},
+	{"paused", output.ExitPaused, "the provider is paused"},
+	{"rate-limit", output.ExitRateLimit, "a daemon rate limit refuses the ask"},
+}
+
+// addFailOnFlags registers the --fail-on-* switches on cmd.
+func addFailOnFlags(cmd *cobra.Command, opts *askOptions) {
+	opts.failOn = make(map[int]*bool, len(failOnFlags))
+	for _, f := range failOnFlags {
+		v := new(bool)
+		opts.failOn[f.code] = v
+		cmd.Flags().BoolVar(v, "fail-on-"+f.name, true, fmt.Sprintf("Exit %d when %s (false: exit 0)", f.code, f.what))
+	}
+}
+
+// exitCode maps code through the --fail-on-* switches: a failure whose
+// switch is off exits 0.
+func (o *askOptions) exitCode(code int) int {
+	if fail, ok := o.failOn[code]; ok && !*fail {
+		return output.ExitOK
+	}
+	return code
+}
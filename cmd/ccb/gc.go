@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/scratch"
+)
+
+// newGCCmd builds "ccb gc", which removes expired temporary data from the
+// run dir.
+func newGCCmd() *cobra.Command {
+	var scratchOnly, dryRun bool
+	var olderThan time.Duration
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove expired per-request scratch data from the run dir",
+		Long: `Remove per-request scratch directories (temporary payloads staged on the
+way to a provider pane) not written to within --older-than. The daemon
+deletes a request's directory when the ask succeeds, keeps it after a
+failure, and sweeps expired ones as new asks arrive; 'ccb gc' does the
+same sweep on demand. The default age is CCB_SCRATCH_TTL, else 24h.`,
+		Example: `  ccb gc --scratch
+  ccb gc --scratch --older-than 1h --dry-run`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ttl := scratch.TTL()
+			if cmd.Flags().Changed("older-than") {
+				ttl = olderThan
+			}
+			root := scratch.Dir(runtime.RunDir())
+			var list []scratch.Info
+			var err error
+			if dryRun {
+				list, err = scratch.Expired(root, ttl, time.Now(), nil)
+			} else {
+				list, err = scratch.GC(root, ttl, time.Now(), nil)
+			}
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]interface{}{"dry_run": dryRun, "scratch": list})
+				return
+			}
+			var size int64
+			for _, info := range list {
+				size += info.Size
+				if dryRun || output.Enabled(output.LevelDebug) {
+					fmt.Printf("%s\t%s\n", info.ReqID, info.Path)
+				}
+			}
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			f := i18n.GetFormatter()
+			fmt.Printf("%s %d scratch dir(s), %s, older than %s.\n", verb, len(list), f.Bytes(size), f.Duration(ttl))
+		},
+	}
+	// Scratch dirs are the only kind of data collected so far, so
+	// --scratch and no selector mean the same thing.
+	cmd.Flags().BoolVar(&scratchOnly, "scratch", false, "Collect per-request scratch directories (the default, and so far the only kind)")
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "Remove only data not written to for this long (default: CCB_SCRATCH_TTL, else 24h)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List what would be removed without removing it")
+	return cmd
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// newHistoryCmd builds "ccb history", which lists past asks for the
+// current project from the daemon's history file.
+func newHistoryCmd() *cobra.Command {
+	var limit int
+	var workDir, contains string
+	var since time.Duration
+	var full bool
+	cmd := &cobra.Command{
+		Use:   "history [provider]",
+		Short: "Show past asks and replies for this project",
+		Example: `  ccb history
+  ccb history codex --limit 5
+  ccb history --grep "race" --since 24h --full`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			f := history.Filter{Contains: contains, Limit: limit}
+			if len(args) == 1 {
+				f.Provider = strings.ToLower(args[0])
+			}
+			if since > 0 {
+				f.Since = time.Now().Add(-since)
+			}
+			entries, err := history.Read(history.File(history.Dir(runtime.RunDir()), workDir), f)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				if entries == nil {
+					entries = []history.Entry{}
+				}
+				return output.PrintJSON(entries)
+			}
+			if len(entries) == 0 {
+				fmt.Println("No history for this project.")
+				return nil
+			}
+			for i, e := range entries {
+				if i > 0 {
+					fmt.Println()
+				}
+				printHistoryEntry(e, full)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Show at most this many of the newest entries (0 = all)")
+	cmd.Flags().StringVar(&contains, "grep", "", "Only entries whose prompt or reply contains this text")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only entries newer than this, e.g. 2h")
+	cmd.Flags().BoolVar(&full, "full", false, "Print whole prompts and replies instead of a preview")
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory (default: current directory)")
+	return cmd
+}
+
+// printHistoryEntry prints a header line, then the prompt and reply.
+func printHistoryEntry(e history.Entry, full bool) {
+	status := i18n.GetFormatter().Duration(time.Duration(e.DurationMs) * time.Millisecond)
+	if e.ExitCode != 0 {
+		status += ", failed: " + i18n.BidiSafe(e.Error)
+	}
+	if e.Queued {
+		status += ", queued"
+	}
+	if e.Note {
+		status = "note"
+	}
+	fmt.Printf("%s  %s  %s  (%s)\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Provider, e.ReqID, status)
+	fmt.Println(indentText("> ", historyPreview(e.Prompt, full)))
+	if e.Reply != "" {
+		fmt.Println(indentText("  ", historyPreview(e.Reply, full)))
+	}
+}
+
+// historyPreviewLines is how many lines of a prompt or reply are shown
+// without --full.
+const historyPreviewLines = 3
+
+// historyPreview trims text to its first few lines unless full is set.
+func historyPreview(text string, full bool) string {
+	text = strings.TrimSpace(text)
+	if full {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) <= historyPreviewLines {
+		return text
+	}
+	return strings.Join(lines[:historyPreviewLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-historyPreviewLines)
+}
+
+// indentText prefixes every line of text.
+func indentText(prefix, text string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
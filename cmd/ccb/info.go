@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// infoReport is the output of "ccb info": the provider's session plus its
+// last ask from the project's history.
+type infoReport struct {
+	session.ProviderInfo
+	LastReqID   string     `json:"last_req_id,omitempty"`
+	LastReplyAt *time.Time `json:"last_reply_at,omitempty"`
+}
+
+// newInfoCmd builds "ccb info", which prints everything ccb knows about a
+// provider in the current project.
+func newInfoCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:               "info <provider>",
+		Short:             "Show a provider's pane, session file, log and last ask for this project",
+		Example:           "  ccb info codex\n  ccb info gemini --dir ~/src/app --json",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := strings.ToLower(args[0])
+			if !isKnownProvider(provider) {
+				output.Errorf("unknown provider %q (known: %s)", provider, strings.Join(config.KnownProviders(), ", "))
+				os.Exit(output.ExitError)
+			}
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			backend, err := terminal.DetectBackend()
+			if err != nil {
+				backend = nil
+			}
+			reg := session.NewPaneRegistry(session.RegistryPath())
+			report := infoReport{ProviderInfo: session.Describe(reg, provider, workDir, backend)}
+			entries, _ := history.Read(history.File(history.Dir(runtime.RunDir()), workDir), history.Filter{Provider: provider, Limit: 1})
+			if len(entries) == 1 {
+				e := entries[0]
+				replyAt := e.Time.Add(time.Duration(e.DurationMs) * time.Millisecond)
+				report.LastReqID, report.LastReplyAt = e.ReqID, &replyAt
+			}
+
+			if jsonOutput {
+				output.PrintJSON(report)
+				return
+			}
+			printInfoReport(report)
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory (default: current directory)")
+	return cmd
+}
+
+// isKnownProvider reports whether name is a supported provider.
+func isKnownProvider(name string) bool {
+	for _, p := range config.KnownProviders() {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printInfoReport prints r as aligned "label: value" lines.
+func printInfoReport(r infoReport) {
+	pane := dash(r.PaneID)
+	if r.PaneID != "" {
+		pane += " (" + r.PaneSource + ", alive: " + aliveLabel(session.SessionInfo{Alive: r.Alive, Checked: r.Checked}) + ")"
+	}
+	logPath := r.LogPath
+	if logPath == "" {
+		logPath = "- (" + r.LogError + ")"
+	}
+	last := "-"
+	if r.LastReqID != "" {
+		last = r.LastReqID + " at " + r.LastReplyAt.Local().Format("2006-01-02 15:04:05")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Provider:\t%s\n", r.Provider)
+	fmt.Fprintf(w, "Work dir:\t%s\n", r.WorkDir)
+	fmt.Fprintf(w, "Project ID:\t%s\n", r.ProjectID)
+	fmt.Fprintf(w, "Pane:\t%s\n", pane)
+	if r.SessionID != "" {
+		fmt.Fprintf(w, "Session ID:\t%s\n", r.SessionID)
+	}
+	fmt.Fprintf(w, "Session file:\t%s\n", dash(r.SessionFile))
+	fmt.Fprintf(w, "Log:\t%s\n", logPath)
+	fmt.Fprintf(w, "Registered:\t%s\n", updatedAt(session.SessionInfo{UpdatedAt: r.UpdatedAt}))
+	fmt.Fprintf(w, "Last ask:\t%s\n", last)
+	w.Flush()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/integrate"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newIntegrateCmd builds "ccb integrate", which wires CCB into terminal
+// multiplexers.
+func newIntegrateCmd() *cobra.Command {
+	integrateCmd := &cobra.Command{
+		Use:   "integrate",
+		Short: "Install CCB status segments and key bindings into your terminal (tmux, WezTerm)",
+	}
+
+	var printOnly bool
+	var opts integrate.TmuxOptions
+	tmuxCmd := &cobra.Command{
+		Use:   "tmux",
+		Short: "Install a tmux status-right segment and prefix+C ask binding",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := integrate.DetectTmuxVersion()
+			if err != nil {
+				return err
+			}
+			opts.Exe = ccbExeForShell()
+			snippet := integrate.TmuxSnippet(v, opts)
+			if printOnly {
+				fmt.Print(snippet)
+				return nil
+			}
+			userConf, err := integrate.InstallTmux(snippet)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Installed %s (tmux %s)\n", integrate.TmuxConfPath(), v)
+			fmt.Printf("Sourced from %s\n", userConf)
+			return nil
+		},
+	}
+	tmuxCmd.Flags().BoolVar(&printOnly, "print", false, "Print the snippet instead of installing it")
+	tmuxCmd.Flags().StringVar(&opts.Provider, "provider", "codex", "Provider asked by the key binding")
+	tmuxCmd.Flags().StringVar(&opts.Key, "key", "C", "Key bound under the tmux prefix")
+
+	var wezOpts integrate.WeztermOptions
+	var wezPrint bool
+	weztermCmd := &cobra.Command{
+		Use:   "wezterm",
+		Short: "Write ccb.lua for wezterm.lua: an ask-selection key and a status cell",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wezOpts.Exe = ccbExeForShell()
+			snippet := integrate.WeztermSnippet(wezOpts)
+			if wezPrint {
+				fmt.Print(snippet)
+				return nil
+			}
+			path, err := integrate.InstallWezterm(snippet)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Installed %s\n", path)
+			fmt.Println("Add to wezterm.lua (before 'return config'):")
+			fmt.Println("  require('ccb').apply_to_config(config)")
+			return nil
+		},
+	}
+	weztermCmd.Flags().BoolVar(&wezPrint, "print", false, "Print the Lua module instead of installing it")
+	weztermCmd.Flags().StringVar(&wezOpts.Provider, "provider", "codex", "Provider asked by the key binding")
+	weztermCmd.Flags().StringVar(&wezOpts.Mods, "mods", "CTRL|ALT", "Modifiers for the key bindings")
+	weztermCmd.Flags().StringVar(&wezOpts.Key, "key", "c", "Key that asks about the selection")
+	weztermCmd.Flags().StringVar(&wezOpts.ToggleKey, "toggle-key", "s", "Key that shows or hides the status cell")
+
+	integrateCmd.AddCommand(tmuxCmd, weztermCmd, newWeztermAskCmd())
+	return integrateCmd
+}
+
+// newWeztermAskCmd builds the hidden "ccb integrate wezterm-ask", run by
+// ccb.lua in a split: it asks provider about the selection saved in file,
+// prints the reply and waits for Enter so the pane stays readable.
+func newWeztermAskCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "wezterm-ask <provider> <selection-file>",
+		Short:  "Ask about a saved selection (run by ccb.lua)",
+		Hidden: true,
+		Args:   cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			provider, path := args[0], args[1]
+			data, err := os.ReadFile(path)
+			os.Remove(path)
+			if err == nil {
+				fmt.Fprintf(os.Stderr, "Asking %s...\n", provider)
+				var result *client.AskResult
+				result, err = client.Ask(client.AskRequest{Provider: provider, Message: string(data), TimeoutS: 300})
+				if err == nil {
+					if result.Error != "" && result.ExitCode != 0 {
+						output.Errorf("%s", result.Error)
+					}
+					fmt.Println(result.Reply)
+				}
+			}
+			if err != nil {
+				output.Errorf("%s", err)
+			}
+			fmt.Print("\n[press enter]")
+			bufio.NewReader(os.Stdin).ReadString('\n')
+		},
+	}
+}
+
+// ccbExeForShell returns "ccb" when it resolves on PATH, otherwise the
+// absolute path of the running binary.
+func ccbExeForShell() string {
+	if _, err := exec.LookPath("ccb"); err == nil {
+		return "ccb"
+	}
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "ccb"
+}
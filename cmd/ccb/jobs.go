@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// jobPollInterval is how often "ccb jobs result --wait" asks the daemon.
+const jobPollInterval = 2 * time.Second
+
+// newJobsCmd builds "ccb jobs", which lists the asks submitted with
+// "ccb ask --async", with "status" and "result" subcommands to fetch one.
+func newJobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List background asks (ccb ask --async) and fetch their results",
+		Example: `  ccb ask --async codex "refactor the parser"
+  ccb jobs
+  ccb jobs status 20260125-143000-123-12345
+  ccb jobs result --wait 20260125-143000-123-12345`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			jobs, err := client.ListJobs()
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(jobs)
+				return
+			}
+			if len(jobs) == 0 {
+				fmt.Println("No jobs.")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "JOB_ID\tPROVIDER\tSTATE\tELAPSED\tEXIT")
+			for _, j := range jobs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", j.JobID, j.Provider, j.State, jobElapsed(j), jobExit(j))
+			}
+			w.Flush()
+		},
+	}
+	cmd.AddCommand(newJobsStatusCmd(), newJobsResultCmd())
+	return cmd
+}
+
+// newJobsStatusCmd builds "ccb jobs status".
+func newJobsStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <job_id>",
+		Short: "Show a background ask's state",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			j, err := client.JobStatus(args[0])
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(j)
+				return
+			}
+			fmt.Printf("%s: %s, %s (%s)\n", j.JobID, j.State, jobElapsed(*j), j.Provider)
+			if j.State == "done" {
+				fmt.Printf("exit %s", jobExit(*j))
+				if j.Error != "" {
+					fmt.Printf(": %s", j.Error)
+				}
+				fmt.Println()
+			}
+		},
+	}
+}
+
+// newJobsResultCmd builds "ccb jobs result", which prints a finished
+// job's reply and exits with its exit code.
+func newJobsResultCmd() *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "result <job_id>",
+		Short: "Print a background ask's reply (exit 2 while it is still running)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+			for {
+				res, err := client.JobResult(args[0])
+				if err != nil {
+					output.Errorf("%s", err)
+					os.Exit(output.ExitError)
+				}
+				if res.Result != nil {
+					printJobResult(res)
+					return
+				}
+				if !wait || (!deadline.IsZero() && time.Now().After(deadline)) {
+					if jsonOutput {
+						output.PrintJSON(res)
+					} else {
+						output.Errorf("job %s is %s", res.Job.JobID, res.Job.State)
+					}
+					os.Exit(output.ExitNoReply)
+				}
+				time.Sleep(jobPollInterval)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for the job to finish")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "With --wait, give up after this long (default: no limit)")
+	return cmd
+}
+
+// printJobResult prints a done job's reply like "ccb ask" and exits with
+// its exit code.
+func printJobResult(res *protocol.JobResultResponse) {
+	r := res.Result
+	if jsonOutput {
+		output.PrintJSON(res)
+		os.Exit(r.ExitCode)
+	}
+	if r.Error != "" && r.ExitCode != 0 {
+		output.Errorf("%s", r.Error)
+	}
+	if r.OutputPath != "" {
+		fmt.Fprintf(os.Stderr, "[reply written to %s]\n", r.OutputPath)
+	} else if r.Reply != "" {
+		fmt.Println(r.Reply)
+	}
+	os.Exit(r.ExitCode)
+}
+
+// jobElapsed formats a job's running time.
+func jobElapsed(j protocol.JobInfo) string {
+	return i18n.GetFormatter().Duration(time.Duration(j.ElapsedS * float64(time.Second)))
+}
+
+// jobExit formats a job's exit code, "-" until it is done.
+func jobExit(j protocol.JobInfo) string {
+	if j.State != "done" {
+		return "-"
+	}
+	return fmt.Sprint(j.ExitCode)
+}
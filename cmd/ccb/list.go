@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/launcher"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newListCmd builds "ccb list", which shows every registered provider
+// pane and whether it is still alive.
+func newListCmd() *cobra.Command {
+	var aliveOnly bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List registered provider panes and sessions with their liveness",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, err := terminal.DetectBackend()
+			if err != nil {
+				backend = nil
+			}
+			cwd, _ := os.Getwd()
+			reg := session.NewPaneRegistry(session.RegistryPath())
+			list := session.ListSessions(reg, []string{cwd}, launcher.Providers(), backend)
+			if aliveOnly {
+				live := list[:0]
+				for _, s := range list {
+					if s.Alive {
+						live = append(live, s)
+					}
+				}
+				list = live
+			}
+
+			if jsonOutput {
+				return output.PrintJSON(list)
+			}
+			if len(list) == 0 {
+				fmt.Println("No registered sessions.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PROVIDER\tPROJECT\tPANE\tWORKDIR\tUPDATED\tALIVE")
+			for _, s := range list {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					s.Provider, shortID(s.ProjectID), dash(s.PaneID), dash(s.WorkDir), updatedAt(s), aliveLabel(s))
+			}
+			w.Flush()
+			if backend == nil {
+				fmt.Fprintln(os.Stderr, "note: no terminal backend detected; liveness not verified")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&aliveOnly, "alive", false, "Only show panes that are alive")
+	return cmd
+}
+
+// shortID abbreviates a project hash for display.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return dash(id)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func updatedAt(s session.SessionInfo) string {
+	if s.UpdatedAt.IsZero() {
+		return "-"
+	}
+	return s.UpdatedAt.Local().Format("2006-01-02 15:04")
+}
+
+func aliveLabel(s session.SessionInfo) string {
+	switch {
+	case !s.Checked:
+		return "?"
+	case s.Alive:
+		return "yes"
+	default:
+		return "no"
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+)
+
+// logsPollInterval is how often "ccb logs -f" checks for new lines.
+const logsPollInterval = 250 * time.Millisecond
+
+// newLogsCmd builds "ccb logs", which tails the session log a provider is
+// writing for the current project.
+func newLogsCmd() *cobra.Command {
+	var lines int
+	var follow, raw bool
+	cmd := &cobra.Command{
+		Use:               "logs <provider>",
+		Short:             "Show the tail of a provider's session log (-f to follow)",
+		Example:           "  ccb logs codex\n  ccb logs claude -f -n 100",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, _ := os.Getwd()
+			path, err := session.ResolveLogFile(args[0], cwd)
+			if err != nil {
+				return err
+			}
+			return tailLog(path, lines, follow, raw)
+		},
+	}
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "Number of lines to show")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing lines as they are written")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Keep ANSI escape sequences")
+	return cmd
+}
+
+// tailLog prints the last lines of the log at path and, with follow,
+// keeps printing lines as they are written. ANSI escapes are stripped
+// unless raw is set.
+func tailLog(path string, lines int, follow, raw bool) error {
+	fmt.Fprintf(os.Stderr, "==> %s <==\n", path)
+
+	emit := func(ls []string) {
+		for _, l := range ls {
+			if !raw {
+				l = comm.StripANSI(l)
+			}
+			fmt.Println(l)
+		}
+	}
+
+	reader := comm.NewLogReader(path)
+	tail, err := reader.ReadTail(lines)
+	if err != nil {
+		return err
+	}
+	emit(tail)
+	if !follow {
+		return nil
+	}
+	if err := reader.SeekEnd(); err != nil {
+		return err
+	}
+	for {
+		time.Sleep(logsPollInterval)
+		ls, err := reader.ReadNew()
+		if err != nil {
+			return err
+		}
+		emit(ls)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/anthropics/claude_code_bridge/internal/client"
 	"github.com/anthropics/claude_code_bridge/internal/daemon"
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
 	"github.com/anthropics/claude_code_bridge/internal/launcher"
 	"github.com/anthropics/claude_code_bridge/internal/output"
 	"github.com/anthropics/claude_code_bridge/internal/protocol"
@@ -16,25 +17,35 @@ import (
 
 var version = "dev"
 
+// jsonOutput is the global --json flag: commands emit structured JSON on
+// stdout instead of text.
+var jsonOutput bool
+
 // knownSubcommands lists all cobra subcommands so we can distinguish
 // "ccb codex,claude" (provider launch) from "ccb daemon start" (subcommand).
 var knownSubcommands = map[string]bool{
-	"ask": true, "ping": true, "pend": true, "daemon": true,
-	"help": true, "completion": true,
-	"cask": true, "gask": true, "oask": true, "dask": true, "lask": true,
-	"cping": true, "gping": true, "oping": true, "dping": true, "lping": true,
-	"cpend": true, "gpend": true, "opend": true, "dpend": true, "lpend": true,
+	"ask": true, "askf": true, "compare": true, "ping": true, "pend": true, "daemon": true,
+	"help": true, "completion": true, cobra.ShellCompRequestCmd: true, cobra.ShellCompNoDescRequestCmd: true,
+	"statusline": true, "integrate": true, "copy": true, "reply-diff": true, "relay": true, "chat": true, "list": true, "state": true, "stop": true, "restart": true, "doctor": true, "logs": true, "history": true, "bind": true, "unbind": true, "requests": true, "templates": true, "tmux-plugin": true, "replay-io": true, "config": true, "share": true, "attach": true, "pause": true, "resume": true, "info": true, "note": true, "gc": true, "orchestrate": true, "mcp": true, "cancel": true, "jobs": true,
+	"cask": true, "gask": true, "oask": true, "dask": true, "lask": true, "aask": true, "qask": true,
+	"cping": true, "gping": true, "oping": true, "dping": true, "lping": true, "aping": true, "qping": true,
+	"cpend": true, "gpend": true, "opend": true, "dpend": true, "lpend": true, "apend": true, "qpend": true,
 }
 
 func main() {
-	// Pre-cobra interception: if the first non-flag arg is NOT a known
-	// subcommand, treat it as a provider launch (e.g. "ccb -a codex,claude").
-	if shouldRunLauncher(os.Args[1:]) {
-		runLauncher(os.Args[1:])
+	// Pre-cobra interception: after alias expansion, if the first non-flag
+	// arg is NOT a known subcommand, treat it as a provider launch
+	// (e.g. "ccb -a codex,claude").
+	aliases := userAliases()
+	args := expandAlias(os.Args[1:], aliases)
+	if shouldRunLauncher(args) {
+		runLauncher(args)
 		return
 	}
 
 	rootCmd := buildRootCmd()
+	addAliasCmds(rootCmd, aliases)
+	rootCmd.SetArgs(args)
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -43,10 +54,15 @@ func main() {
 // shouldRunLauncher checks if the CLI args look like a provider launch
 // rather than a subcommand invocation.
 func shouldRunLauncher(args []string) bool {
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		if arg == "--help" || arg == "-h" || arg == "--version" || arg == "-v" {
 			return false
 		}
+		if arg == "--log-level" {
+			i++ // its value is not a provider
+			continue
+		}
 		// Skip flags
 		if strings.HasPrefix(arg, "-") {
 			continue
@@ -63,7 +79,16 @@ func runLauncher(args []string) {
 	resume := false
 	var providerArgs []string
 
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if name, skip, ok := logLevelArg(args, i); ok {
+			if err := applyLogLevel(name); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			i += skip
+			continue
+		}
 		switch arg {
 		case "-a", "--auto":
 			auto = true
@@ -79,13 +104,13 @@ func runLauncher(args []string) {
 	}
 
 	if len(providerArgs) == 0 {
-		fmt.Fprintln(os.Stderr, "no providers specified. Available: codex, gemini, opencode, claude, droid")
+		fmt.Fprintln(os.Stderr, "no providers specified. Available: "+strings.Join(launcher.Providers(), ", "))
 		os.Exit(1)
 	}
 
 	providers := launcher.ParseProviders(providerArgs)
 	if len(providers) == 0 {
-		fmt.Fprintln(os.Stderr, "no valid providers specified. Available: codex, gemini, opencode, claude, droid")
+		fmt.Fprintln(os.Stderr, "no valid providers specified. Available: "+strings.Join(launcher.Providers(), ", "))
 		os.Exit(1)
 	}
 
@@ -113,15 +138,70 @@ func runLauncher(args []string) {
 		fmt.Fprintln(os.Stderr, "failed to start any provider")
 		os.Exit(1)
 	}
+	registerLaunched(results, cwd)
 
-	fmt.Printf("\n%d/%d providers started", ok, len(providers))
+	summary := fmt.Sprintf("\n%d/%d providers started", ok, len(providers))
 	if resume {
-		fmt.Printf(" (resume mode)")
+		summary += " (resume mode)"
 	}
 	if auto {
-		fmt.Printf(" (auto-approve mode)")
+		summary += " (auto-approve mode)"
+	}
+	output.Infof("%s", summary)
+}
+
+// registerLaunched asks a running daemon to serve the providers just
+// launched in workDir, in case it started without them; there is nothing
+// to do when no daemon is running.
+func registerLaunched(results []launcher.LaunchResult, workDir string) {
+	state, err := client.ReadState("")
+	if err != nil || client.PingDaemon(state) != nil {
+		return
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			continue
+		}
+		if resp, err := client.RegisterProvider(r.Provider, workDir); err != nil {
+			output.Debugf("daemon: register %s: %v", r.Provider, err)
+		} else if resp.Changed {
+			output.Infof("daemon now serves %s", r.Provider)
+		}
+	}
+}
+
+// logLevelArg recognizes the logging flags at args[i] for the launcher,
+// which bypasses cobra. It returns the level name and how many following
+// args the flag consumed.
+func logLevelArg(args []string, i int) (name string, skip int, ok bool) {
+	switch arg := args[i]; {
+	case arg == "--quiet":
+		return "error", 0, true
+	case arg == "--verbose":
+		return "debug", 0, true
+	case strings.HasPrefix(arg, "--log-level="):
+		return strings.TrimPrefix(arg, "--log-level="), 0, true
+	case arg == "--log-level":
+		if i+1 < len(args) {
+			return args[i+1], 1, true
+		}
+		return "", 0, true
+	}
+	return "", 0, false
+}
+
+// applyLogLevel sets the process log level from a level name (empty keeps
+// the default) and exports it so an auto-started daemon inherits it.
+func applyLogLevel(name string) error {
+	if name == "" {
+		return nil
+	}
+	level, err := output.ParseLevel(name)
+	if err != nil {
+		return err
 	}
-	fmt.Println()
+	output.SetLevel(level)
+	return os.Setenv(output.LogLevelEnv, level.String())
 }
 
 func buildRootCmd() *cobra.Command {
@@ -137,8 +217,25 @@ Launch multiple AI providers simultaneously:
   ccb -a -r codex,claude        Resume with auto-approve mode
   ccb codex gemini              Space-separated is also supported
 
-Available providers: codex, gemini, opencode, claude, droid`,
-		Version: version,
+Available providers: ` + strings.Join(launcher.Providers(), ", "),
+		Version:           version,
+		ValidArgsFunction: completeProviders,
+	}
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Emit structured JSON instead of text (ask, ping, pend, daemon status, ...)")
+	var logLevel string
+	var quietLog, verboseLog bool
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log level: debug, info, warn or error (default info, or $CCB_LOG_LEVEL)")
+	rootCmd.PersistentFlags().BoolVar(&quietLog, "quiet", false, "Only print errors (--log-level error)")
+	rootCmd.PersistentFlags().BoolVar(&verboseLog, "verbose", false, "Print debug diagnostics (--log-level debug)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Subcommands with their own --quiet or --verbose shadow these.
+		name := logLevel
+		if f := cmd.Root().PersistentFlags(); f.Changed("quiet") && quietLog {
+			name = "error"
+		} else if f.Changed("verbose") && verboseLog {
+			name = "debug"
+		}
+		return applyLogLevel(name)
 	}
 
 	// --- daemon subcommand ---
@@ -147,13 +244,26 @@ Available providers: codex, gemini, opencode, claude, droid`,
 		Short: "Manage the CCB daemon",
 	}
 
+	var daemonOpts daemon.RunOptions
 	daemonStartCmd := &cobra.Command{
-		Use:   "start",
-		Short: "Start the daemon",
+		Use:     "start",
+		Short:   "Start the daemon",
+		Example: "  ccb daemon start\n  ccb daemon start --foreground --verbose",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return daemon.RunDefault()
+			return daemon.RunWithOptions(daemonOpts)
 		},
 	}
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.Foreground, "foreground", false, "Also log to stderr and never shut down for idleness (Ctrl+C stops it)")
+	daemonStartCmd.Flags().BoolVarP(&daemonOpts.Verbose, "verbose", "v", false, "Log every RPC: method, provider, req_id, outcome and timings")
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.Pipe, "pipe", false, "Windows: listen on a named pipe (\\\\.\\pipe\\ccb-<user>, or CCB_PIPE_NAME) instead of localhost TCP; also CCB_TRANSPORT=pipe")
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.Unix, "unix", false, "Linux/macOS: listen on a unix socket (<run dir>/askd.sock, or CCB_SOCKET_PATH) instead of localhost TCP; enables CCB_AUTH=peercred; also CCB_TRANSPORT=unix")
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.TLS, "tls", false, "Serve TLS with a self-generated CA and require client certificates (see 'ccb daemon cert'); also CCB_ASKD_TLS=1")
+	daemonStartCmd.Flags().StringVar(&daemonOpts.HTTP, "http", "", "Also serve the HTTP+JSON gateway (/ask, /ping, /pend, /status) on ADDR, e.g. 127.0.0.1:8765; also CCB_ASKD_HTTP")
+	daemonStartCmd.Flags().StringVar(&daemonOpts.GRPC, "grpc", "", "Also serve the gRPC service (Ask, Ping, Pend, Status, Cancel; see internal/bridgepb/bridge.proto) on ADDR, e.g. 127.0.0.1:8766; also CCB_ASKD_GRPC")
+	daemonStartCmd.Flags().StringVar(&daemonOpts.Metrics, "metrics", "", "Also serve Prometheus metrics on http://ADDR/metrics without a token, e.g. 127.0.0.1:9464 (the HTTP gateway serves them with one); also CCB_ASKD_METRICS")
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.Audit, "audit", false, "Append every ask (caller, provider, work dir, prompt hash, exit code) to <run dir>/audit.jsonl; also CCB_AUDIT=1")
+	daemonStartCmd.Flags().BoolVar(&daemonOpts.AuditFull, "audit-prompts", false, "Like --audit, but record full prompts instead of their SHA-256; also CCB_AUDIT_PROMPTS=1")
+	daemonStartCmd.Flags().IntVar(&daemonOpts.ParentPID, "parent-pid", 0, "Shut down once process PID exits (e.g. the editor or script that owns this daemon); also CCB_PARENT_PID")
 
 	daemonStopCmd := &cobra.Command{
 		Use:   "stop",
@@ -182,8 +292,44 @@ Available providers: codex, gemini, opencode, claude, droid`,
 				}
 				return err
 			}
+			if jsonOutput {
+				delete(status, "status")
+				status["host"] = state.Host
+				status["port"] = state.Port
+				if state.Pipe != "" {
+					status["pipe"] = state.Pipe
+				}
+				if state.Socket != "" {
+					status["socket"] = state.Socket
+				}
+				status["tls"] = state.TLS
+				if state.HTTP != "" {
+					status["http"] = state.HTTP
+				}
+				if state.GRPC != "" {
+					status["grpc"] = state.GRPC
+				}
+				if state.Metrics != "" {
+					status["metrics"] = state.Metrics
+				}
+				status["pid"] = state.PID
+				return output.PrintJSON(status)
+			}
 			fmt.Printf("PID:       %d\n", state.PID)
-			fmt.Printf("Address:   %s:%d\n", state.Host, state.Port)
+			if state.TLS {
+				fmt.Printf("Address:   %s (TLS)\n", state.Address())
+			} else {
+				fmt.Printf("Address:   %s\n", state.Address())
+			}
+			if state.HTTP != "" {
+				fmt.Printf("HTTP:      %s\n", state.HTTP)
+			}
+			if state.GRPC != "" {
+				fmt.Printf("gRPC:      %s\n", state.GRPC)
+			}
+			if state.Metrics != "" {
+				fmt.Printf("Metrics:   http://%s/metrics\n", state.Metrics)
+			}
 			if providers, ok := status["providers"].([]interface{}); ok {
 				names := make([]string, 0, len(providers))
 				for _, p := range providers {
@@ -196,93 +342,73 @@ Available providers: codex, gemini, opencode, claude, droid`,
 			if workers, ok := status["workers"].(float64); ok {
 				fmt.Printf("Workers:   %d\n", int(workers))
 			}
+			if projects, ok := status["projects"].(float64); ok {
+				fmt.Printf("Projects:  %d\n", int(projects))
+			}
+			active, _ := status["active_requests"].(float64)
+			queued, _ := status["queued"].(float64)
+			f := i18n.GetFormatter()
+			fmt.Printf("Requests:  %s active, %s queued\n", f.Count(int64(active)), f.Count(int64(queued)))
+			printStorageChecks(status["storage"])
+			printPaused(status["paused"])
 			return nil
 		},
 	}
 
 	daemonCmd.AddCommand(daemonStartCmd, daemonStopCmd, daemonStatusCmd)
+	daemonCmd.AddCommand(newDaemonPairCmds()...)
+	daemonCmd.AddCommand(newDaemonCtlCmds()...)
 
 	// --- ask subcommand ---
-	var askTimeout float64
-	var askQuiet bool
-
+	askOpts := &askOptions{}
 	askCmd := &cobra.Command{
-		Use:   "ask <provider> <message...>",
-		Short: "Send a message to an AI provider",
-		Args:  cobra.MinimumNArgs(2),
+		Use:               "ask <provider> [message...]",
+		Short:             "Send a message to an AI provider",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeProviders,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider := args[0]
-			message := strings.Join(args[1:], " ")
-
-			// Read from stdin if message is "-"
-			if message == "-" {
-				data, err := os.ReadFile("/dev/stdin")
-				if err != nil {
-					return fmt.Errorf("failed to read stdin: %w", err)
-				}
-				message = output.DecodeStdinBytes(data)
-			}
-
-			result, err := client.Ask(client.AskRequest{
-				Provider: provider,
-				Message:  message,
-				TimeoutS: askTimeout,
-				Quiet:    askQuiet,
-			})
-			if err != nil {
-				return err
-			}
-
-			if result.Error != "" && result.ExitCode != 0 {
-				output.Errorf("%s", result.Error)
-			}
-			if result.Reply != "" {
-				fmt.Println(result.Reply)
-			}
-			os.Exit(result.ExitCode)
-			return nil
+			return runAsk(cmd, args[0], args[1:], askOpts)
 		},
 	}
-	askCmd.Flags().Float64VarP(&askTimeout, "timeout", "t", 120, "Timeout in seconds")
-	askCmd.Flags().BoolVarP(&askQuiet, "quiet", "q", false, "Suppress progress output")
+	addAskFlags(askCmd, askOpts)
 
 	// --- ping subcommand ---
 	pingCmd := &cobra.Command{
-		Use:   "ping <provider>",
-		Short: "Test connectivity with an AI provider",
-		Args:  cobra.ExactArgs(1),
+		Use:               "ping <provider>",
+		Short:             "Test connectivity with an AI provider",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider := args[0]
-			if err := client.Ping(provider); err != nil {
-				fmt.Printf("%s: offline (%s)\n", provider, err)
-				os.Exit(1)
-			}
-			fmt.Printf("%s: online\n", provider)
-			return nil
+			return runPing(args[0])
 		},
 	}
 
 	// --- pend subcommand ---
+	var pendN int
+	var pendReq string
 	pendCmd := &cobra.Command{
-		Use:   "pend <provider>",
+		Use:   "pend <provider> | --req <req_id>",
 		Short: "View latest reply from an AI provider",
-		Args:  cobra.ExactArgs(1),
+		Example: `  ccb pend codex
+  ccb pend codex -n 2
+  ccb pend --req 20260125-143000-123-12345`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProviders,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider := args[0]
-			reply, err := client.Pend(provider)
-			if err != nil {
-				return err
+			if pendReq != "" {
+				if len(args) > 0 || cmd.Flags().Changed("n") {
+					return fmt.Errorf("--req takes no provider and no -n")
+				}
+				return runPendReq(pendReq)
 			}
-			if reply == "" {
-				fmt.Println("(no reply)")
-				os.Exit(output.ExitNoReply)
+			if len(args) != 1 {
+				return fmt.Errorf("pend needs a provider or --req <req_id>")
 			}
-			// Strip trailing markers for clean display
-			reply = protocol.StripTrailingMarkers(reply)
-			fmt.Println(reply)
-			return nil
+			return runPend(args[0], pendN)
 		},
 	}
+	pendCmd.Flags().IntVarP(&pendN, "n", "n", 1, "Show the Nth most recent reply (1 = latest; older ones come from the daemon's reply store)")
+	pendCmd.Flags().StringVar(&pendReq, "req", "", "Show the reply to this req_id")
 
 	// --- Provider shortcut commands ---
 	providerShortcuts := map[string]string{
@@ -291,46 +417,21 @@ Available providers: codex, gemini, opencode, claude, droid`,
 		"oask": "opencode",
 		"dask": "droid",
 		"lask": "claude",
+		"aask": "aider",
+		"qask": "qwen",
 	}
 
 	for shortcut, provider := range providerShortcuts {
 		p := provider // capture
+		opts := &askOptions{}
 		shortcutCmd := &cobra.Command{
-			Use:   shortcut + " <message...>",
+			Use:   shortcut + " [message...]",
 			Short: fmt.Sprintf("Send a message to %s (shortcut for 'ask %s')", p, p),
-			Args:  cobra.MinimumNArgs(1),
 			RunE: func(cmd *cobra.Command, args []string) error {
-				message := strings.Join(args, " ")
-				if message == "-" {
-					data, err := os.ReadFile("/dev/stdin")
-					if err != nil {
-						return fmt.Errorf("failed to read stdin: %w", err)
-					}
-					message = output.DecodeStdinBytes(data)
-				}
-
-				result, err := client.Ask(client.AskRequest{
-					Provider: p,
-					Message:  message,
-					TimeoutS: askTimeout,
-					Quiet:    askQuiet,
-				})
-				if err != nil {
-					return err
-				}
-
-				if result.Error != "" && result.ExitCode != 0 {
-					output.Errorf("%s", result.Error)
-				}
-				if result.Reply != "" {
-					fmt.Println(result.Reply)
-				}
-				os.Exit(result.ExitCode)
-				return nil
+				return runAsk(cmd, p, args, opts)
 			},
 		}
-		shortcutCmd.Flags().Float64VarP(&askTimeout, "timeout", "t", 120, "Timeout in seconds")
-		shortcutCmd.Flags().BoolVarP(&askQuiet, "quiet", "q", false, "Suppress progress output")
+		addAskFlags(shortcutCmd, opts)
 		rootCmd.AddCommand(shortcutCmd)
 	}
 
@@ -341,12 +442,7 @@ Available providers: codex, gemini, opencode, claude, droid`,
 			Use:   shortcut[:1] + "ping",
 			Short: fmt.Sprintf("Ping %s (shortcut for 'ping %s')", p, p),
 			RunE: func(cmd *cobra.Command, args []string) error {
-				if err := client.Ping(p); err != nil {
-					fmt.Printf("%s: offline (%s)\n", p, err)
-					os.Exit(1)
-				}
-				fmt.Printf("%s: online\n", p)
-				return nil
+				return runPing(p)
 			},
 		}
 		rootCmd.AddCommand(pingShortcut)
@@ -355,27 +451,127 @@ Available providers: codex, gemini, opencode, claude, droid`,
 	// --- Provider pend shortcuts ---
 	for shortcut, provider := range providerShortcuts {
 		p := provider
+		n := new(int)
 		pendShortcut := &cobra.Command{
 			Use:   shortcut[:1] + "pend",
 			Short: fmt.Sprintf("View latest reply from %s", p),
 			RunE: func(cmd *cobra.Command, args []string) error {
-				reply, err := client.Pend(p)
-				if err != nil {
-					return err
-				}
-				if reply == "" {
-					fmt.Println("(no reply)")
-					os.Exit(output.ExitNoReply)
-				}
-				reply = protocol.StripTrailingMarkers(reply)
-				fmt.Println(reply)
-				return nil
+				return runPend(p, *n)
 			},
 		}
+		pendShortcut.Flags().IntVarP(n, "n", "n", 1, "Show the Nth most recent reply (1 = latest)")
 		rootCmd.AddCommand(pendShortcut)
 	}
 
 	rootCmd.AddCommand(daemonCmd, askCmd, pingCmd, pendCmd)
+	rootCmd.AddCommand(newStatuslineCmd(), newIntegrateCmd(), newCopyCmd(), newReplyDiffCmd(), newAskfCmd(), newCompareCmd(), newRelayCmd(), newChatCmd(), newListCmd(), newStateCmd(), newStopCmd(), newRestartCmd(), newDoctorCmd(), newLogsCmd(), newHistoryCmd(), newBindCmd(), newUnbindCmd(), newRequestsCmd(), newTemplatesCmd(), newTmuxPluginCmd(), newReplayIOCmd(), newConfigCmd(), newShareCmd(), newAttachCmd(), newPauseCmd(), newResumeCmd(), newInfoCmd(), newNoteCmd(), newGCCmd(), newOrchestrateCmd(), newMCPCmd(), newCancelCmd(), newJobsCmd())
 
 	return rootCmd
 }
+
+// runPing reports whether provider is reachable, exiting 1 when it isn't.
+func runPing(provider string) error {
+	err := client.Ping(provider)
+	if jsonOutput {
+		resp := map[string]interface{}{"provider": provider, "online": err == nil}
+		if err != nil {
+			resp["error"] = err.Error()
+		}
+		output.PrintJSON(resp)
+	} else if err != nil {
+		fmt.Printf("%s: offline (%s)\n", provider, err)
+	} else {
+		fmt.Printf("%s: online\n", provider)
+	}
+	if err != nil {
+		os.Exit(output.ExitError)
+	}
+	return nil
+}
+
+// runPend prints provider's nth most recent reply, exiting ExitNoReply
+// when there is none.
+func runPend(provider string, n int) error {
+	if n < 1 {
+		return fmt.Errorf("-n must be at least 1")
+	}
+	reply, err := client.PendN(provider, n)
+	if err != nil {
+		return err
+	}
+	// Strip trailing markers for clean display
+	reply = protocol.StripTrailingMarkers(reply)
+	exitCode := output.ExitOK
+	if reply == "" {
+		exitCode = output.ExitNoReply
+	}
+	switch {
+	case jsonOutput:
+		output.PrintJSON(map[string]interface{}{"provider": provider, "reply": reply, "exit_code": exitCode})
+	case reply == "":
+		fmt.Println("(no reply)")
+	default:
+		fmt.Println(reply)
+	}
+	if exitCode != output.ExitOK {
+		os.Exit(exitCode)
+	}
+	return nil
+}
+
+// runPendReq prints the reply to reqID.
+func runPendReq(reqID string) error {
+	reply, provider, err := client.PendReq(reqID)
+	if err != nil {
+		return err
+	}
+	reply = protocol.StripTrailingMarkers(reply)
+	exitCode := output.ExitOK
+	if reply == "" {
+		exitCode = output.ExitNoReply
+	}
+	switch {
+	case jsonOutput:
+		output.PrintJSON(map[string]interface{}{"provider": provider, "req_id": reqID, "reply": reply, "exit_code": exitCode})
+	case reply == "":
+		fmt.Println("(no reply)")
+	default:
+		fmt.Println(reply)
+	}
+	if exitCode != output.ExitOK {
+		os.Exit(exitCode)
+	}
+	return nil
+}
+
+// printStorageChecks prints the daemon's startup storage preflight from a
+// status response: one line when all is well, else one line per problem.
+func printStorageChecks(v interface{}) {
+	checks, _ := v.([]interface{})
+	if len(checks) == 0 {
+		return
+	}
+	var problems []string
+	for _, c := range checks {
+		m, _ := c.(map[string]interface{})
+		if ok, _ := m["ok"].(bool); ok {
+			continue
+		}
+		provider, _ := m["provider"].(string)
+		path, _ := m["path"].(string)
+		problem, _ := m["problem"].(string)
+		hint, _ := m["hint"].(string)
+		problems = append(problems, fmt.Sprintf("%s: %s %s (%s)", provider, path, problem, hint))
+	}
+	if len(problems) == 0 {
+		fmt.Println("Storage:   ok")
+		return
+	}
+	for i, p := range problems {
+		label := "Storage:  "
+		if i > 0 {
+			label = "          "
+		}
+		fmt.Printf("%s %s\n", label, p)
+	}
+}
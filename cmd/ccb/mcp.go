@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/mcp"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// newMCPCmd builds "ccb mcp", a Model Context Protocol server on stdio
+// whose tools ask providers through the daemon.
+func newMCPCmd() *cobra.Command {
+	var providers string
+	var timeout float64
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve providers as MCP tools over stdio (ask_<provider>, ping, pend)",
+		Long: `Run a Model Context Protocol server on stdin/stdout so an agent can call
+other providers as native tools: ask_<provider> for each provider, plus
+ping and pend. Asks go through the daemon (started if needed) and run
+concurrently. Logs go to stderr; stdout carries only the protocol.`,
+		Example: `  claude mcp add ccb -- ccb mcp
+  ccb mcp --providers codex,gemini --timeout 600`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := config.KnownProviders()
+			if providers != "" {
+				names = client.SplitProviders(providers)
+			}
+			s := &mcp.Server{Name: "ccb", Version: version, Tools: mcpTools(names, timeout)}
+			return s.Serve(os.Stdin, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVar(&providers, "providers", "", "Comma-separated providers to expose as ask_<provider> tools (default: all known)")
+	cmd.Flags().Float64VarP(&timeout, "timeout", "t", 0, "Default ask timeout in seconds (default: the provider's ccb.config timeout)")
+	return cmd
+}
+
+// mcpTools builds the tool set for providers.
+func mcpTools(providers []string, timeout float64) []mcp.Tool {
+	providerArg := map[string]interface{}{"type": "string", "enum": providers, "description": "Provider name"}
+	tools := make([]mcp.Tool, 0, len(providers)+2)
+	for _, p := range providers {
+		provider := p
+		tools = append(tools, mcp.Tool{
+			Name:        "ask_" + provider,
+			Description: fmt.Sprintf("Ask %s (running in its own terminal pane) and wait for its reply.", provider),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"message":   map[string]interface{}{"type": "string", "description": "The prompt to send"},
+					"timeout_s": map[string]interface{}{"type": "number", "description": "Reply timeout in seconds"},
+					"work_dir":  map[string]interface{}{"type": "string", "description": "Project directory (default: the caller's)"},
+				},
+				"required": []string{"message"},
+			},
+			Call: func(args map[string]interface{}) (string, error) {
+				return mcpAsk(provider, args, timeout)
+			},
+		})
+	}
+	tools = append(tools,
+		mcp.Tool{
+			Name:        "ping",
+			Description: "Check whether a provider's session is reachable.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"provider": providerArg},
+				"required":   []string{"provider"},
+			},
+			Call: func(args map[string]interface{}) (string, error) {
+				provider, err := mcp.StringArg(args, "provider", true)
+				if err != nil {
+					return "", err
+				}
+				if err := client.Ping(provider); err != nil {
+					return "", fmt.Errorf("%s: offline (%s)", provider, err)
+				}
+				return provider + ": online", nil
+			},
+		},
+		mcp.Tool{
+			Name:        "pend",
+			Description: "Fetch a provider's latest (or nth most recent) reply, or the reply to a req_id.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"provider": providerArg,
+					"n":        map[string]interface{}{"type": "integer", "minimum": 1, "description": "1 for the latest reply, 2 for the one before, ..."},
+					"req_id":   map[string]interface{}{"type": "string", "description": "Request id from an earlier ask"},
+				},
+			},
+			Call: mcpPend,
+		},
+	)
+	return tools
+}
+
+// mcpAsk runs an ask_<provider> call; a failed ask is a tool error.
+func mcpAsk(provider string, args map[string]interface{}, timeout float64) (string, error) {
+	message, err := mcp.StringArg(args, "message", true)
+	if err != nil {
+		return "", err
+	}
+	workDir, _ := mcp.StringArg(args, "work_dir", false)
+	if t := mcp.NumberArg(args, "timeout_s"); t > 0 {
+		timeout = t
+	}
+	result, err := client.Ask(client.AskRequest{Provider: provider, Message: message, WorkDir: workDir, TimeoutS: timeout, Quiet: true})
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("%s ask failed (exit %d, req_id %s): %s", provider, result.ExitCode, result.ReqID, result.Error)
+	}
+	return result.Reply, nil
+}
+
+// mcpPend runs a pend call.
+func mcpPend(args map[string]interface{}) (string, error) {
+	reqID, _ := mcp.StringArg(args, "req_id", false)
+	if reqID != "" {
+		reply, _, err := client.PendReq(reqID)
+		return reply, err
+	}
+	provider, err := mcp.StringArg(args, "provider", true)
+	if err != nil {
+		return "", fmt.Errorf("pend needs a provider or a req_id")
+	}
+	n := int(mcp.NumberArg(args, "n"))
+	if n < 1 {
+		n = 1
+	}
+	reply, err := client.PendN(provider, n)
+	if err != nil {
+		return "", err
+	}
+	if reply = strings.TrimSpace(protocol.StripTrailingMarkers(reply)); reply == "" {
+		return "(no reply)", nil
+	}
+	return reply, nil
+}
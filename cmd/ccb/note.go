@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newNoteCmd builds "ccb note", which types an annotation into a
+// provider's pane without waiting for a reply, so long-lived sessions
+// stay oriented. The note is recorded in the project's ask history.
+func newNoteCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:   "note <provider> <text...>",
+		Short: "Send an annotation into a provider's pane without waiting",
+		Long: `Type "` + prompt.NotePrefix + `<text>" into the provider's pane and return at once.
+Unlike ask there is no req_id marker and no reply is awaited. The note is
+recorded in the project's history (ccb history) but never shared.`,
+		Example:           `  ccb note codex "switching to branch feature/login"`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeLiveProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := strings.ToLower(args[0])
+			text := strings.TrimSpace(strings.Join(args[1:], " "))
+			if text == "" {
+				output.Errorf("empty note")
+				os.Exit(output.ExitError)
+			}
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			paneID, err := sendNote(provider, workDir, text)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]string{"provider": provider, "pane_id": paneID, "note": text})
+				return
+			}
+			fmt.Printf("%s: note sent to pane %s\n", provider, paneID)
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory whose pane gets the note (default: current directory)")
+	return cmd
+}
+
+// sendNote types text as a note into provider's live pane for workDir,
+// records it in the history and returns the pane ID.
+func sendNote(provider, workDir, text string) (string, error) {
+	backend, err := terminal.DetectBackend()
+	if err != nil {
+		return "", err
+	}
+	info := session.Describe(session.NewPaneRegistry(session.RegistryPath()), provider, workDir, backend)
+	if info.PaneID == "" {
+		return "", fmt.Errorf("%s has no pane for this project (start it with 'ccb %s')", provider, provider)
+	}
+	if !info.Alive {
+		return "", fmt.Errorf("%s's pane %s is gone (bring it back with 'ccb restart %s')", provider, info.PaneID, provider)
+	}
+	if err := backend.SendKeys(info.PaneID, prompt.Note(text)); err != nil {
+		return "", fmt.Errorf("cannot send to %s's pane %s: %w", provider, info.PaneID, err)
+	}
+	if history.Enabled() {
+		e := history.Entry{Time: time.Now(), ReqID: protocol.MakeReqID(), Provider: provider, WorkDir: workDir, Prompt: text, Note: true}
+		if err := history.Append(history.Dir(runtime.RunDir()), e); err != nil {
+			fmt.Fprintf(os.Stderr, "note: not recorded in history: %v\n", err)
+		}
+	}
+	return info.PaneID, nil
+}
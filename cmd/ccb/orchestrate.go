@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/pipeline"
+)
+
+// newOrchestrateCmd builds "ccb orchestrate", which lets a planner provider
+// split a goal into steps for executor providers until it is done.
+func newOrchestrateCmd() *cobra.Command {
+	opts := &askOptions{}
+	var planner, executors string
+	var budget pipeline.Budget
+	cmd := &cobra.Command{
+		Use:   "orchestrate [goal...]",
+		Short: "Let a planner provider drive a goal across executor providers (experimental)",
+		Long: `Experimental. The planner is asked to break the goal into STEP blocks, one
+per executor ask; ccb runs the steps in order, sends the replies back to
+the planner, and repeats until the planner answers DONE or a budget runs
+out: --max-rounds planner asks, --max-steps executor asks, --budget wall
+time. A failed step is reported to the planner rather than ending the run.
+Every ask takes --timeout and the other ask flags.`,
+		Example: `  ccb orchestrate "add a --dry-run flag to the importer, with tests"
+  ccb orchestrate --planner claude --executors codex,gemini --max-rounds 8 --budget 30m - < goal.md`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.queue || opts.deliverAt != "" || opts.ttl > 0 || opts.output != "" || opts.stream {
+				return fmt.Errorf("--queue, --deliver-at, --ttl, --output and --stream do not apply to orchestrate")
+			}
+			planners := client.SplitProviders(planner)
+			if len(planners) != 1 {
+				return fmt.Errorf("--planner takes one provider, got %q", planner)
+			}
+			req, err := buildAskRequest(cmd, planners[0], args, opts)
+			if err != nil {
+				return err
+			}
+			o := &pipeline.Orchestrator{
+				Ask:       client.Ask,
+				Planner:   planners[0],
+				Executors: client.SplitProviders(executors),
+				Budget:    budget,
+			}
+			if !opts.quiet {
+				o.OnPlan = func(round int, r pipeline.Round) {
+					switch {
+					case r.Note != "":
+						fmt.Fprintf(os.Stderr, "[orchestrate] round %d: %s's plan was sent back: %s\n", round, o.Planner, r.Note)
+					case r.Plan.Done:
+						fmt.Fprintf(os.Stderr, "[orchestrate] round %d: %s is done\n", round, o.Planner)
+					default:
+						fmt.Fprintf(os.Stderr, "[orchestrate] round %d: %s planned %d step(s)\n", round, o.Planner, len(r.Plan.Steps))
+					}
+				}
+				o.OnStep = func(round int, s pipeline.Step) {
+					fmt.Fprintf(os.Stderr, "[orchestrate] round %d: %s done (exit %d, req_id %s)\n", round, s.Hop.Provider, s.Result.ExitCode, s.Result.ReqID)
+				}
+			}
+			out, runErr := o.Run(req)
+
+			if jsonOutput {
+				output.PrintJSON(out)
+			} else if runErr == nil {
+				fmt.Println(out.Summary)
+			}
+			if runErr != nil {
+				output.Errorf("%s", runErr)
+				exitCode := client.ExitCode(runErr)
+				if n := len(out.Rounds); n > 0 && out.Rounds[n-1].Planner != nil && out.Rounds[n-1].Planner.ExitCode != 0 {
+					exitCode = out.Rounds[n-1].Planner.ExitCode
+				}
+				os.Exit(opts.exitCode(exitCode))
+			}
+			return nil
+		},
+	}
+	addAskFlags(cmd, opts)
+	cmd.Flags().StringVar(&planner, "planner", "claude", "Provider that plans the steps and decides when the goal is met")
+	cmd.Flags().StringVar(&executors, "executors", "codex", "Comma-separated providers the planner may hand steps to")
+	cmd.Flags().IntVar(&budget.MaxRounds, "max-rounds", pipeline.DefaultMaxRounds, "Most planner asks before giving up")
+	cmd.Flags().IntVar(&budget.MaxSteps, "max-steps", 20, "Most executor asks in total (0 = no limit)")
+	cmd.Flags().DurationVar(&budget.MaxTime, "budget", 0, "Wall-time budget, checked before each ask (0 = no limit)")
+	return cmd
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/certs"
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/daemon"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// newDaemonPairCmds builds "ccb daemon pair|unpair|clients", which manage
+// per-client tokens for the paired auth module (CCB_AUTH=paired),
+// "ccb daemon rotate-token", which replaces the shared token, and
+// "ccb daemon cert", which issues TLS client certificates.
+func newDaemonPairCmds() []*cobra.Command {
+	pairCmd := &cobra.Command{
+		Use:   "pair <client-name>",
+		Short: "Issue a token for a client (accepted when the daemon runs with CCB_AUTH=paired)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := auth.Pair(daemon.ClientsFile(), args[0])
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output.PrintJSON(map[string]string{"client": args[0], "token": token})
+			}
+			fmt.Println(token)
+			fmt.Fprintf(os.Stderr, "Paired %s. The token is shown only once; send it as \"token\" in requests.\n", args[0])
+			if !hasString(auth.EnvModules(), "paired") {
+				fmt.Fprintln(os.Stderr, "note: start the daemon with CCB_AUTH=paired to accept paired tokens")
+			}
+			return nil
+		},
+	}
+
+	unpairCmd := &cobra.Command{
+		Use:   "unpair <client-name>",
+		Short: "Revoke a paired client's token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := auth.Unpair(daemon.ClientsFile(), args[0])
+			if err != nil {
+				return err
+			}
+			if !removed {
+				output.Errorf("no paired client named %s", args[0])
+				os.Exit(output.ExitError)
+			}
+			fmt.Printf("Revoked %s\n", args[0])
+			return nil
+		},
+	}
+
+	clientsCmd := &cobra.Command{
+		Use:   "clients",
+		Short: "List paired clients",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clients, err := auth.Clients(daemon.ClientsFile())
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				type entry struct {
+					Name      string `json:"name"`
+					CreatedAt int64  `json:"created_at"`
+				}
+				list := []entry{}
+				for _, c := range clients {
+					list = append(list, entry{c.Name, c.CreatedAt})
+				}
+				return output.PrintJSON(list)
+			}
+			if len(clients) == 0 {
+				fmt.Println("No paired clients.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CLIENT\tPAIRED")
+			for _, c := range clients {
+				fmt.Fprintf(w, "%s\t%s\n", c.Name, time.Unix(c.CreatedAt, 0).Format("2006-01-02 15:04"))
+			}
+			return w.Flush()
+		},
+	}
+	var grace time.Duration
+	rotateCmd := &cobra.Command{
+		Use:   "rotate-token",
+		Short: "Replace the daemon's shared token; the old one stays valid for --grace",
+		Long: `Issue a new shared token and write it to the state file, where the ccb CLI
+and other local clients read it. Clients still holding the old token keep
+working until --grace runs out; --grace 0 revokes it at once.`,
+		Example: "  ccb daemon rotate-token\n  ccb daemon rotate-token --grace 0",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			until, err := client.RotateToken(grace)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output.PrintJSON(map[string]string{"previous_valid_until": until.Format(time.RFC3339)})
+			}
+			if grace <= 0 {
+				fmt.Println("Token rotated; the old token is no longer accepted")
+				return nil
+			}
+			fmt.Printf("Token rotated; the old token is accepted until %s\n", until.Local().Format("15:04:05"))
+			return nil
+		},
+	}
+	rotateCmd.Flags().DurationVar(&grace, "grace", 5*time.Minute, "How long the old token stays valid")
+
+	var certOut string
+	certCmd := &cobra.Command{
+		Use:   "cert <client-name>",
+		Short: "Issue a TLS client certificate for a remote client (daemon started with CCB_ASKD_TLS=1)",
+		Long: `Issue a client certificate signed by the daemon's CA and write it to
+--out as <client-name>.pem and <client-name>-key.pem, with ca.pem to verify
+the daemon. A TLS daemon refuses connections without such a certificate;
+clients still send a token unless the daemon also runs with CCB_AUTH=mtls.`,
+		Example: "  ccb daemon cert laptop --out ./laptop-certs",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if name == certs.LocalClient || name == "server" || name == "ca" || name != filepath.Base(name) {
+				return fmt.Errorf("invalid client name %q", name)
+			}
+			ca, err := certs.LoadOrCreateCA(certs.Dir(runtime.RunDir()))
+			if err != nil {
+				return err
+			}
+			certPEM, keyPEM, err := ca.Issue(name, false, nil)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(certOut, 0700); err != nil {
+				return err
+			}
+			files := map[string][]byte{name + ".pem": certPEM, name + "-key.pem": keyPEM, "ca.pem": ca.PEM}
+			for _, f := range []string{name + ".pem", name + "-key.pem", "ca.pem"} {
+				if err := os.WriteFile(filepath.Join(certOut, f), files[f], 0600); err != nil {
+					return err
+				}
+			}
+			if jsonOutput {
+				return output.PrintJSON(map[string]string{
+					"client": name,
+					"cert":   filepath.Join(certOut, name+".pem"),
+					"key":    filepath.Join(certOut, name+"-key.pem"),
+					"ca":     filepath.Join(certOut, "ca.pem"),
+				})
+			}
+			fmt.Printf("Wrote %s, %s and %s\n", filepath.Join(certOut, name+".pem"), filepath.Join(certOut, name+"-key.pem"), filepath.Join(certOut, "ca.pem"))
+			return nil
+		},
+	}
+	certCmd.Flags().StringVar(&certOut, "out", ".", "Directory to write the certificate, key and CA to")
+
+	return []*cobra.Command{pairCmd, unpairCmd, clientsCmd, rotateCmd, certCmd}
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// newPauseCmd builds "ccb pause", which makes the daemon refuse asks to a
+// provider (with a PAUSED error) while its pane is used by hand. Asks sent
+// with --queue or --ttl are held until "ccb resume" instead.
+func newPauseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <provider> [reason...]",
+		Short: "Refuse asks to a provider until it is resumed",
+		Example: `  ccb pause codex
+  ccb pause gemini "trying a prompt by hand"`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			paused, err := client.PauseProvider(args[0], strings.Join(args[1:], " "))
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(paused)
+				return
+			}
+			fmt.Printf("%s paused; asks to it fail until 'ccb resume %s'\n", args[0], args[0])
+		},
+	}
+}
+
+// newResumeCmd builds "ccb resume", which lifts a pause; asks queued for
+// the provider meanwhile are then delivered.
+func newResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "resume <provider>",
+		Short:             "Accept asks to a paused provider again",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProviders,
+		Run: func(cmd *cobra.Command, args []string) {
+			paused, err := client.ResumeProvider(args[0])
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(paused)
+				return
+			}
+			fmt.Printf("%s resumed\n", args[0])
+		},
+	}
+}
+
+// printPaused prints the daemon status lines for paused providers, if any.
+func printPaused(v interface{}) {
+	var paused map[string]protocol.PauseInfo
+	if data, err := json.Marshal(v); err == nil {
+		json.Unmarshal(data, &paused)
+	}
+	if len(paused) == 0 {
+		return
+	}
+	names := make([]string, 0, len(paused))
+	for name := range paused {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		label := "Paused:   "
+		if i > 0 {
+			label = "          "
+		}
+		info := paused[name]
+		line := name
+		if since, err := time.Parse(time.RFC3339, info.Since); err == nil {
+			line += " since " + since.Format("2006-01-02 15:04")
+		}
+		if info.Reason != "" {
+			line += " (" + info.Reason + ")"
+		}
+		fmt.Printf("%s %s\n", label, line)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/pipeline"
+)
+
+// newRelayCmd builds "ccb relay", which pipes each provider's reply into
+// the next provider as its prompt.
+func newRelayCmd() *cobra.Command {
+	opts := &askOptions{}
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "relay <provider,provider[:timeout]...> [message...]",
+		Short: "Send a prompt to one provider and feed its reply to the next",
+		Example: `  ccb relay codex,claude "draft a migration plan for this schema"
+  ccb relay codex:300,claude:60,gemini - < spec.md
+  ccb relay --all codex,claude "write the function, then review it"`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := buildAskRequest(cmd, args[0], args[1:], opts)
+			if err != nil {
+				return err
+			}
+			hops, err := pipeline.ParseHops(args[0], req.TimeoutS)
+			if err != nil {
+				return err
+			}
+			if len(hops) < 2 {
+				return fmt.Errorf("relay needs at least two providers, got %q", args[0])
+			}
+
+			onStep := func(s pipeline.Step) {
+				if !opts.quiet {
+					fmt.Fprintf(os.Stderr, "[relay] %s done (exit %d, req_id %s)\n", s.Hop.Provider, s.Result.ExitCode, s.Result.ReqID)
+				}
+			}
+			steps, relayErr := pipeline.Relay(client.Ask, req, hops, onStep)
+
+			if jsonOutput {
+				results := make([]*client.AskResult, len(steps))
+				for i, s := range steps {
+					results[i] = s.Result
+				}
+				output.PrintJSON(results)
+			} else if all {
+				sections := make([]output.Section, len(steps))
+				for i, s := range steps {
+					sections[i] = output.Section{Label: strconv.Itoa(i+1) + ". " + s.Hop.Provider, Body: s.Result.Reply}
+				}
+				fmt.Print(output.RenderSections(sections))
+			} else if relayErr == nil {
+				fmt.Println(steps[len(steps)-1].Result.Reply)
+			}
+
+			if relayErr != nil {
+				output.Errorf("%s", relayErr)
+				exitCode := client.ExitCode(relayErr)
+				if n := len(steps); n > 0 && steps[n-1].Result.ExitCode != 0 {
+					exitCode = steps[n-1].Result.ExitCode
+				}
+				os.Exit(opts.exitCode(exitCode))
+			}
+			return nil
+		},
+	}
+	addAskFlags(cmd, opts)
+	cmd.Flags().BoolVar(&all, "all", false, "Print every hop's reply, not just the last")
+	return cmd
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/textdiff"
+)
+
+// newReplayIOCmd builds "ccb replay-io", which steps through the pane
+// recording of an ask made with --record (or under CCB_RECORD=1).
+func newReplayIOCmd() *cobra.Command {
+	var noPause, diff bool
+	cmd := &cobra.Command{
+		Use:   "replay-io [req_id]",
+		Short: "Step through the recorded pane input and snapshots of an ask",
+		Long: `Step through what ccb typed into the provider pane for an ask and the pane
+snapshots it saw, one event at a time (Enter for the next, q to quit).
+Without a req_id, list the recordings. Record an ask with 'ccb ask --record',
+or every ask by starting the daemon with CCB_RECORD=1. Recordings are
+asciicast v2 files, so 'asciinema play' can replay them too.`,
+		Example: `  ccb ask --record codex "explain this"
+  ccb replay-io 20260125-143000-123-12345
+  ccb replay-io --diff --no-pause 20260125-143000-123-12345 | less`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := recording.Dir(runtime.RunDir())
+			if len(args) == 0 {
+				listRecordings(dir)
+				return
+			}
+			path, err := recording.Path(dir, args[0])
+			if err == nil {
+				_, err = os.Stat(path)
+				if os.IsNotExist(err) {
+					err = fmt.Errorf("no recording for %s (ask with --record, or start the daemon with CCB_RECORD=1)", args[0])
+				}
+			}
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			pause := !noPause && !jsonOutput
+			if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+				pause = false
+			}
+			if err := replayRecording(path, pause, diff); err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&noPause, "no-pause", false, "Print every event without waiting for Enter")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Show each snapshot as a diff against the previous one")
+	return cmd
+}
+
+// listRecordings prints the recordings, newest first.
+func listRecordings(dir string) {
+	list, err := recording.List(dir)
+	if err != nil {
+		output.Errorf("%s", err)
+		os.Exit(output.ExitError)
+	}
+	if jsonOutput {
+		output.PrintJSON(list)
+		return
+	}
+	if len(list) == 0 {
+		fmt.Println("No recordings. Ask with --record, or start the daemon with CCB_RECORD=1.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REQ_ID\tRECORDED")
+	for _, info := range list {
+		fmt.Fprintf(w, "%s\t%s\n", info.ReqID, info.ModTime.Format("2006-01-02 15:04:05"))
+	}
+	w.Flush()
+}
+
+// replayRecording prints the events of the recording at path, waiting for
+// Enter between them when pause is set. With --json it prints the parsed
+// recording instead.
+func replayRecording(path string, pause, diff bool) error {
+	header, events, readErr := recording.Read(path)
+	if readErr != nil && len(events) == 0 {
+		return readErr
+	}
+	if jsonOutput {
+		output.PrintJSON(map[string]interface{}{"header": header, "events": events})
+		return nil
+	}
+
+	fmt.Printf("%s (%d events)\n", header.Title, len(events))
+	stdin := bufio.NewReader(os.Stdin)
+	prev := ""
+	for i, e := range events {
+		if pause && i > 0 {
+			fmt.Fprint(os.Stderr, "-- Enter: next, q: quit -- ")
+			line, err := stdin.ReadString('\n')
+			if err != nil {
+				// stdin closed: print the rest without waiting.
+				pause = false
+			} else if strings.TrimSpace(line) == "q" {
+				return nil
+			}
+		}
+		switch e.Kind {
+		case recording.KindInput:
+			fmt.Printf("\n=== [%d/%d] +%.3fs typed %d bytes ===\n", i+1, len(events), e.Time, len(e.Data))
+			fmt.Println(recording.Visible(e.Data))
+		case recording.KindOutput:
+			snap, _ := e.Snapshot()
+			fmt.Printf("\n=== [%d/%d] +%.3fs pane snapshot ===\n", i+1, len(events), e.Time)
+			if diff && prev != "" {
+				d := textdiff.Unified("previous", "snapshot", prev, snap, 2)
+				if d == "" {
+					d = "(no differences)\n"
+				}
+				fmt.Print(d)
+			} else {
+				fmt.Println(strings.TrimRight(snap, "\n"))
+			}
+			prev = snap
+		default:
+			fmt.Printf("\n=== [%d/%d] +%.3fs %s ===\n", i+1, len(events), e.Time, e.Data)
+		}
+	}
+	if readErr != nil {
+		// Events before a truncated line are still worth showing.
+		return readErr
+	}
+	return nil
+}
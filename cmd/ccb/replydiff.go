@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/textdiff"
+)
+
+// newReplyDiffCmd builds "ccb reply-diff", which diffs a code block from a
+// reply against the file on disk before the change is applied.
+func newReplyDiffCmd() *cobra.Command {
+	var block int
+	var context int
+	cmd := &cobra.Command{
+		Use:   "reply-diff <req_id> <file>",
+		Short: "Diff a reply's code block against a file in the working tree",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reqID, path := args[0], args[1]
+			reply, provider, err := client.PendReq(reqID)
+			if err != nil {
+				return err
+			}
+
+			blocks := protocol.ExtractCodeBlocks(reply)
+			var chosen protocol.CodeBlock
+			switch {
+			case len(blocks) == 0:
+				return fmt.Errorf("reply %s has no code blocks", reqID)
+			case block > 0:
+				if block > len(blocks) {
+					return fmt.Errorf("reply %s has only %d code blocks", reqID, len(blocks))
+				}
+				chosen = blocks[block-1]
+			default:
+				chosen, _ = protocol.BestCodeBlockFor(blocks, path)
+			}
+
+			current, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			diff := textdiff.Unified("a/"+path, fmt.Sprintf("b/%s (%s %s)", path, provider, reqID), string(current), chosen.Body, context)
+			if diff == "" {
+				fmt.Println("(no differences)")
+				return nil
+			}
+			fmt.Print(diff)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&block, "block", 0, "Use the Nth code block (1-based) instead of picking one")
+	cmd.Flags().IntVarP(&context, "context", "U", 3, "Lines of context")
+	return cmd
+}
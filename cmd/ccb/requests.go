@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/i18n"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newRequestsCmd builds "ccb requests", which lists the daemon's
+// in-flight and queued asks, and "ccb requests kill" to cancel one.
+func newRequestsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "requests",
+		Short: "List in-flight and queued asks in the daemon",
+		Example: `  ccb requests
+  ccb requests kill 20260125-143000-123-12345`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			reqs, err := client.ListRequests()
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(reqs)
+				return
+			}
+			if len(reqs) == 0 {
+				fmt.Println("No requests in flight.")
+				return
+			}
+			f := i18n.GetFormatter()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REQ_ID\tPROVIDER\tCALLER\tELAPSED\tPHASE")
+			for _, r := range reqs {
+				caller := i18n.BidiSafe(r.Caller)
+				if caller == "" {
+					caller = "-"
+				}
+				elapsed := f.Duration(time.Duration(r.ElapsedS * float64(time.Second)))
+				phase := r.Phase
+				if r.Priority == "background" {
+					phase += " (background)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.ReqID, r.Provider, caller, elapsed, phase)
+			}
+			w.Flush()
+		},
+	}
+	cmd.AddCommand(newRequestsKillCmd())
+	return cmd
+}
+
+// newRequestsKillCmd builds "ccb requests kill", which cancels in-flight
+// asks (their callers get a "canceled" error) or drops queued ones.
+func newRequestsKillCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <req_id>...",
+		Short: "Cancel an in-flight ask, or drop a queued one",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runCancel(args)
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/launcher"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newRestartCmd builds "ccb restart", which starts a provider again in
+// place of a pane that died.
+func newRestartCmd() *cobra.Command {
+	var cfg launcher.RestartConfig
+	cmd := &cobra.Command{
+		Use:   "restart <provider[,provider...]>",
+		Short: "Restart a provider whose pane died, reusing the pane when possible",
+		Example: `  ccb restart codex
+  ccb restart -r -a codex,gemini
+  ccb restart --force claude`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRegisteredProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers := client.SplitProviders(args[0])
+			if len(providers) == 0 {
+				return fmt.Errorf("no providers specified")
+			}
+			if cfg.WorkDir == "" {
+				cfg.WorkDir, _ = os.Getwd()
+			}
+			failed := false
+			for _, provider := range providers {
+				c := cfg
+				c.Provider = provider
+				res, err := launcher.Restart(c)
+				if err != nil {
+					output.Errorf("%s", err)
+					failed = true
+					continue
+				}
+				fmt.Printf("Restarted %s in pane %s\n", provider, res.PaneID)
+			}
+			if failed {
+				os.Exit(output.ExitError)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&cfg.Auto, "auto", "a", false, "Start with auto-approve flags")
+	cmd.Flags().BoolVarP(&cfg.Resume, "resume", "r", false, "Resume the provider's previous session")
+	cmd.Flags().BoolVar(&cfg.Force, "force", false, "Replace the pane even if the provider is still running")
+	cmd.Flags().StringVar(&cfg.WorkDir, "dir", "", "Project directory whose pane to restart (default: current directory)")
+	return cmd
+}
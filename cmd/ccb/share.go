@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// newShareCmd builds "ccb share", which hands one provider's recent
+// exchanges (from the project's ask history) to another provider as
+// background, e.g. before handing off a task.
+func newShareCmd() *cobra.Command {
+	var last, maxChars int
+	var timeout float64
+	var workDir string
+	var dryRun, quiet bool
+	cmd := &cobra.Command{
+		Use:   "share <from-provider> <to-provider> [note...]",
+		Short: "Give a provider the last exchanges with another provider as context",
+		Long: `Send the last N asks to <from-provider> and its replies, from this project's
+ask history, to <to-provider> as context. The receiving provider only
+acknowledges it; ask it the actual question afterwards. Prompts and replies
+are cut to --max-chars each to keep the message compact.`,
+		Example: `  ccb share codex claude
+  ccb share codex claude --last 5 "claude takes over the review from here"
+  ccb share --dry-run gemini codex`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeShareProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to := strings.ToLower(args[0]), strings.ToLower(args[1])
+			if from == to {
+				return fmt.Errorf("cannot share %s's context with itself", from)
+			}
+			if last < 1 {
+				return fmt.Errorf("--last must be at least 1")
+			}
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+
+			entries, err := history.Read(history.File(history.Dir(runtime.RunDir()), workDir), history.Filter{Provider: from})
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			var exchanges []history.Entry
+			for _, e := range entries {
+				if e.ExitCode == 0 && strings.TrimSpace(e.Reply) != "" {
+					exchanges = append(exchanges, e)
+				}
+			}
+			if len(exchanges) == 0 {
+				output.Errorf("no answered asks to %s in this project's history (ccb history %s)", from, from)
+				os.Exit(output.ExitError)
+			}
+			if len(exchanges) > last {
+				exchanges = exchanges[len(exchanges)-last:]
+			}
+			message := prompt.ShareContext(from, exchanges, strings.Join(args[2:], " "), maxChars)
+
+			if dryRun {
+				fmt.Print(message)
+				return nil
+			}
+			result, err := client.Ask(client.AskRequest{Provider: to, Message: message, WorkDir: workDir, TimeoutS: timeout, Quiet: quiet})
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(client.ExitCode(err))
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]interface{}{"from": from, "shared": len(exchanges), "result": result})
+				os.Exit(result.ExitCode)
+			}
+			if result.ExitCode != 0 {
+				output.Errorf("%s", result.Error)
+				os.Exit(result.ExitCode)
+			}
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "[shared %d exchange(s) from %s with %s]\n", len(exchanges), from, to)
+			}
+			if result.Reply != "" {
+				fmt.Println(result.Reply)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&last, "last", "n", 3, "Number of exchanges to share")
+	cmd.Flags().IntVar(&maxChars, "max-chars", prompt.DefaultShareMaxChars, "Cut each prompt and reply to this many characters (0 = no limit)")
+	cmd.Flags().Float64VarP(&timeout, "timeout", "t", 0, "Timeout in seconds for the receiving provider (default: its ccb.config timeout)")
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory (default: current directory)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the context message instead of sending it")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress output")
+	return cmd
+}
+
+// completeShareProviders completes the two provider arguments of share.
+func completeShareProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProviders(cmd, nil, toComplete)
+}
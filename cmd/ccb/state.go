@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/migrate"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// newStateCmd builds "ccb state", which exports and imports CCB state for
+// backups and moving to another machine.
+func newStateCmd() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Export or import CCB state (pane registry, config, templates, history)",
+	}
+
+	var outFile string
+	var includeLogs bool
+	exportCmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Write a tar.gz of CCB state to stdout or a file",
+		Example: "  ccb state export > ccb-state.tar.gz",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var w io.Writer = os.Stdout
+			if outFile != "" && outFile != "-" {
+				f, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			} else if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+				return fmt.Errorf("refusing to write a binary bundle to the terminal; redirect stdout or use -o")
+			}
+			m, err := migrate.Export(w, migrate.ExportOptions{
+				RunDir:      runtime.RunDir(),
+				ConfigDir:   config.GlobalConfigDir(),
+				IncludeLogs: includeLogs,
+			})
+			if err != nil {
+				return fmt.Errorf("export failed: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Exported %s and %s\n", m.RunDir, m.ConfigDir)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVarP(&outFile, "output", "o", "", "Write the bundle to a file instead of stdout")
+	exportCmd.Flags().BoolVar(&includeLogs, "include-logs", false, "Include daemon and provider logs")
+
+	var remapArgs []string
+	var force, yes bool
+	importCmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Restore CCB state from a bundle, remapping registry paths",
+		Example: `  ccb state import ccb-state.tar.gz
+  ccb state import --remap /Users/me=/home/me ccb-state.tar.gz`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			bundle, err := migrate.Read(r)
+			if err != nil {
+				return err
+			}
+
+			var remaps []config.PathRemap
+			for _, arg := range remapArgs {
+				rule, err := config.ParseRemap(arg)
+				if err != nil {
+					return err
+				}
+				remaps = append(remaps, rule)
+			}
+			if len(remaps) == 0 {
+				remaps = promptHomeRemap(bundle, yes || args[0] == "-")
+			}
+
+			report, err := bundle.Install(migrate.ImportOptions{
+				RunDir:    runtime.RunDir(),
+				ConfigDir: config.GlobalConfigDir(),
+				Remaps:    remaps,
+				Force:     force,
+			})
+			if err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+			if jsonOutput {
+				output.PrintJSON(report)
+				return nil
+			}
+			fmt.Printf("Imported %d file(s) from %s", len(report.Written), bundle.Manifest.Hostname)
+			if report.Remapped > 0 {
+				fmt.Printf(", remapped %d registry path(s)", report.Remapped)
+			}
+			fmt.Println()
+			for _, p := range report.Skipped {
+				fmt.Printf("  kept existing %s (use --force to overwrite)\n", p)
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringArrayVar(&remapArgs, "remap", nil, "Rewrite registry paths FROM=TO (repeatable)")
+	importCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+	importCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Accept the suggested home directory remap without asking")
+
+	stateCmd.AddCommand(exportCmd, importCmd)
+	return stateCmd
+}
+
+// promptHomeRemap offers to remap the exporting machine's home directory
+// to this one's when they differ. With assume set (--yes, or the bundle
+// is on stdin) the remap is accepted without asking.
+func promptHomeRemap(bundle *migrate.Bundle, assume bool) []config.PathRemap {
+	oldHome := bundle.Manifest.Home
+	newHome, err := os.UserHomeDir()
+	if err != nil || oldHome == "" || filepath.Clean(oldHome) == filepath.Clean(newHome) {
+		return nil
+	}
+	rule := config.PathRemap{From: oldHome, To: newHome}
+	affected := 0
+	for _, p := range bundle.Paths() {
+		if _, ok := rule.Apply(p); ok {
+			affected++
+		}
+	}
+	if affected == 0 {
+		return nil
+	}
+	if !assume {
+		fmt.Fprintf(os.Stderr, "Remap %s -> %s in %d registry path(s)? [Y/n] ", oldHome, newHome, affected)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "" && answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+	return []config.PathRemap{rule}
+}
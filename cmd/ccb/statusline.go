@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// newStatuslineCmd builds "ccb statusline", a one-line summary meant for
+// Claude Code's statusLine command, tmux status-right or (with --json) the
+// WezTerm status cell.
+func newStatuslineCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:   "statusline",
+		Short: "Print a compact one-line CCB status (for statuslines)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if workDir == "" {
+				workDir = statuslineWorkDir()
+			}
+			st := readStatusline(workDir)
+			if jsonOutput {
+				return output.PrintJSON(st)
+			}
+			fmt.Println(st.Text)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory to report on (default: from stdin JSON, else the current directory)")
+	return cmd
+}
+
+// statusline is the state behind the summary, as printed by --json.
+type statusline struct {
+	Daemon    bool            `json:"daemon"`    // the daemon answered
+	Providers map[string]bool `json:"providers"` // provider -> live pane for the project
+	Active    int             `json:"active"`    // requests in progress
+	Text      string          `json:"text"`      // the rendered one-line summary
+}
+
+// readStatusline queries the daemon; it never fails, since statuslines
+// should degrade to "offline" rather than print errors.
+func readStatusline(workDir string) statusline {
+	st := statusline{Providers: map[string]bool{}, Text: "ccb: offline"}
+	state, err := client.ReadState("")
+	if err != nil {
+		return st
+	}
+	status, err := client.StatusDaemonFor(state, workDir)
+	if err != nil {
+		return st
+	}
+
+	st.Daemon = true
+	if online, ok := status["online"].(map[string]interface{}); ok {
+		for name, v := range online {
+			up, _ := v.(bool)
+			st.Providers[name] = up
+		}
+	}
+	if active, ok := status["active_requests"].(float64); ok {
+		st.Active = int(active)
+	}
+	st.Text = st.render()
+	return st
+}
+
+// render formats the summary, e.g. "ccb: claude● codex○ | 1 active".
+func (st statusline) render() string {
+	names := make([]string, 0, len(st.Providers))
+	for name := range st.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		mark := "○"
+		if st.Providers[name] {
+			mark = "●"
+		}
+		parts = append(parts, name+mark)
+	}
+
+	line := "ccb: " + strings.Join(parts, " ")
+	if st.Active > 0 {
+		line += fmt.Sprintf(" | %d active", st.Active)
+	}
+	return strings.TrimSpace(line)
+}
+
+// statuslineWorkDir returns the project directory to report on. Claude
+// Code pipes a JSON session description on stdin; fall back to the CWD.
+func statuslineWorkDir() string {
+	cwd, _ := os.Getwd()
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice != 0 {
+		return cwd
+	}
+	// Don't block on an inherited pipe that never closes.
+	ch := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(io.LimitReader(os.Stdin, 1<<20))
+		ch <- data
+	}()
+	var data []byte
+	select {
+	case data = <-ch:
+	case <-time.After(200 * time.Millisecond):
+	}
+	if len(data) == 0 {
+		return cwd
+	}
+	var input struct {
+		CWD       string `json:"cwd"`
+		Workspace struct {
+			CurrentDir string `json:"current_dir"`
+		} `json:"workspace"`
+	}
+	if json.Unmarshal(data, &input) != nil {
+		return cwd
+	}
+	if input.Workspace.CurrentDir != "" {
+		return input.Workspace.CurrentDir
+	}
+	if input.CWD != "" {
+		return input.CWD
+	}
+	return cwd
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newStopCmd builds "ccb stop", which kills provider panes and removes
+// their registry entries and session files.
+func newStopCmd() *cobra.Command {
+	var workDir string
+	cmd := &cobra.Command{
+		Use:               "stop <provider[,provider...]>",
+		Short:             "Kill a provider's pane and clean up its registry entry and session file",
+		Example:           "  ccb stop codex\n  ccb stop codex,gemini --dir ~/src/app",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeLiveProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers := client.SplitProviders(args[0])
+			if len(providers) == 0 {
+				return fmt.Errorf("no providers specified")
+			}
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			backend, err := terminal.DetectBackend()
+			if err != nil {
+				backend = nil
+				fmt.Fprintln(os.Stderr, "note: no terminal backend detected; panes are not killed, only unregistered")
+			}
+			reg := session.NewPaneRegistry(session.RegistryPath())
+
+			var results []*session.StopResult
+			failed := false
+			for _, provider := range providers {
+				res, err := session.StopSession(reg, provider, workDir, backend)
+				if err != nil {
+					output.Errorf("%s", err)
+					failed = true
+					continue
+				}
+				results = append(results, res)
+				if !jsonOutput {
+					fmt.Println(stopSummary(res))
+				}
+			}
+			if jsonOutput {
+				output.PrintJSON(results)
+			}
+			if failed {
+				os.Exit(output.ExitError)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory whose panes to stop (default: current directory)")
+	return cmd
+}
+
+// stopSummary describes one StopResult in a line.
+func stopSummary(res *session.StopResult) string {
+	var what string
+	switch {
+	case res.Killed:
+		what = fmt.Sprintf("killed pane %s", res.PaneID)
+	case res.PaneID != "" && res.Checked:
+		what = fmt.Sprintf("pane %s already gone", res.PaneID)
+	case res.PaneID != "":
+		what = fmt.Sprintf("pane %s not checked", res.PaneID)
+	default:
+		what = "no pane"
+	}
+	if res.Unregistered {
+		what += ", unregistered"
+	}
+	if res.SessionFile != "" {
+		what += ", removed " + res.SessionFile
+	}
+	return fmt.Sprintf("%s: %s", res.Provider, what)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+)
+
+// newTemplatesCmd builds "ccb templates", which lists the prompt templates
+// usable with "ccb ask --template".
+func newTemplatesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates",
+		Short: "List prompt templates (~/.ccb/templates/*.tmpl) for ask --template",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := prompt.TemplateDir()
+			names, err := prompt.ListTemplates(dir)
+			if err != nil {
+				output.Errorf("%s", err)
+				os.Exit(output.ExitError)
+			}
+			if jsonOutput {
+				output.PrintJSON(map[string]interface{}{"dir": dir, "templates": names})
+				return
+			}
+			if len(names) == 0 {
+				fmt.Printf("No templates in %s\n", dir)
+				return
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/integrate"
+	"github.com/anthropics/claude_code_bridge/internal/launcher"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// newTmuxPluginCmd builds "ccb tmux-plugin", the entry point run by
+// ccb.tmux when CCB is installed as a TPM plugin.
+func newTmuxPluginCmd() *cobra.Command {
+	var printOnly bool
+	cmd := &cobra.Command{
+		Use:   "tmux-plugin",
+		Short: "Load the tmux plugin: ask key, status segment and pane hooks (run by TPM via ccb.tmux)",
+		Long: `Load the tmux plugin into the running tmux server. It binds prefix+C to ask
+a provider about the most recent selection, adds the ccb statusline to
+status-right, and installs hooks that report ccb panes that exit and
+re-register ccb panes when a client attaches.
+
+Options (set before the plugin loads):
+  set -g @ccb-provider codex   provider asked by the key binding
+  set -g @ccb-key C            key bound under the prefix
+  set -g @ccb-status off       leave status-right alone`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := integrate.DetectTmuxVersion()
+			if err != nil {
+				return err
+			}
+			opts := integrate.ReadPluginOptions()
+			opts.Exe = ccbExeForShell()
+			snippet := integrate.PluginSnippet(v, opts)
+			if printOnly {
+				fmt.Print(snippet)
+				return nil
+			}
+			if err := integrate.LoadPlugin(snippet); err != nil {
+				return err
+			}
+			// Pick up ccb panes from before the plugin was loaded.
+			adoptTaggedPanes()
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print the plugin configuration instead of loading it")
+	cmd.AddCommand(newTmuxPluginHookCmd())
+	return cmd
+}
+
+// newTmuxPluginHookCmd builds "ccb tmux-plugin hook", called by the tmux
+// hooks the plugin installs.
+func newTmuxPluginHookCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "hook <pane-died|client-attached> [pane-id]",
+		Short:  "Handle a tmux hook (called by tmux)",
+		Hidden: true,
+		Args:   cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch args[0] {
+			case "pane-died":
+				if len(args) < 2 {
+					return fmt.Errorf("pane-died needs a pane id")
+				}
+				paneDied(args[1])
+			case "client-attached":
+				adoptTaggedPanes()
+			default:
+				return fmt.Errorf("unknown hook %q", args[0])
+			}
+			integrate.RefreshStatus()
+			return nil
+		},
+	}
+}
+
+// paneDied tells the user when a registered provider pane exits.
+func paneDied(paneID string) {
+	reg := session.NewPaneRegistry(session.RegistryPath())
+	var providers []string
+	for provider, entries := range reg.AllEntries() {
+		for _, e := range entries {
+			if e.PaneID == paneID {
+				providers = append(providers, provider)
+			}
+		}
+	}
+	if len(providers) == 0 {
+		return
+	}
+	sort.Strings(providers)
+	integrate.TmuxMessage(fmt.Sprintf("ccb: %s pane %s exited; run: ccb restart %s",
+		strings.Join(providers, ","), paneID, providers[0]))
+}
+
+// adoptTaggedPanes re-registers ccb-launched panes the registry lost
+// track of, e.g. after the run directory was cleared.
+func adoptTaggedPanes() {
+	backend := &terminal.TmuxBackend{}
+	panes, err := backend.ListTaggedPanes()
+	if err != nil {
+		return
+	}
+	adopted := launcher.AdoptTaggedPanes(panes, backend.IsAlive)
+	if len(adopted) == 0 {
+		return
+	}
+	names := make([]string, 0, len(adopted))
+	for _, p := range adopted {
+		names = append(names, fmt.Sprintf("%s (%s)", p.Provider, p.ID))
+	}
+	integrate.TmuxMessage("ccb: re-registered " + strings.Join(names, ", "))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/launcher"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+)
+
+// newUnbindCmd builds "ccb unbind", which forgets provider panes for the
+// current project without killing them.
+func newUnbindCmd() *cobra.Command {
+	var workDir string
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "unbind [provider[,provider...]]",
+		Short: "Forget a provider's pane for this project (registry entry and session file); the pane keeps running",
+		Example: `  ccb unbind codex
+  ccb unbind --all`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRegisteredProviders,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if workDir == "" {
+				workDir, _ = os.Getwd()
+			}
+			reg := session.NewPaneRegistry(session.RegistryPath())
+
+			var providers []string
+			switch {
+			case all && len(args) > 0:
+				return fmt.Errorf("give providers or --all, not both")
+			case all:
+				providers = session.BoundProviders(reg, workDir, launcher.Providers())
+				if len(providers) == 0 && !jsonOutput {
+					fmt.Println("Nothing bound for this project.")
+					return nil
+				}
+			case len(args) == 1:
+				providers = client.SplitProviders(args[0])
+			}
+			if len(providers) == 0 && !all {
+				return fmt.Errorf("no providers specified (or use --all)")
+			}
+
+			results := []*session.StopResult{}
+			failed := false
+			for _, provider := range providers {
+				res, err := session.UnbindSession(reg, provider, workDir)
+				if err != nil {
+					output.Errorf("%s", err)
+					failed = true
+					continue
+				}
+				results = append(results, res)
+				if !jsonOutput {
+					fmt.Println(unbindSummary(res))
+				}
+			}
+			if jsonOutput {
+				output.PrintJSON(results)
+			}
+			if failed {
+				os.Exit(output.ExitError)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Unbind every provider bound to this project")
+	cmd.Flags().StringVar(&workDir, "dir", "", "Project directory to unbind (default: current directory)")
+	return cmd
+}
+
+// unbindSummary describes one unbind in a line.
+func unbindSummary(res *session.StopResult) string {
+	what := "unbound"
+	if res.PaneID != "" {
+		what += " pane " + res.PaneID
+	}
+	if res.SessionFile != "" {
+		what += ", removed " + res.SessionFile
+	}
+	return fmt.Sprintf("%s: %s", res.Provider, what)
+}
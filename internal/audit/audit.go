@@ -0,0 +1,80 @@
+// Package audit keeps an append-only JSONL record of the asks the daemon
+// serves: who asked which provider what, and how it ended. Prompts are
+// recorded as hashes unless full prompts are asked for. Unlike history,
+// the file is never trimmed or rewritten.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// Entry is one audited ask.
+type Entry struct {
+	Time         time.Time `json:"time"` // when the ask was received
+	ReqID        string    `json:"req_id"`
+	Provider     string    `json:"provider"`
+	WorkDir      string    `json:"work_dir"`
+	Caller       string    `json:"caller,omitempty"`
+	ClientID     string    `json:"client_id,omitempty"`
+	PromptSHA256 string    `json:"prompt_sha256"`
+	Prompt       string    `json:"prompt,omitempty"` // only when full prompts are audited
+	ExitCode     int       `json:"exit_code"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	Queued       bool      `json:"queued,omitempty"`     // held for later; its delivery gets its own entry
+	FromQueue    bool      `json:"from_queue,omitempty"` // delivered (or expired) from the offline queue
+}
+
+// File returns the audit log under runDir.
+func File(runDir string) string {
+	return filepath.Join(runDir, "audit.jsonl")
+}
+
+// Enabled reports whether CCB_AUDIT=1 asks the daemon to audit asks.
+func Enabled() bool {
+	return config.EnvBool("CCB_AUDIT", false)
+}
+
+// FullPrompts reports whether CCB_AUDIT_PROMPTS=1 asks for prompts to be
+// recorded in full rather than hashed.
+func FullPrompts() bool {
+	return config.EnvBool("CCB_AUDIT_PROMPTS", false)
+}
+
+// HashPrompt returns the hex SHA-256 of prompt.
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+var appendMu sync.Mutex
+
+// Append adds e to the audit log at path.
+func Append(path string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	path := File(filepath.Join(t.TempDir(), "run"))
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, ReqID: "r1", Provider: "codex", PromptSHA256: HashPrompt("fix the race")},
+		{Time: base.Add(time.Minute), ReqID: "r2", Provider: "gemini", Prompt: "review", ExitCode: 2, Error: "timeout"},
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].ReqID != "r1" || got[1].ReqID != "r2" || got[1].ExitCode != 2 {
+		t.Fatalf("entries = %+v", got)
+	}
+	if !got[0].Time.Equal(base) {
+		t.Errorf("time = %v, want %v", got[0].Time, base)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("audit log mode = %v, want owner only", info.Mode().Perm())
+	}
+}
+
+func TestHashPrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"hi", "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"},
+	}
+	for _, tt := range tests {
+		if got := HashPrompt(tt.prompt); got != tt.want {
+			t.Errorf("HashPrompt(%q) = %s, want %s", tt.prompt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package auth decides which clients may use the daemon. The shared token
+// from the state file is always accepted (the ccb CLI uses it), as is the
+// one it replaced for a grace period after a rotation; paired
+// per-client tokens, mTLS client certificates and unix peer credentials
+// can be enabled alongside it for other transports and clients.
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidToken is returned when a request's token matches nothing.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Peer describes the connection a request arrived on.
+type Peer struct {
+	Addr string               // remote address
+	TLS  *tls.ConnectionState // nil unless the transport is TLS
+	UID  int                  // peer user id from the socket, or -1
+}
+
+// PeerConn is implemented by connections that know their peer without
+// inspecting a socket, e.g. a request arriving over HTTP.
+type PeerConn interface {
+	Peer() Peer
+}
+
+// PeerOf inspects conn for TLS state and peer credentials.
+func PeerOf(conn net.Conn) Peer {
+	if pc, ok := conn.(PeerConn); ok {
+		return pc.Peer()
+	}
+	p := Peer{UID: -1}
+	if a := conn.RemoteAddr(); a != nil {
+		p.Addr = a.String()
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		state := tc.ConnectionState()
+		p.TLS = &state
+	}
+	if uid, ok := peerUID(conn); ok {
+		p.UID = uid
+	}
+	return p
+}
+
+// Identity names an authenticated client.
+type Identity struct {
+	Client string `json:"client"` // client name, cert subject or uid
+	Method string `json:"method"` // authenticator that accepted it
+}
+
+// Authenticator accepts or rejects one request.
+type Authenticator interface {
+	Name() string
+	Authenticate(peer Peer, req map[string]interface{}) (Identity, error)
+}
+
+// requestToken returns the request's "token" field.
+func requestToken(req map[string]interface{}) string {
+	t, _ := req["token"].(string)
+	return t
+}
+
+// Static accepts requests carrying the daemon's shared token.
+type Static struct {
+	Token string
+}
+
+func (s Static) Name() string { return "token" }
+
+func (s Static) Authenticate(_ Peer, req map[string]interface{}) (Identity, error) {
+	if s.Token == "" || subtle.ConstantTimeCompare([]byte(requestToken(req)), []byte(s.Token)) != 1 {
+		return Identity{}, ErrInvalidToken
+	}
+	return Identity{Client: "ccb", Method: s.Name()}, nil
+}
+
+// Chain accepts a request when any of its authenticators does. When all
+// reject it, the first authenticator's error is returned.
+type Chain []Authenticator
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, a := range c {
+		names[i] = a.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Authenticate(peer Peer, req map[string]interface{}) (Identity, error) {
+	var first error
+	for _, a := range c {
+		id, err := a.Authenticate(peer, req)
+		if err == nil {
+			return id, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		first = ErrInvalidToken
+	}
+	return Identity{}, first
+}
+
+// FromEnv builds the daemon's authenticator: shared, which accepts the
+// token from the state file (Static or Tokens), plus the modules listed in
+// CCB_AUTH (comma-separated: paired, mtls, peercred).
+//
+//	CCB_AUTH_MTLS_NAMES  allowed client certificate names (default: any verified cert)
+//	CCB_AUTH_PEER_UIDS   allowed peer uids (default: the daemon's own uid)
+func FromEnv(shared Authenticator, clientsFile string) (Authenticator, error) {
+	chain := Chain{shared}
+	for _, name := range EnvModules() {
+		switch name {
+		case "token":
+		case "paired":
+			chain = append(chain, NewPaired(clientsFile))
+		case "mtls":
+			chain = append(chain, MTLS{Names: splitList(os.Getenv("CCB_AUTH_MTLS_NAMES"))})
+		case "peercred":
+			uids := []int{os.Getuid()}
+			if raw := splitList(os.Getenv("CCB_AUTH_PEER_UIDS")); len(raw) > 0 {
+				uids = uids[:0]
+				for _, r := range raw {
+					uid, err := strconv.Atoi(r)
+					if err != nil {
+						return nil, fmt.Errorf("CCB_AUTH_PEER_UIDS: bad uid %q", r)
+					}
+					uids = append(uids, uid)
+				}
+			}
+			chain = append(chain, PeerCred{UIDs: uids})
+		default:
+			return nil, fmt.Errorf("CCB_AUTH: unknown module %q (want paired, mtls or peercred)", name)
+		}
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+// EnvModules returns the auth modules listed in CCB_AUTH.
+func EnvModules() []string {
+	return splitList(os.Getenv("CCB_AUTH"))
+}
+
+// splitList splits a comma-separated list, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func req(token string) map[string]interface{} {
+	return map[string]interface{}{"method": "ping", "token": token}
+}
+
+func TestStaticAndChain(t *testing.T) {
+	static := Static{Token: "secret"}
+	if _, err := static.Authenticate(Peer{UID: -1}, req("secret")); err != nil {
+		t.Errorf("static rejected its token: %v", err)
+	}
+	if _, err := (Static{}).Authenticate(Peer{UID: -1}, req("")); err != ErrInvalidToken {
+		t.Errorf("empty static token accepted an empty request token: %v", err)
+	}
+
+	chain := Chain{static, PeerCred{UIDs: []int{1000}}}
+	if id, err := chain.Authenticate(Peer{UID: 1000}, req("wrong")); err != nil || id.Method != "peercred" {
+		t.Errorf("chain = %+v, %v; want peercred", id, err)
+	}
+	if _, err := chain.Authenticate(Peer{UID: 7}, req("wrong")); err != ErrInvalidToken {
+		t.Errorf("chain error = %v, want the first module's", err)
+	}
+}
+
+func TestTokensRotate(t *testing.T) {
+	tokens := NewTokens("one")
+	tokens.Rotate("two", time.Hour)
+	tokens.Rotate("three", 0)
+
+	tests := []struct {
+		token string
+		ok    bool
+	}{
+		{"three", true}, // current
+		{"two", false},  // replaced with no grace
+		{"one", true},   // replaced with an hour's grace
+		{"", false},
+		{"four", false},
+	}
+	for _, tt := range tests {
+		_, err := tokens.Authenticate(Peer{UID: -1}, req(tt.token))
+		if (err == nil) != tt.ok {
+			t.Errorf("token %q: err = %v, want ok=%v", tt.token, err, tt.ok)
+		}
+	}
+	if got := tokens.Current(); got != "three" {
+		t.Errorf("Current() = %q, want three", got)
+	}
+
+	// A rotation with no grace drops the old token at once, and expired
+	// ones are forgotten.
+	tokens = NewTokens("a")
+	tokens.Rotate("b", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	tokens.Rotate("c", 0)
+	for _, old := range []string{"a", "b"} {
+		if _, err := tokens.Authenticate(Peer{UID: -1}, req(old)); err != ErrInvalidToken {
+			t.Errorf("expired token %q accepted: %v", old, err)
+		}
+	}
+	if len(tokens.previous) != 0 {
+		t.Errorf("previous = %v, want expired tokens forgotten", tokens.previous)
+	}
+}
+
+func TestPaired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clients.json")
+	p := NewPaired(path)
+	if _, err := p.Authenticate(Peer{}, req("anything")); err == nil {
+		t.Fatal("accepted a token with no clients file")
+	}
+
+	token, err := Pair(path, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := p.Authenticate(Peer{}, req(token)); err != nil || id.Client != "ci" {
+		t.Fatalf("paired token: %+v, %v", id, err)
+	}
+	data, _ := os.ReadFile(path)
+	if len(data) == 0 || strings.Contains(string(data), token) {
+		t.Error("clients file must hold only the token hash")
+	}
+
+	// Re-pairing replaces the old token.
+	newToken, _ := Pair(path, "ci")
+	if _, err := p.Authenticate(Peer{}, req(token)); err == nil {
+		t.Error("old token still accepted after re-pairing")
+	}
+	if removed, err := Unpair(path, "ci"); !removed || err != nil {
+		t.Fatalf("Unpair = %v, %v", removed, err)
+	}
+	if _, err := p.Authenticate(Peer{}, req(newToken)); err == nil {
+		t.Error("token accepted after unpair")
+	}
+	if removed, _ := Unpair(path, "ci"); removed {
+		t.Error("second unpair reported a removal")
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "builder"}, DNSNames: []string{"ci.local"}}
+	peer := Peer{UID: -1, TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+
+	if id, err := (MTLS{}).Authenticate(peer, nil); err != nil || id.Client != "builder" {
+		t.Errorf("any verified cert: %+v, %v", id, err)
+	}
+	if id, err := (MTLS{Names: []string{"ci.local"}}).Authenticate(peer, nil); err != nil || id.Client != "ci.local" {
+		t.Errorf("allowed DNS name: %+v, %v", id, err)
+	}
+	if _, err := (MTLS{Names: []string{"other"}}).Authenticate(peer, nil); err == nil {
+		t.Error("accepted a certificate not in Names")
+	}
+	if _, err := (MTLS{}).Authenticate(Peer{UID: -1}, nil); err == nil {
+		t.Error("accepted a connection without TLS")
+	}
+}
+
+func TestPeerOfUnixSocket(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only read on Linux")
+	}
+	sock := filepath.Join(t.TempDir(), "s")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := net.Dial("unix", sock)
+		if err == nil {
+			defer c.Close()
+			c.Read(make([]byte, 1))
+		}
+	}()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	peer := PeerOf(conn)
+	if peer.UID != os.Getuid() {
+		t.Fatalf("peer uid = %d, want %d", peer.UID, os.Getuid())
+	}
+	if _, err := (PeerCred{UIDs: []int{os.Getuid()}}).Authenticate(peer, nil); err != nil {
+		t.Errorf("own uid rejected: %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CCB_AUTH", "")
+	a, err := FromEnv(Static{Token: "tok"}, "")
+	if _, ok := a.(Static); !ok || err != nil {
+		t.Errorf("default = %T, %v; want Static", a, err)
+	}
+	t.Setenv("CCB_AUTH", "paired, peercred")
+	a, err = FromEnv(NewTokens("tok"), filepath.Join(t.TempDir(), "c.json"))
+	if err != nil || a.Name() != "token,paired,peercred" {
+		t.Errorf("FromEnv = %v, %v", a, err)
+	}
+	t.Setenv("CCB_AUTH", "kerberos")
+	if _, err := FromEnv(Static{Token: "tok"}, ""); err == nil {
+		t.Error("expected an error for an unknown module")
+	}
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"errors"
+)
+
+// MTLS accepts clients that presented a certificate the TLS listener
+// verified. Names, if set, limits which certificate common names or DNS
+// names are allowed.
+type MTLS struct {
+	Names []string
+}
+
+func (m MTLS) Name() string { return "mtls" }
+
+func (m MTLS) Authenticate(peer Peer, _ map[string]interface{}) (Identity, error) {
+	if peer.TLS == nil || len(peer.TLS.VerifiedChains) == 0 || len(peer.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, errors.New("no verified client certificate")
+	}
+	cert := peer.TLS.VerifiedChains[0][0]
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	if len(m.Names) == 0 {
+		return Identity{Client: names[0], Method: m.Name()}, nil
+	}
+	for _, allowed := range m.Names {
+		for _, n := range names {
+			if n != "" && n == allowed {
+				return Identity{Client: n, Method: m.Name()}, nil
+			}
+		}
+	}
+	return Identity{}, errors.New("client certificate " + names[0] + " not allowed")
+}
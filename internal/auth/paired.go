@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// Client is one paired client. Only a hash of its token is stored.
+type Client struct {
+	Name        string `json:"name"`
+	TokenSHA256 string `json:"token_sha256"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// Paired accepts per-client tokens issued by Pair and kept (hashed) in a
+// JSON file. The file is re-read when it changes, so pairing and revoking
+// take effect without restarting the daemon.
+type Paired struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	clients map[string]Client // by token hash
+}
+
+// NewPaired returns a Paired backed by path.
+func NewPaired(path string) *Paired {
+	return &Paired{path: path}
+}
+
+func (p *Paired) Name() string { return "paired" }
+
+func (p *Paired) Authenticate(_ Peer, req map[string]interface{}) (Identity, error) {
+	token := requestToken(req)
+	if token == "" {
+		return Identity{}, ErrInvalidToken
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reloadLocked()
+	sum := hashToken(token)
+	for hash, c := range p.clients {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(sum)) == 1 {
+			return Identity{Client: c.Name, Method: p.Name()}, nil
+		}
+	}
+	return Identity{}, ErrInvalidToken
+}
+
+// reloadLocked re-reads the clients file if it changed since last read.
+func (p *Paired) reloadLocked() {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		p.clients, p.modTime = nil, time.Time{}
+		return
+	}
+	// A file written within the mtime granularity may change without
+	// its mtime moving, so recent files are always re-read.
+	if p.clients != nil && info.ModTime().Equal(p.modTime) && time.Since(p.modTime) > 2*time.Second {
+		return
+	}
+	list, err := readClients(p.path)
+	if err != nil {
+		return
+	}
+	p.clients = make(map[string]Client, len(list))
+	for _, c := range list {
+		p.clients[c.TokenSHA256] = c
+	}
+	p.modTime = info.ModTime()
+}
+
+// Pair issues a new token for name, replacing any earlier one, and
+// returns it. The token is shown once; only its hash is saved.
+func Pair(path, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("client name is required")
+	}
+	list, err := readClients(path)
+	if err != nil {
+		return "", err
+	}
+	token := runtime.RandomToken() + runtime.RandomToken()
+	kept := list[:0]
+	for _, c := range list {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	kept = append(kept, Client{Name: name, TokenSHA256: hashToken(token), CreatedAt: time.Now().Unix()})
+	return token, writeClients(path, kept)
+}
+
+// Unpair revokes name's token. It reports whether name was paired.
+func Unpair(path, name string) (bool, error) {
+	list, err := readClients(path)
+	if err != nil {
+		return false, err
+	}
+	kept := list[:0]
+	for _, c := range list {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(list) {
+		return false, nil
+	}
+	return true, writeClients(path, kept)
+}
+
+// Clients lists paired clients by name.
+func Clients(path string) ([]Client, error) {
+	list, err := readClients(path)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, err
+}
+
+func readClients(path string) ([]Client, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Clients []Client `json:"clients"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file.Clients, nil
+}
+
+func writeClients(path string, list []Client) error {
+	if list == nil {
+		list = []Client{}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"clients": list}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PeerCred accepts connections whose peer process runs as one of UIDs.
+// Credentials are only available on Linux, over the daemon's unix socket
+// transport (CCB_TRANSPORT=unix); other connections are rejected.
+type PeerCred struct {
+	UIDs []int
+}
+
+func (p PeerCred) Name() string { return "peercred" }
+
+func (p PeerCred) Authenticate(peer Peer, _ map[string]interface{}) (Identity, error) {
+	if peer.UID < 0 {
+		return Identity{}, fmt.Errorf("no peer credentials on this connection")
+	}
+	for _, uid := range p.UIDs {
+		if uid == peer.UID {
+			return Identity{Client: "uid " + strconv.Itoa(uid), Method: p.Name()}, nil
+		}
+	}
+	return Identity{}, fmt.Errorf("peer uid %d not allowed", peer.UID)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package auth
+
+import "net"
+
+// peerUID is not supported on this platform.
+func peerUID(conn net.Conn) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+// Tokens accepts the daemon's current shared token and, until their grace
+// period ends, the tokens it replaced (see Rotate), so clients that read
+// the state file before a rotation keep working for a while.
+type Tokens struct {
+	mu       sync.RWMutex
+	current  string
+	previous map[string]time.Time // replaced token -> when it stops being accepted
+}
+
+// NewTokens returns Tokens accepting current.
+func NewTokens(current string) *Tokens {
+	return &Tokens{current: current, previous: make(map[string]time.Time)}
+}
+
+func (t *Tokens) Name() string { return "token" }
+
+func (t *Tokens) Authenticate(_ Peer, req map[string]interface{}) (Identity, error) {
+	token := []byte(requestToken(req))
+	if len(token) == 0 {
+		return Identity{}, ErrInvalidToken
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ok := t.current != "" && subtle.ConstantTimeCompare(token, []byte(t.current)) == 1
+	now := time.Now()
+	for old, until := range t.previous {
+		if now.Before(until) && subtle.ConstantTimeCompare(token, []byte(old)) == 1 {
+			ok = true
+		}
+	}
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+	return Identity{Client: "ccb", Method: t.Name()}, nil
+}
+
+// Current returns the token clients should use.
+func (t *Tokens) Current() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.current
+}
+
+// Rotate makes next the current token and returns when the one it
+// replaces stops being accepted. Tokens whose grace has ended are
+// forgotten.
+func (t *Tokens) Rotate(next string, grace time.Duration) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for old, until := range t.previous {
+		if !now.Before(until) {
+			delete(t.previous, old)
+		}
+	}
+	until := now.Add(grace)
+	if t.current != "" && grace > 0 {
+		t.previous[t.current] = until
+	}
+	t.current = next
+	return until
+}
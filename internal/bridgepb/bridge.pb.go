@@ -0,0 +1,1298 @@
+// gRPC definition of the ccb daemon protocol. The daemon serves it when
+// started with --grpc (or CCB_ASKD_GRPC); each call is turned into the
+// matching JSON request (see internal/protocol) and run through the same
+// dispatcher as the TCP protocol, so auth, validation and routing match.
+//
+// Authentication: send the daemon or paired client token as
+// "authorization: Bearer <token>" metadata. A request the daemon rejects
+// fails with a gRPC status: Unauthenticated for a bad token,
+// InvalidArgument for a request that fails validation, Unknown otherwise.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: bridge.proto
+
+package bridgepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"` // required, e.g. "codex"
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`   // required
+	ClientId      string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	WorkDir       string                 `protobuf:"bytes,4,opt,name=work_dir,json=workDir,proto3" json:"work_dir,omitempty"`      // project whose provider pane receives the ask
+	ReqId         string                 `protobuf:"bytes,5,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"`            // caller-chosen request id; used for pend and cancel
+	TimeoutS      float64                `protobuf:"fixed64,6,opt,name=timeout_s,json=timeoutS,proto3" json:"timeout_s,omitempty"` // 0 uses the default
+	Quiet         bool                   `protobuf:"varint,7,opt,name=quiet,proto3" json:"quiet,omitempty"`
+	Caller        string                 `protobuf:"bytes,8,opt,name=caller,proto3" json:"caller,omitempty"`
+	Quick         bool                   `protobuf:"varint,9,opt,name=quick,proto3" json:"quick,omitempty"`                          // read the reply from the pane only
+	Queue         bool                   `protobuf:"varint,10,opt,name=queue,proto3" json:"queue,omitempty"`                         // hold the ask while the provider is offline
+	DeliverAt     string                 `protobuf:"bytes,11,opt,name=deliver_at,json=deliverAt,proto3" json:"deliver_at,omitempty"` // hold the ask until this RFC 3339 time
+	TtlS          float64                `protobuf:"fixed64,12,opt,name=ttl_s,json=ttlS,proto3" json:"ttl_s,omitempty"`              // drop a queued ask after this many seconds
+	Priority      string                 `protobuf:"bytes,13,opt,name=priority,proto3" json:"priority,omitempty"`                    // "interactive" (default) or "background"
+	Record        bool                   `protobuf:"varint,14,opt,name=record,proto3" json:"record,omitempty"`                       // record the pane (asciicast v2)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskRequest) Reset() {
+	*x = AskRequest{}
+	mi := &file_bridge_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskRequest) ProtoMessage() {}
+
+func (x *AskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskRequest.ProtoReflect.Descriptor instead.
+func (*AskRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AskRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *AskRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AskRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *AskRequest) GetWorkDir() string {
+	if x != nil {
+		return x.WorkDir
+	}
+	return ""
+}
+
+func (x *AskRequest) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *AskRequest) GetTimeoutS() float64 {
+	if x != nil {
+		return x.TimeoutS
+	}
+	return 0
+}
+
+func (x *AskRequest) GetQuiet() bool {
+	if x != nil {
+		return x.Quiet
+	}
+	return false
+}
+
+func (x *AskRequest) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *AskRequest) GetQuick() bool {
+	if x != nil {
+		return x.Quick
+	}
+	return false
+}
+
+func (x *AskRequest) GetQueue() bool {
+	if x != nil {
+		return x.Queue
+	}
+	return false
+}
+
+func (x *AskRequest) GetDeliverAt() string {
+	if x != nil {
+		return x.DeliverAt
+	}
+	return ""
+}
+
+func (x *AskRequest) GetTtlS() float64 {
+	if x != nil {
+		return x.TtlS
+	}
+	return 0
+}
+
+func (x *AskRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *AskRequest) GetRecord() bool {
+	if x != nil {
+		return x.Record
+	}
+	return false
+}
+
+type AskEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*AskEvent_Chunk
+	//	*AskEvent_Result
+	Event         isAskEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskEvent) Reset() {
+	*x = AskEvent{}
+	mi := &file_bridge_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskEvent) ProtoMessage() {}
+
+func (x *AskEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskEvent.ProtoReflect.Descriptor instead.
+func (*AskEvent) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AskEvent) GetEvent() isAskEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *AskEvent) GetChunk() *Chunk {
+	if x != nil {
+		if x, ok := x.Event.(*AskEvent_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *AskEvent) GetResult() *AskResult {
+	if x != nil {
+		if x, ok := x.Event.(*AskEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isAskEvent_Event interface {
+	isAskEvent_Event()
+}
+
+type AskEvent_Chunk struct {
+	Chunk *Chunk `protobuf:"bytes,1,opt,name=chunk,proto3,oneof"`
+}
+
+type AskEvent_Result struct {
+	Result *AskResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*AskEvent_Chunk) isAskEvent_Event() {}
+
+func (*AskEvent_Result) isAskEvent_Event() {}
+
+// Chunk carries completed reply lines ahead of the result.
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReqId         string                 `protobuf:"bytes,1,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"`
+	Lines         []string               `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_bridge_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Chunk) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *Chunk) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+// AskResult is the final result of an ask. A failed ask is a result with
+// a nonzero exit code, not a gRPC error.
+type AskResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExitCode      int32                  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"` // see "Exit Codes" in the README
+	Reply         string                 `protobuf:"bytes,2,opt,name=reply,proto3" json:"reply,omitempty"`
+	ReqId         string                 `protobuf:"bytes,3,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"`
+	SessionKey    string                 `protobuf:"bytes,4,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"`
+	LogPath       string                 `protobuf:"bytes,5,opt,name=log_path,json=logPath,proto3" json:"log_path,omitempty"`
+	AnchorSeen    bool                   `protobuf:"varint,6,opt,name=anchor_seen,json=anchorSeen,proto3" json:"anchor_seen,omitempty"`
+	DoneSeen      bool                   `protobuf:"varint,7,opt,name=done_seen,json=doneSeen,proto3" json:"done_seen,omitempty"`
+	FallbackScan  bool                   `protobuf:"varint,8,opt,name=fallback_scan,json=fallbackScan,proto3" json:"fallback_scan,omitempty"`
+	AnchorMs      int64                  `protobuf:"varint,9,opt,name=anchor_ms,json=anchorMs,proto3" json:"anchor_ms,omitempty"`
+	DoneMs        int64                  `protobuf:"varint,10,opt,name=done_ms,json=doneMs,proto3" json:"done_ms,omitempty"`
+	StartupMs     int64                  `protobuf:"varint,11,opt,name=startup_ms,json=startupMs,proto3" json:"startup_ms,omitempty"`
+	ReplyMs       int64                  `protobuf:"varint,12,opt,name=reply_ms,json=replyMs,proto3" json:"reply_ms,omitempty"`
+	Error         string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	RetryAfterMs  int64                  `protobuf:"varint,14,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	Queued        bool                   `protobuf:"varint,15,opt,name=queued,proto3" json:"queued,omitempty"` // held in the offline queue, not yet sent
+	Recording     string                 `protobuf:"bytes,16,opt,name=recording,proto3" json:"recording,omitempty"`
+	DoneHeuristic bool                   `protobuf:"varint,17,opt,name=done_heuristic,json=doneHeuristic,proto3" json:"done_heuristic,omitempty"`
+	DoneReason    string                 `protobuf:"bytes,18,opt,name=done_reason,json=doneReason,proto3" json:"done_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskResult) Reset() {
+	*x = AskResult{}
+	mi := &file_bridge_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskResult) ProtoMessage() {}
+
+func (x *AskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskResult.ProtoReflect.Descriptor instead.
+func (*AskResult) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AskResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *AskResult) GetReply() string {
+	if x != nil {
+		return x.Reply
+	}
+	return ""
+}
+
+func (x *AskResult) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *AskResult) GetSessionKey() string {
+	if x != nil {
+		return x.SessionKey
+	}
+	return ""
+}
+
+func (x *AskResult) GetLogPath() string {
+	if x != nil {
+		return x.LogPath
+	}
+	return ""
+}
+
+func (x *AskResult) GetAnchorSeen() bool {
+	if x != nil {
+		return x.AnchorSeen
+	}
+	return false
+}
+
+func (x *AskResult) GetDoneSeen() bool {
+	if x != nil {
+		return x.DoneSeen
+	}
+	return false
+}
+
+func (x *AskResult) GetFallbackScan() bool {
+	if x != nil {
+		return x.FallbackScan
+	}
+	return false
+}
+
+func (x *AskResult) GetAnchorMs() int64 {
+	if x != nil {
+		return x.AnchorMs
+	}
+	return 0
+}
+
+func (x *AskResult) GetDoneMs() int64 {
+	if x != nil {
+		return x.DoneMs
+	}
+	return 0
+}
+
+func (x *AskResult) GetStartupMs() int64 {
+	if x != nil {
+		return x.StartupMs
+	}
+	return 0
+}
+
+func (x *AskResult) GetReplyMs() int64 {
+	if x != nil {
+		return x.ReplyMs
+	}
+	return 0
+}
+
+func (x *AskResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AskResult) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+func (x *AskResult) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *AskResult) GetRecording() string {
+	if x != nil {
+		return x.Recording
+	}
+	return ""
+}
+
+func (x *AskResult) GetDoneHeuristic() bool {
+	if x != nil {
+		return x.DoneHeuristic
+	}
+	return false
+}
+
+func (x *AskResult) GetDoneReason() string {
+	if x != nil {
+		return x.DoneReason
+	}
+	return ""
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"` // empty pings the daemon only
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_bridge_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PingRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PingRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type PingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Providers     []string               `protobuf:"bytes,1,rep,name=providers,proto3" json:"providers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_bridge_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PingResponse) GetProviders() []string {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+type PendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"` // empty fetches from the reply store across all providers
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ReqId         string                 `protobuf:"bytes,3,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"` // fetch the stored reply to this request instead
+	N             int32                  `protobuf:"varint,4,opt,name=n,proto3" json:"n,omitempty"`                     // Nth most recent answered reply; 0 or 1 is the latest
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PendRequest) Reset() {
+	*x = PendRequest{}
+	mi := &file_bridge_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendRequest) ProtoMessage() {}
+
+func (x *PendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendRequest.ProtoReflect.Descriptor instead.
+func (*PendRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PendRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PendRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *PendRequest) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *PendRequest) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+type PendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reply         string                 `protobuf:"bytes,1,opt,name=reply,proto3" json:"reply,omitempty"`
+	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	ReqId         string                 `protobuf:"bytes,3,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"`
+	ExitCode      int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PendResponse) Reset() {
+	*x = PendResponse{}
+	mi := &file_bridge_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendResponse) ProtoMessage() {}
+
+func (x *PendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendResponse.ProtoReflect.Descriptor instead.
+func (*PendResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PendResponse) GetReply() string {
+	if x != nil {
+		return x.Reply
+	}
+	return ""
+}
+
+func (x *PendResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PendResponse) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *PendResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkDir       string                 `protobuf:"bytes,1,opt,name=work_dir,json=workDir,proto3" json:"work_dir,omitempty"` // also report which providers have a live pane here
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_bridge_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatusRequest) GetWorkDir() string {
+	if x != nil {
+		return x.WorkDir
+	}
+	return ""
+}
+
+type StorageCheck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Ok            bool                   `protobuf:"varint,3,opt,name=ok,proto3" json:"ok,omitempty"`
+	Problem       string                 `protobuf:"bytes,4,opt,name=problem,proto3" json:"problem,omitempty"`
+	Hint          string                 `protobuf:"bytes,5,opt,name=hint,proto3" json:"hint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageCheck) Reset() {
+	*x = StorageCheck{}
+	mi := &file_bridge_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageCheck) ProtoMessage() {}
+
+func (x *StorageCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageCheck.ProtoReflect.Descriptor instead.
+func (*StorageCheck) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StorageCheck) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *StorageCheck) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StorageCheck) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *StorageCheck) GetProblem() string {
+	if x != nil {
+		return x.Problem
+	}
+	return ""
+}
+
+func (x *StorageCheck) GetHint() string {
+	if x != nil {
+		return x.Hint
+	}
+	return ""
+}
+
+type PauseInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         string                 `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"` // RFC 3339
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseInfo) Reset() {
+	*x = PauseInfo{}
+	mi := &file_bridge_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseInfo) ProtoMessage() {}
+
+func (x *PauseInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseInfo.ProtoReflect.Descriptor instead.
+func (*PauseInfo) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PauseInfo) GetSince() string {
+	if x != nil {
+		return x.Since
+	}
+	return ""
+}
+
+func (x *PauseInfo) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StatusResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Pid            int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Providers      []string               `protobuf:"bytes,2,rep,name=providers,proto3" json:"providers,omitempty"`
+	Workers        int32                  `protobuf:"varint,3,opt,name=workers,proto3" json:"workers,omitempty"`
+	ActiveRequests int32                  `protobuf:"varint,4,opt,name=active_requests,json=activeRequests,proto3" json:"active_requests,omitempty"`
+	Queued         int32                  `protobuf:"varint,5,opt,name=queued,proto3" json:"queued,omitempty"`
+	Projects       int32                  `protobuf:"varint,6,opt,name=projects,proto3" json:"projects,omitempty"`
+	Online         map[string]bool        `protobuf:"bytes,7,rep,name=online,proto3" json:"online,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Storage        []*StorageCheck        `protobuf:"bytes,8,rep,name=storage,proto3" json:"storage,omitempty"`
+	Paused         map[string]*PauseInfo  `protobuf:"bytes,9,rep,name=paused,proto3" json:"paused,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_bridge_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatusResponse) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetProviders() []string {
+	if x != nil {
+		return x.Providers
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetActiveRequests() int32 {
+	if x != nil {
+		return x.ActiveRequests
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetProjects() int32 {
+	if x != nil {
+		return x.Projects
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetOnline() map[string]bool {
+	if x != nil {
+		return x.Online
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetStorage() []*StorageCheck {
+	if x != nil {
+		return x.Storage
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetPaused() map[string]*PauseInfo {
+	if x != nil {
+		return x.Paused
+	}
+	return nil
+}
+
+type CancelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReqId         string                 `protobuf:"bytes,1,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"` // required
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_bridge_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CancelRequest) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+type CancelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReqId         string                 `protobuf:"bytes,1,opt,name=req_id,json=reqId,proto3" json:"req_id,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"` // "canceled" or "dequeued"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponse) Reset() {
+	*x = CancelResponse{}
+	mi := &file_bridge_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponse) ProtoMessage() {}
+
+func (x *CancelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponse.ProtoReflect.Descriptor instead.
+func (*CancelResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CancelResponse) GetReqId() string {
+	if x != nil {
+		return x.ReqId
+	}
+	return ""
+}
+
+func (x *CancelResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+var File_bridge_proto protoreflect.FileDescriptor
+
+const file_bridge_proto_rawDesc = "" +
+	"\n" +
+	"\fbridge.proto\x12\x06ccb.v1\"\xf0\x02\n" +
+	"\n" +
+	"AskRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12\x19\n" +
+	"\bwork_dir\x18\x04 \x01(\tR\aworkDir\x12\x15\n" +
+	"\x06req_id\x18\x05 \x01(\tR\x05reqId\x12\x1b\n" +
+	"\ttimeout_s\x18\x06 \x01(\x01R\btimeoutS\x12\x14\n" +
+	"\x05quiet\x18\a \x01(\bR\x05quiet\x12\x16\n" +
+	"\x06caller\x18\b \x01(\tR\x06caller\x12\x14\n" +
+	"\x05quick\x18\t \x01(\bR\x05quick\x12\x14\n" +
+	"\x05queue\x18\n" +
+	" \x01(\bR\x05queue\x12\x1d\n" +
+	"\n" +
+	"deliver_at\x18\v \x01(\tR\tdeliverAt\x12\x13\n" +
+	"\x05ttl_s\x18\f \x01(\x01R\x04ttlS\x12\x1a\n" +
+	"\bpriority\x18\r \x01(\tR\bpriority\x12\x16\n" +
+	"\x06record\x18\x0e \x01(\bR\x06record\"g\n" +
+	"\bAskEvent\x12%\n" +
+	"\x05chunk\x18\x01 \x01(\v2\r.ccb.v1.ChunkH\x00R\x05chunk\x12+\n" +
+	"\x06result\x18\x02 \x01(\v2\x11.ccb.v1.AskResultH\x00R\x06resultB\a\n" +
+	"\x05event\"4\n" +
+	"\x05Chunk\x12\x15\n" +
+	"\x06req_id\x18\x01 \x01(\tR\x05reqId\x12\x14\n" +
+	"\x05lines\x18\x02 \x03(\tR\x05lines\"\x9e\x04\n" +
+	"\tAskResult\x12\x1b\n" +
+	"\texit_code\x18\x01 \x01(\x05R\bexitCode\x12\x14\n" +
+	"\x05reply\x18\x02 \x01(\tR\x05reply\x12\x15\n" +
+	"\x06req_id\x18\x03 \x01(\tR\x05reqId\x12\x1f\n" +
+	"\vsession_key\x18\x04 \x01(\tR\n" +
+	"sessionKey\x12\x19\n" +
+	"\blog_path\x18\x05 \x01(\tR\alogPath\x12\x1f\n" +
+	"\vanchor_seen\x18\x06 \x01(\bR\n" +
+	"anchorSeen\x12\x1b\n" +
+	"\tdone_seen\x18\a \x01(\bR\bdoneSeen\x12#\n" +
+	"\rfallback_scan\x18\b \x01(\bR\ffallbackScan\x12\x1b\n" +
+	"\tanchor_ms\x18\t \x01(\x03R\banchorMs\x12\x17\n" +
+	"\adone_ms\x18\n" +
+	" \x01(\x03R\x06doneMs\x12\x1d\n" +
+	"\n" +
+	"startup_ms\x18\v \x01(\x03R\tstartupMs\x12\x19\n" +
+	"\breply_ms\x18\f \x01(\x03R\areplyMs\x12\x14\n" +
+	"\x05error\x18\r \x01(\tR\x05error\x12$\n" +
+	"\x0eretry_after_ms\x18\x0e \x01(\x03R\fretryAfterMs\x12\x16\n" +
+	"\x06queued\x18\x0f \x01(\bR\x06queued\x12\x1c\n" +
+	"\trecording\x18\x10 \x01(\tR\trecording\x12%\n" +
+	"\x0edone_heuristic\x18\x11 \x01(\bR\rdoneHeuristic\x12\x1f\n" +
+	"\vdone_reason\x18\x12 \x01(\tR\n" +
+	"doneReason\"H\n" +
+	"\vPingRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\",\n" +
+	"\fPingResponse\x12\x1c\n" +
+	"\tproviders\x18\x01 \x03(\tR\tproviders\"m\n" +
+	"\vPendRequest\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x15\n" +
+	"\x06req_id\x18\x03 \x01(\tR\x05reqId\x12\f\n" +
+	"\x01n\x18\x04 \x01(\x05R\x01n\"t\n" +
+	"\fPendResponse\x12\x14\n" +
+	"\x05reply\x18\x01 \x01(\tR\x05reply\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x15\n" +
+	"\x06req_id\x18\x03 \x01(\tR\x05reqId\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\"*\n" +
+	"\rStatusRequest\x12\x19\n" +
+	"\bwork_dir\x18\x01 \x01(\tR\aworkDir\"|\n" +
+	"\fStorageCheck\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x0e\n" +
+	"\x02ok\x18\x03 \x01(\bR\x02ok\x12\x18\n" +
+	"\aproblem\x18\x04 \x01(\tR\aproblem\x12\x12\n" +
+	"\x04hint\x18\x05 \x01(\tR\x04hint\"9\n" +
+	"\tPauseInfo\x12\x14\n" +
+	"\x05since\x18\x01 \x01(\tR\x05since\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xe8\x03\n" +
+	"\x0eStatusResponse\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x1c\n" +
+	"\tproviders\x18\x02 \x03(\tR\tproviders\x12\x18\n" +
+	"\aworkers\x18\x03 \x01(\x05R\aworkers\x12'\n" +
+	"\x0factive_requests\x18\x04 \x01(\x05R\x0eactiveRequests\x12\x16\n" +
+	"\x06queued\x18\x05 \x01(\x05R\x06queued\x12\x1a\n" +
+	"\bprojects\x18\x06 \x01(\x05R\bprojects\x12:\n" +
+	"\x06online\x18\a \x03(\v2\".ccb.v1.StatusResponse.OnlineEntryR\x06online\x12.\n" +
+	"\astorage\x18\b \x03(\v2\x14.ccb.v1.StorageCheckR\astorage\x12:\n" +
+	"\x06paused\x18\t \x03(\v2\".ccb.v1.StatusResponse.PausedEntryR\x06paused\x1a9\n" +
+	"\vOnlineEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01\x1aL\n" +
+	"\vPausedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12'\n" +
+	"\x05value\x18\x02 \x01(\v2\x11.ccb.v1.PauseInfoR\x05value:\x028\x01\"&\n" +
+	"\rCancelRequest\x12\x15\n" +
+	"\x06req_id\x18\x01 \x01(\tR\x05reqId\"=\n" +
+	"\x0eCancelResponse\x12\x15\n" +
+	"\x06req_id\x18\x01 \x01(\tR\x05reqId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state2\x8f\x02\n" +
+	"\x06Bridge\x12-\n" +
+	"\x03Ask\x12\x12.ccb.v1.AskRequest\x1a\x10.ccb.v1.AskEvent0\x01\x121\n" +
+	"\x04Ping\x12\x13.ccb.v1.PingRequest\x1a\x14.ccb.v1.PingResponse\x121\n" +
+	"\x04Pend\x12\x13.ccb.v1.PendRequest\x1a\x14.ccb.v1.PendResponse\x127\n" +
+	"\x06Status\x12\x15.ccb.v1.StatusRequest\x1a\x16.ccb.v1.StatusResponse\x127\n" +
+	"\x06Cancel\x12\x15.ccb.v1.CancelRequest\x1a\x16.ccb.v1.CancelResponseB<Z:github.com/anthropics/claude_code_bridge/internal/bridgepbb\x06proto3"
+
+var (
+	file_bridge_proto_rawDescOnce sync.Once
+	file_bridge_proto_rawDescData []byte
+)
+
+func file_bridge_proto_rawDescGZIP() []byte {
+	file_bridge_proto_rawDescOnce.Do(func() {
+		file_bridge_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bridge_proto_rawDesc), len(file_bridge_proto_rawDesc)))
+	})
+	return file_bridge_proto_rawDescData
+}
+
+var file_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_bridge_proto_goTypes = []any{
+	(*AskRequest)(nil),     // 0: ccb.v1.AskRequest
+	(*AskEvent)(nil),       // 1: ccb.v1.AskEvent
+	(*Chunk)(nil),          // 2: ccb.v1.Chunk
+	(*AskResult)(nil),      // 3: ccb.v1.AskResult
+	(*PingRequest)(nil),    // 4: ccb.v1.PingRequest
+	(*PingResponse)(nil),   // 5: ccb.v1.PingResponse
+	(*PendRequest)(nil),    // 6: ccb.v1.PendRequest
+	(*PendResponse)(nil),   // 7: ccb.v1.PendResponse
+	(*StatusRequest)(nil),  // 8: ccb.v1.StatusRequest
+	(*StorageCheck)(nil),   // 9: ccb.v1.StorageCheck
+	(*PauseInfo)(nil),      // 10: ccb.v1.PauseInfo
+	(*StatusResponse)(nil), // 11: ccb.v1.StatusResponse
+	(*CancelRequest)(nil),  // 12: ccb.v1.CancelRequest
+	(*CancelResponse)(nil), // 13: ccb.v1.CancelResponse
+	nil,                    // 14: ccb.v1.StatusResponse.OnlineEntry
+	nil,                    // 15: ccb.v1.StatusResponse.PausedEntry
+}
+var file_bridge_proto_depIdxs = []int32{
+	2,  // 0: ccb.v1.AskEvent.chunk:type_name -> ccb.v1.Chunk
+	3,  // 1: ccb.v1.AskEvent.result:type_name -> ccb.v1.AskResult
+	14, // 2: ccb.v1.StatusResponse.online:type_name -> ccb.v1.StatusResponse.OnlineEntry
+	9,  // 3: ccb.v1.StatusResponse.storage:type_name -> ccb.v1.StorageCheck
+	15, // 4: ccb.v1.StatusResponse.paused:type_name -> ccb.v1.StatusResponse.PausedEntry
+	10, // 5: ccb.v1.StatusResponse.PausedEntry.value:type_name -> ccb.v1.PauseInfo
+	0,  // 6: ccb.v1.Bridge.Ask:input_type -> ccb.v1.AskRequest
+	4,  // 7: ccb.v1.Bridge.Ping:input_type -> ccb.v1.PingRequest
+	6,  // 8: ccb.v1.Bridge.Pend:input_type -> ccb.v1.PendRequest
+	8,  // 9: ccb.v1.Bridge.Status:input_type -> ccb.v1.StatusRequest
+	12, // 10: ccb.v1.Bridge.Cancel:input_type -> ccb.v1.CancelRequest
+	1,  // 11: ccb.v1.Bridge.Ask:output_type -> ccb.v1.AskEvent
+	5,  // 12: ccb.v1.Bridge.Ping:output_type -> ccb.v1.PingResponse
+	7,  // 13: ccb.v1.Bridge.Pend:output_type -> ccb.v1.PendResponse
+	11, // 14: ccb.v1.Bridge.Status:output_type -> ccb.v1.StatusResponse
+	13, // 15: ccb.v1.Bridge.Cancel:output_type -> ccb.v1.CancelResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_bridge_proto_init() }
+func file_bridge_proto_init() {
+	if File_bridge_proto != nil {
+		return
+	}
+	file_bridge_proto_msgTypes[1].OneofWrappers = []any{
+		(*AskEvent_Chunk)(nil),
+		(*AskEvent_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bridge_proto_rawDesc), len(file_bridge_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bridge_proto_goTypes,
+		DependencyIndexes: file_bridge_proto_depIdxs,
+		MessageInfos:      file_bridge_proto_msgTypes,
+	}.Build()
+	File_bridge_proto = out.File
+	file_bridge_proto_goTypes = nil
+	file_bridge_proto_depIdxs = nil
+}
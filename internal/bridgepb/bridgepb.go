@@ -0,0 +1,5 @@
+// Package bridgepb holds the Go bindings for bridge.proto, the gRPC
+// service the daemon serves alongside its TCP listener.
+package bridgepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative bridge.proto
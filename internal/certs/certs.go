@@ -0,0 +1,286 @@
+// Package certs keeps the daemon's self-generated TLS material: a private
+// CA, a server certificate signed by it, and client certificates for the
+// local CLI and for remote clients (ccb daemon cert). The daemon requires
+// a client certificate from this CA on TLS connections.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validity periods; a leaf within renewBefore of expiry is reissued.
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 2 * 365 * 24 * time.Hour
+	renewBefore  = 30 * 24 * time.Hour
+)
+
+// LocalClient names the client certificate the ccb CLI itself uses.
+const LocalClient = "ccb"
+
+// Dir returns the TLS directory under runDir.
+func Dir(runDir string) string {
+	return filepath.Join(runDir, "askd-tls")
+}
+
+// CA is the daemon's certificate authority.
+type CA struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+	PEM  []byte
+}
+
+// LoadOrCreateCA reads the CA from dir, creating it on first use.
+func LoadOrCreateCA(dir string) (*CA, error) {
+	certPath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	if cert, key, certPEM, err := loadPair(certPath, keyPath); err == nil {
+		return &CA{Cert: cert, Key: key, PEM: certPEM}, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial(),
+		Subject:               pkix.Name{CommonName: "ccb daemon CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, _ := x509.ParseCertificate(der)
+	certPEM, err := savePair(certPath, keyPath, der, key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, Key: key, PEM: certPEM}, nil
+}
+
+// Issue signs a new leaf certificate for name. Server certificates carry
+// hosts as DNS or IP names.
+func (ca *CA) Issue(name string, server bool, hosts []string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial(),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		for _, h := range hosts {
+			if ip := net.ParseIP(h); ip != nil {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			} else {
+				tmpl.DNSNames = append(tmpl.DNSNames, h)
+			}
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// ServerHosts returns the names the server certificate covers: localhost,
+// the loopback addresses, this machine's hostname, bindHost unless it is a
+// wildcard, and extra.
+func ServerHosts(bindHost string, extra []string) []string {
+	set := map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true}
+	if h, err := os.Hostname(); err == nil && h != "" {
+		set[strings.ToLower(h)] = true
+	}
+	if bindHost = strings.Trim(strings.TrimSpace(bindHost), "[]"); bindHost != "" && bindHost != "0.0.0.0" && bindHost != "::" {
+		set[strings.ToLower(bindHost)] = true
+	}
+	for _, e := range extra {
+		if e = strings.TrimSpace(e); e != "" {
+			set[strings.ToLower(e)] = true
+		}
+	}
+	hosts := make([]string, 0, len(set))
+	for h := range set {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// ServerConfig returns the daemon's TLS config: a server certificate for
+// hosts (reissued when missing, expiring or not covering them) and
+// mandatory client certificates signed by the CA in dir. It also makes
+// sure the local CLI has its client certificate.
+func ServerConfig(dir string, hosts []string) (*tls.Config, error) {
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		return nil, err
+	}
+	server, err := ensureLeaf(ca, dir, "server", true, hosts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ensureLeaf(ca, dir, LocalClient, false, nil); err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return &tls.Config{
+		Certificates: []tls.Certificate{server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig returns the local CLI's TLS config for the daemon: its
+// client certificate and the CA in dir as the only trusted root.
+func ClientConfig(dir string) (*tls.Config, error) {
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("daemon CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("daemon CA: no certificate in %s", filepath.Join(dir, "ca.pem"))
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, LocalClient+".pem"), filepath.Join(dir, LocalClient+"-key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ensureLeaf loads dir/<name>.pem, issuing a new one when it is missing,
+// close to expiry, not from ca, or (for servers) missing one of hosts.
+func ensureLeaf(ca *CA, dir, name string, server bool, hosts []string) (tls.Certificate, error) {
+	certPath, keyPath := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err == nil && leafUsable(ca, leaf, hosts) {
+			return pair, nil
+		}
+	}
+	certPEM, keyPEM, err := ca.Issue(name, server, hosts)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFile(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFile(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// leafUsable reports whether leaf was signed by ca, is not about to
+// expire and covers hosts.
+func leafUsable(ca *CA, leaf *x509.Certificate, hosts []string) bool {
+	if leaf.CheckSignatureFrom(ca.Cert) != nil || time.Until(leaf.NotAfter) < renewBefore {
+		return false
+	}
+	for _, h := range hosts {
+		if leaf.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPair reads a PEM certificate and EC key.
+func loadPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, []byte, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cb, _ := pem.Decode(certPEM)
+	kb, _ := pem.Decode(keyPEM)
+	if cb == nil || kb == nil {
+		return nil, nil, nil, fmt.Errorf("%s: not PEM", certPath)
+	}
+	cert, err := x509.ParseCertificate(cb.Bytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(kb.Bytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cert, key, certPEM, nil
+}
+
+// savePair writes a certificate and its key as PEM and returns the
+// certificate PEM.
+func savePair(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) ([]byte, error) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := writeFile(certPath, certPEM, 0644); err != nil {
+		return nil, err
+	}
+	if err := writeFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, err
+	}
+	return certPEM, nil
+}
+
+// writeFile writes data atomically, creating the directory.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// serial returns a random certificate serial number.
+func serial() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 120))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return n
+}
@@ -0,0 +1,146 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// handshake runs a TLS handshake between server and client configs over
+// an in-memory pipe and returns the server's view and the client's error.
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, error) {
+	t.Helper()
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+	done := make(chan tls.ConnectionState, 1)
+	go func() {
+		srv := tls.Server(s, server)
+		srv.Handshake()
+		done <- srv.ConnectionState()
+		srv.Close()
+	}()
+	cli := tls.Client(c, client)
+	err := cli.Handshake()
+	if err == nil {
+		// TLS 1.3 reports a rejected client certificate on the first read.
+		_, err = cli.Read(make([]byte, 1))
+		if err != nil && err.Error() == "EOF" {
+			err = nil
+		}
+	}
+	cli.Close()
+	return <-done, err
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	hosts := ServerHosts("10.0.0.5", []string{"ccb.example"})
+	server, err := ServerConfig(dir, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "ca-key.pem")); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("ca-key.pem: %v %v", fi, err)
+	}
+
+	local, err := ClientConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local.ServerName = "127.0.0.1"
+	state, err := handshake(t, server, local)
+	if err != nil {
+		t.Fatalf("local client: %v", err)
+	}
+	if len(state.VerifiedChains) == 0 || state.VerifiedChains[0][0].Subject.CommonName != LocalClient {
+		t.Errorf("server saw %+v", state.VerifiedChains)
+	}
+
+	// A remote client with a certificate from "ccb daemon cert".
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := ca.Issue("laptop", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.PEM)
+	remote := &tls.Config{Certificates: []tls.Certificate{pair}, RootCAs: roots, ServerName: "ccb.example"}
+	if state, err := handshake(t, server, remote); err != nil || state.VerifiedChains[0][0].Subject.CommonName != "laptop" {
+		t.Errorf("remote client: %v", err)
+	}
+
+	// No client certificate, or one from another CA: refused.
+	anon := &tls.Config{RootCAs: roots, ServerName: "ccb.example"}
+	if _, err := handshake(t, server, anon); err == nil {
+		t.Error("client without a certificate was accepted")
+	}
+	other, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, _ = other.Issue("mallory", false, nil)
+	pair, _ = tls.X509KeyPair(certPEM, keyPEM)
+	if _, err := handshake(t, server, &tls.Config{Certificates: []tls.Certificate{pair}, RootCAs: roots, ServerName: "ccb.example"}); err == nil {
+		t.Error("client certificate from another CA was accepted")
+	}
+}
+
+func TestServerCertReissue(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ServerConfig(dir, ServerHosts("", nil)); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(filepath.Join(dir, "server.pem"))
+	caFirst, _ := os.ReadFile(filepath.Join(dir, "ca.pem"))
+
+	if _, err := ServerConfig(dir, ServerHosts("", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, "server.pem")); !bytes.Equal(first, again) {
+		t.Error("server certificate reissued without a reason")
+	}
+
+	if _, err := ServerConfig(dir, ServerHosts("192.168.1.20", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, "server.pem")); bytes.Equal(first, again) {
+		t.Error("server certificate not reissued for a new bind address")
+	}
+	if caAgain, _ := os.ReadFile(filepath.Join(dir, "ca.pem")); !bytes.Equal(caFirst, caAgain) {
+		t.Error("CA changed")
+	}
+}
+
+func TestServerHosts(t *testing.T) {
+	for _, bind := range []string{"", "0.0.0.0", "::"} {
+		for _, h := range ServerHosts(bind, nil) {
+			if h == "0.0.0.0" || h == "::" || h == "" {
+				t.Errorf("ServerHosts(%q) includes %q", bind, h)
+			}
+		}
+	}
+	hosts := ServerHosts("[fd00::1]", []string{" Box.Lan "})
+	want := map[string]bool{"localhost": false, "127.0.0.1": false, "::1": false, "fd00::1": false, "box.lan": false}
+	for _, h := range hosts {
+		if _, ok := want[h]; ok {
+			want[h] = true
+		}
+	}
+	for h, seen := range want {
+		if !seen {
+			t.Errorf("ServerHosts missing %q: %v", h, hosts)
+		}
+	}
+}
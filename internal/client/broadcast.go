@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// SplitProviders splits a comma-separated provider argument, dropping
+// blanks and duplicates while keeping the given order.
+func SplitProviders(arg string) []string {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(arg, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// CompleteProviderList returns shell completions for a comma-separated
+// provider list being typed: candidates matching the part after the last
+// comma, prefixed with what precedes it. Providers already in the list are
+// not offered again.
+func CompleteProviderList(toComplete string, candidates []string) []string {
+	head, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		head, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	listed := make(map[string]bool)
+	for _, p := range SplitProviders(head) {
+		listed[p] = true
+	}
+	var out []string
+	for _, c := range candidates {
+		if !listed[c] && strings.HasPrefix(c, strings.ToLower(last)) {
+			out = append(out, head+c)
+		}
+	}
+	return out
+}
+
+// Broadcast sends the same request to every provider concurrently and
+// returns one result per provider, in the order given. The daemon fans
+// the ask out (broadcast method); daemons without that method get one
+// ask per provider. Transport errors are folded into the results so one
+// unreachable provider doesn't hide the others' replies.
+func Broadcast(req AskRequest, providers []string) []*AskResult {
+	results, err := daemonBroadcast(req, providers)
+	if errors.Is(err, errNoBroadcast) {
+		return broadcastEach(req, providers)
+	}
+	if err != nil {
+		results = make([]*AskResult, len(providers))
+		for i, provider := range providers {
+			results[i] = &AskResult{Provider: provider, ExitCode: ExitCode(err), Error: err.Error()}
+		}
+	}
+	return results
+}
+
+// BroadcastReqID is the req_id of provider's ask in a broadcast whose
+// req_id is base, as the daemon assigns it.
+func BroadcastReqID(base, provider string) string {
+	if base == "" {
+		return ""
+	}
+	return base + "-" + provider
+}
+
+// errNoBroadcast reports a daemon that predates the broadcast method.
+var errNoBroadcast = errors.New("daemon has no broadcast method")
+
+// daemonBroadcast sends req to providers in one broadcast request.
+func daemonBroadcast(req AskRequest, providers []string) ([]*AskResult, error) {
+	state, err := readOrStartState()
+	if err != nil {
+		return nil, &DaemonError{Err: err}
+	}
+
+	// Each provider has its own work dir and default timeout; the daemon
+	// applies the timeout.
+	timeoutS, workDir := req.TimeoutS, req.WorkDir
+	req.Provider = providers[0]
+	req = prepareAsk(req)
+	workDirs := make(map[string]string)
+	longest := timeoutS
+	for _, p := range providers {
+		r := req
+		r.Provider, r.WorkDir, r.TimeoutS = p, workDir, timeoutS
+		r = prepareAsk(r)
+		if r.WorkDir != req.WorkDir {
+			workDirs[p] = r.WorkDir
+		}
+		if r.TimeoutS > longest {
+			longest = r.TimeoutS
+		}
+	}
+	req.TimeoutS = timeoutS
+	req.OnChunk = nil
+	params := askParams("broadcast", state.Token, req)
+	delete(params, "provider")
+	delete(params, "stream")
+	params["providers"] = providers
+	if len(workDirs) > 0 {
+		params["work_dirs"] = workDirs
+	}
+	output.Debugf("broadcast to %s: work_dir=%s via %s", strings.Join(providers, ","), req.WorkDir, state.Address())
+
+	conn, err := dialDaemon(state, 5*time.Second)
+	if err != nil {
+		return nil, &DaemonError{Err: fmt.Errorf("cannot connect to daemon: %w", err)}
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Duration(config.StartupTimeout(req.WorkDir)+longest+15) * time.Second))
+	data, _ := json.Marshal(params)
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("cannot send request: %w", err)
+	}
+
+	var resp struct {
+		Status  string                            `json:"status"`
+		Error   string                            `json:"error"`
+		Results map[string]adapter.ProviderResult `json:"results"`
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Status != "ok" {
+		if strings.HasPrefix(resp.Error, "unknown method") {
+			return nil, errNoBroadcast
+		}
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	results := make([]*AskResult, len(providers))
+	for i, p := range providers {
+		r, ok := resp.Results[p]
+		if !ok {
+			r = adapter.ProviderResult{ExitCode: output.ExitError, Error: "no result from daemon"}
+		}
+		results[i] = askResult(p, &r)
+	}
+	return results, nil
+}
+
+// broadcastEach sends req to each provider over its own connection.
+func broadcastEach(req AskRequest, providers []string) []*AskResult {
+	results := make([]*AskResult, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider string) {
+			defer wg.Done()
+			r := req
+			r.Provider = provider
+			r.ReqID = BroadcastReqID(req.ReqID, provider)
+			result, err := Ask(r)
+			if err != nil {
+				result = &AskResult{Provider: provider, ExitCode: ExitCode(err), Error: err.Error()}
+			}
+			results[i] = result
+		}(i, provider)
+	}
+	wg.Wait()
+	return results
+}
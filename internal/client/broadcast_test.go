@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitProviders(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"codex", []string{"codex"}},
+		{"codex,claude,gemini", []string{"codex", "claude", "gemini"}},
+		{" Codex , claude,,codex ", []string{"codex", "claude"}},
+		{",", nil},
+	}
+	for _, tt := range tests {
+		if got := SplitProviders(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitProviders(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteProviderList(t *testing.T) {
+	known := []string{"claude", "codex", "droid", "gemini", "opencode"}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", known},
+		{"c", []string{"claude", "codex"}},
+		{"Co", []string{"codex"}},
+		{"codex,", []string{"codex,claude", "codex,droid", "codex,gemini", "codex,opencode"}},
+		{"codex,claude,c", nil},
+		{"gemini,o", []string{"gemini,opencode"}},
+		{"x", nil},
+	}
+	for _, tt := range tests {
+		if got := CompleteProviderList(tt.in, known); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CompleteProviderList(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewMultiResult(t *testing.T) {
+	started := time.Now().Add(-2 * time.Second)
+	m := NewMultiResult("broadcast", "20260125-143000-123-42", started, []*AskResult{
+		{Provider: "codex", Reply: "a", ReqID: "20260125-143000-123-42-codex"},
+		{Provider: "gemini", ExitCode: 2, Error: "timeout"},
+		{Provider: "claude", ExitCode: 1, Error: "offline"},
+	})
+	if m.GroupID != "20260125-143000-123-42" || m.Operation != "broadcast" {
+		t.Errorf("group_id/operation = %q/%q", m.GroupID, m.Operation)
+	}
+	if !reflect.DeepEqual(m.Providers, []string{"codex", "gemini", "claude"}) {
+		t.Errorf("providers = %v", m.Providers)
+	}
+	if m.Results["codex"].Reply != "a" || len(m.Results) != 3 {
+		t.Errorf("results = %v", m.Results)
+	}
+	wantFailures := []Failure{{"gemini", 2, "timeout"}, {"claude", 1, "offline"}}
+	if !reflect.DeepEqual(m.Failures, wantFailures) {
+		t.Errorf("failures = %+v", m.Failures)
+	}
+	if m.ExitCode != 2 {
+		t.Errorf("exit_code = %d, want 2", m.ExitCode)
+	}
+	if m.ElapsedMs < 2000 {
+		t.Errorf("elapsed_ms = %d", m.ElapsedMs)
+	}
+
+	ok := NewMultiResult("compare", "20260125-143000-123-42", started, []*AskResult{{Provider: "codex"}})
+	data, _ := json.Marshal(ok)
+	if !strings.Contains(string(data), `"failures":[]`) {
+		t.Errorf("no failures should encode as []: %s", data)
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"os"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// CallerClaude is the caller of asks made from inside Claude Code: its
+// Bash tool, hooks and slash commands.
+const CallerClaude = "claude"
+
+// DetectCaller names the program running ccb: $CCB_CALLER when set, else
+// "claude" inside Claude Code (which sets CLAUDECODE=1), else "".
+func DetectCaller() string {
+	if c := strings.TrimSpace(os.Getenv("CCB_CALLER")); c != "" {
+		return strings.ToLower(c)
+	}
+	if os.Getenv("CLAUDECODE") == "1" {
+		return CallerClaude
+	}
+	return ""
+}
+
+// paneCWDer is implemented by backends that can report the working
+// directory of a pane.
+type paneCWDer interface {
+	PaneCWD(paneID string) (string, error)
+}
+
+// CallerWorkDir returns the working directory of the pane ccb is running
+// in ($TMUX_PANE or $WEZTERM_PANE). Claude Code runs commands from
+// whatever subdirectory its shell has moved to, but its pane stays in the
+// directory the session started in, which is the project. It returns ""
+// when there is no such pane or its directory cannot be read.
+func CallerWorkDir() string {
+	backend, err := terminal.DetectBackend()
+	if err != nil {
+		return ""
+	}
+	p, ok := backend.(paneCWDer)
+	if !ok {
+		return ""
+	}
+	var paneID string
+	switch backend.Name() {
+	case "tmux":
+		paneID = os.Getenv("TMUX_PANE")
+	case "wezterm":
+		paneID = os.Getenv("WEZTERM_PANE")
+	}
+	if paneID == "" {
+		return ""
+	}
+	dir, err := p.PaneCWD(paneID)
+	if err != nil || dir == "" {
+		output.Debugf("cannot read the work dir of pane %s: %v", paneID, err)
+		return ""
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,22 @@
+package client
+
+import "testing"
+
+func TestDetectCaller(t *testing.T) {
+	tests := []struct {
+		ccbCaller, claudeCode, want string
+	}{
+		{"", "", ""},
+		{"", "1", CallerClaude},
+		{"Codex", "1", "codex"},
+		{" hook ", "", "hook"},
+		{"", "0", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("CCB_CALLER", tt.ccbCaller)
+		t.Setenv("CLAUDECODE", tt.claudeCode)
+		if got := DetectCaller(); got != tt.want {
+			t.Errorf("CCB_CALLER=%q CLAUDECODE=%q: DetectCaller() = %q, want %q", tt.ccbCaller, tt.claudeCode, got, tt.want)
+		}
+	}
+}
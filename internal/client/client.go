@@ -4,17 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
 	"time"
 
+	"github.com/anthropics/claude_code_bridge/internal/config"
 	"github.com/anthropics/claude_code_bridge/internal/daemon"
-	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/lock"
+	"github.com/anthropics/claude_code_bridge/internal/output"
 	"github.com/anthropics/claude_code_bridge/internal/protocol"
-	ccbruntime "github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/session"
 )
 
 // AskRequest represents a client-side ask request.
@@ -25,120 +27,99 @@ type AskRequest struct {
 	TimeoutS float64
 	Quiet    bool
 	Caller   string
+	Quick    bool   // pane-capture-only reply extraction
+	Queue    bool   // hold the ask in the daemon if the provider is offline
+	ReqID    string // request ID to use (default: a fresh protocol.MakeReqID)
+
+	OutputPath string // the daemon writes the reply here (absolute path)
+	Record     bool   // the daemon records pane input and snapshots (ccb replay-io)
+	Priority   string // "interactive" or "background"; empty uses CCB_PRIORITY
+
+	DeliverAt time.Time     // schedule: the daemon sends the ask at this time
+	TTL       time.Duration // drop the queued ask if not sent within TTL of being due
+
+	// OnChunk, if set, streams the reply: it receives completed lines as
+	// the provider writes them, before the final result arrives.
+	OnChunk func(lines []string)
 }
 
-// AskResult represents a client-side ask result.
+// AskResult represents a client-side ask result. Beyond the reply it
+// carries the daemon's completion details for --json output.
 type AskResult struct {
-	ExitCode int
-	Reply    string
-	ReqID    string
-	Error    string
+	Provider     string `json:"provider"`
+	ExitCode     int    `json:"exit_code"`
+	Reply        string `json:"reply"`
+	ReqID        string `json:"req_id"`
+	Error        string `json:"error,omitempty"`
+	SessionKey   string `json:"session_key,omitempty"`
+	LogPath      string `json:"log_path,omitempty"`
+	AnchorSeen   bool   `json:"anchor_seen"`
+	DoneSeen     bool   `json:"done_seen"`
+	FallbackScan bool   `json:"fallback_scan"`
+	AnchorMs     int64  `json:"anchor_ms,omitempty"`
+	DoneMs       int64  `json:"done_ms,omitempty"`
+	StartupMs    int64  `json:"startup_ms,omitempty"`
+	ReplyMs      int64  `json:"reply_ms,omitempty"`
+	Queued       bool   `json:"queued,omitempty"`
+	OutputPath   string `json:"output_path,omitempty"`
+	Recording    string `json:"recording,omitempty"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"`
+
+	DoneHeuristic bool   `json:"done_heuristic,omitempty"`
+	DoneReason    string `json:"done_reason,omitempty"`
 }
 
 // Ask sends a request to the daemon and returns the result.
 func Ask(req AskRequest) (*AskResult, error) {
-	state, err := ReadState("")
+	c, err := Dial()
 	if err != nil {
-		// Try to auto-start daemon
-		if startErr := MaybeStartDaemon(); startErr != nil {
-			return nil, fmt.Errorf("daemon not running and auto-start failed: %w", startErr)
-		}
-		state, err = ReadState("")
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
+	defer c.Close()
+	return c.Ask(req)
+}
 
-	if req.WorkDir == "" {
-		req.WorkDir = ResolveWorkDir(req.Provider)
+// readOrStartState returns the daemon state, auto-starting the daemon if
+// it isn't running.
+func readOrStartState() (*daemon.DaemonState, error) {
+	state, err := ReadState("")
+	if err == nil {
+		return state, nil
 	}
-	if req.TimeoutS == 0 {
-		req.TimeoutS = 120
+	output.Debugf("daemon state unavailable (%v); starting the daemon", err)
+	if startErr := MaybeStartDaemon(); startErr != nil {
+		return nil, fmt.Errorf("daemon not running and auto-start failed: %w", startErr)
 	}
-
-	reqID := protocol.MakeReqID()
-
-	host := ccbruntime.NormalizeConnectHost(state.Host)
-	addr := fmt.Sprintf("%s:%d", host, state.Port)
-
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("cannot connect to daemon: %w", err)
-	}
-	defer conn.Close()
-
-	totalTimeout := time.Duration(req.TimeoutS+15) * time.Second
-	conn.SetDeadline(time.Now().Add(totalTimeout))
-
-	// Send request
-	rpcReq := map[string]interface{}{
-		"method":    "request",
-		"token":     state.Token,
-		"provider":  req.Provider,
-		"client_id": fmt.Sprintf("cli-%d", os.Getpid()),
-		"work_dir":  req.WorkDir,
-		"message":   req.Message,
-		"req_id":    reqID,
-		"timeout_s": req.TimeoutS,
-		"quiet":     req.Quiet,
-		"caller":    req.Caller,
-	}
-
-	data, _ := json.Marshal(rpcReq)
-	conn.Write(data)
-	conn.Write([]byte("\n"))
-
-	// Read response
-	decoder := json.NewDecoder(conn)
-	var result adapter.ProviderResult
-	if err := decoder.Decode(&result); err != nil {
-		return nil, fmt.Errorf("invalid response: %w", err)
-	}
-
-	return &AskResult{
-		ExitCode: result.ExitCode,
-		Reply:    result.Reply,
-		ReqID:    result.ReqID,
-		Error:    result.Error,
-	}, nil
+	return ReadState("")
 }
 
 // Ping pings a specific provider through the daemon.
 func Ping(provider string) error {
-	state, err := ReadState("")
-	if err != nil {
-		return fmt.Errorf("daemon not running")
-	}
-
-	resp, err := sendRequest(state, map[string]interface{}{
-		"method":   "ping",
-		"token":    state.Token,
-		"provider": provider,
-	})
-	if err != nil {
-		return err
-	}
-
-	status, _ := resp["status"].(string)
-	if status != "ok" {
-		errMsg, _ := resp["error"].(string)
-		return fmt.Errorf("%s", errMsg)
-	}
-	return nil
+	return call("ping", map[string]interface{}{"provider": provider}, nil)
 }
 
 // Pend retrieves the latest reply from a provider.
 func Pend(provider string) (string, error) {
+	return PendN(provider, 1)
+}
+
+// PendN retrieves a provider's nth most recent reply (1 is the latest).
+// Replies before the latest come from the daemon's reply store.
+func PendN(provider string, n int) (string, error) {
 	state, err := ReadState("")
 	if err != nil {
 		return "", fmt.Errorf("daemon not running")
 	}
 
-	resp, err := sendRequest(state, map[string]interface{}{
+	req := map[string]interface{}{
 		"method":   "pend",
 		"token":    state.Token,
 		"provider": provider,
-	})
+	}
+	if n > 1 {
+		req["n"] = n
+	}
+	resp, err := sendRequest(state, req)
 	if err != nil {
 		return "", err
 	}
@@ -147,19 +128,133 @@ func Pend(provider string) (string, error) {
 	return reply, nil
 }
 
+// PendLatest retrieves the most recent reply from any provider, and the
+// provider that gave it, from the daemon's reply store.
+func PendLatest() (reply string, provider string, err error) {
+	var out protocol.PendResponse
+	if err := call("pend", nil, &out); err != nil {
+		return "", "", err
+	}
+	return out.Reply, out.Provider, nil
+}
+
+// PendReq retrieves the reply to a specific request by req_id, along with
+// the provider that produced it.
+func PendReq(reqID string) (reply string, provider string, err error) {
+	var out protocol.PendResponse
+	if err := call("pend", map[string]interface{}{"req_id": reqID}, &out); err != nil {
+		return "", "", err
+	}
+	return out.Reply, out.Provider, nil
+}
+
+// ListRequests returns the daemon's in-flight and queued asks, oldest
+// first.
+func ListRequests() ([]protocol.RequestInfo, error) {
+	var out protocol.RequestsResponse
+	if err := call("requests", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Requests, nil
+}
+
+// CancelRequest cancels the in-flight ask reqID, or drops it from the
+// offline queue. It returns the daemon's state: "canceled" or "dequeued".
+func CancelRequest(reqID string) (string, error) {
+	var out protocol.CancelResponse
+	if err := call("cancel", map[string]interface{}{"req_id": reqID}, &out); err != nil {
+		return "", err
+	}
+	return out.State, nil
+}
+
+// PauseProvider makes the daemon refuse asks to provider until
+// ResumeProvider; reason is shown in the PAUSED error. It returns the
+// providers paused afterwards.
+func PauseProvider(provider, reason string) (map[string]protocol.PauseInfo, error) {
+	var out protocol.PauseResponse
+	if err := call("pause", map[string]interface{}{"provider": provider, "reason": reason}, &out); err != nil {
+		return nil, err
+	}
+	return out.Paused, nil
+}
+
+// ResumeProvider lifts a pause, returning the providers still paused.
+func ResumeProvider(provider string) (map[string]protocol.PauseInfo, error) {
+	var out protocol.PauseResponse
+	if err := call("resume", map[string]interface{}{"provider": provider}, &out); err != nil {
+		return nil, err
+	}
+	return out.Paused, nil
+}
+
+// ReloadDaemon makes the daemon re-read its providers and limits from
+// ccb.config, keeping its listener and in-memory state.
+func ReloadDaemon() (*protocol.ReloadResponse, error) {
+	var out protocol.ReloadResponse
+	if err := call("reload", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RegisterProvider makes the running daemon serve provider, e.g. one
+// launched after it started. A custom provider is looked up in workDir's
+// ccb.config.
+func RegisterProvider(provider, workDir string) (*protocol.RegistrationResponse, error) {
+	var out protocol.RegistrationResponse
+	if err := call("register_provider", map[string]interface{}{"provider": provider, "work_dir": workDir}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnregisterProvider makes the daemon stop taking asks for provider.
+func UnregisterProvider(provider string) (*protocol.RegistrationResponse, error) {
+	var out protocol.RegistrationResponse
+	if err := call("unregister_provider", map[string]interface{}{"provider": provider}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateToken makes the daemon issue a new token, which it writes to the
+// state file; the old one stays valid for grace. It returns when the old
+// one expires.
+func RotateToken(grace time.Duration) (time.Time, error) {
+	var out protocol.RotateTokenResponse
+	if err := call("rotate_token", map[string]interface{}{"grace_s": grace.Seconds()}, &out); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, out.PreviousValidUntil)
+}
+
 // MaybeStartDaemon starts the daemon if it's not already running.
 func MaybeStartDaemon() error {
-	// Check if already running
-	state, err := ReadState("")
-	if err == nil {
-		if PingDaemon(state) == nil {
-			return nil // Already running
-		}
+	if daemonAlive() {
+		return nil
+	}
+
+	// Only one client spawns the daemon at a time; the others wait here
+	// and find it running once they get the lock.
+	l := lock.NewFileLock(runtime.LockPath("askd-start"), 15*time.Second)
+	if !l.Acquire() {
+		return fmt.Errorf("timeout waiting for another client to start the daemon")
+	}
+	defer l.Release()
+	if daemonAlive() {
+		return nil
 	}
 
 	return MaybeStartDaemonDetached()
 }
 
+// daemonAlive reports whether the daemon in the state file answers a ping.
+func daemonAlive() bool {
+	state, err := ReadState("")
+	return err == nil && PingDaemon(state) == nil
+}
+
 // MaybeStartDaemonDetached starts the daemon as a detached background process.
 // On Windows, uses CREATE_NO_WINDOW / DETACHED_PROCESS flags.
 func MaybeStartDaemonDetached() error {
@@ -262,7 +357,7 @@ func ResolveWorkDirWithRegistry(provider string) string {
 	}
 
 	// Try registry file
-	registryPath := filepath.Join(ccbruntime.RunDir(), "pane-registry.json")
+	registryPath := session.RegistryPath()
 	if _, err := os.Stat(registryPath); err != nil {
 		return cwd
 	}
@@ -278,13 +373,14 @@ func ResolveWorkDirWithRegistry(provider string) string {
 		} `json:"providers"`
 	}
 	if err := json.Unmarshal(data, &registry); err != nil {
+		output.Debugf("ignoring unreadable pane registry %s: %v", registryPath, err)
 		return cwd
 	}
 
 	if provMap, ok := registry.Providers[provider]; ok {
 		for _, entry := range provMap {
 			if entry.WorkDir != "" {
-				return entry.WorkDir
+				return config.RemapPath(entry.WorkDir, config.LoadPathRemaps())
 			}
 		}
 	}
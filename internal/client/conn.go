@@ -0,0 +1,274 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// Conn is a daemon connection that carries many asks, so interactive
+// callers don't dial once per message. Asks may run concurrently: each is
+// sent with an id and the daemon answers them as they finish.
+type Conn struct {
+	state *daemon.DaemonState
+	conn  net.Conn
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[string]*pendingAsk // by request id
+	order   []string               // pending ids in send order
+	err     error                  // why the connection is unusable, once it is
+	broken  chan struct{}          // closed when err is set
+}
+
+// pendingAsk is an ask sent over a Conn and not yet answered.
+type pendingAsk struct {
+	onChunk func([]string)
+	result  chan *adapter.ProviderResult // buffered, so an abandoned ask doesn't block the reader
+}
+
+// Dial connects to the daemon, auto-starting it if needed.
+func Dial() (*Conn, error) {
+	state, err := readOrStartState()
+	if err != nil {
+		return nil, &DaemonError{Err: err}
+	}
+	conn, err := dialDaemon(state, 5*time.Second)
+	if err != nil {
+		return nil, &DaemonError{Err: fmt.Errorf("cannot connect to daemon: %w", err)}
+	}
+	return newConn(state, conn), nil
+}
+
+// newConn wraps conn, a connection to the daemon described by state.
+func newConn(state *daemon.DaemonState, conn net.Conn) *Conn {
+	c := &Conn{
+		state:   state,
+		conn:    conn,
+		pending: make(map[string]*pendingAsk),
+		broken:  make(chan struct{}),
+	}
+	go c.readLoop(json.NewDecoder(conn))
+	return c
+}
+
+// Close closes the connection; asks still waiting fail.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Err returns why the connection can no longer be used, or nil while it
+// can.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Ask sends one request over the connection and waits for its result.
+// It is safe to call from several goroutines at once.
+func (c *Conn) Ask(req AskRequest) (*AskResult, error) {
+	req = prepareAsk(req)
+	output.Debugf("ask %s: caller=%q work_dir=%s timeout=%gs via %s", req.Provider, req.Caller, req.WorkDir, req.TimeoutS, c.state.Address())
+
+	// The daemon budgets getting the prompt into the pane separately.
+	totalTimeout := time.Duration(config.StartupTimeout(req.WorkDir)+req.TimeoutS+15) * time.Second
+
+	p := &pendingAsk{onChunk: req.OnChunk, result: make(chan *adapter.ProviderResult, 1)}
+	if err := c.send(askParams("request", c.state.Token, req), p); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(totalTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-p.result:
+		return askResult(req.Provider, result), nil
+	case <-c.broken:
+		return nil, c.Err()
+	case <-timer.C:
+		return nil, fmt.Errorf("no response from daemon after %s", totalTimeout)
+	}
+}
+
+// send writes req, tagged with a fresh id, and registers p to receive its
+// responses.
+func (c *Conn) send(req map[string]interface{}, p *pendingAsk) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	req["id"] = id
+	data, _ := json.Marshal(req)
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot send request: %w", err)
+	}
+	c.pending[id] = p
+	c.order = append(c.order, id)
+	return nil
+}
+
+// readLoop passes each response to the ask it answers until the
+// connection fails. Daemons that predate pipelining answer in turn and
+// without ids; their responses go to the oldest ask pending.
+func (c *Conn) readLoop(dec *json.Decoder) {
+	for {
+		var msg streamMsg
+		if err := dec.Decode(&msg); err != nil {
+			c.fail(fmt.Errorf("invalid response: %w", err))
+			return
+		}
+		p := c.route(msg.ID, msg.Event != "chunk")
+		switch {
+		case p == nil:
+		case msg.Event == "chunk":
+			if p.onChunk != nil {
+				p.onChunk(msg.Lines)
+			}
+		default:
+			result := msg.ProviderResult
+			p.result <- &result
+		}
+	}
+}
+
+// route returns the pending ask a response with id belongs to, forgetting
+// it if the response is its last.
+func (c *Conn) route(id string, last bool) *pendingAsk {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id == "" && len(c.order) > 0 {
+		id = c.order[0]
+	}
+	p := c.pending[id]
+	if p != nil && last {
+		delete(c.pending, id)
+		for i, o := range c.order {
+			if o == id {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+	}
+	return p
+}
+
+// fail marks the connection unusable with err, failing asks still waiting.
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.broken)
+	}
+}
+
+// askResult converts the daemon's result for provider.
+func askResult(provider string, result *adapter.ProviderResult) *AskResult {
+	return &AskResult{
+		Provider:     provider,
+		ExitCode:     result.ExitCode,
+		Reply:        result.Reply,
+		ReqID:        result.ReqID,
+		Error:        result.Error,
+		SessionKey:   result.SessionKey,
+		LogPath:      result.LogPath,
+		AnchorSeen:   result.AnchorSeen,
+		DoneSeen:     result.DoneSeen,
+		FallbackScan: result.FallbackScan,
+		AnchorMs:     result.AnchorMs,
+		DoneMs:       result.DoneMs,
+		StartupMs:    result.StartupMs,
+		ReplyMs:      result.ReplyMs,
+		Queued:       result.Queued,
+		OutputPath:   result.OutputPath,
+		Recording:    result.Recording,
+		RetryAfterMs: result.RetryAfterMs,
+
+		DoneHeuristic: result.DoneHeuristic,
+		DoneReason:    result.DoneReason,
+	}
+}
+
+// streamMsg is a daemon response: a chunk event or the final result of
+// the request with ID.
+type streamMsg struct {
+	ID    string   `json:"id"`
+	Event string   `json:"event"`
+	Lines []string `json:"lines"`
+	adapter.ProviderResult
+}
+
+// prepareAsk fills in req's caller, work dir, timeout and req_id defaults.
+func prepareAsk(req AskRequest) AskRequest {
+	if req.Caller == "" {
+		req.Caller = DetectCaller()
+	}
+	if req.WorkDir == "" && req.Caller == CallerClaude {
+		// Hooks and slash commands may run from a subdirectory; the
+		// Claude pane knows the project.
+		req.WorkDir = CallerWorkDir()
+	}
+	if req.WorkDir == "" {
+		req.WorkDir = ResolveWorkDir(req.Provider)
+	}
+	if req.TimeoutS == 0 {
+		req.TimeoutS = config.AskTimeout(req.WorkDir, req.Provider)
+	}
+	if req.ReqID == "" {
+		req.ReqID = protocol.MakeReqID()
+	}
+	if req.Priority == "" {
+		req.Priority = strings.ToLower(config.EnvStr("CCB_PRIORITY", ""))
+	}
+	return req
+}
+
+// askParams builds the daemon message for req under method.
+func askParams(method, token string, req AskRequest) map[string]interface{} {
+	rpcReq := map[string]interface{}{
+		"method":    method,
+		"token":     token,
+		"provider":  req.Provider,
+		"client_id": fmt.Sprintf("cli-%d", os.Getpid()),
+		"work_dir":  req.WorkDir,
+		"message":   req.Message,
+		"req_id":    req.ReqID,
+		"timeout_s": req.TimeoutS,
+		"quiet":     req.Quiet,
+		"caller":    req.Caller,
+		"quick":     req.Quick,
+		"queue":     req.Queue,
+		"stream":    req.OnChunk != nil,
+	}
+	if !req.DeliverAt.IsZero() {
+		rpcReq["deliver_at"] = req.DeliverAt.Format(time.RFC3339)
+	}
+	if req.TTL > 0 {
+		rpcReq["ttl_s"] = req.TTL.Seconds()
+	}
+	if req.OutputPath != "" {
+		rpcReq["output_path"] = req.OutputPath
+	}
+	if req.Record {
+		rpcReq["record"] = true
+	}
+	if req.Priority != "" {
+		rpcReq["priority"] = req.Priority
+	}
+	return rpcReq
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon"
+)
+
+func TestConnConcurrentAsks(t *testing.T) {
+	tests := []struct {
+		name      string
+		pipelined bool // the daemon echoes ids and answers out of order
+	}{
+		{"pipelining daemon", true},
+		{"daemon answering in turn", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("USERPROFILE", home)
+			client, server := net.Pipe()
+			c := newConn(&daemon.DaemonState{Token: "tok"}, client)
+			defer c.Close()
+
+			go func() {
+				dec, enc := json.NewDecoder(server), json.NewEncoder(server)
+				var reqs []map[string]interface{}
+				for len(reqs) < 2 {
+					var req map[string]interface{}
+					if dec.Decode(&req) != nil {
+						return
+					}
+					reqs = append(reqs, req)
+				}
+				if tt.pipelined {
+					reqs[0], reqs[1] = reqs[1], reqs[0]
+				}
+				for _, req := range reqs {
+					chunk := map[string]interface{}{"event": "chunk", "lines": []string{"partial " + req["provider"].(string)}}
+					reply := map[string]interface{}{"exit_code": 0, "reply": "echo: " + req["message"].(string)}
+					if tt.pipelined {
+						chunk["id"], reply["id"] = req["id"], req["id"]
+					}
+					enc.Encode(chunk)
+					enc.Encode(reply)
+				}
+			}()
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			chunks := map[string][]string{}
+			replies := map[string]string{}
+			for _, p := range []string{"codex", "gemini"} {
+				wg.Add(1)
+				go func(p string) {
+					defer wg.Done()
+					req := AskRequest{Provider: p, Message: "hi " + p, WorkDir: home, TimeoutS: 5, Caller: "manual"}
+					req.OnChunk = func(lines []string) {
+						mu.Lock()
+						chunks[p] = append(chunks[p], lines...)
+						mu.Unlock()
+					}
+					r, err := c.Ask(req)
+					if err != nil {
+						t.Errorf("%s: %v", p, err)
+						return
+					}
+					mu.Lock()
+					replies[p] = r.Reply
+					mu.Unlock()
+				}(p)
+			}
+			wg.Wait()
+
+			for _, p := range []string{"codex", "gemini"} {
+				if replies[p] != "echo: hi "+p {
+					t.Errorf("%s reply = %q", p, replies[p])
+				}
+				if len(chunks[p]) != 1 || chunks[p][0] != "partial "+p {
+					t.Errorf("%s chunks = %q", p, chunks[p])
+				}
+			}
+			if c.Err() != nil {
+				t.Errorf("Err() = %v on a healthy connection", c.Err())
+			}
+		})
+	}
+}
+
+func TestConnFailsPendingAsks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	client, server := net.Pipe()
+	c := newConn(&daemon.DaemonState{Token: "tok"}, client)
+	go func() {
+		json.NewDecoder(server).Decode(&map[string]interface{}{})
+		server.Close()
+	}()
+	if _, err := c.Ask(AskRequest{Provider: "codex", Message: "hi", WorkDir: home, TimeoutS: 5, Caller: "manual"}); err == nil {
+		t.Fatal("Ask succeeded on a connection the daemon closed")
+	}
+	if c.Err() == nil {
+		t.Error("Err() = nil after the daemon closed the connection")
+	}
+	if _, err := c.Ask(AskRequest{Provider: "codex", Message: "again", WorkDir: home, TimeoutS: 5, Caller: "manual"}); err == nil {
+		t.Error("Ask succeeded on a failed connection")
+	}
+}
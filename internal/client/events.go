@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// errStopSubscription ends Subscribe without an error.
+var errStopSubscription = errors.New("stop subscription")
+
+// Subscribe passes the running daemon's state events of the given kinds
+// (all when none are given) to fn until fn returns an error, which
+// Subscribe returns, or the daemon goes away. A daemon that stops after
+// announcing it (shutting_down) ends the subscription without an error.
+func Subscribe(kinds []string, fn func(protocol.StateEvent) error) error {
+	state, err := ReadState("")
+	if err != nil {
+		return &DaemonError{Err: err}
+	}
+	conn, err := dialDaemon(state, 5*time.Second)
+	if err != nil {
+		return &DaemonError{Err: fmt.Errorf("cannot connect to daemon: %w", err)}
+	}
+	defer conn.Close()
+
+	req := map[string]interface{}{"method": "subscribe", "token": state.Token}
+	if len(kinds) > 0 {
+		req["events"] = kinds
+	}
+	data, _ := json.Marshal(req)
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot send request: %w", err)
+	}
+
+	dec := json.NewDecoder(conn)
+	err = readEvents(dec, fn)
+	if errors.Is(err, errStopSubscription) {
+		return nil
+	}
+	return err
+}
+
+// readEvents reads the subscribe acknowledgement, then events, from dec.
+func readEvents(dec *json.Decoder, fn func(protocol.StateEvent) error) error {
+	acked := false
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return &DaemonError{Err: errors.New("daemon closed the subscription")}
+			}
+			return &DaemonError{Err: fmt.Errorf("subscription lost: %w", err)}
+		}
+		var status protocol.ErrorResponse
+		json.Unmarshal(raw, &status)
+		switch {
+		case status.Status == "error":
+			return fmt.Errorf("%s", status.Error)
+		case !acked:
+			acked = true
+			continue
+		}
+		var ev protocol.StateEvent
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+		if ev.Event == "shutting_down" {
+			return errStopSubscription
+		}
+	}
+}
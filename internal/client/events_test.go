@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		stream     string
+		wantEvents []string
+		wantErr    string // "" for the daemon announcing its shutdown
+	}{
+		{
+			"events until shutdown",
+			`{"status":"ok","events":["request_started","shutting_down"]}
+{"event":"request_started","time":"2026-10-18T10:00:00Z","provider":"codex"}
+{"event":"shutting_down","time":"2026-10-18T10:00:01Z"}
+{"event":"request_started","time":"2026-10-18T10:00:02Z"}`,
+			[]string{"request_started", "shutting_down"}, "",
+		},
+		{"refused", `{"status":"error","error":"unknown event \"x\""}`, nil, `unknown event "x"`},
+		{
+			"dropped for falling behind",
+			`{"status":"ok","events":["pane_died"]}
+{"status":"error","error":"subscriber fell behind; events were dropped"}`,
+			nil, "fell behind",
+		},
+		{"connection closed", `{"status":"ok","events":["pane_died"]}`, nil, "daemon closed the subscription"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := readEvents(json.NewDecoder(strings.NewReader(tt.stream)), func(ev protocol.StateEvent) error {
+				got = append(got, ev.Event)
+				return nil
+			})
+			if tt.wantErr == "" {
+				if !errors.Is(err, errStopSubscription) {
+					t.Errorf("err = %v, want the subscription to stop", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
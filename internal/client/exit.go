@@ -0,0 +1,31 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// DaemonError reports that the daemon could not be reached or started.
+type DaemonError struct {
+	Err error
+}
+
+func (e *DaemonError) Error() string { return e.Err.Error() }
+
+func (e *DaemonError) Unwrap() error { return e.Err }
+
+// ExitCode maps an error returned by Ask, Dial or Broadcast to the CLI
+// exit code: output.ExitDaemonDown for an unreachable daemon, otherwise
+// output.ExitError. Failures reported by the daemon carry their own code
+// in AskResult.ExitCode.
+func ExitCode(err error) int {
+	if err == nil {
+		return output.ExitOK
+	}
+	var de *DaemonError
+	if errors.As(err, &de) {
+		return output.ExitDaemonDown
+	}
+	return output.ExitError
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+func TestExitCode(t *testing.T) {
+	down := &DaemonError{Err: errors.New("connect: connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, output.ExitOK},
+		{"plain", errors.New("boom"), output.ExitError},
+		{"daemon", down, output.ExitDaemonDown},
+		{"wrapped daemon", fmt.Errorf("hop 1 (codex): %w", down), output.ExitDaemonDown},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
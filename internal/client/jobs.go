@@ -0,0 +1,48 @@
+package client
+
+import (
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// SubmitAsk sends req as a background job (ask_async), auto-starting the
+// daemon if needed, and returns the job id. Collect the reply later with
+// JobResult; the id is also the ask's req_id. OnChunk is ignored.
+func SubmitAsk(req AskRequest) (string, error) {
+	state, err := readOrStartState()
+	if err != nil {
+		return "", &DaemonError{Err: err}
+	}
+	req.OnChunk = nil
+	var out protocol.AskAsyncResponse
+	if err := roundTrip(state, askParams("ask_async", state.Token, prepareAsk(req)), &out); err != nil {
+		return "", err
+	}
+	return out.JobID, nil
+}
+
+// JobStatus reports the state of a job.
+func JobStatus(jobID string) (*protocol.JobInfo, error) {
+	var out protocol.JobStatusResponse
+	if err := call("job_status", map[string]interface{}{"job_id": jobID}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Job, nil
+}
+
+// JobResult fetches a job's state and, once it is done, its result.
+func JobResult(jobID string) (*protocol.JobResultResponse, error) {
+	var out protocol.JobResultResponse
+	if err := call("job_result", map[string]interface{}{"job_id": jobID}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobs lists the daemon's jobs, oldest first.
+func ListJobs() ([]protocol.JobInfo, error) {
+	var out protocol.JobsResponse
+	if err := call("jobs", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Jobs, nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import "time"
+
+// MultiResult is the --json envelope of an operation that asks several
+// providers (a broadcast ask, compare). Every such operation prints this
+// one shape, so tools need a single parser.
+type MultiResult struct {
+	GroupID   string                `json:"group_id"`
+	Operation string                `json:"operation"`
+	Providers []string              `json:"providers"` // in the order asked
+	Results   map[string]*AskResult `json:"results"`   // keyed by provider
+	Failures  []Failure             `json:"failures"`  // empty, not null, when all succeeded
+	StartedAt time.Time             `json:"started_at"`
+	ElapsedMs int64                 `json:"elapsed_ms"`
+	ExitCode  int                   `json:"exit_code"` // the first non-zero provider exit code
+}
+
+// Failure is one provider's failed part of a MultiResult.
+type Failure struct {
+	Provider string `json:"provider"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewMultiResult wraps the results of operation, started at started, in a
+// MultiResult. groupID is the req_id the operation's asks were sent under;
+// each provider's req_id is derived from it (see BroadcastReqID).
+func NewMultiResult(operation, groupID string, started time.Time, results []*AskResult) *MultiResult {
+	m := &MultiResult{
+		GroupID:   groupID,
+		Operation: operation,
+		Providers: make([]string, 0, len(results)),
+		Results:   make(map[string]*AskResult, len(results)),
+		Failures:  []Failure{},
+		StartedAt: started,
+		ElapsedMs: time.Since(started).Milliseconds(),
+	}
+	for _, r := range results {
+		m.Providers = append(m.Providers, r.Provider)
+		m.Results[r.Provider] = r
+		if r.ExitCode != 0 {
+			m.Failures = append(m.Failures, Failure{Provider: r.Provider, ExitCode: r.ExitCode, Error: r.Error})
+			if m.ExitCode == 0 {
+				m.ExitCode = r.ExitCode
+			}
+		}
+	}
+	return m
+}
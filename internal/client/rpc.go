@@ -1,13 +1,19 @@
 package client
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/anthropics/claude_code_bridge/internal/certs"
 	"github.com/anthropics/claude_code_bridge/internal/daemon"
+	"github.com/anthropics/claude_code_bridge/internal/lock"
+	"github.com/anthropics/claude_code_bridge/internal/npipe"
 	"github.com/anthropics/claude_code_bridge/internal/runtime"
 )
 
@@ -53,6 +59,58 @@ func ShutdownDaemon(state *daemon.DaemonState) error {
 	return err
 }
 
+// RestartDaemon stops the running daemon, if any, waits up to timeout for
+// it to exit (including finishing its asks in flight) and starts a new
+// detached one. It returns the PIDs of the old daemon (0 if none was
+// running) and the new one.
+func RestartDaemon(timeout time.Duration) (oldPID, newPID int, err error) {
+	if state, err := ReadState(""); err == nil && PingDaemon(state) == nil {
+		oldPID = state.PID
+		deadline := time.Now().Add(timeout)
+		if err := ShutdownDaemon(state); err != nil {
+			return oldPID, 0, fmt.Errorf("cannot stop daemon: %w", err)
+		}
+		if err := waitForDaemonExit(state, timeout); err != nil {
+			return oldPID, 0, err
+		}
+		if err := waitForDaemonUnlock(state, time.Until(deadline)); err != nil {
+			return oldPID, 0, err
+		}
+	}
+	if err := MaybeStartDaemonDetached(); err != nil {
+		return oldPID, 0, err
+	}
+	state, err := ReadState("")
+	if err != nil {
+		return oldPID, 0, err
+	}
+	return oldPID, state.PID, nil
+}
+
+// waitForDaemonExit waits until the daemon described by state stops
+// answering pings.
+func waitForDaemonExit(state *daemon.DaemonState, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for PingDaemon(state) == nil {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("daemon (pid %d) still running after %s", state.PID, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// waitForDaemonUnlock waits until no daemon holds the run dir's singleton
+// lock. A stopped daemon keeps it while it drains asks in flight.
+func waitForDaemonUnlock(state *daemon.DaemonState, timeout time.Duration) error {
+	l := lock.NewFileLock(runtime.LockPath("askd"), timeout)
+	if !l.TryAcquire() && !l.Acquire() {
+		return fmt.Errorf("daemon (pid %d) still finishing asks after %s", state.PID, timeout)
+	}
+	l.Release()
+	return nil
+}
+
 // StatusDaemon gets the daemon status.
 func StatusDaemon(state *daemon.DaemonState) (map[string]interface{}, error) {
 	return sendRequest(state, map[string]interface{}{
@@ -61,14 +119,44 @@ func StatusDaemon(state *daemon.DaemonState) (map[string]interface{}, error) {
 	})
 }
 
-// sendRequest sends a JSON request to the daemon and returns the response.
-func sendRequest(state *daemon.DaemonState, req map[string]interface{}) (map[string]interface{}, error) {
+// StatusDaemonFor gets the daemon status including per-provider pane
+// liveness for workDir.
+func StatusDaemonFor(state *daemon.DaemonState, workDir string) (map[string]interface{}, error) {
+	return sendRequest(state, map[string]interface{}{
+		"method":   "status",
+		"token":    state.Token,
+		"work_dir": workDir,
+	})
+}
+
+// dialDaemon connects to the daemon described by state, over its named
+// pipe if it has one, else TCP, with the local client certificate when
+// the daemon requires TLS.
+func dialDaemon(state *daemon.DaemonState, timeout time.Duration) (net.Conn, error) {
+	if state.Pipe != "" {
+		return npipe.Dial(state.Pipe, timeout)
+	}
+	if state.Socket != "" {
+		return net.DialTimeout("unix", state.Socket, timeout)
+	}
 	host := runtime.NormalizeConnectHost(state.Host)
-	addr := fmt.Sprintf("%s:%d", host, state.Port)
+	addr := net.JoinHostPort(host, strconv.Itoa(state.Port))
+	if !state.TLS {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+	cfg, err := certs.ClientConfig(certs.Dir(runtime.RunDir()))
+	if err != nil {
+		return nil, err
+	}
+	cfg.ServerName = strings.Trim(host, "[]")
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, cfg)
+}
 
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+// sendRequest sends a JSON request to the daemon and returns the response.
+func sendRequest(state *daemon.DaemonState, req map[string]interface{}) (map[string]interface{}, error) {
+	conn, err := dialDaemon(state, 5*time.Second)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to daemon at %s: %w", addr, err)
+		return nil, fmt.Errorf("cannot connect to daemon at %s: %w", state.Address(), err)
 	}
 	defer conn.Close()
 
@@ -88,3 +176,39 @@ func sendRequest(state *daemon.DaemonState, req map[string]interface{}) (map[str
 
 	return resp, nil
 }
+
+// call sends method with fields to the running daemon and decodes its
+// response into out (see roundTrip).
+func call(method string, fields map[string]interface{}, out interface{}) error {
+	state, err := ReadState("")
+	if err != nil {
+		return fmt.Errorf("daemon not running")
+	}
+	req := map[string]interface{}{"method": method, "token": state.Token}
+	for k, v := range fields {
+		req[k] = v
+	}
+	return roundTrip(state, req, out)
+}
+
+// roundTrip sends req and decodes the response into out, if out is not
+// nil. A response whose status is not "ok" is returned as an error
+// carrying the daemon's message.
+func roundTrip(state *daemon.DaemonState, req map[string]interface{}, out interface{}) error {
+	resp, err := sendRequest(state, req)
+	if err != nil {
+		return err
+	}
+	if status, _ := resp["status"].(string); status != "ok" {
+		errMsg, _ := resp["error"].(string)
+		return fmt.Errorf("%s", errMsg)
+	}
+	if out == nil {
+		return nil
+	}
+	data, _ := json.Marshal(resp)
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
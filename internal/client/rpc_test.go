@@ -0,0 +1,44 @@
+package client
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon"
+)
+
+// fakeDaemon answers every request with {"status":"ok"} until closed.
+func fakeDaemon(t *testing.T) (*daemon.DaemonState, net.Listener) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte(`{"status":"ok"}` + "\n"))
+			conn.Close()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return &daemon.DaemonState{Host: "127.0.0.1", Port: addr.Port, PID: 42}, ln
+}
+
+func TestWaitForDaemonExit(t *testing.T) {
+	state, ln := fakeDaemon(t)
+	if err := waitForDaemonExit(state, 200*time.Millisecond); err == nil {
+		t.Fatal("waitForDaemonExit returned nil while the daemon still answers")
+	}
+
+	time.AfterFunc(150*time.Millisecond, func() { ln.Close() })
+	if err := waitForDaemonExit(state, 5*time.Second); err != nil {
+		t.Fatalf("waitForDaemonExit after close: %v", err)
+	}
+}
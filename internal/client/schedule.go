@@ -0,0 +1,36 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseDeliverAt parses a --deliver-at value relative to now. It accepts
+// RFC 3339 ("2026-01-25T18:00:00+08:00"), local "2006-01-02 15:04", a
+// local clock time "15:04" (today, or tomorrow if already past) and a
+// relative "+30m" / "+2h".
+func ParseDeliverAt(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "+"); ok {
+		d, err := time.ParseDuration(rest)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid relative time %q (want e.g. +30m)", s)
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want RFC 3339, \"2006-01-02 15:04\", \"15:04\" or +duration)", s)
+}
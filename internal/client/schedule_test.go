@@ -0,0 +1,36 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeliverAt(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2026, 1, 25, 14, 30, 0, 0, loc)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"+30m", now.Add(30 * time.Minute)},
+		{"2026-01-25T18:00:00+08:00", time.Date(2026, 1, 25, 18, 0, 0, 0, loc)},
+		{"2026-01-26 09:15", time.Date(2026, 1, 26, 9, 15, 0, 0, loc)},
+		{"18:00", time.Date(2026, 1, 25, 18, 0, 0, 0, loc)},
+		{"09:00", time.Date(2026, 1, 26, 9, 0, 0, 0, loc)}, // already past today
+	}
+	for _, tt := range tests {
+		got, err := ParseDeliverAt(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseDeliverAt(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDeliverAt(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "tomorrow", "+-5m", "25:00"} {
+		if _, err := ParseDeliverAt(bad, now); err == nil {
+			t.Errorf("ParseDeliverAt(%q) should fail", bad)
+		}
+	}
+}
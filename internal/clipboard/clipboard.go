@@ -0,0 +1,105 @@
+// Package clipboard reads and writes the system clipboard by shelling out
+// to the platform's clipboard tools.
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable is returned when no clipboard tool is installed.
+var ErrUnavailable = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// tool is one clipboard command line.
+type tool struct {
+	name string
+	args []string
+}
+
+// psCmd is the PowerShell prefix used on Windows and from WSL.
+func psCmd(exe string, script string) tool {
+	return tool{exe, []string{"-NoProfile", "-NonInteractive", "-Command", script}}
+}
+
+// readTools lists paste commands in preference order for goos/env.
+func readTools(goos string, getenv func(string) string) []tool {
+	switch goos {
+	case "darwin":
+		return []tool{{"pbpaste", nil}}
+	case "windows":
+		return []tool{psCmd("powershell", "Get-Clipboard -Raw")}
+	}
+	var tools []tool
+	if getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, tool{"wl-paste", []string{"--no-newline"}})
+	}
+	tools = append(tools,
+		tool{"xclip", []string{"-selection", "clipboard", "-o"}},
+		tool{"xsel", []string{"--clipboard", "--output"}},
+	)
+	if getenv("WSL_DISTRO_NAME") != "" {
+		tools = append(tools, psCmd("powershell.exe", "Get-Clipboard -Raw"))
+	}
+	return tools
+}
+
+// writeTools lists copy commands (reading stdin) in preference order.
+func writeTools(goos string, getenv func(string) string) []tool {
+	const psSet = "Set-Clipboard -Value ([Console]::In.ReadToEnd())"
+	switch goos {
+	case "darwin":
+		return []tool{{"pbcopy", nil}}
+	case "windows":
+		return []tool{psCmd("powershell", psSet)}
+	}
+	var tools []tool
+	if getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, tool{"wl-copy", nil})
+	}
+	tools = append(tools,
+		tool{"xclip", []string{"-selection", "clipboard", "-i"}},
+		tool{"xsel", []string{"--clipboard", "--input"}},
+	)
+	if getenv("WSL_DISTRO_NAME") != "" {
+		tools = append(tools, psCmd("powershell.exe", psSet))
+	}
+	return tools
+}
+
+// firstAvailable returns the first tool found on PATH.
+func firstAvailable(tools []tool) (tool, bool) {
+	for _, t := range tools {
+		if _, err := exec.LookPath(t.name); err == nil {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+// Read returns the clipboard contents as text.
+func Read() (string, error) {
+	t, ok := firstAvailable(readTools(runtime.GOOS, os.Getenv))
+	if !ok {
+		return "", ErrUnavailable
+	}
+	out, err := exec.Command(t.name, t.args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(out), "\r\n", "\n"), nil
+}
+
+// Write replaces the clipboard contents with text.
+func Write(text string) error {
+	t, ok := firstAvailable(writeTools(runtime.GOOS, os.Getenv))
+	if !ok {
+		return ErrUnavailable
+	}
+	cmd := exec.Command(t.name, t.args...)
+	cmd.Stdin = bytes.NewBufferString(text)
+	return cmd.Run()
+}
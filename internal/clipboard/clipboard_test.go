@@ -0,0 +1,43 @@
+package clipboard
+
+import "testing"
+
+func envOf(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestReadToolsOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		goos  string
+		env   map[string]string
+		first string
+		count int
+	}{
+		{"macos", "darwin", nil, "pbpaste", 1},
+		{"windows", "windows", nil, "powershell", 1},
+		{"x11", "linux", nil, "xclip", 2},
+		{"wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, "wl-paste", 3},
+		{"wsl", "linux", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, "xclip", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := readTools(tt.goos, envOf(tt.env))
+			if len(tools) != tt.count {
+				t.Fatalf("readTools returned %d tools, want %d", len(tools), tt.count)
+			}
+			if tools[0].name != tt.first {
+				t.Errorf("first tool = %q, want %q", tools[0].name, tt.first)
+			}
+		})
+	}
+}
+
+func TestWriteToolsMirrorRead(t *testing.T) {
+	env := envOf(map[string]string{"WAYLAND_DISPLAY": "w", "WSL_DISTRO_NAME": "d"})
+	for _, goos := range []string{"darwin", "windows", "linux"} {
+		if r, w := len(readTools(goos, env)), len(writeTools(goos, env)); r != w {
+			t.Errorf("%s: %d read tools but %d write tools", goos, r, w)
+		}
+	}
+}
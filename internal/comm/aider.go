@@ -0,0 +1,219 @@
+package comm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// AiderCommunicator handles communication with Aider.
+// Aider appends each chat to a markdown history file, .aider.chat.history.md
+// in the directory it runs in unless --chat-history-file moves it: user
+// input on lines prefixed "#### ", tool output on lines prefixed "> ", and
+// the model's replies as plain text.
+type AiderCommunicator struct {
+	BaseCommunicator
+}
+
+// NewAiderCommunicator creates a new Aider communicator.
+func NewAiderCommunicator(backend terminal.Backend) *AiderCommunicator {
+	return &AiderCommunicator{
+		BaseCommunicator: BaseCommunicator{
+			ProviderName: "aider",
+			Backend:      backend,
+			PollCfg:      DefaultPollConfig(),
+		},
+	}
+}
+
+func (c *AiderCommunicator) Name() string { return "aider" }
+
+func (c *AiderCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
+	return c.SendViaTerminal(ctx, paneID, message)
+}
+
+func (c *AiderCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
+	if opts.LogPath == "" {
+		return "", nil
+	}
+	var pin aiderHistoryPin
+	return pin.read(opts.LogPath, opts.ReqID)
+}
+
+// WaitForReply pins the history file holding the request's anchor for the
+// whole wait when LogPath is a directory of histories.
+func (c *AiderCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	var pin aiderHistoryPin
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
+		}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
+}
+
+func (c *AiderCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
+	state := &CaptureState{}
+	if opts.LogPath == "" {
+		return state, nil
+	}
+
+	reply, err := c.ReadReply(ctx, opts)
+	if err != nil {
+		return state, err
+	}
+	if reply != "" {
+		state.AnchorSeen = true
+		state.ReplyLines = strings.Split(reply, "\n")
+		if protocol.IsDoneText(reply, opts.ReqID) {
+			state.DoneSeen = true
+		}
+	}
+	return state, nil
+}
+
+func (c *AiderCommunicator) HealthCheck(ctx context.Context, paneID string) error {
+	if !c.IsAlive(paneID) {
+		return &ErrPaneDead{Provider: "aider", PaneID: paneID}
+	}
+	return nil
+}
+
+// aiderAnchorScanLimit caps how many of the newest history files in a
+// directory are searched for a request's anchor.
+const aiderAnchorScanLimit = 20
+
+// aiderHistoryPin remembers which history file holds a request's anchor.
+type aiderHistoryPin struct {
+	path string
+}
+
+// read returns the reply following reqID's anchor in historyPath, a
+// history file or a directory of them. In a directory the first file found
+// with the anchor is pinned until it no longer carries it.
+func (p *aiderHistoryPin) read(historyPath string, reqID string) (string, error) {
+	info, err := os.Stat(historyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Aider creates the file with the first message.
+			return "", nil
+		}
+		return "", err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(historyPath)
+		if err != nil {
+			return "", err
+		}
+		reply, _ := aiderReplyAfterAnchor(string(data), reqID)
+		return reply, nil
+	}
+
+	if p.path != "" {
+		if data, err := os.ReadFile(p.path); err == nil {
+			if reply, ok := aiderReplyAfterAnchor(string(data), reqID); ok {
+				return reply, nil
+			}
+		}
+		p.path = ""
+	}
+	files := listAiderHistories(historyPath)
+	if len(files) > aiderAnchorScanLimit {
+		files = files[:aiderAnchorScanLimit]
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if reply, ok := aiderReplyAfterAnchor(string(data), reqID); ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
+
+// aiderReplyAfterAnchor returns the model's reply to the user message
+// carrying reqID's anchor: the plain lines after that message, up to the
+// next user message, without tool output. ok is false when the anchor is
+// not in history.
+func aiderReplyAfterAnchor(history string, reqID string) (reply string, ok bool) {
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	lines := strings.Split(history, "\n")
+	start := -1
+	for i, line := range lines {
+		if isAiderUserLine(line) && strings.Contains(line, anchor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+
+	i := start + 1
+	for i < len(lines) && isAiderUserLine(lines[i]) {
+		i++ // the rest of the wrapped prompt
+	}
+	var replyLines []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \r")
+		if isAiderUserLine(line) {
+			break
+		}
+		if strings.HasPrefix(line, ">") || strings.HasPrefix(line, "# aider chat started") {
+			continue
+		}
+		replyLines = append(replyLines, line)
+	}
+	return strings.TrimSpace(strings.Join(replyLines, "\n")), true
+}
+
+func isAiderUserLine(line string) bool {
+	return strings.HasPrefix(line, "####")
+}
+
+// listAiderHistories returns the markdown files in dir and its immediate
+// subdirectories, newest first.
+func listAiderHistories(dir string) []string {
+	type fileEntry struct {
+		path    string
+		modTime time.Time
+	}
+	var files []fileEntry
+	add := func(d string) []os.DirEntry {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			return nil
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				files = append(files, fileEntry{path: filepath.Join(d, e.Name()), modTime: info.ModTime()})
+			}
+		}
+		return entries
+	}
+	for _, e := range add(dir) {
+		if e.IsDir() {
+			add(filepath.Join(dir, e.Name()))
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
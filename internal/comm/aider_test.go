@@ -0,0 +1,70 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const aiderHistory = `
+# aider chat started at 2026-01-25 14:30:00
+
+#### CCB_REQ_ID: req-1
+####
+#### what is 2+2?
+
+4
+
+CCB_DONE: req-1
+
+> Tokens: 1.2k sent, 12 received.
+
+#### CCB_REQ_ID: req-2
+####
+#### and 3+3?
+
+> Add file to the chat? (Y)es/(N)o [Yes]: y
+
+6
+`
+
+func TestAiderReplyAfterAnchor(t *testing.T) {
+	tests := []struct {
+		reqID string
+		want  string
+		ok    bool
+	}{
+		{"req-1", "4\n\nCCB_DONE: req-1", true},
+		{"req-2", "6", true},
+		{"req-3", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := aiderReplyAfterAnchor(aiderHistory, tt.reqID)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("aiderReplyAfterAnchor(%q) = %q, %v, want %q, %v", tt.reqID, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAiderHistoryPin(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(dir, "proj-a.md")
+	b := filepath.Join(dir, "history", "proj-b.md")
+	os.MkdirAll(filepath.Dir(b), 0755)
+	os.WriteFile(a, []byte(aiderHistory), 0644)
+	os.WriteFile(b, []byte("#### CCB_REQ_ID: req-b\n\nanswer b\n"), 0644)
+	os.Chtimes(a, now.Add(-time.Minute), now.Add(-time.Minute))
+
+	var pin aiderHistoryPin
+	if reply, err := pin.read(dir, "req-2"); err != nil || reply != "6" || pin.path != a {
+		t.Fatalf("read(dir, req-2) = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+	if reply, _ := pin.read(b, "req-b"); reply != "answer b" {
+		t.Errorf("read(file, req-b) = %q", reply)
+	}
+	if reply, err := pin.read(filepath.Join(dir, "missing.md"), "req-1"); err != nil || reply != "" {
+		t.Errorf("history not created yet: %q, %v", reply, err)
+	}
+}
@@ -0,0 +1,233 @@
+package comm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// AmpCommunicator handles communication with Amp.
+// Amp keeps a local copy of each thread in ~/.local/share/amp/threads/T-<id>.json,
+// rewritten whole as the thread grows.
+type AmpCommunicator struct {
+	BaseCommunicator
+}
+
+// NewAmpCommunicator creates a new Amp communicator.
+func NewAmpCommunicator(backend terminal.Backend) *AmpCommunicator {
+	return &AmpCommunicator{
+		BaseCommunicator: BaseCommunicator{
+			ProviderName: "amp",
+			Backend:      backend,
+			PollCfg:      DefaultPollConfig(),
+		},
+	}
+}
+
+func (c *AmpCommunicator) Name() string { return "amp" }
+
+func (c *AmpCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
+	return c.SendViaTerminal(ctx, paneID, message)
+}
+
+func (c *AmpCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
+	if opts.LogPath == "" {
+		return "", nil
+	}
+	var pin ampThreadPin
+	return pin.read(opts.LogPath, opts.ReqID)
+}
+
+// WaitForReply pins the thread holding the request's anchor for the whole
+// wait, so a concurrent Amp thread can't be read instead.
+func (c *AmpCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	var pin ampThreadPin
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
+		}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
+}
+
+func (c *AmpCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
+	state := &CaptureState{}
+	if opts.LogPath == "" {
+		return state, nil
+	}
+
+	reply, err := c.ReadReply(ctx, opts)
+	if err != nil {
+		return state, err
+	}
+	if reply != "" {
+		state.AnchorSeen = true
+		state.ReplyLines = strings.Split(reply, "\n")
+		if protocol.IsDoneText(reply, opts.ReqID) {
+			state.DoneSeen = true
+		}
+	}
+	return state, nil
+}
+
+func (c *AmpCommunicator) HealthCheck(ctx context.Context, paneID string) error {
+	if !c.IsAlive(paneID) {
+		return &ErrPaneDead{Provider: "amp", PaneID: paneID}
+	}
+	return nil
+}
+
+// AmpThread is the part of an Amp thread file ccb reads.
+type AmpThread struct {
+	ID       string       `json:"id"`
+	Messages []AmpMessage `json:"messages"`
+}
+
+// AmpMessage is one message of an Amp thread. Content is a list of blocks;
+// only "text" blocks carry text, the rest are tool use and thinking.
+type AmpMessage struct {
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// text joins the message's text blocks.
+func (m AmpMessage) text() string {
+	var parts []string
+	for _, c := range m.Content {
+		if c.Type == "text" && c.Text != "" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ampAnchorScanLimit caps how many of the newest thread files are searched
+// for a request's anchor before the thread is pinned.
+const ampAnchorScanLimit = 20
+
+// ampThreadPin remembers which thread file holds a request's anchor.
+type ampThreadPin struct {
+	path string
+}
+
+// read returns the reply following reqID's anchor in threadPath, a thread
+// file or the threads directory. In the directory the first thread found
+// with the anchor is pinned until it no longer carries it.
+func (p *ampThreadPin) read(threadPath string, reqID string) (string, error) {
+	info, err := os.Stat(threadPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		thread, err := parseAmpThread(threadPath)
+		if err != nil {
+			// Amp rewrites the file whole; a read can catch it half written.
+			return "", nil
+		}
+		reply, _ := ampReplyAfterAnchor(thread.Messages, reqID)
+		return reply, nil
+	}
+
+	if p.path != "" {
+		thread, err := parseAmpThread(p.path)
+		if err == nil {
+			if reply, ok := ampReplyAfterAnchor(thread.Messages, reqID); ok {
+				return reply, nil
+			}
+		} else if _, statErr := os.Stat(p.path); statErr == nil {
+			return "", nil // half written; keep the pin
+		}
+		p.path = ""
+	}
+	files, err := listAmpThreads(threadPath)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > ampAnchorScanLimit {
+		files = files[:ampAnchorScanLimit]
+	}
+	for _, f := range files {
+		thread, err := parseAmpThread(f)
+		if err != nil {
+			continue
+		}
+		if reply, ok := ampReplyAfterAnchor(thread.Messages, reqID); ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
+
+// ampReplyAfterAnchor collects the assistant text after reqID's anchor.
+// ok is false when the anchor is not in messages.
+func ampReplyAfterAnchor(messages []AmpMessage, reqID string) (reply string, ok bool) {
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	start := -1
+	for i, m := range messages {
+		if m.Role == "user" && strings.Contains(m.text(), anchor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+
+	var replyParts []string
+	for _, m := range messages[start+1:] {
+		if m.Role != "assistant" {
+			continue
+		}
+		if text := m.text(); text != "" {
+			replyParts = append(replyParts, text)
+		}
+	}
+	return strings.Join(replyParts, "\n"), true
+}
+
+// listAmpThreads returns the thread files in threadsDir, newest first.
+func listAmpThreads(threadsDir string) ([]string, error) {
+	entries, err := os.ReadDir(threadsDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(threadsDir, f.Name())
+	}
+	return paths, nil
+}
+
+// parseAmpThread parses an Amp thread file.
+func parseAmpThread(path string) (*AmpThread, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var thread AmpThread
+	if err := json.Unmarshal(data, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
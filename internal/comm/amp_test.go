@@ -0,0 +1,51 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeAmpThread(t *testing.T, path string, mtime time.Time, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, mtime, mtime)
+}
+
+func TestAmpThreadPin(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(dir, "T-a.json")
+	b := filepath.Join(dir, "T-b.json")
+	writeAmpThread(t, a, now.Add(-time.Minute), `{"id":"T-a","messages":[
+		{"role":"user","content":[{"type":"text","text":"CCB_REQ_ID: req-a\n\nhi"}]},
+		{"role":"assistant","content":[{"type":"thinking","thinking":"hm"},{"type":"text","text":"answer a"},{"type":"tool_use","id":"t1"}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1"}]},
+		{"role":"assistant","content":[{"type":"text","text":"CCB_DONE: req-a"}]}]}`)
+	writeAmpThread(t, b, now, `{"id":"T-b","messages":[
+		{"role":"user","content":[{"type":"text","text":"CCB_REQ_ID: req-b"}]},
+		{"role":"assistant","content":[{"type":"text","text":"answer b"}]}]}`)
+
+	var pin ampThreadPin
+	reply, err := pin.read(dir, "req-a")
+	if err != nil || reply != "answer a\nCCB_DONE: req-a" || pin.path != a {
+		t.Fatalf("read(dir, req-a) = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+
+	// Caught half rewritten: nothing yet, and the pin holds.
+	writeAmpThread(t, a, now, `{"id":"T-a","messages":[{"role":"us`)
+	if reply, err := pin.read(dir, "req-a"); err != nil || reply != "" || pin.path != a {
+		t.Errorf("half-written read = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+
+	if reply, _ := pin.read(b, "req-b"); reply != "answer b" {
+		t.Errorf("read(file, req-b) = %q", reply)
+	}
+	var fresh ampThreadPin
+	if reply, _ := fresh.read(dir, "req-missing"); reply != "" {
+		t.Errorf("unknown request should read empty, got %q", reply)
+	}
+}
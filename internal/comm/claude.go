@@ -28,6 +28,7 @@ func NewClaudeCommunicator(backend terminal.Backend) *ClaudeCommunicator {
 			ProviderName: "claude",
 			Backend:      backend,
 			PollCfg:      DefaultPollConfig(),
+			TurnComplete: claudeTurnComplete,
 		},
 	}
 }
@@ -35,7 +36,7 @@ func NewClaudeCommunicator(backend terminal.Backend) *ClaudeCommunicator {
 func (c *ClaudeCommunicator) Name() string { return "claude" }
 
 func (c *ClaudeCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
-	return c.SendViaTerminal(paneID, message)
+	return c.SendViaTerminal(ctx, paneID, message)
 }
 
 func (c *ClaudeCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
@@ -86,40 +87,9 @@ func (c *ClaudeCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (stri
 }
 
 func (c *ClaudeCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
-	cfg := c.PollCfg
-	interval := cfg.InitialInterval
-	if opts.PollMs > 0 {
-		interval = time.Duration(opts.PollMs) * time.Millisecond
-	}
-
-	lastForceRead := time.Now()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", &ErrTimeout{Provider: "claude", ReqID: opts.ReqID}
-		default:
-		}
-
-		reply, err := c.ReadReply(ctx, ReadOpts{
-			LogPath: opts.LogPath,
-			ReqID:   opts.ReqID,
-		})
-		if err == nil && reply != "" && protocol.IsDoneText(reply, opts.ReqID) {
-			return protocol.StripDoneText(reply, opts.ReqID), nil
-		}
-
-		// Check pane alive periodically
-		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
-			lastForceRead = time.Now()
-			if !c.IsAlive(opts.PaneID) {
-				return "", &ErrPaneDead{Provider: "claude", PaneID: opts.PaneID}
-			}
-		}
-
-		time.Sleep(interval)
-		interval = adaptiveSleep(interval, cfg)
-	}
+	return c.pollReply(ctx, opts, func() (string, error) {
+		return c.ReadReply(ctx, ReadOpts{LogPath: opts.LogPath, ReqID: opts.ReqID})
+	})
 }
 
 func (c *ClaudeCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
@@ -149,6 +119,30 @@ func (c *ClaudeCommunicator) HealthCheck(ctx context.Context, paneID string) err
 	return nil
 }
 
+// claudeTurnComplete reports whether the last assistant entry after the
+// request's anchor ended its turn (message.stop_reason "end_turn").
+func claudeTurnComplete(logPath, reqID string) bool {
+	entries, err := readClaudeLog(logPath, reqID)
+	if err != nil {
+		return false
+	}
+	foundAnchor, ended := false, false
+	for _, entry := range entries {
+		entryType, _ := entry["type"].(string)
+		switch {
+		case entryType == "human" || entryType == "user":
+			if strings.Contains(extractClaudeEntryContent(entry), protocol.ReqIDPrefix+" "+reqID) {
+				foundAnchor, ended = true, false
+			}
+		case foundAnchor && entryType == "assistant":
+			msg, _ := entry["message"].(map[string]interface{})
+			reason, _ := msg["stop_reason"].(string)
+			ended = reason == "end_turn"
+		}
+	}
+	return ended
+}
+
 // ClaudeEntry represents a parsed entry from a Claude JSONL log.
 type ClaudeEntry = map[string]interface{}
 
@@ -258,14 +252,14 @@ func extractClaudeEntryContent(entry ClaudeEntry) string {
 func extractClaudeContent(content interface{}) string {
 	switch v := content.(type) {
 	case string:
-		return stripANSI(v)
+		return StripANSI(v)
 	case []interface{}:
 		var parts []string
 		for _, item := range v {
 			if m, ok := item.(map[string]interface{}); ok {
 				if t, ok := m["type"].(string); ok && t == "text" {
 					if text, ok := m["text"].(string); ok {
-						parts = append(parts, stripANSI(text))
+						parts = append(parts, StripANSI(text))
 					}
 				}
 			}
@@ -275,11 +269,12 @@ func extractClaudeContent(content interface{}) string {
 	return ""
 }
 
-// ansiRE matches ANSI escape sequences.
-var ansiRE = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+// ansiRE matches ANSI escape sequences: CSI (colors, cursor movement)
+// and OSC (window titles, hyperlinks).
+var ansiRE = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
 
-// stripANSI removes ANSI escape codes from a string.
-func stripANSI(s string) string {
+// StripANSI removes ANSI escape codes from a string.
+func StripANSI(s string) string {
 	return ansiRE.ReplaceAllString(s, "")
 }
 
@@ -27,6 +27,7 @@ func NewCodexCommunicator(backend terminal.Backend) *CodexCommunicator {
 			ProviderName: "codex",
 			Backend:      backend,
 			PollCfg:      DefaultPollConfig(),
+			TurnComplete: codexTurnComplete,
 		},
 	}
 }
@@ -34,7 +35,7 @@ func NewCodexCommunicator(backend terminal.Backend) *CodexCommunicator {
 func (c *CodexCommunicator) Name() string { return "codex" }
 
 func (c *CodexCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
-	return c.SendViaTerminal(paneID, message)
+	return c.SendViaTerminal(ctx, paneID, message)
 }
 
 func (c *CodexCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
@@ -76,48 +77,29 @@ func (c *CodexCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (strin
 	return strings.Join(replyLines, "\n"), nil
 }
 
-func (c *CodexCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
-	cfg := c.PollCfg
-	interval := cfg.InitialInterval
-	if opts.PollMs > 0 {
-		interval = time.Duration(opts.PollMs) * time.Millisecond
+// codexTurnComplete reports whether Codex logged a task_complete event
+// after the request's anchor.
+func codexTurnComplete(logPath, reqID string) bool {
+	lines, err := NewReverseReader(logPath).ReadLastLines(500)
+	if err != nil {
+		return false
 	}
-
-	lastForceRead := time.Now()
-	startTime := time.Now()
-	var anchorMs int64
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", &ErrTimeout{Provider: "codex", ReqID: opts.ReqID}
-		default:
-		}
-
-		reply, err := c.ReadReply(ctx, ReadOpts{
-			LogPath: opts.LogPath,
-			ReqID:   opts.ReqID,
-		})
-		if err == nil && reply != "" {
-			if anchorMs == 0 {
-				anchorMs = time.Since(startTime).Milliseconds()
-			}
-			if protocol.IsDoneText(reply, opts.ReqID) {
-				return protocol.StripDoneText(reply, opts.ReqID), nil
-			}
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], anchor) {
+			return false
 		}
-
-		// Check pane alive periodically
-		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
-			lastForceRead = time.Now()
-			if !c.IsAlive(opts.PaneID) {
-				return "", &ErrPaneDead{Provider: "codex", PaneID: opts.PaneID}
-			}
+		if strings.Contains(lines[i], `"task_complete"`) {
+			return true
 		}
-
-		time.Sleep(interval)
-		interval = adaptiveSleep(interval, cfg)
 	}
+	return false
+}
+
+func (c *CodexCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	return c.pollReply(ctx, opts, func() (string, error) {
+		return c.ReadReply(ctx, ReadOpts{LogPath: opts.LogPath, ReqID: opts.ReqID})
+	})
 }
 
 func (c *CodexCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
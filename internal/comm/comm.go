@@ -2,8 +2,10 @@ package comm
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/anthropics/claude_code_bridge/internal/config"
 	"github.com/anthropics/claude_code_bridge/internal/terminal"
 )
 
@@ -26,6 +28,10 @@ type Communicator interface {
 
 	// HealthCheck verifies the provider pane is alive and responsive.
 	HealthCheck(ctx context.Context, paneID string) error
+
+	// Interrupt sends key (terminal.KeyEscape, terminal.KeyCtrlC) to the
+	// provider pane to stop the turn it is working on.
+	Interrupt(paneID string, key string) error
 }
 
 // ReadOpts holds options for reading a reply.
@@ -43,6 +49,14 @@ type WaitOpts struct {
 	ReqID     string
 	PaneID    string
 	PollMs    int
+	OnLines   func(lines []string) // optional: completed lines of the reply so far
+	OnAnchor  func()               // optional: called once the request's anchor shows up
+
+	// QuietDone, if set, accepts a reply without CCB_DONE once it has
+	// stopped growing for this long, or once the provider's log marks the
+	// turn complete. OnHeuristicDone is told which ("quiet", "turn_complete").
+	QuietDone       time.Duration
+	OnHeuristicDone func(reason string)
 }
 
 // CaptureState holds the state of an in-progress reply capture.
@@ -79,16 +93,69 @@ type BaseCommunicator struct {
 	ProviderName string
 	Backend      terminal.Backend
 	PollCfg      PollConfig
+
+	// TurnComplete, if set, reports whether the provider's log marks the
+	// turn answering reqID as finished (used only with WaitOpts.QuietDone).
+	TurnComplete func(logPath, reqID string) bool
 }
 
-// SendViaTerminal sends text to a terminal pane.
-func (b *BaseCommunicator) SendViaTerminal(paneID string, text string) error {
+// SendViaTerminal sends text to a terminal pane. Backends that stage the
+// text in a file put it in ctx's scratch directory (WithScratchDir).
+func (b *BaseCommunicator) SendViaTerminal(ctx context.Context, paneID string, text string) error {
 	if b.Backend == nil {
 		return &ErrNoBackend{Provider: b.ProviderName}
 	}
+	if dir := ScratchDir(ctx); dir != "" {
+		if ss, ok := b.Backend.(terminal.ScratchSender); ok {
+			return ss.SendKeysIn(dir, paneID, text)
+		}
+	}
 	return b.Backend.SendKeys(paneID, text)
 }
 
+type scratchDirKey struct{}
+
+// WithScratchDir returns a context carrying dir as the request's scratch
+// directory for temporary payloads.
+func WithScratchDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, scratchDirKey{}, dir)
+}
+
+// ScratchDir returns the scratch directory set by WithScratchDir, or "".
+func ScratchDir(ctx context.Context) string {
+	dir, _ := ctx.Value(scratchDirKey{}).(string)
+	return dir
+}
+
+// Interrupt sends a control key to a terminal pane. Backends that cannot
+// send bare control keys report ErrNoInterrupt.
+func (b *BaseCommunicator) Interrupt(paneID string, key string) error {
+	if b.Backend == nil {
+		return &ErrNoBackend{Provider: b.ProviderName}
+	}
+	in, ok := b.Backend.(terminal.Interrupter)
+	if !ok {
+		return &ErrNoInterrupt{Backend: b.Backend.Name()}
+	}
+	return in.SendControlKey(paneID, key)
+}
+
+// InterruptKey returns the control key sent to a provider's pane when its
+// ask is canceled: CCB_INTERRUPT_KEY_<PROVIDER>, else CCB_INTERRUPT_KEY
+// ("escape", "ctrl-c" or "none"). It defaults to Escape, which stops the
+// current turn without quitting the CLI; "" means send nothing.
+func InterruptKey(provider string) string {
+	v := config.EnvStr("CCB_INTERRUPT_KEY", "escape")
+	v = config.EnvStr("CCB_INTERRUPT_KEY_"+strings.ToUpper(provider), v)
+	switch strings.ToLower(v) {
+	case "ctrl-c", "ctrl+c", "c-c":
+		return terminal.KeyCtrlC
+	case "none", "off":
+		return ""
+	}
+	return terminal.KeyEscape
+}
+
 // IsAlive checks if a pane is still alive via the backend.
 func (b *BaseCommunicator) IsAlive(paneID string) bool {
 	if b.Backend == nil {
@@ -115,6 +182,15 @@ func (e *ErrNoBackend) Error() string {
 	return "no terminal backend available for " + e.Provider
 }
 
+// ErrNoInterrupt is returned when the terminal backend cannot send control keys.
+type ErrNoInterrupt struct {
+	Backend string
+}
+
+func (e *ErrNoInterrupt) Error() string {
+	return e.Backend + " backend cannot send control keys"
+}
+
 // ErrTimeout is returned when waiting for a reply times out.
 type ErrTimeout struct {
 	Provider string
@@ -0,0 +1,39 @@
+package comm
+
+import (
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+func TestInterruptKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		provider string
+		want     string
+	}{
+		{"default", "", "", terminal.KeyEscape},
+		{"global ctrl-c", "ctrl-c", "", terminal.KeyCtrlC},
+		{"global none", "none", "", ""},
+		{"provider overrides", "ctrl-c", "escape", terminal.KeyEscape},
+		{"provider none", "", "none", ""},
+		{"unknown falls back", "bogus", "", terminal.KeyEscape},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CCB_INTERRUPT_KEY", tt.global)
+			t.Setenv("CCB_INTERRUPT_KEY_CODEX", tt.provider)
+			if got := InterruptKey("codex"); got != tt.want {
+				t.Errorf("InterruptKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterruptWithoutBackend(t *testing.T) {
+	b := &BaseCommunicator{ProviderName: "codex"}
+	if _, ok := b.Interrupt("%1", terminal.KeyEscape).(*ErrNoBackend); !ok {
+		t.Error("Interrupt without a backend should return ErrNoBackend")
+	}
+}
@@ -0,0 +1,254 @@
+package comm
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// CursorCommunicator handles communication with the cursor-agent CLI.
+// cursor-agent writes a plain-text transcript per chat to
+// ~/.cursor/projects/<project-slug>/agent-transcripts/<chat-id>.txt: turns
+// start with a "user:" or "assistant:" line, and the assistant's thinking
+// and tool calls are bracketed ("[Thinking] ...", "[Tool call] ...") with
+// their details indented below.
+type CursorCommunicator struct {
+	BaseCommunicator
+}
+
+// NewCursorCommunicator creates a new cursor-agent communicator.
+func NewCursorCommunicator(backend terminal.Backend) *CursorCommunicator {
+	return &CursorCommunicator{
+		BaseCommunicator: BaseCommunicator{
+			ProviderName: "cursor",
+			Backend:      backend,
+			PollCfg:      DefaultPollConfig(),
+		},
+	}
+}
+
+func (c *CursorCommunicator) Name() string { return "cursor" }
+
+func (c *CursorCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
+	return c.SendViaTerminal(ctx, paneID, message)
+}
+
+func (c *CursorCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
+	if opts.LogPath == "" {
+		return "", nil
+	}
+	var pin cursorTranscriptPin
+	return pin.read(opts.LogPath, opts.ReqID)
+}
+
+// WaitForReply pins the transcript holding the request's anchor for the
+// whole wait, so a concurrent chat can't be read instead.
+func (c *CursorCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	var pin cursorTranscriptPin
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
+		}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
+}
+
+func (c *CursorCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
+	state := &CaptureState{}
+	if opts.LogPath == "" {
+		return state, nil
+	}
+
+	reply, err := c.ReadReply(ctx, opts)
+	if err != nil {
+		return state, err
+	}
+	if reply != "" {
+		state.AnchorSeen = true
+		state.ReplyLines = strings.Split(reply, "\n")
+		if protocol.IsDoneText(reply, opts.ReqID) {
+			state.DoneSeen = true
+		}
+	}
+	return state, nil
+}
+
+func (c *CursorCommunicator) HealthCheck(ctx context.Context, paneID string) error {
+	if !c.IsAlive(paneID) {
+		return &ErrPaneDead{Provider: "cursor", PaneID: paneID}
+	}
+	return nil
+}
+
+// CursorTurn is one turn of a cursor-agent transcript.
+type CursorTurn struct {
+	Role string // "user" or "assistant"
+	Text string // without thinking and tool calls
+}
+
+// parseCursorTranscript splits a transcript into turns. Text before the
+// first role line is dropped.
+func parseCursorTranscript(transcript string) []CursorTurn {
+	var turns []CursorTurn
+	var lines []string
+	role := ""
+	inTool := false
+	flush := func() {
+		if role != "" {
+			turns = append(turns, CursorTurn{Role: role, Text: strings.TrimSpace(strings.Join(lines, "\n"))})
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(transcript, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch strings.TrimSpace(line) {
+		case "user:":
+			flush()
+			role, inTool = "user", false
+			continue
+		case "assistant:", "A:":
+			flush()
+			role, inTool = "assistant", false
+			continue
+		}
+		if role == "assistant" {
+			if strings.HasPrefix(line, "[") {
+				if i := strings.Index(line, "]"); i > 0 && isCursorAnnotation(line[1:i]) {
+					inTool = true
+					continue
+				}
+			}
+			if inTool {
+				if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+					continue
+				}
+				inTool = false
+			}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return turns
+}
+
+func isCursorAnnotation(tag string) bool {
+	switch tag {
+	case "Thinking", "Tool call", "Tool result":
+		return true
+	}
+	return false
+}
+
+// cursorReplyAfterAnchor collects the assistant text after reqID's anchor,
+// up to the next user turn. ok is false when the anchor is not in turns.
+func cursorReplyAfterAnchor(turns []CursorTurn, reqID string) (reply string, ok bool) {
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	start := -1
+	for i, t := range turns {
+		if t.Role == "user" && strings.Contains(t.Text, anchor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+
+	var replyParts []string
+	for _, t := range turns[start+1:] {
+		if t.Role == "user" {
+			break
+		}
+		if t.Text != "" {
+			replyParts = append(replyParts, t.Text)
+		}
+	}
+	return strings.Join(replyParts, "\n"), true
+}
+
+// cursorAnchorScanLimit caps how many of the newest transcripts are
+// searched for a request's anchor before the transcript is pinned.
+const cursorAnchorScanLimit = 20
+
+// cursorTranscriptPin remembers which transcript holds a request's anchor.
+type cursorTranscriptPin struct {
+	path string
+}
+
+// read returns the reply following reqID's anchor in transcriptPath, a
+// transcript or a directory holding transcripts (a project's
+// agent-transcripts, or all projects). In a directory the first transcript
+// found with the anchor is pinned until it no longer carries it.
+func (p *cursorTranscriptPin) read(transcriptPath string, reqID string) (string, error) {
+	info, err := os.Stat(transcriptPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(transcriptPath)
+		if err != nil {
+			return "", err
+		}
+		reply, _ := cursorReplyAfterAnchor(parseCursorTranscript(string(data)), reqID)
+		return reply, nil
+	}
+
+	if p.path != "" {
+		if data, err := os.ReadFile(p.path); err == nil {
+			if reply, ok := cursorReplyAfterAnchor(parseCursorTranscript(string(data)), reqID); ok {
+				return reply, nil
+			}
+		}
+		p.path = ""
+	}
+	files := listCursorTranscripts(transcriptPath)
+	if len(files) > cursorAnchorScanLimit {
+		files = files[:cursorAnchorScanLimit]
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if reply, ok := cursorReplyAfterAnchor(parseCursorTranscript(string(data)), reqID); ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
+
+// listCursorTranscripts returns the transcripts under dir, newest first.
+func listCursorTranscripts(dir string) []string {
+	type fileEntry struct {
+		path    string
+		modTime time.Time
+	}
+	var files []fileEntry
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".txt") {
+			return nil
+		}
+		if filepath.Base(filepath.Dir(path)) != "agent-transcripts" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, fileEntry{path: path, modTime: info.ModTime()})
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
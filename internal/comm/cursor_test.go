@@ -0,0 +1,80 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const cursorTranscript = `user:
+<user_query>
+CCB_REQ_ID: req-1
+
+what is in main.go?
+</user_query>
+
+assistant:
+[Thinking] The user wants a summary.
+[Tool call] read_file
+  path: main.go
+
+[Tool result] read_file
+  package main
+
+It defines the CLI entry point.
+
+CCB_DONE: req-1
+
+user:
+<user_query>
+CCB_REQ_ID: req-2
+</user_query>
+
+A:
+second answer
+`
+
+func TestCursorReplyAfterAnchor(t *testing.T) {
+	turns := parseCursorTranscript(cursorTranscript)
+	if len(turns) != 4 {
+		t.Fatalf("parsed %d turns, want 4: %+v", len(turns), turns)
+	}
+	tests := []struct {
+		reqID string
+		want  string
+		ok    bool
+	}{
+		{"req-1", "It defines the CLI entry point.\n\nCCB_DONE: req-1", true},
+		{"req-2", "second answer", true},
+		{"req-3", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := cursorReplyAfterAnchor(turns, tt.reqID)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cursorReplyAfterAnchor(%q) = %q, %v, want %q, %v", tt.reqID, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCursorTranscriptPin(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(root, "home-me-a", "agent-transcripts", "chat-a.txt")
+	b := filepath.Join(root, "home-me-b", "agent-transcripts", "chat-b.txt")
+	for _, p := range []string{a, b} {
+		os.MkdirAll(filepath.Dir(p), 0755)
+	}
+	os.WriteFile(a, []byte(cursorTranscript), 0644)
+	os.WriteFile(b, []byte("user:\nCCB_REQ_ID: req-b\n\nassistant:\nanswer b\n"), 0644)
+	os.WriteFile(filepath.Join(root, "home-me-b", "notes.txt"), []byte("user:\nCCB_REQ_ID: req-2\n"), 0644)
+	os.Chtimes(a, now.Add(-time.Minute), now.Add(-time.Minute))
+
+	var pin cursorTranscriptPin
+	if reply, err := pin.read(root, "req-2"); err != nil || reply != "second answer" || pin.path != a {
+		t.Fatalf("read(root, req-2) = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+	if reply, _ := pin.read(filepath.Dir(b), "req-b"); reply != "answer b" {
+		t.Errorf("read(project dir, req-b) = %q", reply)
+	}
+}
@@ -0,0 +1,284 @@
+package comm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// CustomCommunicator handles communication with a provider declared in
+// ccb.config. It reads the provider's session logs in the declared format
+// (config.CustomLogJSONL or config.CustomLogPlain).
+type CustomCommunicator struct {
+	BaseCommunicator
+	Format string
+}
+
+// NewCustomCommunicator creates a communicator for a custom provider.
+func NewCustomCommunicator(backend terminal.Backend, spec config.CustomProvider) *CustomCommunicator {
+	return &CustomCommunicator{
+		BaseCommunicator: BaseCommunicator{
+			ProviderName: spec.Name,
+			Backend:      backend,
+			PollCfg:      DefaultPollConfig(),
+		},
+		Format: spec.LogFormat,
+	}
+}
+
+func (c *CustomCommunicator) Name() string { return c.ProviderName }
+
+func (c *CustomCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
+	return c.SendViaTerminal(ctx, paneID, message)
+}
+
+func (c *CustomCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
+	if opts.LogPath == "" {
+		return "", nil
+	}
+	pin := customLogPin{format: c.Format}
+	return pin.read(opts.LogPath, opts.ReqID)
+}
+
+// WaitForReply pins the log file holding the request's anchor for the
+// whole wait, so another session's log can't be read instead.
+func (c *CustomCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	pin := customLogPin{format: c.Format}
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
+		}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
+}
+
+func (c *CustomCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
+	state := &CaptureState{}
+	if opts.LogPath == "" {
+		return state, nil
+	}
+
+	reply, err := c.ReadReply(ctx, opts)
+	if err != nil {
+		return state, err
+	}
+	if reply != "" {
+		state.AnchorSeen = true
+		state.ReplyLines = strings.Split(reply, "\n")
+		if protocol.IsDoneText(reply, opts.ReqID) {
+			state.DoneSeen = true
+		}
+	}
+	return state, nil
+}
+
+func (c *CustomCommunicator) HealthCheck(ctx context.Context, paneID string) error {
+	if !c.IsAlive(paneID) {
+		return &ErrPaneDead{Provider: c.ProviderName, PaneID: paneID}
+	}
+	return nil
+}
+
+const (
+	// customAnchorScanLimit caps how many of the newest log files are
+	// searched for a request's anchor before the file is pinned.
+	customAnchorScanLimit = 20
+	// plainTailBytes is how much of the end of a plain-tail log is read.
+	plainTailBytes = 512 << 10
+)
+
+// customLogPin remembers which log file holds a request's anchor.
+type customLogPin struct {
+	format string
+	path   string
+}
+
+// read returns the reply following reqID's anchor in logPath, a log file
+// or a directory of them. In a directory the first file found with the
+// anchor is pinned until it no longer carries it.
+func (p *customLogPin) read(logPath string, reqID string) (string, error) {
+	info, err := os.Stat(logPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		reply, _, err := p.readFile(logPath, reqID)
+		return reply, err
+	}
+
+	if p.path != "" {
+		if reply, ok, err := p.readFile(p.path, reqID); err == nil && ok {
+			return reply, nil
+		}
+		p.path = ""
+	}
+	for _, f := range p.listLogs(logPath) {
+		if reply, ok, err := p.readFile(f, reqID); err == nil && ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
+
+func (p *customLogPin) readFile(path string, reqID string) (reply string, ok bool, err error) {
+	if p.format == config.CustomLogJSONL {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, err
+		}
+		reply, ok := jsonlReplyAfterAnchor(string(data), reqID)
+		return reply, ok, nil
+	}
+	text, err := readTail(path, plainTailBytes)
+	if err != nil {
+		return "", false, err
+	}
+	reply, ok = plainReplyAfterAnchor(text, reqID)
+	return reply, ok, nil
+}
+
+// listLogs returns the newest log files in dir, newest first: .jsonl files
+// for jsonl-role-content, any file for plain-tail.
+func (p *customLogPin) listLogs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || (p.format == config.CustomLogJSONL && !strings.HasSuffix(e.Name(), ".jsonl")) {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+	if len(files) > customAnchorScanLimit {
+		files = files[:customAnchorScanLimit]
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(dir, f.Name())
+	}
+	return paths
+}
+
+// customMessage is one line of a jsonl-role-content log.
+type customMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the message's content: a string, or its text parts joined.
+func (m customMessage) text() string {
+	var s string
+	if json.Unmarshal(m.Content, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(m.Content, &parts)
+	var texts []string
+	for _, part := range parts {
+		if (part.Type == "text" || part.Type == "") && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// jsonlReplyAfterAnchor collects the assistant messages after reqID's
+// anchor, up to the next ask. User messages in between (tool results in
+// many CLIs) are skipped. ok is false when the anchor is not in log.
+func jsonlReplyAfterAnchor(log string, reqID string) (reply string, ok bool) {
+	var messages []customMessage
+	for _, line := range strings.Split(log, "\n") {
+		var m customMessage
+		if line = strings.TrimSpace(line); line != "" && json.Unmarshal([]byte(line), &m) == nil {
+			messages = append(messages, m)
+		}
+	}
+
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	start := -1
+	for i, m := range messages {
+		if m.Role == "user" && strings.Contains(m.text(), anchor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+
+	var replyParts []string
+	for _, m := range messages[start+1:] {
+		text := m.text()
+		if m.Role == "user" && strings.Contains(text, protocol.ReqIDPrefix) {
+			break
+		}
+		if m.Role == "assistant" && text != "" {
+			replyParts = append(replyParts, text)
+		}
+	}
+	return strings.Join(replyParts, "\n"), true
+}
+
+// plainReplyAfterAnchor returns the text after reqID's anchor in a plain
+// log, without the rest of the prompt when the log echoes it and without
+// what follows the reply's CCB_DONE line (the CLI's own prompt, say). ok
+// is false when the anchor is not in log.
+func plainReplyAfterAnchor(log string, reqID string) (reply string, ok bool) {
+	idx := strings.LastIndex(log, protocol.ReqIDPrefix+" "+reqID)
+	if idx < 0 {
+		return "", false
+	}
+	text := strings.ReplaceAll(log[idx:], "\r\n", "\n")
+	if nl := strings.Index(text, "\n"); nl >= 0 {
+		text = text[nl+1:]
+	} else {
+		text = ""
+	}
+	lines := strings.Split(protocol.StripEchoedWrapper(text), "\n")
+	done := protocol.DoneLineRE(reqID)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if done.MatchString(lines[i]) {
+			lines = lines[:i+1]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), true
+}
+
+// readTail reads up to the last n bytes of the file at path.
+func readTail(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > n {
+		if _, err := f.Seek(info.Size()-n, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	data, err := io.ReadAll(f)
+	return string(data), err
+}
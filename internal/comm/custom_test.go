@@ -0,0 +1,56 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+func TestCustomLogPinJSONL(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(dir, "a.jsonl")
+	b := filepath.Join(dir, "b.jsonl")
+	writeDroidEvents(t, a, now.Add(-time.Minute),
+		`{"role":"user","content":"CCB_REQ_ID: req-a\n\nhi"}`,
+		`{"role":"assistant","content":[{"type":"tool_use","id":"t1"},{"type":"text","text":"answer a"}]}`,
+		`{"role":"user","content":[{"type":"tool_result","text":"ok"}]}`,
+		`{"role":"assistant","content":"CCB_DONE: req-a"}`,
+		`{"role":"user","content":"CCB_REQ_ID: req-c"}`,
+		`{"role":"assistant","content":"answer c"}`)
+	writeDroidEvents(t, b, now,
+		`{"role":"user","content":"CCB_REQ_ID: req-b"}`,
+		`{"role":"assistant","content":"answer b"}`)
+
+	pin := customLogPin{format: config.CustomLogJSONL}
+	reply, err := pin.read(dir, "req-a")
+	if err != nil || reply != "answer a\nCCB_DONE: req-a" || pin.path != a {
+		t.Fatalf("read(dir, req-a) = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+	if reply, _ := pin.read(b, "req-b"); reply != "answer b" {
+		t.Errorf("read(file, req-b) = %q", reply)
+	}
+	if reply, _ := pin.read(b, "req-a"); reply != "" {
+		t.Errorf("anchor in another file should read empty, got %q", reply)
+	}
+}
+
+func TestCustomLogPinPlain(t *testing.T) {
+	dir := t.TempDir()
+	prompt := protocol.WrapCodexPrompt("what is 2+2?", "20260101-120000-000-1")
+	log := "$ mycli\n" + prompt + "\n4\nCCB_DONE: 20260101-120000-000-1\n> "
+	os.WriteFile(filepath.Join(dir, "session.log"), []byte(log), 0644)
+
+	pin := customLogPin{format: config.CustomLogPlain}
+	reply, err := pin.read(dir, "20260101-120000-000-1")
+	if want := "4\nCCB_DONE: 20260101-120000-000-1"; err != nil || reply != want {
+		t.Errorf("read(dir, 20260101-120000-000-1) = %q, %v, want %q", reply, err, want)
+	}
+	if reply, _ := pin.read(dir, "20260101-120000-000-2"); reply != "" {
+		t.Errorf("missing anchor should read empty, got %q", reply)
+	}
+}
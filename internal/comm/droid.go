@@ -33,7 +33,7 @@ func NewDroidCommunicator(backend terminal.Backend) *DroidCommunicator {
 func (c *DroidCommunicator) Name() string { return "droid" }
 
 func (c *DroidCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
-	return c.SendViaTerminal(paneID, message)
+	return c.SendViaTerminal(ctx, paneID, message)
 }
 
 func (c *DroidCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
@@ -43,41 +43,16 @@ func (c *DroidCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (strin
 	return readDroidSession(opts.LogPath, opts.ReqID)
 }
 
+// WaitForReply pins the session file holding the request's anchor for
+// the whole wait, so a concurrent Droid session can't be read instead.
 func (c *DroidCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
-	cfg := c.PollCfg
-	interval := cfg.InitialInterval
-	if opts.PollMs > 0 {
-		interval = time.Duration(opts.PollMs) * time.Millisecond
-	}
-
-	lastForceRead := time.Now()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", &ErrTimeout{Provider: "droid", ReqID: opts.ReqID}
-		default:
-		}
-
-		reply, err := c.ReadReply(ctx, ReadOpts{
-			LogPath: opts.LogPath,
-			ReqID:   opts.ReqID,
-		})
-		if err == nil && reply != "" && protocol.IsDoneText(reply, opts.ReqID) {
-			return protocol.StripDoneText(reply, opts.ReqID), nil
-		}
-
-		// Check pane alive periodically
-		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
-			lastForceRead = time.Now()
-			if !c.IsAlive(opts.PaneID) {
-				return "", &ErrPaneDead{Provider: "droid", PaneID: opts.PaneID}
-			}
+	var pin droidSessionPin
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
 		}
-
-		time.Sleep(interval)
-		interval = adaptiveSleep(interval, cfg)
-	}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
 }
 
 func (c *DroidCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
@@ -117,19 +92,62 @@ type DroidEvent struct {
 	ID      string `json:"id"`
 }
 
-// readDroidSession reads the latest reply from Droid's session directory.
-func readDroidSession(sessionsDir string, reqID string) (string, error) {
-	eventsFile, err := findLatestDroidEvents(sessionsDir)
-	if err != nil || eventsFile == "" {
-		return "", err
+// droidAnchorScanLimit caps how many of the newest session files are
+// searched for a request's anchor before the session is pinned.
+const droidAnchorScanLimit = 20
+
+// droidSessionPin remembers which events file holds a request's anchor,
+// so a busier concurrent Droid session can't take over mid-request.
+type droidSessionPin struct {
+	path string
+}
+
+// read returns the reply following reqID's anchor. The first file found
+// with the anchor is pinned; the pin only moves if that file disappears
+// or no longer carries the anchor (Droid rewrote or rotated it).
+func (p *droidSessionPin) read(sessionsDir string, reqID string) (string, error) {
+	if p.path != "" {
+		events, err := parseDroidEvents(p.path)
+		if err == nil {
+			if reply, ok := droidReplyAfterAnchor(events, reqID); ok {
+				return reply, nil
+			}
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		p.path = ""
 	}
 
-	events, err := parseDroidEvents(eventsFile)
+	files, err := listDroidEventFiles(sessionsDir)
 	if err != nil {
 		return "", err
 	}
+	if len(files) > droidAnchorScanLimit {
+		files = files[:droidAnchorScanLimit]
+	}
+	for _, f := range files {
+		events, err := parseDroidEvents(f)
+		if err != nil {
+			continue
+		}
+		if reply, ok := droidReplyAfterAnchor(events, reqID); ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
 
-	// Find the anchor and collect reply
+// readDroidSession reads the reply to reqID from whichever recent session
+// file carries its anchor.
+func readDroidSession(sessionsDir string, reqID string) (string, error) {
+	var pin droidSessionPin
+	return pin.read(sessionsDir, reqID)
+}
+
+// droidReplyAfterAnchor collects assistant messages after reqID's anchor.
+// ok is false when the anchor is not in events.
+func droidReplyAfterAnchor(events []DroidEvent, reqID string) (reply string, ok bool) {
 	foundAnchor := false
 	var replyParts []string
 
@@ -153,14 +171,15 @@ func readDroidSession(sessionsDir string, reqID string) (string, error) {
 		}
 	}
 
-	return strings.Join(replyParts, "\n"), nil
+	return strings.Join(replyParts, "\n"), foundAnchor
 }
 
-// findLatestDroidEvents finds the most recent events.jsonl file in the sessions directory.
-func findLatestDroidEvents(sessionsDir string) (string, error) {
+// listDroidEventFiles returns the session event files under sessionsDir,
+// newest first.
+func listDroidEventFiles(sessionsDir string) ([]string, error) {
 	entries, err := os.ReadDir(sessionsDir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	type fileEntry struct {
@@ -214,15 +233,15 @@ func findLatestDroidEvents(sessionsDir string) (string, error) {
 		})
 	}
 
-	if len(files) == 0 {
-		return "", nil
-	}
-
 	sort.Slice(files, func(i, j int) bool {
 		return files[i].modTime.After(files[j].modTime)
 	})
 
-	return files[0].path, nil
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
 }
 
 // parseDroidEvents parses a Droid events JSONL file.
@@ -0,0 +1,85 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeDroidEvents(t *testing.T, path string, mtime time.Time, lines ...string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, mtime, mtime)
+}
+
+func TestDroidSessionPinConcurrentSessions(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(dir, "proj-a", "s1.jsonl")
+	b := filepath.Join(dir, "proj-b", "s2.jsonl")
+	writeDroidEvents(t, a, now.Add(-time.Minute),
+		`{"role":"user","content":"hi\nCCB_REQ_ID: req-a"}`,
+		`{"role":"assistant","content":"answer a"}`)
+	writeDroidEvents(t, b, now,
+		`{"role":"user","content":"CCB_REQ_ID: req-b"}`,
+		`{"role":"assistant","content":"answer b"}`)
+
+	var pin droidSessionPin
+	reply, err := pin.read(dir, "req-a")
+	if err != nil || reply != "answer a" || pin.path != a {
+		t.Fatalf("read = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+
+	// The other session keeps writing and stays newest; the pin holds.
+	writeDroidEvents(t, b, now.Add(time.Minute),
+		`{"role":"user","content":"CCB_REQ_ID: req-b"}`,
+		`{"role":"assistant","content":"answer b"}`,
+		`{"role":"assistant","content":"more b"}`)
+	writeDroidEvents(t, a, now.Add(-time.Minute),
+		`{"role":"user","content":"hi\nCCB_REQ_ID: req-a"}`,
+		`{"role":"assistant","content":"answer a"}`,
+		`{"role":"assistant","content":"CCB_DONE: req-a"}`)
+	reply, err = pin.read(dir, "req-a")
+	if err != nil || !strings.Contains(reply, "CCB_DONE: req-a") || strings.Contains(reply, "b") {
+		t.Fatalf("pinned read = %q, %v", reply, err)
+	}
+
+	// Stateless reads find the anchored file too, not just the newest.
+	if reply, _ := readDroidSession(dir, "req-b"); reply != "answer b\nmore b" {
+		t.Errorf("readDroidSession(req-b) = %q", reply)
+	}
+	if reply, _ := readDroidSession(dir, "req-missing"); reply != "" {
+		t.Errorf("unknown request should read empty, got %q", reply)
+	}
+}
+
+func TestDroidSessionPinFailover(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "s", "old.jsonl")
+	writeDroidEvents(t, old, time.Now(),
+		`{"role":"user","content":"CCB_REQ_ID: r1"}`,
+		`{"role":"assistant","content":"partial"}`)
+
+	var pin droidSessionPin
+	if reply, _ := pin.read(dir, "r1"); reply != "partial" {
+		t.Fatalf("reply = %q", reply)
+	}
+
+	// Droid rotated the session: the pinned file is gone and the
+	// conversation continues in a new file.
+	os.Remove(old)
+	moved := filepath.Join(dir, "s", "new.jsonl")
+	writeDroidEvents(t, moved, time.Now(),
+		`{"role":"user","content":"CCB_REQ_ID: r1"}`,
+		`{"role":"assistant","content":"partial"}`,
+		`{"role":"assistant","content":"rest"}`)
+	reply, err := pin.read(dir, "r1")
+	if err != nil || reply != "partial\nrest" || pin.path != moved {
+		t.Errorf("after failover: %q, %v (pinned %q)", reply, err, pin.path)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 
 // GeminiCommunicator handles communication with Gemini CLI.
 // Gemini stores chats in JSON files under ~/.gemini/tmp/<hash>/chats/
+// It serves Qwen Code too, a fork keeping the same layout under ~/.qwen/tmp.
 type GeminiCommunicator struct {
 	BaseCommunicator
 }
@@ -32,10 +34,17 @@ func NewGeminiCommunicator(backend terminal.Backend) *GeminiCommunicator {
 	}
 }
 
-func (c *GeminiCommunicator) Name() string { return "gemini" }
+// NewQwenCommunicator creates a communicator for Qwen Code.
+func NewQwenCommunicator(backend terminal.Backend) *GeminiCommunicator {
+	c := NewGeminiCommunicator(backend)
+	c.ProviderName = "qwen"
+	return c
+}
+
+func (c *GeminiCommunicator) Name() string { return c.ProviderName }
 
 func (c *GeminiCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
-	return c.SendViaTerminal(paneID, message)
+	return c.SendViaTerminal(ctx, paneID, message)
 }
 
 func (c *GeminiCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
@@ -46,40 +55,9 @@ func (c *GeminiCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (stri
 }
 
 func (c *GeminiCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
-	cfg := c.PollCfg
-	interval := cfg.InitialInterval
-	if opts.PollMs > 0 {
-		interval = time.Duration(opts.PollMs) * time.Millisecond
-	}
-
-	lastForceRead := time.Now()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", &ErrTimeout{Provider: "gemini", ReqID: opts.ReqID}
-		default:
-		}
-
-		reply, err := c.ReadReply(ctx, ReadOpts{
-			LogPath: opts.LogPath,
-			ReqID:   opts.ReqID,
-		})
-		if err == nil && reply != "" && protocol.IsDoneText(reply, opts.ReqID) {
-			return protocol.StripDoneText(reply, opts.ReqID), nil
-		}
-
-		// Check pane alive periodically
-		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
-			lastForceRead = time.Now()
-			if !c.IsAlive(opts.PaneID) {
-				return "", &ErrPaneDead{Provider: "gemini", PaneID: opts.PaneID}
-			}
-		}
-
-		time.Sleep(interval)
-		interval = adaptiveSleep(interval, cfg)
-	}
+	return c.pollReply(ctx, opts, func() (string, error) {
+		return c.ReadReply(ctx, ReadOpts{LogPath: opts.LogPath, ReqID: opts.ReqID})
+	})
 }
 
 func (c *GeminiCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
@@ -104,7 +82,7 @@ func (c *GeminiCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*
 
 func (c *GeminiCommunicator) HealthCheck(ctx context.Context, paneID string) error {
 	if !c.IsAlive(paneID) {
-		return &ErrPaneDead{Provider: "gemini", PaneID: paneID}
+		return &ErrPaneDead{Provider: c.ProviderName, PaneID: paneID}
 	}
 	return nil
 }
@@ -123,9 +101,9 @@ func readGeminiChat(chatsDir string, reqID string) (string, error) {
 		return "", err
 	}
 
-	messages, err := parseGeminiMessages(sessionFile)
+	messages, err := readGeminiMessagesStable(sessionFile)
 	if err != nil {
-		return "", nil // retry on parse error (in-place writes)
+		return "", err
 	}
 
 	// Find the last model response after our request
@@ -185,13 +163,44 @@ func findLatestGeminiSession(chatsDir string) (string, error) {
 	return files[0].path, nil
 }
 
-// parseGeminiMessages parses a Gemini chat JSON file into messages.
-func parseGeminiMessages(sessionFile string) ([]GeminiMessage, error) {
-	data, err := os.ReadFile(sessionFile)
-	if err != nil {
-		return nil, err
+// Gemini rewrites its chat file in place, so a read can catch it half
+// written. Such reads are retried with a growing backoff.
+var (
+	geminiReadRetries  = 4
+	geminiRetryBackoff = 15 * time.Millisecond
+)
+
+// errGeminiPartialWrite is returned when the chat file never settled into
+// valid JSON; the caller polls again rather than taking an empty reply.
+var errGeminiPartialWrite = errors.New("gemini chat file is being rewritten")
+
+// readGeminiMessagesStable reads and parses a chat file, rereading after
+// a short backoff when it fails to parse or its length changed during the
+// read (the file's size no longer matches the bytes read), so a truncated
+// reply is never returned as complete.
+func readGeminiMessagesStable(sessionFile string) ([]GeminiMessage, error) {
+	backoff := geminiRetryBackoff
+	for attempt := 0; ; attempt++ {
+		data, err := os.ReadFile(sessionFile)
+		if err != nil {
+			return nil, err
+		}
+		messages, perr := decodeGeminiMessages(data)
+		if perr == nil {
+			if info, err := os.Stat(sessionFile); err == nil && info.Size() == int64(len(data)) {
+				return messages, nil
+			}
+		}
+		if attempt >= geminiReadRetries {
+			return nil, errGeminiPartialWrite
+		}
+		time.Sleep(backoff)
+		backoff *= 2
 	}
+}
 
+// decodeGeminiMessages decodes the contents of a Gemini chat file.
+func decodeGeminiMessages(data []byte) ([]GeminiMessage, error) {
 	// Gemini uses two possible formats:
 	// 1. { "messages": [ { "role": "...", "content": "...", "parts": [...] } ] }
 	// 2. Array of messages directly
@@ -0,0 +1,43 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const geminiChat = `{"messages":[{"role":"user","content":"q\nCCB_REQ_ID: r1"},{"role":"model","content":"full answer\nCCB_DONE: r1"}]}`
+
+func TestReadGeminiChatRetriesPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-1.json")
+	// Half-written file, as seen mid-rewrite.
+	os.WriteFile(path, []byte(geminiChat[:len(geminiChat)/2]), 0644)
+
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		os.WriteFile(path, []byte(geminiChat), 0644)
+		close(done)
+	}()
+
+	reply, err := readGeminiChat(dir, "r1")
+	<-done
+	if err != nil || reply != "full answer\nCCB_DONE: r1" {
+		t.Fatalf("readGeminiChat = %q, %v", reply, err)
+	}
+}
+
+func TestReadGeminiChatGivesUpOnBrokenFile(t *testing.T) {
+	oldRetries, oldBackoff := geminiReadRetries, geminiRetryBackoff
+	geminiReadRetries, geminiRetryBackoff = 2, time.Millisecond
+	defer func() { geminiReadRetries, geminiRetryBackoff = oldRetries, oldBackoff }()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session-1.json"), []byte(`{"messages":[{"role":"user"`), 0644)
+	reply, err := readGeminiChat(dir, "r1")
+	if err != errGeminiPartialWrite || reply != "" {
+		t.Errorf("readGeminiChat = %q, %v; want errGeminiPartialWrite", reply, err)
+	}
+}
@@ -0,0 +1,237 @@
+package comm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// GooseCommunicator handles communication with Goose.
+// Goose stores sessions in ~/.config/goose/sessions/<session-id>.jsonl: a
+// metadata line (working_dir, description) followed by one message per line.
+type GooseCommunicator struct {
+	BaseCommunicator
+}
+
+// NewGooseCommunicator creates a new Goose communicator.
+func NewGooseCommunicator(backend terminal.Backend) *GooseCommunicator {
+	return &GooseCommunicator{
+		BaseCommunicator: BaseCommunicator{
+			ProviderName: "goose",
+			Backend:      backend,
+			PollCfg:      DefaultPollConfig(),
+		},
+	}
+}
+
+func (c *GooseCommunicator) Name() string { return "goose" }
+
+func (c *GooseCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
+	return c.SendViaTerminal(ctx, paneID, message)
+}
+
+func (c *GooseCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
+	if opts.LogPath == "" {
+		return "", nil
+	}
+	var pin gooseSessionPin
+	return pin.read(opts.LogPath, opts.ReqID)
+}
+
+// WaitForReply pins the session file holding the request's anchor for
+// the whole wait, so a concurrent Goose session can't be read instead.
+func (c *GooseCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
+	var pin gooseSessionPin
+	return c.pollReply(ctx, opts, func() (string, error) {
+		if opts.LogPath == "" {
+			return "", nil
+		}
+		return pin.read(opts.LogPath, opts.ReqID)
+	})
+}
+
+func (c *GooseCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
+	state := &CaptureState{}
+	if opts.LogPath == "" {
+		return state, nil
+	}
+
+	reply, err := c.ReadReply(ctx, opts)
+	if err != nil {
+		return state, err
+	}
+	if reply != "" {
+		state.AnchorSeen = true
+		state.ReplyLines = strings.Split(reply, "\n")
+		if protocol.IsDoneText(reply, opts.ReqID) {
+			state.DoneSeen = true
+		}
+	}
+	return state, nil
+}
+
+func (c *GooseCommunicator) HealthCheck(ctx context.Context, paneID string) error {
+	if !c.IsAlive(paneID) {
+		return &ErrPaneDead{Provider: "goose", PaneID: paneID}
+	}
+	return nil
+}
+
+// GooseMessage is one line of a Goose session file. The metadata line has
+// WorkingDir set and no Role.
+type GooseMessage struct {
+	Role       string             `json:"role"`
+	Content    []GooseMessagePart `json:"content"`
+	WorkingDir string             `json:"working_dir"`
+}
+
+// GooseMessagePart is one part of a Goose message; only "text" parts carry
+// text, the rest are tool requests and responses.
+type GooseMessagePart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// text joins the message's text parts.
+func (m GooseMessage) text() string {
+	var parts []string
+	for _, p := range m.Content {
+		if p.Type == "text" && p.Text != "" {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// gooseAnchorScanLimit caps how many of the newest session files are
+// searched for a request's anchor before the session is pinned.
+const gooseAnchorScanLimit = 20
+
+// gooseSessionPin remembers which session file holds a request's anchor.
+type gooseSessionPin struct {
+	path string
+}
+
+// read returns the reply following reqID's anchor in sessionPath, a
+// session file or the sessions directory. In the directory the first file
+// found with the anchor is pinned until it no longer carries it.
+func (p *gooseSessionPin) read(sessionPath string, reqID string) (string, error) {
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		messages, err := parseGooseSession(sessionPath)
+		if err != nil {
+			return "", err
+		}
+		reply, _ := gooseReplyAfterAnchor(messages, reqID)
+		return reply, nil
+	}
+
+	if p.path != "" {
+		if messages, err := parseGooseSession(p.path); err == nil {
+			if reply, ok := gooseReplyAfterAnchor(messages, reqID); ok {
+				return reply, nil
+			}
+		}
+		p.path = ""
+	}
+	files, err := listGooseSessions(sessionPath)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > gooseAnchorScanLimit {
+		files = files[:gooseAnchorScanLimit]
+	}
+	for _, f := range files {
+		messages, err := parseGooseSession(f)
+		if err != nil {
+			continue
+		}
+		if reply, ok := gooseReplyAfterAnchor(messages, reqID); ok {
+			p.path = f
+			return reply, nil
+		}
+	}
+	return "", nil
+}
+
+// gooseReplyAfterAnchor collects the assistant text after reqID's anchor.
+// ok is false when the anchor is not in messages.
+func gooseReplyAfterAnchor(messages []GooseMessage, reqID string) (reply string, ok bool) {
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	start := -1
+	for i, m := range messages {
+		if m.Role == "user" && strings.Contains(m.text(), anchor) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+
+	var replyParts []string
+	for _, m := range messages[start+1:] {
+		if m.Role != "assistant" {
+			continue
+		}
+		if text := m.text(); text != "" {
+			replyParts = append(replyParts, text)
+		}
+	}
+	return strings.Join(replyParts, "\n"), true
+}
+
+// listGooseSessions returns the session files in sessionsDir, newest first.
+func listGooseSessions(sessionsDir string) ([]string, error) {
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(sessionsDir, f.Name())
+	}
+	return paths, nil
+}
+
+// parseGooseSession parses a Goose session JSONL file, skipping lines that
+// don't parse (a line being written).
+func parseGooseSession(path string) ([]GooseMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var messages []GooseMessage
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var m GooseMessage
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
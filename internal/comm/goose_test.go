@@ -0,0 +1,46 @@
+package comm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGooseSessionPin(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := filepath.Join(dir, "a.jsonl")
+	b := filepath.Join(dir, "b.jsonl")
+	writeDroidEvents(t, a, now.Add(-time.Minute),
+		`{"working_dir":"/work/a","description":"a"}`,
+		`{"role":"user","content":[{"type":"text","text":"CCB_REQ_ID: req-a\n\nhi"}]}`,
+		`{"role":"assistant","content":[{"type":"toolRequest","id":"t1"},{"type":"text","text":"answer a"}]}`,
+		`{"role":"user","content":[{"type":"toolResponse","id":"t1"}]}`,
+		`{"role":"assistant","content":[{"type":"text","text":"CCB_DONE: req-a"}]}`)
+	writeDroidEvents(t, b, now,
+		`{"working_dir":"/work/b"}`,
+		`{"role":"user","content":[{"type":"text","text":"CCB_REQ_ID: req-b"}]}`,
+		`{"role":"assistant","content":[{"type":"text","text":"answer b"}]}`)
+
+	var pin gooseSessionPin
+	reply, err := pin.read(dir, "req-a")
+	if err != nil || reply != "answer a\nCCB_DONE: req-a" || pin.path != a {
+		t.Fatalf("read(dir, req-a) = %q, %v (pinned %q)", reply, err, pin.path)
+	}
+	if reply, _ := pin.read(b, "req-b"); reply != "answer b" {
+		t.Errorf("read(file, req-b) = %q", reply)
+	}
+	if reply, _ := pin.read(b, "req-a"); reply != "" {
+		t.Errorf("anchor in another file should read empty, got %q", reply)
+	}
+
+	// A partly written last line is skipped until complete.
+	f, _ := os.OpenFile(b, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"role":"assistant","content":[{"type":"te`)
+	f.Close()
+	if reply, err := pin.read(b, "req-b"); err != nil || strings.Contains(reply, "te") {
+		t.Errorf("partial line: %q, %v", reply, err)
+	}
+}
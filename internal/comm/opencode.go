@@ -34,7 +34,7 @@ func NewOpenCodeCommunicator(backend terminal.Backend) *OpenCodeCommunicator {
 func (c *OpenCodeCommunicator) Name() string { return "opencode" }
 
 func (c *OpenCodeCommunicator) SendPrompt(ctx context.Context, paneID string, message string) error {
-	return c.SendViaTerminal(paneID, message)
+	return c.SendViaTerminal(ctx, paneID, message)
 }
 
 func (c *OpenCodeCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (string, error) {
@@ -45,40 +45,9 @@ func (c *OpenCodeCommunicator) ReadReply(ctx context.Context, opts ReadOpts) (st
 }
 
 func (c *OpenCodeCommunicator) WaitForReply(ctx context.Context, opts WaitOpts) (string, error) {
-	cfg := c.PollCfg
-	interval := cfg.InitialInterval
-	if opts.PollMs > 0 {
-		interval = time.Duration(opts.PollMs) * time.Millisecond
-	}
-
-	lastForceRead := time.Now()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return "", &ErrTimeout{Provider: "opencode", ReqID: opts.ReqID}
-		default:
-		}
-
-		reply, err := c.ReadReply(ctx, ReadOpts{
-			LogPath: opts.LogPath,
-			ReqID:   opts.ReqID,
-		})
-		if err == nil && reply != "" && protocol.IsDoneText(reply, opts.ReqID) {
-			return protocol.StripDoneText(reply, opts.ReqID), nil
-		}
-
-		// Check pane alive periodically
-		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
-			lastForceRead = time.Now()
-			if !c.IsAlive(opts.PaneID) {
-				return "", &ErrPaneDead{Provider: "opencode", PaneID: opts.PaneID}
-			}
-		}
-
-		time.Sleep(interval)
-		interval = adaptiveSleep(interval, cfg)
-	}
+	return c.pollReply(ctx, opts, func() (string, error) {
+		return c.ReadReply(ctx, ReadOpts{LogPath: opts.LogPath, ReqID: opts.ReqID})
+	})
 }
 
 func (c *OpenCodeCommunicator) CaptureState(ctx context.Context, opts ReadOpts) (*CaptureState, error) {
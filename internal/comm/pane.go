@@ -0,0 +1,103 @@
+package comm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// DefaultQuickTimeout is the reply budget for pane-capture ("quick") asks.
+const DefaultQuickTimeout = 30 * time.Second
+
+// paneBorderCutset is trimmed from captured lines; TUIs frame their
+// transcript with box-drawing borders and prompt glyphs.
+const paneBorderCutset = " \t│┃|>▌"
+
+// ExtractPaneReply extracts the reply for reqID from captured pane text.
+// The echoed prompt (anchor, instructions and the DONE line it asks for)
+// is skipped; the reply is whatever sits between the echo and the real
+// CCB_DONE line. The second return value reports whether that line was seen.
+func ExtractPaneReply(text string, reqID string) (string, bool) {
+	lines := strings.Split(StripANSI(text), "\n")
+	anchor := protocol.ReqIDPrefix + " " + reqID
+	done := protocol.DonePrefix + " " + reqID
+
+	anchorIdx := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], anchor) {
+			anchorIdx = i
+			break
+		}
+	}
+	if anchorIdx < 0 {
+		return "", false
+	}
+
+	start := anchorIdx + 1
+	for i := start; i < len(lines); i++ {
+		if strings.Trim(lines[i], paneBorderCutset) != done {
+			continue
+		}
+		if isEchoedDoneLine(lines, i) {
+			start = i + 1
+			continue
+		}
+		var reply []string
+		for _, l := range lines[start:i] {
+			reply = append(reply, strings.TrimRight(strings.TrimLeft(l, paneBorderCutset), " \t│┃"))
+		}
+		return strings.Trim(protocol.StripEchoedWrapper(strings.Join(reply, "\n")), "\n\r\t "), true
+	}
+	return "", false
+}
+
+// isEchoedDoneLine reports whether the DONE line at idx is part of the
+// echoed prompt, i.e. directly follows the "exact final line" instruction.
+func isEchoedDoneLine(lines []string, idx int) bool {
+	for j := idx - 1; j >= 0; j-- {
+		prev := strings.TrimSpace(lines[j])
+		if prev == "" {
+			continue
+		}
+		return strings.Contains(prev, "exact final line")
+	}
+	return false
+}
+
+// WaitForPaneReply polls the pane contents until the reply for reqID is
+// framed by its markers. It never touches provider log files.
+func WaitForPaneReply(ctx context.Context, backend terminal.Backend, provider, paneID, reqID string) (string, error) {
+	if backend == nil {
+		return "", &ErrNoBackend{Provider: provider}
+	}
+	cfg := DefaultPollConfig()
+	interval := cfg.InitialInterval
+	lastAliveCheck := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", &ErrTimeout{Provider: provider, ReqID: reqID}
+		default:
+		}
+
+		if text, err := backend.CapturePane(paneID); err == nil {
+			if reply, ok := ExtractPaneReply(text, reqID); ok {
+				return reply, nil
+			}
+		}
+
+		if time.Since(lastAliveCheck) > cfg.ForceReadEvery {
+			lastAliveCheck = time.Now()
+			if !backend.IsAlive(paneID) {
+				return "", &ErrPaneDead{Provider: provider, PaneID: paneID}
+			}
+		}
+
+		time.Sleep(interval)
+		interval = adaptiveSleep(interval, cfg)
+	}
+}
@@ -0,0 +1,68 @@
+package comm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+func TestExtractPaneReply(t *testing.T) {
+	reqID := "20260125-143000-123-12345"
+	echo := strings.TrimRight(protocol.WrapCodexPrompt("What is 2+2?", reqID), "\n")
+
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "reply after echoed prompt",
+			text:   "$ codex\n" + echo + "\n\nThe answer is 4.\n\nCCB_DONE: " + reqID + "\n> ",
+			want:   "The answer is 4.",
+			wantOK: true,
+		},
+		{
+			name:   "still streaming",
+			text:   echo + "\nThe answer",
+			wantOK: false,
+		},
+		{
+			name: "bordered TUI lines",
+			text: "│ " + protocol.ReqIDPrefix + " " + reqID + "\n│ End your reply with this exact final line (verbatim, on its own line):\n│ CCB_DONE: " + reqID +
+				"\n│ line one\n│ line two │\n│ CCB_DONE: " + reqID + " │\n",
+			want:   "line one\nline two",
+			wantOK: true,
+		},
+		{
+			name:   "instruction wrapped before the echoed DONE line",
+			text:   protocol.ReqIDPrefix + " " + reqID + "\n\nWhat is 2+2?\n\nIMPORTANT:\n- Reply normally.\n- End your reply with this exact final line (verbatim, on its\nown line):\n\nThe answer is 4.\nCCB_DONE: " + reqID,
+			want:   "The answer is 4.",
+			wantOK: true,
+		},
+		{
+			name:   "no anchor",
+			text:   "some unrelated output\nCCB_DONE: " + reqID,
+			wantOK: false,
+		},
+		{
+			name:   "uses latest anchor",
+			text:   echo + "\nold reply\nCCB_DONE: " + reqID + "\n" + echo + "\nnew reply\nCCB_DONE: " + reqID,
+			want:   "new reply",
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractPaneReply(tt.text, reqID)
+			if ok != tt.wantOK {
+				t.Fatalf("ExtractPaneReply ok = %v, want %v (reply %q)", ok, tt.wantOK, got)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractPaneReply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package comm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// pollReply polls read until the reply carries the CCB_DONE marker for
+// opts.ReqID, checking pane liveness along the way. While the reply is
+// still growing, its completed lines are passed to opts.OnLines. With
+// opts.QuietDone set, a reply that settles without the marker is accepted
+// too (see WaitOpts). opts.OnAnchor is called when the first reply text
+// after the anchor appears.
+func (b *BaseCommunicator) pollReply(ctx context.Context, opts WaitOpts, read func() (string, error)) (string, error) {
+	cfg := b.PollCfg
+	interval := cfg.InitialInterval
+	if opts.PollMs > 0 {
+		interval = time.Duration(opts.PollMs) * time.Millisecond
+	}
+
+	lastForceRead := time.Now()
+	var stream lineStreamer
+	var lastReply string
+	lastChange := time.Now()
+	anchored := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", &ErrTimeout{Provider: b.ProviderName, ReqID: opts.ReqID}
+		default:
+		}
+
+		reply, err := read()
+		if err == nil && reply != "" {
+			if !anchored {
+				anchored = true
+				if opts.OnAnchor != nil {
+					opts.OnAnchor()
+				}
+			}
+			if protocol.IsDoneText(reply, opts.ReqID) {
+				return protocol.StripDoneText(reply, opts.ReqID), nil
+			}
+			// Stream the text the final reply is cut from, so clients can
+			// match the two up.
+			stream.push(protocol.StripEchoedWrapper(reply), opts.OnLines)
+
+			if reply != lastReply {
+				lastReply, lastChange = reply, time.Now()
+			}
+			if reason := b.heuristicDone(opts, reply, lastChange); reason != "" && settled(reason, reply, read) {
+				if opts.OnHeuristicDone != nil {
+					opts.OnHeuristicDone(reason)
+				}
+				return strings.TrimRight(protocol.StripEchoedWrapper(reply), " \t\r\n"), nil
+			}
+		}
+
+		// Check pane alive periodically
+		if opts.PaneID != "" && time.Since(lastForceRead) > cfg.ForceReadEvery {
+			lastForceRead = time.Now()
+			if !b.IsAlive(opts.PaneID) {
+				return "", &ErrPaneDead{Provider: b.ProviderName, PaneID: opts.PaneID}
+			}
+		}
+
+		time.Sleep(interval)
+		interval = adaptiveSleep(interval, cfg)
+	}
+}
+
+// heuristicDone returns why a reply without CCB_DONE counts as finished,
+// or "" if it doesn't. Only substantive replies qualify.
+func (b *BaseCommunicator) heuristicDone(opts WaitOpts, reply string, lastChange time.Time) string {
+	if opts.QuietDone <= 0 || strings.TrimSpace(reply) == "" {
+		return ""
+	}
+	if b.TurnComplete != nil && b.TurnComplete(opts.LogPath, opts.ReqID) {
+		return "turn_complete"
+	}
+	if time.Since(lastChange) >= opts.QuietDone {
+		return "quiet"
+	}
+	return ""
+}
+
+// settled reports whether reply, found done for reason, is final. The turn
+// can end in the log after reply was read, with text written in between,
+// so a turn_complete reply only counts if reading again gives the same.
+func settled(reason string, reply string, read func() (string, error)) bool {
+	if reason != "turn_complete" {
+		return true
+	}
+	again, err := read()
+	return err == nil && again == reply
+}
+
+// lineStreamer reports each line of a growing partial reply once. The
+// last line is held back until a newline ends it.
+type lineStreamer struct {
+	sent int
+}
+
+func (s *lineStreamer) push(partial string, onLines func([]string)) {
+	if onLines == nil {
+		return
+	}
+	lines := strings.Split(strings.ReplaceAll(partial, "\r\n", "\n"), "\n")
+	complete := lines[:len(lines)-1]
+	if len(complete) > s.sent {
+		onLines(complete[s.sent:])
+		s.sent = len(complete)
+	}
+}
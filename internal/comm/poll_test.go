@@ -0,0 +1,160 @@
+package comm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollReplyStreamsLines(t *testing.T) {
+	reqID := "20260125-143000-123-12345"
+	reads := []string{
+		"",
+		"first line\nsecond li",
+		"first line\nsecond line\nthird",
+		"first line\nsecond line\nthird line\nCCB_DONE: " + reqID + "\n",
+	}
+	i := 0
+	read := func() (string, error) {
+		r := reads[i]
+		if i < len(reads)-1 {
+			i++
+		}
+		return r, nil
+	}
+
+	var streamed []string
+	anchors := 0
+	b := &BaseCommunicator{ProviderName: "codex", PollCfg: DefaultPollConfig()}
+	reply, err := b.pollReply(context.Background(), WaitOpts{
+		ReqID:    reqID,
+		PollMs:   1,
+		OnLines:  func(lines []string) { streamed = append(streamed, lines...) },
+		OnAnchor: func() { anchors++ },
+	}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "first line\nsecond line\nthird line" {
+		t.Errorf("reply = %q", reply)
+	}
+	if want := []string{"first line", "second line"}; !reflect.DeepEqual(streamed, want) {
+		t.Errorf("streamed = %q, want %q", streamed, want)
+	}
+	if anchors != 1 {
+		t.Errorf("OnAnchor called %d times, want 1", anchors)
+	}
+}
+
+func TestPollReplyStreamsStrippedLines(t *testing.T) {
+	// Echoed scaffolding is left out of the stream as it is of the reply,
+	// so the streamed lines are a prefix of the reply's.
+	reqID := "20260125-143000-123-12345"
+	reads := []string{
+		"CCB_REQ_ID: " + reqID + "\nReply normally.\nfirst line\nsec",
+		"CCB_REQ_ID: " + reqID + "\nReply normally.\nfirst line\nsecond line\nCCB_DONE: " + reqID + "\n",
+	}
+	i := 0
+	read := func() (string, error) {
+		r := reads[i]
+		if i < len(reads)-1 {
+			i++
+		}
+		return r, nil
+	}
+	var streamed []string
+	b := &BaseCommunicator{ProviderName: "codex", PollCfg: DefaultPollConfig()}
+	reply, err := b.pollReply(context.Background(), WaitOpts{
+		ReqID:   reqID,
+		PollMs:  1,
+		OnLines: func(lines []string) { streamed = append(streamed, lines...) },
+	}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "first line\nsecond line" {
+		t.Errorf("reply = %q", reply)
+	}
+	if want := []string{"first line"}; !reflect.DeepEqual(streamed, want) {
+		t.Errorf("streamed = %q, want %q", streamed, want)
+	}
+}
+
+func TestPollReplyTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	b := &BaseCommunicator{ProviderName: "gemini", PollCfg: DefaultPollConfig()}
+	_, err := b.pollReply(ctx, WaitOpts{ReqID: "x", PollMs: 1}, func() (string, error) { return "partial", nil })
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for reply from gemini") {
+		t.Errorf("err = %v, want timeout", err)
+	}
+}
+
+func TestPollReplyHeuristicDone(t *testing.T) {
+	b := &BaseCommunicator{ProviderName: "codex", PollCfg: DefaultPollConfig()}
+	var reason string
+	opts := WaitOpts{
+		ReqID:           "r1",
+		PollMs:          1,
+		QuietDone:       30 * time.Millisecond,
+		OnHeuristicDone: func(r string) { reason = r },
+	}
+
+	// A reply that stops growing is accepted after QuietDone.
+	reply, err := b.pollReply(context.Background(), opts, func() (string, error) { return "all done\n", nil })
+	if err != nil || reply != "all done" || reason != "quiet" {
+		t.Fatalf("quiet: reply = %q, err = %v, reason = %q", reply, err, reason)
+	}
+
+	// Turn-complete metadata wins without waiting.
+	reason = ""
+	opts.QuietDone = time.Hour
+	b.TurnComplete = func(logPath, reqID string) bool { return reqID == "r1" }
+	if reply, _ := b.pollReply(context.Background(), opts, func() (string, error) { return "answer", nil }); reply != "answer" || reason != "turn_complete" {
+		t.Errorf("turn_complete: reply = %q, reason = %q", reply, reason)
+	}
+
+	// A turn that ends after the reply was read is only accepted once a
+	// fresh read shows nothing more came in.
+	reads := []string{"partial", "partial answer", "partial answer"}
+	i := 0
+	read := func() (string, error) {
+		r := reads[i]
+		if i < len(reads)-1 {
+			i++
+		}
+		return r, nil
+	}
+	if reply, _ := b.pollReply(context.Background(), opts, read); reply != "partial answer" {
+		t.Errorf("turn_complete race: reply = %q, want the text written before the turn ended", reply)
+	}
+
+	// Whitespace is not substantive output; without QuietDone nothing changes.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := b.pollReply(ctx, opts, func() (string, error) { return "  \n", nil }); err == nil {
+		t.Error("blank reply must not complete heuristically")
+	}
+}
+
+func TestClaudeTurnComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	log := `{"type":"user","message":{"content":"q CCB_REQ_ID: r1"}}
+{"type":"assistant","message":{"content":"thinking","stop_reason":"tool_use"}}
+`
+	os.WriteFile(path, []byte(log), 0644)
+	if claudeTurnComplete(path, "r1") {
+		t.Error("tool_use is not the end of the turn")
+	}
+	os.WriteFile(path, []byte(log+`{"type":"assistant","message":{"content":"done","stop_reason":"end_turn"}}`+"\n"), 0644)
+	if !claudeTurnComplete(path, "r1") {
+		t.Error("end_turn after the anchor should complete the turn")
+	}
+	if claudeTurnComplete(path, "other") {
+		t.Error("a different request's anchor must not match")
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Aliases returns the user's command aliases from the "aliases" object of
+// ccb.config, global entries first, then the project's, which win:
+//
+//	{"aliases": {"rv": "ask codex --template review", "all": ["ask", "codex,claude"]}}
+//
+// A value is a command line, split like a shell would (quotes, backslash
+// escapes), or a list of arguments. Entries that do not parse are skipped.
+func Aliases(workDir string) map[string][]string {
+	project, global := configPaths(workDir)
+	paths := []string{global}
+	if workDir != "" {
+		paths = append(paths, project)
+	}
+	aliases := make(map[string][]string)
+	for _, path := range paths {
+		entries, _ := readConfig(path)["aliases"].(map[string]interface{})
+		for name, v := range entries {
+			var args []string
+			switch v := v.(type) {
+			case string:
+				var err error
+				if args, err = SplitArgs(v); err != nil {
+					continue
+				}
+			case []interface{}:
+				for _, a := range v {
+					if s, ok := a.(string); ok {
+						args = append(args, s)
+					}
+				}
+			}
+			if name = strings.TrimSpace(name); name != "" && len(args) > 0 {
+				aliases[name] = args
+			}
+		}
+	}
+	return aliases
+}
+
+// SplitArgs splits a command line into arguments: whitespace separates
+// them, single quotes keep text literally, double quotes keep it but allow
+// backslash escapes, and a backslash outside quotes escapes the next
+// character.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"ask codex --template review", []string{"ask", "codex", "--template", "review"}, false},
+		{`  ask  "two words" 'it''s' `, []string{"ask", "two words", "its"}, false},
+		{`ask codex "say \"hi\""`, []string{"ask", "codex", `say "hi"`}, false},
+		{`a\ b 'c\d' ""`, []string{"a b", `c\d`, ""}, false},
+		{"", nil, false},
+		{`ask "open`, nil, true},
+		{`ask \`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitArgs(%q) = %q, %v; want %q, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename),
+		[]byte(`{"aliases": {"rv": "ask codex --template review", "all": ["ask", "codex,claude"], "bad": "ask \"x"}}`), 0644)
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"aliases": {"rv": "ask gemini --template review", "st": "daemon status"}}`), 0644)
+
+	want := map[string][]string{
+		"rv":  {"ask", "gemini", "--template", "review"},
+		"all": {"ask", "codex,claude"},
+		"st":  {"daemon", "status"},
+	}
+	if got := Aliases(work); !reflect.DeepEqual(got, want) {
+		t.Errorf("Aliases(project) = %v, want %v", got, want)
+	}
+	if got := Aliases(""); got["rv"][1] != "codex" || got["st"] != nil {
+		t.Errorf("Aliases(\"\") = %v, want global entries only", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -14,10 +15,24 @@ const ConfigFilename = "ccb.config"
 var (
 	DefaultProviders = []string{"codex", "gemini", "opencode", "claude"}
 	allowedProviders = map[string]bool{
-		"codex": true, "gemini": true, "opencode": true, "claude": true, "droid": true,
+		"codex": true, "gemini": true, "opencode": true, "claude": true, "droid": true, "aider": true, "goose": true, "amp": true, "cursor": true, "qwen": true,
 	}
 )
 
+// KnownProviders returns the names of all supported providers, sorted:
+// the built-in ones and those ccb.config declares for the current
+// directory (see CustomProviders).
+func KnownProviders() []string {
+	names := make([]string, 0, len(allowedProviders))
+	for name := range allowedProviders {
+		names = append(names, name)
+	}
+	cwd, _ := os.Getwd()
+	names = append(names, customProviderNames(cwd)...)
+	sort.Strings(names)
+	return names
+}
+
 // StartConfig holds parsed CCB start configuration.
 type StartConfig struct {
 	Data map[string]interface{}
@@ -99,8 +114,9 @@ func parseTokens(raw string) []string {
 	return result
 }
 
-// normalizeProviders filters and deduplicates provider tokens.
-func normalizeProviders(tokens []string) ([]string, bool) {
+// normalizeProviders filters and deduplicates provider tokens, keeping
+// built-in providers and the custom ones named in custom.
+func normalizeProviders(tokens []string, custom map[string]bool) ([]string, bool) {
 	var providers []string
 	seen := make(map[string]bool)
 	cmdEnabled := false
@@ -114,7 +130,7 @@ func normalizeProviders(tokens []string) ([]string, bool) {
 			cmdEnabled = true
 			continue
 		}
-		if !allowedProviders[token] {
+		if !allowedProviders[token] && !custom[token] {
 			continue
 		}
 		if seen[token] {
@@ -140,12 +156,12 @@ func readConfig(path string) map[string]interface{} {
 	// Try JSON parse
 	var obj interface{}
 	if err := json.Unmarshal(data, &obj); err == nil {
-		return parseConfigObj(obj)
+		return parseConfigObj(obj, customNames(path))
 	}
 
 	// Fallback: parse as token list
 	tokens := parseTokens(raw)
-	providers, cmdEnabled := normalizeProviders(tokens)
+	providers, cmdEnabled := normalizeProviders(tokens, customNames(path))
 	result := map[string]interface{}{"providers": providers}
 	if cmdEnabled {
 		result["cmd"] = true
@@ -153,8 +169,9 @@ func readConfig(path string) map[string]interface{} {
 	return result
 }
 
-// parseConfigObj parses a JSON-decoded config object.
-func parseConfigObj(obj interface{}) map[string]interface{} {
+// parseConfigObj parses a JSON-decoded config object. Custom providers
+// named in custom are kept in "providers".
+func parseConfigObj(obj interface{}, custom map[string]bool) map[string]interface{} {
 	switch v := obj.(type) {
 	case map[string]interface{}:
 		data := make(map[string]interface{})
@@ -177,7 +194,7 @@ func parseConfigObj(obj interface{}) map[string]interface{} {
 			}
 		}
 		if len(tokens) > 0 {
-			providers, cmdEnabled := normalizeProviders(tokens)
+			providers, cmdEnabled := normalizeProviders(tokens, custom)
 			data["providers"] = providers
 			if cmdEnabled {
 				if _, exists := data["cmd"]; !exists {
@@ -194,7 +211,7 @@ func parseConfigObj(obj interface{}) map[string]interface{} {
 				tokens = append(tokens, s)
 			}
 		}
-		providers, cmdEnabled := normalizeProviders(tokens)
+		providers, cmdEnabled := normalizeProviders(tokens, custom)
 		data := map[string]interface{}{"providers": providers}
 		if cmdEnabled {
 			data["cmd"] = true
@@ -203,7 +220,7 @@ func parseConfigObj(obj interface{}) map[string]interface{} {
 
 	case string:
 		tokens := parseTokens(v)
-		providers, cmdEnabled := normalizeProviders(tokens)
+		providers, cmdEnabled := normalizeProviders(tokens, custom)
 		data := map[string]interface{}{"providers": providers}
 		if cmdEnabled {
 			data["cmd"] = true
@@ -214,14 +231,46 @@ func parseConfigObj(obj interface{}) map[string]interface{} {
 	return nil
 }
 
+// GlobalConfigDir returns the per-user CCB directory (~/.ccb).
+func GlobalConfigDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ccb")
+}
+
 // configPaths returns the project and global config file paths.
 func configPaths(workDir string) (string, string) {
 	project := filepath.Join(workDir, ".ccb_config", ConfigFilename)
-	home, _ := os.UserHomeDir()
-	global := filepath.Join(home, ".ccb", ConfigFilename)
+	global := filepath.Join(GlobalConfigDir(), ConfigFilename)
 	return project, global
 }
 
+// lookupSection returns the first non-zero number under keys in the
+// section object of ccb.config, 0 if there is none. Keys go from most to
+// least specific (say the provider, then "default"), and each key is
+// looked up in the project's ccb.config before the global one, so a
+// global entry for the provider beats a project-wide "default":
+//
+//	{"timeouts": {"gemini": 300, "default": 150}}
+func lookupSection(workDir, section string, keys ...string) float64 {
+	project, global := configPaths(workDir)
+	paths := []string{global}
+	if workDir != "" {
+		paths = []string{project, global}
+	}
+	sections := make([]map[string]interface{}, len(paths))
+	for i, path := range paths {
+		sections[i], _ = readConfig(path)[section].(map[string]interface{})
+	}
+	for _, key := range keys {
+		for _, entries := range sections {
+			if v, ok := entries[key].(float64); ok && v != 0 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
 // LoadStartConfig loads the CCB start configuration.
 func LoadStartConfig(workDir string) *StartConfig {
 	project, global := configPaths(workDir)
@@ -237,18 +286,11 @@ func LoadStartConfig(workDir string) *StartConfig {
 // EnsureDefaultStartConfig ensures a default config file exists.
 func EnsureDefaultStartConfig(workDir string) (string, bool) {
 	project, _ := configPaths(workDir)
-	if _, err := os.Stat(project); err == nil {
-		return project, false
-	}
-	dir := filepath.Dir(project)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", false
-	}
-	payload := strings.Join(DefaultProviders, ",") + "\n"
-	if err := os.WriteFile(project, []byte(payload), 0600); err != nil {
+	created, err := EnsureStartConfig(project)
+	if err != nil {
 		return "", false
 	}
-	return project, true
+	return project, created
 }
 
 // GetBackendEnv returns the backend environment ("wsl" or "windows").
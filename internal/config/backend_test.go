@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupSection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename),
+		[]byte(`{"timeouts": {"gemini": 300, "codex": 60, "default": 150}, "rate_limits": {"gemini": 5}}`), 0644)
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"timeouts": {"codex": 90, "claude": 0, "default": 200}, "rate_limits": {"default": 20}}`), 0644)
+
+	tests := []struct {
+		name, section string
+		keys          []string
+		want          float64
+	}{
+		{"project entry beats global entry", "timeouts", []string{"codex", "default"}, 90},
+		{"global entry beats project default", "timeouts", []string{"gemini", "default"}, 300},
+		{"same order in other sections", "rate_limits", []string{"gemini", "default"}, 5},
+		{"zero counts as unset", "timeouts", []string{"claude", "default"}, 200},
+		{"no entry", "concurrency", []string{"codex", "default"}, 0},
+	}
+	for _, tt := range tests {
+		if got := lookupSection(work, tt.section, tt.keys...); got != tt.want {
+			t.Errorf("%s: lookupSection(%q, %v) = %v, want %v", tt.name, tt.section, tt.keys, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "strings"
+
+// MaxConcurrency returns how many asks provider may run at once across all
+// of its panes, 0 for no limit. CCB_MAX_CONCURRENCY_<PROVIDER> wins, then
+// the provider's entry (or "default") in the "concurrency" object of
+// ccb.config (see lookupSection):
+//
+//	{"concurrency": {"codex": 1, "default": 4}}
+func MaxConcurrency(workDir, provider string) int {
+	if n := EnvInt("CCB_MAX_CONCURRENCY_"+strings.ToUpper(provider), 0); n > 0 {
+		return n
+	}
+	if n := lookupSection(workDir, "concurrency", provider, "default"); n > 0 {
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_MAX_CONCURRENCY_CODEX", "")
+	work := t.TempDir()
+
+	if got := MaxConcurrency(work, "codex"); got != 0 {
+		t.Errorf("no config: MaxConcurrency = %d, want 0", got)
+	}
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"concurrency": {"codex": 1, "default": 3}}`), 0644)
+	tests := []struct {
+		provider string
+		want     int
+	}{
+		{"codex", 1},
+		{"gemini", 3},
+	}
+	for _, tt := range tests {
+		if got := MaxConcurrency(work, tt.provider); got != tt.want {
+			t.Errorf("MaxConcurrency(%s) = %d, want %d", tt.provider, got, tt.want)
+		}
+	}
+	t.Setenv("CCB_MAX_CONCURRENCY_CODEX", "2")
+	if got := MaxConcurrency(work, "codex"); got != 2 {
+		t.Errorf("env: MaxConcurrency = %d, want 2", got)
+	}
+}
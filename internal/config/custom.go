@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Log formats a custom provider's session logs can be read in.
+const (
+	// CustomLogJSONL is one {"role": ..., "content": ...} object per line;
+	// content is a string or a list of {"type": "text", "text": ...} parts.
+	CustomLogJSONL = "jsonl-role-content"
+	// CustomLogPlain is plain text, such as a terminal transcript: the
+	// reply is what follows the prompt.
+	CustomLogPlain = "plain-tail"
+)
+
+// CustomProvider is a provider declared in ccb.config rather than built
+// into ccb.
+type CustomProvider struct {
+	Name       string
+	Command    string   // executable
+	Args       []string // flags it always starts with
+	AutoArgs   []string // flags added in auto-approve mode (-a)
+	ResumeArgs []string // flags added to resume its last session (-r)
+	SessionDir string   // where it writes session logs, ~ expanded
+	LogFormat  string   // CustomLogJSONL or CustomLogPlain
+}
+
+var customNameRE = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CustomProviders returns the providers declared in the
+// "custom_providers" object of ccb.config, global entries first, then the
+// project's, which win:
+//
+//	{"custom_providers": {"crush": {
+//	  "command": "crush", "args": "--cwd .", "auto_args": ["--yolo"],
+//	  "resume_args": ["--continue"], "session_dir": "~/.crush/logs",
+//	  "log_format": "jsonl-role-content"}}}
+//
+// Flags are a command line, split like a shell would, or a list. Entries
+// without a command, with an unknown log format (plain-tail when unset),
+// or named like a built-in provider are skipped.
+func CustomProviders(workDir string) map[string]CustomProvider {
+	project, global := configPaths(workDir)
+	paths := []string{global}
+	if workDir != "" {
+		paths = append(paths, project)
+	}
+	providers := make(map[string]CustomProvider)
+	for _, path := range paths {
+		for name, p := range readCustomProviders(path) {
+			providers[name] = p
+		}
+	}
+	return providers
+}
+
+// LookupCustomProvider returns the custom provider name declared for
+// workDir, if any.
+func LookupCustomProvider(workDir, name string) (CustomProvider, bool) {
+	p, ok := CustomProviders(workDir)[name]
+	return p, ok
+}
+
+// readCustomProviders reads the valid "custom_providers" entries of one
+// config file. It parses the file itself, since readConfig needs the names
+// to keep custom providers in "providers".
+func readCustomProviders(path string) map[string]CustomProvider {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var obj struct {
+		Custom map[string]map[string]interface{} `json:"custom_providers"`
+	}
+	if json.Unmarshal(data, &obj) != nil {
+		return nil
+	}
+	providers := make(map[string]CustomProvider)
+	for name, entry := range obj.Custom {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !customNameRE.MatchString(name) || allowedProviders[name] || name == "cmd" {
+			continue
+		}
+		str := func(key string) string {
+			s, _ := entry[key].(string)
+			return strings.TrimSpace(s)
+		}
+		p := CustomProvider{
+			Name:       name,
+			Command:    expandHome(str("command")),
+			Args:       argList(entry["args"]),
+			AutoArgs:   argList(entry["auto_args"]),
+			ResumeArgs: argList(entry["resume_args"]),
+			SessionDir: expandHome(str("session_dir")),
+			LogFormat:  str("log_format"),
+		}
+		if p.LogFormat == "" {
+			p.LogFormat = CustomLogPlain
+		}
+		if p.Command == "" || (p.LogFormat != CustomLogJSONL && p.LogFormat != CustomLogPlain) {
+			continue
+		}
+		providers[name] = p
+	}
+	return providers
+}
+
+// customNames returns the names of the custom providers the global
+// ccb.config and the file at path declare.
+func customNames(path string) map[string]bool {
+	_, global := configPaths("")
+	names := make(map[string]bool)
+	for _, p := range []string{global, path} {
+		for name := range readCustomProviders(p) {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// argList reads flags given as a command line or a list of arguments.
+func argList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		args, _ := SplitArgs(v)
+		return args
+	case []interface{}:
+		var args []string
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				args = append(args, s)
+			}
+		}
+		return args
+	}
+	return nil
+}
+
+// expandHome expands a leading ~ to the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// customProviderNames returns the sorted names of the custom providers
+// declared for workDir.
+func customProviderNames(workDir string) []string {
+	var names []string
+	for name := range CustomProviders(workDir) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCustomProviders(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename), []byte(`{"custom_providers": {
+		"crush": {"command": "crush", "args": "--cwd '.'", "session_dir": "~/.crush/logs"},
+		"kiro": {"command": "kiro-cli", "log_format": "jsonl-role-content"}
+	}}`), 0644)
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename), []byte(`{
+		"providers": ["codex", "crush", "nope"],
+		"custom_providers": {
+			"kiro": {"command": "kiro", "auto_args": ["--trust-all-tools"], "resume_args": "--resume", "log_format": "jsonl-role-content"},
+			"codex": {"command": "my-codex"},
+			"Bad Name": {"command": "x"},
+			"nocmd": {"args": "--x"},
+			"odd": {"command": "odd", "log_format": "xml"}
+		}}`), 0644)
+
+	got := CustomProviders(work)
+	want := map[string]CustomProvider{
+		"crush": {Name: "crush", Command: "crush", Args: []string{"--cwd", "."}, SessionDir: filepath.Join(home, ".crush", "logs"), LogFormat: CustomLogPlain},
+		"kiro":  {Name: "kiro", Command: "kiro", AutoArgs: []string{"--trust-all-tools"}, ResumeArgs: []string{"--resume"}, LogFormat: CustomLogJSONL},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CustomProviders = %+v, want %+v", got, want)
+	}
+
+	if p, ok := LookupCustomProvider("", "kiro"); !ok || p.Command != "kiro-cli" {
+		t.Errorf("LookupCustomProvider without project = %+v, %v, want the global kiro-cli", p, ok)
+	}
+	if _, ok := LookupCustomProvider(work, "codex"); ok {
+		t.Error("LookupCustomProvider(codex) found a custom provider shadowing a built-in one")
+	}
+
+	if got, want := LoadStartConfig(work).GetProviders(), []string{"codex", "crush"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProviders = %v, want %v", got, want)
+	}
+}
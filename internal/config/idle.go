@@ -0,0 +1,35 @@
+package config
+
+import "github.com/anthropics/claude_code_bridge/internal/protocol"
+
+// DefaultIdleTimeoutS is how long, in seconds, the daemon keeps serving a
+// provider nobody has used when nothing else sets it.
+const DefaultIdleTimeoutS = 1800
+
+// IdleTimeout returns how long in seconds the daemon stays up for provider
+// after its last use; a negative value keeps it up for good. The provider's
+// own variable from its daemon spec (CCB_CASKD_IDLE_TIMEOUT_S for codex,
+// ...) wins, then the provider's entry in the "idle_timeouts" object of
+// ccb.config (see lookupSection), then CCB_ASKD_IDLE_TIMEOUT_S, then the
+// "default" entry, then
+// DefaultIdleTimeoutS. Zero counts as unset everywhere. An empty provider
+// gives the daemon-wide timeout, for providers without a value of their own:
+//
+//	{"idle_timeouts": {"codex": 7200, "gemini": -1, "default": 600}}
+func IdleTimeout(workDir, provider string) int {
+	if spec := protocol.DaemonSpecByProvider(provider); spec != nil {
+		if t := EnvInt(spec.IdleTimeoutEnv, 0); t != 0 {
+			return t
+		}
+	}
+	if t := lookupSection(workDir, "idle_timeouts", provider); t != 0 {
+		return int(t)
+	}
+	if t := EnvInt("CCB_ASKD_IDLE_TIMEOUT_S", 0); t != 0 {
+		return t
+	}
+	if t := lookupSection(workDir, "idle_timeouts", "default"); t != 0 {
+		return int(t)
+	}
+	return DefaultIdleTimeoutS
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_ASKD_IDLE_TIMEOUT_S", "")
+	t.Setenv("CCB_CASKD_IDLE_TIMEOUT_S", "")
+	work := t.TempDir()
+
+	if got := IdleTimeout(work, "codex"); got != DefaultIdleTimeoutS {
+		t.Errorf("no config: IdleTimeout = %d, want %d", got, DefaultIdleTimeoutS)
+	}
+
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename),
+		[]byte(`{"idle_timeouts": {"gemini": 300, "default": 900}}`), 0644)
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"idle_timeouts": {"codex": 7200, "claude": -1, "droid": 0}}`), 0644)
+
+	tests := []struct {
+		name              string
+		env               map[string]string
+		workDir, provider string
+		want              int
+	}{
+		{"project entry", nil, work, "codex", 7200},
+		{"global entry", nil, work, "gemini", 300},
+		{"negative keeps alive", nil, work, "claude", -1},
+		{"zero entry ignored", nil, work, "droid", 900},
+		{"no project", nil, "", "codex", 900},
+		{"provider env wins", map[string]string{"CCB_CASKD_IDLE_TIMEOUT_S": "60"}, work, "codex", 60},
+		{"daemon env before default", map[string]string{"CCB_ASKD_IDLE_TIMEOUT_S": "120"}, work, "droid", 120},
+		{"daemon env after entry", map[string]string{"CCB_ASKD_IDLE_TIMEOUT_S": "120"}, work, "gemini", 300},
+		{"provider without a spec", map[string]string{"CCB_ASKD_IDLE_TIMEOUT_S": "120"}, "", "copilot", 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := IdleTimeout(tt.workDir, tt.provider); got != tt.want {
+				t.Errorf("IdleTimeout(%q, %q) = %d, want %d", tt.workDir, tt.provider, got, tt.want)
+			}
+		})
+	}
+}
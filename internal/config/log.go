@@ -0,0 +1,35 @@
+package config
+
+import "strings"
+
+// Daemon log formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogFormat returns the daemon's log format for workDir: CCB_LOG_FORMAT
+// wins, then the "log_format" key of the project's ccb.config, then the
+// global one. Anything but "json" means LogFormatText.
+//
+//	{"log_format": "json"}
+func LogFormat(workDir string) string {
+	format := EnvStr("CCB_LOG_FORMAT", "")
+	if format == "" {
+		project, global := configPaths(workDir)
+		paths := []string{global}
+		if workDir != "" {
+			paths = []string{project, global}
+		}
+		for _, path := range paths {
+			if v, ok := readConfig(path)["log_format"].(string); ok && strings.TrimSpace(v) != "" {
+				format = strings.TrimSpace(v)
+				break
+			}
+		}
+	}
+	if strings.EqualFold(format, LogFormatJSON) {
+		return LogFormatJSON
+	}
+	return LogFormatText
+}
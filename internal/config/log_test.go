@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogFormat(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_LOG_FORMAT", "")
+	work := t.TempDir()
+
+	if got := LogFormat(work); got != LogFormatText {
+		t.Errorf("no config: LogFormat = %q, want text", got)
+	}
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename), []byte(`{"log_format": "JSON"}`), 0644)
+	if got := LogFormat(work); got != LogFormatJSON {
+		t.Errorf("global config: LogFormat = %q, want json", got)
+	}
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename), []byte(`{"log_format": "text"}`), 0644)
+	if got := LogFormat(work); got != LogFormatText {
+		t.Errorf("project config: LogFormat = %q, want text", got)
+	}
+	t.Setenv("CCB_LOG_FORMAT", "json")
+	if got := LogFormat(work); got != LogFormatJSON {
+		t.Errorf("env: LogFormat = %q, want json", got)
+	}
+}
@@ -17,7 +17,8 @@ var (
 )
 
 // NormalizeWorkDir normalizes a work directory path into a stable string for hashing.
-// It handles Windows drive letters, WSL /mnt/ paths, MSYS paths, and forward/back slashes.
+// It handles Windows drive letters, WSL /mnt/ paths, MSYS paths, UNC shares,
+// \\?\ long-path prefixes, and forward/back slashes.
 func NormalizeWorkDir(value string) string {
 	raw := strings.TrimSpace(value)
 	if raw == "" {
@@ -47,6 +48,7 @@ func NormalizeWorkDir(value string) string {
 	}
 
 	s := strings.ReplaceAll(raw, "\\", "/")
+	s = stripWinPathPrefix(s)
 
 	// Map WSL /mnt/<drive>/... to <drive>:/...
 	if m := mntDriveRE.FindStringSubmatch(s); m != nil {
@@ -65,10 +67,7 @@ func NormalizeWorkDir(value string) string {
 
 	// Collapse redundant separators using filepath.Clean logic on forward slashes
 	if strings.HasPrefix(s, "//") {
-		prefix := "//"
-		rest := cleanPosixPath(s[2:])
-		rest = strings.TrimLeft(rest, "/")
-		s = prefix + rest
+		s = normalizeUNC(s[2:])
 	} else {
 		s = cleanPosixPath(s)
 	}
@@ -81,6 +80,48 @@ func NormalizeWorkDir(value string) string {
 	return s
 }
 
+// stripWinPathPrefix removes the Win32 long-path and device prefixes
+// (\\?\, \\.\, \??\) from a forward-slashed path, so \\?\C:\x is C:/x
+// and \\?\UNC\server\share is //server/share.
+func stripWinPathPrefix(s string) string {
+	for _, prefix := range []string{"//?/", "//./", "/??/"} {
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		rest := s[len(prefix):]
+		if len(rest) >= 4 && strings.EqualFold(rest[:4], "UNC/") {
+			return "//" + rest[4:]
+		}
+		return rest
+	}
+	return s
+}
+
+// normalizeUNC normalizes the part of a UNC path after "//". Server and
+// share names are case-insensitive on Windows and are lowercased; ".."
+// never climbs above the share. WSL shares (\\wsl$\<distro>,
+// \\wsl.localhost\<distro>) map to the distro's own path so Windows and
+// WSL tools agree.
+func normalizeUNC(rest string) string {
+	parts := strings.SplitN(strings.TrimLeft(rest, "/"), "/", 3)
+	server := strings.ToLower(parts[0])
+	if len(parts) < 2 || parts[1] == "" {
+		return "//" + server
+	}
+	tail := "/"
+	if len(parts) == 3 {
+		tail = cleanPosixPath("/" + parts[2])
+	}
+	if server == "wsl$" || server == "wsl.localhost" {
+		return tail
+	}
+	root := "//" + server + "/" + strings.ToLower(parts[1])
+	if tail == "/" {
+		return root
+	}
+	return root + tail
+}
+
 // cleanPosixPath normalizes a POSIX-style path (forward slashes).
 func cleanPosixPath(p string) string {
 	// Use filepath.Clean but ensure forward slashes
@@ -58,6 +58,51 @@ func TestNormalizeWorkDirWSL(t *testing.T) {
 	}
 }
 
+func TestNormalizeWorkDirWindowsExotic(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unc share", `\\server\share\dir`, "//server/share/dir"},
+		{"unc casing and trailing slash", `\\SERVER\Share\Dir\`, "//server/share/Dir"},
+		{"unc forward slashes", "//server/share/a/../b", "//server/share/b"},
+		{"unc dotdot stays in share", `\\server\share\..\..`, "//server/share"},
+		{"unc share root", `\\server\share\`, "//server/share"},
+		{"unc server only", `\\Server`, "//server"},
+		{"long path drive", `\\?\C:\Users\test`, "c:/Users/test"},
+		{"long path unc", `\\?\UNC\Server\Share\dir`, "//server/share/dir"},
+		{"long path unc lowercase marker", `\\?\unc\server\share`, "//server/share"},
+		{"device path", `\\.\D:\work`, "d:/work"},
+		{"nt object path", `\??\C:\work`, "c:/work"},
+		{"long path forward slashes", "//?/C:/work/./x", "c:/work/x"},
+		{"wsl share", `\\wsl$\Ubuntu\home\me\proj`, "/home/me/proj"},
+		{"wsl.localhost share", `\\wsl.localhost\Ubuntu-22.04\home\me\`, "/home/me"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeWorkDir(tt.input); got != tt.want {
+				t.Errorf("NormalizeWorkDir(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// Every spelling of one directory hashes to the same project.
+	same := [][]string{
+		{`C:\Users\test`, `\\?\C:\Users\test`, `\\.\c:\Users\test\`, "/mnt/c/Users/test"},
+		{`\\srv\proj\app`, `\\?\UNC\SRV\PROJ\app`, "//srv/proj/app/"},
+		{`\\wsl$\Ubuntu\home\me`, "/home/me"},
+	}
+	for _, group := range same {
+		want := NormalizeWorkDir(group[0])
+		for _, p := range group[1:] {
+			if got := NormalizeWorkDir(p); got != want {
+				t.Errorf("NormalizeWorkDir(%q) = %q, want %q as for %q", p, got, want, group[0])
+			}
+		}
+	}
+}
+
 func TestComputeCCBProjectID(t *testing.T) {
 	// Project ID should be a 64-char hex string (SHA256)
 	id := ComputeCCBProjectID(".")
@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// ClientRateLimit returns how many asks one client_id may send per minute,
+// 0 for no limit: CCB_RATE_LIMIT_CLIENT, else the "client" key of the
+// "rate_limits" object in ccb.config (see lookupSection).
+func ClientRateLimit(workDir string) int {
+	if n := EnvInt("CCB_RATE_LIMIT_CLIENT", 0); n > 0 {
+		return n
+	}
+	return configRateLimit(workDir, "client")
+}
+
+// ProviderRateLimit returns how many asks provider may receive per minute
+// from all clients together, 0 for no limit: CCB_RATE_LIMIT_<PROVIDER>,
+// else the provider's entry (or "default") in "rate_limits":
+//
+//	{"rate_limits": {"client": 30, "codex": 10, "default": 20}}
+func ProviderRateLimit(workDir, provider string) int {
+	if n := EnvInt("CCB_RATE_LIMIT_"+strings.ToUpper(provider), 0); n > 0 {
+		return n
+	}
+	return configRateLimit(workDir, provider, "default")
+}
+
+// configRateLimit returns the positive "rate_limits" entry under keys, 0
+// if there is none.
+func configRateLimit(workDir string, keys ...string) int {
+	if n := lookupSection(workDir, "rate_limits", keys...); n > 0 {
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRateLimits(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_RATE_LIMIT_CLIENT", "")
+	t.Setenv("CCB_RATE_LIMIT_CODEX", "")
+	work := t.TempDir()
+
+	if got := ClientRateLimit(work); got != 0 {
+		t.Errorf("no config: ClientRateLimit = %d, want 0", got)
+	}
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"rate_limits": {"client": 30, "codex": 10, "default": 20}}`), 0644)
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"client", ClientRateLimit(work), 30},
+		{"codex", ProviderRateLimit(work, "codex"), 10},
+		{"gemini uses default", ProviderRateLimit(work, "gemini"), 20},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+	t.Setenv("CCB_RATE_LIMIT_CLIENT", "5")
+	t.Setenv("CCB_RATE_LIMIT_CODEX", "2")
+	if got := ClientRateLimit(work); got != 5 {
+		t.Errorf("env: ClientRateLimit = %d, want 5", got)
+	}
+	if got := ProviderRateLimit(work, "codex"); got != 2 {
+		t.Errorf("env: ProviderRateLimit = %d, want 2", got)
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PathRemap rewrites paths under From to live under To, e.g. /Users/me to
+// /home/me when state moves between machines.
+type PathRemap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ParseRemap parses "FROM=TO".
+func ParseRemap(s string) (PathRemap, error) {
+	from, to, ok := strings.Cut(s, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return PathRemap{}, fmt.Errorf("invalid remap %q (want FROM=TO)", s)
+	}
+	return PathRemap{From: from, To: to}, nil
+}
+
+// Apply rewrites path if it is From or lies under it. Separators are
+// compared loosely so Windows and POSIX spellings match, and drive paths
+// (C:\...) match case-insensitively.
+func (r PathRemap) Apply(path string) (string, bool) {
+	from := strings.TrimRight(strings.ReplaceAll(r.From, "\\", "/"), "/")
+	norm := strings.ReplaceAll(path, "\\", "/")
+	match := strings.HasPrefix(norm, from)
+	if !match && isDrivePath(from) {
+		match = strings.HasPrefix(strings.ToLower(norm), strings.ToLower(from))
+	}
+	if from == "" || !match {
+		return path, false
+	}
+	rest := norm[len(from):]
+	if rest != "" && rest[0] != '/' {
+		return path, false
+	}
+	to := strings.TrimRight(r.To, "/\\")
+	if strings.Contains(to, "\\") && !strings.Contains(to, "/") {
+		rest = strings.ReplaceAll(rest, "/", "\\")
+	}
+	return to + rest, true
+}
+
+// RemapPath applies the first matching rule to path.
+func RemapPath(path string, rules []PathRemap) string {
+	for _, r := range rules {
+		if out, ok := r.Apply(path); ok {
+			return out
+		}
+	}
+	return path
+}
+
+// LoadPathRemaps returns the remap rules from CCB_PATH_REMAP
+// ("FROM=TO;FROM=TO") followed by "path_remap" in the global ccb.config,
+// so registries and session files synced between machines resolve
+// locally. The config value is an object {"FROM": "TO"} or a list of
+// "FROM=TO" strings or {"from", "to"} objects.
+func LoadPathRemaps() []PathRemap {
+	var rules []PathRemap
+	for _, item := range strings.Split(os.Getenv("CCB_PATH_REMAP"), ";") {
+		if r, err := ParseRemap(item); err == nil {
+			rules = append(rules, r)
+		}
+	}
+	data := readConfig(filepath.Join(GlobalConfigDir(), ConfigFilename))
+	if data != nil {
+		rules = append(rules, parseRemapValue(data["path_remap"])...)
+	}
+	return rules
+}
+
+// parseRemapValue decodes the "path_remap" config value. Object keys
+// have no order, so the longest From is tried first.
+func parseRemapValue(v interface{}) []PathRemap {
+	var rules []PathRemap
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for from, to := range val {
+			if s, ok := to.(string); ok {
+				if r, err := ParseRemap(from + "=" + s); err == nil {
+					rules = append(rules, r)
+				}
+			}
+		}
+		sort.Slice(rules, func(i, j int) bool {
+			if len(rules[i].From) != len(rules[j].From) {
+				return len(rules[i].From) > len(rules[j].From)
+			}
+			return rules[i].From < rules[j].From
+		})
+	case []interface{}:
+		for _, item := range val {
+			switch it := item.(type) {
+			case string:
+				if r, err := ParseRemap(it); err == nil {
+					rules = append(rules, r)
+				}
+			case map[string]interface{}:
+				from, _ := it["from"].(string)
+				to, _ := it["to"].(string)
+				if r, err := ParseRemap(from + "=" + to); err == nil {
+					rules = append(rules, r)
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// isDrivePath reports whether p starts with a Windows drive letter.
+func isDrivePath(p string) bool {
+	return len(p) >= 2 && p[1] == ':' && (p[0]|0x20 >= 'a' && p[0]|0x20 <= 'z')
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathRemapApply(t *testing.T) {
+	tests := []struct {
+		from, to, in, want string
+		ok                 bool
+	}{
+		{"/Users/me", "/home/me", "/Users/me/src/app", "/home/me/src/app", true},
+		{"/Users/me/", "/home/me", "/Users/me", "/home/me", true},
+		{"/Users/me", "/home/me", "/Users/meg/src", "/Users/meg/src", false},
+		{"/Users/me", "/home/me", "/users/me/src", "/users/me/src", false},
+		{"C:\\Users\\me", "/mnt/c/Users/me", "c:\\users\\me\\proj", "/mnt/c/Users/me/proj", true},
+		{"/home/me", "C:\\Users\\me", "/home/me/proj/x", "C:\\Users\\me\\proj\\x", true},
+	}
+	for _, tt := range tests {
+		got, ok := PathRemap{tt.from, tt.to}.Apply(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s=%s Apply(%q) = %q, %v; want %q, %v", tt.from, tt.to, tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseRemap(t *testing.T) {
+	r, err := ParseRemap(" /Users/me = /home/me ")
+	if err != nil || r.From != "/Users/me" || r.To != "/home/me" {
+		t.Errorf("ParseRemap = %+v, %v", r, err)
+	}
+	for _, bad := range []string{"", "/a", "=/b", "/a="} {
+		if _, err := ParseRemap(bad); err == nil {
+			t.Errorf("ParseRemap(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadPathRemaps(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_PATH_REMAP", "/Volumes/work=/srv/work; bogus")
+
+	cfg := map[string]interface{}{
+		"providers":  []string{"codex"},
+		"path_remap": map[string]string{"/Users/me": "/home/me", "/Users/me/src": "/src"},
+	}
+	data, _ := json.Marshal(cfg)
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename), data, 0644)
+
+	rules := LoadPathRemaps()
+	want := []PathRemap{{"/Volumes/work", "/srv/work"}, {"/Users/me/src", "/src"}, {"/Users/me", "/home/me"}}
+	if len(rules) != len(want) {
+		t.Fatalf("rules = %+v, want %+v", rules, want)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rules[%d] = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+	if got := RemapPath("/Users/me/src/app", rules); got != "/src/app" {
+		t.Errorf("RemapPath = %q, want /src/app", got)
+	}
+
+	list := parseRemapValue([]interface{}{"/a=/b", map[string]interface{}{"from": "/c", "to": "/d"}, 3})
+	if len(list) != 2 || list[1] != (PathRemap{"/c", "/d"}) {
+		t.Errorf("list form = %+v", list)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+// DefaultAskTimeoutS is the ask timeout, in seconds, when neither the
+// caller nor ccb.config sets one.
+const DefaultAskTimeoutS = 120
+
+// DefaultStartupTimeoutS is the startup budget, in seconds, when neither
+// CCB_STARTUP_TIMEOUT_S nor ccb.config sets one.
+const DefaultStartupTimeoutS = 60
+
+// DefaultDrainTimeoutS is how long, in seconds, a stopping daemon waits
+// for asks in flight when neither CCB_DRAIN_TIMEOUT_S nor ccb.config sets
+// it.
+const DefaultDrainTimeoutS = 30
+
+// AskTimeout returns the default ask timeout in seconds for provider in
+// workDir: the provider's entry in the "timeouts" object of ccb.config,
+// else its "default" entry (see lookupSection for the order), else
+// DefaultAskTimeoutS:
+//
+//	{"timeouts": {"gemini": 300, "default": 150}}
+func AskTimeout(workDir, provider string) float64 {
+	if t := lookupSection(workDir, "timeouts", provider, "default"); t > 0 {
+		return t
+	}
+	return DefaultAskTimeoutS
+}
+
+// StartupTimeout returns the budget in seconds for getting an ask's prompt
+// into the pane (waiting for the session's worker and typing it), which
+// is not counted against the reply timeout. CCB_STARTUP_TIMEOUT_S wins,
+// then the "startup" key of "timeouts" in ccb.config, then
+// DefaultStartupTimeoutS.
+func StartupTimeout(workDir string) float64 {
+	if t := EnvInt("CCB_STARTUP_TIMEOUT_S", 0); t > 0 {
+		return float64(t)
+	}
+	if t := lookupSection(workDir, "timeouts", "startup"); t > 0 {
+		return t
+	}
+	return DefaultStartupTimeoutS
+}
+
+// DrainTimeout returns how long in seconds a stopping daemon lets asks in
+// flight finish and send their results before it exits anyway.
+// CCB_DRAIN_TIMEOUT_S wins, then the "drain" key of "timeouts" in
+// ccb.config, then DefaultDrainTimeoutS.
+func DrainTimeout(workDir string) float64 {
+	if t := EnvInt("CCB_DRAIN_TIMEOUT_S", 0); t > 0 {
+		return float64(t)
+	}
+	if t := lookupSection(workDir, "timeouts", "drain"); t > 0 {
+		return t
+	}
+	return DefaultDrainTimeoutS
+}
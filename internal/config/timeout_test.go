@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAskTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	if got := AskTimeout(work, "codex"); got != DefaultAskTimeoutS {
+		t.Errorf("no config: AskTimeout = %v, want %v", got, DefaultAskTimeoutS)
+	}
+
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename),
+		[]byte(`{"timeouts": {"gemini": 300, "default": 150}}`), 0644)
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"providers": ["codex", "gemini"], "timeouts": {"codex": 90, "claude": 0}}`), 0644)
+
+	tests := []struct {
+		workDir, provider string
+		want              float64
+	}{
+		{work, "codex", 90},   // project entry
+		{work, "gemini", 300}, // global entry
+		{work, "claude", 150}, // non-positive project entry ignored; global default
+		{"", "codex", 150},    // no project: global default
+		{"", "gemini", 300},
+	}
+	for _, tt := range tests {
+		if got := AskTimeout(tt.workDir, tt.provider); got != tt.want {
+			t.Errorf("AskTimeout(%q, %q) = %v, want %v", tt.workDir, tt.provider, got, tt.want)
+		}
+	}
+}
+
+func TestStartupTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_STARTUP_TIMEOUT_S", "")
+	work := t.TempDir()
+
+	if got := StartupTimeout(work); got != DefaultStartupTimeoutS {
+		t.Errorf("no config: StartupTimeout = %v, want %v", got, DefaultStartupTimeoutS)
+	}
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ConfigFilename),
+		[]byte(`{"timeouts": {"startup": 90, "default": 200}}`), 0644)
+	if got := StartupTimeout(work); got != 90 {
+		t.Errorf("config: StartupTimeout = %v, want 90", got)
+	}
+	if got := AskTimeout(work, "codex"); got != 200 {
+		t.Errorf("AskTimeout = %v, want 200 (startup must not apply)", got)
+	}
+	t.Setenv("CCB_STARTUP_TIMEOUT_S", "15")
+	if got := StartupTimeout(work); got != 15 {
+		t.Errorf("env: StartupTimeout = %v, want 15", got)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CCB_DRAIN_TIMEOUT_S", "")
+	work := t.TempDir()
+
+	if got := DrainTimeout(work); got != DefaultDrainTimeoutS {
+		t.Errorf("no config: DrainTimeout = %v, want %v", got, DefaultDrainTimeoutS)
+	}
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", ConfigFilename),
+		[]byte(`{"timeouts": {"drain": 120, "default": 200}}`), 0644)
+	if got := DrainTimeout(work); got != 120 {
+		t.Errorf("global config: DrainTimeout = %v, want 120", got)
+	}
+	t.Setenv("CCB_DRAIN_TIMEOUT_S", "5")
+	if got := DrainTimeout(work); got != 5 {
+		t.Errorf("env: DrainTimeout = %v, want 5", got)
+	}
+}
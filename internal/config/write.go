@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StartConfigPaths returns the project (<workDir>/.ccb_config/ccb.config)
+// and global (~/.ccb/ccb.config) config files. LoadStartConfig uses the
+// project file when it exists, else the global one.
+func StartConfigPaths(workDir string) (project, global string) {
+	return configPaths(workDir)
+}
+
+// EnsureStartConfig writes the default provider list to path unless the
+// file exists, and reports whether it created it.
+func EnsureStartConfig(path string) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	payload := strings.Join(DefaultProviders, ",") + "\n"
+	if err := os.WriteFile(path, []byte(payload), 0600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetStartConfigValue sets key in the config file at path, creating the
+// file if needed. A dotted key sets a nested value ("timeouts.gemini").
+// "providers" takes a comma-separated list of known providers; other
+// values are stored as JSON when they parse as JSON (numbers, booleans,
+// objects) and as strings otherwise. A file holding a bare provider list
+// is rewritten as a JSON object.
+func SetStartConfigValue(path, key, value string) error {
+	parts := strings.Split(key, ".")
+	for _, p := range parts {
+		if p == "" {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+
+	data := readConfig(path)
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	if key == "providers" {
+		custom := customNames(path)
+		tokens := parseTokens(value)
+		for _, t := range tokens {
+			if t = strings.ToLower(t); t != "cmd" && !allowedProviders[t] && !custom[t] {
+				return fmt.Errorf("unknown provider %q (known: %s)", t, strings.Join(KnownProviders(), ", "))
+			}
+		}
+		providers, cmdEnabled := normalizeProviders(tokens, custom)
+		if len(providers) == 0 {
+			return fmt.Errorf("providers: no provider given")
+		}
+		data["providers"] = providers
+		if cmdEnabled {
+			data["cmd"] = true
+		}
+	} else {
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value
+		}
+		obj := data
+		for _, p := range parts[:len(parts)-1] {
+			switch next := obj[p].(type) {
+			case map[string]interface{}:
+				obj = next
+			case nil:
+				m := make(map[string]interface{})
+				obj[p] = m
+				obj = m
+			default:
+				return fmt.Errorf("%s: %q is not an object", key, p)
+			}
+		}
+		obj[parts[len(parts)-1]] = v
+	}
+
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0600)
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnsureStartConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", ConfigFilename)
+	created, err := EnsureStartConfig(path)
+	if err != nil || !created {
+		t.Fatalf("EnsureStartConfig = %v, %v; want created", created, err)
+	}
+	if got := (&StartConfig{Data: readConfig(path)}).GetProviders(); !reflect.DeepEqual(got, DefaultProviders) {
+		t.Errorf("providers = %v, want %v", got, DefaultProviders)
+	}
+	if created, err := EnsureStartConfig(path); err != nil || created {
+		t.Errorf("second EnsureStartConfig = %v, %v; want existing file kept", created, err)
+	}
+}
+
+func TestSetStartConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFilename)
+	os.WriteFile(path, []byte("codex, gemini\n"), 0644)
+
+	steps := []struct{ key, value string }{
+		{"providers", "claude,codex"},
+		{"timeouts.gemini", "300"},
+		{"timeouts.default", "150"},
+		{"cmd", "true"},
+		{"note", "hello world"},
+	}
+	for _, s := range steps {
+		if err := SetStartConfigValue(path, s.key, s.value); err != nil {
+			t.Fatalf("set %s=%s: %v", s.key, s.value, err)
+		}
+	}
+
+	cfg := &StartConfig{Data: readConfig(path)}
+	if got := cfg.GetProviders(); !reflect.DeepEqual(got, []string{"claude", "codex"}) {
+		t.Errorf("providers = %v", got)
+	}
+	if !cfg.CmdEnabled() {
+		t.Error("cmd not enabled")
+	}
+	timeouts, _ := cfg.Data["timeouts"].(map[string]interface{})
+	if timeouts["gemini"] != 300.0 || timeouts["default"] != 150.0 {
+		t.Errorf("timeouts = %v", timeouts)
+	}
+	if cfg.Data["note"] != "hello world" {
+		t.Errorf("note = %v", cfg.Data["note"])
+	}
+
+	errs := []struct{ key, value, want string }{
+		{"providers", "codex,bogus", "unknown provider"},
+		{"providers", " ", "no provider"},
+		{"note.x", "1", "not an object"},
+		{"timeouts..x", "1", "invalid key"},
+	}
+	for _, e := range errs {
+		err := SetStartConfigValue(path, e.key, e.value)
+		if err == nil || !strings.Contains(err.Error(), e.want) {
+			t.Errorf("set %s=%q: err = %v, want %q", e.key, e.value, err, e.want)
+		}
+	}
+}
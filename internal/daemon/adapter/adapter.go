@@ -2,8 +2,23 @@ package adapter
 
 import (
 	"context"
+	"errors"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
 )
 
+// ErrCanceled is the cause an ask's context is canceled with when its
+// client gives up on it (cancel, Ctrl+C). Other ends, such as a spent
+// startup budget, leave the provider alone; this one interrupts it.
+var ErrCanceled = errors.New("canceled by the client")
+
+// CanceledByClient reports whether ctx ended because the client canceled
+// the ask.
+func CanceledByClient(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrCanceled)
+}
+
 // ProviderRequest represents a request to a provider adapter.
 type ProviderRequest struct {
 	ClientID   string  `json:"client_id"`
@@ -14,23 +29,76 @@ type ProviderRequest struct {
 	Quiet      bool    `json:"quiet"`
 	OutputPath string  `json:"output_path,omitempty"`
 	Caller     string  `json:"caller,omitempty"`
+	Priority   string  `json:"priority,omitempty"`
+	Quick      bool    `json:"quick,omitempty"`  // extract the reply from pane capture only
+	Record     bool    `json:"record,omitempty"` // record pane input and snapshots (see Recorder)
+
+	// OnLines, if set, receives completed reply lines while the provider
+	// is still answering (streaming asks).
+	OnLines func(lines []string) `json:"-"`
+
+	// OnAnchor, if set, is called once the provider's log shows the
+	// request's anchor, i.e. the provider has started answering.
+	OnAnchor func() `json:"-"`
+
+	// OnPhase, if set, is told when the request moves to a new phase
+	// (PhaseSending, PhaseWaiting).
+	OnPhase func(phase string) `json:"-"`
+
+	// Recorder, if set, receives the text typed into the pane and
+	// snapshots of the pane while the request runs.
+	Recorder *recording.Recorder `json:"-"`
+
+	// ScratchDir, if set, is the request's scratch directory; payloads
+	// staged on the way to the pane are written there.
+	ScratchDir string `json:"-"`
+
+	// Route, if set, is the provider's pane and log for the request's
+	// project from the daemon's routing table; it wins over what the
+	// provider's session loader finds in WorkDir.
+	Route *Route `json:"-"`
 }
 
-// ProviderResult represents a result from a provider adapter.
-type ProviderResult struct {
-	ExitCode     int    `json:"exit_code"`
-	Reply        string `json:"reply"`
-	ReqID        string `json:"req_id"`
-	SessionKey   string `json:"session_key"`
-	LogPath      string `json:"log_path,omitempty"`
-	AnchorSeen   bool   `json:"anchor_seen"`
-	DoneSeen     bool   `json:"done_seen"`
-	FallbackScan bool   `json:"fallback_scan"`
-	AnchorMs     int64  `json:"anchor_ms,omitempty"`
-	DoneMs       int64  `json:"done_ms,omitempty"`
-	Error        string `json:"error,omitempty"`
+// Route is where a project's asks to one provider go.
+type Route struct {
+	ProjectID string
+	WorkDir   string // the project's root, as registered
+	PaneID    string
+	LogPath   string // empty leaves the loader's log path
 }
 
+// Request phases, as listed by "ccb requests".
+const (
+	PhaseQueued  = "queued"  // held in the offline queue
+	PhasePending = "pending" // waiting for the session's worker
+	PhaseSending = "sending" // typing the prompt into the pane
+	PhaseWaiting = "waiting" // prompt sent, waiting for the reply
+)
+
+// Request priorities (ProviderRequest.Priority; empty is interactive).
+// Interactive asks are scheduled ahead of background ones waiting for the
+// same session or provider.
+const (
+	PriorityInteractive = "interactive"
+	PriorityBackground  = "background"
+)
+
+// Background reports whether r was sent with PriorityBackground.
+func (r *ProviderRequest) Background() bool {
+	return r.Priority == PriorityBackground
+}
+
+// setPhase reports phase to OnPhase, if set.
+func (r *ProviderRequest) setPhase(phase string) {
+	if r.OnPhase != nil {
+		r.OnPhase(phase)
+	}
+}
+
+// ProviderResult represents a result from a provider adapter. It is
+// sent to clients as is, so it is the protocol's ask response.
+type ProviderResult = protocol.AskResponse
+
 // QueuedTask wraps a request with a result channel.
 type QueuedTask struct {
 	Request  *ProviderRequest
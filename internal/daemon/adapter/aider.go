@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// AiderAdapter implements the Adapter interface for Aider.
+type AiderAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	Comm      *comm.AiderCommunicator
+	lastReply string
+}
+
+func NewAiderAdapter(backend terminal.Backend) *AiderAdapter {
+	return &AiderAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: "aider"},
+		Backend:     backend,
+		Comm:        comm.NewAiderCommunicator(backend),
+	}
+}
+
+func (a *AiderAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	result := sendAndWait(ctx, sendSpec{
+		provider: "aider",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadAiderSession,
+		wrap:     protocol.AiderProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *AiderAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("aider pane %s not found", sessionID)
+	}
+	return nil
+}
+
+func (a *AiderAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *AiderAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	sess, err := session.LoadAiderSession(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no aider session configured")
+}
@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// AmpAdapter implements the Adapter interface for Amp.
+type AmpAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	Comm      *comm.AmpCommunicator
+	lastReply string
+}
+
+func NewAmpAdapter(backend terminal.Backend) *AmpAdapter {
+	return &AmpAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: "amp"},
+		Backend:     backend,
+		Comm:        comm.NewAmpCommunicator(backend),
+	}
+}
+
+func (a *AmpAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	result := sendAndWait(ctx, sendSpec{
+		provider: "amp",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadAmpSession,
+		wrap:     protocol.AmpProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *AmpAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("amp pane %s not found", sessionID)
+	}
+	return nil
+}
+
+func (a *AmpAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *AmpAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	sess, err := session.LoadAmpSession(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no amp session configured")
+}
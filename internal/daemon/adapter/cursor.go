@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// CursorAdapter implements the Adapter interface for Cursor.
+type CursorAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	Comm      *comm.CursorCommunicator
+	lastReply string
+}
+
+func NewCursorAdapter(backend terminal.Backend) *CursorAdapter {
+	return &CursorAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: "cursor"},
+		Backend:     backend,
+		Comm:        comm.NewCursorCommunicator(backend),
+	}
+}
+
+func (a *CursorAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	result := sendAndWait(ctx, sendSpec{
+		provider: "cursor",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadCursorSession,
+		wrap:     protocol.CursorProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *CursorAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("cursor pane %s not found", sessionID)
+	}
+	return nil
+}
+
+func (a *CursorAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *CursorAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	sess, err := session.LoadCursorSession(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no cursor session configured")
+}
@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// CustomAdapter implements the Adapter interface for a provider declared
+// in ccb.config. The declaration is looked up in each request's project,
+// so one daemon serves projects that declare the provider differently.
+type CustomAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	lastReply string
+}
+
+func NewCustomAdapter(backend terminal.Backend, name string) *CustomAdapter {
+	return &CustomAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: name},
+		Backend:     backend,
+	}
+}
+
+// spec returns the provider's declaration in workDir's ccb.config.
+func (a *CustomAdapter) spec(workDir string) (config.CustomProvider, error) {
+	spec, ok := config.LookupCustomProvider(workDir, a.ProviderName)
+	if !ok {
+		return spec, fmt.Errorf("%s is not declared in ccb.config for %s", a.ProviderName, workDir)
+	}
+	return spec, nil
+}
+
+func (a *CustomAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	spec, err := a.spec(req.WorkDir)
+	if err != nil {
+		return &ProviderResult{ExitCode: output.ExitNoSession, ReqID: req.ReqID, Error: err.Error()}, nil
+	}
+	result := sendAndWait(ctx, sendSpec{
+		provider: spec.Name,
+		backend:  a.Backend,
+		comm:     comm.NewCustomCommunicator(a.Backend, spec),
+		load:     session.CustomLoader(spec),
+		wrap:     protocol.CustomProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *CustomAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("%s pane %s not found", a.ProviderName, sessionID)
+	}
+	return nil
+}
+
+func (a *CustomAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *CustomAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	spec, err := a.spec(workDir)
+	if err != nil {
+		return "", err
+	}
+	sess, err := session.CustomLoader(spec)(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no %s session configured", a.ProviderName)
+}
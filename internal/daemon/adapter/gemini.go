@@ -3,7 +3,6 @@ package adapter
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/anthropics/claude_code_bridge/internal/comm"
 	"github.com/anthropics/claude_code_bridge/internal/protocol"
@@ -28,51 +27,16 @@ func NewGeminiAdapter(backend terminal.Backend) *GeminiAdapter {
 }
 
 func (a *GeminiAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
-	startTime := time.Now()
-
-	sess, err := session.LoadGeminiSession(req.WorkDir)
-	if err != nil || sess == nil {
-		return &ProviderResult{ExitCode: 1, ReqID: req.ReqID, Error: "gemini session not found"}, nil
-	}
-
-	reqID := req.ReqID
-	if reqID == "" {
-		reqID = protocol.MakeReqID()
-	}
-
-	wrapped := protocol.GeminiProto.WrapPrompt(req.Message, reqID)
-	if err := a.Comm.SendPrompt(ctx, sess.PaneID, wrapped); err != nil {
-		return &ProviderResult{ExitCode: 1, ReqID: reqID, Error: fmt.Sprintf("send failed: %v", err)}, nil
-	}
-
-	timeout := time.Duration(req.TimeoutS) * time.Second
-	if timeout == 0 {
-		timeout = 120 * time.Second
+	result := sendAndWait(ctx, sendSpec{
+		provider: "gemini",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadGeminiSession,
+		wrap:     protocol.GeminiProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	reply, err := a.Comm.WaitForReply(ctx, comm.WaitOpts{
-		LogPath: sess.LogPath, ReqID: reqID, PaneID: sess.PaneID, PollMs: 20,
-	})
-
-	result := &ProviderResult{ReqID: reqID, SessionKey: sess.ProjectID, LogPath: sess.LogPath}
-	if err != nil {
-		result.ExitCode = 2
-		result.Error = err.Error()
-		state, _ := a.Comm.CaptureState(ctx, comm.ReadOpts{LogPath: sess.LogPath, ReqID: reqID})
-		if state != nil {
-			result.AnchorSeen = state.AnchorSeen
-			result.AnchorMs = state.AnchorMs
-		}
-		return result, nil
-	}
-
-	result.ExitCode = 0
-	result.Reply = reply
-	result.DoneSeen = true
-	result.DoneMs = time.Since(startTime).Milliseconds()
-	a.lastReply = reply
 	return result, nil
 }
 
@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// GooseAdapter implements the Adapter interface for Goose.
+type GooseAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	Comm      *comm.GooseCommunicator
+	lastReply string
+}
+
+func NewGooseAdapter(backend terminal.Backend) *GooseAdapter {
+	return &GooseAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: "goose"},
+		Backend:     backend,
+		Comm:        comm.NewGooseCommunicator(backend),
+	}
+}
+
+func (a *GooseAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	result := sendAndWait(ctx, sendSpec{
+		provider: "goose",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadGooseSession,
+		wrap:     protocol.GooseProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *GooseAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("goose pane %s not found", sessionID)
+	}
+	return nil
+}
+
+func (a *GooseAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *GooseAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	sess, err := session.LoadGooseSession(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no goose session configured")
+}
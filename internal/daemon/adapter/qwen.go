@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// QwenAdapter implements the Adapter interface for Qwen Code.
+type QwenAdapter struct {
+	BaseAdapter
+	Backend   terminal.Backend
+	Comm      *comm.GeminiCommunicator // Qwen Code logs its chats like Gemini
+	lastReply string
+}
+
+func NewQwenAdapter(backend terminal.Backend) *QwenAdapter {
+	return &QwenAdapter{
+		BaseAdapter: BaseAdapter{ProviderName: "qwen"},
+		Backend:     backend,
+		Comm:        comm.NewQwenCommunicator(backend),
+	}
+}
+
+func (a *QwenAdapter) Send(ctx context.Context, req *ProviderRequest) (*ProviderResult, error) {
+	result := sendAndWait(ctx, sendSpec{
+		provider: "qwen",
+		backend:  a.Backend,
+		comm:     a.Comm,
+		load:     session.LoadQwenSession,
+		wrap:     protocol.QwenProto.WrapPrompt,
+	}, req)
+	if result.ExitCode == 0 {
+		a.lastReply = result.Reply
+	}
+	return result, nil
+}
+
+func (a *QwenAdapter) Ping(ctx context.Context, sessionID string) error {
+	if a.Backend == nil {
+		return fmt.Errorf("no terminal backend")
+	}
+	if sessionID != "" && !a.Backend.IsAlive(sessionID) {
+		return fmt.Errorf("qwen pane %s not found", sessionID)
+	}
+	return nil
+}
+
+func (a *QwenAdapter) Pend(ctx context.Context, sessionID string) (string, error) {
+	if a.lastReply != "" {
+		return a.lastReply, nil
+	}
+	return "", nil
+}
+
+func (a *QwenAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	sess, err := session.LoadQwenSession(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess != nil && sess.PaneID != "" {
+		if a.Backend != nil && a.Backend.IsAlive(sess.PaneID) {
+			return sess.PaneID, nil
+		}
+	}
+	return "", fmt.Errorf("no qwen session configured")
+}
@@ -0,0 +1,188 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/comm"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// sendSpec bundles the provider-specific pieces used by sendAndWait.
+type sendSpec struct {
+	provider string
+	backend  terminal.Backend
+	comm     comm.Communicator
+	load     func(workDir string) (*session.ProjectSession, error)
+	wrap     func(message string, reqID string) string
+}
+
+// recordInterval is how often a recorded request snapshots its pane.
+const recordInterval = 500 * time.Millisecond
+
+// sendAndWait delivers the wrapped prompt to the provider pane and waits for
+// the CCB_DONE marker, either via the provider's logs or, for quick asks,
+// via pane capture only.
+func sendAndWait(ctx context.Context, spec sendSpec, req *ProviderRequest) *ProviderResult {
+	startTime := time.Now()
+
+	sess, err := loadSession(spec, req)
+	if err != nil || sess == nil {
+		return &ProviderResult{ExitCode: output.ExitNoSession, ReqID: req.ReqID, Error: spec.provider + " session not found"}
+	}
+
+	reqID := req.ReqID
+	if reqID == "" {
+		reqID = protocol.MakeReqID()
+	}
+
+	rec := req.Recorder
+	if rec != nil && spec.backend != nil {
+		rec.Mark(fmt.Sprintf("%s pane %s via %s", spec.provider, sess.PaneID, spec.backend.Name()))
+		stop := rec.Watch(func() (string, error) { return spec.backend.CapturePane(sess.PaneID) }, recordInterval)
+		defer stop()
+	}
+
+	if req.ScratchDir != "" {
+		ctx = comm.WithScratchDir(ctx, req.ScratchDir)
+	}
+	wrapped := spec.wrap(req.Message, reqID)
+	req.setPhase(PhaseSending)
+	// The backend types the text, then presses Enter.
+	rec.Input(wrapped + "\r")
+	if err := spec.comm.SendPrompt(ctx, sess.PaneID, wrapped); err != nil {
+		rec.Mark("send failed: " + err.Error())
+		if paneDead(spec, sess.PaneID) {
+			return &ProviderResult{ExitCode: output.ExitPaneDead, ReqID: reqID, Error: paneDeadError(spec.provider, sess.PaneID)}
+		}
+		return &ProviderResult{ExitCode: output.ExitError, ReqID: reqID, Error: fmt.Sprintf("send failed: %v", err)}
+	}
+	rec.Mark("sent")
+	req.setPhase(PhaseWaiting)
+
+	timeout := time.Duration(req.TimeoutS * float64(time.Second))
+	if timeout == 0 {
+		timeout = 120 * time.Second
+		if req.Quick {
+			timeout = comm.DefaultQuickTimeout
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := &ProviderResult{ReqID: reqID, SessionKey: sess.ProjectID, LogPath: sess.LogPath}
+
+	var reply string
+	var heuristic string
+	if req.Quick {
+		result.LogPath = ""
+		reply, err = comm.WaitForPaneReply(ctx, spec.backend, spec.provider, sess.PaneID, reqID)
+	} else {
+		reply, err = spec.comm.WaitForReply(ctx, comm.WaitOpts{
+			LogPath: sess.LogPath, ReqID: reqID, PaneID: sess.PaneID, PollMs: 20,
+			OnLines:         req.OnLines,
+			OnAnchor:        req.OnAnchor,
+			QuietDone:       quietDoneFor(spec.provider),
+			OnHeuristicDone: func(reason string) { heuristic = reason },
+		})
+	}
+
+	if err != nil {
+		if CanceledByClient(ctx) {
+			// Canceled by the caller, not timed out: stop the provider
+			// working on an ask nobody is waiting for.
+			interrupt(spec, sess.PaneID, rec)
+		}
+		result.ExitCode = output.ExitTimeout
+		result.Error = err.Error()
+		if paneDead(spec, sess.PaneID) {
+			result.ExitCode = output.ExitPaneDead
+			result.Error = paneDeadError(spec.provider, sess.PaneID)
+		}
+		if !req.Quick {
+			state, _ := spec.comm.CaptureState(ctx, comm.ReadOpts{LogPath: sess.LogPath, ReqID: reqID})
+			if state != nil {
+				result.AnchorSeen = state.AnchorSeen
+				result.AnchorMs = state.AnchorMs
+				result.FallbackScan = state.FallbackScan
+			}
+		}
+		return result
+	}
+
+	result.ExitCode = output.ExitOK
+	result.Reply = reply
+	result.DoneSeen = heuristic == ""
+	result.DoneHeuristic = heuristic != ""
+	result.DoneReason = heuristic
+	result.DoneMs = time.Since(startTime).Milliseconds()
+	return result
+}
+
+// loadSession returns the provider's session for req: the one the session
+// loader finds in req.WorkDir, pointed at req.Route's pane and log when the
+// daemon routed the request. A routed ask needs no session file of its
+// own; the registered work dir's is used for the rest.
+func loadSession(spec sendSpec, req *ProviderRequest) (*session.ProjectSession, error) {
+	sess, err := spec.load(req.WorkDir)
+	r := req.Route
+	if r == nil || r.PaneID == "" {
+		return sess, err
+	}
+	if sess == nil && r.WorkDir != "" {
+		sess, _ = spec.load(r.WorkDir)
+	}
+	if sess == nil {
+		sess = &session.ProjectSession{Provider: spec.provider, WorkDir: req.WorkDir}
+	}
+	sess.ProjectID = r.ProjectID
+	sess.PaneID = r.PaneID
+	if r.LogPath != "" {
+		sess.LogPath = r.LogPath
+	}
+	return sess, nil
+}
+
+// quietDoneFor returns how long a provider's reply must stop growing to be
+// accepted without CCB_DONE: CCB_QUIET_DONE_S_<PROVIDER>, else
+// CCB_QUIET_DONE_S. Zero (the default) waits for the marker only.
+func quietDoneFor(provider string) time.Duration {
+	secs := config.EnvInt("CCB_QUIET_DONE_S", 0)
+	secs = config.EnvInt("CCB_QUIET_DONE_S_"+strings.ToUpper(provider), secs)
+	if secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// interrupt sends the provider's interrupt key (comm.InterruptKey) to
+// paneID, noting the outcome on rec.
+func interrupt(spec sendSpec, paneID string, rec *recording.Recorder) {
+	key := comm.InterruptKey(spec.provider)
+	if key == "" {
+		return
+	}
+	if err := spec.comm.Interrupt(paneID, key); err != nil {
+		rec.Mark("interrupt failed: " + err.Error())
+		return
+	}
+	rec.Mark("canceled; sent " + key)
+}
+
+// paneDead reports whether the backend knows paneID is gone. It is only
+// asked after a failure, to tell a dead pane from a slow provider.
+func paneDead(spec sendSpec, paneID string) bool {
+	return spec.backend != nil && !spec.backend.IsAlive(paneID)
+}
+
+// paneDeadError is the error for an ask whose pane went away.
+func paneDeadError(provider, paneID string) string {
+	return fmt.Sprintf("%s pane %s is no longer alive (bring it back with 'ccb restart %s')", provider, paneID, provider)
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// handleBroadcast handles a broadcast request: the message goes to every
+// listed provider at once, each as its own ask (and worker task), and one
+// response maps each provider to its result. A provider that cannot be
+// asked gets an error result rather than failing the others.
+func (s *Server) handleBroadcast(conn net.Conn, req *protocol.BroadcastRequest) {
+	providers := broadcastProviders(req.Providers)
+	if len(providers) == 0 {
+		s.sendError(conn, "missing providers")
+		return
+	}
+	if !s.asks.enter() {
+		s.sendError(conn, "daemon is shutting down")
+		return
+	}
+	defer s.asks.leave()
+	// Each provider gets the broadcast's ask fields, with its own work dir
+	// if the project differs.
+	each := protocol.AskRequest{
+		Envelope:  protocol.Envelope{Method: "request", Token: req.Token},
+		Message:   req.Message,
+		ClientID:  req.ClientID,
+		WorkDir:   req.WorkDir,
+		TimeoutS:  req.TimeoutS,
+		Quiet:     req.Quiet,
+		Caller:    req.Caller,
+		Quick:     req.Quick,
+		Queue:     req.Queue,
+		DeliverAt: req.DeliverAt,
+		TTLS:      req.TTLS,
+		Priority:  req.Priority,
+		Record:    req.Record,
+	}
+	base := req.ReqID
+	s.log("broadcast: %d providers (%s)", len(providers), strings.Join(providers, ","))
+
+	results := make(map[string]*adapter.ProviderResult, len(providers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		ask := each
+		ask.Provider = provider
+		if dir := req.WorkDirs[provider]; dir != "" {
+			ask.WorkDir = dir
+		}
+		reqID := ""
+		if base != "" {
+			reqID = base + "-" + provider
+		}
+		ask.ReqID = reqID
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handleRequest(&resultConn{Conn: conn, reqID: reqID, ctx: contextOf(conn), done: func(r *adapter.ProviderResult) {
+				mu.Lock()
+				results[provider] = r
+				mu.Unlock()
+			}}, &ask)
+		}()
+	}
+	wg.Wait()
+
+	resp := protocol.BroadcastResponse{Status: "ok", Providers: providers, Results: make(map[string]protocol.AskResponse, len(results))}
+	for p, r := range results {
+		resp.Results[p] = *r
+	}
+	s.sendJSON(conn, resp)
+}
+
+// broadcastProviders returns a broadcast's provider names, lowercased,
+// without blanks or duplicates, in request order.
+func broadcastProviders(list []string) []string {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, p := range list {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		providers = append(providers, p)
+	}
+	return providers
+}
@@ -1,15 +1,31 @@
 package daemon
 
 import (
+	"crypto/tls"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/signal"
+	goruntime "runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/anthropics/claude_code_bridge/internal/audit"
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/certs"
 	"github.com/anthropics/claude_code_bridge/internal/config"
 	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/lock"
+	"github.com/anthropics/claude_code_bridge/internal/npipe"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
+	"github.com/anthropics/claude_code_bridge/internal/replies"
 	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/scratch"
+	"github.com/anthropics/claude_code_bridge/internal/session"
 	"github.com/anthropics/claude_code_bridge/internal/terminal"
 )
 
@@ -18,6 +34,8 @@ type UnifiedDaemon struct {
 	server   *Server
 	registry *Registry
 	backend  terminal.Backend
+	host     string
+	port     int
 }
 
 // DaemonConfig holds configuration for the unified daemon.
@@ -26,9 +44,67 @@ type DaemonConfig struct {
 	Port        int
 	Providers   []string
 	IdleTimeout time.Duration
+	Idle        IdleTimeouts // providers' own idle timeouts, overriding IdleTimeout
+	Drain       time.Duration
 	ParentPID   int
 	StateFile   string
 	LogFile     string
+	LogFormat   string // config.LogFormatText or config.LogFormatJSON
+	TraceRPC    bool
+	LogMirror   io.Writer
+	Pipe        string      // Windows named pipe to listen on instead of TCP
+	Socket      string      // unix socket to listen on instead of TCP
+	TLS         *tls.Config // serve TCP over TLS; nil for plain TCP
+	HTTPAddr    string      // host:port for the HTTP+JSON gateway; empty disables it
+	GRPCAddr    string      // host:port for the gRPC service; empty disables it
+	MetricsAddr string      // host:port for the unauthenticated /metrics listener; empty disables it
+	AuditFile   string      // append-only audit log of asks; empty disables it
+	AuditFull   bool        // audit full prompts instead of their hashes
+	Concurrency map[string]int
+	RateLimits  RateLimits
+	Reload      func() Settings // re-reads providers and limits on SIGHUP or the reload method; nil disables it
+	WorkDir     string          // project whose ccb.config declares custom providers
+}
+
+// newAdapter returns the adapter for provider, built in or declared in
+// workDir's ccb.config, or false if ccb has none. A custom provider's
+// adapter looks its declaration up again for each ask's project.
+func newAdapter(provider string, backend terminal.Backend, workDir string) (adapter.Adapter, bool) {
+	switch provider {
+	case "codex":
+		return adapter.NewCodexAdapter(backend), true
+	case "gemini":
+		return adapter.NewGeminiAdapter(backend), true
+	case "opencode":
+		return adapter.NewOpenCodeAdapter(backend), true
+	case "claude":
+		return adapter.NewClaudeAdapter(backend), true
+	case "droid":
+		return adapter.NewDroidAdapter(backend), true
+	case "aider":
+		return adapter.NewAiderAdapter(backend), true
+	case "goose":
+		return adapter.NewGooseAdapter(backend), true
+	case "amp":
+		return adapter.NewAmpAdapter(backend), true
+	case "cursor":
+		return adapter.NewCursorAdapter(backend), true
+	case "qwen":
+		return adapter.NewQwenAdapter(backend), true
+	}
+	if _, ok := config.LookupCustomProvider(workDir, provider); ok {
+		return adapter.NewCustomAdapter(backend, provider), true
+	}
+	return nil, false
+}
+
+// storageRoot returns the directory provider's session logs are read
+// from, for a custom provider as workDir's ccb.config declares it.
+func storageRoot(provider, workDir string) string {
+	if spec, ok := config.LookupCustomProvider(workDir, provider); ok {
+		return spec.SessionDir
+	}
+	return session.ProviderLogRoot(provider)
 }
 
 // NewUnifiedDaemon creates a new unified daemon.
@@ -45,22 +121,9 @@ func NewUnifiedDaemon(cfg DaemonConfig) (*UnifiedDaemon, error) {
 	registry := NewRegistry()
 
 	for _, provider := range cfg.Providers {
-		var a adapter.Adapter
-		switch provider {
-		case "codex":
-			a = adapter.NewCodexAdapter(backend)
-		case "gemini":
-			a = adapter.NewGeminiAdapter(backend)
-		case "opencode":
-			a = adapter.NewOpenCodeAdapter(backend)
-		case "claude":
-			a = adapter.NewClaudeAdapter(backend)
-		case "droid":
-			a = adapter.NewDroidAdapter(backend)
-		default:
-			continue
+		if a, ok := newAdapter(provider, backend, cfg.WorkDir); ok {
+			registry.Register(provider, a)
 		}
-		registry.Register(provider, a)
 	}
 
 	// Determine state and log files
@@ -71,59 +134,271 @@ func NewUnifiedDaemon(cfg DaemonConfig) (*UnifiedDaemon, error) {
 		cfg.LogFile = runtime.LogPath("askd")
 	}
 
+	storage := Preflight(registry.Names(), func(provider string) string {
+		return storageRoot(provider, cfg.WorkDir)
+	})
+	for _, c := range storage {
+		// In the foreground the server's own preflight log lines reach stderr.
+		if !c.OK && cfg.LogMirror == nil {
+			output.Warnf("%s storage %s %s (%s)", c.Provider, c.Path, c.Problem, c.Hint)
+		}
+	}
+
+	tokens := auth.NewTokens(runtime.RandomToken())
+	authn, err := auth.FromEnv(tokens, ClientsFile())
+	if err != nil {
+		return nil, err
+	}
+
+	adapters := func(provider, workDir string) (adapter.Adapter, bool) {
+		if workDir == "" {
+			workDir = cfg.WorkDir
+		}
+		return newAdapter(provider, backend, workDir)
+	}
 	server := NewServer(ServerConfig{
 		Host:        cfg.Host,
 		Port:        cfg.Port,
+		Tokens:      tokens,
+		Auth:        authn,
 		StateFile:   cfg.StateFile,
 		LogFile:     cfg.LogFile,
+		LogFormat:   cfg.LogFormat,
+		QueueFile:   runtime.StateFilePath("askd-queue"),
+		PauseFile:   runtime.StateFilePath("askd-paused"),
+		RoutesFile:  session.RegistryPath(),
+		HistoryDir:  history.Dir(runtime.RunDir()),
+		RecordDir:   recording.Dir(runtime.RunDir()),
+		ScratchDir:  scratch.Dir(runtime.RunDir()),
+		ReplyDir:    replies.Dir(runtime.RunDir()),
+		AuditFile:   cfg.AuditFile,
+		AuditFull:   cfg.AuditFull,
+		Storage:     storage,
 		IdleTimeout: cfg.IdleTimeout,
+		Idle:        cfg.Idle,
+		Drain:       cfg.Drain,
+		Concurrency: cfg.Concurrency,
+		RateLimits:  cfg.RateLimits,
+		Reload:      cfg.Reload,
+		NewAdapter:  adapters,
+		StorageRoot: storageRoot,
 		ParentPID:   cfg.ParentPID,
+		TraceRPC:    cfg.TraceRPC,
+		LogMirror:   cfg.LogMirror,
+		Pipe:        cfg.Pipe,
+		Socket:      cfg.Socket,
+		TLS:         cfg.TLS,
+		HTTPAddr:    cfg.HTTPAddr,
+		GRPCAddr:    cfg.GRPCAddr,
+		MetricsAddr: cfg.MetricsAddr,
 	}, registry)
 
+	host := cfg.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
 	return &UnifiedDaemon{
 		server:   server,
 		registry: registry,
 		backend:  backend,
+		host:     host,
+		port:     cfg.Port, // 0 auto-assigns
 	}, nil
 }
 
+// ClientsFile is where paired client tokens are kept (see auth.Paired).
+func ClientsFile() string {
+	return runtime.StateFilePath("askd-clients")
+}
+
 // Run starts the daemon and blocks until shutdown.
 func (d *UnifiedDaemon) Run() error {
-	host := "127.0.0.1"
-	port := 0 // auto-assign
-
-	if err := d.server.Start(host, port); err != nil {
+	if err := d.server.Start(d.host, d.port); err != nil {
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+wait:
+	for {
+		select {
+		case sig := <-sigCh:
+			d.server.log("received signal %v, shutting down", sig)
+			d.server.Shutdown()
+			break wait
+		case <-hupCh:
+			d.server.reload()
+		case <-d.server.shutdown:
+			// Already shutting down
+			break wait
+		}
+	}
 
+	// Asks in flight get to finish, unless another signal says not to wait.
+	stopped := make(chan struct{})
+	go func() {
+		d.server.Wait()
+		close(stopped)
+	}()
 	select {
+	case <-stopped:
 	case sig := <-sigCh:
-		fmt.Fprintf(os.Stderr, "received signal %v, shutting down\n", sig)
-		d.server.Shutdown()
-	case <-d.server.shutdown:
-		// Already shutting down
+		d.server.log("received signal %v, exiting without waiting for asks in flight", sig)
 	}
-
-	d.server.Wait()
 	return nil
 }
 
+// RunOptions are the "ccb daemon start" flags.
+type RunOptions struct {
+	Foreground bool   // log to stderr as well and never shut down for idleness
+	Verbose    bool   // log every RPC with its outcome and timings; implied by --log-level debug
+	Pipe       bool   // listen on a Windows named pipe; implied by CCB_TRANSPORT=pipe
+	Unix       bool   // listen on a unix socket (Linux, macOS); implied by CCB_TRANSPORT=unix
+	TLS        bool   // serve TLS and require client certificates; implied by CCB_ASKD_TLS=1
+	HTTP       string // also serve the HTTP+JSON gateway on this host:port; defaults to CCB_ASKD_HTTP
+	GRPC       string // also serve the gRPC service on this host:port; defaults to CCB_ASKD_GRPC
+	Metrics    string // also serve /metrics, without a token, on this host:port; defaults to CCB_ASKD_METRICS
+	Audit      bool   // append every ask to the audit log; implied by CCB_AUDIT=1
+	AuditFull  bool   // audit full prompts, not hashes (implies Audit); implied by CCB_AUDIT_PROMPTS=1
+	ParentPID  int    // shut down once this process exits; defaults to CCB_PARENT_PID
+}
+
+// ParentPIDEnv ties a daemon to the process whose PID it holds: the daemon
+// shuts down once that process exits. Unset, the daemon outlives whoever
+// started it, as an auto-started one must.
+const ParentPIDEnv = "CCB_PARENT_PID"
+
+// TransportEnv selects how clients reach an auto-started daemon: "tcp"
+// (the default), "pipe" for a Windows named pipe (see runtime.PipeName) or
+// "unix" for a unix socket (see runtime.SocketPath).
+const TransportEnv = "CCB_TRANSPORT"
+
+// pipeName returns the named pipe the daemon should listen on, or "" for
+// another transport.
+func pipeName(opts RunOptions) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv(TransportEnv))); v {
+	case "", "tcp", "unix":
+		if !opts.Pipe {
+			return "", nil
+		}
+	case "pipe":
+	default:
+		return "", fmt.Errorf("%s=%q: want tcp, pipe or unix", TransportEnv, v)
+	}
+	if goruntime.GOOS != "windows" {
+		return "", npipe.ErrUnsupported
+	}
+	return runtime.PipeName(), nil
+}
+
+// socketPath returns the unix socket the daemon should listen on, or ""
+// for another transport.
+func socketPath(opts RunOptions) (string, error) {
+	if !opts.Unix && strings.ToLower(strings.TrimSpace(os.Getenv(TransportEnv))) != "unix" {
+		return "", nil
+	}
+	if goruntime.GOOS == "windows" {
+		return "", fmt.Errorf("unix sockets are not supported on Windows; use the named pipe (%s=pipe)", TransportEnv)
+	}
+	return runtime.SocketPath(), nil
+}
+
 // RunDefault creates and runs a daemon with default configuration.
 func RunDefault() error {
+	return RunWithOptions(RunOptions{})
+}
+
+// RunWithOptions creates and runs a daemon with default configuration
+// adjusted by opts.
+func RunWithOptions(opts RunOptions) error {
+	single, err := lockSingleton()
+	if err != nil {
+		return err
+	}
+	defer single.Release()
+
+	pipe, err := pipeName(opts)
+	if err != nil {
+		return err
+	}
+	socket, err := socketPath(opts)
+	if err != nil {
+		return err
+	}
+	if pipe != "" && socket != "" {
+		return fmt.Errorf("--pipe and --unix cannot be combined")
+	}
+	host := strings.TrimSpace(os.Getenv("CCB_ASKD_HOST"))
+	tlsConfig, err := serverTLS(opts, host, pipe != "" || socket != "")
+	if err != nil {
+		return err
+	}
+	httpAddr := opts.HTTP
+	if httpAddr == "" {
+		httpAddr = strings.TrimSpace(os.Getenv(HTTPEnv))
+	}
+	grpcAddr := opts.GRPC
+	if grpcAddr == "" {
+		grpcAddr = strings.TrimSpace(os.Getenv(GRPCEnv))
+	}
+	metricsAddr := opts.Metrics
+	if metricsAddr == "" {
+		metricsAddr = strings.TrimSpace(os.Getenv(MetricsEnv))
+	}
+	auditFull := opts.AuditFull || audit.FullPrompts()
+	var auditFile string
+	if opts.Audit || auditFull || audit.Enabled() {
+		auditFile = audit.File(runtime.RunDir())
+	}
+	parentPID := opts.ParentPID
+	if parentPID == 0 {
+		parentPID = config.EnvInt(ParentPIDEnv, 0)
+	}
 	cwd, _ := os.Getwd()
-	cfg := LoadStartConfig(cwd)
-	providers := cfg.GetProviders()
+	settings := LoadSettings(cwd)
+	reload := func() Settings { return LoadSettings(cwd) }
 
-	idleTimeout := time.Duration(config.EnvInt("CCB_ASKD_IDLE_TIMEOUT_S", 1800)) * time.Second
+	idleTimeout := time.Duration(config.IdleTimeout(cwd, "")) * time.Second
+	var mirror io.Writer
+	if opts.Foreground {
+		idleTimeout = -1
+		settings.Idle = nil
+		reload = func() Settings {
+			st := LoadSettings(cwd)
+			st.Idle = nil
+			return st
+		}
+		mirror = os.Stderr
+	}
 
 	daemon, err := NewUnifiedDaemon(DaemonConfig{
-		Providers:   providers,
+		Providers:   settings.Providers,
 		IdleTimeout: idleTimeout,
-		ParentPID:   os.Getppid(),
+		Idle:        settings.Idle,
+		Drain:       settings.Drain,
+		ParentPID:   parentPID,
+		TraceRPC:    opts.Verbose || output.Enabled(output.LevelDebug),
+		LogFormat:   config.LogFormat(cwd),
+		WorkDir:     cwd,
+		LogMirror:   mirror,
+		Pipe:        pipe,
+		Socket:      socket,
+		Host:        host,
+		Port:        config.EnvInt("CCB_ASKD_PORT", 0),
+		TLS:         tlsConfig,
+		HTTPAddr:    httpAddr,
+		GRPCAddr:    grpcAddr,
+		MetricsAddr: metricsAddr,
+		AuditFile:   auditFile,
+		AuditFull:   auditFull,
+		Concurrency: settings.Concurrency,
+		RateLimits:  settings.RateLimits,
+		Reload:      reload,
 	})
 	if err != nil {
 		return err
@@ -132,6 +407,47 @@ func RunDefault() error {
 	return daemon.Run()
 }
 
+// lockSingleton takes the run dir's daemon lock, held until the daemon
+// exits, so a second daemon can't start and overwrite the first one's
+// state file.
+func lockSingleton() (*lock.ProviderLock, error) {
+	l := lock.NewFileLock(runtime.LockPath("askd"), time.Second)
+	if l.Acquire() {
+		return l, nil
+	}
+	if pid := l.Holder(); pid > 0 {
+		return nil, fmt.Errorf("daemon already running (pid %d; lock %s)", pid, l.LockFile)
+	}
+	return nil, fmt.Errorf("daemon already running (lock %s)", l.LockFile)
+}
+
+// serverTLS returns the daemon's TLS config when --tls or CCB_ASKD_TLS asks
+// for it, creating the CA and certificates under the run dir as needed.
+// Binding a non-loopback host without TLS only draws a warning.
+func serverTLS(opts RunOptions, host string, local bool) (*tls.Config, error) {
+	if !opts.TLS && !config.EnvBool("CCB_ASKD_TLS", false) {
+		if host != "" && !isLoopback(host) {
+			output.Warnf("the daemon listens on %s without TLS; anyone who learns the token can use it (set CCB_ASKD_TLS=1)", host)
+		}
+		return nil, nil
+	}
+	if local {
+		return nil, fmt.Errorf("TLS applies to TCP only, not to the named pipe or unix socket transports")
+	}
+	hosts := certs.ServerHosts(host, strings.Split(os.Getenv("CCB_ASKD_TLS_HOSTS"), ","))
+	return certs.ServerConfig(certs.Dir(runtime.RunDir()), hosts)
+}
+
+// isLoopback reports whether host is localhost or a loopback address.
+func isLoopback(host string) bool {
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // LoadStartConfig loads the start configuration for the daemon.
 func LoadStartConfig(workDir string) *config.StartConfig {
 	return config.LoadStartConfig(workDir)
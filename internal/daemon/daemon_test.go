@@ -1,7 +1,43 @@
 package daemon
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/anthropics/claude_code_bridge/internal/audit"
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/bridgepb"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/npipe"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
+	"github.com/anthropics/claude_code_bridge/internal/schema"
+	"github.com/anthropics/claude_code_bridge/internal/session"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -39,3 +75,2733 @@ func TestWorkerPoolShutdown(t *testing.T) {
 		t.Errorf("after shutdown active workers = %d, want 0", wp.ActiveWorkers())
 	}
 }
+
+func TestWorkerPoolLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int32
+	}{
+		{"limit 1 serializes sessions", 1, 1},
+		{"no limit", 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp := NewWorkerPool(10)
+			defer wp.Shutdown()
+			wp.SetLimit("codex", tt.limit)
+			if got := wp.Limit("codex"); got != tt.limit {
+				t.Errorf("Limit = %d, want %d", got, tt.limit)
+			}
+
+			var running, peak int32
+			var wg sync.WaitGroup
+			handler := func(ctx context.Context, task *adapter.QueuedTask) {
+				defer wg.Done()
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(30 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			}
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				task := &adapter.QueuedTask{Ctx: context.Background()}
+				wp.Submit("codex", fmt.Sprintf("codex:/work/%d", i), task, handler)
+			}
+			wg.Wait()
+			if peak != tt.want {
+				t.Errorf("peak concurrency = %d, want %d", peak, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolLimitCanceled(t *testing.T) {
+	wp := NewWorkerPool(10)
+	defer wp.Shutdown()
+	wp.SetLimit("codex", 1)
+
+	release := make(chan struct{})
+	ran := make(chan error, 2)
+	handler := func(ctx context.Context, task *adapter.QueuedTask) {
+		if ctx.Err() == nil {
+			<-release
+		}
+		ran <- ctx.Err()
+	}
+	wp.Submit("codex", "codex:/a", &adapter.QueuedTask{Ctx: context.Background()}, handler)
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Submit("codex", "codex:/b", &adapter.QueuedTask{Ctx: ctx}, handler)
+	cancel()
+	select {
+	case err := <-ran:
+		if err != context.Canceled {
+			t.Errorf("waiting task ran with ctx err %v, want canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("canceled task still waiting for a slot")
+	}
+	close(release)
+	<-ran
+}
+
+func TestWorkerPoolSetLimitWhileRunning(t *testing.T) {
+	wp := NewWorkerPool(10)
+	defer wp.Shutdown()
+	wp.SetLimit("codex", 2)
+
+	started := make(chan string, 4)
+	release := map[string]chan struct{}{"a": make(chan struct{}), "b": make(chan struct{}), "c": make(chan struct{}), "d": make(chan struct{})}
+	handler := func(ctx context.Context, task *adapter.QueuedTask) {
+		started <- task.Request.ReqID
+		<-release[task.Request.ReqID]
+	}
+	submit := func(id string) {
+		wp.Submit("codex", "codex:/"+id, &adapter.QueuedTask{Ctx: context.Background(), Request: &adapter.ProviderRequest{ReqID: id}}, handler)
+	}
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-started:
+			if got != want {
+				t.Fatalf("started %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%q never started", want)
+		}
+	}
+	expectNone := func(why string) {
+		t.Helper()
+		select {
+		case got := <-started:
+			t.Fatalf("%q started %s", got, why)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	submit("a")
+	expect("a")
+	submit("b")
+	expect("b")
+	submit("c")
+	expectNone("over the cap of 2")
+
+	// Lowered to 1 with two running: one finishing frees no slot.
+	wp.SetLimit("codex", 1)
+	close(release["a"])
+	expectNone("while the lowered cap of 1 is still taken")
+	close(release["b"])
+	expect("c")
+
+	// Raising the cap starts a waiter straight away.
+	submit("d")
+	expectNone("over the cap of 1")
+	wp.SetLimit("codex", 2)
+	expect("d")
+	close(release["c"])
+	close(release["d"])
+}
+
+func TestWorkerPoolPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		sessions bool // each task in its own session, ordered by the provider limit
+	}{
+		{"one session", 0, false},
+		{"provider limit", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp := NewWorkerPool(10)
+			defer wp.Shutdown()
+			wp.SetLimit("codex", tt.limit)
+
+			release := make(chan struct{})
+			var mu sync.Mutex
+			var order []string
+			var wg sync.WaitGroup
+			handler := func(ctx context.Context, task *adapter.QueuedTask) {
+				defer wg.Done()
+				if task.Request.ReqID == "first" {
+					<-release
+				}
+				mu.Lock()
+				order = append(order, task.Request.ReqID)
+				mu.Unlock()
+			}
+			submit := func(id, priority string) {
+				wg.Add(1)
+				key := "codex:/work"
+				if tt.sessions {
+					key += "/" + id
+				}
+				task := &adapter.QueuedTask{Ctx: context.Background(), Request: &adapter.ProviderRequest{ReqID: id, Priority: priority}}
+				wp.Submit("codex", key, task, handler)
+				time.Sleep(10 * time.Millisecond)
+			}
+			submit("first", "")
+			submit("batch1", adapter.PriorityBackground)
+			submit("batch2", adapter.PriorityBackground)
+			submit("cask", adapter.PriorityInteractive)
+			close(release)
+			wg.Wait()
+
+			want := []string{"first", "cask", "batch1", "batch2"}
+			if !reflect.DeepEqual(order, want) {
+				t.Errorf("order = %v, want %v", order, want)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	tests := []struct {
+		name      string
+		sendFirst bool // the handler sends its result before panicking
+		wantErr   string
+	}{
+		{"panic fails the task", false, "internal error: codex adapter panicked: adapter bug"},
+		{"result already sent", true, "sent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp := NewWorkerPool(10)
+			defer wp.Shutdown()
+			wp.SetLimit("codex", 1) // the crashed task must give its slot back
+
+			var mu sync.Mutex
+			var panicked []string
+			wp.OnPanic(func(provider string, task *adapter.QueuedTask, v interface{}, stack []byte) {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(stack) == 0 {
+					t.Error("OnPanic got no stack")
+				}
+				panicked = append(panicked, fmt.Sprintf("%s/%s: %v", provider, task.Request.ReqID, v))
+			})
+			handler := func(ctx context.Context, task *adapter.QueuedTask) {
+				if task.Request.ReqID == "bad" {
+					if tt.sendFirst {
+						task.ResultCh <- &adapter.ProviderResult{ReqID: "bad", Error: "sent"}
+					}
+					panic("adapter bug")
+				}
+				task.ResultCh <- &adapter.ProviderResult{ReqID: task.Request.ReqID}
+			}
+			submit := func(id, key string) *adapter.QueuedTask {
+				task := &adapter.QueuedTask{Ctx: context.Background(), Request: &adapter.ProviderRequest{ReqID: id},
+					ResultCh: make(chan *adapter.ProviderResult, 1)}
+				wp.Submit("codex", key, task, handler)
+				return task
+			}
+			bad := submit("bad", "codex:/work")
+			same := submit("same", "codex:/work")
+			other := submit("other", "codex:/other")
+
+			for _, task := range []*adapter.QueuedTask{bad, same, other} {
+				select {
+				case r := <-task.ResultCh:
+					wantErr, wantCode := "", output.ExitOK
+					if task == bad {
+						wantErr = tt.wantErr
+						if !tt.sendFirst {
+							wantCode = output.ExitError
+						}
+					}
+					if r.Error != wantErr || r.ExitCode != wantCode || r.ReqID != task.Request.ReqID {
+						t.Errorf("%s: result = %+v, want exit %d error %q", task.Request.ReqID, r, wantCode, wantErr)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("%s: no result", task.Request.ReqID)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if want := []string{"codex/bad: adapter bug"}; !reflect.DeepEqual(panicked, want) {
+				t.Errorf("panics = %v, want %v", panicked, want)
+			}
+		})
+	}
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	c := newResultCache(2)
+	for _, id := range []string{"a", "b", "c"} {
+		c.Put("codex", &adapter.ProviderResult{ReqID: id, Reply: "reply " + id})
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("oldest entry should have been evicted")
+	}
+	got, ok := c.Get("c")
+	if !ok || got.Provider != "codex" || got.Result.Reply != "reply c" {
+		t.Errorf("Get(c) = %+v, %v", got, ok)
+	}
+	c.Put("codex", &adapter.ProviderResult{ReqID: ""})
+	if len(c.order) != 2 {
+		t.Errorf("results without req_id must not be cached, order=%v", c.order)
+	}
+}
+
+func TestHandleConnServesMultipleRequests(t *testing.T) {
+	s := NewServer(ServerConfig{Token: "tok"}, NewRegistry())
+	defer s.workerPool.Shutdown()
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		s.handleConn(server)
+		close(done)
+	}()
+
+	enc := json.NewEncoder(client)
+	dec := json.NewDecoder(client)
+	for i := 0; i < 3; i++ {
+		if err := enc.Encode(map[string]interface{}{"method": "ping", "token": "tok"}); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("response %d: %v", i, err)
+		}
+		if resp["status"] != "ok" {
+			t.Fatalf("response %d = %v", i, resp)
+		}
+	}
+
+	// A request that fails schema validation is rejected, but the
+	// connection stays usable.
+	enc.Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "timeout_s": "60"})
+	var invalid map[string]interface{}
+	dec.Decode(&invalid)
+	if msg, _ := invalid["error"].(string); !strings.HasPrefix(msg, "invalid request: timeout_s") {
+		t.Errorf("invalid request response = %v", invalid)
+	}
+
+	// A bad token ends the conversation.
+	enc.Encode(map[string]interface{}{"method": "ping", "token": "wrong"})
+	var resp map[string]interface{}
+	dec.Decode(&resp)
+	if resp["error"] != "invalid token" {
+		t.Errorf("bad token response = %v", resp)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("connection not closed after invalid token")
+	}
+}
+
+// fakeAdapter answers every ask with "echo: <message>" while online,
+// reporting its anchor and streaming "echo:" first when asked to.
+type fakeAdapter struct {
+	adapter.BaseAdapter
+	mu     sync.Mutex
+	online bool
+}
+
+func (f *fakeAdapter) setOnline(v bool) {
+	f.mu.Lock()
+	f.online = v
+	f.mu.Unlock()
+}
+
+func (f *fakeAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	if req.OnAnchor != nil {
+		req.OnAnchor()
+	}
+	if req.OnLines != nil {
+		req.OnLines([]string{"echo:"})
+	}
+	req.Recorder.Input(req.Message + "\r")
+	return &adapter.ProviderResult{ReqID: req.ReqID, Reply: "echo: " + req.Message}, nil
+}
+func (f *fakeAdapter) Ping(ctx context.Context, sessionID string) error           { return nil }
+func (f *fakeAdapter) Pend(ctx context.Context, sessionID string) (string, error) { return "", nil }
+func (f *fakeAdapter) OnStop() error                                              { return nil }
+func (f *fakeAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.online {
+		return "", errors.New("pane not found")
+	}
+	return "%1", nil
+}
+
+func TestOfflineQueueDelivery(t *testing.T) {
+	t.Setenv("CCB_NOTIFY", "0")
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	queueFile := filepath.Join(t.TempDir(), "queue.json")
+	s := NewServer(ServerConfig{Token: "tok", QueueFile: queueFile}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "work_dir": "/w",
+		"message": "hi", "req_id": "r1", "timeout_s": 5, "queue": true,
+	})
+	var resp adapter.ProviderResult
+	if err := json.NewDecoder(client).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Queued || resp.ReqID != "r1" {
+		t.Fatalf("offline ask not queued: %+v", resp)
+	}
+
+	// The queue survives a restart.
+	if n := newAskQueue(queueFile).Len(); n != 1 {
+		t.Fatalf("persisted queue has %d items, want 1", n)
+	}
+
+	s.deliverQueued() // still offline: nothing happens
+	if s.queue.Len() != 1 {
+		t.Fatal("ask delivered while provider offline")
+	}
+
+	fake.setOnline(true)
+	s.deliverQueued()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got, ok := s.results.Get("r1"); ok {
+			if got.Result.Reply != "echo: hi" {
+				t.Errorf("delivered reply = %q", got.Result.Reply)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("queued ask was not delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.queue.Len() != 0 {
+		t.Error("delivered ask still queued")
+	}
+	if _, err := os.Stat(queueFile); !os.IsNotExist(err) {
+		t.Error("empty queue file should be removed")
+	}
+}
+
+func TestStreamingRequestSendsChunks(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex",
+		"message": "hi", "req_id": "r2", "timeout_s": 5, "stream": true,
+	})
+
+	dec := json.NewDecoder(client)
+	var chunk map[string]interface{}
+	if err := dec.Decode(&chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk["event"] != "chunk" || chunk["req_id"] != "r2" {
+		t.Fatalf("first message = %v, want chunk event", chunk)
+	}
+	var result adapter.ProviderResult
+	if err := dec.Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Reply != "echo: hi" {
+		t.Errorf("final reply = %q", result.Reply)
+	}
+}
+
+func TestAskQueueScheduleAndTTL(t *testing.T) {
+	now := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	req := func(id string) *adapter.ProviderRequest { return &adapter.ProviderRequest{ReqID: id, WorkDir: "/w"} }
+	q := newAskQueue("")
+	q.Add(queuedAsk{Provider: "codex", Request: req("now")})
+	q.Add(queuedAsk{Provider: "codex", Request: req("later"), DeliverAt: now.Add(time.Hour)})
+	q.Add(queuedAsk{Provider: "gemini", Request: req("stale"), ExpiresAt: now.Add(-time.Minute)})
+
+	if expired := q.Expire(now); len(expired) != 1 || expired[0].Request.ReqID != "stale" {
+		t.Fatalf("Expire = %+v, want only the stale ask", expired)
+	}
+	target := queueTarget{"codex", "/w"}
+	if got := q.Targets(now); len(got) != 1 || got[0] != target {
+		t.Fatalf("Targets = %v", got)
+	}
+	if taken := q.Take(target, now); len(taken) != 1 || taken[0].Request.ReqID != "now" {
+		t.Fatalf("Take(now) = %+v, want only the due ask", taken)
+	}
+	if q.Len() != 1 || len(q.Targets(now)) != 0 {
+		t.Fatal("scheduled ask should stay queued until due")
+	}
+	if taken := q.Take(target, now.Add(time.Hour)); len(taken) != 1 {
+		t.Fatal("scheduled ask not released at its delivery time")
+	}
+}
+
+// blockingAdapter never replies; it waits for its context like a provider
+// that is still thinking.
+type blockingAdapter struct {
+	fakeAdapter
+}
+
+func (b *blockingAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	req.OnPhase(adapter.PhaseWaiting)
+	<-ctx.Done()
+	return &adapter.ProviderResult{ExitCode: 2, Error: "timeout", ReqID: req.ReqID}, nil
+}
+
+func TestRequestsListAndCancel(t *testing.T) {
+	slow := &blockingAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	reg := NewRegistry()
+	reg.Register("codex", slow)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	s.queue.Add(queuedAsk{Provider: "codex", Request: &adapter.ProviderRequest{ReqID: "later"}, QueuedAt: time.Now().Add(-time.Minute)})
+
+	done := make(chan *adapter.ProviderResult, 1)
+	go func() {
+		done <- s.execute(context.Background(), "codex", slow, &adapter.ProviderRequest{ReqID: "r3", Caller: "claude", TimeoutS: 30})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		reqs := s.Requests()
+		// Oldest first: the queued ask was added before r3 started.
+		if len(reqs) == 2 && reqs[1].ReqID == "r3" && reqs[1].Phase == adapter.PhaseWaiting {
+			if reqs[1].Caller != "claude" || reqs[0].ReqID != "later" || reqs[0].Phase != adapter.PhaseQueued {
+				t.Fatalf("requests = %+v", reqs)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("requests = %+v, want r3 waiting and later queued", reqs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if state, ok := s.Cancel("r3"); !ok || state != "canceled" {
+		t.Fatalf("Cancel(r3) = %q, %v", state, ok)
+	}
+	select {
+	case result := <-done:
+		if result.Error != "canceled" || result.ExitCode == 0 {
+			t.Errorf("canceled result = %+v", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("canceled ask did not return")
+	}
+
+	if state, ok := s.Cancel("later"); !ok || state != "dequeued" {
+		t.Errorf("Cancel(later) = %q, %v", state, ok)
+	}
+	if _, ok := s.Cancel("r3"); ok {
+		t.Error("finished ask still cancelable")
+	}
+	if reqs := s.Requests(); len(reqs) != 0 {
+		t.Errorf("requests after cancel = %+v", reqs)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GEMINI_ROOT", "")
+	os.MkdirAll(filepath.Join(home, ".codex", "sessions"), 0755)
+	notDir := filepath.Join(home, "file")
+	os.WriteFile(notDir, nil, 0644)
+	roots := map[string]string{
+		"codex":  filepath.Join(home, ".codex", "sessions"),
+		"gemini": filepath.Join(home, ".gemini", "tmp"),
+		"droid":  notDir,
+	}
+
+	checks := Preflight([]string{"codex", "gemini", "droid", "custom"}, func(p string) string { return roots[p] })
+	if len(checks) != 3 {
+		t.Fatalf("checks = %+v, want codex, gemini, droid", checks)
+	}
+	if !checks[0].OK || checks[0].Hint != "" {
+		t.Errorf("codex = %+v, want ok", checks[0])
+	}
+	wantHint := "run gemini once to create ~" + string(filepath.Separator) + filepath.Join(".gemini", "tmp")
+	if checks[1].OK || checks[1].Problem != "not found" || checks[1].Hint != wantHint {
+		t.Errorf("gemini = %+v, want not found with hint %q", checks[1], wantHint)
+	}
+	if checks[2].OK || checks[2].Problem != "not a directory" {
+		t.Errorf("droid = %+v, want not a directory", checks[2])
+	}
+
+	t.Setenv("GEMINI_ROOT", roots["gemini"])
+	checks = Preflight([]string{"gemini"}, func(p string) string { return roots[p] })
+	if want := "check GEMINI_ROOT, which points at " + roots["gemini"]; checks[0].Hint != want {
+		t.Errorf("hint with GEMINI_ROOT = %q, want %q", checks[0].Hint, want)
+	}
+}
+
+func TestRequestWritesOutputFile(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+	workDir := t.TempDir()
+	blocker := filepath.Join(workDir, "file")
+	os.WriteFile(blocker, nil, 0644)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+
+	// Relative paths resolve against work_dir.
+	enc.Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "work_dir": workDir,
+		"message": "hi", "timeout_s": 5, "output_path": filepath.Join("out", "reply.md"),
+	})
+	var ok adapter.ProviderResult
+	if err := dec.Decode(&ok); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(workDir, "out", "reply.md")
+	if data, err := os.ReadFile(want); err != nil || string(data) != "echo: hi" || ok.OutputPath != want {
+		t.Errorf("output file = %q, %v; result = %+v", data, err, ok)
+	}
+
+	// A failed write fails the ask but keeps the reply.
+	enc.Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "work_dir": workDir,
+		"message": "hi", "timeout_s": 5, "output_path": filepath.Join(blocker, "reply.md"),
+	})
+	var failed adapter.ProviderResult
+	if err := dec.Decode(&failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.ExitCode == 0 || !strings.Contains(failed.Error, "cannot write reply") || failed.Reply != "echo: hi" || failed.OutputPath != "" {
+		t.Errorf("failed write result = %+v", failed)
+	}
+
+	// Paths landing outside work_dir are refused before the ask runs.
+	outside := t.TempDir()
+	os.Symlink(outside, filepath.Join(workDir, "link"))
+	for _, path := range []string{
+		filepath.Join("..", filepath.Base(outside), "reply.md"),
+		filepath.Join(outside, "reply.md"),
+		filepath.Join("link", "reply.md"),
+		filepath.Join("link", "new", "reply.md"),
+	} {
+		enc.Encode(map[string]interface{}{
+			"method": "request", "token": "tok", "provider": "codex", "work_dir": workDir,
+			"message": "hi", "timeout_s": 5, "output_path": path,
+		})
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["status"] != "error" || !strings.Contains(fmt.Sprint(resp["error"]), "outside work_dir") {
+			t.Errorf("output_path %s: %v, want refused", path, resp)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("files written outside work_dir: %v", entries)
+	}
+}
+
+func TestRequestRecording(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	dir := t.TempDir()
+	s := NewServer(ServerConfig{Token: "tok", RecordDir: dir}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+
+	enc.Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "req_id": "r1",
+		"message": "hi", "timeout_s": 5, "record": true,
+	})
+	var res adapter.ProviderResult
+	if err := dec.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Recording != filepath.Join(dir, "r1"+recording.Ext) {
+		t.Fatalf("recording = %q", res.Recording)
+	}
+	_, events, err := recording.Read(res.Recording)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+	if events[0].Kind != recording.KindInput || events[0].Data != "hi\r" || events[1].Data != "exit 0" {
+		t.Errorf("events = %+v", events)
+	}
+
+	// Without "record" (and CCB_RECORD unset) nothing is recorded.
+	enc.Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "req_id": "r2",
+		"message": "hi", "timeout_s": 5,
+	})
+	res = adapter.ProviderResult{}
+	if err := dec.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "r2"+recording.Ext)); res.Recording != "" || err == nil {
+		t.Errorf("unrequested recording: %q", res.Recording)
+	}
+}
+
+// scratchAdapter stages each message in the request's scratch dir and
+// fails messages that say "fail".
+type scratchAdapter struct {
+	fakeAdapter
+}
+
+func (f *scratchAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	if err := os.WriteFile(filepath.Join(req.ScratchDir, "prompt.txt"), []byte(req.Message), 0600); err != nil {
+		return nil, err
+	}
+	if req.Message == "fail" {
+		return &adapter.ProviderResult{ReqID: req.ReqID, ExitCode: output.ExitError, Error: "failed"}, nil
+	}
+	return &adapter.ProviderResult{ReqID: req.ReqID, Reply: "ok"}, nil
+}
+
+func TestRequestScratchDir(t *testing.T) {
+	fake := &scratchAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	dir := t.TempDir()
+	s := NewServer(ServerConfig{Token: "tok", ScratchDir: dir}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	ask := func(reqID, message string) adapter.ProviderResult {
+		t.Helper()
+		enc.Encode(map[string]interface{}{
+			"method": "request", "token": "tok", "provider": "codex", "req_id": reqID,
+			"message": message, "timeout_s": 5,
+		})
+		var res adapter.ProviderResult
+		if err := dec.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// A success cleans up after itself; a failure keeps its payload.
+	if res := ask("r1", "hi"); res.ExitCode != 0 {
+		t.Fatalf("r1 = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "r1")); !os.IsNotExist(err) {
+		t.Errorf("r1 scratch dir left behind: %v", err)
+	}
+	if res := ask("r2", "fail"); res.ExitCode == 0 {
+		t.Fatalf("r2 = %+v", res)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "r2", "prompt.txt")); err != nil || string(data) != "fail" {
+		t.Fatalf("r2 payload = %q, %v", data, err)
+	}
+
+	// Once expired, the next request sweeps it.
+	t.Setenv("CCB_SCRATCH_TTL", "0s")
+	ask("r3", "hi")
+	if _, err := os.Stat(filepath.Join(dir, "r2")); !os.IsNotExist(err) {
+		t.Errorf("expired r2 scratch dir not swept: %v", err)
+	}
+}
+
+func TestPipeTransport(t *testing.T) {
+	tests := []struct {
+		env  string
+		pipe bool
+		want string // "" for TCP, "error" for a failure
+	}{
+		{"", false, ""},
+		{"tcp", false, ""},
+		{"pipe", false, "pipe"},
+		{"", true, "pipe"},
+		{"unix", false, ""},
+		{"carrier-pigeon", false, "error"},
+	}
+	for _, tt := range tests {
+		t.Setenv(TransportEnv, tt.env)
+		t.Setenv("CCB_PIPE_NAME", "ccb-test")
+		got, err := pipeName(RunOptions{Pipe: tt.pipe})
+		switch {
+		case tt.want == "error":
+			if err == nil {
+				t.Errorf("%q: no error", tt.env)
+			}
+		case tt.want == "":
+			if got != "" || err != nil {
+				t.Errorf("%q, pipe=%v: %q, %v; want TCP", tt.env, tt.pipe, got, err)
+			}
+		case goruntime.GOOS != "windows":
+			if !errors.Is(err, npipe.ErrUnsupported) {
+				t.Errorf("%q, pipe=%v: %v; want ErrUnsupported", tt.env, tt.pipe, err)
+			}
+		case got != `\\.\pipe\ccb-test` || err != nil:
+			t.Errorf("%q, pipe=%v: %q, %v", tt.env, tt.pipe, got, err)
+		}
+	}
+
+	tcp := DaemonState{Host: "127.0.0.1", Port: 4242}
+	pipe := DaemonState{Pipe: `\\.\pipe\ccb-test`}
+	if tcp.Address() != "127.0.0.1:4242" || pipe.Address() != `\\.\pipe\ccb-test` {
+		t.Errorf("Address = %q, %q", tcp.Address(), pipe.Address())
+	}
+}
+
+func TestUnixSocketTransport(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("no unix socket transport on Windows")
+	}
+	dir := t.TempDir()
+	t.Setenv(TransportEnv, "unix")
+	t.Setenv("CCB_SOCKET_PATH", filepath.Join(dir, "askd.sock"))
+	path, err := socketPath(RunOptions{})
+	if err != nil || path != filepath.Join(dir, "askd.sock") {
+		t.Fatalf("socketPath = %q, %v", path, err)
+	}
+
+	// A stale socket file from a crashed daemon is replaced.
+	os.WriteFile(path, nil, 0o600)
+	reg := NewRegistry()
+	reg.Register("codex", &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true})
+	s := NewServer(ServerConfig{
+		Token:     "tok",
+		Auth:      auth.Chain{auth.PeerCred{UIDs: []int{os.Getuid()}}},
+		StateFile: filepath.Join(dir, "askd.json"),
+		Socket:    path,
+	}, reg)
+	if err := s.Start("", 0); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, %v; want 0600", info, err)
+	}
+	var state DaemonState
+	data, err := os.ReadFile(filepath.Join(dir, "askd.json"))
+	if err != nil || json.Unmarshal(data, &state) != nil || state.Socket != path || state.Address() != path {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	// With a wrong token, only peer credentials can let the ping through.
+	json.NewEncoder(conn).Encode(map[string]interface{}{"method": "ping", "token": "wrong"})
+	var resp map[string]interface{}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if ok := resp["status"] == "ok"; ok != (goruntime.GOOS == "linux") {
+		t.Errorf("ping with a wrong token over unix socket = %v", resp)
+	}
+}
+
+func TestStartupBudget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CCB_STARTUP_TIMEOUT_S", "1")
+	slow := &blockingAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	reg := NewRegistry()
+	reg.Register("codex", slow)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	first := make(chan *adapter.ProviderResult, 1)
+	go func() {
+		first <- s.execute(context.Background(), "codex", slow, &adapter.ProviderRequest{ReqID: "r1", TimeoutS: 30})
+	}()
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		if reqs := s.Requests(); len(reqs) == 1 && reqs[0].Phase == adapter.PhaseWaiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("r1 never reached the waiting phase")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// r2 waits behind r1 for the session's worker and runs out of startup
+	// budget long before its reply timeout.
+	r2 := s.execute(context.Background(), "codex", slow, &adapter.ProviderRequest{ReqID: "r2", TimeoutS: 30})
+	if r2.ExitCode != 2 || !strings.Contains(r2.Error, "startup timeout") || r2.StartupMs < 1000 || r2.ReplyMs != 0 {
+		t.Errorf("r2 = %+v", r2)
+	}
+
+	// r1 was sent in time, so the startup budget does not apply to it.
+	if reqs := s.Requests(); len(reqs) != 1 || reqs[0].ReqID != "r1" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	s.Cancel("r1")
+	select {
+	case r1 := <-first:
+		if r1.Error != "canceled" || r1.ReplyMs < 1000 {
+			t.Errorf("r1 = %+v", r1)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("r1 not canceled")
+	}
+}
+
+// stuckAdapter never gets its prompt typed in; it reports whether its context
+// ended because the client canceled the ask.
+type stuckAdapter struct {
+	fakeAdapter
+	byClient chan bool
+}
+
+func (a *stuckAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	req.OnPhase(adapter.PhaseSending)
+	<-ctx.Done()
+	a.byClient <- adapter.CanceledByClient(ctx)
+	return &adapter.ProviderResult{ExitCode: 2, Error: "timeout", ReqID: req.ReqID}, nil
+}
+
+func TestCancelCause(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CCB_STARTUP_TIMEOUT_S", "1")
+	stuck := &stuckAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}, make(chan bool, 1)}
+	reg := NewRegistry()
+	reg.Register("codex", stuck)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	// A spent startup budget is no reason to interrupt the provider.
+	r1 := s.execute(context.Background(), "codex", stuck, &adapter.ProviderRequest{ReqID: "r1", TimeoutS: 30})
+	if !strings.Contains(r1.Error, "startup timeout") {
+		t.Errorf("r1 = %+v", r1)
+	}
+	if <-stuck.byClient {
+		t.Error("startup timeout looked like a client cancel to the adapter")
+	}
+
+	done := make(chan *adapter.ProviderResult, 1)
+	go func() {
+		done <- s.execute(context.Background(), "codex", stuck, &adapter.ProviderRequest{ReqID: "r2", TimeoutS: 30})
+	}()
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		if reqs := s.Requests(); len(reqs) == 1 && reqs[0].Phase == adapter.PhaseSending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("r2 never reached the sending phase")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Cancel("r2")
+	if !<-stuck.byClient {
+		t.Error("client cancel not seen as one by the adapter")
+	}
+	if r2 := <-done; r2.Error != "canceled" {
+		t.Errorf("r2 = %+v", r2)
+	}
+}
+
+// lineSink collects log lines written by concurrent goroutines.
+type lineSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *lineSink) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+func (l *lineSink) find(substr string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestTraceRPC(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	sink := &lineSink{}
+	s := NewServer(ServerConfig{Token: "tok", TraceRPC: true, LogMirror: sink}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	for _, req := range []map[string]interface{}{
+		{"method": "ping", "token": "tok"},
+		{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "req_id": "r9", "timeout_s": 5},
+		{"method": "request", "token": "tok", "provider": "gemini", "message": "hi", "timeout_s": 5},
+	} {
+		enc.Encode(req)
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		"rpc method=ping status=ok duration=",
+		"rpc method=request provider=codex req_id=r9 status=ok exit_code=0 ",
+		`rpc method=request provider=gemini status=error duration=`,
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for _, w := range want {
+		for sink.find(w) == "" && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if sink.find(w) == "" {
+			t.Errorf("no trace line containing %q in %q", w, sink.lines)
+		}
+	}
+	if line := sink.find("provider=gemini"); !strings.Contains(line, `error="unknown provider: gemini"`) {
+		t.Errorf("error trace = %q", line)
+	}
+	if line := sink.find("provider=codex"); strings.Contains(line, "tok") {
+		t.Errorf("trace leaks the token: %q", line)
+	}
+}
+
+func TestJSONLog(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	logFile := filepath.Join(t.TempDir(), "askd.log")
+	s := NewServer(ServerConfig{Token: "tok", TraceRPC: true, LogFile: logFile, LogFormat: config.LogFormatJSON}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{
+		"method": "request", "token": "tok", "provider": "codex", "message": "hi",
+		"req_id": "r7", "client_id": "cli-1", "timeout_s": 5,
+	})
+	var resp map[string]interface{}
+	if err := json.NewDecoder(client).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	deadline := time.Now().Add(2 * time.Second)
+	for rec == nil && time.Now().Before(deadline) {
+		data, _ := os.ReadFile(logFile)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var r map[string]interface{}
+			if json.Unmarshal([]byte(line), &r) == nil && r["msg"] == "rpc" {
+				rec = r
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec == nil {
+		t.Fatal("no rpc record in the JSON log")
+	}
+	for k, want := range map[string]interface{}{
+		"level": "INFO", "method": "request", "provider": "codex", "req_id": "r7",
+		"client_id": "cli-1", "status": "ok", "exit_code": float64(0),
+	} {
+		if rec[k] != want {
+			t.Errorf("%s = %v, want %v (record %v)", k, rec[k], want, rec)
+		}
+	}
+	if _, ok := rec["duration"].(float64); !ok {
+		t.Errorf("duration = %v, want seconds", rec["duration"])
+	}
+	if _, ok := rec["time"].(string); !ok {
+		t.Errorf("record has no time: %v", rec)
+	}
+}
+
+func TestPauseProvider(t *testing.T) {
+	t.Setenv("CCB_NOTIFY", "0")
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	pauseFile := filepath.Join(t.TempDir(), "paused.json")
+	s := NewServer(ServerConfig{Token: "tok", PauseFile: pauseFile}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc := json.NewEncoder(client)
+	dec := json.NewDecoder(client)
+	call := func(req map[string]interface{}, out interface{}) {
+		t.Helper()
+		req["token"] = "tok"
+		enc.Encode(req)
+		if err := dec.Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var pause protocol.PauseResponse
+	call(map[string]interface{}{"method": "pause", "provider": "codex", "reason": "manual"}, &pause)
+	if pause.Status != "ok" || pause.Paused["codex"].Reason != "manual" {
+		t.Fatalf("pause response = %+v", pause)
+	}
+	if _, ok := newPauseSet(pauseFile).Get("codex"); !ok {
+		t.Fatal("pause not persisted")
+	}
+
+	var refused adapter.ProviderResult
+	call(map[string]interface{}{"method": "request", "provider": "codex", "message": "hi", "req_id": "r1", "timeout_s": 5}, &refused)
+	if refused.ExitCode != output.ExitPaused || !strings.HasPrefix(refused.Error, "PAUSED: codex") || refused.ReqID != "r1" {
+		t.Fatalf("ask to paused provider = %+v", refused)
+	}
+
+	// With --queue the ask is held until resume.
+	var held adapter.ProviderResult
+	call(map[string]interface{}{"method": "request", "provider": "codex", "message": "later", "req_id": "r2", "timeout_s": 5, "queue": true}, &held)
+	if !held.Queued {
+		t.Fatalf("queued ask to paused provider = %+v", held)
+	}
+	s.deliverQueued()
+	if s.queue.Len() != 1 {
+		t.Fatal("queued ask delivered while paused")
+	}
+
+	var resume protocol.PauseResponse
+	call(map[string]interface{}{"method": "resume", "provider": "codex"}, &resume)
+	if resume.Status != "ok" || len(resume.Paused) != 0 {
+		t.Fatalf("resume response = %+v", resume)
+	}
+	if _, err := os.Stat(pauseFile); !os.IsNotExist(err) {
+		t.Error("pause file should be removed once nothing is paused")
+	}
+	var again map[string]interface{}
+	call(map[string]interface{}{"method": "resume", "provider": "codex"}, &again)
+	if again["error"] != "codex is not paused" {
+		t.Errorf("second resume = %v", again)
+	}
+
+	var answered adapter.ProviderResult
+	call(map[string]interface{}{"method": "request", "provider": "codex", "message": "hi", "timeout_s": 5}, &answered)
+	if answered.Reply != "echo: hi" {
+		t.Errorf("ask after resume = %+v", answered)
+	}
+}
+
+func TestDuplicateReqIDRefused(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc := json.NewEncoder(client)
+	dec := json.NewDecoder(client)
+	ask := func(queue bool) adapter.ProviderResult {
+		t.Helper()
+		enc.Encode(map[string]interface{}{
+			"method": "request", "token": "tok", "provider": "codex",
+			"message": "hi", "req_id": "r1", "timeout_s": 5, "queue": queue,
+		})
+		var resp adapter.ProviderResult
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if first := ask(true); !first.Queued {
+		t.Fatalf("first ask not queued: %+v", first)
+	}
+	dup := ask(false)
+	if dup.ExitCode != 1 || !strings.Contains(dup.Error, "duplicate req_id r1") {
+		t.Errorf("ask reusing a queued req_id = %+v", dup)
+	}
+
+	req := &adapter.ProviderRequest{ReqID: "r2"}
+	if !s.inflight.add("codex", req, func() {}) || s.inflight.add("codex", req, func() {}) {
+		t.Error("inflight set must accept a req_id once")
+	}
+	if got := s.execute(context.Background(), "codex", fake, req); got.ExitCode != 1 || !strings.Contains(got.Error, "duplicate req_id r2") {
+		t.Errorf("execute with an in-flight req_id = %+v", got)
+	}
+	if !s.inflight.has("r2") {
+		t.Error("the refused duplicate must not untrack the original ask")
+	}
+}
+
+func TestHTTPGateway(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+	h := s.httpHandler()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		body   string
+		code   int
+		want   string // substring of the response body
+	}{
+		{"ping", "GET", "/ping?provider=codex", "tok", "", 200, `"status":"ok"`},
+		{"ping in body", "POST", "/ping", "", `{"provider":"codex","token":"tok"}`, 200, `"status":"ok"`},
+		{"no token", "GET", "/ping?provider=codex", "", "", 401, `"status":"error"`},
+		{"bad token", "GET", "/status", "nope", "", 401, `"status":"error"`},
+		{"ask", "POST", "/ask", "tok", `{"provider":"codex","message":"hi","timeout_s":5}`, 200, `echo: hi`},
+		{"ask missing message", "POST", "/ask", "tok", `{"provider":"codex"}`, 400, `"status":"error"`},
+		{"ask via GET", "GET", "/ask?provider=codex&message=hi", "tok", "", 405, `not allowed`},
+		{"bad JSON", "POST", "/ask", "tok", `{`, 400, `invalid JSON`},
+		{"unknown path", "GET", "/nope", "tok", "", 404, `unknown endpoint`},
+		{"pend no session", "GET", "/pend?provider=gemini", "tok", "", 500, `"status":"error"`},
+		{"ask with output_path", "POST", "/ask", "tok", `{"provider":"codex","message":"hi","timeout_s":5,"work_dir":"/tmp","output_path":"reply.md"}`, 400, `only honored for local clients`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("%s %s = %d %s; want %d containing %q", tt.method, tt.target, w.Code, w.Body, tt.code, tt.want)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("POST", "/ask", strings.NewReader(`{"provider":"codex","message":"hi","timeout_s":5,"stream":true}`))
+	r.Header.Set("Authorization", "Bearer tok")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" || len(lines) < 2 {
+		t.Fatalf("stream: %s, %q", ct, w.Body)
+	}
+	if !strings.Contains(lines[0], `"event":"chunk"`) || !strings.Contains(lines[len(lines)-1], "echo: hi") {
+		t.Errorf("stream lines = %q", lines)
+	}
+}
+
+func TestWebSocketEvents(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	reg.Register("slow", &blockingAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "slow"}, online: true}})
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+	srv := httptest.NewServer(s.httpHandler())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	upgrade := func(token string) (net.Conn, *bufio.Reader, int) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		fmt.Fprintf(conn, "GET /ws?token=%s HTTP/1.1\r\nHost: x\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+			"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", token)
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn, br, resp.StatusCode
+	}
+	send := func(conn net.Conn, msg string) {
+		// Client frames are masked; a zero mask keeps the payload as is.
+		frame := append([]byte{0x81, 0x80 | byte(len(msg)), 0, 0, 0, 0}, msg...)
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recv := func(br *bufio.Reader) map[string]interface{} {
+		var hdr [2]byte
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			t.Fatal(err)
+		}
+		n := int(hdr[1] & 0x7F)
+		if n == 126 {
+			var ext [2]byte
+			io.ReadFull(br, ext[:])
+			n = int(ext[0])<<8 | int(ext[1])
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(payload, &m); err != nil {
+			t.Fatalf("event %q: %v", payload, err)
+		}
+		return m
+	}
+
+	if _, _, code := upgrade("nope"); code != http.StatusUnauthorized {
+		t.Errorf("bad token upgrade = %d, want 401", code)
+	}
+	conn, br, code := upgrade("tok")
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade = %d", code)
+	}
+
+	send(conn, `{"provider":"codex","message":"hi","req_id":"w1","timeout_s":5}`)
+	for _, want := range []string{EventAnchorSeen, EventPartialReply, EventDone} {
+		ev := recv(br)
+		if ev["event"] != want || ev["req_id"] != "w1" {
+			t.Fatalf("event = %v, want %s for w1", ev, want)
+		}
+		if want == EventDone && ev["reply"] != "echo: hi" {
+			t.Errorf("done reply = %v", ev["reply"])
+		}
+	}
+
+	send(conn, `{"provider":"codex","req_id":"w2"}`)
+	if ev := recv(br); ev["event"] != EventError || ev["req_id"] != "w2" {
+		t.Errorf("invalid ask event = %v, want error for w2", ev)
+	}
+	send(conn, `{"method":"ping","provider":"codex"}`)
+	if ev := recv(br); ev["status"] != "ok" || ev["event"] != nil {
+		t.Errorf("ping response = %v", ev)
+	}
+
+	// Closing the socket cancels the asks still running on it.
+	conn, _, _ = upgrade("tok")
+	send(conn, `{"provider":"slow","message":"hi","req_id":"w3","timeout_s":30}`)
+	waitFor(t, func() bool { return s.inflight.has("w3") })
+	conn.Close()
+	waitFor(t, func() bool { return !s.inflight.has("w3") })
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGRPCService(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	reg.Register("slow", &blockingAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "slow"}, online: true}})
+	s := NewServer(ServerConfig{Token: "tok", GRPCAddr: "127.0.0.1:0"}, reg)
+	defer s.workerPool.Shutdown()
+	addr, err := s.startGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.grpcServer.Stop()
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := bridgepb.NewBridgeClient(cc)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer tok")
+
+	if _, err := client.Ping(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), &bridgepb.PingRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ping with bad token: %v, want Unauthenticated", err)
+	}
+	ping, err := client.Ping(ctx, &bridgepb.PingRequest{Provider: "codex"})
+	if err != nil || !reflect.DeepEqual(ping.Providers, []string{"codex", "slow"}) {
+		t.Errorf("ping = %v, %v", ping, err)
+	}
+	st, err := client.Status(ctx, &bridgepb.StatusRequest{})
+	if err != nil || st.Pid != int32(os.Getpid()) {
+		t.Errorf("status = %v, %v", st, err)
+	}
+
+	stream, err := client.Ask(ctx, &bridgepb.AskRequest{Provider: "codex", Message: "hi", ReqId: "g1", TimeoutS: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks int
+	var result *bridgepb.AskResult
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := ev.GetChunk(); c != nil {
+			chunks++
+		} else {
+			result = ev.GetResult()
+		}
+	}
+	if chunks == 0 || result == nil || result.Reply != "echo: hi" || result.ReqId != "g1" || result.ExitCode != 0 {
+		t.Errorf("ask: %d chunks, result %v", chunks, result)
+	}
+	if pend, err := client.Pend(ctx, &bridgepb.PendRequest{ReqId: "g1"}); err != nil || pend.Reply != "echo: hi" {
+		t.Errorf("pend = %v, %v", pend, err)
+	}
+
+	stream, err = client.Ask(ctx, &bridgepb.AskRequest{Provider: "codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ask without message: %v, want InvalidArgument", err)
+	}
+
+	// Cancel stops an ask in flight, which still ends with its result.
+	stream, err = client.Ask(ctx, &bridgepb.AskRequest{Provider: "slow", Message: "hi", ReqId: "g2", TimeoutS: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return s.inflight.has("g2") })
+	if c, err := client.Cancel(ctx, &bridgepb.CancelRequest{ReqId: "g2"}); err != nil || c.State != "canceled" {
+		t.Errorf("cancel = %v, %v", c, err)
+	}
+	if ev, err := stream.Recv(); err != nil || ev.GetResult().GetExitCode() == 0 {
+		t.Errorf("canceled ask = %v, %v", ev, err)
+	}
+
+	// So does canceling the call.
+	callCtx, cancel := context.WithCancel(ctx)
+	if _, err := client.Ask(callCtx, &bridgepb.AskRequest{Provider: "slow", Message: "hi", ReqId: "g3", TimeoutS: 30}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return s.inflight.has("g3") })
+	cancel()
+	waitFor(t, func() bool { return !s.inflight.has("g3") })
+}
+
+func TestJSONRPC(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	dec := json.NewDecoder(client)
+	call := func(msg string, out interface{}) {
+		t.Helper()
+		if _, err := client.Write([]byte(msg + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if out == nil {
+			return
+		}
+		if err := dec.Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A notification is answered by nothing, so the next line is the ping's.
+	call(`{"jsonrpc":"2.0","method":"ping","params":{"token":"tok"}}`, nil)
+	var resp schema.RPCResponse
+	call(`{"jsonrpc":"2.0","id":"p1","method":"ping","params":{"token":"tok"}}`, &resp)
+	if resp.ID != "p1" || resp.Error != nil || resp.Result["status"] != "ok" {
+		t.Errorf("ping = %+v", resp)
+	}
+
+	// The plain format still works on the same connection.
+	var plain map[string]interface{}
+	call(`{"method":"ping","token":"tok"}`, &plain)
+	if plain["status"] != "ok" || plain["jsonrpc"] != nil {
+		t.Errorf("plain ping = %v", plain)
+	}
+
+	// Streamed asks send chunk notifications ahead of the response.
+	var note schema.RPCNotification
+	call(`{"jsonrpc":"2.0","id":7,"method":"ask","params":{"token":"tok","provider":"codex","message":"hi","req_id":"j1","timeout_s":5,"stream":true}}`, &note)
+	if note.Method != "chunk" || note.Params.ReqID != "j1" || len(note.Params.Lines) != 1 {
+		t.Errorf("notification = %+v", note)
+	}
+	resp = schema.RPCResponse{}
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 7.0 || resp.Result["reply"] != "echo: hi" {
+		t.Errorf("ask = %+v", resp)
+	}
+
+	var batch []schema.RPCResponse
+	call(`[
+		{"jsonrpc":"2.0","id":1,"method":"ask","params":{"token":"tok","provider":"codex","message":"a","timeout_s":5}},
+		{"jsonrpc":"2.0","method":"ping","params":{"token":"tok"}},
+		{"jsonrpc":"2.0","id":2,"method":"nope","params":{"token":"tok"}},
+		{"jsonrpc":"2.0","id":3,"method":"ask","params":{"token":"tok","provider":"codex"}},
+		{"jsonrpc":"2.0","id":4,"method":"ping","params":["tok"]},
+		{"id":5,"method":"ping"},
+		42
+	]`, &batch)
+	want := []struct {
+		id   interface{}
+		code int
+	}{
+		{1.0, 0},
+		{2.0, schema.CodeMethodNotFound},
+		{3.0, schema.CodeInvalidParams},
+		{4.0, schema.CodeInvalidParams},
+		{5.0, schema.CodeInvalidRequest},
+		{nil, schema.CodeInvalidRequest},
+	}
+	if len(batch) != len(want) {
+		t.Fatalf("batch answered %d responses, want %d: %+v", len(batch), len(want), batch)
+	}
+	for i, w := range want {
+		got := batch[i]
+		code := 0
+		if got.Error != nil {
+			code = got.Error.Code
+		}
+		if got.ID != w.id || code != w.code {
+			t.Errorf("batch[%d] = id %v code %d, want id %v code %d", i, got.ID, code, w.id, w.code)
+		}
+	}
+	if batch[0].Result["reply"] != "echo: a" {
+		t.Errorf("batch ask = %+v", batch[0].Result)
+	}
+
+	resp = schema.RPCResponse{}
+	call(`{"jsonrpc":"2.0","id":9,"method":"ping","params":{"token":"nope"}}`, &resp)
+	if resp.Error == nil || resp.Error.Code != schema.CodeUnauthorized {
+		t.Errorf("bad token = %+v", resp)
+	}
+}
+
+func TestPendSurvivesRestart(t *testing.T) {
+	replyDir := t.TempDir()
+	newServer := func() *Server {
+		fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+		reg := NewRegistry()
+		reg.Register("codex", fake)
+		s := NewServer(ServerConfig{Token: "tok", ReplyDir: replyDir}, reg)
+		t.Cleanup(s.workerPool.Shutdown)
+		return s
+	}
+	call := func(s *Server, req map[string]interface{}) map[string]interface{} {
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		req["token"] = "tok"
+		json.NewEncoder(client).Encode(req)
+		var resp map[string]interface{}
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	before := newServer()
+	call(before, map[string]interface{}{"method": "request", "provider": "codex", "message": "hi", "req_id": "p1", "timeout_s": 5})
+
+	after := newServer() // empty cache, adapter without a last reply
+	tests := []struct {
+		name string
+		req  map[string]interface{}
+	}{
+		{"by provider", map[string]interface{}{"method": "pend", "provider": "codex"}},
+		{"by req_id", map[string]interface{}{"method": "pend", "req_id": "p1"}},
+		{"any provider", map[string]interface{}{"method": "pend"}},
+	}
+	for _, tt := range tests {
+		if resp := call(after, tt.req); resp["status"] != "ok" || resp["reply"] != "echo: hi" {
+			t.Errorf("%s after restart = %v", tt.name, resp)
+		}
+	}
+	if resp := call(after, map[string]interface{}{"method": "pend"}); resp["provider"] != "codex" || resp["req_id"] != "p1" {
+		t.Errorf("any provider = %v, want codex's p1", resp)
+	}
+}
+
+func TestPendNth(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok", ReplyDir: t.TempDir()}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	for _, msg := range []string{"one", "two", "three"} {
+		enc.Encode(map[string]interface{}{"method": "ask", "token": "tok", "provider": "codex", "message": msg, "req_id": "n-" + msg, "timeout_s": 5})
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		n         int
+		wantReply string
+		wantReq   string
+	}{
+		{2, "echo: two", "n-two"},
+		{3, "echo: one", "n-one"},
+		{4, "", ""},
+	}
+	for _, tt := range tests {
+		enc.Encode(map[string]interface{}{"method": "pend", "token": "tok", "provider": "codex", "n": tt.n})
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["status"] != "ok" || resp["reply"] != tt.wantReply || resp["req_id"] != tt.wantReq {
+			t.Errorf("pend -n %d = %v", tt.n, resp)
+		}
+	}
+
+	enc.Encode(map[string]interface{}{"method": "pend", "token": "tok", "provider": "codex", "n": 0})
+	var resp map[string]interface{}
+	dec.Decode(&resp)
+	if resp["status"] != "error" {
+		t.Errorf("pend -n 0 = %v, want a validation error", resp)
+	}
+}
+
+func TestAskAsyncJobs(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok", ReplyDir: t.TempDir()}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+
+	enc.Encode(map[string]interface{}{"method": "ask_async", "token": "tok", "provider": "codex", "message": "hi", "req_id": "job-1", "timeout_s": 5})
+	var submitted protocol.AskAsyncResponse
+	if err := dec.Decode(&submitted); err != nil {
+		t.Fatal(err)
+	}
+	if submitted.Status != "ok" || submitted.JobID != "job-1" {
+		t.Fatalf("ask_async = %+v", submitted)
+	}
+
+	var res protocol.JobResultResponse
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		enc.Encode(map[string]interface{}{"method": "job_result", "token": "tok", "job_id": "job-1"})
+		res = protocol.JobResultResponse{}
+		if err := dec.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Job.State == JobDone {
+			break
+		}
+		if res.Result != nil {
+			t.Fatalf("result before the job is done: %+v", res)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job not done: %+v", res.Job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.Result == nil || res.Result.Reply != "echo: hi" || res.Job.Provider != "codex" || res.Job.Finished == "" {
+		t.Errorf("job_result = %+v, result %+v", res.Job, res.Result)
+	}
+
+	enc.Encode(map[string]interface{}{"method": "jobs", "token": "tok"})
+	var list protocol.JobsResponse
+	dec.Decode(&list)
+	if len(list.Jobs) != 1 || list.Jobs[0].JobID != "job-1" || list.Jobs[0].State != JobDone {
+		t.Errorf("jobs = %+v", list.Jobs)
+	}
+
+	enc.Encode(map[string]interface{}{"method": "ask_async", "token": "tok", "provider": "codex", "message": "again", "req_id": "job-1"})
+	var dup map[string]interface{}
+	dec.Decode(&dup)
+	if msg, _ := dup["error"].(string); !strings.Contains(msg, "duplicate req_id job-1") {
+		t.Errorf("ask_async reusing a job id = %v", dup)
+	}
+
+	// A job the table has forgotten is rebuilt from the reply store.
+	s.jobs = newJobTable(defaultJobTableSize)
+	enc.Encode(map[string]interface{}{"method": "job_status", "token": "tok", "job_id": "job-1"})
+	var status protocol.JobStatusResponse
+	dec.Decode(&status)
+	if status.Job.State != JobDone || status.Job.Provider != "codex" {
+		t.Errorf("job_status after restart = %+v", status)
+	}
+
+	enc.Encode(map[string]interface{}{"method": "job_status", "token": "tok", "job_id": "nope"})
+	var unknown map[string]interface{}
+	dec.Decode(&unknown)
+	if unknown["error"] != "unknown job: nope" {
+		t.Errorf("job_status for an unknown job = %v", unknown)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	reg := NewRegistry()
+	for _, name := range []string{"codex", "gemini"} {
+		reg.Register(name, &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: name}, online: true})
+	}
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+
+	enc.Encode(map[string]interface{}{
+		"method": "broadcast", "token": "tok", "message": "hi", "req_id": "b1", "timeout_s": 5,
+		"providers": []string{"codex", "Gemini", "codex", "nope"},
+	})
+	var resp protocol.BroadcastResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "ok" || !reflect.DeepEqual(resp.Providers, []string{"codex", "gemini", "nope"}) {
+		t.Fatalf("broadcast = %+v", resp)
+	}
+	for _, p := range []string{"codex", "gemini"} {
+		r := resp.Results[p]
+		if r.Reply != "echo: hi" || r.ReqID != "b1-"+p || r.ExitCode != 0 {
+			t.Errorf("result for %s = %+v", p, r)
+		}
+	}
+	if r := resp.Results["nope"]; r.ExitCode == 0 || r.Error != "unknown provider: nope" || r.ReqID != "b1-nope" {
+		t.Errorf("result for an unknown provider = %+v", r)
+	}
+
+	enc.Encode(map[string]interface{}{"method": "broadcast", "token": "tok", "message": "hi", "providers": []string{}})
+	var empty map[string]interface{}
+	dec.Decode(&empty)
+	if empty["error"] != "missing providers" {
+		t.Errorf("broadcast without providers = %v", empty)
+	}
+}
+
+// workDirAdapter replies with the work dir its ask ran in.
+type workDirAdapter struct {
+	fakeAdapter
+}
+
+func (w *workDirAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	return &adapter.ProviderResult{ReqID: req.ReqID, Reply: req.WorkDir}, nil
+}
+
+func TestBroadcastWorkDirs(t *testing.T) {
+	reg := NewRegistry()
+	for _, name := range []string{"codex", "gemini"} {
+		reg.Register(name, &workDirAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: name}, online: true}})
+	}
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{
+		"method": "broadcast", "token": "tok", "message": "hi", "timeout_s": 5,
+		"providers": []string{"codex", "gemini"},
+		"work_dir":  "/proj/a", "work_dirs": map[string]string{"gemini": "/proj/b"},
+	})
+	var resp protocol.BroadcastResponse
+	if err := json.NewDecoder(client).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Results["codex"].Reply; got != "/proj/a" {
+		t.Errorf("codex work dir = %q, want the shared /proj/a", got)
+	}
+	if got := resp.Results["gemini"].Reply; got != "/proj/b" {
+		t.Errorf("gemini work dir = %q, want its own /proj/b", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	s.execute(context.Background(), "codex", fake, &adapter.ProviderRequest{ReqID: "m1", Message: "hi", TimeoutS: 5})
+	slow := &blockingAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	go func() {
+		for !s.inflight.has("m2") {
+			time.Sleep(time.Millisecond)
+		}
+		s.Cancel("m2")
+	}()
+	s.execute(context.Background(), "codex", slow, &adapter.ProviderRequest{ReqID: "m2", WorkDir: "/other", TimeoutS: 5})
+
+	h := s.httpHandler()
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 401 {
+		t.Errorf("/metrics without a token = %d", w.Code)
+	}
+
+	r.Header.Set("Authorization", "Bearer tok")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	body := w.Body.String()
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("/metrics = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`ccb_requests_total{provider="codex",outcome="ok"} 1`,
+		`ccb_requests_total{provider="codex",outcome="canceled"} 1`,
+		`ccb_request_duration_seconds_count{provider="codex"} 2`,
+		"ccb_workers_max 50",
+		"ccb_requests_in_flight 0",
+		"# TYPE ccb_uptime_seconds gauge",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics lacks %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	t.Setenv("CCB_NOTIFY", "0")
+	for _, full := range []bool{false, true} {
+		fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+		reg := NewRegistry()
+		reg.Register("codex", fake)
+		auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+		s := NewServer(ServerConfig{Token: "tok", AuditFile: auditFile, AuditFull: full}, reg)
+
+		client, server := net.Pipe()
+		go s.handleConn(server)
+		enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+		for _, req := range []map[string]interface{}{
+			{"method": "request", "provider": "codex", "message": "secret plan", "req_id": "a1", "caller": "claude", "client_id": "cli-1", "timeout_s": 5},
+			{"method": "pause", "provider": "codex"},
+			{"method": "request", "provider": "codex", "message": "later", "req_id": "a2", "timeout_s": 5},
+			{"method": "ping"},
+		} {
+			req["token"] = "tok"
+			enc.Encode(req)
+			var resp map[string]interface{}
+			if err := dec.Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		client.Close()
+		s.workerPool.Shutdown()
+
+		data, err := os.ReadFile(auditFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []audit.Entry
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e audit.Entry
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("line %q: %v", line, err)
+			}
+			entries = append(entries, e)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("full=%v: %d entries, want one per ask: %s", full, len(entries), data)
+		}
+		e := entries[0]
+		if e.ReqID != "a1" || e.Provider != "codex" || e.Caller != "claude" || e.ClientID != "cli-1" || e.ExitCode != 0 {
+			t.Errorf("full=%v: entry = %+v", full, e)
+		}
+		if e.PromptSHA256 != audit.HashPrompt("secret plan") {
+			t.Errorf("full=%v: prompt hash = %q", full, e.PromptSHA256)
+		}
+		if got := strings.Contains(string(data), "secret plan"); got != full {
+			t.Errorf("full=%v: prompt in audit log = %v", full, got)
+		}
+		if e := entries[1]; e.ReqID != "a2" || e.ExitCode != output.ExitPaused {
+			t.Errorf("full=%v: paused entry = %+v", full, e)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(RateLimits{Client: 2, Provider: map[string]int{"codex": 3}})
+	t0 := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		client   string
+		provider string
+		at       time.Duration
+		ok       bool
+		wait     time.Duration
+	}{
+		{"first", "a", "codex", 0, true, 0},
+		{"second", "a", "codex", 10 * time.Second, true, 0},
+		{"client full", "a", "codex", 20 * time.Second, false, 40 * time.Second},
+		{"other client", "b", "codex", 30 * time.Second, true, 0},
+		{"provider full", "c", "codex", 40 * time.Second, false, 20 * time.Second},
+		{"unlimited provider", "c", "gemini", 40 * time.Second, true, 0},
+		{"window slid", "a", "codex", 61 * time.Second, true, 0},
+	}
+	for _, tt := range tests {
+		_, wait, ok := l.allow(tt.client, tt.provider, t0.Add(tt.at))
+		if ok != tt.ok || wait != tt.wait {
+			t.Errorf("%s: allow = %v, %v; want %v, %v", tt.name, ok, wait, tt.ok, tt.wait)
+		}
+	}
+}
+
+func TestRateLimitedAsk(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok", RateLimits: RateLimits{Client: 1}}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	var results []protocol.AskResponse
+	for _, id := range []string{"r1", "r2"} {
+		enc.Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "req_id": id, "client_id": "loop", "timeout_s": 5})
+		var r protocol.AskResponse
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	if results[0].ExitCode != 0 {
+		t.Errorf("first ask = %+v", results[0])
+	}
+	r := results[1]
+	if r.ExitCode != output.ExitRateLimit || !strings.HasPrefix(r.Error, "RATE_LIMITED: client loop") || r.RetryAfterMs <= 0 || r.ReqID != "r2" {
+		t.Errorf("second ask = %+v, want rate limited", r)
+	}
+
+	req := httptest.NewRequest("POST", "/ask", strings.NewReader(`{"provider":"codex","message":"hi","client_id":"loop","timeout_s":5}`))
+	req.Header.Set("Authorization", "Bearer tok")
+	w := httptest.NewRecorder()
+	s.httpHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("HTTP ask = %d (Retry-After %q) %s, want 429", w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+}
+
+// gatedAdapter holds every ask until release is closed.
+type gatedAdapter struct {
+	fakeAdapter
+	release chan struct{}
+}
+
+func (g *gatedAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	req.OnPhase(adapter.PhaseWaiting)
+	select {
+	case <-g.release:
+		return &adapter.ProviderResult{ReqID: req.ReqID, Reply: "echo: " + req.Message}, nil
+	case <-ctx.Done():
+		return &adapter.ProviderResult{ExitCode: 2, Error: "timeout", ReqID: req.ReqID}, nil
+	}
+}
+
+func TestStatusActiveRequests(t *testing.T) {
+	gated := &gatedAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}, make(chan struct{})}
+	reg := NewRegistry()
+	reg.Register("codex", gated)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	status := func() protocol.StatusResponse {
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{"method": "status", "token": "tok"})
+		var resp protocol.StatusResponse
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	enc.Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "timeout_s": 30})
+	deadline := time.Now().Add(2 * time.Second)
+	for s.inflight.len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("ask never went in flight")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := status().ActiveRequests; got != 1 {
+		t.Errorf("active_requests during an ask = %d, want 1", got)
+	}
+
+	gated.release <- struct{}{}
+	var r adapter.ProviderResult
+	if err := dec.Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	// The session worker stays in the pool after its ask.
+	if got := status(); got.ActiveRequests != 0 || got.Workers == 0 {
+		t.Errorf("after the ask: active_requests = %d, workers = %d; want 0 and the idle worker", got.ActiveRequests, got.Workers)
+	}
+}
+
+// countingAdapter counts pane checks.
+type countingAdapter struct {
+	fakeAdapter
+	checks int32
+}
+
+func (c *countingAdapter) EnsurePane(ctx context.Context, workDir string) (string, error) {
+	atomic.AddInt32(&c.checks, 1)
+	return c.fakeAdapter.EnsurePane(ctx, workDir)
+}
+
+func TestStatusLivenessCached(t *testing.T) {
+	counting := &countingAdapter{fakeAdapter: fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	reg := NewRegistry()
+	reg.Register("codex", counting)
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	online := func() bool {
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{"method": "status", "token": "tok", "work_dir": "/w"})
+		var resp protocol.StatusResponse
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Online["codex"]
+	}
+	checks := func() int32 { return atomic.LoadInt32(&counting.checks) }
+
+	if !online() || checks() != 1 {
+		t.Fatalf("first status: online = false or %d pane checks, want true and 1", checks())
+	}
+	// Statuslines poll status; repeated calls are served from cache.
+	counting.setOnline(false)
+	if !online() || checks() != 1 {
+		t.Errorf("second status: online = false or %d pane checks, want the cached true and 1", checks())
+	}
+	// An ask that finds the pane gone updates the cache.
+	s.askFinished("codex", &adapter.ProviderRequest{WorkDir: "/w"}, &adapter.ProviderResult{ExitCode: output.ExitPaneDead}, 0)
+	if online() || checks() != 1 {
+		t.Errorf("after a pane-dead ask: online = true or %d pane checks, want false and 1", checks())
+	}
+	// Registry changes drop the cache.
+	counting.setOnline(true)
+	s.providersChanged([]string{"gemini"}, nil)
+	if !online() || checks() != 2 {
+		t.Errorf("after a registry change: online = false or %d pane checks, want true and 2", checks())
+	}
+	if _, ok := s.liveness.get("/w", time.Now().Add(livenessTTL)); ok {
+		t.Error("liveness served past its TTL")
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	tests := []struct {
+		name    string
+		release bool // let the ask in flight finish during the drain
+	}{
+		{"ask finishes", true},
+		{"drain timeout", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gated := &gatedAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}, make(chan struct{})}
+			t.Cleanup(func() { close(gated.release) })
+			reg := NewRegistry()
+			reg.Register("codex", gated)
+			drain := 300 * time.Millisecond
+			s := NewServer(ServerConfig{Token: "tok", StateFile: filepath.Join(t.TempDir(), "askd.json"), Drain: drain}, reg)
+			if err := s.Start("127.0.0.1", 0); err != nil {
+				t.Fatal(err)
+			}
+			addr := s.Addr().String()
+
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			json.NewEncoder(conn).Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "req_id": "r1", "timeout_s": 30})
+			deadline := time.Now().Add(2 * time.Second)
+			for s.inflight.len() == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("ask never went in flight")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			stopping := time.Now()
+			s.Shutdown()
+			if c, err := net.Dial("tcp", addr); err == nil {
+				c.Close()
+				t.Error("daemon still accepts connections after Shutdown")
+			}
+			client, server := net.Pipe()
+			go s.handleConn(server)
+			json.NewEncoder(client).Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "late", "req_id": "r2"})
+			var late protocol.AskResponse
+			if err := json.NewDecoder(client).Decode(&late); err != nil {
+				t.Fatal(err)
+			}
+			client.Close()
+			if late.ExitCode != output.ExitDaemonDown {
+				t.Errorf("ask during drain = %+v, want refused", late)
+			}
+
+			if tt.release {
+				gated.release <- struct{}{}
+				var r protocol.AskResponse
+				if err := json.NewDecoder(conn).Decode(&r); err != nil {
+					t.Fatalf("ask in flight lost its result: %v", err)
+				}
+				if r.ExitCode != 0 || r.Reply != "echo: hi" {
+					t.Errorf("drained ask = %+v", r)
+				}
+			}
+			waited := make(chan struct{})
+			go func() {
+				s.Wait()
+				close(waited)
+			}()
+			select {
+			case <-waited:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Wait did not return after the drain")
+			}
+			if !tt.release && time.Since(stopping) < drain {
+				t.Errorf("Wait returned after %s, before the %s drain timeout", time.Since(stopping), drain)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	settings := Settings{Providers: []string{"codex", "gemini"}, Concurrency: map[string]int{"gemini": 2}, RateLimits: RateLimits{Client: 3}}
+	reg := NewRegistry()
+	codex := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg.Register("codex", codex)
+	s := NewServer(ServerConfig{
+		Token:       "tok",
+		Concurrency: map[string]int{"codex": 1},
+		Reload:      func() Settings { return settings },
+		NewAdapter: func(provider, workDir string) (adapter.Adapter, bool) {
+			return &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: provider}, online: true}, provider != "bogus"
+		},
+	}, reg)
+	defer s.workerPool.Shutdown()
+
+	reload := func() protocol.ReloadResponse {
+		t.Helper()
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{"method": "reload", "token": "tok"})
+		var resp protocol.ReloadResponse
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := reload()
+	if !reflect.DeepEqual(resp.Added, []string{"gemini"}) || resp.Removed != nil || !reflect.DeepEqual(resp.Providers, []string{"codex", "gemini"}) {
+		t.Errorf("first reload = %+v", resp)
+	}
+	if a, _ := reg.Get("codex"); a != codex {
+		t.Error("reload replaced the adapter of a provider that stayed")
+	}
+	if got := s.workerPool.Limit("gemini"); got != 2 {
+		t.Errorf("gemini limit = %d, want 2", got)
+	}
+	if got := s.workerPool.Limit("codex"); got != 0 {
+		t.Errorf("codex limit = %d, want it lifted", got)
+	}
+	if _, _, ok := s.rateLimit.allow("c", "codex", time.Now()); !ok || s.rateLimit.limits.Client != 3 {
+		t.Errorf("rate limits = %+v, want client limit 3", s.rateLimit.limits)
+	}
+
+	settings.Providers = []string{"gemini", "bogus"}
+	resp = reload()
+	if resp.Added != nil || !reflect.DeepEqual(resp.Removed, []string{"codex"}) || !reflect.DeepEqual(resp.Providers, []string{"gemini"}) {
+		t.Errorf("second reload = %+v", resp)
+	}
+	if _, ok := reg.Get("codex"); ok {
+		t.Error("codex still registered after it was dropped")
+	}
+
+	s.reloadFn = nil
+	if _, err := s.reload(); err == nil {
+		t.Error("reload without a Reload func succeeded")
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("codex", &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true})
+	s := NewServer(ServerConfig{
+		Token: "tok",
+		Reload: func() Settings {
+			return Settings{Concurrency: map[string]int{"droid": 2}, RateLimits: RateLimits{Provider: map[string]int{"droid": 5}}}
+		},
+		NewAdapter: func(provider, workDir string) (adapter.Adapter, bool) {
+			return &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: provider}, online: true}, provider != "bogus"
+		},
+	}, reg)
+	defer s.workerPool.Shutdown()
+
+	call := func(method, provider string) map[string]interface{} {
+		t.Helper()
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{"method": method, "token": "tok", "provider": provider})
+		var resp map[string]interface{}
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		method, provider string
+		want             string // "changed", "unchanged" or the error
+		providers        []string
+	}{
+		{"register_provider", "droid", "changed", []string{"codex", "droid"}},
+		{"register_provider", "droid", "unchanged", []string{"codex", "droid"}},
+		{"register_provider", "bogus", "unknown provider: bogus", nil},
+		{"unregister_provider", "codex", "changed", []string{"droid"}},
+		{"unregister_provider", "codex", "unchanged", []string{"droid"}},
+	}
+	for _, tt := range tests {
+		resp := call(tt.method, tt.provider)
+		got := "unchanged"
+		if resp["status"] == "error" {
+			got, _ = resp["error"].(string)
+		} else if resp["changed"] == true {
+			got = "changed"
+		}
+		if got != tt.want {
+			t.Errorf("%s %s = %v, want %s", tt.method, tt.provider, resp, tt.want)
+		}
+		if tt.providers != nil && !reflect.DeepEqual(reg.Names(), tt.providers) {
+			t.Errorf("after %s %s: providers = %v, want %v", tt.method, tt.provider, reg.Names(), tt.providers)
+		}
+	}
+	if got := s.workerPool.Limit("droid"); got != 2 {
+		t.Errorf("droid limit = %d, want 2 from the settings", got)
+	}
+	if got := s.rateLimit.limits.Provider["droid"]; got != 5 {
+		t.Errorf("droid rate limit = %d, want 5", got)
+	}
+}
+
+func TestCustomProviderByWorkDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	started, other := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(other, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(other, ".ccb_config", config.ConfigFilename),
+		[]byte(`{"custom_providers": {"crush": {"command": "crush", "session_dir": "`+filepath.ToSlash(filepath.Join(home, "crush"))+`"}}}`), 0644)
+
+	reg := NewRegistry()
+	s := NewServer(ServerConfig{
+		Token: "tok",
+		NewAdapter: func(provider, workDir string) (adapter.Adapter, bool) {
+			if workDir == "" {
+				workDir = started
+			}
+			return newAdapter(provider, nil, workDir)
+		},
+		StorageRoot: storageRoot,
+	}, reg)
+	defer s.workerPool.Shutdown()
+
+	ask := func(workDir string) map[string]interface{} {
+		t.Helper()
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{
+			"method": "request", "token": "tok", "provider": "crush", "work_dir": workDir, "message": "hi", "timeout_s": 5,
+		})
+		var resp map[string]interface{}
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// The daemon's own project does not declare crush.
+	if resp := ask(started); resp["error"] != "unknown provider: crush" {
+		t.Fatalf("ask from a project without crush = %v, want unknown provider", resp)
+	}
+	// The asking project does: crush is registered and looked up there.
+	resp := ask(other)
+	if resp["exit_code"] != float64(output.ExitNoSession) || resp["error"] != "crush session not found" {
+		t.Errorf("ask from the declaring project = %v, want no session", resp)
+	}
+	if _, ok := reg.Get("crush"); !ok {
+		t.Fatal("crush was not registered")
+	}
+	if checks := s.storageChecks(); len(checks) != 1 || checks[0].Provider != "crush" || checks[0].OK {
+		t.Errorf("storage checks = %+v, want crush's missing session dir", checks)
+	}
+	// Registered, it still answers only for projects that declare it.
+	if resp := ask(started); !strings.Contains(fmt.Sprint(resp["error"]), "crush is not declared in ccb.config") {
+		t.Errorf("ask from a project without crush after registration = %v", resp)
+	}
+}
+
+func TestRotateToken(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "askd.json")
+	s := NewServer(ServerConfig{Token: "t1", StateFile: stateFile}, NewRegistry())
+	defer s.workerPool.Shutdown()
+	s.writeState("127.0.0.1", 1234, "", "", "")
+
+	call := func(req map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		client, server := net.Pipe()
+		defer client.Close()
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(req)
+		var resp map[string]interface{}
+		if err := json.NewDecoder(client).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	stateToken := func() string {
+		t.Helper()
+		var st DaemonState
+		data, err := os.ReadFile(stateFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &st); err != nil {
+			t.Fatal(err)
+		}
+		if st.Port != 1234 {
+			t.Errorf("state lost its other fields: %+v", st)
+		}
+		return st.Token
+	}
+	pingOK := func(token string) bool {
+		return call(map[string]interface{}{"method": "ping", "token": token})["status"] == "ok"
+	}
+
+	resp := call(map[string]interface{}{"method": "rotate_token", "token": "t1", "grace_s": 60})
+	if resp["status"] != "ok" || resp["previous_valid_until"] == nil {
+		t.Fatalf("rotate = %v", resp)
+	}
+	t2 := stateToken()
+	if t2 == "t1" || t2 == "" || s.Token() != t2 {
+		t.Fatalf("state token = %q, server token = %q; want a new one", t2, s.Token())
+	}
+	if !pingOK("t1") || !pingOK(t2) {
+		t.Error("old or new token rejected during the grace period")
+	}
+
+	call(map[string]interface{}{"method": "rotate_token", "token": t2, "grace_s": 0})
+	t3 := stateToken()
+	if pingOK(t2) {
+		t.Error("token rotated out with no grace still accepted")
+	}
+	if !pingOK(t3) || !pingOK("t1") {
+		t.Error("new token or t1 (still in its grace period) rejected")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(stateFile), ".askd-state-*")); len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+}
+
+func TestPipelinedRequests(t *testing.T) {
+	gated := &gatedAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}, make(chan struct{})}
+	reg := NewRegistry()
+	reg.Register("codex", gated)
+	reg.Register("gemini", &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "gemini"}, online: true})
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	enc, dec := json.NewEncoder(client), json.NewDecoder(client)
+	read := func() map[string]interface{} {
+		t.Helper()
+		var resp map[string]interface{}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	ask := func(id interface{}, provider string) map[string]interface{} {
+		return map[string]interface{}{
+			"method": "request", "token": "tok", "provider": provider, "work_dir": "/w",
+			"message": "hi " + provider, "timeout_s": 5, "id": id,
+		}
+	}
+
+	// The held codex ask doesn't hold up the requests behind it.
+	enc.Encode(ask("a", "codex"))
+	enc.Encode(map[string]interface{}{"method": "ping", "token": "tok", "id": 2})
+	enc.Encode(ask("b", "gemini"))
+	got := map[interface{}]map[string]interface{}{}
+	for i := 0; i < 2; i++ {
+		resp := read()
+		got[resp["id"]] = resp
+	}
+	if got[2.0]["status"] != "ok" {
+		t.Errorf("ping response = %v", got[2.0])
+	}
+	if got["b"]["reply"] != "echo: hi gemini" {
+		t.Errorf("gemini response = %v", got["b"])
+	}
+
+	close(gated.release)
+	if resp := read(); resp["id"] != "a" || resp["reply"] != "echo: hi codex" {
+		t.Errorf("codex response = %v", resp)
+	}
+
+	// Requests without an id are answered in turn, without one.
+	enc.Encode(map[string]interface{}{"method": "ping", "token": "tok"})
+	if resp := read(); resp["status"] != "ok" || resp["id"] != nil {
+		t.Errorf("plain ping response = %v", resp)
+	}
+	enc.Encode(map[string]interface{}{"method": "ping", "token": "tok", "id": true})
+	if resp := read(); resp["status"] != "error" {
+		t.Errorf("boolean id accepted: %v", resp)
+	}
+}
+
+func TestMalformedRequestsRejected(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("codex", &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true})
+	s := NewServer(ServerConfig{Token: "tok"}, reg)
+	defer s.workerPool.Shutdown()
+
+	tests := []struct {
+		name    string
+		req     map[string]interface{}
+		wantErr string // "" for a successful response
+	}{
+		{"ping", map[string]interface{}{"method": "ping", "provider": "codex"}, ""},
+		{"ping provider not a string", map[string]interface{}{"method": "ping", "provider": 7}, "provider: must be a string"},
+		{"status work_dir not a string", map[string]interface{}{"method": "status", "work_dir": true}, "work_dir: must be a string"},
+		{"pend n fractional", map[string]interface{}{"method": "pend", "provider": "codex", "n": 1.5}, "n: must be an integer"},
+		{"pend n overflows", map[string]interface{}{"method": "pend", "provider": "codex", "n": 1e300}, "n: must be int"},
+		{"ask timeout not a number", map[string]interface{}{"method": "request", "provider": "codex", "message": "hi", "timeout_s": "30"}, "timeout_s: must be a number"},
+		{"shutdown with bad id", map[string]interface{}{"method": "shutdown", "id": true}, "id must be a string or number"},
+		{"broadcast provider not a string", map[string]interface{}{"method": "broadcast", "providers": []interface{}{"codex", 3}, "message": "hi"}, "providers[1]: must be a string"},
+		{"job_status job_id not a string", map[string]interface{}{"method": "job_status", "job_id": 12}, "job_id: must be a string"},
+		{"cancel req_id not a string", map[string]interface{}{"method": "cancel", "req_id": 12}, "req_id: must be a string"},
+		{"pause reason not a string", map[string]interface{}{"method": "pause", "provider": "codex", "reason": false}, "reason: must be a string"},
+		{"resume unknown", map[string]interface{}{"method": "resume", "provider": "codex"}, "codex is not paused"},
+		{"rotate_token grace not a number", map[string]interface{}{"method": "rotate_token", "grace_s": "10"}, "grace_s: must be a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			go s.handleConn(server)
+			tt.req["token"] = "tok"
+			json.NewEncoder(client).Encode(tt.req)
+			var resp map[string]interface{}
+			if err := json.NewDecoder(client).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			msg, _ := resp["error"].(string)
+			if tt.wantErr == "" {
+				if resp["status"] != "ok" {
+					t.Errorf("response = %v, want ok", resp)
+				}
+				return
+			}
+			if resp["status"] != "error" || !strings.Contains(msg, tt.wantErr) {
+				t.Errorf("response = %v, want error containing %q", resp, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	fake := &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}
+	reg := NewRegistry()
+	reg.Register("codex", fake)
+	s := NewServer(ServerConfig{Token: "tok", StateFile: filepath.Join(t.TempDir(), "askd.json")}, reg)
+
+	subscribe := func(events ...string) *json.Decoder {
+		client, server := net.Pipe()
+		t.Cleanup(func() { client.Close() })
+		go s.handleConn(server)
+		json.NewEncoder(client).Encode(map[string]interface{}{"method": "subscribe", "token": "tok", "events": events})
+		return json.NewDecoder(client)
+	}
+	next := func(dec *json.Decoder) map[string]interface{} {
+		t.Helper()
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	if resp := next(subscribe("pane_born")); resp["status"] != "error" {
+		t.Errorf("unknown event kind accepted: %v", resp)
+	}
+
+	dec := subscribe("shutting_down", "request_finished", "pane_died")
+	if ack := next(dec); ack["status"] != "ok" || fmt.Sprint(ack["events"]) != "[pane_died request_finished shutting_down]" {
+		t.Fatalf("ack = %v", ack)
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "work_dir": "/w", "message": "hi", "req_id": "r1", "timeout_s": 5})
+	var result adapter.ProviderResult
+	if err := json.NewDecoder(client).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	// request_started was not asked for.
+	if ev := next(dec); ev["event"] != EventRequestFinished || ev["req_id"] != "r1" || ev["exit_code"] != 0.0 || ev["provider"] != "codex" {
+		t.Errorf("event = %v, want request_finished for r1", ev)
+	}
+
+	fake.setOnline(false)
+	s.checkPanes()
+	if ev := next(dec); ev["event"] != EventPaneDied || ev["provider"] != "codex" || ev["work_dir"] != "/w" {
+		t.Errorf("event = %v, want pane_died for codex in /w", ev)
+	}
+	s.checkPanes() // reported once only
+
+	s.Shutdown()
+	if ev := next(dec); ev["event"] != EventShuttingDown {
+		t.Errorf("event = %v, want shutting_down", ev)
+	}
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err == nil {
+		t.Errorf("subscription still open after shutdown: %v", m)
+	}
+	<-s.drained
+}
+
+func TestIdleExpired(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name      string
+		providers []string
+		idle      IdleTimeouts
+		used      map[string]time.Duration // provider -> last use, after start
+		after     time.Duration
+		want      bool
+	}{
+		{"no providers, daemon timeout", nil, nil, nil, 31 * time.Minute, true},
+		{"no providers, before timeout", nil, nil, nil, 29 * time.Minute, false},
+		{"every provider idle", []string{"codex", "gemini"}, IdleTimeouts{"codex": time.Hour, "gemini": 10 * time.Minute},
+			nil, 61 * time.Minute, true},
+		{"longest timeout keeps the daemon up", []string{"codex", "gemini"}, IdleTimeouts{"codex": time.Hour, "gemini": 10 * time.Minute},
+			nil, 30 * time.Minute, false},
+		{"recent use of a short one", []string{"codex", "gemini"}, IdleTimeouts{"codex": 5 * time.Minute, "gemini": 10 * time.Minute},
+			map[string]time.Duration{"gemini": 25 * time.Minute}, 30 * time.Minute, false},
+		{"use of another provider does not count", []string{"codex", "gemini"}, IdleTimeouts{"codex": 5 * time.Minute, "gemini": 10 * time.Minute},
+			map[string]time.Duration{"claude": 29 * time.Minute}, 30 * time.Minute, true},
+		{"daemon timeout for the rest", []string{"codex", "gemini"}, IdleTimeouts{"codex": 5 * time.Minute},
+			nil, 20 * time.Minute, false},
+		{"keepalive", []string{"codex", "gemini"}, IdleTimeouts{"codex": 5 * time.Minute, "gemini": -1},
+			nil, 48 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := NewRegistry()
+			for _, p := range tt.providers {
+				reg.Register(p, &fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: p}, online: true})
+			}
+			s := NewServer(ServerConfig{Token: "tok", Idle: tt.idle}, reg)
+			defer s.workerPool.Shutdown()
+			s.lastActive = start
+			for p, d := range tt.used {
+				s.lastUsed[p] = start.Add(d)
+			}
+			if got := s.idleExpired(start.Add(tt.after)); got != tt.want {
+				t.Errorf("idleExpired after %v = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdleExpiredWhileBusy(t *testing.T) {
+	gated := &gatedAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}, make(chan struct{})}
+	reg := NewRegistry()
+	reg.Register("codex", gated)
+	s := NewServer(ServerConfig{Token: "tok", Idle: IdleTimeouts{"codex": 10 * time.Minute}}, reg)
+	defer s.workerPool.Shutdown()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go s.handleConn(server)
+	json.NewEncoder(client).Encode(map[string]interface{}{"method": "request", "token": "tok", "provider": "codex", "message": "hi", "timeout_s": 7200})
+	deadline := time.Now().Add(2 * time.Second)
+	for s.inflight.len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("ask never went in flight")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// An hour into the ask the daemon is still working, not idle.
+	hour := time.Now().Add(time.Hour)
+	if s.idleExpired(hour) {
+		t.Error("idle expired with an ask in flight")
+	}
+	gated.release <- struct{}{}
+	var r adapter.ProviderResult
+	if err := json.NewDecoder(client).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	for s.busy() {
+		if time.Now().After(deadline) {
+			t.Fatal("daemon still busy after the ask")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Idle time counts from when the ask was last seen running.
+	if s.idleExpired(hour.Add(5 * time.Minute)) {
+		t.Error("idle expired 5m after a long ask")
+	}
+	if !s.idleExpired(hour.Add(11 * time.Minute)) {
+		t.Error("idle not expired 11m after a long ask")
+	}
+
+	// Queued asks and unfinished jobs count as work too.
+	s.jobs.add(&job{ID: "j1", Provider: "codex", Submitted: time.Now()})
+	if s.idleExpired(hour.Add(time.Hour)) {
+		t.Error("idle expired with a job running")
+	}
+	s.jobs.finish("j1", &adapter.ProviderResult{ReqID: "j1"})
+	s.queue.Add(queuedAsk{Provider: "codex", Request: &adapter.ProviderRequest{ReqID: "q1"}})
+	if s.idleExpired(hour.Add(2 * time.Hour)) {
+		t.Error("idle expired with an ask queued")
+	}
+	s.queue.Drop("q1")
+	if !s.idleExpired(hour.Add(3 * time.Hour)) {
+		t.Error("idle not expired once the job finished and the queue emptied")
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		pid  int
+		want bool
+	}{
+		{"self", os.Getpid(), true},
+		{"exited child", exited.Process.Pid, false},
+	}
+	for _, tt := range tests {
+		if got := processAlive(tt.pid); got != tt.want {
+			t.Errorf("%s: processAlive(%d) = %v, want %v", tt.name, tt.pid, got, tt.want)
+		}
+	}
+}
+
+// routeAdapter replies with the pane its asks were routed to.
+type routeAdapter struct {
+	fakeAdapter
+}
+
+func (r *routeAdapter) Send(ctx context.Context, req *adapter.ProviderRequest) (*adapter.ProviderResult, error) {
+	if req.Route == nil {
+		return &adapter.ProviderResult{ReqID: req.ReqID, Reply: "unrouted"}, nil
+	}
+	return &adapter.ProviderResult{ReqID: req.ReqID, Reply: req.Route.PaneID + " " + req.Route.LogPath}, nil
+}
+
+func TestRouteTable(t *testing.T) {
+	repoA, repoB, loose := t.TempDir(), t.TempDir(), t.TempDir()
+	regPath := filepath.Join(t.TempDir(), "pane-registry.json")
+	panes := session.NewPaneRegistry(regPath)
+	panes.Upsert("codex", config.ComputeCCBProjectID(repoA), &session.PaneEntry{PaneID: "%1", WorkDir: repoA, SessionPath: "/logs/a"})
+	panes.Upsert("codex", config.ComputeCCBProjectID(repoB), &session.PaneEntry{PaneID: "%2", WorkDir: repoB})
+
+	reg := NewRegistry()
+	a := &routeAdapter{fakeAdapter{BaseAdapter: adapter.BaseAdapter{ProviderName: "codex"}, online: true}}
+	reg.Register("codex", a)
+	s := NewServer(ServerConfig{Token: "tok", RoutesFile: regPath}, reg)
+	defer s.workerPool.Shutdown()
+
+	ask := func(workDir string) string {
+		t.Helper()
+		return s.execute(context.Background(), "codex", a, &adapter.ProviderRequest{WorkDir: workDir, Message: "hi", TimeoutS: 5}).Reply
+	}
+	tests := []struct {
+		name, workDir, want string
+	}{
+		{"project A", repoA, "%1 /logs/a"},
+		{"project B, registry has no log", repoB, "%2 "},
+		{"unregistered project", loose, "unrouted"},
+	}
+	for _, tt := range tests {
+		if got := ask(tt.workDir); got != tt.want {
+			t.Errorf("%s: routed to %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := s.routes.projects(); got != 2 {
+		t.Errorf("projects = %d, want 2", got)
+	}
+
+	// The launcher moving project B to a new pane is picked up.
+	panes.Upsert("codex", config.ComputeCCBProjectID(repoB), &session.PaneEntry{PaneID: "%22", WorkDir: repoB})
+	if got := ask(repoB); got != "%22 " {
+		t.Errorf("after the registry changed: routed to %q, want %q", got, "%22 ")
+	}
+	os.Remove(regPath)
+	if got := ask(repoA); got != "unrouted" {
+		t.Errorf("without a registry: routed to %q, want unrouted", got)
+	}
+}
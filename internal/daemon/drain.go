@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// askGate counts the asks being served so a stopping daemon can let them
+// finish. Once closed it admits no new ones.
+type askGate struct {
+	mu     sync.Mutex
+	n      int
+	closed bool
+	idle   chan struct{} // closed once the gate is closed and n is 0
+}
+
+func newAskGate() *askGate {
+	return &askGate{idle: make(chan struct{})}
+}
+
+// enter admits an ask, reporting false once the gate is closed.
+func (g *askGate) enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.n++
+	return true
+}
+
+// leave marks an admitted ask as done.
+func (g *askGate) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n--
+	if g.closed && g.n == 0 {
+		close(g.idle)
+	}
+}
+
+// active returns how many admitted asks are still being served.
+func (g *askGate) active() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.n
+}
+
+// close stops admitting asks. It returns how many are still being served
+// and a channel closed once they are done.
+func (g *askGate) close() (int, <-chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.closed {
+		g.closed = true
+		if g.n == 0 {
+			close(g.idle)
+		}
+	}
+	return g.n, g.idle
+}
+
+// drain waits up to s.drainLimit for the n asks in flight when the gate
+// closed to finish and send their results (idle is closed once they have),
+// then closes what is left open and stops the workers.
+func (s *Server) drain(n int, idle <-chan struct{}) {
+	defer close(s.drained)
+	s.mu.Lock()
+	limit := s.drainLimit // a reload may change it
+	s.mu.Unlock()
+	if n > 0 {
+		s.log("waiting up to %s for %d ask(s) in flight", limit, n)
+		timer := time.NewTimer(limit)
+		select {
+		case <-idle:
+		case <-timer.C:
+			left, _ := s.asks.close()
+			s.logger.Warn("drain timeout: abandoning asks in flight", "asks", left, "timeout", limit)
+		}
+		timer.Stop()
+	}
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	s.workerPool.Shutdown()
+}
+
+// stopHTTP stops the HTTP gateway from taking new connections, leaving
+// requests already being served to the drain.
+func (s *Server) stopHTTP() {
+	if s.httpServer != nil {
+		go s.httpServer.Shutdown(context.Background())
+	}
+}
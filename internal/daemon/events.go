@@ -0,0 +1,279 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// State events pushed to subscribers (see handleSubscribe).
+const (
+	EventProviderRegistered   = "provider_registered"
+	EventProviderUnregistered = "provider_unregistered"
+	EventPaneDied             = "pane_died"        // a pane an ask reached is gone
+	EventRequestStarted       = "request_started"  // an ask is being sent
+	EventRequestFinished      = "request_finished" // with exit_code and duration_ms
+	EventShuttingDown         = "shutting_down"
+)
+
+var stateEvents = []string{
+	EventProviderRegistered, EventProviderUnregistered, EventPaneDied,
+	EventRequestStarted, EventRequestFinished, EventShuttingDown,
+}
+
+const (
+	// subscriberBuffer is how far a subscriber may fall behind before the
+	// daemon drops it rather than wait for it.
+	subscriberBuffer = 256
+	// paneWatchInterval is how often, while anyone is subscribed, the
+	// panes asks have reached are checked.
+	paneWatchInterval = 5 * time.Second
+)
+
+// paneKey names a provider's pane for one project.
+type paneKey struct {
+	provider string
+	workDir  string
+}
+
+// eventHub fans state events out to subscribers, and remembers the panes
+// asks have reached so their death can be reported.
+type eventHub struct {
+	mu    sync.Mutex
+	subs  map[*subscriber]bool
+	panes map[paneKey]bool // panes last seen alive
+}
+
+// subscriber is one subscribe connection's queue of events.
+type subscriber struct {
+	kinds map[string]bool
+	ch    chan protocol.StateEvent // closed when the subscriber is dropped
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[*subscriber]bool), panes: make(map[paneKey]bool)}
+}
+
+func (h *eventHub) subscribe(kinds []string) *subscriber {
+	sub := &subscriber{kinds: make(map[string]bool, len(kinds)), ch: make(chan protocol.StateEvent, subscriberBuffer)}
+	for _, k := range kinds {
+		sub.kinds[k] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[sub] = true
+	return sub
+}
+
+func (h *eventHub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[sub] {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// active reports whether anyone is subscribed.
+func (h *eventHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+// publish queues ev for the subscribers that want it. One whose queue is
+// full is dropped.
+func (h *eventHub) publish(ev protocol.StateEvent) {
+	ev.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.kinds[ev.Event] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// paneSeen records that provider's pane for workDir is alive.
+func (h *eventHub) paneSeen(provider, workDir string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.panes[paneKey{provider, workDir}] = true
+}
+
+// paneGone forgets a pane, reporting whether it was last seen alive.
+func (h *eventHub) paneGone(provider, workDir string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := paneKey{provider, workDir}
+	alive := h.panes[k]
+	delete(h.panes, k)
+	return alive
+}
+
+// knownPanes returns the panes last seen alive.
+func (h *eventHub) knownPanes() []paneKey {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]paneKey, 0, len(h.panes))
+	for k := range h.panes {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// askStarted publishes request_started for provReq.
+func (s *Server) askStarted(provider string, provReq *adapter.ProviderRequest) {
+	s.events.publish(protocol.StateEvent{Event: EventRequestStarted, Provider: provider, WorkDir: provReq.WorkDir,
+		ReqID: provReq.ReqID, Caller: provReq.Caller})
+}
+
+// askFinished publishes request_finished for provReq, and pane_died if
+// the ask found its pane gone.
+func (s *Server) askFinished(provider string, provReq *adapter.ProviderRequest, result *adapter.ProviderResult, took time.Duration) {
+	exit := result.ExitCode
+	s.events.publish(protocol.StateEvent{Event: EventRequestFinished, Provider: provider, WorkDir: provReq.WorkDir,
+		ReqID: provReq.ReqID, Caller: provReq.Caller, ExitCode: &exit, Error: result.Error, DurationMs: took.Milliseconds()})
+	switch result.ExitCode {
+	case output.ExitOK:
+		s.events.paneSeen(provider, provReq.WorkDir)
+		s.liveness.set(provider, provReq.WorkDir, true)
+	case output.ExitPaneDead:
+		s.liveness.set(provider, provReq.WorkDir, false)
+		s.paneDied(provider, provReq.WorkDir, result.Error)
+	}
+}
+
+// paneDied publishes pane_died for a pane last seen alive.
+func (s *Server) paneDied(provider, workDir, reason string) {
+	if s.events.paneGone(provider, workDir) {
+		s.logger.Info("pane died", "provider", provider, "work_dir", workDir, "reason", reason)
+		s.events.publish(protocol.StateEvent{Event: EventPaneDied, Provider: provider, WorkDir: workDir, Error: reason})
+	}
+}
+
+// providersChanged forgets cached pane liveness and publishes
+// provider_registered and provider_unregistered events.
+func (s *Server) providersChanged(added, removed []string) {
+	s.liveness.reset()
+	for _, p := range added {
+		s.events.publish(protocol.StateEvent{Event: EventProviderRegistered, Provider: p})
+	}
+	for _, p := range removed {
+		s.events.publish(protocol.StateEvent{Event: EventProviderUnregistered, Provider: p})
+	}
+}
+
+// paneWatcher checks, while anyone is subscribed, that the panes asks have
+// reached are still there.
+func (s *Server) paneWatcher() {
+	ticker := time.NewTicker(paneWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+		}
+		if s.events.active() {
+			s.checkPanes()
+		}
+	}
+}
+
+// checkPanes reports the panes last seen alive that are gone now.
+func (s *Server) checkPanes() {
+	for _, k := range s.events.knownPanes() {
+		a, ok := s.registry.Get(k.provider)
+		if !ok {
+			s.events.paneGone(k.provider, k.workDir)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		_, err := a.EnsurePane(ctx, k.workDir)
+		cancel()
+		if err != nil {
+			s.paneDied(k.provider, k.workDir, err.Error())
+		}
+	}
+}
+
+// handleSubscribe handles a subscribe request: it acknowledges, then
+// writes each state event the client asked for until the client goes
+// away, falls too far behind, or the daemon stops. The connection is
+// closed afterwards.
+func (s *Server) handleSubscribe(conn net.Conn, req *protocol.SubscribeRequest) {
+	kinds := req.Events
+	if len(kinds) == 0 {
+		kinds = stateEvents
+	}
+	for _, k := range kinds {
+		if !isStateEvent(k) {
+			s.sendError(conn, fmt.Sprintf("unknown event %q (want one of %v)", k, stateEvents))
+			return
+		}
+	}
+	kinds = append([]string(nil), kinds...)
+	sort.Strings(kinds)
+
+	sub := s.events.subscribe(kinds)
+	defer s.events.unsubscribe(sub)
+	// Subscriptions are long-lived; each write gets its own deadline.
+	conn.SetDeadline(time.Time{})
+	if !s.sendEvent(conn, protocol.SubscribeResponse{Status: "ok", Events: kinds}) {
+		return
+	}
+	s.logger.Info("subscribe: client subscribed", "events", len(kinds))
+	for {
+		select {
+		case ev, ok := <-sub.ch:
+			if !ok {
+				s.logger.Warn("subscribe: dropped a subscriber that fell behind")
+				s.sendEvent(conn, protocol.ErrorResponse{Status: "error", Error: "subscriber fell behind; events were dropped"})
+				return
+			}
+			if !s.sendEvent(conn, ev) {
+				return
+			}
+		case <-s.shutdown:
+			// Pass on what was published before, shutting_down included.
+			for n := len(sub.ch); n > 0; n-- {
+				if ev, ok := <-sub.ch; !ok || !s.sendEvent(conn, ev) {
+					return
+				}
+			}
+			return
+		}
+	}
+}
+
+func isStateEvent(kind string) bool {
+	for _, k := range stateEvents {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// sendEvent writes v to a subscriber, reporting whether it got through.
+func (s *Server) sendEvent(conn net.Conn, v interface{}) bool {
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	data, _ := json.Marshal(v)
+	_, err := conn.Write(append(data, '\n'))
+	return err == nil
+}
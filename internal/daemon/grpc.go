@@ -0,0 +1,229 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/bridgepb"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+)
+
+// GRPCEnv enables the gRPC service on the given host:port.
+const GRPCEnv = "CCB_ASKD_GRPC"
+
+// startGRPC serves the gRPC service (bridgepb.Bridge) when an address is
+// configured, over TLS if the daemon uses it, and returns the address it
+// bound.
+func (s *Server) startGRPC() (string, error) {
+	if s.grpcAddr == "" {
+		return "", nil
+	}
+	ln, err := net.Listen("tcp", s.grpcAddr)
+	if err != nil {
+		return "", fmt.Errorf("grpc: %w", err)
+	}
+	var opts []grpc.ServerOption
+	if s.tls != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	s.grpcServer = grpc.NewServer(opts...)
+	bridgepb.RegisterBridgeServer(s.grpcServer, &grpcBridge{s: s})
+	go func() {
+		if err := s.grpcServer.Serve(ln); err != nil {
+			s.log("grpc: %v", err)
+		}
+	}()
+	return ln.Addr().String(), nil
+}
+
+// stopGRPC stops the gRPC service from taking new calls, leaving calls
+// already running to the drain.
+func (s *Server) stopGRPC() {
+	if s.grpcServer != nil {
+		go s.grpcServer.GracefulStop()
+	}
+}
+
+// grpcBridge implements bridgepb.BridgeServer: each call becomes the
+// matching protocol message and runs through dispatch, so auth, schema
+// validation and tracing match the TCP protocol. The token comes from
+// "authorization: Bearer" metadata.
+type grpcBridge struct {
+	bridgepb.UnimplementedBridgeServer
+	s *Server
+}
+
+// Ask streams the reply's chunks and then its result. Canceling the call
+// cancels the ask, as closing the connection does for a CLI ask.
+func (b *grpcBridge) Ask(req *bridgepb.AskRequest, stream bridgepb.Bridge_AskServer) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	stop := context.AfterFunc(stream.Context(), func() { cancel(adapter.ErrCanceled) })
+	defer stop()
+	return b.s.serveGRPC(stream.Context(), &grpcConn{ctx: ctx, stream: stream}, "ask", req)
+}
+
+func (b *grpcBridge) Ping(ctx context.Context, req *bridgepb.PingRequest) (*bridgepb.PingResponse, error) {
+	resp := &bridgepb.PingResponse{}
+	return resp, b.s.serveGRPC(ctx, &grpcConn{ctx: ctx, resp: resp}, "ping", req)
+}
+
+func (b *grpcBridge) Pend(ctx context.Context, req *bridgepb.PendRequest) (*bridgepb.PendResponse, error) {
+	resp := &bridgepb.PendResponse{}
+	return resp, b.s.serveGRPC(ctx, &grpcConn{ctx: ctx, resp: resp}, "pend", req)
+}
+
+func (b *grpcBridge) Status(ctx context.Context, req *bridgepb.StatusRequest) (*bridgepb.StatusResponse, error) {
+	resp := &bridgepb.StatusResponse{}
+	return resp, b.s.serveGRPC(ctx, &grpcConn{ctx: ctx, resp: resp}, "status", req)
+}
+
+func (b *grpcBridge) Cancel(ctx context.Context, req *bridgepb.CancelRequest) (*bridgepb.CancelResponse, error) {
+	resp := &bridgepb.CancelResponse{}
+	return resp, b.s.serveGRPC(ctx, &grpcConn{ctx: ctx, resp: resp}, "cancel", req)
+}
+
+// serveGRPC turns in into a request for method and dispatches it on conn,
+// returning the status error the call should fail with, if any. The
+// proto field names are the protocol's JSON names.
+func (s *Server) serveGRPC(ctx context.Context, conn *grpcConn, method string, in proto.Message) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(in)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	req := map[string]interface{}{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	req["method"] = method
+	if method == "ask" {
+		req["stream"] = true
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if bearer, ok := strings.CutPrefix(v, "Bearer "); ok {
+			req["token"] = strings.TrimSpace(bearer)
+		}
+	}
+
+	conn.peer = auth.Peer{UID: -1}
+	if p, ok := peer.FromContext(ctx); ok {
+		conn.peer.Addr = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			conn.peer.TLS = &info.State
+		}
+	}
+	if _, err := s.auth.Authenticate(conn.peer, req); err != nil {
+		s.log("auth: rejected grpc %s: %v", conn.peer.Addr, err)
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	s.dispatch(conn, req)
+	return conn.err
+}
+
+// grpcConn carries one call's responses: an ask's chunks and result go
+// out on stream as AskEvents; any other method's response fills resp. A
+// protocol error becomes the call's status error, InvalidArgument for a
+// request that failed validation.
+type grpcConn struct {
+	ctx    context.Context
+	peer   auth.Peer
+	stream bridgepb.Bridge_AskServer
+	resp   proto.Message
+
+	mu  sync.Mutex
+	err error
+}
+
+// Write translates one protocol message (a JSON line).
+func (c *grpcConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	var head struct {
+		Event  string `json:"event"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(p, &head); err != nil {
+		c.err = status.Error(codes.Internal, err.Error())
+		return 0, c.err
+	}
+	if head.Status == "error" {
+		code := codes.Unknown
+		if strings.HasPrefix(head.Error, "invalid request") {
+			code = codes.InvalidArgument
+		}
+		c.err = status.Error(code, head.Error)
+		return len(p), nil
+	}
+	c.err = c.forward(p, head.Event == "chunk")
+	if c.err != nil {
+		return 0, c.err
+	}
+	return len(p), nil
+}
+
+// forward decodes p into the call's response or the next AskEvent.
+func (c *grpcConn) forward(p []byte, chunk bool) error {
+	decode := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if c.stream == nil {
+		if err := decode.Unmarshal(p, c.resp); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return nil
+	}
+	ev := &bridgepb.AskEvent{}
+	if chunk {
+		m := &bridgepb.Chunk{}
+		if err := decode.Unmarshal(p, m); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		ev.Event = &bridgepb.AskEvent_Chunk{Chunk: m}
+	} else {
+		m := &bridgepb.AskResult{}
+		if err := decode.Unmarshal(p, m); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		ev.Event = &bridgepb.AskEvent_Result{Result: m}
+	}
+	return c.stream.Send(ev)
+}
+
+// Peer describes the gRPC client for the authenticators.
+func (c *grpcConn) Peer() auth.Peer { return c.peer }
+
+// Context ends when the call does; for an ask, with adapter.ErrCanceled.
+func (c *grpcConn) Context() context.Context { return c.ctx }
+
+func (c *grpcConn) Read([]byte) (int, error)         { return 0, io.EOF }
+func (c *grpcConn) Close() error                     { return nil }
+func (c *grpcConn) LocalAddr() net.Addr              { return grpcAddr("grpc") }
+func (c *grpcConn) RemoteAddr() net.Addr             { return grpcAddr(c.peer.Addr) }
+func (c *grpcConn) SetDeadline(time.Time) error      { return nil }
+func (c *grpcConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *grpcConn) SetWriteDeadline(time.Time) error { return nil }
+
+// grpcAddr is an address reported by gRPC.
+type grpcAddr string
+
+func (a grpcAddr) Network() string { return "grpc" }
+func (a grpcAddr) String() string  { return string(a) }
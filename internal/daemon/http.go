@@ -0,0 +1,216 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// httpRoutes maps gateway paths to protocol methods and the HTTP verbs
+// they accept. GET requests take their fields from the query string.
+var httpRoutes = map[string]struct {
+	method string
+	get    bool
+}{
+	"/ask":    {"ask", false},
+	"/ping":   {"ping", true},
+	"/pend":   {"pend", true},
+	"/status": {"status", true},
+}
+
+// maxHTTPBody bounds a gateway request body.
+const maxHTTPBody = 8 << 20
+
+// HTTPEnv enables the HTTP gateway on the given host:port.
+const HTTPEnv = "CCB_ASKD_HTTP"
+
+// startHTTP serves the gateway when an address is configured, over TLS if
+// the daemon uses it, and returns the address it bound.
+func (s *Server) startHTTP() (string, error) {
+	if s.httpAddr == "" {
+		return "", nil
+	}
+	ln, err := net.Listen("tcp", s.httpAddr)
+	if err != nil {
+		return "", fmt.Errorf("http gateway: %w", err)
+	}
+	addr := ln.Addr().String()
+	if s.tls != nil {
+		ln = tls.NewListener(ln, s.tls)
+	}
+	s.httpServer = &http.Server{
+		Handler:           s.httpHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.log("http gateway: %v", err)
+		}
+	}()
+	return addr, nil
+}
+
+// httpHandler serves the HTTP+JSON gateway, plus WebSocket event streams
+// on /ws (see serveWebSocket) and Prometheus metrics on /metrics: each route turns the request
+// into a protocol message and runs it through dispatch, so auth, schema
+// validation and tracing match the TCP protocol. The token comes from an
+// "Authorization: Bearer" header, or a "token" field or query parameter.
+func (s *Server) httpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			s.serveWebSocket(w, r)
+			return
+		}
+		if r.URL.Path == "/metrics" {
+			req := map[string]interface{}{"method": "metrics"}
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				req["token"] = strings.TrimSpace(bearer)
+			}
+			if _, err := s.auth.Authenticate((&httpConn{w: w, r: r}).Peer(), req); err != nil {
+				s.log("auth: rejected http %s: %v", r.RemoteAddr, err)
+				writeHTTPError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			s.serveMetrics(w, r)
+			return
+		}
+		route, ok := httpRoutes[r.URL.Path]
+		if !ok {
+			writeHTTPError(w, http.StatusNotFound, "unknown endpoint: "+r.URL.Path)
+			return
+		}
+		req := map[string]interface{}{}
+		switch {
+		case r.Method == http.MethodPost:
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBody))
+			if err != nil {
+				writeHTTPError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if len(bytes.TrimSpace(body)) > 0 {
+				if err := json.Unmarshal(body, &req); err != nil {
+					writeHTTPError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+					return
+				}
+			}
+		case r.Method == http.MethodGet && route.get:
+			for k, v := range r.URL.Query() {
+				req[k] = v[len(v)-1]
+			}
+		default:
+			allow := "POST"
+			if route.get {
+				allow = "GET, POST"
+			}
+			w.Header().Set("Allow", allow)
+			writeHTTPError(w, http.StatusMethodNotAllowed, r.Method+" not allowed on "+r.URL.Path)
+			return
+		}
+		req["method"] = route.method
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			req["token"] = strings.TrimSpace(bearer)
+		}
+
+		conn := &httpConn{w: w, r: r}
+		if _, err := s.auth.Authenticate(conn.Peer(), req); err != nil {
+			s.log("auth: rejected http %s: %v", r.RemoteAddr, err)
+			writeHTTPError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if stream, _ := req["stream"].(bool); stream && route.method == "ask" {
+			// Chunk events and the result go out as NDJSON as they happen.
+			conn.stream = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			s.dispatch(conn, req)
+			return
+		}
+		s.dispatch(conn, req)
+		conn.finish()
+	})
+}
+
+// writeHTTPError sends a protocol-style error object with status code.
+func writeHTTPError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "error", "error": msg})
+}
+
+// httpConn lets dispatch answer an HTTP request. Streaming asks are
+// written through as they come; otherwise the response is buffered so its
+// status code can reflect a protocol error.
+type httpConn struct {
+	w      http.ResponseWriter
+	r      *http.Request
+	stream bool
+	buf    bytes.Buffer
+}
+
+func (c *httpConn) Write(p []byte) (int, error) {
+	if !c.stream {
+		return c.buf.Write(p)
+	}
+	n, err := c.w.Write(p)
+	if f, ok := c.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+// finish sends the buffered response: 400 for a request that failed
+// validation, 500 for any other protocol error, 429 (with Retry-After) for
+// a rate-limited ask, else 200.
+func (c *httpConn) finish() {
+	body := bytes.TrimSpace(c.buf.Bytes())
+	code := http.StatusOK
+	var resp struct {
+		Status       string `json:"status"`
+		Error        string `json:"error"`
+		ExitCode     int    `json:"exit_code"`
+		RetryAfterMs int64  `json:"retry_after_ms"`
+	}
+	if json.Unmarshal(body, &resp) == nil {
+		switch {
+		case resp.Status == "error":
+			code = http.StatusInternalServerError
+			if strings.HasPrefix(resp.Error, "invalid request") {
+				code = http.StatusBadRequest
+			}
+		case resp.ExitCode == output.ExitRateLimit:
+			code = http.StatusTooManyRequests
+			c.w.Header().Set("Retry-After", strconv.FormatInt((resp.RetryAfterMs+999)/1000, 10))
+		}
+	}
+	c.w.Header().Set("Content-Type", "application/json")
+	c.w.WriteHeader(code)
+	c.w.Write(append(body, '\n'))
+}
+
+// Peer describes the HTTP client for the authenticators.
+func (c *httpConn) Peer() auth.Peer {
+	return auth.Peer{Addr: c.r.RemoteAddr, TLS: c.r.TLS, UID: -1}
+}
+
+func (c *httpConn) Read([]byte) (int, error)         { return 0, io.EOF }
+func (c *httpConn) Close() error                     { return nil }
+func (c *httpConn) LocalAddr() net.Addr              { return httpAddr(c.r.Host) }
+func (c *httpConn) RemoteAddr() net.Addr             { return httpAddr(c.r.RemoteAddr) }
+func (c *httpConn) SetDeadline(time.Time) error      { return nil }
+func (c *httpConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *httpConn) SetWriteDeadline(time.Time) error { return nil }
+
+// httpAddr is an address reported by net/http.
+type httpAddr string
+
+func (a httpAddr) Network() string { return "http" }
+func (a httpAddr) String() string  { return string(a) }
@@ -0,0 +1,88 @@
+package daemon
+
+import "time"
+
+// idleCheckInterval is how often the daemon checks whether it has been
+// idle long enough to stop.
+const idleCheckInterval = 30 * time.Second
+
+// IdleTimeouts maps providers to how long the daemon stays up after their
+// last use; a negative timeout keeps the daemon up for good.
+type IdleTimeouts map[string]time.Duration
+
+// touchActivity records a request naming provider, or, for one naming
+// none, activity for every provider.
+func (s *Server) touchActivity(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if provider == "" {
+		s.lastActive = time.Now()
+		return
+	}
+	s.lastUsed[provider] = time.Now()
+}
+
+// setIdle replaces the providers' own idle timeouts (reload).
+func (s *Server) setIdle(idle IdleTimeouts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idle = idle
+}
+
+// idleTimeoutFor returns provider's idle timeout. s.mu must be held.
+func (s *Server) idleTimeoutFor(provider string) time.Duration {
+	if t := s.idle[provider]; t != 0 {
+		return t
+	}
+	return s.idleTimeout
+}
+
+// busy reports whether the daemon still has work: asks being served or in
+// flight, asks queued for a provider, or ask_async jobs without a result.
+func (s *Server) busy() bool {
+	return s.asks.active() > 0 || s.inflight.len() > 0 || s.queue.Len() > 0 || s.jobs.running() > 0
+}
+
+// idleExpired reports whether, at now, every registered provider has gone
+// unused for longer than its idle timeout, so the daemon may stop. With no
+// providers the daemon-wide IdleTimeout applies. While the daemon is busy
+// it never expires, and the idle clock restarts from the last check that
+// saw work, so a long ask does not count as idle time.
+func (s *Server) idleExpired(now time.Time) bool {
+	busy := s.busy()
+	providers := s.registry.Names()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if busy {
+		s.lastActive = later(s.lastActive, now)
+		return false
+	}
+	if len(providers) == 0 {
+		return s.idleTimeout >= 0 && now.Sub(s.lastActive) > s.idleTimeout
+	}
+	for _, p := range providers {
+		timeout := s.idleTimeoutFor(p)
+		if timeout < 0 || now.Sub(later(s.lastActive, s.lastUsed[p])) <= timeout {
+			return false
+		}
+	}
+	return true
+}
+
+// idleMonitor shuts down the server once idleExpired.
+func (s *Server) idleMonitor() {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case now := <-ticker.C:
+			if s.idleExpired(now) {
+				s.log("idle timeout, shutting down")
+				s.Shutdown()
+				return
+			}
+		}
+	}
+}
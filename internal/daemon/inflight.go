@@ -0,0 +1,204 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// inflightReq is one ask being executed, with the cancel func of its
+// context.
+type inflightReq struct {
+	provider string
+	req      *adapter.ProviderRequest
+	started  time.Time
+	phase    string
+	cancel   context.CancelFunc
+}
+
+// inflightSet tracks the asks currently being executed, by req_id.
+type inflightSet struct {
+	mu   sync.Mutex
+	reqs map[string]*inflightReq
+}
+
+func newInflightSet() *inflightSet {
+	return &inflightSet{reqs: make(map[string]*inflightReq)}
+}
+
+// add starts tracking req in PhasePending. It reports false, tracking
+// nothing, if an ask with the same req_id is already in flight.
+func (s *inflightSet) add(provider string, req *adapter.ProviderRequest, cancel context.CancelFunc) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.reqs[req.ReqID]; dup {
+		return false
+	}
+	s.reqs[req.ReqID] = &inflightReq{
+		provider: provider,
+		req:      req,
+		started:  time.Now(),
+		phase:    adapter.PhasePending,
+		cancel:   cancel,
+	}
+	return true
+}
+
+// len returns the number of asks in flight.
+func (s *inflightSet) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.reqs)
+}
+
+// has reports whether reqID is in flight.
+func (s *inflightSet) has(reqID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.reqs[reqID]
+	return ok
+}
+
+// setPhase records the phase of reqID, if it is still tracked.
+func (s *inflightSet) setPhase(reqID, phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.reqs[reqID]; ok {
+		r.phase = phase
+	}
+}
+
+// remove stops tracking reqID.
+func (s *inflightSet) remove(reqID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reqs, reqID)
+}
+
+// cancel cancels reqID's context and reports whether it was in flight.
+func (s *inflightSet) cancel(reqID string) bool {
+	s.mu.Lock()
+	r, ok := s.reqs[reqID]
+	s.mu.Unlock()
+	if ok {
+		r.cancel()
+	}
+	return ok
+}
+
+// list returns the tracked asks as of now.
+func (s *inflightSet) list(now time.Time) []protocol.RequestInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]protocol.RequestInfo, 0, len(s.reqs))
+	for _, r := range s.reqs {
+		infos = append(infos, requestInfo(r.provider, r.req, r.phase, r.started, now))
+	}
+	return infos
+}
+
+// requestInfo builds the listing entry for one ask.
+func requestInfo(provider string, req *adapter.ProviderRequest, phase string, started, now time.Time) protocol.RequestInfo {
+	return protocol.RequestInfo{
+		ReqID:    req.ReqID,
+		Provider: provider,
+		Caller:   req.Caller,
+		ClientID: req.ClientID,
+		WorkDir:  req.WorkDir,
+		Phase:    phase,
+		Priority: req.Priority,
+		Started:  started.Format(time.RFC3339),
+		ElapsedS: now.Sub(started).Seconds(),
+	}
+}
+
+// Requests returns the in-flight and queued asks, oldest first.
+func (s *Server) Requests() []protocol.RequestInfo {
+	now := time.Now()
+	infos := s.inflight.list(now)
+	for _, it := range s.queue.Items() {
+		infos = append(infos, requestInfo(it.Provider, it.Request, adapter.PhaseQueued, it.QueuedAt, now))
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].ElapsedS > infos[j].ElapsedS })
+	return infos
+}
+
+// Cancel stops the in-flight ask reqID, or drops it from the offline
+// queue. It returns "canceled" or "dequeued", and false if reqID is
+// unknown.
+func (s *Server) Cancel(reqID string) (string, bool) {
+	if reqID == "" {
+		return "", false
+	}
+	if s.inflight.cancel(reqID) {
+		s.logger.Info("cancel: canceled", "req_id", reqID)
+		return "canceled", true
+	}
+	if len(s.queue.Drop(reqID)) > 0 {
+		s.logger.Info("cancel: dropped from queue", "req_id", reqID)
+		return "dequeued", true
+	}
+	return "", false
+}
+
+// handleRequests handles a requests (list) request.
+func (s *Server) handleRequests(conn net.Conn) {
+	s.sendJSON(conn, protocol.RequestsResponse{Status: "ok", Requests: s.Requests()})
+}
+
+// handleCancel handles a cancel request.
+func (s *Server) handleCancel(conn net.Conn, req *protocol.CancelRequest) {
+	reqID := req.ReqID
+	state, ok := s.Cancel(reqID)
+	if !ok {
+		s.sendError(conn, "no such request: "+reqID)
+		return
+	}
+	s.sendJSON(conn, protocol.CancelResponse{Status: "ok", ReqID: reqID, State: state})
+}
+
+// phaseClock times an ask's startup (from submission until the prompt is
+// sent to the pane) apart from its reply, so each has its own budget.
+type phaseClock struct {
+	submitted time.Time
+	sent      chan struct{}
+	once      sync.Once
+	sentAt    time.Time // set before sent is closed
+}
+
+func newPhaseClock() *phaseClock {
+	return &phaseClock{submitted: time.Now(), sent: make(chan struct{})}
+}
+
+// markSent records that the prompt reached the pane.
+func (c *phaseClock) markSent() {
+	c.once.Do(func() {
+		c.sentAt = time.Now()
+		close(c.sent)
+	})
+}
+
+// isSent reports whether markSent was called.
+func (c *phaseClock) isSent() bool {
+	select {
+	case <-c.sent:
+		return true
+	default:
+		return false
+	}
+}
+
+// stamp reports the phase durations in r.
+func (c *phaseClock) stamp(r *adapter.ProviderResult) {
+	if !c.isSent() {
+		r.StartupMs = time.Since(c.submitted).Milliseconds()
+		return
+	}
+	r.StartupMs = c.sentAt.Sub(c.submitted).Milliseconds()
+	r.ReplyMs = time.Since(c.sentAt).Milliseconds()
+}
@@ -0,0 +1,242 @@
+package daemon
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// defaultJobTableSize bounds how many ask_async jobs are remembered.
+// Older jobs' results stay reachable through the reply store.
+const defaultJobTableSize = 200
+
+// Job states, as reported in protocol.JobInfo.
+const (
+	JobRunning = "running"
+	JobQueued  = "queued"
+	JobDone    = "done"
+)
+
+// job is one ask submitted with ask_async. Its id is the ask's req_id.
+type job struct {
+	ID        string
+	Provider  string
+	Caller    string
+	WorkDir   string
+	Submitted time.Time
+	Finished  time.Time
+	Result    *adapter.ProviderResult // nil while running
+}
+
+// jobTable keeps the most recent jobs keyed by id, oldest first.
+type jobTable struct {
+	mu    sync.Mutex
+	max   int
+	order []string
+	byID  map[string]*job
+}
+
+func newJobTable(max int) *jobTable {
+	return &jobTable{max: max, byID: make(map[string]*job)}
+}
+
+// add records a submitted job, evicting the oldest when full.
+func (t *jobTable) add(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.byID[j.ID]; !exists {
+		t.order = append(t.order, j.ID)
+	}
+	t.byID[j.ID] = j
+	for len(t.order) > t.max {
+		delete(t.byID, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// finish stores a job's result; a queued one is not finished yet.
+func (t *jobTable) finish(id string, r *adapter.ProviderResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if j, ok := t.byID[id]; ok {
+		j.Result = r
+		if !r.Queued {
+			j.Finished = time.Now()
+		}
+	}
+}
+
+// get returns a copy of job id.
+func (t *jobTable) get(id string) (job, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j, ok := t.byID[id]
+	if !ok {
+		return job{}, false
+	}
+	return *j, true
+}
+
+// has reports whether id is a known job.
+func (t *jobTable) has(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.byID[id]
+	return ok
+}
+
+// running returns how many jobs have no result yet.
+func (t *jobTable) running() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, j := range t.byID {
+		if j.Result == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// all returns copies of the jobs, oldest first.
+func (t *jobTable) all() []job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]job, 0, len(t.order))
+	for _, id := range t.order {
+		out = append(out, *t.byID[id])
+	}
+	return out
+}
+
+// resolveJob looks up a job, completing a queued one from the result
+// cache once the queue has delivered it. Jobs the table no longer holds
+// (evicted, or from before a restart) are rebuilt from their stored result.
+func (s *Server) resolveJob(id string) (job, bool) {
+	j, ok := s.jobs.get(id)
+	if ok && (j.Result == nil || !j.Result.Queued) {
+		return j, true
+	}
+	cached, found := s.lookupResult(id)
+	if found && !cached.Result.Queued {
+		if ok {
+			s.jobs.finish(id, cached.Result)
+			return s.jobs.get(id)
+		}
+		return job{ID: id, Provider: cached.Provider, Result: cached.Result}, true
+	}
+	return j, ok
+}
+
+// jobInfo describes j for the protocol.
+func jobInfo(j job, now time.Time) protocol.JobInfo {
+	info := protocol.JobInfo{JobID: j.ID, Provider: j.Provider, Caller: j.Caller, WorkDir: j.WorkDir, State: JobRunning}
+	if !j.Submitted.IsZero() {
+		info.Submitted = j.Submitted.Format(time.RFC3339)
+		end := now
+		if !j.Finished.IsZero() {
+			end = j.Finished
+		}
+		info.ElapsedS = end.Sub(j.Submitted).Seconds()
+	}
+	switch {
+	case j.Result == nil:
+	case j.Result.Queued:
+		info.State = JobQueued
+	default:
+		info.State = JobDone
+		info.ExitCode = j.Result.ExitCode
+		info.Error = j.Result.Error
+		if !j.Finished.IsZero() {
+			info.Finished = j.Finished.Format(time.RFC3339)
+		}
+	}
+	return info
+}
+
+// Jobs lists the daemon's ask_async jobs, oldest first.
+func (s *Server) Jobs() []protocol.JobInfo {
+	now := time.Now()
+	out := []protocol.JobInfo{}
+	for _, j := range s.jobs.all() {
+		if resolved, ok := s.resolveJob(j.ID); ok {
+			j = resolved
+		}
+		out = append(out, jobInfo(j, now))
+	}
+	return out
+}
+
+// handleAskAsync handles an ask_async request: the ask runs as a job in
+// the background and the caller gets its id straight away.
+func (s *Server) handleAskAsync(conn net.Conn, req *protocol.AskRequest) {
+	provider := req.Provider
+	if provider == "" {
+		s.sendError(conn, "missing provider")
+		return
+	}
+	if _, ok := s.adapterFor(provider, req.WorkDir); !ok {
+		s.sendError(conn, "unknown provider: "+provider)
+		return
+	}
+	id := req.ReqID
+	if id == "" {
+		id = protocol.MakeReqID()
+	}
+	if s.reqIDInUse(id) || s.jobs.has(id) {
+		s.sendError(conn, duplicateReqIDError(id))
+		return
+	}
+
+	ask := *req
+	ask.Method = "request"
+	ask.ReqID = id
+	ask.Stream = false
+
+	s.jobs.add(&job{ID: id, Provider: provider, Caller: req.Caller, WorkDir: req.WorkDir, Submitted: time.Now()})
+	s.logger.Info("job: submitted", "provider", provider, "req_id", id)
+	go s.handleRequest(&resultConn{Conn: conn, reqID: id, done: func(r *adapter.ProviderResult) {
+		s.jobs.finish(id, r)
+		if r.Queued {
+			s.logger.Info("job: queued", "req_id", id)
+		} else {
+			s.logger.Info("job: finished", "req_id", id, "exit_code", r.ExitCode)
+		}
+	}}, &ask)
+	s.sendJSON(conn, protocol.AskAsyncResponse{Status: "ok", JobID: id, State: JobRunning})
+}
+
+// handleJobStatus handles a job_status request.
+func (s *Server) handleJobStatus(conn net.Conn, req *protocol.JobStatusRequest) {
+	id := req.JobID
+	j, ok := s.resolveJob(id)
+	if !ok {
+		s.sendError(conn, "unknown job: "+id)
+		return
+	}
+	s.sendJSON(conn, protocol.JobStatusResponse{Status: "ok", Job: jobInfo(j, time.Now())})
+}
+
+// handleJobResult handles a job_result request. Until the job is done the
+// response carries its state only.
+func (s *Server) handleJobResult(conn net.Conn, req *protocol.JobResultRequest) {
+	id := req.JobID
+	j, ok := s.resolveJob(id)
+	if !ok {
+		s.sendError(conn, "unknown job: "+id)
+		return
+	}
+	resp := protocol.JobResultResponse{Status: "ok", Job: jobInfo(j, time.Now())}
+	if resp.Job.State == JobDone {
+		resp.Result = j.Result
+	}
+	s.sendJSON(conn, resp)
+}
+
+// handleJobs handles a jobs (list) request.
+func (s *Server) handleJobs(conn net.Conn) {
+	s.sendJSON(conn, protocol.JobsResponse{Status: "ok", Jobs: s.Jobs()})
+}
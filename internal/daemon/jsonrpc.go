@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/schema"
+)
+
+// handleMessage runs one message read from a connection: a JSON-RPC 2.0
+// request or batch, or a plain request map. It reports whether the
+// connection should stay open.
+func (s *Server) handleMessage(requests *connRequests, raw json.RawMessage) bool {
+	conn := requests.conn
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		return s.handleBatch(conn, raw)
+	}
+	var req map[string]interface{}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		s.sendJSON(conn, rpcFailure(nil, schema.CodeInvalidRequest, "request must be an object or an array"))
+		return true
+	}
+	if _, ok := req["jsonrpc"]; !ok {
+		id, ok := req["id"]
+		if !ok {
+			return s.dispatch(conn, req)
+		}
+		switch id.(type) {
+		case string, float64:
+			s.startPipelined(requests, id, req)
+		default:
+			s.sendError(conn, "invalid request: id must be a string or number")
+		}
+		return true
+	}
+	w := &rpcWriter{conn: conn}
+	resp, keep := s.rpcCall(w, req)
+	if resp != nil {
+		w.send(resp)
+	}
+	return keep
+}
+
+// handleBatch runs a JSON-RPC batch concurrently and answers with the
+// responses in request order; a batch of notifications gets no answer.
+func (s *Server) handleBatch(conn net.Conn, raw json.RawMessage) bool {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		s.sendJSON(conn, rpcFailure(nil, schema.CodeInvalidRequest, "invalid batch: "+err.Error()))
+		return true
+	}
+	if len(items) == 0 {
+		s.sendJSON(conn, rpcFailure(nil, schema.CodeInvalidRequest, "empty batch"))
+		return true
+	}
+
+	w := &rpcWriter{conn: conn}
+	resps := make([]*schema.RPCResponse, len(items))
+	keeps := make([]bool, len(items))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		var req map[string]interface{}
+		if err := json.Unmarshal(item, &req); err != nil {
+			resps[i], keeps[i] = rpcFailure(nil, schema.CodeInvalidRequest, "batch entries must be objects"), true
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resps[i], keeps[i] = s.rpcCall(w, req)
+		}(i)
+	}
+	wg.Wait()
+
+	out := make([]*schema.RPCResponse, 0, len(resps))
+	keep := true
+	for i, r := range resps {
+		if r != nil {
+			out = append(out, r)
+		}
+		keep = keep && keeps[i]
+	}
+	if len(out) > 0 {
+		w.send(out)
+	}
+	return keep
+}
+
+// rpcCall runs one JSON-RPC request through dispatch and returns its
+// response, or nil for a notification.
+func (s *Server) rpcCall(w *rpcWriter, msg map[string]interface{}) (*schema.RPCResponse, bool) {
+	id, hasID := msg["id"]
+	switch id.(type) {
+	case nil, string, float64:
+	default:
+		return rpcFailure(nil, schema.CodeInvalidRequest, "id must be a string, number or null"), true
+	}
+	method, _ := msg["method"].(string)
+	if msg["jsonrpc"] != schema.JSONRPCVersion || method == "" {
+		return rpcFailure(id, schema.CodeInvalidRequest, `want "jsonrpc": "2.0" and a method`), true
+	}
+	req := map[string]interface{}{}
+	switch params := msg["params"].(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, v := range params {
+			req[k] = v
+		}
+	default:
+		return rpcFailure(id, schema.CodeInvalidParams, "params must be an object"), true
+	}
+	req["method"] = method
+
+	c := &rpcConn{Conn: w.conn, w: w, hasID: hasID}
+	keep := s.dispatch(c, req)
+	if !hasID {
+		return nil, keep
+	}
+	return c.response(id, keep), keep
+}
+
+// maxTimeout returns the longest timeout_s in a message, whether a plain
+// request, a JSON-RPC request (in params) or a batch of them.
+func maxTimeout(raw json.RawMessage) float64 {
+	var batch []map[string]interface{}
+	if json.Unmarshal(raw, &batch) != nil {
+		var one map[string]interface{}
+		json.Unmarshal(raw, &one)
+		batch = append(batch, one)
+	}
+	max := 0.0
+	for _, m := range batch {
+		if params, ok := m["params"].(map[string]interface{}); ok {
+			m = params
+		}
+		if t := getFloat(m, "timeout_s"); t > max {
+			max = t
+		}
+	}
+	return max
+}
+
+// rpcFailure builds an error response.
+func rpcFailure(id interface{}, code int, msg string) *schema.RPCResponse {
+	return &schema.RPCResponse{JSONRPC: schema.JSONRPCVersion, ID: id, Error: &schema.RPCError{Code: code, Message: msg}}
+}
+
+// rpcWriter serializes the JSON lines of concurrent batch entries.
+type rpcWriter struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func (w *rpcWriter) send(v interface{}) {
+	data, _ := json.Marshal(v)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conn.Write(append(data, '\n'))
+}
+
+// rpcConn captures the plain response dispatch writes for one JSON-RPC
+// request, forwarding streamed chunks as notifications as they come.
+type rpcConn struct {
+	net.Conn
+	w     *rpcWriter
+	hasID bool // notifications get no chunks either
+	mu    sync.Mutex
+	last  map[string]interface{}
+}
+
+func (c *rpcConn) Write(p []byte) (int, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		return 0, err
+	}
+	if m["event"] == "chunk" {
+		if c.hasID {
+			delete(m, "event")
+			c.w.send(map[string]interface{}{"jsonrpc": schema.JSONRPCVersion, "method": "chunk", "params": m})
+		}
+		return len(p), nil
+	}
+	c.mu.Lock()
+	c.last = m
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+// Peer reports the underlying connection's peer to the authenticators.
+func (c *rpcConn) Peer() auth.Peer {
+	return auth.PeerOf(c.Conn)
+}
+
+// response turns the captured plain response into a JSON-RPC one. keep is
+// dispatch's verdict: a rejected token closes the connection.
+func (c *rpcConn) response(id interface{}, keep bool) *schema.RPCResponse {
+	c.mu.Lock()
+	m := c.last
+	c.mu.Unlock()
+	if m == nil {
+		return rpcFailure(id, schema.CodeServerError, "no response")
+	}
+	if m["status"] != "error" {
+		return &schema.RPCResponse{JSONRPC: schema.JSONRPCVersion, ID: id, Result: m}
+	}
+	msg, _ := m["error"].(string)
+	code := schema.CodeServerError
+	switch {
+	case !keep:
+		code = schema.CodeUnauthorized
+	case strings.HasPrefix(msg, "invalid request"):
+		code = schema.CodeInvalidParams
+	case strings.HasPrefix(msg, "unknown method"):
+		code = schema.CodeMethodNotFound
+	}
+	return rpcFailure(id, code, msg)
+}
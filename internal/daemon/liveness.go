@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// livenessTTL is how long a project's pane liveness, as reported by
+// status, is served from cache. Statuslines ask every few seconds;
+// checking each pane means a round of terminal commands.
+const livenessTTL = 30 * time.Second
+
+// livenessCache remembers, per project, which providers had a live pane
+// when last checked. Ask outcomes keep it current between checks.
+type livenessCache struct {
+	mu    sync.Mutex
+	byDir map[string]*livenessEntry
+}
+
+type livenessEntry struct {
+	checked time.Time
+	online  map[string]bool // provider -> live pane
+}
+
+func newLivenessCache() *livenessCache {
+	return &livenessCache{byDir: make(map[string]*livenessEntry)}
+}
+
+// get returns a copy of workDir's liveness if it was checked within
+// livenessTTL.
+func (c *livenessCache) get(workDir string, now time.Time) (map[string]bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.byDir[workDir]
+	if e == nil || now.Sub(e.checked) >= livenessTTL {
+		return nil, false
+	}
+	return copyLiveness(e.online), true
+}
+
+// put records a full check of workDir's panes.
+func (c *livenessCache) put(workDir string, online map[string]bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byDir[workDir] = &livenessEntry{checked: now, online: copyLiveness(online)}
+}
+
+func copyLiveness(online map[string]bool) map[string]bool {
+	cp := make(map[string]bool, len(online))
+	for name, up := range online {
+		cp[name] = up
+	}
+	return cp
+}
+
+// set updates one provider's liveness for workDir, as learned from an
+// ask, without extending the entry's lifetime. Projects not yet checked
+// are left for the next status call to check in full.
+func (c *livenessCache) set(provider, workDir string, up bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.byDir[workDir]; e != nil {
+		e.online[provider] = up
+	}
+}
+
+// reset forgets every project, as when providers come or go.
+func (c *livenessCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byDir = make(map[string]*livenessEntry)
+}
+
+// providerLiveness reports, per registered provider, whether a live pane
+// exists for workDir, checking the panes at most once per livenessTTL.
+func (s *Server) providerLiveness(workDir string) map[string]bool {
+	if online, ok := s.liveness.get(workDir, time.Now()); ok {
+		return online
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	online := make(map[string]bool)
+	for _, name := range s.registry.Names() {
+		a, ok := s.registry.Get(name)
+		if !ok {
+			continue
+		}
+		_, err := a.EnsurePane(ctx, workDir)
+		online[name] = err == nil
+	}
+	s.liveness.put(workDir, online, time.Now())
+	return online
+}
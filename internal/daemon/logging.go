@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// newLogger builds the daemon's logger. Records go to logFile (rotated by
+// runtime.WriteLog) and to mirror, one per line: config.LogFormatJSON
+// writes JSON objects, anything else "[time] message key=value ..." text.
+// Debug records are kept only while output's level allows them.
+func newLogger(format, logFile string, mirror io.Writer) *slog.Logger {
+	w := &logSink{file: logFile, mirror: mirror}
+	if format == config.LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       outputLevel{},
+			ReplaceAttr: jsonAttr,
+		}))
+	}
+	return slog.New(&textHandler{w: w, mu: &sync.Mutex{}})
+}
+
+// logSink writes each formatted record to the log file and the mirror.
+type logSink struct {
+	file   string
+	mirror io.Writer
+}
+
+func (w *logSink) Write(p []byte) (int, error) {
+	if w.file != "" {
+		runtime.WriteLog(w.file, string(p))
+	}
+	if w.mirror != nil {
+		w.mirror.Write(p)
+	}
+	return len(p), nil
+}
+
+// outputLevel follows the process's output level, so --verbose and
+// --log-level also govern the daemon log.
+type outputLevel struct{}
+
+func (outputLevel) Level() slog.Level {
+	if output.Enabled(output.LevelDebug) {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// jsonAttr renders durations as seconds, which log tools can aggregate.
+func jsonAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindDuration {
+		return slog.Float64(a.Key, a.Value.Duration().Seconds())
+	}
+	return a
+}
+
+// textHandler writes records in the daemon's traditional log line format,
+// with attributes appended as key=value pairs.
+type textHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string // group prefix for attributes, e.g. "req."
+	attrs  []byte // preformatted attributes from WithAttrs
+}
+
+func (h *textHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= outputLevel{}.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b bytes.Buffer
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	b.WriteString("[" + t.Format("2006-01-02 15:04:05") + "] ")
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("warning: ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	b.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendTextAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b.Bytes())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b bytes.Buffer
+	b.Write(h.attrs)
+	for _, a := range attrs {
+		appendTextAttr(&b, h.prefix, a)
+	}
+	return &textHandler{w: h.w, mu: h.mu, prefix: h.prefix, attrs: b.Bytes()}
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &textHandler{w: h.w, mu: h.mu, prefix: h.prefix + name + ".", attrs: h.attrs}
+}
+
+// appendTextAttr appends " key=value", flattening groups into dotted keys.
+func appendTextAttr(b *bytes.Buffer, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			appendTextAttr(b, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" && v.Any() == nil {
+		return
+	}
+	b.WriteString(" " + prefix + a.Key + "=")
+	var s string
+	switch v.Kind() {
+	case slog.KindDuration:
+		s = v.Duration().String()
+	case slog.KindTime:
+		s = v.Time().Format(time.RFC3339)
+	default:
+		s = v.String()
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = strconv.Quote(s)
+	}
+	b.WriteString(s)
+}
+
+// log writes an info message, formatted like fmt.Sprintf.
+func (s *Server) log(format string, args ...interface{}) {
+	s.logger.Info(fmt.Sprintf(format, args...))
+}
+
+// debugf writes to the daemon log at debug level only.
+func (s *Server) debugf(format string, args ...interface{}) {
+	if s.logger.Enabled(context.Background(), slog.LevelDebug) {
+		s.logger.Debug(fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/metrics"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// MetricsEnv serves /metrics, without a token, on the given host:port.
+const MetricsEnv = "CCB_ASKD_METRICS"
+
+// serverMetrics are the daemon's Prometheus metrics.
+type serverMetrics struct {
+	registry *metrics.Registry
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	anchor   *metrics.HistogramVec
+	done     *metrics.HistogramVec
+	panics   *metrics.CounterVec
+}
+
+// newServerMetrics registers the daemon's metrics; gauges read s when scraped.
+func newServerMetrics(s *Server) *serverMetrics {
+	r := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: r,
+		requests: r.Counter("ccb_requests_total", "Asks run (or refused by a rate limit) by the daemon, by provider and outcome (ok, timeout, pane_dead, no_session, paused, canceled, rate_limited, error).", "provider", "outcome"),
+		duration: r.Histogram("ccb_request_duration_seconds", "Time from an ask reaching a worker to its result.", metrics.DefBuckets, "provider"),
+		anchor:   r.Histogram("ccb_anchor_latency_seconds", "Time from sending a prompt to its anchor showing up in the provider's log.", metrics.DefBuckets, "provider"),
+		done:     r.Histogram("ccb_done_latency_seconds", "Time from sending a prompt to its CCB_DONE marker.", metrics.DefBuckets, "provider"),
+		panics:   r.Counter("ccb_worker_panics_total", "Panics recovered in session workers, by provider.", "provider"),
+	}
+	started := time.Now()
+	r.GaugeFunc("ccb_workers", "Session workers in the pool.", func() float64 { return float64(s.workerPool.ActiveWorkers()) })
+	r.GaugeFunc("ccb_workers_max", "Worker pool capacity.", func() float64 { return float64(s.workerPool.MaxSize()) })
+	r.GaugeFunc("ccb_requests_in_flight", "Asks being sent or waited on.", func() float64 { return float64(s.inflight.len()) })
+	r.GaugeFunc("ccb_requests_queued", "Asks held for offline or paused providers, or scheduled.", func() float64 { return float64(s.queue.Len()) })
+	r.GaugeFunc("ccb_uptime_seconds", "Seconds since the daemon started.", func() float64 { return time.Since(started).Seconds() })
+	return m
+}
+
+// observe records a finished ask.
+func (m *serverMetrics) observe(provider string, r *adapter.ProviderResult, elapsed time.Duration) {
+	m.requests.Inc(provider, outcome(r.ExitCode))
+	m.duration.Observe(elapsed.Seconds(), provider)
+	if r.AnchorMs > 0 {
+		m.anchor.Observe(float64(r.AnchorMs)/1000, provider)
+	}
+	if r.DoneMs > 0 && r.ExitCode == output.ExitOK {
+		m.done.Observe(float64(r.DoneMs)/1000, provider)
+	}
+}
+
+// outcome names an exit code for the requests counter.
+func outcome(code int) string {
+	switch code {
+	case output.ExitOK:
+		return "ok"
+	case output.ExitTimeout:
+		return "timeout"
+	case output.ExitPaneDead:
+		return "pane_dead"
+	case output.ExitNoSession:
+		return "no_session"
+	case output.ExitPaused:
+		return "paused"
+	case output.ExitCanceled:
+		return "canceled"
+	case output.ExitRateLimit:
+		return "rate_limited"
+	}
+	return "error"
+}
+
+// serveMetrics writes the metrics for a scrape.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET")
+		writeHTTPError(w, http.StatusMethodNotAllowed, r.Method+" not allowed on "+r.URL.Path)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	s.metrics.registry.WriteText(w)
+}
+
+// startMetrics serves /metrics on its own listener, without a token, when
+// an address is configured, and returns the address it bound. The
+// metrics carry no prompts or replies.
+func (s *Server) startMetrics() (string, error) {
+	if s.metricsAddr == "" {
+		return "", nil
+	}
+	ln, err := net.Listen("tcp", s.metricsAddr)
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	s.metricsSrv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.metricsSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.log("metrics: %v", err)
+		}
+	}()
+	return ln.Addr().String(), nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package daemon
+
+import "syscall"
+
+// processAlive reports whether process pid exists. Signal 0 checks without
+// delivering anything; EPERM means it exists but belongs to someone else.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package daemon
+
+import "syscall"
+
+// processAlive reports whether process pid is still running. A process
+// that has exited can still be opened while handles to it remain, so an
+// open handle must also not be signaled yet.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		// Running under another user: it exists, but we cannot watch it.
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+	event, err := syscall.WaitForSingleObject(h, 0)
+	return err == nil && event == syscall.WAIT_TIMEOUT
+}
@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// pauseInfo records when and why a provider was paused.
+type pauseInfo struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// pauseSet holds the paused providers, persisted to path (if set) so a
+// pause survives a daemon restart.
+type pauseSet struct {
+	mu    sync.Mutex
+	path  string
+	items map[string]pauseInfo
+}
+
+// newPauseSet loads the paused providers from path; a missing or corrupt
+// file pauses nothing.
+func newPauseSet(path string) *pauseSet {
+	p := &pauseSet{path: path, items: make(map[string]pauseInfo)}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &p.items)
+		}
+		if p.items == nil {
+			p.items = make(map[string]pauseInfo)
+		}
+	}
+	return p
+}
+
+// Pause pauses provider, keeping the original time if it already is.
+func (p *pauseSet) Pause(provider, reason string) (pauseInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, ok := p.items[provider]
+	if !ok {
+		info.Since = time.Now()
+	}
+	if reason != "" || !ok {
+		info.Reason = reason
+	}
+	p.items[provider] = info
+	return info, p.saveLocked()
+}
+
+// Resume lifts the pause on provider, reporting whether it was paused.
+func (p *pauseSet) Resume(provider string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.items[provider]; !ok {
+		return false, nil
+	}
+	delete(p.items, provider)
+	return true, p.saveLocked()
+}
+
+// Get returns the pause on provider, if any.
+func (p *pauseSet) Get(provider string) (pauseInfo, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, ok := p.items[provider]
+	return info, ok
+}
+
+// All returns the paused providers in the form the protocol reports them.
+func (p *pauseSet) All() map[string]protocol.PauseInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	all := make(map[string]protocol.PauseInfo, len(p.items))
+	for name, info := range p.items {
+		all[name] = protocol.PauseInfo{Since: info.Since.Format(time.RFC3339), Reason: info.Reason}
+	}
+	return all
+}
+
+func (p *pauseSet) saveLocked() error {
+	if p.path == "" {
+		return nil
+	}
+	if len(p.items) == 0 {
+		err := os.Remove(p.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(p.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(p.path), 0755)
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// pausedError is the error returned for an ask to a paused provider.
+func pausedError(provider string, info pauseInfo) string {
+	msg := fmt.Sprintf("PAUSED: %s is paused since %s", provider, info.Since.Format("2006-01-02 15:04"))
+	if info.Reason != "" {
+		msg += " (" + info.Reason + ")"
+	}
+	return msg + "; resume it with 'ccb resume " + provider + "'"
+}
+
+// handlePause handles a pause request.
+func (s *Server) handlePause(conn net.Conn, req *protocol.PauseRequest) {
+	provider := req.Provider
+	if _, ok := s.registry.Get(provider); !ok {
+		s.sendError(conn, "unknown provider: "+provider)
+		return
+	}
+	if _, err := s.paused.Pause(provider, req.Reason); err != nil {
+		s.log("pause: persist failed: %v", err)
+	}
+	s.log("pause: %s paused", provider)
+	s.sendJSON(conn, protocol.PauseResponse{Status: "ok", Provider: provider, Paused: s.paused.All()})
+}
+
+// handleResume handles a resume request and delivers the asks queued
+// meanwhile.
+func (s *Server) handleResume(conn net.Conn, req *protocol.ResumeRequest) {
+	provider := req.Provider
+	was, err := s.paused.Resume(provider)
+	if err != nil {
+		s.log("pause: persist failed: %v", err)
+	}
+	if !was {
+		s.sendError(conn, provider+" is not paused")
+		return
+	}
+	s.log("pause: %s resumed", provider)
+	go s.deliverQueued()
+	s.sendJSON(conn, protocol.PauseResponse{Status: "ok", Provider: provider, Paused: s.paused.All()})
+}
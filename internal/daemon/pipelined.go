@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+)
+
+// maxPipelined caps the pipelined requests in flight on one connection;
+// past it the daemon stops reading from the connection until one is done.
+const maxPipelined = 64
+
+// sharedConn serializes the response lines written for the requests in
+// flight on one connection.
+type sharedConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *sharedConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+// Peer reports the underlying connection's peer to the authenticators.
+func (c *sharedConn) Peer() auth.Peer {
+	return auth.PeerOf(c.Conn)
+}
+
+// pipedConn tags every line written for one pipelined request, chunk
+// events included, with the request's id.
+type pipedConn struct {
+	*sharedConn
+	id interface{}
+}
+
+func (c *pipedConn) Write(p []byte) (int, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		return 0, err
+	}
+	m["id"] = c.id
+	data, _ := json.Marshal(m)
+	if _, err := c.sharedConn.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// connRequests runs the pipelined requests of one connection: plain
+// requests carrying an "id", which are served concurrently and answered in
+// whatever order they finish. Requests without one are served in turn.
+type connRequests struct {
+	conn  *sharedConn
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newConnRequests(conn net.Conn) *connRequests {
+	return &connRequests{conn: &sharedConn{Conn: conn}, slots: make(chan struct{}, maxPipelined)}
+}
+
+// startPipelined serves req, tagged with id, in the background. A request
+// that should end the connection (shutdown, a rejected token) closes it.
+func (s *Server) startPipelined(r *connRequests, id interface{}, req map[string]interface{}) {
+	r.slots <- struct{}{}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.slots }()
+		if !s.dispatch(&pipedConn{sharedConn: r.conn, id: id}, req) {
+			r.conn.Close()
+		}
+	}()
+}
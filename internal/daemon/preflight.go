@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// rootEnv names the variable that overrides a provider's storage root.
+var rootEnv = map[string]string{
+	"codex":  "CODEX_SESSION_ROOT",
+	"gemini": "GEMINI_ROOT",
+}
+
+// Preflight checks that each provider's storage directory, as given by
+// root, exists and can be listed. Providers without a known directory are
+// skipped.
+func Preflight(providers []string, root func(provider string) string) []protocol.StorageCheck {
+	var checks []protocol.StorageCheck
+	for _, p := range providers {
+		dir := root(p)
+		if dir == "" {
+			continue
+		}
+		c := protocol.StorageCheck{Provider: p, Path: dir, OK: true}
+		if problem := checkReadableDir(dir); problem != "" {
+			c.OK = false
+			c.Problem = problem
+			c.Hint = storageHint(p, dir)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkReadableDir returns why dir is not a listable directory, or "".
+func checkReadableDir(dir string) string {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return "not found"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if !info.IsDir() {
+		return "not a directory"
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return "not readable: " + err.Error()
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return "not readable: " + err.Error()
+	}
+	return ""
+}
+
+// storageHint says how to get dir created for provider.
+func storageHint(provider, dir string) string {
+	if env := rootEnv[provider]; env != "" && strings.TrimSpace(os.Getenv(env)) != "" {
+		return "check " + env + ", which points at " + dir
+	}
+	return "run " + provider + " once to create " + tildePath(dir)
+}
+
+// tildePath shortens paths under the home directory to ~/...
+func tildePath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		return "~" + string(filepath.Separator) + rel
+	}
+	return path
+}
@@ -0,0 +1,324 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/notify"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// queueCheckInterval is how often the queue monitor expires stale asks
+// and delivers due ones whose provider is online.
+const queueCheckInterval = 5 * time.Second
+
+// queuedAsk is an ask held until it is due (DeliverAt) and its provider's
+// pane is up. It is dropped unsent once ExpiresAt passes.
+type queuedAsk struct {
+	Provider  string                   `json:"provider"`
+	Request   *adapter.ProviderRequest `json:"request"`
+	QueuedAt  time.Time                `json:"queued_at"`
+	DeliverAt time.Time                `json:"deliver_at"`
+	ExpiresAt time.Time                `json:"expires_at"`
+}
+
+// due reports whether the ask may be sent at now.
+func (it queuedAsk) due(now time.Time) bool {
+	return !now.Before(it.DeliverAt)
+}
+
+// expired reports whether the ask's TTL has run out at now.
+func (it queuedAsk) expired(now time.Time) bool {
+	return !it.ExpiresAt.IsZero() && now.After(it.ExpiresAt)
+}
+
+// queueTarget identifies the provider pane a queued ask waits for.
+type queueTarget struct {
+	Provider string
+	WorkDir  string
+}
+
+// askQueue is the offline queue, persisted to path (if set) so queued
+// asks survive a daemon restart.
+type askQueue struct {
+	mu    sync.Mutex
+	path  string
+	items []queuedAsk
+}
+
+// newAskQueue loads the queue from path; a missing or corrupt file starts
+// an empty queue.
+func newAskQueue(path string) *askQueue {
+	q := &askQueue{path: path}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &q.items)
+		}
+	}
+	return q
+}
+
+// Add appends an ask and persists the queue.
+func (q *askQueue) Add(item queuedAsk) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, item)
+	return q.saveLocked()
+}
+
+// Len returns the number of queued asks.
+func (q *askQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Has reports whether an ask with reqID is queued.
+func (q *askQueue) Has(reqID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, it := range q.items {
+		if it.Request.ReqID == reqID {
+			return true
+		}
+	}
+	return false
+}
+
+// Items returns a snapshot of the queued asks, oldest first.
+func (q *askQueue) Items() []queuedAsk {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]queuedAsk(nil), q.items...)
+}
+
+// Targets returns the distinct panes with asks due at now, in queue order.
+func (q *askQueue) Targets(now time.Time) []queueTarget {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var targets []queueTarget
+	seen := make(map[queueTarget]bool)
+	for _, it := range q.items {
+		t := queueTarget{it.Provider, it.Request.WorkDir}
+		if it.due(now) && !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// Take removes and returns the asks for t that are due at now, oldest
+// first.
+func (q *askQueue) Take(t queueTarget, now time.Time) []queuedAsk {
+	return q.remove(func(it queuedAsk) bool {
+		return it.Provider == t.Provider && it.Request.WorkDir == t.WorkDir && it.due(now)
+	})
+}
+
+// Drop removes and returns the ask with reqID.
+func (q *askQueue) Drop(reqID string) []queuedAsk {
+	return q.remove(func(it queuedAsk) bool { return it.Request.ReqID == reqID })
+}
+
+// Expire removes and returns the asks whose TTL ran out before now.
+func (q *askQueue) Expire(now time.Time) []queuedAsk {
+	return q.remove(func(it queuedAsk) bool { return it.expired(now) })
+}
+
+// remove deletes the matching asks, persisting the queue if any matched.
+func (q *askQueue) remove(match func(queuedAsk) bool) []queuedAsk {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var taken, kept []queuedAsk
+	for _, it := range q.items {
+		if match(it) {
+			taken = append(taken, it)
+		} else {
+			kept = append(kept, it)
+		}
+	}
+	if len(taken) > 0 {
+		q.items = kept
+		q.saveLocked()
+	}
+	return taken
+}
+
+func (q *askQueue) saveLocked() error {
+	if q.path == "" {
+		return nil
+	}
+	if len(q.items) == 0 {
+		err := os.Remove(q.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(q.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(q.path), 0755)
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// providerOnline reports whether the provider has a live pane for workDir.
+func (s *Server) providerOnline(a adapter.Adapter, workDir string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := a.EnsurePane(ctx, workDir)
+	return err == nil
+}
+
+// queueItem decides whether an ask goes to the queue instead of being
+// sent now: it is scheduled for later (deliver_at), or queueing was asked
+// for (queue, ttl_s) and the provider is offline or paused. The TTL counts from when
+// the ask becomes due.
+func (s *Server) queueItem(req *protocol.AskRequest, provider string, a adapter.Adapter, provReq *adapter.ProviderRequest) (queuedAsk, bool) {
+	now := time.Now()
+	item := queuedAsk{Provider: provider, Request: provReq, QueuedAt: now}
+	if req.DeliverAt != "" {
+		if t, err := time.Parse(time.RFC3339, req.DeliverAt); err == nil && t.After(now) {
+			item.DeliverAt = t
+		}
+	}
+	ttl := req.TTLS
+	if ttl > 0 {
+		from := now
+		if !item.DeliverAt.IsZero() {
+			from = item.DeliverAt
+		}
+		item.ExpiresAt = from.Add(time.Duration(ttl * float64(time.Second)))
+	}
+
+	if !item.DeliverAt.IsZero() {
+		return item, true
+	}
+	if req.Queue || ttl > 0 {
+		if _, paused := s.paused.Get(provider); paused || !s.providerOnline(a, provReq.WorkDir) {
+			return item, true
+		}
+	}
+	return queuedAsk{}, false
+}
+
+// enqueue holds an ask and acknowledges it.
+func (s *Server) enqueue(conn net.Conn, item queuedAsk) {
+	if err := s.queue.Add(item); err != nil {
+		s.log("queue: persist failed: %v", err)
+	}
+	when := "provider offline"
+	if _, paused := s.paused.Get(item.Provider); paused {
+		when = "provider paused"
+	}
+	if !item.DeliverAt.IsZero() {
+		when = "scheduled for " + item.DeliverAt.Format(time.RFC3339)
+	}
+	s.logger.Info("queue: held", "reason", when, "provider", item.Provider, "req_id", item.Request.ReqID, "queued", s.queue.Len())
+	ack := &adapter.ProviderResult{ReqID: item.Request.ReqID, Queued: true}
+	s.auditAsk(item.Provider, item.Request, ack, item.QueuedAt, false)
+	s.sendJSON(conn, ack)
+}
+
+// queueMonitor delivers queued asks once their provider comes back.
+func (s *Server) queueMonitor() {
+	ticker := time.NewTicker(queueCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+			s.deliverQueued()
+		}
+	}
+}
+
+// deliverQueued drops expired asks, then sends the due asks of every
+// provider that is online and not paused. Each pane's asks go out in order in their own
+// goroutine.
+func (s *Server) deliverQueued() {
+	now := time.Now()
+	for _, it := range s.queue.Expire(now) {
+		s.logger.Info("queue: expired unsent", "provider", it.Provider, "req_id", it.Request.ReqID, "queued_at", it.QueuedAt)
+		s.auditAsk(it.Provider, it.Request, &adapter.ProviderResult{ReqID: it.Request.ReqID, ExitCode: output.ExitTimeout, Error: "expired unsent"}, it.QueuedAt, true)
+		if err := notify.Send(fmt.Sprintf("ccb: queued ask to %s expired", it.Provider), firstLine(it.Request.Message)); err != nil {
+			s.log("queue: notify: %v", err)
+		}
+	}
+
+	for _, t := range s.queue.Targets(now) {
+		a, ok := s.registry.Get(t.Provider)
+		if _, paused := s.paused.Get(t.Provider); paused {
+			continue
+		}
+		if !ok || !s.providerOnline(a, t.WorkDir) {
+			continue
+		}
+		items := s.queue.Take(t, now)
+		go func(items []queuedAsk) {
+			for _, it := range items {
+				s.deliver(a, it)
+			}
+		}(items)
+	}
+}
+
+// deliver sends one queued ask, keeps its result and notifies the user.
+func (s *Server) deliver(a adapter.Adapter, it queuedAsk) {
+	if !s.asks.enter() {
+		// Shutting down: keep it queued for the next daemon.
+		if err := s.queue.Add(it); err != nil {
+			s.log("queue: %v", err)
+		}
+		return
+	}
+	defer s.asks.leave()
+	s.logger.Info("queue: delivering", "provider", it.Provider, "req_id", it.Request.ReqID, "waited", time.Since(it.QueuedAt).Round(time.Second))
+	started := time.Now()
+	result := s.execute(context.Background(), it.Provider, a, it.Request)
+	s.writeOutput(it.Request, result)
+	s.keepResult(it.Provider, it.Request, result)
+	s.recordHistory(it.Provider, it.Request, result, started, true)
+	s.auditAsk(it.Provider, it.Request, result, started, true)
+	s.touchActivity(it.Provider)
+
+	title := fmt.Sprintf("ccb: %s replied", it.Provider)
+	body := firstLine(result.Reply)
+	if result.ExitCode != 0 {
+		title = fmt.Sprintf("ccb: queued ask to %s failed", it.Provider)
+		body = result.Error
+	}
+	if err := notify.Send(title, body); err != nil {
+		s.log("queue: notify: %v", err)
+	}
+}
+
+// firstLine returns the first non-blank line of text, shortened for a
+// notification body.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if r := []rune(line); len(r) > 120 {
+				return string(r[:120]) + "..."
+			}
+			return line
+		}
+	}
+	return "(empty reply)"
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+)
+
+// rateWindow is the span rate limits are counted over.
+const rateWindow = time.Minute
+
+// RateLimits caps asks per minute; zero or missing entries are unlimited.
+type RateLimits struct {
+	Client   int            // per client_id (asks without one share a bucket)
+	Provider map[string]int // per provider, from all clients together
+}
+
+// rateLimiter counts recent asks per bucket over a sliding window.
+type rateLimiter struct {
+	limits RateLimits
+	mu     sync.Mutex
+	seen   map[string][]time.Time // bucket -> ask times within the window, oldest first
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{limits: limits, seen: make(map[string][]time.Time)}
+}
+
+// setLimits replaces the limits; asks already counted still count.
+func (l *rateLimiter) setLimits(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// setProviderLimit replaces provider's limit, 0 for none.
+func (l *rateLimiter) setProviderLimit(provider string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Copied, as the map may be shared with the Settings it came from.
+	limits := make(map[string]int, len(l.limits.Provider)+1)
+	for p, v := range l.limits.Provider {
+		limits[p] = v
+	}
+	if n > 0 {
+		limits[provider] = n
+	} else {
+		delete(limits, provider)
+	}
+	l.limits.Provider = limits
+}
+
+// rateBucket is one limit an ask counts against.
+type rateBucket struct {
+	key   string
+	what  string // for the error, e.g. "client cli-123"
+	limit int
+}
+
+// allow records an ask from clientID to provider at now, unless a bucket
+// is full; then it returns the bucket and how long until it has room.
+func (l *rateLimiter) allow(clientID, provider string, now time.Time) (rateBucket, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var buckets []rateBucket
+	if n := l.limits.Client; n > 0 {
+		buckets = append(buckets, rateBucket{"client\x00" + clientID, "client " + clientID, n})
+	}
+	if n := l.limits.Provider[provider]; n > 0 {
+		buckets = append(buckets, rateBucket{"provider\x00" + provider, provider, n})
+	}
+	if len(buckets) == 0 {
+		return rateBucket{}, 0, true
+	}
+
+	cutoff := now.Add(-rateWindow)
+	for _, b := range buckets {
+		times := l.seen[b.key]
+		i := 0
+		for i < len(times) && !times[i].After(cutoff) {
+			i++
+		}
+		times = times[i:]
+		l.seen[b.key] = times
+		if len(times) >= b.limit {
+			return b, times[len(times)-b.limit].Add(rateWindow).Sub(now), false
+		}
+	}
+	for _, b := range buckets {
+		l.seen[b.key] = append(l.seen[b.key], now)
+	}
+	return rateBucket{}, 0, true
+}
+
+// rateLimited returns the result refusing req because bucket b is full.
+func rateLimited(req *adapter.ProviderRequest, b rateBucket, retryAfter time.Duration) *adapter.ProviderResult {
+	if b.what == "client " {
+		b.what = "clients without a client_id"
+	}
+	return &adapter.ProviderResult{
+		ReqID:        req.ReqID,
+		ExitCode:     output.ExitRateLimit,
+		RetryAfterMs: retryAfter.Milliseconds(),
+		Error: fmt.Sprintf("RATE_LIMITED: %s is over its limit of %d asks per minute; retry in %s",
+			b.what, b.limit, (retryAfter + time.Second - 1).Truncate(time.Second)),
+	}
+}
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
@@ -26,6 +27,28 @@ func (r *Registry) Register(name string, a adapter.Adapter) {
 	r.adapters[name] = a
 }
 
+// Add registers a for a provider name unless it already has an adapter,
+// reporting whether it did.
+func (r *Registry) Add(name string, a adapter.Adapter) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.adapters[name]; ok {
+		return false
+	}
+	r.adapters[name] = a
+	return true
+}
+
+// Unregister removes the adapter for a provider name, reporting whether
+// there was one.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.adapters[name]
+	delete(r.adapters, name)
+	return ok
+}
+
 // Get returns the adapter for a provider name.
 func (r *Registry) Get(name string) (adapter.Adapter, bool) {
 	r.mu.RLock()
@@ -34,7 +57,7 @@ func (r *Registry) Get(name string) (adapter.Adapter, bool) {
 	return a, ok
 }
 
-// Names returns all registered provider names.
+// Names returns all registered provider names, sorted.
 func (r *Registry) Names() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -42,6 +65,7 @@ func (r *Registry) Names() []string {
 	for name := range r.adapters {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// Settings are the parts of the daemon's configuration that a reload
+// (SIGHUP or the reload method) re-reads. Ask and startup timeouts need no
+// reload: they are read from ccb.config for every ask.
+type Settings struct {
+	Providers   []string
+	Concurrency map[string]int // provider -> max asks running at once
+	RateLimits  RateLimits
+	Drain       time.Duration // how long a stopping daemon waits for asks in flight
+	Idle        IdleTimeouts  // how long the daemon stays up for each provider
+}
+
+// LoadSettings reads Settings for a daemon started in workDir. Limits are
+// read for every provider ccb knows, so one registered later (see
+// handleRegisterProvider) gets its own.
+func LoadSettings(workDir string) Settings {
+	st := Settings{
+		Providers:   LoadStartConfig(workDir).GetProviders(),
+		Concurrency: make(map[string]int),
+		RateLimits:  RateLimits{Client: config.ClientRateLimit(workDir), Provider: make(map[string]int)},
+		Drain:       time.Duration(config.DrainTimeout(workDir) * float64(time.Second)),
+		Idle:        make(IdleTimeouts),
+	}
+	for _, p := range config.KnownProviders() {
+		st.Idle[p] = time.Duration(config.IdleTimeout(workDir, p)) * time.Second
+		if n := config.MaxConcurrency(workDir, p); n > 0 {
+			st.Concurrency[p] = n
+		}
+		if n := config.ProviderRateLimit(workDir, p); n > 0 {
+			st.RateLimits.Provider[p] = n
+		}
+	}
+	return st
+}
+
+// reload re-reads Settings and applies them without dropping the listener
+// or what the daemon holds in memory (results for pend, jobs, queued
+// asks). Providers that stay keep their adapters; removed ones stop taking
+// asks, though their asks in flight finish.
+func (s *Server) reload() (*protocol.ReloadResponse, error) {
+	if s.reloadFn == nil {
+		return nil, fmt.Errorf("this daemon cannot reload its configuration")
+	}
+	st := s.reloadFn()
+	resp := &protocol.ReloadResponse{Status: "ok"}
+
+	before := s.registry.Names()
+	want := make(map[string]bool, len(st.Providers))
+	for _, p := range st.Providers {
+		want[p] = true
+		if _, ok := s.registry.Get(p); ok {
+			continue
+		}
+		a, ok := s.newAdapter(p, "")
+		if !ok {
+			s.logger.Warn("reload: unknown provider", "provider", p)
+			continue
+		}
+		s.registry.Register(p, a)
+		resp.Added = append(resp.Added, p)
+	}
+	for _, p := range before {
+		if !want[p] {
+			s.registry.Unregister(p)
+			resp.Removed = append(resp.Removed, p)
+		}
+	}
+	resp.Providers = s.registry.Names()
+	s.providersChanged(resp.Added, resp.Removed)
+
+	for _, p := range append(before, resp.Added...) {
+		s.workerPool.SetLimit(p, st.Concurrency[p])
+	}
+	s.rateLimit.setLimits(st.RateLimits)
+	s.setIdle(st.Idle)
+	if st.Drain > 0 {
+		s.mu.Lock()
+		s.drainLimit = st.Drain
+		s.mu.Unlock()
+	}
+
+	s.logger.Info("reload: configuration re-read", "providers", strings.Join(resp.Providers, ","),
+		"added", strings.Join(resp.Added, ","), "removed", strings.Join(resp.Removed, ","))
+	return resp, nil
+}
+
+// handleReload handles a reload request.
+func (s *Server) handleReload(conn net.Conn) {
+	resp, err := s.reload()
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	s.sendJSON(conn, resp)
+}
+
+// handleRegisterProvider handles a register_provider request. A custom
+// provider is looked up in the ccb.config of the request's work_dir; a
+// later reload drops the provider again unless ccb.config lists it.
+func (s *Server) handleRegisterProvider(conn net.Conn, req *protocol.RegisterProviderRequest) {
+	changed, err := s.addProvider(req.Provider, req.WorkDir)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	s.sendJSON(conn, protocol.RegistrationResponse{Status: "ok", Provider: req.Provider, Changed: changed, Providers: s.registry.Names()})
+}
+
+// addProvider registers provider, built in or declared in workDir's
+// ccb.config, reporting whether it was not registered already. The
+// provider gets the concurrency cap and rate limit ccb.config sets for it
+// and a preflight check of its storage.
+func (s *Server) addProvider(provider, workDir string) (bool, error) {
+	if _, ok := s.registry.Get(provider); ok {
+		return false, nil
+	}
+	if s.newAdapter == nil {
+		return false, fmt.Errorf("this daemon cannot register providers")
+	}
+	a, ok := s.newAdapter(provider, workDir)
+	if !ok {
+		return false, fmt.Errorf("unknown provider: %s", provider)
+	}
+	if !s.registry.Add(provider, a) {
+		return false, nil
+	}
+	if s.reloadFn != nil {
+		st := s.reloadFn()
+		s.workerPool.SetLimit(provider, st.Concurrency[provider])
+		s.rateLimit.setProviderLimit(provider, st.RateLimits.Provider[provider])
+	}
+	if s.storageRoot != nil {
+		s.addStorageChecks(Preflight([]string{provider}, func(p string) string { return s.storageRoot(p, workDir) }))
+	}
+	s.logger.Info("registry: provider registered", "provider", provider, "work_dir", workDir)
+	s.providersChanged([]string{provider}, nil)
+	return true, nil
+}
+
+// adapterFor returns provider's adapter. A custom provider that is not
+// registered yet but is declared in workDir's ccb.config is registered
+// first, so a daemon started in another project serves it too.
+func (s *Server) adapterFor(provider, workDir string) (adapter.Adapter, bool) {
+	if a, ok := s.registry.Get(provider); ok {
+		return a, true
+	}
+	if _, ok := config.LookupCustomProvider(workDir, provider); !ok || workDir == "" {
+		return nil, false
+	}
+	if _, err := s.addProvider(provider, workDir); err != nil {
+		s.logger.Warn("registry: cannot register custom provider", "provider", provider, "work_dir", workDir, "error", err)
+	}
+	return s.registry.Get(provider)
+}
+
+// storageChecks returns the preflight checks of the providers' storage.
+func (s *Server) storageChecks() []protocol.StorageCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]protocol.StorageCheck(nil), s.storage...)
+}
+
+// addStorageChecks records checks, replacing earlier ones for the same
+// providers, and logs the failed ones.
+func (s *Server) addStorageChecks(checks []protocol.StorageCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range checks {
+		if !c.OK {
+			s.logger.Warn("preflight: storage "+c.Problem, "provider", c.Provider, "path", c.Path, "hint", c.Hint)
+		}
+		kept := s.storage[:0]
+		for _, old := range s.storage {
+			if old.Provider != c.Provider {
+				kept = append(kept, old)
+			}
+		}
+		s.storage = append(kept, c)
+	}
+}
+
+// handleUnregisterProvider handles an unregister_provider request. Asks to
+// the provider already in flight finish; queued ones wait until it is
+// registered again.
+func (s *Server) handleUnregisterProvider(conn net.Conn, req *protocol.UnregisterProviderRequest) {
+	provider := req.Provider
+	changed := s.registry.Unregister(provider)
+	if changed {
+		s.logger.Info("registry: provider unregistered", "provider", provider)
+		s.providersChanged(nil, []string{provider})
+	}
+	s.sendJSON(conn, protocol.RegistrationResponse{Status: "ok", Provider: provider, Changed: changed, Providers: s.registry.Names()})
+}
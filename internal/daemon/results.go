@@ -0,0 +1,345 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/audit"
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/history"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/recording"
+	"github.com/anthropics/claude_code_bridge/internal/replies"
+	"github.com/anthropics/claude_code_bridge/internal/scratch"
+)
+
+// defaultResultCacheSize bounds how many completed results are kept for
+// req_id lookups.
+const defaultResultCacheSize = 200
+
+// resultCache keeps the most recent completed results keyed by req_id.
+type resultCache struct {
+	mu    sync.Mutex
+	max   int
+	order []string
+	byID  map[string]cachedResult
+}
+
+// cachedResult is a completed result and the provider that produced it.
+type cachedResult struct {
+	Provider string
+	Result   *adapter.ProviderResult
+}
+
+func newResultCache(max int) *resultCache {
+	return &resultCache{max: max, byID: make(map[string]cachedResult)}
+}
+
+// Put records a result, evicting the oldest entry when full.
+func (c *resultCache) Put(provider string, r *adapter.ProviderResult) {
+	if r == nil || r.ReqID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.byID[r.ReqID]; !exists {
+		c.order = append(c.order, r.ReqID)
+	}
+	c.byID[r.ReqID] = cachedResult{Provider: provider, Result: r}
+	for len(c.order) > c.max {
+		delete(c.byID, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// Get looks up a result by req_id.
+func (c *resultCache) Get(reqID string) (cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.byID[reqID]
+	return r, ok
+}
+
+// resultConn stands in for a client connection when the daemon runs an
+// ask on its own behalf (ask_async jobs, broadcast): the ask's final
+// response goes to done instead of being written, and chunks are dropped.
+type resultConn struct {
+	net.Conn
+	reqID string // for error responses, which carry no req_id
+	done  func(r *adapter.ProviderResult)
+	ctx   context.Context // the ask's parent; nil outlives the client (jobs)
+}
+
+// Context is the ask's parent context (see contextOf).
+func (c *resultConn) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c *resultConn) Write(p []byte) (int, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		return 0, err
+	}
+	if _, ok := m["event"]; ok {
+		return len(p), nil
+	}
+	r := &adapter.ProviderResult{ReqID: c.reqID}
+	if m["status"] == "error" {
+		r.ExitCode = output.ExitError
+		r.Error, _ = m["error"].(string)
+	} else if err := json.Unmarshal(p, r); err != nil {
+		return 0, err
+	}
+	c.done(r)
+	return len(p), nil
+}
+
+// Peer reports the client's peer to the authenticators.
+func (c *resultConn) Peer() auth.Peer {
+	return auth.PeerOf(c.Conn)
+}
+
+// keepResult caches a completed result for req_id lookups and adds it to
+// the provider's reply store, which outlives the daemon.
+func (s *Server) keepResult(provider string, req *adapter.ProviderRequest, r *adapter.ProviderResult) {
+	s.results.Put(provider, r)
+	if s.replyDir == "" || r == nil || r.Queued {
+		return
+	}
+	e := replies.Entry{Time: time.Now(), Provider: provider, WorkDir: req.WorkDir, Result: r}
+	if err := replies.Append(s.replyDir, e, replies.Max()); err != nil {
+		s.log("replies: %v", err)
+	}
+}
+
+// lookupResult finds a completed result by req_id, in memory first, then
+// in the reply store.
+func (s *Server) lookupResult(reqID string) (cachedResult, bool) {
+	if r, ok := s.results.Get(reqID); ok {
+		return r, true
+	}
+	if s.replyDir == "" {
+		return cachedResult{}, false
+	}
+	e, ok, err := replies.Find(s.replyDir, reqID)
+	if err != nil {
+		s.log("replies: %v", err)
+	}
+	if !ok {
+		return cachedResult{}, false
+	}
+	return cachedResult{Provider: e.Provider, Result: e.Result}, true
+}
+
+// storedReply returns provider's (or, if provider is empty, any
+// provider's) nth most recent answered result from the store.
+func (s *Server) storedReply(provider string, n int) (replies.Entry, bool) {
+	if s.replyDir == "" {
+		return replies.Entry{}, false
+	}
+	e, ok, err := replies.Latest(s.replyDir, provider, n)
+	if err != nil {
+		s.log("replies: %v", err)
+	}
+	return e, ok
+}
+
+// recordHistory appends a finished ask to the project's history file.
+func (s *Server) recordHistory(provider string, req *adapter.ProviderRequest, r *adapter.ProviderResult, started time.Time, queued bool) {
+	if s.historyDir == "" || r == nil || !history.Enabled() {
+		return
+	}
+	err := history.Append(s.historyDir, history.Entry{
+		Time:       started,
+		ReqID:      r.ReqID,
+		Provider:   provider,
+		WorkDir:    req.WorkDir,
+		Caller:     req.Caller,
+		Prompt:     req.Message,
+		Reply:      r.Reply,
+		ExitCode:   r.ExitCode,
+		Error:      r.Error,
+		DurationMs: time.Since(started).Milliseconds(),
+		Queued:     queued,
+	})
+	if err != nil {
+		s.log("history: %v", err)
+	}
+}
+
+// auditAsk appends how an ask ended to the audit log, when auditing is on.
+// The prompt is hashed unless full prompts are audited.
+func (s *Server) auditAsk(provider string, req *adapter.ProviderRequest, r *adapter.ProviderResult, received time.Time, fromQueue bool) {
+	if s.auditFile == "" || r == nil {
+		return
+	}
+	e := audit.Entry{
+		Time:         received,
+		ReqID:        req.ReqID,
+		Provider:     provider,
+		WorkDir:      req.WorkDir,
+		Caller:       req.Caller,
+		ClientID:     req.ClientID,
+		PromptSHA256: audit.HashPrompt(req.Message),
+		ExitCode:     r.ExitCode,
+		Error:        r.Error,
+		DurationMs:   time.Since(received).Milliseconds(),
+		Queued:       r.Queued,
+		FromQueue:    fromQueue,
+	}
+	if s.auditFull {
+		e.Prompt = req.Message
+	}
+	if err := audit.Append(s.auditFile, e); err != nil {
+		s.log("audit: %v", err)
+	}
+}
+
+// startRecording opens the pane recording for req when it asks for one or
+// CCB_RECORD is set, and hands it to the adapter via req.Recorder.
+func (s *Server) startRecording(provider string, req *adapter.ProviderRequest) *recording.Recorder {
+	if s.recordDir == "" || !(req.Record || recording.All()) {
+		return nil
+	}
+	recording.Prune(s.recordDir, recording.MaxRecordings-1)
+	rec, err := recording.Create(s.recordDir, req.ReqID, fmt.Sprintf("ccb %s %s", provider, req.ReqID))
+	if err != nil {
+		s.log("recording: %v", err)
+		return nil
+	}
+	req.Recorder = rec
+	return rec
+}
+
+// finishRecording marks how the request ended, closes the recording and
+// points the result at it.
+func (s *Server) finishRecording(rec *recording.Recorder, r *adapter.ProviderResult) {
+	if rec == nil {
+		return
+	}
+	label := fmt.Sprintf("exit %d", r.ExitCode)
+	if r.Error != "" {
+		label += ": " + r.Error
+	}
+	rec.Mark(label)
+	if err := rec.Close(); err != nil {
+		s.log("recording: %v", err)
+	}
+	r.Recording = rec.Path()
+}
+
+// startScratch sweeps expired scratch dirs, sparing running requests, and
+// creates req's own, handing it to the adapter via req.ScratchDir.
+func (s *Server) startScratch(req *adapter.ProviderRequest) {
+	if s.scratchDir == "" {
+		return
+	}
+	if removed, err := scratch.GC(s.scratchDir, scratch.TTL(), time.Now(), s.inflight.has); err != nil {
+		s.log("scratch: %v", err)
+	} else if len(removed) > 0 {
+		s.debugf("scratch: removed %d expired dir(s)", len(removed))
+	}
+	dir, err := scratch.Create(s.scratchDir, req.ReqID)
+	if err != nil {
+		s.log("scratch: %v", err)
+		return
+	}
+	req.ScratchDir = dir
+}
+
+// finishScratch removes req's scratch dir after a success. A failed
+// request's dir is kept until it expires so its payloads can be inspected.
+func (s *Server) finishScratch(req *adapter.ProviderRequest, r *adapter.ProviderResult) {
+	if req.ScratchDir == "" || r.ExitCode != output.ExitOK {
+		return
+	}
+	if err := scratch.Remove(s.scratchDir, req.ReqID); err != nil {
+		s.log("scratch: %v", err)
+	}
+}
+
+// writeOutput saves a successful reply to req.OutputPath (see
+// outputTarget). On failure the result keeps its reply but is marked
+// failed so the client does not assume the file exists.
+func (s *Server) writeOutput(req *adapter.ProviderRequest, r *adapter.ProviderResult) {
+	if req.OutputPath == "" || r == nil || r.ExitCode != 0 || r.Queued {
+		return
+	}
+	path, err := outputTarget(req.WorkDir, req.OutputPath)
+	if err == nil {
+		err = output.AtomicWriteText(path, r.Reply)
+	}
+	if err != nil {
+		s.logger.Info("output: "+err.Error(), "req_id", r.ReqID)
+		r.ExitCode = output.ExitError
+		r.Error = fmt.Sprintf("cannot write reply to %s: %v", path, err)
+		return
+	}
+	r.OutputPath = path
+}
+
+// outputTarget resolves an ask's output_path against its work dir and
+// rejects a path that lands outside it, through symlinks included.
+func outputTarget(workDir, path string) (string, error) {
+	if workDir == "" {
+		return "", fmt.Errorf("output_path needs a work_dir")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	path = filepath.Clean(path)
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", err
+	}
+	// The nearest existing directory decides where the file really lands.
+	dir, rest := filepath.Dir(path), ""
+	for {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			dir = filepath.Join(real, rest)
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+	if rel, err := filepath.Rel(root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("output_path %s is outside work_dir %s", path, workDir)
+	}
+	return path, nil
+}
+
+// localClient reports whether conn comes from this machine over a plain
+// transport: loopback TCP without TLS, or the named pipe. Only such
+// clients may have the daemon write files for them; the HTTP gateway and
+// WebSocket never count, whatever address they listen on.
+func localClient(conn net.Conn) bool {
+	switch conn.(type) {
+	case *httpConn, *wsConn:
+		return false
+	}
+	peer := auth.PeerOf(conn)
+	if peer.TLS != nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(peer.Addr)
+	if err != nil {
+		return true // a named pipe, which rejects remote clients
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+)
+
+// routeTable maps projects to the panes and logs of their providers, as
+// the launcher records them in the pane registry, so one daemon can serve
+// asks from several repos at once without the session loaders, which
+// only look at an ask's work dir, picking another project's pane or log.
+type routeTable struct {
+	path string // pane registry file (session.RegistryPath)
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	routes  map[string]map[string]adapter.Route // project ID -> provider -> route
+}
+
+func newRouteTable(path string) *routeTable {
+	return &routeTable{path: path, routes: make(map[string]map[string]adapter.Route)}
+}
+
+// lookup returns provider's route for the project workDir belongs to, or
+// nil if the registry has none. The registry is re-read when it changed.
+func (t *routeTable) lookup(provider, workDir string) *adapter.Route {
+	if t == nil || t.path == "" {
+		return nil
+	}
+	projectID := config.ComputeCCBProjectID(workDir)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	r, ok := t.routes[projectID][provider]
+	if !ok {
+		return nil
+	}
+	return &r
+}
+
+// projects returns the number of projects with at least one route.
+func (t *routeTable) projects() int {
+	if t == nil || t.path == "" {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	return len(t.routes)
+}
+
+// refreshLocked rebuilds the table from the registry if the file changed
+// since it was last read. t.mu must be held.
+func (t *routeTable) refreshLocked() {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		t.routes = make(map[string]map[string]adapter.Route)
+		t.modTime, t.size = time.Time{}, 0
+		return
+	}
+	if info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return
+	}
+	t.modTime, t.size = info.ModTime(), info.Size()
+
+	routes := make(map[string]map[string]adapter.Route)
+	for provider, projects := range session.NewPaneRegistry(t.path).AllEntries() {
+		for projectID, e := range projects {
+			if e == nil || e.PaneID == "" {
+				continue
+			}
+			if routes[projectID] == nil {
+				routes[projectID] = make(map[string]adapter.Route)
+			}
+			routes[projectID][provider] = adapter.Route{ProjectID: projectID, WorkDir: e.WorkDir, PaneID: e.PaneID, LogPath: e.SessionPath}
+		}
+	}
+	t.routes = routes
+}
@@ -2,31 +2,82 @@ package daemon
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/config"
 	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/npipe"
+	"github.com/anthropics/claude_code_bridge/internal/output"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
 	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/schema"
 )
 
 // Server implements a TCP JSON-RPC server for the unified ask daemon.
 type Server struct {
 	listener    net.Listener
-	token       string
+	httpAddr    string
+	httpServer  *http.Server
+	grpcAddr    string
+	grpcServer  *grpc.Server
+	metricsAddr string
+	metricsSrv  *http.Server
+	metrics     *serverMetrics
+	tokens      *auth.Tokens
+	auth        auth.Authenticator
 	registry    *Registry
 	workerPool  *WorkerPool
+	results     *resultCache
+	jobs        *jobTable
+	queue       *askQueue
+	paused      *pauseSet
+	inflight    *inflightSet
+	asks        *askGate
+	rateLimit   *rateLimiter
+	events      *eventHub
+	liveness    *livenessCache
+	routes      *routeTable
+	historyDir  string
+	recordDir   string
+	scratchDir  string
+	replyDir    string
+	auditFile   string
+	auditFull   bool
+	pipe        string
+	socket      string
+	tls         *tls.Config
+	storage     []protocol.StorageCheck
 	mu          sync.Mutex
-	lastActive  time.Time
+	lastActive  time.Time            // last request not naming a provider
+	lastUsed    map[string]time.Time // provider -> last request naming it
 	idleTimeout time.Duration
+	idle        IdleTimeouts
+	drainLimit  time.Duration
 	stateFile   string
-	logFile     string
+	state       DaemonState // as last written to stateFile
 	parentPID   int
+	traceRPC    bool
+	reloadFn    func() Settings
+	newAdapter  func(provider, workDir string) (adapter.Adapter, bool)
+	storageRoot func(provider, workDir string) string
+	logger      *slog.Logger
 	shutdown    chan struct{}
 	done        chan struct{}
+	drained     chan struct{}
 }
 
 // ServerConfig holds configuration for the daemon server.
@@ -34,18 +85,71 @@ type ServerConfig struct {
 	Host        string
 	Port        int
 	Token       string
+	Auth        auth.Authenticator // nil accepts Tokens only
+	Tokens      *auth.Tokens       // the shared token and those rotated out; nil makes one of Token
 	StateFile   string
 	LogFile     string
-	IdleTimeout time.Duration
-	ParentPID   int
+	LogFormat   string                  // config.LogFormatText (default) or config.LogFormatJSON
+	QueueFile   string                  // offline queue; empty keeps it in memory only
+	PauseFile   string                  // paused providers; empty keeps them in memory only
+	RoutesFile  string                  // pane registry (session.RegistryPath) routing asks by project; empty leaves it to the session loaders
+	HistoryDir  string                  // ask history (history package); empty records nothing
+	RecordDir   string                  // pane recordings (recording package); empty records nothing
+	ScratchDir  string                  // per-request scratch dirs (scratch package); empty disables them
+	ReplyDir    string                  // completed results for pend (replies package); empty keeps them in memory only
+	AuditFile   string                  // append-only audit log (audit package); empty audits nothing
+	AuditFull   bool                    // audit prompts in full rather than their hashes
+	Pipe        string                  // listen on this Windows named pipe instead of TCP
+	Socket      string                  // listen on this unix socket instead of TCP
+	TLS         *tls.Config             // serve TCP connections over TLS (see the certs package)
+	HTTPAddr    string                  // also serve the HTTP+JSON gateway on this host:port
+	GRPCAddr    string                  // also serve the gRPC service (bridgepb) on this host:port
+	MetricsAddr string                  // also serve /metrics, without a token, on this host:port
+	Storage     []protocol.StorageCheck // startup preflight, reported by status
+	IdleTimeout time.Duration           // 0 means 30 minutes; negative never shuts down for idleness
+	Idle        IdleTimeouts            // providers' own idle timeouts, overriding IdleTimeout
+	Drain       time.Duration           // how long Shutdown lets asks in flight finish; 0 means 30s
+	Concurrency map[string]int          // provider -> max asks running at once (WorkerPool.SetLimit)
+	RateLimits  RateLimits              // asks per minute per client_id and per provider
+	ParentPID   int                     // shut down once this process exits; 0 watches none
+	TraceRPC    bool                    // log every request with its outcome and timings
+	LogMirror   io.Writer               // log lines are also written here (foreground mode)
+
+	// Reload, if set, re-reads Settings for the reload method and SIGHUP;
+	// NewAdapter builds adapters for the providers a reload, a
+	// register_provider request or an ask adds. workDir is the project
+	// that asks for the provider, empty for the daemon's own.
+	// StorageRoot, if set, locates their session logs for the preflight
+	// check (see Preflight).
+	Reload      func() Settings
+	NewAdapter  func(provider, workDir string) (adapter.Adapter, bool)
+	StorageRoot func(provider, workDir string) string
 }
 
 // DaemonState represents the persisted daemon state.
 type DaemonState struct {
-	Host  string `json:"host"`
-	Port  int    `json:"port"`
-	Token string `json:"token"`
-	PID   int    `json:"pid"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Pipe    string `json:"pipe,omitempty"`    // set when the daemon listens on a named pipe instead
+	Socket  string `json:"socket,omitempty"`  // set when the daemon listens on a unix socket instead
+	TLS     bool   `json:"tls,omitempty"`     // connections need TLS with a client certificate
+	HTTP    string `json:"http,omitempty"`    // address of the HTTP gateway, if enabled
+	GRPC    string `json:"grpc,omitempty"`    // address of the gRPC service, if enabled
+	Metrics string `json:"metrics,omitempty"` // address of the /metrics listener, if enabled
+	Token   string `json:"token"`
+	PID     int    `json:"pid"`
+}
+
+// Address is where clients reach the daemon: its pipe, socket or
+// host:port.
+func (st *DaemonState) Address() string {
+	if st.Pipe != "" {
+		return st.Pipe
+	}
+	if st.Socket != "" {
+		return st.Socket
+	}
+	return fmt.Sprintf("%s:%d", st.Host, st.Port)
 }
 
 // NewServer creates a new daemon server.
@@ -56,49 +160,182 @@ func NewServer(cfg ServerConfig, registry *Registry) *Server {
 	if cfg.IdleTimeout == 0 {
 		cfg.IdleTimeout = 30 * time.Minute
 	}
+	if cfg.Drain <= 0 {
+		cfg.Drain = config.DefaultDrainTimeoutS * time.Second
+	}
 	if cfg.Token == "" {
 		cfg.Token = runtime.RandomToken()
 	}
+	if cfg.Tokens == nil {
+		cfg.Tokens = auth.NewTokens(cfg.Token)
+	}
+	if cfg.Auth == nil {
+		cfg.Auth = cfg.Tokens
+	}
 
-	return &Server{
-		token:       cfg.Token,
+	s := &Server{
+		tokens:      cfg.Tokens,
+		auth:        cfg.Auth,
 		registry:    registry,
 		workerPool:  NewWorkerPool(50),
+		results:     newResultCache(defaultResultCacheSize),
+		jobs:        newJobTable(defaultJobTableSize),
+		queue:       newAskQueue(cfg.QueueFile),
+		paused:      newPauseSet(cfg.PauseFile),
+		inflight:    newInflightSet(),
+		asks:        newAskGate(),
+		rateLimit:   newRateLimiter(cfg.RateLimits),
+		events:      newEventHub(),
+		liveness:    newLivenessCache(),
+		routes:      newRouteTable(cfg.RoutesFile),
+		historyDir:  cfg.HistoryDir,
+		recordDir:   cfg.RecordDir,
+		scratchDir:  cfg.ScratchDir,
+		replyDir:    cfg.ReplyDir,
+		auditFile:   cfg.AuditFile,
+		auditFull:   cfg.AuditFull,
+		pipe:        cfg.Pipe,
+		socket:      cfg.Socket,
+		tls:         cfg.TLS,
+		httpAddr:    cfg.HTTPAddr,
+		grpcAddr:    cfg.GRPCAddr,
+		metricsAddr: cfg.MetricsAddr,
+		storage:     cfg.Storage,
 		lastActive:  time.Now(),
+		lastUsed:    make(map[string]time.Time),
 		idleTimeout: cfg.IdleTimeout,
+		idle:        cfg.Idle,
+		drainLimit:  cfg.Drain,
 		stateFile:   cfg.StateFile,
-		logFile:     cfg.LogFile,
 		parentPID:   cfg.ParentPID,
+		traceRPC:    cfg.TraceRPC,
+		reloadFn:    cfg.Reload,
+		newAdapter:  cfg.NewAdapter,
+		storageRoot: cfg.StorageRoot,
 		shutdown:    make(chan struct{}),
 		done:        make(chan struct{}),
+		drained:     make(chan struct{}),
+	}
+	s.logger = newLogger(cfg.LogFormat, cfg.LogFile, cfg.LogMirror)
+	for provider, n := range cfg.Concurrency {
+		s.workerPool.SetLimit(provider, n)
 	}
+	s.metrics = newServerMetrics(s)
+	s.workerPool.OnPanic(s.workerPanicked)
+	return s
 }
 
-// Start starts the daemon server.
-func (s *Server) Start(host string, port int) error {
-	addr := fmt.Sprintf("%s:%d", host, port)
-	listener, err := net.Listen("tcp", addr)
+// listenUnix listens on the unix socket at path, replacing a stale socket
+// file (the daemon lock is held, so no live daemon owns it) and making it
+// reachable by the owner only.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
 	if err != nil {
-		// Try with port 0 for auto-assignment
-		listener, err = net.Listen("tcp", fmt.Sprintf("%s:0", host))
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Start starts the daemon server on host:port, or on the configured named
+// pipe or unix socket.
+func (s *Server) Start(host string, port int) error {
+	var listener net.Listener
+	var err error
+	if s.pipe != "" {
+		listener, err = npipe.Listen(s.pipe)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		host, port = "", 0
+	} else if s.socket != "" {
+		listener, err = listenUnix(s.socket)
 		if err != nil {
 			return fmt.Errorf("failed to listen: %w", err)
 		}
+		host, port = "", 0
+	} else {
+		addr := fmt.Sprintf("%s:%d", host, port)
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			// Try with port 0 for auto-assignment
+			listener, err = net.Listen("tcp", fmt.Sprintf("%s:0", host))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+		}
+		// Get actual port
+		port = listener.Addr().(*net.TCPAddr).Port
+		if s.tls != nil {
+			listener = tls.NewListener(listener, s.tls)
+		}
 	}
 	s.listener = listener
 
-	// Get actual port
-	actualAddr := listener.Addr().(*net.TCPAddr)
-	actualPort := actualAddr.Port
+	httpAddr, err := s.startHTTP()
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	grpcAddr, err := s.startGRPC()
+	if err != nil {
+		listener.Close()
+		if s.httpServer != nil {
+			s.httpServer.Close()
+		}
+		return err
+	}
+	metricsAddr, err := s.startMetrics()
+	if err != nil {
+		listener.Close()
+		if s.httpServer != nil {
+			s.httpServer.Close()
+		}
+		if s.grpcServer != nil {
+			s.grpcServer.Stop()
+		}
+		return fmt.Errorf("metrics: %w", err)
+	}
 
 	// Write state file
-	s.writeState(host, actualPort)
+	s.writeState(host, port, httpAddr, grpcAddr, metricsAddr)
 
-	s.log("daemon started on %s:%d (pid=%d)", host, actualPort, os.Getpid())
+	transport := ""
+	if s.tls != nil {
+		transport = " over TLS"
+	}
+	s.log("daemon started on %s%s (pid=%d)", listener.Addr(), transport, os.Getpid())
+	if httpAddr != "" {
+		s.log("http gateway on %s", httpAddr)
+	}
+	if grpcAddr != "" {
+		s.log("grpc on %s", grpcAddr)
+	}
+	if metricsAddr != "" {
+		s.log("metrics on http://%s/metrics", metricsAddr)
+	}
+	for _, c := range s.storageChecks() {
+		if !c.OK {
+			s.logger.Warn("preflight: storage "+c.Problem, "provider", c.Provider, "path", c.Path, "hint", c.Hint)
+		}
+	}
 
 	// Start idle monitor
 	go s.idleMonitor()
 
+	// Start offline queue monitor
+	go s.queueMonitor()
+
+	// Start pane watcher for subscribers
+	go s.paneWatcher()
+
 	// Start parent process monitor
 	if s.parentPID > 0 {
 		go s.parentMonitor()
@@ -128,87 +365,249 @@ func (s *Server) acceptLoop() {
 	}
 }
 
-// handleConn handles a single client connection.
+// connIdleTimeout bounds how long a connection may sit between requests.
+const connIdleTimeout = 5 * time.Minute
+
+// handleConn serves requests on a client connection until the client
+// closes it. One-shot clients send a single request; interactive clients
+// (ccb chat) keep the connection open across many, and may pipeline them
+// (see connRequests).
 func (s *Server) handleConn(conn net.Conn) {
 	defer conn.Close()
-	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+	requests := newConnRequests(conn)
+	defer requests.wg.Wait()
 
 	decoder := json.NewDecoder(conn)
-	var req map[string]interface{}
-	if err := decoder.Decode(&req); err != nil {
-		s.sendError(conn, "invalid request")
-		return
+	var busyUntil time.Time // when the longest ask sent so far times out
+	for {
+		conn.SetDeadline(later(time.Now().Add(connIdleTimeout), busyUntil))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err != io.EOF {
+				s.sendError(requests.conn, "invalid request")
+			}
+			return
+		}
+		// Asks may legitimately run longer than the idle window.
+		busyUntil = later(busyUntil, time.Now().Add(time.Duration(maxTimeout(raw)+30)*time.Second))
+		conn.SetDeadline(later(time.Now().Add(connIdleTimeout), busyUntil))
+		if !s.handleMessage(requests, raw) {
+			return
+		}
 	}
+}
 
-	// Verify token
-	token, _ := req["token"].(string)
-	if token != s.token {
-		s.sendError(conn, "invalid token")
-		return
+// later returns the later of a and b.
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// dispatch handles one request and reports whether the connection should
+// stay open.
+func (s *Server) dispatch(conn net.Conn, req map[string]interface{}) bool {
+	if _, err := s.auth.Authenticate(auth.PeerOf(conn), req); err != nil {
+		s.log("auth: rejected %s: %v", conn.RemoteAddr(), err)
+		s.sendError(conn, err.Error())
+		return false
 	}
 
-	s.touchActivity()
+	s.touchActivity(getStr(req, "provider"))
+
+	if s.traceRPC {
+		tc := &tracedConn{Conn: conn}
+		conn = tc
+		defer s.logRPC(req, tc, time.Now())
+	}
+
+	if err := schema.Validate(req); err != nil {
+		s.sendError(conn, "invalid request: "+err.Error())
+		return true
+	}
+	if path := getStr(req, "output_path"); path != "" {
+		if !localClient(conn) {
+			s.sendError(conn, "invalid request: output_path is only honored for local clients")
+			return true
+		}
+		if _, err := outputTarget(getStr(req, "work_dir"), path); err != nil {
+			s.sendError(conn, "invalid request: "+err.Error())
+			return true
+		}
+	}
 
 	method, _ := req["method"].(string)
 	switch method {
 	case "ping", ".ping":
-		s.handlePing(conn, req)
+		var r protocol.PingRequest
+		if s.decode(conn, req, &r) {
+			s.handlePing(conn, &r)
+		}
 	case "shutdown", ".shutdown":
+		var r protocol.ShutdownRequest
+		if !s.decode(conn, req, &r) {
+			return true
+		}
 		s.handleShutdown(conn)
+		return false
 	case "status", ".status":
-		s.handleStatus(conn)
+		var r protocol.StatusRequest
+		if s.decode(conn, req, &r) {
+			s.handleStatus(conn, &r)
+		}
 	case "request", ".request", "ask":
-		s.handleRequest(conn, req)
+		var r protocol.AskRequest
+		if s.decode(conn, req, &r) {
+			s.handleRequest(conn, &r)
+		}
+	case "broadcast":
+		var r protocol.BroadcastRequest
+		if s.decode(conn, req, &r) {
+			s.handleBroadcast(conn, &r)
+		}
+	case "ask_async":
+		var r protocol.AskRequest
+		if s.decode(conn, req, &r) {
+			s.handleAskAsync(conn, &r)
+		}
+	case "job_status":
+		var r protocol.JobStatusRequest
+		if s.decode(conn, req, &r) {
+			s.handleJobStatus(conn, &r)
+		}
+	case "job_result":
+		var r protocol.JobResultRequest
+		if s.decode(conn, req, &r) {
+			s.handleJobResult(conn, &r)
+		}
+	case "jobs":
+		s.handleJobs(conn)
 	case "pend", ".pend":
-		s.handlePend(conn, req)
+		var r protocol.PendRequest
+		if s.decode(conn, req, &r) {
+			s.handlePend(conn, &r)
+		}
+	case "requests":
+		s.handleRequests(conn)
+	case "cancel":
+		var r protocol.CancelRequest
+		if s.decode(conn, req, &r) {
+			s.handleCancel(conn, &r)
+		}
+	case "pause":
+		var r protocol.PauseRequest
+		if s.decode(conn, req, &r) {
+			s.handlePause(conn, &r)
+		}
+	case "resume":
+		var r protocol.ResumeRequest
+		if s.decode(conn, req, &r) {
+			s.handleResume(conn, &r)
+		}
+	case "reload":
+		s.handleReload(conn)
+	case "register_provider":
+		var r protocol.RegisterProviderRequest
+		if s.decode(conn, req, &r) {
+			s.handleRegisterProvider(conn, &r)
+		}
+	case "unregister_provider":
+		var r protocol.UnregisterProviderRequest
+		if s.decode(conn, req, &r) {
+			s.handleUnregisterProvider(conn, &r)
+		}
+	case "subscribe":
+		var r protocol.SubscribeRequest
+		if s.decode(conn, req, &r) {
+			s.handleSubscribe(conn, &r)
+		}
+		return false
+	case "rotate_token":
+		var r protocol.RotateTokenRequest
+		if s.decode(conn, req, &r) {
+			s.handleRotateToken(conn, &r)
+		}
 	default:
 		s.sendError(conn, fmt.Sprintf("unknown method: %s", method))
 	}
+	return true
 }
 
 // handlePing handles a ping request.
-func (s *Server) handlePing(conn net.Conn, req map[string]interface{}) {
-	provider, _ := req["provider"].(string)
-	if provider != "" {
-		a, ok := s.registry.Get(provider)
+func (s *Server) handlePing(conn net.Conn, req *protocol.PingRequest) {
+	if req.Provider != "" {
+		a, ok := s.registry.Get(req.Provider)
 		if !ok {
-			s.sendJSON(conn, map[string]interface{}{"status": "error", "error": "unknown provider: " + provider})
+			s.sendJSON(conn, protocol.PingResponse{Status: "error", Error: "unknown provider: " + req.Provider})
 			return
 		}
-		sessionID, _ := req["session_id"].(string)
-		if err := a.Ping(context.Background(), sessionID); err != nil {
-			s.sendJSON(conn, map[string]interface{}{"status": "error", "error": err.Error()})
+		if err := a.Ping(context.Background(), req.SessionID); err != nil {
+			s.sendJSON(conn, protocol.PingResponse{Status: "error", Error: err.Error()})
 			return
 		}
 	}
-	s.sendJSON(conn, map[string]interface{}{"status": "ok", "providers": s.registry.Names()})
+	s.sendJSON(conn, protocol.PingResponse{Status: "ok", Providers: s.registry.Names()})
 }
 
 // handleShutdown handles a shutdown request.
 func (s *Server) handleShutdown(conn net.Conn) {
-	s.sendJSON(conn, map[string]interface{}{"status": "ok", "message": "shutting down"})
+	s.sendJSON(conn, protocol.ShutdownResponse{Status: "ok", Message: "shutting down"})
 	go func() {
 		time.Sleep(100 * time.Millisecond)
 		s.Shutdown()
 	}()
 }
 
-// handleStatus handles a status request.
-func (s *Server) handleStatus(conn net.Conn) {
-	s.sendJSON(conn, map[string]interface{}{
-		"status":         "ok",
-		"pid":            os.Getpid(),
-		"providers":      s.registry.Names(),
-		"workers":        s.workerPool.ActiveWorkers(),
-		"active_requests": s.activeRequestCount(),
-	})
+// handleStatus handles a status request. When work_dir is given, the
+// response also reports which providers have a live pane for that project,
+// as last checked (see providerLiveness).
+func (s *Server) handleStatus(conn net.Conn, req *protocol.StatusRequest) {
+	resp := protocol.StatusResponse{
+		Status:         "ok",
+		PID:            os.Getpid(),
+		Providers:      s.registry.Names(),
+		Workers:        s.workerPool.ActiveWorkers(),
+		ActiveRequests: s.activeRequestCount(),
+		Queued:         s.queue.Len(),
+		Projects:       s.routes.projects(),
+		Storage:        s.storageChecks(),
+		Paused:         s.paused.All(),
+	}
+	if req.WorkDir != "" {
+		resp.Online = s.providerLiveness(req.WorkDir)
+	}
+	s.sendJSON(conn, resp)
 }
 
-// handlePend handles a pend request (retrieve latest reply from a provider).
-func (s *Server) handlePend(conn net.Conn, req map[string]interface{}) {
-	provider, _ := req["provider"].(string)
+// handlePend handles a pend request (retrieve the latest or Nth most
+// recent reply from a provider or from any provider, or the reply to a
+// specific req_id).
+func (s *Server) handlePend(conn net.Conn, req *protocol.PendRequest) {
+	if req.ReqID != "" {
+		cached, ok := s.lookupResult(req.ReqID)
+		if !ok {
+			s.sendError(conn, "unknown req_id: "+req.ReqID)
+			return
+		}
+		s.sendJSON(conn, protocol.PendResponse{
+			Status:   "ok",
+			Reply:    cached.Result.Reply,
+			Provider: cached.Provider,
+			ReqID:    req.ReqID,
+			ExitCode: cached.Result.ExitCode,
+		})
+		return
+	}
+
+	provider := req.Provider
 	if provider == "" {
-		s.sendError(conn, "missing provider")
+		// The latest reply from any provider, which only the store knows.
+		resp := protocol.PendResponse{Status: "ok"}
+		if e, ok := s.storedReply("", req.N); ok {
+			resp.Reply, resp.Provider, resp.ReqID = e.Result.Reply, e.Provider, e.Result.ReqID
+		}
+		s.sendJSON(conn, resp)
 		return
 	}
 
@@ -218,37 +617,52 @@ func (s *Server) handlePend(conn net.Conn, req map[string]interface{}) {
 		return
 	}
 
-	sessionID, _ := req["session_id"].(string)
-	reply, err := a.Pend(context.Background(), sessionID)
+	if req.N > 1 {
+		// Only the reply store goes further back than the latest reply.
+		resp := protocol.PendResponse{Status: "ok", Provider: provider}
+		if e, ok := s.storedReply(provider, req.N); ok {
+			resp.Reply, resp.ReqID = e.Result.Reply, e.Result.ReqID
+		}
+		s.sendJSON(conn, resp)
+		return
+	}
+
+	reply, err := a.Pend(context.Background(), req.SessionID)
+	if err == nil && reply == "" {
+		// The adapter only remembers replies since the daemon started.
+		if e, ok := s.storedReply(provider, 1); ok {
+			reply = e.Result.Reply
+		}
+	}
 	if err != nil {
-		s.sendJSON(conn, map[string]interface{}{
-			"status": "error",
-			"error":  err.Error(),
-			"reply":  "",
-		})
+		s.sendJSON(conn, protocol.PendResponse{Status: "error", Error: err.Error()})
 		return
 	}
 
-	s.sendJSON(conn, map[string]interface{}{
-		"status": "ok",
-		"reply":  reply,
-	})
+	s.sendJSON(conn, protocol.PendResponse{Status: "ok", Reply: reply})
 }
 
-// activeRequestCount returns the number of active workers processing requests.
+// activeRequestCount returns the number of asks in flight. Workers
+// outlive their asks, so the pool's size does not count them.
 func (s *Server) activeRequestCount() int {
-	return s.workerPool.ActiveWorkers()
+	return s.inflight.len()
 }
 
 // handleRequest handles an ask request.
-func (s *Server) handleRequest(conn net.Conn, req map[string]interface{}) {
-	provider, _ := req["provider"].(string)
+func (s *Server) handleRequest(conn net.Conn, req *protocol.AskRequest) {
+	received := time.Now()
+	if !s.asks.enter() {
+		s.sendJSON(conn, &adapter.ProviderResult{ReqID: req.ReqID, ExitCode: output.ExitDaemonDown, Error: "daemon is shutting down"})
+		return
+	}
+	defer s.asks.leave()
+	provider := req.Provider
 	if provider == "" {
 		s.sendError(conn, "missing provider")
 		return
 	}
 
-	a, ok := s.registry.Get(provider)
+	a, ok := s.adapterFor(provider, req.WorkDir)
 	if !ok {
 		s.sendError(conn, "unknown provider: "+provider)
 		return
@@ -256,17 +670,138 @@ func (s *Server) handleRequest(conn net.Conn, req map[string]interface{}) {
 
 	// Build provider request
 	provReq := &adapter.ProviderRequest{
-		ClientID: getStr(req, "client_id"),
-		WorkDir:  getStr(req, "work_dir"),
-		Message:  getStr(req, "message"),
-		ReqID:    getStr(req, "req_id"),
-		TimeoutS: getFloat(req, "timeout_s"),
-		Quiet:    getBool(req, "quiet"),
-		Caller:   getStr(req, "caller"),
+		ClientID: req.ClientID,
+		WorkDir:  req.WorkDir,
+		Message:  req.Message,
+		ReqID:    req.ReqID,
+		TimeoutS: req.TimeoutS,
+		Quiet:    req.Quiet,
+		Caller:   req.Caller,
+		Quick:    req.Quick,
+		Record:   req.Record,
+		Priority: req.Priority,
+
+		OutputPath: req.OutputPath,
+	}
+	if provReq.TimeoutS <= 0 {
+		provReq.TimeoutS = config.AskTimeout(provReq.WorkDir, provider)
+	}
+	if provReq.ReqID == "" {
+		// Every ask needs an id to be listed and canceled.
+		provReq.ReqID = protocol.MakeReqID()
+	}
+
+	if s.reqIDInUse(provReq.ReqID) {
+		// A shared anchor would hand one ask's reply to the other.
+		s.logger.Info("request: refused duplicate", "provider", provider, "req_id", provReq.ReqID)
+		result := &adapter.ProviderResult{ReqID: provReq.ReqID, ExitCode: output.ExitError, Error: duplicateReqIDError(provReq.ReqID)}
+		s.auditAsk(provider, provReq, result, received, false)
+		s.sendJSON(conn, result)
+		return
+	}
+
+	if b, wait, ok := s.rateLimit.allow(provReq.ClientID, provider, received); !ok {
+		s.logger.Info("rate limit: refused", "provider", provider, "req_id", provReq.ReqID, "client_id", provReq.ClientID)
+		result := rateLimited(provReq, b, wait)
+		s.metrics.requests.Inc(provider, outcome(result.ExitCode))
+		s.auditAsk(provider, provReq, result, received, false)
+		s.sendJSON(conn, result)
+		return
+	}
+
+	if item, ok := s.queueItem(req, provider, a, provReq); ok {
+		s.enqueue(conn, item)
+		return
+	}
+	if info, ok := s.paused.Get(provider); ok {
+		s.logger.Info("pause: refused", "provider", provider, "req_id", provReq.ReqID)
+		result := &adapter.ProviderResult{ReqID: provReq.ReqID, ExitCode: output.ExitPaused, Error: pausedError(provider, info)}
+		s.auditAsk(provider, provReq, result, received, false)
+		s.sendJSON(conn, result)
+		return
+	}
+
+	var chunks *chunkWriter
+	if req.Stream {
+		chunks = &chunkWriter{s: s, conn: conn, reqID: provReq.ReqID}
+		provReq.OnLines = chunks.send
+		if _, ok := anchorListenerOf(conn); ok {
+			provReq.OnAnchor = chunks.anchor
+		}
 	}
 
-	// Execute via worker pool
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(provReq.TimeoutS+10)*time.Second)
+	started := time.Now()
+	result := s.execute(contextOf(conn), provider, a, provReq)
+	if chunks != nil {
+		chunks.close()
+	}
+	s.writeOutput(provReq, result)
+	s.keepResult(provider, provReq, result)
+	s.recordHistory(provider, provReq, result, started, false)
+	s.auditAsk(provider, provReq, result, received, false)
+	s.sendJSON(conn, result)
+}
+
+// reqIDInUse reports whether an in-flight or queued ask has reqID.
+func (s *Server) reqIDInUse(reqID string) bool {
+	return s.inflight.has(reqID) || s.queue.Has(reqID)
+}
+
+// duplicateReqIDError is the error for an ask reusing a live req_id.
+func duplicateReqIDError(reqID string) string {
+	return "duplicate req_id " + reqID + ": another ask with this id is in flight or queued"
+}
+
+// chunkWriter streams partial reply lines to the client as
+// {"event":"chunk"} messages ahead of the final result.
+type chunkWriter struct {
+	s      *Server
+	conn   net.Conn
+	reqID  string
+	mu     sync.Mutex
+	closed bool
+}
+
+func (w *chunkWriter) send(lines []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.s.sendJSON(w.conn, map[string]interface{}{"event": "chunk", "req_id": w.reqID, "lines": lines})
+}
+
+// anchor tells a listening connection that the ask's anchor showed up.
+func (w *chunkWriter) anchor() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if l, ok := anchorListenerOf(w.conn); ok && !w.closed {
+		l.anchorSeen(w.reqID)
+	}
+}
+
+// close stops further chunks, e.g. from a provider still polling after
+// the request timed out.
+func (w *chunkWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+}
+
+// execute runs a request through the worker pool, serialized per provider
+// session, and waits for its result. The request is tracked in s.inflight
+// until it finishes so it can be listed and canceled. Getting the prompt
+// into the pane has its own budget (config.StartupTimeout), so time spent
+// waiting for the session does not eat into the reply timeout. The ask
+// ends early if parent does.
+func (s *Server) execute(parent context.Context, provider string, a adapter.Adapter, provReq *adapter.ProviderRequest) *adapter.ProviderResult {
+	budget := time.Duration(config.StartupTimeout(provReq.WorkDir) * float64(time.Second))
+	// Only a client's cancel carries adapter.ErrCanceled, which tells the
+	// adapter to interrupt the provider.
+	base, cancelAsk := context.WithCancelCause(parent)
+	defer cancelAsk(nil)
+	ctx, cancel := context.WithTimeout(base, budget+time.Duration(provReq.TimeoutS+10)*time.Second)
+	defer cancel()
 	task := &adapter.QueuedTask{
 		Request:  provReq,
 		ResultCh: make(chan *adapter.ProviderResult, 1),
@@ -274,41 +809,96 @@ func (s *Server) handleRequest(conn net.Conn, req map[string]interface{}) {
 		Cancel:   cancel,
 	}
 
+	if !s.inflight.add(provider, provReq, func() { cancelAsk(adapter.ErrCanceled) }) {
+		return &adapter.ProviderResult{ExitCode: output.ExitError, Error: duplicateReqIDError(provReq.ReqID), ReqID: provReq.ReqID}
+	}
+	defer s.inflight.remove(provReq.ReqID)
+	s.askStarted(provider, provReq)
+	rec := s.startRecording(provider, provReq)
+	s.startScratch(provReq)
+	clock := newPhaseClock()
+	started := time.Now()
+	provReq.OnPhase = func(phase string) {
+		s.inflight.setPhase(provReq.ReqID, phase)
+		if phase == adapter.PhaseWaiting {
+			clock.markSent()
+		}
+	}
+
+	// Asks reaching one pane run in turn, whichever project dir they came from.
 	sessionKey := fmt.Sprintf("%s:%s", provider, provReq.WorkDir)
-	s.workerPool.Submit(sessionKey, task, func(taskCtx context.Context, t *adapter.QueuedTask) {
+	if provReq.Route = s.routes.lookup(provider, provReq.WorkDir); provReq.Route != nil {
+		sessionKey = fmt.Sprintf("%s:%s", provider, provReq.Route.ProjectID)
+	}
+	s.workerPool.Submit(provider, sessionKey, task, func(taskCtx context.Context, t *adapter.QueuedTask) {
+		if t.Ctx.Err() != nil {
+			// Canceled or timed out while waiting for the worker; don't
+			// send it late.
+			t.ResultCh <- &adapter.ProviderResult{ExitCode: output.ExitTimeout, Error: "timeout", ReqID: t.Request.ReqID}
+			return
+		}
 		result, err := a.Send(t.Ctx, t.Request)
 		if err != nil {
-			t.ResultCh <- &adapter.ProviderResult{ExitCode: 1, Error: err.Error(), ReqID: t.Request.ReqID}
+			t.ResultCh <- &adapter.ProviderResult{ExitCode: output.ExitError, Error: err.Error(), ReqID: t.Request.ReqID}
 		} else {
 			t.ResultCh <- result
 		}
 	})
 
-	// Wait for result
-	select {
-	case result := <-task.ResultCh:
-		cancel()
-		s.sendJSON(conn, result)
-	case <-ctx.Done():
-		cancel()
-		s.sendJSON(conn, &adapter.ProviderResult{ExitCode: 2, Error: "timeout", ReqID: provReq.ReqID})
+	startup := time.NewTimer(budget)
+	defer startup.Stop()
+	var result *adapter.ProviderResult
+	for result == nil {
+		select {
+		case result = <-task.ResultCh:
+		case <-startup.C:
+			if clock.isSent() {
+				continue
+			}
+			cancel()
+			result = &adapter.ProviderResult{ExitCode: output.ExitTimeout, ReqID: provReq.ReqID,
+				Error: fmt.Sprintf("startup timeout: prompt not sent within %s", budget)}
+		case <-ctx.Done():
+			result = &adapter.ProviderResult{ExitCode: output.ExitTimeout, Error: "timeout", ReqID: provReq.ReqID}
+		}
 	}
+	if result.ExitCode != 0 && adapter.CanceledByClient(ctx) {
+		result = &adapter.ProviderResult{ExitCode: output.ExitCanceled, Error: "canceled", ReqID: provReq.ReqID}
+	}
+	clock.stamp(result)
+	s.metrics.observe(provider, result, time.Since(started))
+	s.askFinished(provider, provReq, result, time.Since(started))
+	s.finishRecording(rec, result)
+	s.finishScratch(provReq, result)
+	return result
 }
 
-// Shutdown gracefully shuts down the server.
+// Shutdown gracefully shuts down the server: it stops accepting
+// connections and new asks at once, and lets asks in flight finish and
+// send their results in the background (see drain and Wait).
 func (s *Server) Shutdown() {
 	s.log("shutting down...")
+	s.events.publish(protocol.StateEvent{Event: EventShuttingDown})
 	close(s.shutdown)
 	if s.listener != nil {
 		s.listener.Close()
 	}
-	s.workerPool.Shutdown()
+	s.stopHTTP()
+	s.stopGRPC()
+	if s.metricsSrv != nil {
+		s.metricsSrv.Close()
+	}
 	s.removeState()
+	// Refuse new asks now, not once the drain goroutine gets to run.
+	n, idle := s.asks.close()
+	go s.drain(n, idle)
 }
 
-// Wait waits for the server to finish.
+// Wait waits for the server to finish, including the drain of asks in
+// flight after Shutdown.
 func (s *Server) Wait() {
 	<-s.done
+	<-s.drained
 }
 
 // Addr returns the listener address.
@@ -321,72 +911,77 @@ func (s *Server) Addr() net.Addr {
 
 // Token returns the server token.
 func (s *Server) Token() string {
-	return s.token
-}
-
-// touchActivity updates the last activity timestamp.
-func (s *Server) touchActivity() {
-	s.mu.Lock()
-	s.lastActive = time.Now()
-	s.mu.Unlock()
+	return s.tokens.Current()
 }
 
-// idleMonitor shuts down the server after idle timeout.
-func (s *Server) idleMonitor() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-s.shutdown:
-			return
-		case <-ticker.C:
-			s.mu.Lock()
-			idle := time.Since(s.lastActive)
-			s.mu.Unlock()
-			if idle > s.idleTimeout {
-				s.log("idle timeout (%v), shutting down", s.idleTimeout)
-				s.Shutdown()
-				return
-			}
-		}
-	}
-}
+// parentCheckInterval is how often the daemon checks that the process it
+// is tied to (ServerConfig.ParentPID) is still running.
+const parentCheckInterval = 5 * time.Second
 
-// parentMonitor shuts down if the parent process dies.
+// parentMonitor shuts down once the parent process has exited.
 func (s *Server) parentMonitor() {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(parentCheckInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-s.shutdown:
 			return
 		case <-ticker.C:
-			proc, err := os.FindProcess(s.parentPID)
-			if err != nil {
+			if !processAlive(s.parentPID) {
 				s.log("parent process %d gone, shutting down", s.parentPID)
 				s.Shutdown()
 				return
 			}
-			// On Unix, FindProcess always succeeds; check with signal 0
-			_ = proc
 		}
 	}
 }
 
 // writeState writes the daemon state file.
-func (s *Server) writeState(host string, port int) {
+func (s *Server) writeState(host string, port int, httpAddr, grpcAddr, metricsAddr string) {
 	if s.stateFile == "" {
 		return
 	}
 	state := DaemonState{
-		Host:  host,
-		Port:  port,
-		Token: s.token,
-		PID:   os.Getpid(),
+		Host:    host,
+		Port:    port,
+		Pipe:    s.pipe,
+		Socket:  s.socket,
+		TLS:     s.tls != nil,
+		HTTP:    httpAddr,
+		GRPC:    grpcAddr,
+		Metrics: metricsAddr,
+		Token:   s.tokens.Current(),
+		PID:     os.Getpid(),
 	}
-	data, _ := json.MarshalIndent(state, "", "  ")
-	os.MkdirAll(runtime.RunDir(), 0755)
-	os.WriteFile(s.stateFile, data, 0600)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if err := s.saveStateLocked(); err != nil {
+		s.log("state: %v", err)
+	}
+}
+
+// saveStateLocked writes s.state to the state file atomically, so a client
+// never reads a half-written token. s.mu must be held.
+func (s *Server) saveStateLocked() error {
+	data, _ := json.MarshalIndent(s.state, "", "  ")
+	dir := filepath.Dir(s.stateFile)
+	os.MkdirAll(dir, 0755)
+	f, err := os.CreateTemp(dir, ".askd-state-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.stateFile)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
 }
 
 // removeState removes the daemon state file.
@@ -396,21 +991,12 @@ func (s *Server) removeState() {
 	}
 }
 
-// log writes a log message.
-func (s *Server) log(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	ts := time.Now().Format("2006-01-02 15:04:05")
-	line := fmt.Sprintf("[%s] %s", ts, msg)
-	if s.logFile != "" {
-		runtime.WriteLog(s.logFile, line)
-	}
-}
-
 // sendJSON sends a JSON response.
 func (s *Server) sendJSON(conn net.Conn, v interface{}) {
 	data, _ := json.Marshal(v)
-	conn.Write(data)
-	conn.Write([]byte("\n"))
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		s.debugf("send to %s: %v", conn.RemoteAddr(), err)
+	}
 }
 
 // sendError sends an error response.
@@ -418,6 +1004,34 @@ func (s *Server) sendError(conn net.Conn, msg string) {
 	s.sendJSON(conn, map[string]interface{}{"status": "error", "error": msg})
 }
 
+// decode fills v, a protocol request struct, from req. Validate has checked
+// the types of the fields the schema describes; decode catches the rest,
+// e.g. a fraction where an integer belongs, answering an invalid request
+// error and reporting false.
+func (s *Server) decode(conn net.Conn, req map[string]interface{}, v interface{}) bool {
+	if err := decodeRequest(req, v); err != nil {
+		s.sendError(conn, "invalid request: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// decodeRequest fills v, a protocol request struct, from req.
+func decodeRequest(req map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		var te *json.UnmarshalTypeError
+		if errors.As(err, &te) {
+			return fmt.Errorf("%s: must be %s, got %s", te.Field, te.Type, te.Value)
+		}
+		return err
+	}
+	return nil
+}
+
 // Helper functions for extracting typed values from map
 func getStr(m map[string]interface{}, key string) string {
 	v, _ := m[key].(string)
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+)
+
+// defaultTokenGrace is how long a rotated-out token stays valid when the
+// rotate_token request doesn't say.
+const defaultTokenGrace = 5 * time.Minute
+
+// rotateToken issues a new shared token, writes it to the state file and
+// keeps the old one valid for grace, returning when it expires. If the
+// state file can't be written the old token stays current.
+func (s *Server) rotateToken(grace time.Duration) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.tokens.Current()
+	until := s.tokens.Rotate(runtime.RandomToken(), grace)
+	if s.stateFile == "" {
+		return until, nil
+	}
+	s.state.Token = s.tokens.Current()
+	if err := s.saveStateLocked(); err != nil {
+		s.tokens.Rotate(old, 0)
+		s.state.Token = old
+		return time.Time{}, fmt.Errorf("token not rotated: %w", err)
+	}
+	return until, nil
+}
+
+// handleRotateToken handles a rotate_token request.
+func (s *Server) handleRotateToken(conn net.Conn, req *protocol.RotateTokenRequest) {
+	grace := defaultTokenGrace
+	if req.GraceS != nil {
+		grace = time.Duration(*req.GraceS * float64(time.Second))
+	}
+	until, err := s.rotateToken(grace)
+	if err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	s.logger.Info("auth: token rotated", "grace", grace)
+	s.sendJSON(conn, protocol.RotateTokenResponse{Status: "ok", PreviousValidUntil: until.UTC().Format(time.RFC3339)})
+}
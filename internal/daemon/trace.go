@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// tracedConn remembers the last message written to a connection, so the
+// outcome of an RPC can be logged once it has been handled.
+type tracedConn struct {
+	net.Conn
+	mu   sync.Mutex
+	last []byte
+}
+
+func (c *tracedConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.last = append(c.last[:0], p...)
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+func (c *tracedConn) lastMessage() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.last...)
+}
+
+// logRPC logs one handled request: its method, provider, req_id and
+// client_id, the outcome from the response and how long it took.
+func (s *Server) logRPC(req map[string]interface{}, conn *tracedConn, started time.Time) {
+	s.logger.LogAttrs(context.Background(), slog.LevelInfo, "rpc", rpcAttrs(req, conn.lastMessage(), time.Since(started))...)
+}
+
+// rpcAttrs describes an RPC for the log, e.g. in text form
+// "rpc method=request provider=codex req_id=... status=ok exit_code=0
+// startup=120ms reply=4.2s duration=4.3s".
+func rpcAttrs(req map[string]interface{}, resp []byte, took time.Duration) []slog.Attr {
+	var r map[string]interface{}
+	json.Unmarshal(resp, &r)
+
+	attrs := []slog.Attr{slog.String("method", getStr(req, "method"))}
+	if p := getStr(req, "provider"); p != "" {
+		attrs = append(attrs, slog.String("provider", p))
+	}
+	reqID := getStr(r, "req_id")
+	if reqID == "" {
+		reqID = getStr(req, "req_id")
+	}
+	if reqID != "" {
+		attrs = append(attrs, slog.String("req_id", reqID))
+	}
+	if c := getStr(req, "client_id"); c != "" {
+		attrs = append(attrs, slog.String("client_id", c))
+	}
+	status := getStr(r, "status")
+	switch {
+	case r == nil:
+		status = "no-response"
+	case status == "":
+		status = "ok"
+	}
+	attrs = append(attrs, slog.String("status", status))
+	if r["exit_code"] != nil {
+		attrs = append(attrs, slog.Int("exit_code", int(getFloat(r, "exit_code"))))
+	}
+	if getBool(r, "queued") {
+		attrs = append(attrs, slog.Bool("queued", true))
+	}
+	if ms := getFloat(r, "startup_ms"); ms > 0 {
+		attrs = append(attrs, slog.Duration("startup", msDuration(ms)))
+	}
+	if ms := getFloat(r, "reply_ms"); ms > 0 {
+		attrs = append(attrs, slog.Duration("reply", msDuration(ms)))
+	}
+	attrs = append(attrs, slog.Duration("duration", took.Round(time.Millisecond)))
+	if e := getStr(r, "error"); e != "" {
+		attrs = append(attrs, slog.String("error", e))
+	}
+	return attrs
+}
+
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
@@ -2,22 +2,50 @@ package daemon
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
 	"sync"
 
 	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/output"
 )
 
+// Scheduling lanes, served in order: interactive tasks go ahead of
+// background ones that are still waiting.
+const (
+	laneInteractive = iota
+	laneBackground
+	numLanes
+)
+
+// taskLane returns the lane for task's priority.
+func taskLane(task *adapter.QueuedTask) int {
+	if task.Request != nil && task.Request.Background() {
+		return laneBackground
+	}
+	return laneInteractive
+}
+
 // WorkerPool manages per-session goroutine workers for processing requests.
+// Tasks for one session run one at a time, interactive ones first; a
+// provider's tasks across sessions can also be capped (SetLimit). A task
+// whose handler panics fails, and its worker carries on with the next one.
 type WorkerPool struct {
-	mu      sync.Mutex
-	workers map[string]*sessionWorker
-	maxSize int
+	mu       sync.Mutex
+	workers  map[string]*sessionWorker
+	maxSize  int
+	limiters map[string]*limiter // provider -> its running tasks and their cap
+	onPanic  func(provider string, task *adapter.QueuedTask, v interface{}, stack []byte)
 }
 
 type sessionWorker struct {
+	provider   string
 	sessionKey string
-	taskCh     chan *adapter.QueuedTask
 	cancel     context.CancelFunc
+
+	mu    sync.Mutex
+	lanes [numLanes][]*adapter.QueuedTask
+	wake  chan struct{}
 }
 
 // NewWorkerPool creates a new worker pool.
@@ -26,65 +54,281 @@ func NewWorkerPool(maxSize int) *WorkerPool {
 		maxSize = 50
 	}
 	return &WorkerPool{
-		workers: make(map[string]*sessionWorker),
-		maxSize: maxSize,
+		workers:  make(map[string]*sessionWorker),
+		maxSize:  maxSize,
+		limiters: make(map[string]*limiter),
+	}
+}
+
+// SetLimit caps how many of provider's tasks run at once, across all its
+// sessions; n <= 0 removes the cap. The cap is resized in place: tasks
+// already running keep their slot and count against the new cap, so after
+// lowering it no new task starts until enough of them have finished.
+func (p *WorkerPool) SetLimit(provider string, n int) {
+	if n < 0 {
+		n = 0
 	}
+	p.limiter(provider).resize(n)
 }
 
-// Submit submits a task to the worker for the given session key.
-// If no worker exists for the session, one is created.
-func (p *WorkerPool) Submit(sessionKey string, task *adapter.QueuedTask, handler func(context.Context, *adapter.QueuedTask)) {
+// Limit returns provider's cap, 0 when it has none.
+func (p *WorkerPool) Limit(provider string) int {
+	l := p.limiter(provider)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+// limiter returns provider's limiter, creating an uncapped one so running
+// tasks are counted before any cap is set.
+func (p *WorkerPool) limiter(provider string) *limiter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l := p.limiters[provider]
+	if l == nil {
+		l = &limiter{}
+		p.limiters[provider] = l
+	}
+	return l
+}
+
+// OnPanic sets fn to be told, with the stack, when a task's handler panics.
+func (p *WorkerPool) OnPanic(fn func(provider string, task *adapter.QueuedTask, v interface{}, stack []byte)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onPanic = fn
+}
+
+// Submit submits a task to the worker for the given session key of
+// provider. If no worker exists for the session, one is created.
+func (p *WorkerPool) Submit(provider, sessionKey string, task *adapter.QueuedTask, handler func(context.Context, *adapter.QueuedTask)) {
 	p.mu.Lock()
 	w, ok := p.workers[sessionKey]
 	if !ok {
 		ctx, cancel := context.WithCancel(context.Background())
 		w = &sessionWorker{
+			provider:   provider,
 			sessionKey: sessionKey,
-			taskCh:     make(chan *adapter.QueuedTask, 16),
 			cancel:     cancel,
+			wake:       make(chan struct{}, 1),
 		}
 		p.workers[sessionKey] = w
 		go p.runWorker(ctx, w, handler)
 	}
 	p.mu.Unlock()
+	w.push(task)
+}
 
-	// Non-blocking send; if channel is full, run in a new goroutine
+// push queues task in its lane and wakes the worker.
+func (w *sessionWorker) push(task *adapter.QueuedTask) {
+	w.mu.Lock()
+	lane := taskLane(task)
+	w.lanes[lane] = append(w.lanes[lane], task)
+	w.mu.Unlock()
 	select {
-	case w.taskCh <- task:
+	case w.wake <- struct{}{}:
 	default:
-		go handler(task.Ctx, task)
 	}
 }
 
-// runWorker processes tasks for a single session.
+// next pops the first task of the highest-priority non-empty lane.
+func (w *sessionWorker) next() *adapter.QueuedTask {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.lanes {
+		if len(w.lanes[i]) > 0 {
+			task := w.lanes[i][0]
+			w.lanes[i][0] = nil
+			w.lanes[i] = w.lanes[i][1:]
+			return task
+		}
+	}
+	return nil
+}
+
+// runWorker processes tasks for a single session, restarting the worker
+// after a task crashes it.
 func (p *WorkerPool) runWorker(ctx context.Context, w *sessionWorker, handler func(context.Context, *adapter.QueuedTask)) {
+	for !p.work(ctx, w, handler) {
+	}
+}
+
+// work runs w's tasks until ctx ends, then returns true. When a handler
+// panics, its task fails (see crashed) and work returns false.
+func (p *WorkerPool) work(ctx context.Context, w *sessionWorker, handler func(context.Context, *adapter.QueuedTask)) (done bool) {
+	var task *adapter.QueuedTask
+	defer func() {
+		if v := recover(); v != nil {
+			p.crashed(w.provider, task, v, debug.Stack())
+		}
+	}()
 	for {
+		if task = w.next(); task != nil {
+			p.run(w.provider, task, handler)
+			continue
+		}
 		select {
 		case <-ctx.Done():
-			return
-		case task, ok := <-w.taskCh:
-			if !ok {
-				return
-			}
-			handler(task.Ctx, task)
+			return true
+		case <-w.wake:
+		}
+	}
+}
+
+// crashed fails task, whose handler panicked with v, unless the handler
+// got its result out first, and reports the panic to OnPanic. task is nil
+// if the worker panicked between tasks.
+func (p *WorkerPool) crashed(provider string, task *adapter.QueuedTask, v interface{}, stack []byte) {
+	p.mu.Lock()
+	onPanic := p.onPanic
+	p.mu.Unlock()
+	if task != nil && task.ResultCh != nil {
+		result := &adapter.ProviderResult{ExitCode: output.ExitError, Error: panicError(provider, v)}
+		if task.Request != nil {
+			result.ReqID = task.Request.ReqID
 		}
+		select {
+		case task.ResultCh <- result:
+		default:
+		}
+	}
+	if onPanic != nil {
+		onPanic(provider, task, v, stack)
+	}
+}
+
+// panicError is the error a task fails with when its handler panics.
+func panicError(provider string, v interface{}) string {
+	return fmt.Sprintf("internal error: %s adapter panicked: %v", provider, v)
+}
+
+// run calls handler once provider has a free slot.
+func (p *WorkerPool) run(provider string, task *adapter.QueuedTask, handler func(context.Context, *adapter.QueuedTask)) {
+	l := p.limiter(provider)
+	ctx := task.Ctx
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	if l.acquire(ctx, taskLane(task)) {
+		defer l.release()
+	}
+	handler(task.Ctx, task)
 }
 
-// Shutdown stops all workers.
+// limiter counts the tasks running and lets at most n (0 for no cap) run
+// at once, handing freed slots to waiting interactive tasks before
+// background ones.
+type limiter struct {
+	mu      sync.Mutex
+	n       int
+	used    int
+	waiting [numLanes][]chan struct{}
+}
+
+// free reports whether a task may start now. l.mu must be held.
+func (l *limiter) free() bool {
+	return l.n == 0 || l.used < l.n
+}
+
+// resize sets the cap to n (0 for none) and starts as many waiters as the
+// new cap has room for. Running tasks keep their slots, so a lowered cap
+// may stay exceeded until they finish.
+func (l *limiter) resize(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.n = n
+	for l.free() && l.wakeFirst() {
+		l.used++
+	}
+}
+
+// wakeFirst starts the first waiter, interactive lane first, reporting
+// false when none is waiting. l.mu must be held.
+func (l *limiter) wakeFirst() bool {
+	for i := range l.waiting {
+		if len(l.waiting[i]) > 0 {
+			ready := l.waiting[i][0]
+			l.waiting[i] = l.waiting[i][1:]
+			close(ready)
+			return true
+		}
+	}
+	return false
+}
+
+// acquire waits for a slot and reports whether it got one. When ctx ends
+// first it returns false; the task then runs without a slot and finds its
+// context done.
+func (l *limiter) acquire(ctx context.Context, lane int) bool {
+	l.mu.Lock()
+	if l.free() {
+		l.used++
+		l.mu.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	l.waiting[lane] = append(l.waiting[lane], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, ch := range l.waiting[lane] {
+		if ch == ready {
+			l.waiting[lane] = append(l.waiting[lane][:i], l.waiting[lane][i+1:]...)
+			return false
+		}
+	}
+	// release handed us the slot just as ctx ended; the caller frees it.
+	return true
+}
+
+// release frees a slot, passing it straight to the first waiter unless
+// a lowered cap is still exceeded.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.used--
+	if l.free() && l.wakeFirst() {
+		l.used++
+	}
+}
+
+// Shutdown stops all workers. Tasks still waiting in their session's
+// queue are dropped.
 func (p *WorkerPool) Shutdown() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	for key, w := range p.workers {
 		w.cancel()
-		close(w.taskCh)
 		delete(p.workers, key)
 	}
 }
 
+// MaxSize returns the pool's capacity.
+func (p *WorkerPool) MaxSize() int {
+	return p.maxSize
+}
+
 // ActiveWorkers returns the number of active session workers.
 func (p *WorkerPool) ActiveWorkers() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	return len(p.workers)
 }
+
+// workerPanicked logs a panic recovered in a session worker; the worker
+// pool has already failed the task and restarts the worker.
+func (s *Server) workerPanicked(provider string, task *adapter.QueuedTask, v interface{}, stack []byte) {
+	reqID := ""
+	if task != nil && task.Request != nil {
+		reqID = task.Request.ReqID
+	}
+	s.logger.Error("worker: recovered from panic", "provider", provider, "req_id", reqID,
+		"panic", fmt.Sprint(v), "stack", string(stack))
+	s.metrics.panics.Inc(provider)
+}
@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/auth"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+	"github.com/anthropics/claude_code_bridge/internal/websocket"
+)
+
+// WebSocket events sent for an ask. Every event carries the req_id; done
+// and error also carry the result fields (reply, exit_code, ...).
+const (
+	EventAnchorSeen   = "anchor_seen"   // the provider has started answering
+	EventPartialReply = "partial_reply" // completed reply lines so far ("lines")
+	EventQueued       = "queued"        // held in the offline queue
+	EventDone         = "done"          // the reply arrived
+	EventError        = "error"         // the ask failed or was rejected
+)
+
+// anchorListener is implemented by connections that want to know when a
+// streamed ask's anchor shows up.
+type anchorListener interface {
+	anchorSeen(reqID string)
+}
+
+// anchorListenerOf returns conn's anchorListener, looking through tracing.
+func anchorListenerOf(conn net.Conn) (anchorListener, bool) {
+	if tc, ok := conn.(*tracedConn); ok {
+		conn = tc.Conn
+	}
+	l, ok := conn.(anchorListener)
+	return l, ok
+}
+
+// contextConn is implemented by connections whose asks end with them.
+type contextConn interface {
+	Context() context.Context
+}
+
+// contextOf returns the context asks arriving on conn run under, looking
+// through tracing: conn's own, or a background context if it has none.
+func contextOf(conn net.Conn) context.Context {
+	if tc, ok := conn.(*tracedConn); ok {
+		conn = tc.Conn
+	}
+	if c, ok := conn.(contextConn); ok {
+		return c.Context()
+	}
+	return context.Background()
+}
+
+// serveWebSocket upgrades r and runs each message it receives as a
+// protocol request (method "ask" unless given), concurrently. Asks stream
+// their progress as events; other methods get their usual response. The
+// token is checked once, from an "Authorization: Bearer" header or the
+// "token" query parameter (browsers cannot set headers on an upgrade).
+// Closing the socket cancels the asks still running on it, as closing the
+// terminal does for a CLI ask.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = strings.TrimSpace(bearer)
+	}
+	peer := auth.Peer{Addr: r.RemoteAddr, TLS: r.TLS, UID: -1}
+	if _, err := s.auth.Authenticate(peer, map[string]interface{}{"token": token}); err != nil {
+		s.log("auth: rejected websocket %s: %v", r.RemoteAddr, err)
+		writeHTTPError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	ws, err := websocket.Upgrade(w, r)
+	if err != nil {
+		s.debugf("websocket %s: %v", r.RemoteAddr, err)
+		return
+	}
+	defer ws.Close()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(adapter.ErrCanceled)
+
+	for {
+		msg, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		req := map[string]interface{}{}
+		if err := json.Unmarshal(msg, &req); err != nil {
+			ws.WriteText(wsEvent(map[string]interface{}{"event": EventError, "error": "invalid JSON: " + err.Error()}))
+			continue
+		}
+		if _, ok := req["method"]; !ok {
+			req["method"] = "ask"
+		}
+		if _, ok := req["token"]; !ok {
+			req["token"] = token
+		}
+		c := &wsConn{ws: ws, ctx: ctx, peer: peer, reqID: getStr(req, "req_id")}
+		switch req["method"] {
+		case "ask", "request", ".request":
+			c.ask = true
+			req["stream"] = true
+		}
+		go func() {
+			if !s.dispatch(c, req) {
+				ws.Close()
+			}
+		}()
+	}
+}
+
+// wsConn carries one request's responses over a WebSocket, turning an
+// ask's chunks and result into events.
+type wsConn struct {
+	ws    *websocket.Conn
+	ctx   context.Context // canceled when the socket closes
+	peer  auth.Peer
+	reqID string
+	ask   bool
+}
+
+// Write translates one protocol message (a JSON line) into an event.
+func (c *wsConn) Write(p []byte) (int, error) {
+	var m map[string]interface{}
+	if !c.ask || json.Unmarshal(p, &m) != nil {
+		return len(p), c.ws.WriteText([]byte(strings.TrimSpace(string(p))))
+	}
+	if _, ok := m["req_id"]; !ok && c.reqID != "" {
+		m["req_id"] = c.reqID
+	}
+	exit, hasExit := m["exit_code"].(float64)
+	queued, _ := m["queued"].(bool)
+	switch {
+	case m["event"] == "chunk":
+		m["event"] = EventPartialReply
+	case m["status"] == "error":
+		m["event"] = EventError
+	case queued:
+		m["event"] = EventQueued
+	case hasExit && exit == 0:
+		m["event"] = EventDone
+	default:
+		m["event"] = EventError
+	}
+	return len(p), c.ws.WriteText(wsEvent(m))
+}
+
+func (c *wsConn) anchorSeen(reqID string) {
+	c.ws.WriteText(wsEvent(map[string]interface{}{"event": EventAnchorSeen, "req_id": reqID}))
+}
+
+// wsEvent encodes an event message.
+func wsEvent(m map[string]interface{}) []byte {
+	data, _ := json.Marshal(m)
+	return data
+}
+
+// Peer describes the client that opened the WebSocket.
+func (c *wsConn) Peer() auth.Peer { return c.peer }
+
+// Context ends when the socket closes.
+func (c *wsConn) Context() context.Context { return c.ctx }
+
+func (c *wsConn) Read([]byte) (int, error)         { return 0, net.ErrClosed }
+func (c *wsConn) Close() error                     { return nil }
+func (c *wsConn) LocalAddr() net.Addr              { return httpAddr("websocket") }
+func (c *wsConn) RemoteAddr() net.Addr             { return c.ws.RemoteAddr() }
+func (c *wsConn) SetDeadline(time.Time) error      { return nil }
+func (c *wsConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *wsConn) SetWriteDeadline(time.Time) error { return nil }
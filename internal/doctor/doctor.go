@@ -0,0 +1,198 @@
+// Package doctor runs environment diagnostics for "ccb doctor".
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// Status is the outcome of one check.
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is one diagnostic result. Hint says how to fix a warn or fail.
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// Env is what the checks inspect, passed in so tests can use fakes.
+type Env struct {
+	WorkDir      string
+	Providers    []string // providers configured for WorkDir
+	Backend      terminal.Backend
+	BackendErr   error
+	StateFile    string // daemon state file (askd.json)
+	RegistryPath string
+	LookPath     func(string) (string, error)
+	LogRoot      func(provider string) string
+}
+
+// Run performs every check in a fixed order.
+func Run(env Env) []Check {
+	var checks []Check
+	checks = append(checks, CheckBackend(env.Backend, env.BackendErr))
+	checks = append(checks, CheckDaemon(env.StateFile)...)
+	checks = append(checks, CheckCLIs(env.Providers, env.LookPath)...)
+	checks = append(checks, CheckSessionFiles(env.WorkDir, env.Providers)...)
+	checks = append(checks, CheckRegistry(env.RegistryPath, env.Backend))
+	checks = append(checks, CheckLogDirs(env.Providers, env.LogRoot)...)
+	return checks
+}
+
+// Failed reports whether any check failed.
+func Failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBackend reports whether a terminal backend (tmux, WezTerm, ...) is
+// usable.
+func CheckBackend(backend terminal.Backend, err error) Check {
+	if err != nil || backend == nil {
+		detail := "no terminal backend detected"
+		if err != nil {
+			detail = err.Error()
+		}
+		return Check{Name: "terminal backend", Status: Fail, Detail: detail,
+			Hint: "run ccb inside tmux or WezTerm, or install one of them"}
+	}
+	return Check{Name: "terminal backend", Status: Pass, Detail: backend.Name()}
+}
+
+// CheckDaemon checks that the daemon in stateFile answers and accepts its
+// token. A daemon that isn't running is only a warning: ask starts it.
+func CheckDaemon(stateFile string) []Check {
+	state, err := client.ReadState(stateFile)
+	if err != nil {
+		return []Check{{Name: "daemon", Status: Warn, Detail: err.Error(),
+			Hint: "it starts on the first ask, or run: ccb daemon start"}}
+	}
+	reach := Check{Name: "daemon", Status: Pass, Detail: fmt.Sprintf("pid %d on %s", state.PID, state.Address())}
+	token := Check{Name: "daemon token", Status: Pass, Detail: "accepted"}
+	if err := client.PingDaemon(state); err != nil {
+		if strings.Contains(err.Error(), "invalid token") {
+			token.Status, token.Detail = Fail, "rejected by the daemon"
+			token.Hint = "the state file is out of date; run: ccb daemon stop && ccb daemon start"
+			return []Check{reach, token}
+		}
+		reach.Status, reach.Detail = Fail, fmt.Sprintf("not reachable at %s: %v", state.Address(), err)
+		reach.Hint = "stale state file; remove " + stateFile + " or run: ccb daemon start"
+		return []Check{reach}
+	}
+	return []Check{reach, token}
+}
+
+// CheckCLIs checks that each provider's CLI is on PATH.
+func CheckCLIs(providers []string, lookPath func(string) (string, error)) []Check {
+	var checks []Check
+	for _, p := range providers {
+		c := Check{Name: p + " CLI"}
+		if path, err := lookPath(p); err != nil {
+			c.Status, c.Detail = Fail, p+" not found on PATH"
+			c.Hint = "install " + p + " or add it to PATH"
+		} else {
+			c.Status, c.Detail = Pass, path
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// CheckSessionFiles checks that each provider's .ccb_config session file
+// in workDir can be written.
+func CheckSessionFiles(workDir string, providers []string) []Check {
+	dir := config.ProjectConfigDir(workDir)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return []Check{{Name: "session files", Status: Warn, Detail: "no " + config.CCBProjectConfigDirname + " in " + workDir,
+			Hint: "launch providers here first, e.g.: ccb " + strings.Join(providers, ",")}}
+	}
+	var checks []Check
+	for _, p := range providers {
+		path := filepath.Join(dir, "."+p+"-session")
+		c := Check{Name: p + " session file", Status: Pass, Detail: path}
+		if ok, reason, fix := config.CheckSessionWritable(path); !ok {
+			c.Status, c.Detail, c.Hint = Fail, reason, fix
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// CheckRegistry checks that the pane registry parses and how many of its
+// panes are dead. Liveness is skipped without a backend.
+func CheckRegistry(path string, backend terminal.Backend) Check {
+	c := Check{Name: "pane registry"}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.Status, c.Detail = Pass, "empty (no providers launched yet)"
+		return c
+	}
+	if err == nil {
+		var probe map[string]interface{}
+		err = json.Unmarshal(data, &probe)
+	}
+	if err != nil {
+		c.Status, c.Detail = Fail, fmt.Sprintf("%s: %v", path, err)
+		c.Hint = "remove it; panes re-register on the next launch"
+		return c
+	}
+
+	entries, dead := 0, []string{}
+	for provider, projects := range session.NewPaneRegistry(path).AllEntries() {
+		for _, e := range projects {
+			entries++
+			if backend != nil && e.PaneID != "" && !backend.IsAlive(e.PaneID) {
+				dead = append(dead, provider+" "+e.PaneID)
+			}
+		}
+	}
+	c.Status, c.Detail = Pass, fmt.Sprintf("%d entries", entries)
+	if len(dead) > 0 {
+		sort.Strings(dead)
+		c.Status = Warn
+		c.Detail = fmt.Sprintf("%d entries, %d dead (%s)", entries, len(dead), strings.Join(dead, ", "))
+		c.Hint = "ccb list to inspect; ccb stop <provider> or ccb restart <provider>"
+	}
+	return c
+}
+
+// CheckLogDirs checks that each provider's log directory exists, which
+// replies are read from.
+func CheckLogDirs(providers []string, logRoot func(string) string) []Check {
+	var checks []Check
+	for _, p := range providers {
+		dir := logRoot(p)
+		if dir == "" {
+			continue
+		}
+		c := Check{Name: p + " logs", Status: Pass, Detail: dir}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			c.Status, c.Detail = Warn, dir+" not found"
+			c.Hint = "start " + p + " once so it creates its session logs"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
@@ -0,0 +1,81 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+type fakeBackend struct {
+	terminal.Backend
+	alive map[string]bool
+}
+
+func (b fakeBackend) Name() string               { return "fake" }
+func (b fakeBackend) IsAlive(paneID string) bool { return b.alive[paneID] }
+
+func TestCheckCLIs(t *testing.T) {
+	lookPath := func(name string) (string, error) {
+		if name == "codex" {
+			return "/usr/bin/codex", nil
+		}
+		return "", errors.New("not found")
+	}
+	checks := CheckCLIs([]string{"codex", "gemini"}, lookPath)
+	if len(checks) != 2 || checks[0].Status != Pass || checks[1].Status != Fail || checks[1].Hint == "" {
+		t.Errorf("checks = %+v", checks)
+	}
+}
+
+func TestCheckRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pane-registry.json")
+
+	if c := CheckRegistry(path, nil); c.Status != Pass {
+		t.Errorf("missing registry: %+v", c)
+	}
+
+	os.WriteFile(path, []byte("{not json"), 0600)
+	if c := CheckRegistry(path, nil); c.Status != Fail {
+		t.Errorf("corrupt registry: %+v", c)
+	}
+
+	os.WriteFile(path, []byte(`{"version":2,"providers":{"codex":{"a":{"pane_id":"%1"}},"gemini":{"b":{"pane_id":"%2"}}}}`), 0600)
+	c := CheckRegistry(path, fakeBackend{alive: map[string]bool{"%1": true}})
+	if c.Status != Warn || c.Detail != "2 entries, 1 dead (gemini %2)" {
+		t.Errorf("dead pane: %+v", c)
+	}
+}
+
+func TestCheckSessionFilesAndLogDirs(t *testing.T) {
+	work := t.TempDir()
+	if checks := CheckSessionFiles(work, []string{"codex"}); len(checks) != 1 || checks[0].Status != Warn {
+		t.Errorf("no .ccb_config: %+v", checks)
+	}
+	os.MkdirAll(filepath.Join(work, ".ccb_config", ".codex-session"), 0755) // a directory, not a file
+	checks := CheckSessionFiles(work, []string{"codex", "claude"})
+	if len(checks) != 2 || checks[0].Status != Fail || checks[1].Status != Pass {
+		t.Errorf("session files: %+v", checks)
+	}
+
+	logs := t.TempDir()
+	root := func(p string) string { return filepath.Join(logs, p) }
+	os.MkdirAll(root("codex"), 0755)
+	checks = CheckLogDirs([]string{"codex", "claude"}, root)
+	if checks[0].Status != Pass || checks[1].Status != Warn {
+		t.Errorf("log dirs: %+v", checks)
+	}
+}
+
+func TestCheckDaemonNotRunning(t *testing.T) {
+	checks := CheckDaemon(filepath.Join(t.TempDir(), "askd.json"))
+	if len(checks) != 1 || checks[0].Status != Warn {
+		t.Errorf("checks = %+v", checks)
+	}
+	if Failed(checks) {
+		t.Error("a stopped daemon must not fail doctor")
+	}
+}
@@ -0,0 +1,129 @@
+// Package history keeps a per-project log of asks and their replies as
+// JSONL files under the runtime directory.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// Entry is one recorded ask.
+type Entry struct {
+	Time       time.Time `json:"time"` // when the ask started
+	ReqID      string    `json:"req_id"`
+	Provider   string    `json:"provider"`
+	WorkDir    string    `json:"work_dir"`
+	Caller     string    `json:"caller,omitempty"`
+	Prompt     string    `json:"prompt"`
+	Reply      string    `json:"reply"`
+	ExitCode   int       `json:"exit_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Queued     bool      `json:"queued,omitempty"` // delivered from the offline queue
+	Note       bool      `json:"note,omitempty"`   // an annotation from ccb note; no reply was awaited
+}
+
+// Filter selects entries in Read. Zero fields match everything.
+type Filter struct {
+	Provider string
+	Contains string    // case-insensitive match on prompt or reply
+	Since    time.Time // entries at or after this time
+	Limit    int       // keep only the newest Limit matches
+}
+
+// Match reports whether e passes the filter (ignoring Limit).
+func (f Filter) Match(e Entry) bool {
+	if f.Provider != "" && e.Provider != f.Provider {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Contains != "" {
+		needle := strings.ToLower(f.Contains)
+		if !strings.Contains(strings.ToLower(e.Prompt), needle) && !strings.Contains(strings.ToLower(e.Reply), needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dir returns the history directory under runDir.
+func Dir(runDir string) string {
+	return filepath.Join(runDir, "history")
+}
+
+// File returns the history file for workDir's project.
+func File(dir, workDir string) string {
+	return filepath.Join(dir, config.ComputeCCBProjectID(workDir)+".jsonl")
+}
+
+// Enabled reports whether asks are recorded. Set CCB_HISTORY=0 to stop.
+func Enabled() bool {
+	v := strings.TrimSpace(os.Getenv("CCB_HISTORY"))
+	return v != "0" && !strings.EqualFold(v, "false")
+}
+
+var appendMu sync.Mutex
+
+// Append adds e to the project history file under dir.
+func Append(dir string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(File(dir, e.WorkDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Read returns the entries in path that match f, oldest first. A missing
+// file is an empty history; malformed lines are skipped.
+func Read(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || !f.Match(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if f.Limit > 0 && len(entries) > 2*f.Limit {
+			entries = append(entries[:0], entries[len(entries)-f.Limit:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[len(entries)-f.Limit:]
+	}
+	return entries, nil
+}
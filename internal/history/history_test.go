@@ -0,0 +1,67 @@
+package history
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAppendRead(t *testing.T) {
+	dir := t.TempDir()
+	work := t.TempDir()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, ReqID: "r1", Provider: "codex", WorkDir: work, Prompt: "fix the race", Reply: "done"},
+		{Time: base.Add(time.Minute), ReqID: "r2", Provider: "gemini", WorkDir: work, Prompt: "review", Reply: "LGTM"},
+		{Time: base.Add(2 * time.Minute), ReqID: "r3", Provider: "codex", WorkDir: work, Prompt: "again", Reply: "Race fixed"},
+	}
+	for _, e := range entries {
+		if err := Append(dir, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := File(dir, work)
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("not json\n")
+	f.Close()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"r1", "r2", "r3"}},
+		{"provider", Filter{Provider: "codex"}, []string{"r1", "r3"}},
+		{"limit keeps newest", Filter{Limit: 2}, []string{"r2", "r3"}},
+		{"contains prompt or reply", Filter{Contains: "RACE"}, []string{"r1", "r3"}},
+		{"since", Filter{Since: base.Add(30 * time.Second)}, []string{"r2", "r3"}},
+		{"provider and limit", Filter{Provider: "codex", Limit: 1}, []string{"r3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(path, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, e := range got {
+				ids = append(ids, e.ReqID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	got, err := Read(File(t.TempDir(), "/nowhere"), Filter{})
+	if err != nil || got != nil {
+		t.Fatalf("Read(missing) = %v, %v", got, err)
+	}
+}
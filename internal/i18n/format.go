@@ -0,0 +1,174 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Formatter renders numbers, durations and sizes for one language, for
+// status-style output (elapsed times, counts, byte sizes).
+type Formatter struct {
+	lang string
+}
+
+// durationUnits are the unit suffixes of one language, largest first.
+type durationUnits struct {
+	hour, minute, second, milli string
+	sep                         string // between "1h" and "5m"
+}
+
+var unitsByLang = map[string]durationUnits{
+	LangEN: {hour: "h", minute: "m", second: "s", milli: "ms", sep: " "},
+	LangZH: {hour: "小时", minute: "分", second: "秒", milli: "毫秒"},
+	LangJA: {hour: "時間", minute: "分", second: "秒", milli: "ミリ秒"},
+}
+
+// bigUnits are the myriad-based count units of zh and ja (10^4, 10^8).
+var bigUnits = map[string][2]string{
+	LangZH: {"万", "亿"},
+	LangJA: {"万", "億"},
+}
+
+// GetFormatter returns the Formatter for the detected language.
+func GetFormatter() Formatter {
+	return FormatterFor(DetectLanguage())
+}
+
+// FormatterFor returns the Formatter for lang, falling back to English.
+func FormatterFor(lang string) Formatter {
+	if _, ok := unitsByLang[lang]; !ok {
+		lang = LangEN
+	}
+	return Formatter{lang: lang}
+}
+
+// Lang returns the formatter's language.
+func (f Formatter) Lang() string {
+	if f.lang == "" {
+		return LangEN
+	}
+	return f.lang
+}
+
+// Duration renders d at a precision suited to its size: "850ms", "4.2s",
+// "3m 5s", "2h 10m" in English; "4.2秒", "3分5秒", "2小时10分" in Chinese.
+func (f Formatter) Duration(d time.Duration) string {
+	u := unitsByLang[f.Lang()]
+	neg := ""
+	if d < 0 {
+		neg, d = "-", -d
+	}
+	switch {
+	case d < time.Second:
+		return neg + strconv.FormatInt(d.Milliseconds(), 10) + u.milli
+	case d < time.Minute:
+		return neg + strconv.FormatFloat(d.Seconds(), 'f', 1, 64) + u.second
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return neg + pair(int64(d/time.Minute), u.minute, int64(d%time.Minute/time.Second), u.second, u.sep)
+	default:
+		d = d.Round(time.Minute)
+		return neg + pair(int64(d/time.Hour), u.hour, int64(d%time.Hour/time.Minute), u.minute, u.sep)
+	}
+}
+
+// pair renders "<a><ua><sep><b><ub>", dropping a zero second part.
+func pair(a int64, ua string, b int64, ub, sep string) string {
+	s := strconv.FormatInt(a, 10) + ua
+	if b != 0 {
+		s += sep + strconv.FormatInt(b, 10) + ub
+	}
+	return s
+}
+
+// Count renders n with digit grouping ("12,345"); zh and ja switch to
+// myriad units from ten thousand on ("1.2万", "3.4亿"/"3.4億").
+func (f Formatter) Count(n int64) string {
+	if units, ok := bigUnits[f.Lang()]; ok {
+		abs := n
+		if abs < 0 {
+			abs = -abs
+		}
+		switch {
+		case abs >= 1e8:
+			return trimZero(float64(n)/1e8) + units[1]
+		case abs >= 1e4:
+			return trimZero(float64(n)/1e4) + units[0]
+		}
+	}
+	return group(n)
+}
+
+// group inserts thousands separators.
+func group(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if neg {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+// trimZero renders v with one decimal, dropping a trailing ".0".
+func trimZero(v float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(v, 'f', 1, 64), ".0")
+}
+
+// Bytes renders a size in binary units: "512 B", "1.5 KiB", "20 MiB".
+// The unit symbols are the same in every language.
+func (f Formatter) Bytes(n int64) string {
+	if n < 1024 && n > -1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	v, i := float64(n)/1024, 0
+	for (v >= 1024 || v <= -1024) && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return trimZero(v) + " " + units[i]
+}
+
+// Bidi control characters that can reorder the text around them.
+const (
+	firstStrongIsolate = '\u2068'
+	popDirIsolate      = '\u2069'
+)
+
+// BidiSafe makes text from elsewhere (pane titles, provider output, file
+// names) safe to embed in a line of output: explicit bidi embeddings,
+// overrides and isolates are removed, and text containing right-to-left
+// characters is wrapped in an isolate so it cannot reorder its
+// surroundings.
+func BidiSafe(s string) string {
+	rtl := false
+	clean := strings.Map(func(r rune) rune {
+		if isBidiControl(r) {
+			return -1
+		}
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			rtl = true
+		}
+		return r
+	}, s)
+	if !rtl {
+		return clean
+	}
+	return string(firstStrongIsolate) + clean + string(popDirIsolate)
+}
+
+// isBidiControl reports whether r is an explicit directional formatting
+// character (LRE, RLE, PDF, LRO, RLO, LRI, RLI, FSI, PDI).
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
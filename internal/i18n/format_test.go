@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		lang string
+		d    time.Duration
+		want string
+	}{
+		{LangEN, 850 * time.Millisecond, "850ms"},
+		{LangEN, 4200 * time.Millisecond, "4.2s"},
+		{LangEN, 3*time.Minute + 5*time.Second, "3m 5s"},
+		{LangEN, 3 * time.Minute, "3m"},
+		{LangEN, 2*time.Hour + 10*time.Minute + 20*time.Second, "2h 10m"},
+		{LangEN, -1500 * time.Millisecond, "-1.5s"},
+		{LangZH, 850 * time.Millisecond, "850毫秒"},
+		{LangZH, 4200 * time.Millisecond, "4.2秒"},
+		{LangZH, 3*time.Minute + 5*time.Second, "3分5秒"},
+		{LangZH, 2*time.Hour + 10*time.Minute, "2小时10分"},
+		{LangJA, 850 * time.Millisecond, "850ミリ秒"},
+		{LangJA, 2*time.Hour + 10*time.Minute, "2時間10分"},
+		{"fr", 4200 * time.Millisecond, "4.2s"},
+	}
+	for _, tt := range tests {
+		if got := FormatterFor(tt.lang).Duration(tt.d); got != tt.want {
+			t.Errorf("%s Duration(%v) = %q, want %q", tt.lang, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		lang string
+		n    int64
+		want string
+	}{
+		{LangEN, 0, "0"},
+		{LangEN, 999, "999"},
+		{LangEN, 1234567, "1,234,567"},
+		{LangEN, -12345, "-12,345"},
+		{LangZH, 9999, "9,999"},
+		{LangZH, 12000, "1.2万"},
+		{LangZH, 30000, "3万"},
+		{LangZH, 340000000, "3.4亿"},
+		{LangJA, 340000000, "3.4億"},
+		{LangJA, -25000, "-2.5万"},
+	}
+	for _, tt := range tests {
+		if got := FormatterFor(tt.lang).Count(tt.n); got != tt.want {
+			t.Errorf("%s Count(%d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	f := FormatterFor(LangEN)
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{20 << 20, "20 MiB"},
+		{3 << 40, "3 TiB"},
+		{5 << 50, "5120 TiB"},
+	}
+	for _, tt := range tests {
+		if got := f.Bytes(tt.n); got != tt.want {
+			t.Errorf("Bytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBidiSafe(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"codex", "codex"},
+		{"日本語のペイン", "日本語のペイン"},
+		{"access\u202e\u2066 // admin\u2069\u2066", "access // admin"},
+		{"שלום", "\u2068שלום\u2069"},
+		{"reply \u202bمرحبا\u202c done", "\u2068reply مرحبا done\u2069"},
+	}
+	for _, tt := range tests {
+		if got := BidiSafe(tt.in); got != tt.want {
+			t.Errorf("BidiSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package integrate
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// pluginHookIndex is the hook array slot the plugin uses (tmux 3.0+), so
+// it neither clobbers nor duplicates other plugins' hooks when re-sourced.
+const pluginHookIndex = 77
+
+// PluginOptions configures the tmux plugin. TPM users set them as global
+// tmux options before the plugin loads: @ccb-provider, @ccb-key and
+// @ccb-status ("off" drops the status segment).
+type PluginOptions struct {
+	TmuxOptions
+	NoStatus bool
+}
+
+// ReadPluginOptions reads the plugin options from the running tmux server.
+func ReadPluginOptions() PluginOptions {
+	return PluginOptions{
+		TmuxOptions: TmuxOptions{
+			Provider: TmuxGlobalOption("@ccb-provider"),
+			Key:      TmuxGlobalOption("@ccb-key"),
+		},
+		NoStatus: TmuxGlobalOption("@ccb-status") == "off",
+	}
+}
+
+// TmuxGlobalOption returns a global tmux option, or "" when unset.
+func TmuxGlobalOption(name string) string {
+	out, err := exec.Command("tmux", "show-option", "-gqv", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// PluginSnippet renders the configuration loaded by `ccb tmux-plugin`: the
+// ask key binding, the status segment unless disabled, and (tmux 3.0+)
+// hooks that call `ccb tmux-plugin hook` when a pane dies or a client
+// attaches.
+func PluginSnippet(v TmuxVersion, opts PluginOptions) string {
+	opts.TmuxOptions = opts.TmuxOptions.withDefaults()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by `ccb tmux-plugin` for tmux %s.\n", v)
+	if !opts.NoStatus {
+		writeStatusSegment(&b, v, opts.TmuxOptions)
+	}
+	writeAskBinding(&b, v, opts.TmuxOptions)
+
+	if !v.AtLeast(3, 0) {
+		b.WriteString("\n# Pane hooks need tmux 3.0 or newer.\n")
+		return b.String()
+	}
+	hook := func(event, args string) {
+		run := "run-shell -b " + tmuxQuote(formatEscape(shellQuote(opts.Exe))+" tmux-plugin hook "+args)
+		fmt.Fprintf(&b, "set-hook -g %s %s\n", tmuxQuote(fmt.Sprintf("%s[%d]", event, pluginHookIndex)), tmuxQuote(run))
+	}
+	b.WriteString("\n# Report ccb panes that exit; re-register ccb panes when a client attaches\n")
+	hook("pane-died", "pane-died #{hook_pane}")
+	hook("pane-exited", "pane-died #{hook_pane}")
+	hook("client-attached", "client-attached")
+	return b.String()
+}
+
+// PluginConfPath is where `ccb tmux-plugin` writes its configuration.
+func PluginConfPath() string {
+	return filepath.Join(config.GlobalConfigDir(), "tmux-plugin.conf")
+}
+
+// LoadPlugin writes snippet to PluginConfPath and sources it into the
+// running tmux server.
+func LoadPlugin(snippet string) error {
+	path := PluginConfPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(snippet), 0644); err != nil {
+		return err
+	}
+	if out, err := exec.Command("tmux", "source-file", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux source-file %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// TmuxMessage shows msg in the status line of the attached clients.
+func TmuxMessage(msg string) error {
+	return exec.Command("tmux", "display-message", msg).Run()
+}
+
+// RefreshStatus redraws the status line so segments pick up changes.
+func RefreshStatus() error {
+	return exec.Command("tmux", "refresh-client", "-S").Run()
+}
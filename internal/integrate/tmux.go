@@ -0,0 +1,214 @@
+// Package integrate generates terminal multiplexer configuration that
+// makes CCB reachable from the user's terminal (status segments, keys).
+package integrate
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// TmuxVersion is a parsed `tmux -V` version.
+type TmuxVersion struct {
+	Major int
+	Minor int
+}
+
+// AtLeast reports whether v is major.minor or newer.
+func (v TmuxVersion) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+func (v TmuxVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+var tmuxVersionRE = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// ParseTmuxVersion parses `tmux -V` output such as "tmux 3.3a" or
+// "tmux next-3.4". Development builds ("tmux master") are treated as new.
+func ParseTmuxVersion(out string) (TmuxVersion, error) {
+	out = strings.TrimSpace(out)
+	if m := tmuxVersionRE.FindStringSubmatch(out); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		return TmuxVersion{Major: major, Minor: minor}, nil
+	}
+	if strings.HasSuffix(out, "master") {
+		return TmuxVersion{Major: 99}, nil
+	}
+	return TmuxVersion{}, fmt.Errorf("unrecognized tmux version %q", out)
+}
+
+// DetectTmuxVersion runs `tmux -V`.
+func DetectTmuxVersion() (TmuxVersion, error) {
+	out, err := exec.Command("tmux", "-V").Output()
+	if err != nil {
+		return TmuxVersion{}, fmt.Errorf("tmux not available: %w", err)
+	}
+	return ParseTmuxVersion(string(out))
+}
+
+// TmuxOptions configures the generated tmux snippet.
+type TmuxOptions struct {
+	Exe      string // ccb executable name or path (default "ccb")
+	Provider string // provider asked by the key binding (default "codex")
+	Key      string // key bound under the prefix (default "C")
+}
+
+func (o TmuxOptions) withDefaults() TmuxOptions {
+	if o.Exe == "" {
+		o.Exe = "ccb"
+	}
+	if o.Provider == "" {
+		o.Provider = "codex"
+	}
+	if o.Key == "" {
+		o.Key = "C"
+	}
+	return o
+}
+
+// TmuxSnippet renders the tmux configuration for the given tmux version:
+// a status-right segment fed by `ccb statusline` and a key that sends the
+// most recent selection (paste buffer) to a provider.
+func TmuxSnippet(v TmuxVersion, opts TmuxOptions) string {
+	opts = opts.withDefaults()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by `ccb integrate tmux` for tmux %s. Re-run to regenerate.\n", v)
+	writeStatusSegment(&b, v, opts)
+	writeAskBinding(&b, v, opts)
+	return b.String()
+}
+
+// writeStatusSegment appends the `ccb statusline` status-right segment.
+func writeStatusSegment(b *strings.Builder, v TmuxVersion, opts TmuxOptions) {
+	b.WriteString("set -g status-interval 5\n")
+	b.WriteString("set -g status-right-length 120\n")
+
+	// Append to the user's status-right once, even if this file is sourced
+	// repeatedly. #() commands run in the tmux server's directory, so the
+	// active pane's directory selects the project to report on.
+	segment := fmt.Sprintf("#(%s statusline --dir '#{pane_current_path}')", formatEscape(shellQuote(opts.Exe)))
+	appendSegment := "set -ag status-right " + tmuxQuote(" "+segment)
+	if v.AtLeast(3, 0) {
+		fmt.Fprintf(b, "if -F '#{m:*statusline*,#{status-right}}' '' %s\n", tmuxQuote(appendSegment))
+	} else {
+		fmt.Fprintf(b, "if-shell %s %s\n", tmuxQuote("! tmux show-options -gv status-right | grep -q statusline"), tmuxQuote(appendSegment))
+	}
+}
+
+// writeAskBinding appends the key that asks opts.Provider about the most
+// recent selection.
+func writeAskBinding(b *strings.Builder, v TmuxVersion, opts TmuxOptions) {
+	askCmd := fmt.Sprintf("tmux save-buffer - | %s ask %s -; printf '\\n[press enter]'; read -r _", shellQuote(opts.Exe), opts.Provider)
+	var view string
+	if v.AtLeast(3, 2) {
+		view = "display-popup -E -w 80% -h 70% " + tmuxQuote(askCmd)
+	} else {
+		view = "split-window -v " + tmuxQuote(askCmd)
+	}
+
+	fmt.Fprintf(b, "\n# prefix+%s: ask %s about the most recent selection\n", opts.Key, opts.Provider)
+	fmt.Fprintf(b, "bind-key %s %s\n", opts.Key, view)
+	if v.AtLeast(2, 4) {
+		// In copy mode, copy the current selection first.
+		for _, table := range []string{"copy-mode", "copy-mode-vi"} {
+			fmt.Fprintf(b, "bind-key -T %s %s send-keys -X copy-selection-and-cancel \\; %s\n", table, opts.Key, view)
+		}
+	}
+}
+
+// TmuxConfPath is where the generated snippet is installed.
+func TmuxConfPath() string {
+	return filepath.Join(config.GlobalConfigDir(), "tmux.conf")
+}
+
+// userTmuxConf locates the user's tmux.conf, preferring existing files.
+func userTmuxConf() string {
+	home, _ := os.UserHomeDir()
+	legacy := filepath.Join(home, ".tmux.conf")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if p := filepath.Join(xdg, "tmux", "tmux.conf"); fileExists(p) {
+		return p
+	}
+	return legacy
+}
+
+// InstallTmux writes snippet to TmuxConfPath and makes the user's
+// tmux.conf source it. It returns the user's tmux.conf path.
+func InstallTmux(snippet string) (string, error) {
+	confPath := TmuxConfPath()
+	if err := os.MkdirAll(filepath.Dir(confPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(confPath, []byte(snippet), 0644); err != nil {
+		return "", err
+	}
+
+	userConf := userTmuxConf()
+	sourceLine := fmt.Sprintf("source-file %s", shellQuote(confPath))
+	existing, _ := os.ReadFile(userConf)
+	if !strings.Contains(string(existing), sourceLine) {
+		f, err := os.OpenFile(userConf, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return userConf, err
+		}
+		defer f.Close()
+		prefix := ""
+		if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+			prefix = "\n"
+		}
+		if _, err := fmt.Fprintf(f, "%s\n# ccb integration\n%s\n", prefix, sourceLine); err != nil {
+			return userConf, err
+		}
+	}
+
+	// Apply to a running server right away.
+	if os.Getenv("TMUX") != "" {
+		exec.Command("tmux", "source-file", confPath).Run()
+	}
+	return userConf, nil
+}
+
+// shellQuote quotes s for sh when it contains anything unusual.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t'\"\\$`;&|<>()*?[]#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tmuxQuote quotes s as one argument in a tmux configuration file: a
+// double-quoted string with backslashes, double quotes and $ (which tmux
+// would expand as an environment variable) escaped.
+func tmuxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(s) + `"`
+}
+
+// formatEscape escapes s for a tmux format, such as a #() command or a
+// run-shell command, where # starts a format sequence.
+func formatEscape(s string) string {
+	return strings.ReplaceAll(s, "#", "##")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package integrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTmuxVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TmuxVersion
+		wantErr bool
+	}{
+		{"tmux 3.3a", TmuxVersion{3, 3}, false},
+		{"tmux 2.8\n", TmuxVersion{2, 8}, false},
+		{"tmux next-3.4", TmuxVersion{3, 4}, false},
+		{"tmux openbsd-7.4", TmuxVersion{7, 4}, false},
+		{"tmux master", TmuxVersion{99, 0}, false},
+		{"garbage", TmuxVersion{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTmuxVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseTmuxVersion(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseTmuxVersion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTmuxSnippetVersionSpecific(t *testing.T) {
+	modern := TmuxSnippet(TmuxVersion{3, 3}, TmuxOptions{})
+	for _, want := range []string{"display-popup", "bind-key C ", "ccb statusline", "ccb ask codex -", "copy-mode-vi"} {
+		if !strings.Contains(modern, want) {
+			t.Errorf("tmux 3.3 snippet missing %q:\n%s", want, modern)
+		}
+	}
+
+	old := TmuxSnippet(TmuxVersion{2, 1}, TmuxOptions{Provider: "gemini", Key: "G"})
+	if strings.Contains(old, "display-popup") {
+		t.Errorf("tmux 2.1 snippet must not use display-popup:\n%s", old)
+	}
+	for _, want := range []string{"split-window", "bind-key G ", "ccb ask gemini -"} {
+		if !strings.Contains(old, want) {
+			t.Errorf("tmux 2.1 snippet missing %q:\n%s", want, old)
+		}
+	}
+	if strings.Contains(old, "copy-selection-and-cancel") {
+		t.Errorf("tmux 2.1 snippet must not bind copy-mode keys:\n%s", old)
+	}
+}
+
+func TestTmuxSnippetStatusSegment(t *testing.T) {
+	// The executable is shell-quoted, then escaped for the tmux format and
+	// for each level of tmux string it is nested in. The project directory
+	// is the active pane's, expanded by tmux when it runs the command.
+	exe := "/opt/it's $HOME/#1/ccb"
+	tests := []struct {
+		v    TmuxVersion
+		want string
+	}{
+		{TmuxVersion{3, 3}, `if -F '#{m:*statusline*,#{status-right}}' '' "set -ag status-right \" #('/opt/it'\\\\''s \\\$HOME/##1/ccb' statusline --dir '#{pane_current_path}')\""`},
+		// Older tmux appends to status-right too, rather than replacing it.
+		{TmuxVersion{2, 8}, `if-shell "! tmux show-options -gv status-right | grep -q statusline" "set -ag status-right \" #('/opt/it'\\\\''s \\\$HOME/##1/ccb' statusline --dir '#{pane_current_path}')\""`},
+	}
+	for _, tt := range tests {
+		snippet := TmuxSnippet(tt.v, TmuxOptions{Exe: exe})
+		if !strings.Contains(snippet, tt.want+"\n") {
+			t.Errorf("tmux %s status segment:\n%s\nwant line:\n%s", tt.v, snippet, tt.want)
+		}
+		if strings.Contains(snippet, "set -g status-right ") {
+			t.Errorf("tmux %s snippet replaces the user's status-right:\n%s", tt.v, snippet)
+		}
+	}
+}
+
+func TestTmuxQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      `"plain"`,
+		`say "hi"`:   `"say \"hi\""`,
+		`C:\bin`:     `"C:\\bin"`,
+		"$HOME/#{x}": `"\$HOME/#{x}"`,
+	}
+	for in, want := range tests {
+		if got := tmuxQuote(in); got != want {
+			t.Errorf("tmuxQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestPluginSnippet(t *testing.T) {
+	modern := PluginSnippet(TmuxVersion{3, 3}, PluginOptions{TmuxOptions: TmuxOptions{Provider: "gemini"}})
+	for _, want := range []string{
+		"ccb statusline",
+		"ccb ask gemini -",
+		`set-hook -g "pane-died[77]" "run-shell -b \"ccb tmux-plugin hook pane-died #{hook_pane}\""`,
+		`set-hook -g "client-attached[77]" "run-shell -b \"ccb tmux-plugin hook client-attached\""`,
+	} {
+		if !strings.Contains(modern, want) {
+			t.Errorf("plugin snippet missing %q:\n%s", want, modern)
+		}
+	}
+
+	noStatus := PluginSnippet(TmuxVersion{3, 3}, PluginOptions{NoStatus: true})
+	if strings.Contains(noStatus, "statusline") {
+		t.Errorf("@ccb-status off still adds the segment:\n%s", noStatus)
+	}
+
+	old := PluginSnippet(TmuxVersion{2, 8}, PluginOptions{})
+	if strings.Contains(old, "set-hook") {
+		t.Errorf("tmux 2.8 plugin snippet must not use hook arrays:\n%s", old)
+	}
+}
+
+func TestWeztermSnippet(t *testing.T) {
+	lua := WeztermSnippet(WeztermOptions{Exe: `C:\Tools\ccb.exe`, Provider: "gemini"})
+	for _, want := range []string{
+		`exe = "C:\\Tools\\ccb.exe",`,
+		`provider = "gemini",`,
+		`key = "c",`,
+		`key = "s",`,
+		`mods = "CTRL|ALT",`,
+		`{ M.exe, 'statusline', '--json' }`,
+		`{ M.exe, 'integrate', 'wezterm-ask', M.provider, path }`,
+		"function M.apply_to_config(config)",
+	} {
+		if !strings.Contains(lua, want) {
+			t.Errorf("wezterm snippet missing %q:\n%s", want, lua)
+		}
+	}
+	if strings.Contains(lua, "$") {
+		t.Errorf("unreplaced placeholder in wezterm snippet:\n%s", lua)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("ccb"); got != "ccb" {
+		t.Errorf("shellQuote(ccb) = %q", got)
+	}
+	if got := shellQuote("/opt/my tools/ccb"); got != "'/opt/my tools/ccb'" {
+		t.Errorf("shellQuote with space = %q", got)
+	}
+}
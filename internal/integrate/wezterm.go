@@ -0,0 +1,205 @@
+package integrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WeztermOptions configures the generated wezterm.lua module.
+type WeztermOptions struct {
+	Exe       string // ccb executable name or path (default "ccb")
+	Provider  string // provider asked by the key binding (default "codex")
+	Mods      string // modifiers for both keys (default "CTRL|ALT")
+	Key       string // asks about the selection (default "c")
+	ToggleKey string // shows/hides the status cell (default "s")
+}
+
+func (o WeztermOptions) withDefaults() WeztermOptions {
+	if o.Exe == "" {
+		o.Exe = "ccb"
+	}
+	if o.Provider == "" {
+		o.Provider = "codex"
+	}
+	if o.Mods == "" {
+		o.Mods = "CTRL|ALT"
+	}
+	if o.Key == "" {
+		o.Key = "c"
+	}
+	if o.ToggleKey == "" {
+		o.ToggleKey = "s"
+	}
+	return o
+}
+
+// WeztermSnippet renders ccb.lua, a module for wezterm.lua that binds a
+// key to ask a provider about the selection and shows a status cell fed by
+// `ccb statusline --json`. The Lua only glues keys and events to the ccb
+// binary, which does the work.
+func WeztermSnippet(opts WeztermOptions) string {
+	opts = opts.withDefaults()
+	r := strings.NewReplacer(
+		"$EXE", luaQuote(opts.Exe),
+		"$PROVIDER", luaQuote(opts.Provider),
+		"$MODS", luaQuote(opts.Mods),
+		"$KEY", luaQuote(opts.Key),
+		"$TOGGLE", luaQuote(opts.ToggleKey),
+	)
+	return r.Replace(weztermLua)
+}
+
+const weztermLua = `-- Generated by ` + "`ccb integrate wezterm`" + `. Re-run to regenerate.
+-- In wezterm.lua:
+--   local ccb = require 'ccb'
+--   ccb.apply_to_config(config)
+local wezterm = require 'wezterm'
+
+local M = {
+  exe = $EXE,
+  provider = $PROVIDER,
+  status = true,       -- show the status cell; toggled by the key below
+  refresh_secs = 5,    -- how often ccb statusline runs
+}
+
+local cache = { text = nil, at = 0, dir = nil }
+
+-- Working directory of the pane, for the project's provider status.
+local function pane_dir(pane)
+  local cwd = pane:get_current_working_dir()
+  if not cwd then
+    return nil
+  end
+  if type(cwd) == 'userdata' or type(cwd) == 'table' then
+    return cwd.file_path
+  end
+  -- Older WezTerm returns a file:// URL string.
+  local path = tostring(cwd):gsub('^file://[^/]*', '')
+  if path:match('^/%a:') then
+    path = path:sub(2)
+  end
+  return path
+end
+
+-- Renders the status cell from ` + "`ccb statusline --json`" + `.
+local function status_cell(dir)
+  local args = { M.exe, 'statusline', '--json' }
+  if dir then
+    table.insert(args, '--dir')
+    table.insert(args, dir)
+  end
+  local ok, stdout = wezterm.run_child_process(args)
+  if not ok then
+    return nil
+  end
+  local parsed, st = pcall(wezterm.json_parse, stdout)
+  if not parsed or not st.daemon then
+    return wezterm.format { { Foreground = { AnsiColor = 'Grey' } }, { Text = ' ccb: offline ' } }
+  end
+  local names = {}
+  for name in pairs(st.providers or {}) do
+    table.insert(names, name)
+  end
+  table.sort(names)
+  local cells = { { Text = ' ccb:' } }
+  for _, name in ipairs(names) do
+    local color = st.providers[name] and 'Green' or 'Grey'
+    table.insert(cells, { Foreground = { AnsiColor = color } })
+    table.insert(cells, { Text = ' ' .. name })
+    table.insert(cells, 'ResetAttributes')
+  end
+  if (st.active or 0) > 0 then
+    table.insert(cells, { Text = ' | ' .. st.active .. ' active' })
+  end
+  table.insert(cells, { Text = ' ' })
+  return wezterm.format(cells)
+end
+
+wezterm.on('update-right-status', function(window, pane)
+  if not M.status then
+    window:set_right_status('')
+    return
+  end
+  local dir = pane_dir(pane)
+  if cache.text == nil or dir ~= cache.dir or os.time() - cache.at >= M.refresh_secs then
+    cache.text = status_cell(dir) or cache.text or ''
+    cache.at = os.time()
+    cache.dir = dir
+  end
+  window:set_right_status(cache.text)
+end)
+
+-- Sends the selection to the provider in a split; ccb reads the saved
+-- selection, asks, and keeps the pane open until Enter.
+local function ask_selection(window, pane)
+  local text = window:get_selection_text_for_pane(pane)
+  if text == nil or text == '' then
+    window:toast_notification('ccb', 'Select some text first', nil, 3000)
+    return
+  end
+  local path = os.tmpname()
+  local f = io.open(path, 'w')
+  if not f then
+    return
+  end
+  f:write(text)
+  f:close()
+  pane:split {
+    direction = 'Bottom',
+    size = 0.4,
+    args = { M.exe, 'integrate', 'wezterm-ask', M.provider, path },
+    cwd = pane_dir(pane),
+  }
+end
+
+function M.apply_to_config(config)
+  config.keys = config.keys or {}
+  table.insert(config.keys, {
+    key = $KEY,
+    mods = $MODS,
+    action = wezterm.action_callback(ask_selection),
+  })
+  table.insert(config.keys, {
+    key = $TOGGLE,
+    mods = $MODS,
+    action = wezterm.action_callback(function(window)
+      M.status = not M.status
+      cache.text = nil
+    end),
+  })
+end
+
+return M
+`
+
+// luaQuote renders s as a Lua string literal.
+func luaQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// WeztermConfigDir is where WezTerm looks for modules required by
+// wezterm.lua: $XDG_CONFIG_HOME/wezterm, else ~/.config/wezterm.
+func WeztermConfigDir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "wezterm")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "wezterm")
+}
+
+// InstallWezterm writes snippet as ccb.lua in WeztermConfigDir and returns
+// its path. wezterm.lua is left to the user: it must require the module.
+func InstallWezterm(snippet string) (string, error) {
+	dir := WeztermConfigDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "ccb.lua")
+	if err := os.WriteFile(path, []byte(snippet), 0644); err != nil {
+		return "", fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,45 @@
+package launcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// Bind records paneID as the provider's pane for workDir, writing the
+// session file and registry entry as a launch would. It is for panes ccb
+// did not start or failed to detect. With a backend the pane must be
+// alive; without one it is taken on trust. The previously bound pane, if
+// any, is returned.
+func Bind(provider, paneID, workDir string, backend terminal.Backend) (string, error) {
+	if !isValidProvider(provider) {
+		return "", fmt.Errorf("unknown provider %q (known: %s)", provider, strings.Join(Providers(), ", "))
+	}
+	paneID = strings.TrimSpace(paneID)
+	if paneID == "" {
+		return "", fmt.Errorf("empty pane id")
+	}
+	if backend != nil && !backend.IsAlive(paneID) {
+		return "", fmt.Errorf("pane %s not found in %s", paneID, backend.Name())
+	}
+
+	dir, err := config.EnsureSessionDir(workDir)
+	if err != nil {
+		return "", fmt.Errorf("cannot create %s: %w", config.ProjectConfigDir(workDir), err)
+	}
+	sessionFile := filepath.Join(dir, "."+provider+"-session")
+	if ok, reason, fix := config.CheckSessionWritable(sessionFile); !ok {
+		if fix != "" {
+			reason += " (fix: " + fix + ")"
+		}
+		return "", fmt.Errorf("%s: %s", sessionFile, reason)
+	}
+
+	previous := previousPane(provider, workDir)
+	RegisterSession(provider, paneID, workDir)
+	tagPane(backend, provider, paneID, workDir)
+	return previous, nil
+}
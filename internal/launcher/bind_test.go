@@ -0,0 +1,75 @@
+package launcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+func TestBind(t *testing.T) {
+	runDir := t.TempDir()
+	t.Setenv("CCB_RUN_DIR", runDir)
+	workDir := t.TempDir()
+	backend := &fakeBackend{alive: map[string]bool{"%4": true, "%5": true}}
+
+	if _, err := Bind("nope", "%4", workDir, backend); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if _, err := Bind("codex", "%9", workDir, backend); err == nil {
+		t.Error("expected an error for a pane that is not alive")
+	}
+
+	prev, err := Bind("codex", "%4", workDir, backend)
+	if err != nil || prev != "" {
+		t.Fatalf("Bind = %q, %v", prev, err)
+	}
+	entry := session.NewPaneRegistry(filepath.Join(runDir, "pane-registry.json")).GetEntry("codex", config.ComputeCCBProjectID(workDir))
+	if entry == nil || entry.PaneID != "%4" || entry.WorkDir != workDir {
+		t.Fatalf("registry entry = %+v", entry)
+	}
+	sessionFile := config.FindProjectSessionFile(workDir, ".codex-session")
+	if !strings.Contains(config.ReadSessionFile(sessionFile), `"pane_id": "%4"`) {
+		t.Errorf("session file %s = %q", sessionFile, config.ReadSessionFile(sessionFile))
+	}
+
+	// Rebinding reports the old pane; a nil backend skips the liveness check.
+	if prev, err = Bind("codex", "%7", workDir, nil); err != nil || prev != "%4" {
+		t.Fatalf("rebind = %q, %v", prev, err)
+	}
+}
+
+func TestAdoptTaggedPanes(t *testing.T) {
+	runDir := t.TempDir()
+	t.Setenv("CCB_RUN_DIR", runDir)
+	app, lib := t.TempDir(), t.TempDir()
+	RegisterSession("codex", "%1", app)  // still alive: keep
+	RegisterSession("gemini", "%2", app) // gone: replace
+	alive := map[string]bool{"%1": true, "%5": true, "%6": true, "%7": true}
+
+	adopted := AdoptTaggedPanes([]terminal.TaggedPane{
+		{ID: "%5", Provider: "codex", WorkDir: app},
+		{ID: "%6", Provider: "gemini", WorkDir: app},
+		{ID: "%7", Provider: "claude", WorkDir: lib},
+		{ID: "%8", Provider: "codex", WorkDir: lib, Dead: true},
+		{ID: "%9", Provider: "nope", WorkDir: lib},
+	}, func(id string) bool { return alive[id] })
+
+	var got []string
+	for _, p := range adopted {
+		got = append(got, p.Provider+" "+p.ID)
+	}
+	if want := "gemini %6,claude %7"; strings.Join(got, ",") != want {
+		t.Errorf("adopted = %v, want %s", got, want)
+	}
+	reg := session.NewPaneRegistry(filepath.Join(runDir, "pane-registry.json"))
+	if e := reg.GetEntry("codex", config.ComputeCCBProjectID(app)); e == nil || e.PaneID != "%1" {
+		t.Errorf("live codex entry replaced: %+v", e)
+	}
+	if e := reg.GetEntry("gemini", config.ComputeCCBProjectID(app)); e == nil || e.PaneID != "%6" {
+		t.Errorf("gemini entry = %+v, want %%6", e)
+	}
+}
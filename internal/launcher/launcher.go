@@ -7,9 +7,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/output"
 	"github.com/anthropics/claude_code_bridge/internal/session"
 	"github.com/anthropics/claude_code_bridge/internal/terminal"
 )
@@ -32,6 +35,21 @@ var AutoApproveSpec = map[string]ProviderAutoSpec{
 	"droid": {
 		// Droid does not have a known auto-approve mechanism
 	},
+	"aider": {
+		CLIFlags: []string{"--yes-always"},
+	},
+	"goose": {
+		ConfigFunc: ensureGooseAutoMode,
+	},
+	"amp": {
+		CLIFlags: []string{"--dangerously-allow-all"},
+	},
+	"cursor": {
+		CLIFlags: []string{"--force"},
+	},
+	"qwen": {
+		CLIFlags: []string{"--yolo"},
+	},
 }
 
 // ProviderAutoSpec holds auto-approve configuration for a provider.
@@ -76,7 +94,7 @@ func ParseProviders(args []string) []string {
 			continue
 		}
 		if !isValidProvider(p) {
-			fmt.Fprintf(os.Stderr, "warning: unknown provider %q, skipping\n", p)
+			output.Warnf("unknown provider %q, skipping", p)
 			continue
 		}
 		seen[p] = true
@@ -86,17 +104,36 @@ func ParseProviders(args []string) []string {
 }
 
 func isValidProvider(name string) bool {
-	switch name {
-	case "codex", "gemini", "opencode", "claude", "droid":
-		return true
+	for _, p := range Providers() {
+		if p == name {
+			return true
+		}
 	}
 	return false
 }
 
+// Providers returns the names of the providers ccb can launch: the
+// built-in ones, then those ccb.config declares for the current directory.
+func Providers() []string {
+	providers := []string{"codex", "gemini", "opencode", "claude", "droid", "aider", "goose", "amp", "cursor", "qwen"}
+	cwd, _ := os.Getwd()
+	var custom []string
+	for name := range config.CustomProviders(cwd) {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	return append(providers, custom...)
+}
+
 // BuildStartCommand builds the CLI start command for a provider.
 // If auto is true, injects auto-approve flags.
 // If resume is true, injects resume/continue flags for the provider.
 func BuildStartCommand(provider string, auto bool, resume bool) (string, error) {
+	cwd, _ := os.Getwd()
+	if spec, ok := config.LookupCustomProvider(cwd, provider); ok {
+		return buildCustomStartCommand(spec, auto, resume), nil
+	}
+
 	exe := providerExe(provider)
 	if exe == "" {
 		return "", fmt.Errorf("no CLI executable known for provider %q", provider)
@@ -107,7 +144,7 @@ func BuildStartCommand(provider string, auto bool, resume bool) (string, error)
 		spec, ok := AutoApproveSpec[provider]
 		if ok && spec.ConfigFunc != nil {
 			if err := spec.ConfigFunc(); err != nil {
-				fmt.Fprintf(os.Stderr, "warning: auto-config for %s failed: %v\n", provider, err)
+				output.Warnf("auto-config for %s failed: %v", provider, err)
 			}
 		}
 	}
@@ -122,29 +159,58 @@ func BuildStartCommand(provider string, auto bool, resume bool) (string, error)
 			// Codex resume: codex resume --last [flags]
 			parts = append(parts, "resume", "--last")
 			parts = append(parts, "-c", "disable_paste_burst=true")
-			fmt.Printf("  Resuming %s session...\n", provider)
+			output.Infof("  Resuming %s session...", provider)
 		} else {
 			parts = append(parts, "-c", "disable_paste_burst=true")
 		}
 	case "claude":
 		if resume {
 			parts = append(parts, "--continue")
-			fmt.Printf("  Resuming %s session...\n", provider)
+			output.Infof("  Resuming %s session...", provider)
 		}
 	case "gemini":
 		if resume {
 			parts = append(parts, "--resume", "latest")
-			fmt.Printf("  Resuming %s session...\n", provider)
+			output.Infof("  Resuming %s session...", provider)
 		}
 	case "opencode":
 		if resume {
 			parts = append(parts, "--continue")
-			fmt.Printf("  Resuming %s session...\n", provider)
+			output.Infof("  Resuming %s session...", provider)
 		}
 	case "droid":
 		if resume {
 			parts = append(parts, "-r")
-			fmt.Printf("  Resuming %s session...\n", provider)
+			output.Infof("  Resuming %s session...", provider)
+		}
+	case "aider":
+		if resume {
+			parts = append(parts, "--restore-chat-history")
+			output.Infof("  Resuming %s session...", provider)
+		}
+	case "goose":
+		// Goose: goose session [--resume]
+		parts = append(parts, "session")
+		if resume {
+			parts = append(parts, "--resume")
+			output.Infof("  Resuming %s session...", provider)
+		}
+	case "amp":
+		if resume {
+			// Amp resume: amp threads continue [flags]
+			parts = append(parts, "threads", "continue")
+			output.Infof("  Resuming %s session...", provider)
+		}
+	case "cursor":
+		if resume {
+			// cursor-agent resume: cursor-agent resume [flags]
+			parts = append(parts, "resume")
+			output.Infof("  Resuming %s session...", provider)
+		}
+	case "qwen":
+		if resume {
+			parts = append(parts, "--continue")
+			output.Infof("  Resuming %s session...", provider)
 		}
 	}
 
@@ -159,6 +225,21 @@ func BuildStartCommand(provider string, auto bool, resume bool) (string, error)
 	return strings.Join(parts, " "), nil
 }
 
+// buildCustomStartCommand builds the start command for a provider declared
+// in ccb.config from its declared flags.
+func buildCustomStartCommand(spec config.CustomProvider, auto bool, resume bool) string {
+	parts := []string{findExe(spec.Command)}
+	parts = append(parts, spec.Args...)
+	if resume && len(spec.ResumeArgs) > 0 {
+		parts = append(parts, spec.ResumeArgs...)
+		output.Infof("  Resuming %s session...", spec.Name)
+	}
+	if auto {
+		parts = append(parts, spec.AutoArgs...)
+	}
+	return strings.Join(parts, " ")
+}
+
 // Launch launches multiple providers in terminal panes.
 func Launch(cfg LaunchConfig) ([]LaunchResult, error) {
 	if len(cfg.Providers) == 0 {
@@ -202,59 +283,62 @@ func launchWithBackend(cfg LaunchConfig, backend terminal.Backend) ([]LaunchResu
 		var paneID string
 		if i == 0 && len(cfg.Providers) == 1 {
 			// Single provider: run in current pane directly
-			fmt.Printf("Starting %s...\n", provider)
+			output.Infof("Starting %s...", provider)
 			if cfg.Auto {
-				fmt.Printf("  [auto-approve mode enabled]\n")
+				output.Infof("  [auto-approve mode enabled]")
 			}
 			execErr := execInCurrentPane(backend, currentPaneID, cmd)
 			if execErr != nil {
 				results = append(results, LaunchResult{Provider: provider, Command: cmd, Error: execErr})
-				fmt.Printf("Failed to start %s: %v\n", provider, execErr)
+				output.Errorf("Failed to start %s: %v", provider, execErr)
 				continue
 			}
 			paneID = currentPaneID
-			fmt.Printf("Started %s in pane %s\n", provider, paneID)
+			output.Infof("Started %s in pane %s", provider, paneID)
 		} else if i == 0 {
 			// First of multiple providers: send command to current pane
-			fmt.Printf("Starting %s in current pane...\n", provider)
+			output.Infof("Starting %s in current pane...", provider)
 			if cfg.Auto {
-				fmt.Printf("  [auto-approve mode enabled]\n")
+				output.Infof("  [auto-approve mode enabled]")
 			}
 			execErr := execInCurrentPane(backend, currentPaneID, cmd)
 			if execErr != nil {
 				results = append(results, LaunchResult{Provider: provider, Command: cmd, Error: execErr})
-				fmt.Printf("Failed to start %s: %v\n", provider, execErr)
+				output.Errorf("Failed to start %s: %v", provider, execErr)
 				continue
 			}
 			paneID = currentPaneID
-			fmt.Printf("Started %s in pane %s\n", provider, paneID)
+			output.Infof("Started %s in pane %s", provider, paneID)
 		} else {
 			// Subsequent providers: split from current pane
 			newID, splitErr := backend.SplitWindow(currentPaneID, cmd)
 			if splitErr != nil {
 				// Fallback: try spawning a new tab
-				fmt.Printf("  split failed, trying new tab for %s...\n", provider)
+				output.Infof("  split failed, trying new tab for %s...", provider)
 				newID, splitErr = trySpawnWindow(backend, provider, cmd)
 			}
 			if splitErr != nil {
 				results = append(results, LaunchResult{Provider: provider, Command: cmd, Error: splitErr})
-				fmt.Printf("Failed to start %s: %v\n", provider, splitErr)
+				output.Errorf("Failed to start %s: %v", provider, splitErr)
 				continue
 			}
 			paneID = newID
-			fmt.Printf("Started %s in pane %s\n", provider, paneID)
+			output.Infof("Started %s in pane %s", provider, paneID)
 			if cfg.Auto {
-				fmt.Printf("  [auto-approve mode enabled]\n")
+				output.Infof("  [auto-approve mode enabled]")
 			}
 
 			// Set pane title for identification
-			backend.SetPaneTitle(paneID, fmt.Sprintf("ccb-%s", provider))
+			if err := backend.SetPaneTitle(paneID, fmt.Sprintf("ccb-%s", provider)); err != nil {
+				output.Debugf("cannot set title of pane %s: %v", paneID, err)
+			}
 		}
 
 		results = append(results, LaunchResult{Provider: provider, PaneID: paneID, Command: cmd})
 
 		// Register session so /cask, /gask etc. can find this pane
-		registerSession(provider, paneID, cfg.WorkDir)
+		RegisterSession(provider, paneID, cfg.WorkDir)
+		tagPane(backend, provider, paneID, cfg.WorkDir)
 	}
 
 	return results, nil
@@ -265,9 +349,10 @@ func execInCurrentPane(backend terminal.Backend, paneID string, cmd string) erro
 	return backend.SendKeys(paneID, cmd)
 }
 
-// registerSession writes the pane ID to the session file and pane registry
-// so that /cask, /gask etc. can find the provider's pane.
-func registerSession(provider string, paneID string, workDir string) {
+// RegisterSession writes the pane ID to the session file and pane registry
+// so that /cask, /gask etc. can find the provider's pane. Other fields of
+// an existing registry entry are kept.
+func RegisterSession(provider string, paneID string, workDir string) {
 	if paneID == "" {
 		return
 	}
@@ -284,10 +369,14 @@ func registerSession(provider string, paneID string, workDir string) {
 	registryPath := filepath.Join(ccbRunDir(), "pane-registry.json")
 	registry := session.NewPaneRegistry(registryPath)
 	projectID := config.ComputeCCBProjectID(workDir)
-	registry.Upsert(provider, projectID, &session.PaneEntry{
-		PaneID:  paneID,
-		WorkDir: workDir,
-	})
+	entry := &session.PaneEntry{}
+	if existing := registry.GetEntry(provider, projectID); existing != nil {
+		*entry = *existing
+	}
+	entry.PaneID = paneID
+	entry.WorkDir = workDir
+	entry.UpdatedAt = time.Now().Unix()
+	registry.Upsert(provider, projectID, entry)
 }
 
 // writeSessionFile writes or updates a session file.
@@ -470,6 +559,16 @@ func providerExe(provider string) string {
 		return findExe("claude")
 	case "droid":
 		return findExe("droid")
+	case "aider":
+		return findExe("aider")
+	case "goose":
+		return findExe("goose")
+	case "amp":
+		return findExe("amp")
+	case "cursor":
+		return findExe("cursor-agent")
+	case "qwen":
+		return findExe("qwen")
 	}
 	return ""
 }
@@ -561,3 +660,28 @@ func ensureOpenCodeAutoConfig() error {
 	out, _ := json.MarshalIndent(cfg, "", "  ")
 	return os.WriteFile(configFile, out, 0600)
 }
+
+// ensureGooseAutoMode sets GOOSE_MODE to auto in Goose's config.yaml, so
+// tools run without asking. Other settings in the file are kept.
+func ensureGooseAutoMode() error {
+	configDir := filepath.Dir(session.ProviderLogRoot("goose"))
+	configFile := filepath.Join(configDir, "config.yaml")
+
+	var lines []string
+	if data, err := os.ReadFile(configFile); err == nil {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "GOOSE_MODE:") {
+			if strings.TrimSpace(strings.TrimPrefix(line, "GOOSE_MODE:")) == "auto" {
+				return nil // Already configured
+			}
+			lines[i] = "GOOSE_MODE: auto"
+			return os.WriteFile(configFile, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+		}
+	}
+
+	os.MkdirAll(configDir, 0755)
+	lines = append(lines, "GOOSE_MODE: auto")
+	return os.WriteFile(configFile, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
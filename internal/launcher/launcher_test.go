@@ -1,7 +1,11 @@
 package launcher
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
 )
 
 func TestParseProviders(t *testing.T) {
@@ -139,6 +143,39 @@ func TestBuildStartCommand(t *testing.T) {
 			resume:   true,
 			contains: []string{"droid", "-r"},
 		},
+		{
+			provider: "aider",
+			auto:     true,
+			contains: []string{"aider", "--yes-always"},
+		},
+		{
+			provider: "aider",
+			resume:   true,
+			contains: []string{"aider", "--restore-chat-history"},
+		},
+		{
+			provider: "goose",
+			resume:   true,
+			contains: []string{"goose", "session", "--resume"},
+		},
+		{
+			provider: "amp",
+			auto:     true,
+			resume:   true,
+			contains: []string{"amp", "threads continue", "--dangerously-allow-all"},
+		},
+		{
+			provider: "cursor",
+			auto:     true,
+			resume:   true,
+			contains: []string{"cursor-agent", "resume", "--force"},
+		},
+		{
+			provider: "qwen",
+			auto:     true,
+			resume:   true,
+			contains: []string{"qwen", "--continue", "--yolo"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -170,8 +207,35 @@ func TestBuildStartCommandUnknown(t *testing.T) {
 	}
 }
 
+func TestBuildStartCommandCustom(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	os.MkdirAll(filepath.Join(home, ".ccb"), 0755)
+	os.WriteFile(filepath.Join(home, ".ccb", config.ConfigFilename), []byte(`{"custom_providers": {"ccbtestcli": {
+		"command": "ccbtestcli", "args": "--cwd .", "auto_args": ["--yes"], "resume_args": ["--continue"]}}}`), 0644)
+
+	tests := []struct {
+		auto, resume bool
+		want         string
+	}{
+		{false, false, "ccbtestcli --cwd ."},
+		{true, false, "ccbtestcli --cwd . --yes"},
+		{true, true, "ccbtestcli --cwd . --continue --yes"},
+	}
+	for _, tt := range tests {
+		cmd, err := BuildStartCommand("ccbtestcli", tt.auto, tt.resume)
+		if err != nil || cmd != tt.want {
+			t.Errorf("BuildStartCommand(auto=%v, resume=%v) = %q, %v; want %q", tt.auto, tt.resume, cmd, err, tt.want)
+		}
+	}
+	if !isValidProvider("ccbtestcli") {
+		t.Error("isValidProvider(ccbtestcli) = false, want true")
+	}
+}
+
 func TestIsValidProvider(t *testing.T) {
-	valid := []string{"codex", "gemini", "opencode", "claude", "droid"}
+	valid := []string{"codex", "gemini", "opencode", "claude", "droid", "aider", "goose", "amp", "cursor", "qwen"}
 	for _, p := range valid {
 		if !isValidProvider(p) {
 			t.Errorf("isValidProvider(%q) = false, want true", p)
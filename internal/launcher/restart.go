@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// RestartConfig holds the configuration for restarting one provider.
+type RestartConfig struct {
+	Provider string
+	Auto     bool   // auto-approve mode (-a)
+	Resume   bool   // resume the provider's last session
+	WorkDir  string // project whose pane is restarted
+	Force    bool   // replace the pane even if it is still alive
+}
+
+// paneRespawner is implemented by backends that can restart a command in
+// an existing pane (tmux respawn-pane).
+type paneRespawner interface {
+	RespawnPane(paneID string, cmd string) error
+}
+
+// Restart starts a provider again for a project whose pane died, reusing
+// the pane when the backend can respawn it and splitting a new one
+// otherwise. The registry and session file are updated to the new pane.
+func Restart(cfg RestartConfig) (LaunchResult, error) {
+	backend, err := terminal.DetectBackend()
+	if err != nil {
+		return LaunchResult{Provider: cfg.Provider}, err
+	}
+	return restartWithBackend(cfg, backend)
+}
+
+func restartWithBackend(cfg RestartConfig, backend terminal.Backend) (LaunchResult, error) {
+	res := LaunchResult{Provider: cfg.Provider}
+	if !isValidProvider(cfg.Provider) {
+		return res, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+	if cfg.WorkDir == "" {
+		cfg.WorkDir, _ = os.Getwd()
+	}
+
+	oldPane := previousPane(cfg.Provider, cfg.WorkDir)
+	alive := oldPane != "" && backend.IsAlive(oldPane)
+	if alive && !cfg.Force {
+		return res, fmt.Errorf("%s is still running in pane %s (use --force to replace it)", cfg.Provider, oldPane)
+	}
+
+	cmd, err := BuildStartCommand(cfg.Provider, cfg.Auto, cfg.Resume)
+	if err != nil {
+		return res, err
+	}
+	res.Command = cmd
+
+	paneID := ""
+	if r, ok := backend.(paneRespawner); ok && oldPane != "" {
+		// respawn-pane -k replaces a live process too, so no kill needed.
+		if err := r.RespawnPane(oldPane, cmd); err == nil {
+			paneID = oldPane
+		}
+	}
+	if paneID == "" {
+		if alive {
+			if err := backend.KillPane(oldPane); err != nil {
+				return res, fmt.Errorf("failed to kill pane %s: %w", oldPane, err)
+			}
+		}
+		paneID, err = backend.SplitWindow(resolveCurrentPaneID(backend), cmd)
+		if err != nil {
+			paneID, err = trySpawnWindow(backend, cfg.Provider, cmd)
+		}
+		if err != nil {
+			res.Error = err
+			return res, fmt.Errorf("failed to start %s: %w", cfg.Provider, err)
+		}
+		backend.SetPaneTitle(paneID, fmt.Sprintf("ccb-%s", cfg.Provider))
+	}
+
+	res.PaneID = paneID
+	RegisterSession(cfg.Provider, paneID, cfg.WorkDir)
+	tagPane(backend, cfg.Provider, paneID, cfg.WorkDir)
+	return res, nil
+}
+
+// previousPane returns the provider's last known pane for workDir, from
+// the registry or else the project session file.
+func previousPane(provider, workDir string) string {
+	registry := session.NewPaneRegistry(filepath.Join(ccbRunDir(), "pane-registry.json"))
+	if entry := registry.GetEntry(provider, config.ComputeCCBProjectID(workDir)); entry != nil && entry.PaneID != "" {
+		return entry.PaneID
+	}
+	path := config.FindProjectSessionFile(workDir, fmt.Sprintf(".%s-session", provider))
+	if path == "" {
+		return ""
+	}
+	content := config.ReadSessionFile(path)
+	var sess struct {
+		PaneID string `json:"pane_id"`
+	}
+	if strings.HasPrefix(content, "{") {
+		if json.Unmarshal([]byte(content), &sess) == nil {
+			return sess.PaneID
+		}
+		return ""
+	}
+	return content
+}
@@ -0,0 +1,89 @@
+package launcher
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// fakeBackend records splits and kills; other Backend methods are unused.
+type fakeBackend struct {
+	terminal.Backend
+	alive  map[string]bool
+	splits []string
+	killed []string
+}
+
+func (b *fakeBackend) Name() string                            { return "fake" }
+func (b *fakeBackend) IsAlive(paneID string) bool              { return b.alive[paneID] }
+func (b *fakeBackend) ListPanes() ([]terminal.PaneInfo, error) { return nil, nil }
+func (b *fakeBackend) SetPaneTitle(string, string) error       { return nil }
+func (b *fakeBackend) KillPane(paneID string) error {
+	b.killed = append(b.killed, paneID)
+	return nil
+}
+func (b *fakeBackend) SplitWindow(target, cmd string) (string, error) {
+	b.splits = append(b.splits, cmd)
+	return "%new", nil
+}
+
+// respawnBackend can respawn panes in place, like tmux.
+type respawnBackend struct {
+	fakeBackend
+	respawned []string
+	fail      bool
+}
+
+func (b *respawnBackend) RespawnPane(paneID, cmd string) error {
+	if b.fail {
+		return errors.New("pane gone")
+	}
+	b.respawned = append(b.respawned, paneID)
+	return nil
+}
+
+func TestRestartWithBackend(t *testing.T) {
+	runDir := t.TempDir()
+	t.Setenv("CCB_RUN_DIR", runDir)
+	t.Setenv("TMUX_PANE", "%0")
+	workDir := t.TempDir()
+	regPath := filepath.Join(runDir, "pane-registry.json")
+	projectID := config.ComputeCCBProjectID(workDir)
+	session.NewPaneRegistry(regPath).Upsert("gemini", projectID, &session.PaneEntry{PaneID: "%3", WorkDir: workDir, SessionID: "s1"})
+	cfg := RestartConfig{Provider: "gemini", WorkDir: workDir}
+
+	// Live pane without Force is refused.
+	live := &fakeBackend{alive: map[string]bool{"%3": true}}
+	if _, err := restartWithBackend(cfg, live); err == nil {
+		t.Fatal("expected an error for a live pane")
+	}
+
+	// Dead pane on a backend that can respawn is reused.
+	rb := &respawnBackend{}
+	res, err := restartWithBackend(cfg, rb)
+	if err != nil || res.PaneID != "%3" || len(rb.respawned) != 1 || len(rb.splits) != 0 {
+		t.Fatalf("respawn: res = %+v, err = %v, backend = %+v", res, err, rb)
+	}
+
+	// When respawning fails, a new pane is split and registered.
+	rb = &respawnBackend{fail: true}
+	res, err = restartWithBackend(cfg, rb)
+	if err != nil || res.PaneID != "%new" || len(rb.splits) != 1 {
+		t.Fatalf("split: res = %+v, err = %v", res, err)
+	}
+	entry := session.NewPaneRegistry(regPath).GetEntry("gemini", projectID)
+	if entry == nil || entry.PaneID != "%new" || entry.SessionID != "s1" {
+		t.Errorf("registry entry = %+v", entry)
+	}
+
+	// Force replaces a live pane on a backend without respawn.
+	live = &fakeBackend{alive: map[string]bool{"%new": true}}
+	cfg.Force = true
+	if _, err := restartWithBackend(cfg, live); err != nil || len(live.killed) != 1 || live.killed[0] != "%new" {
+		t.Errorf("force: err = %v, killed = %v", err, live.killed)
+	}
+}
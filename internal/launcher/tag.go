@@ -0,0 +1,43 @@
+package launcher
+
+import (
+	"path/filepath"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/session"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// paneTagger is implemented by backends that can mark a pane as a ccb
+// provider pane (tmux pane options), see AdoptTaggedPanes.
+type paneTagger interface {
+	TagPane(paneID, provider, workDir string) error
+}
+
+// tagPane marks paneID as provider's pane for workDir when the backend
+// supports it. Failures (e.g. tmux before 3.0) are ignored.
+func tagPane(backend terminal.Backend, provider, paneID, workDir string) {
+	if t, ok := backend.(paneTagger); ok && paneID != "" {
+		t.TagPane(paneID, provider, workDir)
+	}
+}
+
+// AdoptTaggedPanes registers live tagged panes that the registry has lost
+// track of: their provider has no entry for the project, or the entry
+// points at a pane that is gone. It returns the panes it registered.
+func AdoptTaggedPanes(panes []terminal.TaggedPane, isAlive func(paneID string) bool) []terminal.TaggedPane {
+	registry := session.NewPaneRegistry(filepath.Join(ccbRunDir(), "pane-registry.json"))
+	var adopted []terminal.TaggedPane
+	for _, p := range panes {
+		if p.Dead || p.WorkDir == "" || !isValidProvider(p.Provider) {
+			continue
+		}
+		entry := registry.GetEntry(p.Provider, config.ComputeCCBProjectID(p.WorkDir))
+		if entry != nil && entry.PaneID != "" && (entry.PaneID == p.ID || isAlive(entry.PaneID)) {
+			continue
+		}
+		RegisterSession(p.Provider, p.ID, p.WorkDir)
+		adopted = append(adopted, p)
+	}
+	return adopted
+}
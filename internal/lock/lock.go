@@ -42,6 +42,27 @@ func NewProviderLock(provider string, timeout time.Duration, cwd string) *Provid
 	}
 }
 
+// NewFileLock creates a lock on the file at path, such as a singleton lock
+// in the run dir.
+func NewFileLock(path string, timeout time.Duration) *ProviderLock {
+	return &ProviderLock{
+		Timeout:  timeout,
+		LockDir:  filepath.Dir(path),
+		LockFile: path,
+	}
+}
+
+// Holder returns the PID written by whoever holds (or last held) the
+// lock, or 0 if it can't be read.
+func (l *ProviderLock) Holder() int {
+	data, err := os.ReadFile(l.LockFile)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
 // isPIDAlive checks if a process with the given PID is still running.
 func isPIDAlive(pid int) bool {
 	if pid <= 0 {
@@ -148,6 +169,19 @@ func (l *ProviderLock) tryLockOnce() bool {
 	if err := lockFile(l.fd); err != nil {
 		return false
 	}
+	// Another process may have removed the file as stale after we opened
+	// it; a lock on the unlinked file would exclude no one, so reopen.
+	if !l.stillLinked() {
+		unlockFile(l.fd)
+		l.fd.Close()
+		f, err := os.OpenFile(l.LockFile, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			l.fd = nil
+			return false
+		}
+		l.fd = f
+		return false
+	}
 
 	// Write PID
 	pid := fmt.Sprintf("%d\n", os.Getpid())
@@ -158,6 +192,17 @@ func (l *ProviderLock) tryLockOnce() bool {
 	return true
 }
 
+// stillLinked reports whether the open lock file is still the one at
+// LockFile.
+func (l *ProviderLock) stillLinked() bool {
+	opened, err := l.fd.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(l.LockFile)
+	return err == nil && os.SameFile(opened, current)
+}
+
 // checkStaleLock checks if the current lock holder is dead.
 func (l *ProviderLock) checkStaleLock() bool {
 	data, err := os.ReadFile(l.LockFile)
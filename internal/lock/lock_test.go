@@ -0,0 +1,55 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+	"time"
+)
+
+func TestFileLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "askd.lock")
+	a := NewFileLock(path, time.Second)
+	if !a.TryAcquire() {
+		t.Fatal("first TryAcquire failed")
+	}
+
+	b := NewFileLock(path, 300*time.Millisecond)
+	if b.TryAcquire() {
+		t.Fatal("second TryAcquire succeeded while the lock was held")
+	}
+	if b.Acquire() {
+		t.Fatal("second Acquire succeeded while the lock was held")
+	}
+	// Windows locks the file's contents against other handles.
+	if goruntime.GOOS != "windows" {
+		if pid := b.Holder(); pid != os.Getpid() {
+			t.Errorf("Holder() = %d, want %d", pid, os.Getpid())
+		}
+	}
+
+	a.Release()
+	if !b.TryAcquire() {
+		t.Fatal("TryAcquire failed after release")
+	}
+	b.Release()
+}
+
+func TestFileLockStalePID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "askd.lock")
+	// Left behind by a process that died without unlinking it.
+	if err := os.WriteFile(path, []byte("999999999\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l := NewFileLock(path, time.Second)
+	if !l.Acquire() {
+		t.Fatal("Acquire failed on a stale lock file")
+	}
+	defer l.Release()
+	if goruntime.GOOS != "windows" {
+		if pid := l.Holder(); pid != os.Getpid() {
+			t.Errorf("Holder() = %d, want %d", pid, os.Getpid())
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package mcp serves tools over the Model Context Protocol: JSON-RPC 2.0
+// messages, one per line, on stdio. It implements the subset a tool
+// server needs (initialize, ping, tools/list, tools/call), so agents such
+// as Claude Code can call ccb's providers as native tools.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/anthropics/claude_code_bridge/internal/schema"
+)
+
+// ProtocolVersion is the MCP revision this server speaks unless the
+// client asks for another one it also knows.
+const ProtocolVersion = "2024-11-05"
+
+// knownVersions are the revisions whose tool subset matches ours.
+var knownVersions = map[string]bool{"2024-11-05": true, "2025-03-26": true, "2025-06-18": true}
+
+// maxMessage bounds one incoming line.
+const maxMessage = 16 << 20
+
+// Tool is one callable tool. Call's text is the tool result; an error is
+// reported to the model as a failed call (isError) rather than a protocol
+// error.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{} // JSON Schema for the arguments object
+	Call        func(args map[string]interface{}) (string, error)
+}
+
+// Server answers MCP requests with its tools.
+type Server struct {
+	Name    string
+	Version string
+	Tools   []Tool
+
+	mu sync.Mutex // serializes writes to out
+}
+
+// request is an incoming JSON-RPC message.
+type request struct {
+	JSONRPC string                 `json:"jsonrpc"`
+	ID      json.RawMessage        `json:"id,omitempty"`
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// Serve reads requests from in until EOF and writes responses to out.
+// Tool calls run concurrently, so a slow ask does not hold up the rest.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), maxMessage)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(out, rpcError(nil, schema.CodeParseError, "parse error: "+err.Error()))
+			continue
+		}
+		if req.ID == nil {
+			continue // notifications (initialized, cancelled) need no answer
+		}
+		id := json.RawMessage(append([]byte(nil), req.ID...))
+		if req.Method == "tools/call" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.write(out, s.handle(id, req))
+			}()
+			continue
+		}
+		s.write(out, s.handle(id, req))
+	}
+	return sc.Err()
+}
+
+// handle answers one request.
+func (s *Server) handle(id json.RawMessage, req request) map[string]interface{} {
+	if req.JSONRPC != schema.JSONRPCVersion {
+		return rpcError(id, schema.CodeInvalidRequest, `want "jsonrpc": "2.0"`)
+	}
+	switch req.Method {
+	case "initialize":
+		version, _ := req.Params["protocolVersion"].(string)
+		if !knownVersions[version] {
+			version = ProtocolVersion
+		}
+		return rpcResult(id, map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": s.Name, "version": s.Version},
+		})
+	case "ping":
+		return rpcResult(id, map[string]interface{}{})
+	case "tools/list":
+		tools := make([]map[string]interface{}, len(s.Tools))
+		for i, t := range s.Tools {
+			tools[i] = map[string]interface{}{"name": t.Name, "description": t.Description, "inputSchema": t.InputSchema}
+		}
+		return rpcResult(id, map[string]interface{}{"tools": tools})
+	case "tools/call":
+		name, _ := req.Params["name"].(string)
+		args, _ := req.Params["arguments"].(map[string]interface{})
+		for _, t := range s.Tools {
+			if t.Name == name {
+				return rpcResult(id, callTool(t, args))
+			}
+		}
+		return rpcError(id, schema.CodeInvalidParams, "unknown tool: "+name)
+	}
+	return rpcError(id, schema.CodeMethodNotFound, "method not found: "+req.Method)
+}
+
+// callTool runs t and wraps its outcome as a tools/call result.
+func callTool(t Tool, args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	text, err := t.Call(args)
+	if err != nil {
+		text = err.Error()
+	}
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": err != nil,
+	}
+}
+
+func (s *Server) write(out io.Writer, msg map[string]interface{}) {
+	data, _ := json.Marshal(msg)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out.Write(append(data, '\n'))
+}
+
+func rpcResult(id json.RawMessage, result interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": schema.JSONRPCVersion, "id": id, "result": result}
+}
+
+func rpcError(id json.RawMessage, code int, msg string) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": schema.JSONRPCVersion, "id": id, "error": schema.RPCError{Code: code, Message: msg}}
+}
+
+// StringArg returns args[key] as a string, or an error naming the missing
+// argument when required.
+func StringArg(args map[string]interface{}, key string, required bool) (string, error) {
+	v, ok := args[key].(string)
+	if required && (!ok || v == "") {
+		return "", fmt.Errorf("missing argument %q", key)
+	}
+	return v, nil
+}
+
+// NumberArg returns args[key] as a number, or 0 when absent.
+func NumberArg(args map[string]interface{}, key string) float64 {
+	v, _ := args[key].(float64)
+	return v
+}
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	s := &Server{Name: "ccb", Version: "test", Tools: []Tool{
+		{
+			Name:        "echo",
+			Description: "Echo a message",
+			InputSchema: map[string]interface{}{"type": "object"},
+			Call: func(args map[string]interface{}) (string, error) {
+				msg, err := StringArg(args, "message", true)
+				return "echo: " + msg, err
+			},
+		},
+		{
+			Name: "fail",
+			Call: func(map[string]interface{}) (string, error) { return "", errors.New("provider offline") },
+		},
+	}}
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"fail"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":"x","method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tool calls may answer out of order; index responses by id.
+	byID := map[string]map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		id, _ := json.Marshal(m["id"])
+		byID[string(id)] = m
+	}
+	if len(byID) != 9 {
+		t.Fatalf("got %d responses, want 9: %s", len(byID), out.String())
+	}
+	result := func(id string) map[string]interface{} {
+		r, _ := byID[id]["result"].(map[string]interface{})
+		return r
+	}
+	errCode := func(id string) float64 {
+		e, _ := byID[id]["error"].(map[string]interface{})
+		code, _ := e["code"].(float64)
+		return code
+	}
+	text := func(id string) (string, bool) {
+		r := result(id)
+		content, _ := r["content"].([]interface{})
+		if len(content) != 1 {
+			return "", false
+		}
+		c, _ := content[0].(map[string]interface{})
+		s, _ := c["text"].(string)
+		isErr, _ := r["isError"].(bool)
+		return s, isErr
+	}
+
+	if v := result("1")["protocolVersion"]; v != "2025-03-26" {
+		t.Errorf("initialize protocolVersion = %v", v)
+	}
+	if tools, _ := result("2")["tools"].([]interface{}); len(tools) != 2 {
+		t.Errorf("tools/list = %v", result("2"))
+	}
+	tests := []struct {
+		id      string
+		want    string
+		isError bool
+	}{
+		{"3", "echo: hi", false},
+		{"4", `missing argument "message"`, true},
+		{"5", "provider offline", true},
+	}
+	for _, tt := range tests {
+		if got, isErr := text(tt.id); got != tt.want || isErr != tt.isError {
+			t.Errorf("call %s = %q (isError %v), want %q (%v)", tt.id, got, isErr, tt.want, tt.isError)
+		}
+	}
+	if errCode("6") != -32602 || errCode(`"x"`) != -32601 || errCode("null") != -32700 {
+		t.Errorf("error codes: unknown tool %v, unknown method %v, bad JSON %v", errCode("6"), errCode(`"x"`), errCode("null"))
+	}
+	if result("7") == nil {
+		t.Error("ping got no result")
+	}
+}
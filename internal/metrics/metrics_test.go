@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	reqs := r.Counter("ccb_requests_total", "Asks by provider and outcome.", "provider", "outcome")
+	lat := r.Histogram("ccb_latency_seconds", "Latency.", []float64{1, 5}, "provider")
+	r.GaugeFunc("ccb_up", "Always 1.", func() float64 { return 1 })
+
+	reqs.Inc("gemini", "ok")
+	reqs.Inc("codex", "ok")
+	reqs.Add(2, "codex", "ok")
+	reqs.Inc("codex", `we"ird`)
+	lat.Observe(0.5, "codex")
+	lat.Observe(3, "codex")
+	lat.Observe(9, "codex")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP ccb_requests_total Asks by provider and outcome.
+# TYPE ccb_requests_total counter
+ccb_requests_total{provider="codex",outcome="ok"} 3
+ccb_requests_total{provider="codex",outcome="we\"ird"} 1
+ccb_requests_total{provider="gemini",outcome="ok"} 1
+# HELP ccb_latency_seconds Latency.
+# TYPE ccb_latency_seconds histogram
+ccb_latency_seconds_bucket{provider="codex",le="1"} 1
+ccb_latency_seconds_bucket{provider="codex",le="5"} 2
+ccb_latency_seconds_bucket{provider="codex",le="+Inf"} 3
+ccb_latency_seconds_sum{provider="codex"} 12.5
+ccb_latency_seconds_count{provider="codex"} 3
+# HELP ccb_up Always 1.
+# TYPE ccb_up gauge
+ccb_up 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", got, want)
+	}
+	if v := reqs.Value("codex", "ok"); v != 3 {
+		t.Errorf("Value = %v, want 3", v)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := NewRegistry().Counter("c", "help", "provider")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with the wrong number of label values should panic")
+		}
+	}()
+	c.Inc()
+}
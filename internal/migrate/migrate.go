@@ -0,0 +1,361 @@
+// Package migrate bundles CCB state (run directory and global config) into
+// a tar.gz for backups and moving to another machine.
+package migrate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+const (
+	bundleVersion = 1
+	manifestName  = "manifest.json"
+	registryName  = "pane-registry.json"
+	runPrefix     = "run/"
+	configPrefix  = "config/"
+)
+
+// Manifest describes where a bundle came from. It is the first entry of
+// every bundle.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Home      string    `json:"home"`
+	RunDir    string    `json:"run_dir"`
+	ConfigDir string    `json:"config_dir"`
+}
+
+// ExportOptions selects the directories to bundle.
+type ExportOptions struct {
+	RunDir      string
+	ConfigDir   string
+	IncludeLogs bool
+}
+
+// Export writes a tar.gz of the run directory and global config to w.
+// Daemon state (askd.json), locks and temp files are machine-specific and
+// left out; logs are only included on request.
+func Export(w io.Writer, opts ExportOptions) (*Manifest, error) {
+	home, _ := os.UserHomeDir()
+	host, _ := os.Hostname()
+	m := &Manifest{
+		Version:   bundleVersion,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Hostname:  host,
+		Home:      home,
+		RunDir:    opts.RunDir,
+		ConfigDir: opts.ConfigDir,
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, _ := json.MarshalIndent(m, "", "  ")
+	if err := writeEntry(tw, manifestName, 0600, m.CreatedAt, data); err != nil {
+		return nil, err
+	}
+	if err := addDir(tw, opts.RunDir, runPrefix, func(name string) bool {
+		return !skipRunFile(name, opts.IncludeLogs)
+	}); err != nil {
+		return nil, err
+	}
+	if err := addDir(tw, opts.ConfigDir, configPrefix, func(string) bool { return true }); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// skipRunFile reports whether a run-dir file is left out of a bundle.
+// Secrets never leave the machine: the daemon's token (askd.json), paired
+// clients' tokens (askd-clients.json) and its TLS keys (askd-tls/).
+func skipRunFile(name string, includeLogs bool) bool {
+	base := path.Base(name)
+	switch {
+	case base == "askd.json", base == "askd-clients.json":
+		return true
+	case strings.HasPrefix(name, "askd-tls/"), strings.HasSuffix(base, "-key.pem"):
+		return true
+	case strings.HasSuffix(base, ".lock"), strings.HasSuffix(base, ".tmp"), strings.HasSuffix(base, ".pid"):
+		return true
+	case strings.HasSuffix(base, ".log"):
+		return !includeLogs
+	}
+	return false
+}
+
+// addDir adds the regular files under dir as prefix+relpath. A missing
+// dir is not an error.
+func addDir(tw *tar.Writer, dir, prefix string, keep func(string) bool) error {
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !keep(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return writeEntry(tw, prefix+rel, info.Mode().Perm(), info.ModTime(), data)
+	})
+}
+
+func writeEntry(tw *tar.Writer, name string, mode fs.FileMode, mtime time.Time, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    int64(mode),
+		Size:    int64(len(data)),
+		ModTime: mtime,
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// File is one bundled file, named "run/..." or "config/...".
+type File struct {
+	Name string
+	Mode fs.FileMode
+	Data []byte
+}
+
+// Bundle is a bundle read into memory.
+type Bundle struct {
+	Manifest Manifest
+	Files    []File
+}
+
+// Read loads a bundle written by Export.
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a ccb state bundle: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	b := &Bundle{}
+	sawManifest := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == manifestName {
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			sawManifest = true
+			continue
+		}
+		if err := checkName(hdr.Name); err != nil {
+			return nil, err
+		}
+		b.Files = append(b.Files, File{Name: hdr.Name, Mode: fs.FileMode(hdr.Mode).Perm(), Data: data})
+	}
+	if !sawManifest {
+		return nil, fmt.Errorf("not a ccb state bundle: missing %s", manifestName)
+	}
+	if b.Manifest.Version > bundleVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than supported (%d)", b.Manifest.Version, bundleVersion)
+	}
+	sort.Slice(b.Files, func(i, j int) bool { return b.Files[i].Name < b.Files[j].Name })
+	return b, nil
+}
+
+// checkName rejects entries that would land outside the target dirs.
+func checkName(name string) error {
+	clean := path.Clean(name)
+	if path.IsAbs(name) || clean != name || strings.HasPrefix(clean, "../") || strings.Contains(name, "\\") {
+		return fmt.Errorf("unsafe path in bundle: %q", name)
+	}
+	if !strings.HasPrefix(name, runPrefix) && !strings.HasPrefix(name, configPrefix) {
+		return fmt.Errorf("unexpected entry in bundle: %q", name)
+	}
+	return nil
+}
+
+// ImportOptions controls where and how a bundle is installed.
+type ImportOptions struct {
+	RunDir    string
+	ConfigDir string
+	Remaps    []config.PathRemap // applied to paths in the pane registry
+	Force     bool               // overwrite existing files
+}
+
+// ImportReport lists what Install did.
+type ImportReport struct {
+	Written  []string `json:"written"`
+	Skipped  []string `json:"skipped"`  // existed and Force was not set
+	Remapped int      `json:"remapped"` // registry paths rewritten
+}
+
+// Install writes the bundle's files into the run and config directories.
+func (b *Bundle) Install(opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{}
+	for _, f := range b.Files {
+		var dest string
+		switch {
+		case strings.HasPrefix(f.Name, runPrefix):
+			dest = filepath.Join(opts.RunDir, filepath.FromSlash(strings.TrimPrefix(f.Name, runPrefix)))
+		case strings.HasPrefix(f.Name, configPrefix):
+			dest = filepath.Join(opts.ConfigDir, filepath.FromSlash(strings.TrimPrefix(f.Name, configPrefix)))
+		default:
+			continue
+		}
+
+		data := f.Data
+		if f.Name == runPrefix+registryName && len(opts.Remaps) > 0 {
+			rewritten, n, err := RemapRegistry(data, opts.Remaps)
+			if err != nil {
+				return report, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			data = rewritten
+			report.Remapped += n
+		}
+
+		if _, err := os.Stat(dest); err == nil && !opts.Force {
+			report.Skipped = append(report.Skipped, dest)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return report, err
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0600
+		}
+		tmp := dest + ".tmp"
+		if err := os.WriteFile(tmp, data, mode); err != nil {
+			return report, err
+		}
+		if err := os.Rename(tmp, dest); err != nil {
+			os.Remove(tmp)
+			return report, err
+		}
+		report.Written = append(report.Written, dest)
+	}
+	return report, nil
+}
+
+// RemapRegistry rewrites work_dir and session_path in pane registry JSON,
+// keeping every other field as is. It returns how many paths changed.
+func RemapRegistry(data []byte, rules []config.PathRemap) ([]byte, int, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	providers, _ := doc["providers"].(map[string]interface{})
+	changed := 0
+	for _, projects := range providers {
+		entries, _ := projects.(map[string]interface{})
+		for _, e := range entries {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, field := range []string{"work_dir", "session_path"} {
+				old, _ := entry[field].(string)
+				if old == "" {
+					continue
+				}
+				if p := config.RemapPath(old, rules); p != old {
+					entry[field] = p
+					changed++
+				}
+			}
+		}
+	}
+	if changed == 0 {
+		return data, 0, nil
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, changed, nil
+}
+
+// Paths returns the registry paths a bundle's remap rules would apply to,
+// so callers can show them before importing.
+func (b *Bundle) Paths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, f := range b.Files {
+		if f.Name != runPrefix+registryName {
+			continue
+		}
+		var data struct {
+			Providers map[string]map[string]struct {
+				WorkDir     string `json:"work_dir"`
+				SessionPath string `json:"session_path"`
+			} `json:"providers"`
+		}
+		if json.Unmarshal(f.Data, &data) != nil {
+			continue
+		}
+		for _, projects := range data.Providers {
+			for _, e := range projects {
+				for _, p := range []string{e.WorkDir, e.SessionPath} {
+					if p != "" && !seen[p] {
+						seen[p] = true
+						paths = append(paths, p)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	runDir := filepath.Join(src, "run")
+	cfgDir := filepath.Join(src, "cfg")
+	registry := `{"providers":{"codex":{"abc":{"pane_id":"%1","work_dir":"/Users/me/proj","session_path":"/Users/me/proj/.ccb_config/.codex-session","updated_at":1}}},"version":2}`
+	writeFile(t, filepath.Join(runDir, registryName), registry)
+	writeFile(t, filepath.Join(runDir, "askd.json"), `{"token":"secret"}`)
+	writeFile(t, filepath.Join(runDir, "askd.log"), "log")
+	writeFile(t, filepath.Join(runDir, "x.lock"), "")
+	writeFile(t, filepath.Join(runDir, "askd-clients.json"), `{"laptop":{"token":"secret"}}`)
+	writeFile(t, filepath.Join(runDir, "askd-tls", "ca.pem"), "cert")
+	writeFile(t, filepath.Join(runDir, "askd-tls", "ca-key.pem"), "key")
+	writeFile(t, filepath.Join(runDir, "askd-tls", "client-key.pem"), "key")
+	writeFile(t, filepath.Join(runDir, "other", "server-key.pem"), "key")
+	writeFile(t, filepath.Join(cfgDir, "ccb.config"), "codex,claude\n")
+	writeFile(t, filepath.Join(cfgDir, "templates", "review.md"), "Review {{file}}")
+
+	var buf bytes.Buffer
+	if _, err := Export(&buf, ExportOptions{RunDir: runDir, ConfigDir: cfgDir}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	b, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var names []string
+	for _, f := range b.Files {
+		names = append(names, f.Name)
+	}
+	want := "config/ccb.config,config/templates/review.md,run/pane-registry.json"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+	if b.Manifest.Version != bundleVersion || b.Manifest.RunDir != runDir {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+
+	dst := t.TempDir()
+	opts := ImportOptions{
+		RunDir:    filepath.Join(dst, "run"),
+		ConfigDir: filepath.Join(dst, "cfg"),
+		Remaps:    []config.PathRemap{{From: "/Users/me", To: "/home/me"}},
+	}
+	writeFile(t, filepath.Join(opts.ConfigDir, "ccb.config"), "gemini\n")
+	report, err := b.Install(opts)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if len(report.Written) != 2 || len(report.Skipped) != 1 || report.Remapped != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(opts.ConfigDir, "ccb.config")); string(data) != "gemini\n" {
+		t.Errorf("existing config overwritten without Force: %q", data)
+	}
+
+	data, err := os.ReadFile(filepath.Join(opts.RunDir, registryName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reg struct {
+		Providers map[string]map[string]map[string]interface{} `json:"providers"`
+		Version   int                                          `json:"version"`
+	}
+	if err := json.Unmarshal(data, &reg); err != nil {
+		t.Fatal(err)
+	}
+	entry := reg.Providers["codex"]["abc"]
+	if entry["work_dir"] != "/home/me/proj" || entry["session_path"] != "/home/me/proj/.ccb_config/.codex-session" {
+		t.Errorf("entry not remapped: %v", entry)
+	}
+	if entry["pane_id"] != "%1" || reg.Version != 2 {
+		t.Errorf("other fields lost: %v version=%d", entry, reg.Version)
+	}
+
+	opts.Force = true
+	if _, err := b.Install(opts); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(opts.ConfigDir, "ccb.config")); string(data) != "codex,claude\n" {
+		t.Errorf("Force did not overwrite: %q", data)
+	}
+}
+
+func TestReadRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../etc/passwd", "/etc/passwd", "run/../../x", "other/file"} {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		writeEntry(tw, manifestName, 0600, time.Time{}, []byte(`{"version":1}`))
+		writeEntry(tw, name, 0600, time.Time{}, []byte("x"))
+		tw.Close()
+		gz.Close()
+		if _, err := Read(&buf); err == nil {
+			t.Errorf("Read accepted %q", name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package notify shows desktop notifications by shelling out to the
+// platform's notifier. Delivery is best effort.
+package notify
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable is returned when no notifier is installed.
+var ErrUnavailable = errors.New("no desktop notifier found")
+
+// Enabled reports whether notifications are on. Set CCB_NOTIFY=0 to
+// silence them.
+func Enabled() bool {
+	v := strings.TrimSpace(os.Getenv("CCB_NOTIFY"))
+	return v != "0" && !strings.EqualFold(v, "false")
+}
+
+// Send shows a notification with title and body.
+func Send(title, body string) error {
+	if !Enabled() {
+		return nil
+	}
+	for _, c := range commands(runtime.GOOS, os.Getenv, title, body) {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		return exec.Command(c[0], c[1:]...).Run()
+	}
+	return ErrUnavailable
+}
+
+// commands lists notifier command lines in preference order.
+func commands(goos string, getenv func(string) string, title, body string) [][]string {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleQuote(body) + " with title " + appleQuote(title)
+		return [][]string{{"osascript", "-e", script}}
+	case "windows":
+		return [][]string{psBalloon("powershell", title, body)}
+	}
+	cmds := [][]string{{"notify-send", "--app-name=ccb", title, body}}
+	if getenv("WSL_DISTRO_NAME") != "" {
+		cmds = append(cmds, psBalloon("powershell.exe", title, body))
+	}
+	return cmds
+}
+
+// appleQuote quotes s as an AppleScript string literal.
+func appleQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// psBalloon shows a tray balloon tip, which needs no extra modules.
+func psBalloon(exe, title, body string) []string {
+	q := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	script := "Add-Type -AssemblyName System.Windows.Forms; " +
+		"$n = New-Object System.Windows.Forms.NotifyIcon; " +
+		"$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; " +
+		"$n.ShowBalloonTip(5000, " + q(title) + ", " + q(body) + ", 'Info'); " +
+		"Start-Sleep -Seconds 6; $n.Dispose()"
+	return []string{exe, "-NoProfile", "-NonInteractive", "-Command", script}
+}
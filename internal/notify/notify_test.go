@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	none := func(string) string { return "" }
+	wsl := func(k string) string {
+		if k == "WSL_DISTRO_NAME" {
+			return "Ubuntu"
+		}
+		return ""
+	}
+	tests := []struct {
+		name   string
+		goos   string
+		getenv func(string) string
+		first  string
+		count  int
+	}{
+		{"macos", "darwin", none, "osascript", 1},
+		{"windows", "windows", none, "powershell", 1},
+		{"linux", "linux", none, "notify-send", 1},
+		{"wsl", "linux", wsl, "notify-send", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := commands(tt.goos, tt.getenv, "t", "b")
+			if len(cmds) != tt.count || cmds[0][0] != tt.first {
+				t.Errorf("commands = %v, want %d starting with %s", cmds, tt.count, tt.first)
+			}
+		})
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	script := commands("darwin", nil, `say "hi"`, `a\b`)[0][2]
+	if want := `display notification "a\\b" with title "say \"hi\""`; script != want {
+		t.Errorf("osascript = %s, want %s", script, want)
+	}
+	ps := psBalloon("powershell", "it's", "done")
+	if !strings.Contains(ps[len(ps)-1], "'it''s'") {
+		t.Errorf("powershell quoting wrong: %s", ps[len(ps)-1])
+	}
+}
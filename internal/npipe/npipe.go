@@ -0,0 +1,18 @@
+// Package npipe provides a net.Listener and net.Conn over Windows named
+// pipes, so the daemon can be reached where localhost TCP is blocked and
+// its endpoint can be restricted to the current user. On other systems
+// Listen and Dial return ErrUnsupported.
+package npipe
+
+import "errors"
+
+// ErrUnsupported is returned by Listen and Dial outside Windows.
+var ErrUnsupported = errors.New("named pipes are only supported on Windows")
+
+// Addr is the address of a named pipe, e.g. `\\.\pipe\ccb-alice`.
+type Addr string
+
+// Network returns "pipe".
+func (a Addr) Network() string { return "pipe" }
+
+func (a Addr) String() string { return string(a) }
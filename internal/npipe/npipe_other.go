@@ -0,0 +1,18 @@
+//go:build !windows
+
+package npipe
+
+import (
+	"net"
+	"time"
+)
+
+// Listen is not supported outside Windows.
+func Listen(name string) (net.Listener, error) {
+	return nil, ErrUnsupported
+}
+
+// Dial is not supported outside Windows.
+func Dial(name string, timeout time.Duration) (net.Conn, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,72 @@
+package npipe
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	name := fmt.Sprintf(`\\.\pipe\ccb-test-%d`, os.Getpid())
+	if runtime.GOOS != "windows" {
+		if _, err := Listen(name); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Listen = %v, want ErrUnsupported", err)
+		}
+		if _, err := Dial(name, time.Second); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Dial = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+
+	l, err := Listen(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := Listen(name); err == nil {
+		t.Error("second Listen on the same pipe succeeded")
+	}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				line, err := bufio.NewReader(c).ReadString('\n')
+				if err == nil {
+					fmt.Fprintf(c, "echo %s", line)
+				}
+			}()
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		c, err := Dial(name, 2*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(c, "hi %d\n", i)
+		got, err := bufio.NewReader(c).ReadString('\n')
+		c.Close()
+		if want := fmt.Sprintf("echo hi %d\n", i); err != nil || got != want {
+			t.Errorf("reply %d = %q, %v; want %q", i, got, err, want)
+		}
+	}
+
+	// A read with nothing to read honors the deadline.
+	c, err := Dial(name, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read past the deadline = %v", err)
+	}
+}
@@ -0,0 +1,395 @@
+//go:build windows
+
+package npipe
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procCreateEventW     = kernel32.NewProc("CreateEventW")
+	procGetOverlappedRes = kernel32.NewProc("GetOverlappedResult")
+	procCreateNamedPipeW = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = kernel32.NewProc("ConnectNamedPipe")
+	procWaitNamedPipeW   = kernel32.NewProc("WaitNamedPipeW")
+	procConvertSDDL      = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+)
+
+const (
+	pipeAccessDuplex          = 0x00000003
+	fileFlagFirstPipeInstance = 0x00080000
+	pipeRejectRemoteClients   = 0x00000008 // byte type, byte read mode, blocking
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 64 * 1024
+	securitySQOSPresent       = 0x00100000
+	securityIdentification    = 0x00010000
+
+	errPipeBusy         syscall.Errno = 231
+	errNoData           syscall.Errno = 232
+	errPipeNotConnected syscall.Errno = 233
+	errPipeConnected    syscall.Errno = 535
+	errOperationAborted syscall.Errno = 995
+)
+
+// ioWait starts an overlapped operation with start and waits for it until
+// deadline (zero for no limit), canceling it if the deadline passes.
+func ioWait(h syscall.Handle, deadline time.Time, start func(ov *syscall.Overlapped) error) (uint32, error) {
+	r, _, err := procCreateEventW.Call(0, 1, 0, 0) // manual reset, unsignaled
+	if r == 0 {
+		return 0, err
+	}
+	ev := syscall.Handle(r)
+	defer syscall.CloseHandle(ev)
+	ov := &syscall.Overlapped{HEvent: ev}
+	if err := start(ov); err != nil && err != syscall.ERROR_IO_PENDING {
+		return 0, err
+	}
+	wait := uint32(syscall.INFINITE)
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d < 0 {
+			d = 0
+		}
+		if d < time.Duration(syscall.INFINITE-1)*time.Millisecond {
+			wait = uint32(d / time.Millisecond)
+		}
+	}
+	timedOut := false
+	if s, err := syscall.WaitForSingleObject(ev, wait); s == syscall.WAIT_TIMEOUT {
+		timedOut = true
+		syscall.CancelIoEx(h, ov)
+	} else if s != syscall.WAIT_OBJECT_0 {
+		syscall.CancelIoEx(h, ov)
+		var n uint32
+		overlappedResult(h, ov, &n, true)
+		return n, err
+	}
+	var n uint32
+	err = overlappedResult(h, ov, &n, true)
+	if timedOut && err == errOperationAborted {
+		return n, os.ErrDeadlineExceeded
+	}
+	return n, err
+}
+
+// overlappedResult waits for ov to complete and returns the bytes moved.
+func overlappedResult(h syscall.Handle, ov *syscall.Overlapped, n *uint32, wait bool) error {
+	w := uintptr(0)
+	if wait {
+		w = 1
+	}
+	r, _, err := procGetOverlappedRes.Call(uintptr(h), uintptr(unsafe.Pointer(ov)), uintptr(unsafe.Pointer(n)), w)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// conn is one end of a connected pipe instance. Deadlines apply to
+// operations started after they are set.
+type conn struct {
+	h      syscall.Handle
+	addr   Addr
+	mu     sync.Mutex
+	closed bool
+	ops    sync.WaitGroup
+	rdl    time.Time
+	wdl    time.Time
+}
+
+// begin registers an operation and returns its deadline.
+func (c *conn) begin(write bool) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return time.Time{}, net.ErrClosed
+	}
+	dl := c.rdl
+	if write {
+		dl = c.wdl
+	}
+	if !dl.IsZero() && !time.Now().Before(dl) {
+		return dl, os.ErrDeadlineExceeded
+	}
+	c.ops.Add(1)
+	return dl, nil
+}
+
+func (c *conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	dl, err := c.begin(false)
+	if err != nil {
+		return 0, err
+	}
+	defer c.ops.Done()
+	n, err := ioWait(c.h, dl, func(ov *syscall.Overlapped) error {
+		var done uint32
+		return syscall.ReadFile(c.h, b, &done, ov)
+	})
+	switch {
+	case err == nil && n == 0:
+		return 0, io.EOF
+	case err == syscall.ERROR_BROKEN_PIPE || err == errPipeNotConnected || err == errNoData:
+		return int(n), io.EOF
+	case err == errOperationAborted && c.isClosed():
+		return int(n), net.ErrClosed
+	case err != nil:
+		return int(n), &net.OpError{Op: "read", Net: "pipe", Addr: c.addr, Err: err}
+	}
+	return int(n), nil
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	dl, err := c.begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer c.ops.Done()
+	total := 0
+	for total < len(b) {
+		chunk := b[total:]
+		n, err := ioWait(c.h, dl, func(ov *syscall.Overlapped) error {
+			var done uint32
+			return syscall.WriteFile(c.h, chunk, &done, ov)
+		})
+		total += int(n)
+		if err == errOperationAborted && c.isClosed() {
+			return total, net.ErrClosed
+		}
+		if err != nil {
+			return total, &net.OpError{Op: "write", Net: "pipe", Addr: c.addr, Err: err}
+		}
+	}
+	return total, nil
+}
+
+// Close cancels pending reads and writes, waits for them and closes the
+// handle. Data already written stays readable by the other end.
+func (c *conn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	syscall.CancelIoEx(c.h, nil)
+	c.ops.Wait()
+	return syscall.CloseHandle(c.h)
+}
+
+func (c *conn) LocalAddr() net.Addr  { return c.addr }
+func (c *conn) RemoteAddr() net.Addr { return c.addr }
+
+func (c *conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rdl, c.wdl = t, t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rdl = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.wdl = t
+	c.mu.Unlock()
+	return nil
+}
+
+// listener serves a pipe name, keeping one instance waiting for the next
+// client.
+type listener struct {
+	name      *uint16
+	addr      Addr
+	sa        *syscall.SecurityAttributes
+	mu        sync.Mutex
+	next      syscall.Handle // instance for the next Accept; InvalidHandle if none
+	accepting bool
+	closed    bool
+}
+
+// Listen creates the pipe name, accessible to the current user only. It
+// fails if another process already serves name.
+func Listen(name string) (net.Listener, error) {
+	name16, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	sa, err := currentUserOnly()
+	if err != nil {
+		return nil, fmt.Errorf("pipe %s: %w", name, err)
+	}
+	l := &listener{name: name16, addr: Addr(name), sa: sa}
+	h, err := l.create(true)
+	if err != nil {
+		syscall.LocalFree(syscall.Handle(sa.SecurityDescriptor))
+		if err == syscall.ERROR_ACCESS_DENIED {
+			return nil, fmt.Errorf("pipe %s is already in use", name)
+		}
+		return nil, &net.OpError{Op: "listen", Net: "pipe", Addr: l.addr, Err: err}
+	}
+	l.next = h
+	return l, nil
+}
+
+// create makes a new instance of the pipe.
+func (l *listener) create(first bool) (syscall.Handle, error) {
+	mode := uint32(pipeAccessDuplex | syscall.FILE_FLAG_OVERLAPPED)
+	if first {
+		mode |= fileFlagFirstPipeInstance
+	}
+	r, _, err := procCreateNamedPipeW.Call(uintptr(unsafe.Pointer(l.name)), uintptr(mode), pipeRejectRemoteClients,
+		pipeUnlimitedInstances, pipeBufferSize, pipeBufferSize, 0, uintptr(unsafe.Pointer(l.sa)))
+	if syscall.Handle(r) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, err
+	}
+	return syscall.Handle(r), nil
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	h := l.next
+	l.next = syscall.InvalidHandle
+	if h == syscall.InvalidHandle {
+		var err error
+		if h, err = l.create(false); err != nil {
+			l.mu.Unlock()
+			return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: l.addr, Err: err}
+		}
+	}
+	l.accepting = true
+	l.next = h
+	l.mu.Unlock()
+
+	_, err := ioWait(h, time.Time{}, func(ov *syscall.Overlapped) error {
+		r, _, err := procConnectNamedPipe.Call(uintptr(h), uintptr(unsafe.Pointer(ov)))
+		if r != 0 {
+			return nil
+		}
+		return err
+	})
+	if err == errPipeConnected {
+		err = nil // the client connected before ConnectNamedPipe
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.accepting = false
+	l.next = syscall.InvalidHandle
+	if l.closed {
+		syscall.CloseHandle(h)
+		return nil, net.ErrClosed
+	}
+	if err != nil {
+		syscall.CloseHandle(h)
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: l.addr, Err: err}
+	}
+	if nh, err := l.create(false); err == nil {
+		l.next = nh
+	}
+	return &conn{h: h, addr: l.addr}, nil
+}
+
+// Close stops accepting. A pending Accept returns net.ErrClosed.
+func (l *listener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if l.next != syscall.InvalidHandle {
+		if l.accepting {
+			// Accept closes the handle once the connect is canceled.
+			syscall.CancelIoEx(l.next, nil)
+		} else {
+			syscall.CloseHandle(l.next)
+		}
+		l.next = syscall.InvalidHandle
+	}
+	return nil
+}
+
+func (l *listener) Addr() net.Addr { return l.addr }
+
+// currentUserOnly returns security attributes whose DACL grants the
+// current user and SYSTEM access and nobody else.
+func currentUserOnly() (*syscall.SecurityAttributes, error) {
+	token, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return nil, err
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	sid, err := user.User.Sid.String()
+	if err != nil {
+		return nil, err
+	}
+	sddl, err := syscall.UTF16PtrFromString("D:P(A;;GA;;;" + sid + ")(A;;GA;;;SY)")
+	if err != nil {
+		return nil, err
+	}
+	var sd uintptr
+	if r, _, err := procConvertSDDL.Call(uintptr(unsafe.Pointer(sddl)), 1, uintptr(unsafe.Pointer(&sd)), 0); r == 0 {
+		return nil, err
+	}
+	sa := &syscall.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	return sa, nil
+}
+
+// Dial connects to the pipe name, waiting up to timeout while every
+// instance is busy.
+func Dial(name string, timeout time.Duration) (net.Conn, error) {
+	name16, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		h, err := syscall.CreateFile(name16, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_EXISTING,
+			syscall.FILE_FLAG_OVERLAPPED|securitySQOSPresent|securityIdentification, 0)
+		if err == nil {
+			return &conn{h: h, addr: Addr(name)}, nil
+		}
+		left := time.Until(deadline)
+		if err != errPipeBusy || left <= 0 {
+			if err == errPipeBusy {
+				err = os.ErrDeadlineExceeded
+			}
+			return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: Addr(name), Err: err}
+		}
+		procWaitNamedPipeW.Call(uintptr(unsafe.Pointer(name16)), uintptr(left/time.Millisecond))
+	}
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{" error ", LevelError, false},
+		{"loud", LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	var out, errOut bytes.Buffer
+	oldOut, oldErr, oldLevel := logOut, logErr, CurrentLevel()
+	logOut, logErr = &out, &errOut
+	defer func() { logOut, logErr = oldOut, oldErr; SetLevel(oldLevel) }()
+
+	emit := func() {
+		Debugf("d")
+		Infof("i")
+		Warnf("w")
+		Errorf("e")
+	}
+	tests := []struct {
+		level       Level
+		out, errOut string
+	}{
+		{LevelDebug, "i\n", "debug: d\nwarning: w\ne\n"},
+		{LevelInfo, "i\n", "warning: w\ne\n"},
+		{LevelWarn, "", "warning: w\ne\n"},
+		{LevelError, "", "e\n"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		errOut.Reset()
+		SetLevel(tt.level)
+		emit()
+		if out.String() != tt.out || errOut.String() != tt.errOut {
+			t.Errorf("level %v: stdout %q stderr %q, want %q %q", tt.level, out.String(), errOut.String(), tt.out, tt.errOut)
+		}
+	}
+}
@@ -1,19 +1,29 @@
 package output
 
 import (
-	"fmt"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-// Exit codes
+// Exit codes. They are shared by the daemon's results (exit_code), the
+// client and the CLI, so scripts can tell failure modes apart.
 const (
-	ExitOK      = 0
-	ExitError   = 1
-	ExitNoReply = 2
+	ExitOK         = 0
+	ExitError      = 1 // any failure not listed below
+	ExitNoReply    = 2 // no (complete) reply in time, or none to fetch
+	ExitPaneDead   = 3 // the provider's pane is gone
+	ExitNoSession  = 4 // no session for the provider in this project
+	ExitDaemonDown = 5 // the daemon is unreachable and could not be started
+	ExitPaused     = 6 // the provider is paused (ccb pause)
+	ExitCanceled   = 7 // the ask was canceled (ccb requests kill)
+	ExitRateLimit  = 8 // a daemon rate limit refused the ask (retry_after_ms)
 )
 
+// ExitTimeout is ExitNoReply as returned for an ask that timed out.
+const ExitTimeout = ExitNoReply
+
 // AtomicWriteText writes content to a file atomically via temp file + rename.
 func AtomicWriteText(path string, content string) error {
 	dir := filepath.Dir(path)
@@ -100,12 +110,9 @@ func decodeUTF16BE(data []byte) string {
 	return string(runes)
 }
 
-// Errorf prints a formatted error message to stderr.
-func Errorf(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
-}
-
-// Infof prints a formatted info message to stdout.
-func Infof(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stdout, format+"\n", args...)
+// PrintJSON writes v to stdout as a single line of JSON.
+func PrintJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
 }
@@ -64,3 +64,54 @@ func TestAtomicWriteText(t *testing.T) {
 		t.Errorf("AtomicWriteText content = %q, want %q", string(data), "hello world")
 	}
 }
+
+func TestRenderSections(t *testing.T) {
+	got := RenderSections([]Section{
+		{Label: "codex", Body: "answer one\n"},
+		{Label: "claude", Body: ""},
+		{Label: "gemini", Body: "line a\nline b"},
+	})
+	want := "=== codex ===\nanswer one\n\n=== claude ===\n\n=== gemini ===\nline a\nline b\n"
+	if got != want {
+		t.Errorf("RenderSections() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderColumns(t *testing.T) {
+	got := RenderColumns([]Section{
+		{Label: "codex", Body: "short"},
+		{Label: "claude", Body: "a line that wraps\nsecond"},
+	}, 23)
+	want := "codex      | claude\n" +
+		"---------- | ----------\n" +
+		"short      | a line tha\n" +
+		"           | t wraps\n" +
+		"           | second\n"
+	if got != want {
+		t.Errorf("RenderColumns() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+		cut  bool
+	}{
+		{"disabled", "a\nb\nc", 0, "a\nb\nc", false},
+		{"fits", "a\nb\nc\n", 3, "a\nb\nc\n", false},
+		{"head and tail", "1\n2\n3\n4\n5\n6\n", 4, "1\n2\n[... 2 lines omitted; use --full ...]\n5\n6", true},
+		{"odd max", "1\n2\n3\n4\n5", 3, "1\n2\n[... 2 lines omitted; use --full ...]\n5", true},
+		{"one line", "1\n2\n3", 1, "1\n[... 2 lines omitted; use --full ...]", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut := TruncateLines(tt.text, tt.max, "use --full")
+			if got != tt.want || cut != tt.cut {
+				t.Errorf("TruncateLines() = %q, %v; want %q, %v", got, cut, tt.want, tt.cut)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Section is one labeled block of multi-provider output.
+type Section struct {
+	Label string
+	Body  string
+}
+
+// RenderSections renders sections one after another under "=== label ==="
+// headers, separated by a blank line.
+func RenderSections(sections []Section) string {
+	var b strings.Builder
+	for i, s := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "=== %s ===\n", s.Label)
+		body := strings.TrimRight(s.Body, "\n")
+		if body != "" {
+			b.WriteString(body)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// RenderColumns renders sections side by side within width terminal
+// columns. Long lines are wrapped inside their column.
+func RenderColumns(sections []Section, width int) string {
+	if len(sections) == 0 {
+		return ""
+	}
+	const sep = " | "
+	colWidth := (width - len(sep)*(len(sections)-1)) / len(sections)
+	if colWidth < 10 {
+		colWidth = 10
+	}
+
+	columns := make([][]string, len(sections))
+	rows := 0
+	for i, s := range sections {
+		col := []string{s.Label, strings.Repeat("-", colWidth)}
+		for _, line := range strings.Split(strings.TrimRight(s.Body, "\n"), "\n") {
+			col = append(col, wrapRunes(line, colWidth)...)
+		}
+		columns[i] = col
+		if len(col) > rows {
+			rows = len(col)
+		}
+	}
+
+	var b strings.Builder
+	for r := 0; r < rows; r++ {
+		var cells []string
+		for _, col := range columns {
+			cell := ""
+			if r < len(col) {
+				cell = col[r]
+			}
+			cells = append(cells, padRunes(cell, colWidth))
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, sep), " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// wrapRunes splits line into chunks of at most width runes. Tabs are
+// expanded so column alignment holds.
+func wrapRunes(line string, width int) []string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	var out []string
+	for len(runes) > width {
+		out = append(out, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(out, string(runes))
+}
+
+// padRunes right-pads s with spaces to width runes.
+func padRunes(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// TruncateLines shortens text of more than max lines to its first and last
+// lines around a "[... N lines omitted; hint ...]" notice, so long replies
+// do not flood the terminal. It reports whether anything was cut; text is
+// returned unchanged when max <= 0 or it fits.
+func TruncateLines(text string, max int, hint string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if max <= 0 || len(lines) <= max {
+		return text, false
+	}
+	tail := max / 2
+	head := max - tail
+	notice := fmt.Sprintf("[... %d lines omitted", len(lines)-max)
+	if hint != "" {
+		notice += "; " + hint
+	}
+	notice += " ...]"
+	out := append(append(append([]string{}, lines[:head]...), notice), lines[len(lines)-tail:]...)
+	return strings.Join(out, "\n"), true
+}
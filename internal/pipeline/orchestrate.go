@@ -0,0 +1,240 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/prompt"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// PlanStep is one step of a planner reply: an instruction for a provider.
+type PlanStep struct {
+	Provider    string `json:"provider"`
+	Instruction string `json:"instruction"`
+}
+
+// Plan is a parsed planner reply: steps to run, or Done with a summary.
+type Plan struct {
+	Steps   []PlanStep `json:"steps,omitempty"`
+	Done    bool       `json:"done,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+}
+
+var (
+	stepLine = regexp.MustCompile(`^\s*STEP\s+([A-Za-z0-9_-]+)\s*:?\s*(.*)$`)
+	doneLine = regexp.MustCompile(`^\s*DONE\b\s*:?\s*(.*)$`)
+)
+
+// ParsePlan reads the STEP/DONE blocks of a planner reply. Text before the
+// first block is ignored; a DONE block ends the plan and wins over any
+// steps. It fails when the reply has neither.
+func ParsePlan(reply string) (Plan, error) {
+	var plan Plan
+	var body *strings.Builder
+	var summary strings.Builder
+	var bodies []*strings.Builder
+	for _, line := range strings.Split(protocol.StripTrailingMarkers(reply), "\n") {
+		if plan.Done {
+			summary.WriteString(line + "\n")
+			continue
+		}
+		if m := doneLine.FindStringSubmatch(line); m != nil {
+			plan.Done = true
+			summary.WriteString(m[1] + "\n")
+			continue
+		}
+		if m := stepLine.FindStringSubmatch(line); m != nil {
+			plan.Steps = append(plan.Steps, PlanStep{Provider: strings.ToLower(m[1])})
+			body = &strings.Builder{}
+			bodies = append(bodies, body)
+			body.WriteString(m[2] + "\n")
+			continue
+		}
+		if body != nil {
+			body.WriteString(line + "\n")
+		}
+	}
+	if plan.Done {
+		plan.Steps = nil
+		plan.Summary = strings.TrimSpace(summary.String())
+		return plan, nil
+	}
+	for i := range plan.Steps {
+		plan.Steps[i].Instruction = strings.TrimSpace(bodies[i].String())
+	}
+	if len(plan.Steps) == 0 {
+		return plan, fmt.Errorf("planner reply has no STEP or DONE block")
+	}
+	return plan, nil
+}
+
+// Budget bounds an orchestration. Zero fields are unlimited, except
+// MaxRounds, which defaults to DefaultMaxRounds.
+type Budget struct {
+	MaxRounds int           // planner asks
+	MaxSteps  int           // executor asks
+	MaxTime   time.Duration // wall time, checked before each ask
+}
+
+// DefaultMaxRounds is how many times the planner is asked without a
+// MaxRounds budget.
+const DefaultMaxRounds = 5
+
+// Round is one planner ask and the executor steps it produced.
+type Round struct {
+	Planner *client.AskResult `json:"planner"`
+	Plan    Plan              `json:"plan"`
+	Steps   []Step            `json:"steps,omitempty"`
+	Note    string            `json:"note,omitempty"` // why the plan was not run, if it was not
+}
+
+// Outcome is the result of Orchestrate.
+type Outcome struct {
+	Rounds  []Round `json:"rounds"`
+	Done    bool    `json:"done"`
+	Summary string  `json:"summary,omitempty"`
+}
+
+// Orchestrator drives a goal across providers: the planner splits it into
+// steps, each step goes to an executor, and the replies go back to the
+// planner until it answers DONE or the budget runs out.
+type Orchestrator struct {
+	Ask       AskFunc
+	Planner   string
+	Executors []string
+	Budget    Budget
+	MaxChars  int // cap on each reply fed back to the planner; 0 for DefaultOrchestrateMaxChars
+
+	// OnPlan and OnStep, if set, are called after each planner ask and
+	// each executor step.
+	OnPlan func(round int, r Round)
+	OnStep func(round int, s Step)
+}
+
+// Run orchestrates base.Message as the goal, using base for every ask's
+// other settings. It returns the rounds run so far with an error when an
+// ask fails or the budget runs out before the planner is done.
+func (o *Orchestrator) Run(base client.AskRequest) (*Outcome, error) {
+	if len(o.Executors) == 0 {
+		return nil, fmt.Errorf("no executors")
+	}
+	maxRounds := o.Budget.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxRounds
+	}
+	maxChars := o.MaxChars
+	if maxChars == 0 {
+		maxChars = prompt.DefaultOrchestrateMaxChars
+	}
+	started := time.Now()
+	outOfTime := func() bool { return o.Budget.MaxTime > 0 && time.Since(started) >= o.Budget.MaxTime }
+
+	goal := base.Message
+	out := &Outcome{}
+	steps := 0
+	next := prompt.OrchestrateStart(goal, o.Executors, maxRounds)
+	for round := 1; round <= maxRounds; round++ {
+		if outOfTime() {
+			return out, fmt.Errorf("time budget of %s used up after %d round(s)", o.Budget.MaxTime, round-1)
+		}
+		req := base
+		req.Provider = o.Planner
+		req.Message = next
+		res, err := o.Ask(req)
+		if err != nil {
+			return out, fmt.Errorf("planner %s: %w", o.Planner, err)
+		}
+		r := Round{Planner: res}
+		if res.ExitCode != 0 {
+			out.Rounds = append(out.Rounds, r)
+			return out, fmt.Errorf("planner %s: %s", o.Planner, askError(res))
+		}
+		plan, err := ParsePlan(res.Reply)
+		r.Plan = plan
+		if err == nil {
+			err = o.checkPlan(plan)
+		}
+		if err != nil {
+			r.Note = err.Error()
+			out.Rounds = append(out.Rounds, r)
+			if o.OnPlan != nil {
+				o.OnPlan(round, r)
+			}
+			next = prompt.OrchestrateResults(nil, "Your last reply could not be used: "+err.Error()+".", maxRounds-round, maxChars)
+			continue
+		}
+		if o.OnPlan != nil {
+			o.OnPlan(round, r)
+		}
+		if plan.Done {
+			out.Rounds = append(out.Rounds, r)
+			out.Done, out.Summary = true, plan.Summary
+			return out, nil
+		}
+
+		var results []prompt.StepResult
+		for _, ps := range plan.Steps {
+			if o.Budget.MaxSteps > 0 && steps >= o.Budget.MaxSteps {
+				out.Rounds = append(out.Rounds, r)
+				return out, fmt.Errorf("step budget of %d used up", o.Budget.MaxSteps)
+			}
+			if outOfTime() {
+				out.Rounds = append(out.Rounds, r)
+				return out, fmt.Errorf("time budget of %s used up in round %d", o.Budget.MaxTime, round)
+			}
+			req := base
+			req.Provider = ps.Provider
+			req.Message = prompt.OrchestrateStep(goal, ps.Instruction)
+			res, err := o.Ask(req)
+			steps++
+			if err != nil {
+				out.Rounds = append(out.Rounds, r)
+				return out, fmt.Errorf("round %d, %s: %w", round, ps.Provider, err)
+			}
+			step := Step{Hop: Hop{Provider: ps.Provider, TimeoutS: req.TimeoutS}, Prompt: req.Message, Result: res}
+			r.Steps = append(r.Steps, step)
+			if o.OnStep != nil {
+				o.OnStep(round, step)
+			}
+			sr := prompt.StepResult{Provider: ps.Provider, Instruction: ps.Instruction,
+				Reply: strings.TrimSpace(protocol.StripTrailingMarkers(res.Reply))}
+			if res.ExitCode != 0 {
+				// Let the planner route around a failed step.
+				sr.Error = askError(res)
+			}
+			results = append(results, sr)
+		}
+		out.Rounds = append(out.Rounds, r)
+		next = prompt.OrchestrateResults(results, "", maxRounds-round, maxChars)
+	}
+	return out, fmt.Errorf("planner %s not done after %d round(s)", o.Planner, maxRounds)
+}
+
+// checkPlan rejects steps for providers that are not executors.
+func (o *Orchestrator) checkPlan(plan Plan) error {
+	for _, ps := range plan.Steps {
+		known := false
+		for _, e := range o.Executors {
+			known = known || e == ps.Provider
+		}
+		if !known {
+			return fmt.Errorf("%q is not an executor (use one of %s)", ps.Provider, strings.Join(o.Executors, ", "))
+		}
+		if ps.Instruction == "" {
+			return fmt.Errorf("the step for %s has no instructions", ps.Provider)
+		}
+	}
+	return nil
+}
+
+// askError describes a failed ask result.
+func askError(res *client.AskResult) string {
+	if res.Error != "" {
+		return res.Error
+	}
+	return fmt.Sprintf("exit code %d", res.ExitCode)
+}
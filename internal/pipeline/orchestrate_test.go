@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+)
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  Plan
+		err   bool
+	}{
+		{
+			name:  "steps",
+			reply: "Sure, here is the plan.\nSTEP codex\nwrite the parser\nwith tests\n\nSTEP Gemini: review it\nCCB_DONE: 20260125-143000-123-1\n",
+			want: Plan{Steps: []PlanStep{
+				{Provider: "codex", Instruction: "write the parser\nwith tests"},
+				{Provider: "gemini", Instruction: "review it"},
+			}},
+		},
+		{
+			name:  "done wins",
+			reply: "STEP codex\nmore work\nDONE: all set\nthe parser is in place\n",
+			want:  Plan{Done: true, Summary: "all set\nthe parser is in place"},
+		},
+		{name: "neither", reply: "I think we should start with the parser.", err: true},
+		{name: "lowercase is prose", reply: "step codex: do it\ndone", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePlan(tt.reply)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParsePlan = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// scriptedAsk answers the planner from replies in order and echoes
+// executor prompts.
+func scriptedAsk(planner string, replies []string, sent *[]client.AskRequest) AskFunc {
+	n := 0
+	return func(req client.AskRequest) (*client.AskResult, error) {
+		*sent = append(*sent, req)
+		if req.Provider != planner {
+			if strings.Contains(req.Message, "explode") {
+				return &client.AskResult{Provider: req.Provider, ExitCode: 1, Error: "pane gone"}, nil
+			}
+			return &client.AskResult{Provider: req.Provider, Reply: req.Provider + " did it"}, nil
+		}
+		if n >= len(replies) {
+			return nil, fmt.Errorf("planner asked %d times", n+1)
+		}
+		n++
+		return &client.AskResult{Provider: req.Provider, Reply: replies[n-1]}, nil
+	}
+}
+
+func TestOrchestratorRun(t *testing.T) {
+	var sent []client.AskRequest
+	o := &Orchestrator{
+		Planner:   "claude",
+		Executors: []string{"codex", "gemini"},
+		Ask: scriptedAsk("claude", []string{
+			"STEP droid\ndo it",
+			"STEP codex\nwrite it\nSTEP gemini\nexplode",
+			"DONE\nshipped",
+		}, &sent),
+	}
+	out, err := o.Run(client.AskRequest{Message: "ship the parser", TimeoutS: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Done || out.Summary != "shipped" || len(out.Rounds) != 3 {
+		t.Fatalf("outcome = %+v", out)
+	}
+	if !strings.Contains(out.Rounds[0].Note, `"droid" is not an executor`) || len(out.Rounds[0].Steps) != 0 {
+		t.Errorf("round 1 = %+v", out.Rounds[0])
+	}
+	if len(out.Rounds[1].Steps) != 2 {
+		t.Fatalf("round 2 steps = %+v", out.Rounds[1].Steps)
+	}
+
+	var providers []string
+	for _, r := range sent {
+		providers = append(providers, r.Provider)
+		if r.TimeoutS != 30 {
+			t.Errorf("ask to %s lost the timeout", r.Provider)
+		}
+	}
+	if want := []string{"claude", "claude", "codex", "gemini", "claude"}; !reflect.DeepEqual(providers, want) {
+		t.Errorf("asked %v, want %v", providers, want)
+	}
+	if !strings.Contains(sent[0].Message, "ship the parser") || !strings.Contains(sent[0].Message, "codex, gemini") {
+		t.Errorf("first planner prompt = %q", sent[0].Message)
+	}
+	if !strings.Contains(sent[1].Message, "could not be used") {
+		t.Errorf("planner not told about the bad plan: %q", sent[1].Message)
+	}
+	if !strings.Contains(sent[2].Message, "ship the parser") || !strings.Contains(sent[2].Message, "write it") {
+		t.Errorf("executor prompt = %q", sent[2].Message)
+	}
+	last := sent[4].Message
+	if !strings.Contains(last, "codex did it") || !strings.Contains(last, "gemini failed\npane gone") {
+		t.Errorf("results prompt = %q", last)
+	}
+}
+
+func TestOrchestratorBudget(t *testing.T) {
+	loop := []string{"STEP codex\nagain", "STEP codex\nagain", "STEP codex\nagain"}
+	var sent []client.AskRequest
+	o := &Orchestrator{Planner: "claude", Executors: []string{"codex"}, Ask: scriptedAsk("claude", loop, &sent),
+		Budget: Budget{MaxRounds: 2}}
+	out, err := o.Run(client.AskRequest{Message: "goal"})
+	if err == nil || !strings.Contains(err.Error(), "not done after 2 round(s)") || len(out.Rounds) != 2 {
+		t.Errorf("rounds budget: %v, %d rounds", err, len(out.Rounds))
+	}
+
+	sent = nil
+	o = &Orchestrator{Planner: "claude", Executors: []string{"codex"}, Ask: scriptedAsk("claude", loop, &sent),
+		Budget: Budget{MaxSteps: 1}}
+	if _, err := o.Run(client.AskRequest{Message: "goal"}); err == nil || !strings.Contains(err.Error(), "step budget") {
+		t.Errorf("steps budget: %v", err)
+	}
+
+	sent = nil
+	o = &Orchestrator{Planner: "claude", Executors: []string{"codex"}, Ask: scriptedAsk("claude", loop, &sent),
+		Budget: Budget{MaxTime: time.Nanosecond}}
+	time.Sleep(time.Millisecond)
+	if _, err := o.Run(client.AskRequest{Message: "goal"}); err == nil || !strings.Contains(err.Error(), "time budget") {
+		t.Errorf("time budget: %v", err)
+	}
+}
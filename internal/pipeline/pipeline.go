@@ -0,0 +1,94 @@
+// Package pipeline chains asks so that one provider's reply becomes the
+// next provider's prompt.
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// AskFunc sends one request; client.Ask in production.
+type AskFunc func(client.AskRequest) (*client.AskResult, error)
+
+// Hop is one provider in a relay and its timeout in seconds.
+type Hop struct {
+	Provider string
+	TimeoutS float64
+}
+
+// Step records the prompt sent to a hop and what came back.
+type Step struct {
+	Hop    Hop
+	Prompt string
+	Result *client.AskResult
+}
+
+// ParseHops parses "codex,claude:90,gemini" into hops. A ":seconds" suffix
+// overrides defaultTimeout for that hop.
+func ParseHops(arg string, defaultTimeout float64) ([]Hop, error) {
+	var hops []Hop
+	for _, part := range strings.Split(arg, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		hop := Hop{Provider: part, TimeoutS: defaultTimeout}
+		if name, secs, ok := strings.Cut(part, ":"); ok {
+			t, err := strconv.ParseFloat(secs, 64)
+			if err != nil || t <= 0 {
+				return nil, fmt.Errorf("invalid timeout in hop %q", part)
+			}
+			hop = Hop{Provider: strings.TrimSpace(name), TimeoutS: t}
+		}
+		hop.Provider = strings.ToLower(hop.Provider)
+		if hop.Provider == "" {
+			return nil, fmt.Errorf("missing provider in hop %q", part)
+		}
+		hops = append(hops, hop)
+	}
+	return hops, nil
+}
+
+// Relay sends base.Message to the first hop, then each stripped reply to
+// the next hop. onStep, if set, is called after every hop. It stops at the
+// first failing hop and returns the steps completed so far with an error.
+func Relay(ask AskFunc, base client.AskRequest, hops []Hop, onStep func(Step)) ([]Step, error) {
+	if len(hops) == 0 {
+		return nil, fmt.Errorf("no hops")
+	}
+	steps := make([]Step, 0, len(hops))
+	prompt := base.Message
+	for i, hop := range hops {
+		req := base
+		req.Provider = hop.Provider
+		req.Message = prompt
+		req.TimeoutS = hop.TimeoutS
+
+		result, err := ask(req)
+		if err != nil {
+			return steps, fmt.Errorf("hop %d (%s): %w", i+1, hop.Provider, err)
+		}
+		step := Step{Hop: hop, Prompt: prompt, Result: result}
+		steps = append(steps, step)
+		if onStep != nil {
+			onStep(step)
+		}
+		if result.ExitCode != 0 {
+			msg := result.Error
+			if msg == "" {
+				msg = fmt.Sprintf("exit code %d", result.ExitCode)
+			}
+			return steps, fmt.Errorf("hop %d (%s): %s", i+1, hop.Provider, msg)
+		}
+
+		prompt = strings.TrimSpace(protocol.StripTrailingMarkers(result.Reply))
+		if prompt == "" && i < len(hops)-1 {
+			return steps, fmt.Errorf("hop %d (%s): empty reply", i+1, hop.Provider)
+		}
+	}
+	return steps, nil
+}
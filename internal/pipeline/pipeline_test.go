@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/claude_code_bridge/internal/client"
+)
+
+func TestParseHops(t *testing.T) {
+	hops, err := ParseHops("Codex, claude:90 ,gemini", 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Hop{{"codex", 120}, {"claude", 90}, {"gemini", 120}}
+	if !reflect.DeepEqual(hops, want) {
+		t.Errorf("ParseHops = %v, want %v", hops, want)
+	}
+	for _, bad := range []string{"codex:abc", "codex:-1", ":30"} {
+		if _, err := ParseHops(bad, 120); err == nil {
+			t.Errorf("ParseHops(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRelayFeedsReplies(t *testing.T) {
+	var sent []client.AskRequest
+	ask := func(req client.AskRequest) (*client.AskResult, error) {
+		sent = append(sent, req)
+		return &client.AskResult{
+			Provider: req.Provider,
+			Reply:    req.Provider + "(" + req.Message + ")\nCCB_DONE: 20260125-143000-123-1\n",
+		}, nil
+	}
+	hops := []Hop{{"codex", 60}, {"claude", 30}}
+	steps, err := Relay(ask, client.AskRequest{Message: "draft", Quiet: true}, hops, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || sent[1].Message != "codex(draft)" {
+		t.Fatalf("second hop prompt = %q, want stripped first reply", sent[1].Message)
+	}
+	if sent[0].TimeoutS != 60 || sent[1].TimeoutS != 30 || !sent[1].Quiet {
+		t.Errorf("per-hop request fields not applied: %+v", sent)
+	}
+}
+
+func TestRelayStopsOnFailure(t *testing.T) {
+	calls := 0
+	ask := func(req client.AskRequest) (*client.AskResult, error) {
+		calls++
+		return &client.AskResult{Provider: req.Provider, ExitCode: 2, Error: "timeout"}, nil
+	}
+	steps, err := Relay(ask, client.AskRequest{Message: "x"}, []Hop{{"codex", 1}, {"claude", 1}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "hop 1 (codex): timeout") {
+		t.Errorf("err = %v", err)
+	}
+	if calls != 1 || len(steps) != 1 {
+		t.Errorf("relay continued after failure: calls=%d steps=%d", calls, len(steps))
+	}
+}
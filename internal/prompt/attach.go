@@ -0,0 +1,116 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// Default attachment limits. A file over MaxFileBytes is truncated at a
+// line boundary; attachments over MaxTotalBytes in all are refused.
+const (
+	DefaultMaxFileBytes  = 256 << 10
+	DefaultMaxTotalBytes = 1 << 20
+)
+
+// AttachLimits bounds how much file content is inlined into a prompt.
+type AttachLimits struct {
+	MaxFileBytes  int
+	MaxTotalBytes int
+}
+
+// AttachFiles renders each spec ("path" or "path:start-end") as a fenced
+// block under a "File:" header and returns them joined, in order.
+func AttachFiles(specs []string, limits AttachLimits) (string, error) {
+	if limits.MaxFileBytes <= 0 {
+		limits.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if limits.MaxTotalBytes <= 0 {
+		limits.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+
+	var blocks []string
+	total := 0
+	for _, spec := range specs {
+		fr, err := ParseFileRange(spec)
+		if err != nil {
+			return "", err
+		}
+		var block string
+		if fr.Start > 0 {
+			block, err = RenderFileRange(fr, 0)
+		} else {
+			block, err = renderFile(fr.Path, limits.MaxFileBytes)
+		}
+		if err != nil {
+			return "", err
+		}
+		total += len(block)
+		if total > limits.MaxTotalBytes {
+			return "", fmt.Errorf("attached files exceed %d bytes in total (at %s); attach fewer files or line ranges", limits.MaxTotalBytes, spec)
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// renderFile fences a whole file, truncating it to maxBytes.
+func renderFile(path string, maxBytes int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if isBinary(data) {
+		return "", fmt.Errorf("%s looks like a binary file", path)
+	}
+	size := len(data)
+	header := "File: " + path
+	if size > maxBytes {
+		cut := bytes.LastIndexByte(data[:maxBytes], '\n')
+		if cut <= 0 {
+			cut = maxBytes
+		}
+		data = data[:cut]
+		header += fmt.Sprintf(" (truncated: first %d of %d bytes)", len(data), size)
+	}
+	text := strings.TrimRight(strings.ReplaceAll(strings.ToValidUTF8(string(data), ""), "\r\n", "\n"), "\n")
+	fence := fenceFor(text)
+	return header + "\n" + fence + protocol.FenceLang(path) + "\n" + text + "\n" + fence, nil
+}
+
+// fenceFor returns a backtick fence longer than any backtick run in text,
+// so markdown files with their own code blocks stay intact.
+func fenceFor(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// isBinary reports whether data has NUL bytes or is not UTF-8 near the start.
+func isBinary(data []byte) bool {
+	head := data[:min(len(data), 8000)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	if len(head) == len(data) {
+		return !utf8.Valid(head)
+	}
+	// The cut may split a multi-byte rune.
+	for trim := 0; trim < utf8.UTFMax; trim++ {
+		if utf8.Valid(head[:len(head)-trim]) {
+			return false
+		}
+	}
+	return true
+}
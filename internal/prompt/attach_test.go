@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttachFiles(t *testing.T) {
+	dir := t.TempDir()
+	goFile := filepath.Join(dir, "main.go")
+	os.WriteFile(goFile, []byte("package main\n\nfunc main() {}\n"), 0644)
+	mdFile := filepath.Join(dir, "design.md")
+	os.WriteFile(mdFile, []byte("# Design\n```go\nx := 1\n```\n"), 0644)
+
+	got, err := AttachFiles([]string{goFile, mdFile}, AttachLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "File: " + goFile + "\n```go\npackage main\n\nfunc main() {}\n```\n\n" +
+		"File: " + mdFile + "\n````md\n# Design\n```go\nx := 1\n```\n````"
+	if got != want {
+		t.Errorf("AttachFiles =\n%s\nwant\n%s", got, want)
+	}
+
+	// Line ranges use the numbered file-range rendering.
+	got, err = AttachFiles([]string{goFile + ":3"}, AttachLimits{})
+	if err != nil || !strings.Contains(got, "3 | func main() {}") {
+		t.Errorf("range attachment = %q, %v", got, err)
+	}
+}
+
+func TestAttachFilesLimits(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.txt")
+	os.WriteFile(big, []byte(strings.Repeat("0123456789\n", 100)), 0644)
+
+	got, err := AttachFiles([]string{big}, AttachLimits{MaxFileBytes: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "(truncated: first 43 of 1100 bytes)") || strings.Count(got, "0123456789") != 4 {
+		t.Errorf("truncated attachment = %q", got)
+	}
+
+	if _, err := AttachFiles([]string{big, big}, AttachLimits{MaxTotalBytes: 1500}); err == nil {
+		t.Error("expected an error over the total limit")
+	}
+
+	bin := filepath.Join(dir, "a.bin")
+	os.WriteFile(bin, []byte{0x7f, 'E', 'L', 'F', 0, 1}, 0644)
+	if _, err := AttachFiles([]string{bin}, AttachLimits{}); err == nil {
+		t.Error("expected an error for a binary file")
+	}
+	if _, err := AttachFiles([]string{filepath.Join(dir, "missing.go")}, AttachLimits{}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
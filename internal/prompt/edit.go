@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	goruntime "runtime"
+	"strings"
+)
+
+// EditCommentPrefix starts the help lines Edit adds to the buffer; lines
+// with it are removed from the saved message.
+const EditCommentPrefix = "# ccb:"
+
+// EditorCommand returns the editor to run: $VISUAL, then $EDITOR (which
+// may carry arguments, e.g. "code --wait"), else notepad on Windows and vi
+// elsewhere.
+func EditorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if goruntime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// Edit opens the editor on a temporary file holding initial plus a short
+// help footer for provider, and returns the saved text with the footer
+// removed. An empty result means the user gave up.
+func Edit(initial, provider string) (string, error) {
+	f, err := os.CreateTemp("", "ccb-ask-*.md")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	buf := strings.TrimRight(initial, "\n") + "\n\n" +
+		EditCommentPrefix + " Write your message to " + provider + " above, then save and quit.\n" +
+		EditCommentPrefix + " Lines starting with '" + EditCommentPrefix + "' are removed; an empty message cancels.\n"
+	_, err = f.WriteString(buf)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	editor := EditorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return stripEditComments(string(data)), nil
+}
+
+// stripEditComments drops the help lines and surrounding blank space.
+func stripEditComments(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, EditCommentPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,53 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+	dir := t.TempDir()
+	seen := filepath.Join(dir, "seen")
+	script := filepath.Join(dir, "editor.sh")
+	// The fake editor saves the buffer it was given, then appends a line.
+	os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" "+seen+"\necho 'and this' >> \"$1\"\n"), 0755)
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh "+script)
+
+	got, err := Edit("Review auth.go", "codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Review auth.go\n\nand this"; got != want {
+		t.Errorf("Edit = %q, want %q", got, want)
+	}
+	buf, _ := os.ReadFile(seen)
+	if !strings.HasPrefix(string(buf), "Review auth.go\n\n"+EditCommentPrefix) || !strings.Contains(string(buf), "to codex") {
+		t.Errorf("editor buffer = %q", buf)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got := EditorCommand(); strings.Join(got, " ") != "code --wait" {
+		t.Errorf("EditorCommand = %q", got)
+	}
+	t.Setenv("VISUAL", "nvim")
+	if got := EditorCommand(); strings.Join(got, " ") != "nvim" {
+		t.Errorf("EditorCommand with VISUAL = %q", got)
+	}
+}
+
+func TestStripEditComments(t *testing.T) {
+	in := "\n# Heading stays\nbody\r\n\n" + EditCommentPrefix + " help\n" + EditCommentPrefix + " more\n"
+	if got, want := stripEditComments(in), "# Heading stays\nbody"; got != want {
+		t.Errorf("stripEditComments = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+// Package prompt assembles ask messages from files, code and templates.
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// DefaultContextLines is how many lines around a requested range are included.
+const DefaultContextLines = 3
+
+// FileRange is a file path with an optional 1-based, inclusive line range.
+// Start and End are zero when the whole file is meant.
+type FileRange struct {
+	Path  string
+	Start int
+	End   int
+}
+
+var rangeSuffixRE = regexp.MustCompile(`:(\d+)(?:-(\d+))?$`)
+
+// ParseFileRange parses "path", "path:120" or "path:120-180". Windows drive
+// letters ("C:\src\a.go:10-20") are left intact.
+func ParseFileRange(spec string) (FileRange, error) {
+	m := rangeSuffixRE.FindStringSubmatchIndex(spec)
+	if m == nil {
+		if spec == "" {
+			return FileRange{}, fmt.Errorf("empty file spec")
+		}
+		return FileRange{Path: spec}, nil
+	}
+	fr := FileRange{Path: spec[:m[0]]}
+	fr.Start, _ = strconv.Atoi(spec[m[2]:m[3]])
+	fr.End = fr.Start
+	if m[4] >= 0 {
+		fr.End, _ = strconv.Atoi(spec[m[4]:m[5]])
+	}
+	if fr.Path == "" {
+		return FileRange{}, fmt.Errorf("missing path in %q", spec)
+	}
+	if fr.Start < 1 || fr.End < fr.Start {
+		return FileRange{}, fmt.Errorf("invalid line range in %q", spec)
+	}
+	return fr, nil
+}
+
+// String formats the range back into "path:start-end" form.
+func (fr FileRange) String() string {
+	switch {
+	case fr.Start == 0:
+		return fr.Path
+	case fr.Start == fr.End:
+		return fmt.Sprintf("%s:%d", fr.Path, fr.Start)
+	}
+	return fmt.Sprintf("%s:%d-%d", fr.Path, fr.Start, fr.End)
+}
+
+// RenderFileRange reads the file and renders the requested lines, widened
+// by contextLines on each side, as a numbered code block under a header
+// naming the file.
+func RenderFileRange(fr FileRange, contextLines int) (string, error) {
+	data, err := os.ReadFile(fr.Path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	return renderLines(fr, lines, contextLines)
+}
+
+func renderLines(fr FileRange, lines []string, contextLines int) (string, error) {
+	first, last := 1, len(lines)
+	header := fmt.Sprintf("File: %s", fr.Path)
+	if fr.Start > 0 {
+		if fr.Start > len(lines) {
+			return "", fmt.Errorf("%s has only %d lines", fr.Path, len(lines))
+		}
+		first = max(1, fr.Start-contextLines)
+		last = min(len(lines), fr.End+contextLines)
+		header = fmt.Sprintf("File: %s (lines %d-%d, focus on %d-%d)", fr.Path, first, last, fr.Start, min(fr.End, len(lines)))
+	}
+
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("\n```")
+	b.WriteString(protocol.FenceLang(fr.Path))
+	b.WriteString("\n")
+	for n := first; n <= last; n++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, lines[n-1])
+	}
+	b.WriteString("```")
+	return b.String(), nil
+}
@@ -0,0 +1,66 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileRange(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    FileRange
+		wantErr bool
+	}{
+		{"a/b.go", FileRange{Path: "a/b.go"}, false},
+		{"a/b.go:12", FileRange{Path: "a/b.go", Start: 12, End: 12}, false},
+		{"a/b.go:120-180", FileRange{Path: "a/b.go", Start: 120, End: 180}, false},
+		{`C:\src\b.go:10-20`, FileRange{Path: `C:\src\b.go`, Start: 10, End: 20}, false},
+		{`C:\src\b.go`, FileRange{Path: `C:\src\b.go`}, false},
+		{"b.go:20-10", FileRange{}, true},
+		{"b.go:0", FileRange{}, true},
+		{":10", FileRange{}, true},
+		{"", FileRange{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFileRange(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseFileRange(%q) err = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFileRange(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestRenderFileRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leak.go")
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line"+string(rune('a'+i-1)))
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	got, err := RenderFileRange(FileRange{Path: path, Start: 10, End: 12}, 2)
+	if err != nil {
+		t.Fatalf("RenderFileRange: %v", err)
+	}
+	if !strings.Contains(got, "(lines 8-14, focus on 10-12)") {
+		t.Errorf("missing header range:\n%s", got)
+	}
+	if !strings.Contains(got, "```go\n 8 | lineh\n") || !strings.HasSuffix(got, "14 | linen\n```") {
+		t.Errorf("unexpected body:\n%s", got)
+	}
+	if strings.Contains(got, " 7 |") || strings.Contains(got, "15 |") {
+		t.Errorf("context exceeded:\n%s", got)
+	}
+
+	if _, err := RenderFileRange(FileRange{Path: path, Start: 30, End: 31}, 2); err == nil {
+		t.Error("expected error for range past end of file")
+	}
+	whole, _ := RenderFileRange(FileRange{Path: path}, 2)
+	if !strings.Contains(whole, " 1 | linea") || !strings.Contains(whole, "20 | linet") {
+		t.Errorf("whole file render wrong:\n%s", whole)
+	}
+}
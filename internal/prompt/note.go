@@ -0,0 +1,11 @@
+package prompt
+
+import "strings"
+
+// NotePrefix marks a message as an annotation rather than a question.
+const NotePrefix = "[ccb note] "
+
+// Note renders text as an annotation for a provider pane.
+func Note(text string) string {
+	return NotePrefix + strings.TrimSpace(text)
+}
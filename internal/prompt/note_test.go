@@ -0,0 +1,15 @@
+package prompt
+
+import "testing"
+
+func TestNote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"switching to branch X", "[ccb note] switching to branch X"},
+		{"  tests are green\n", "[ccb note] tests are green"},
+	}
+	for _, tt := range tests {
+		if got := Note(tt.in); got != tt.want {
+			t.Errorf("Note(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
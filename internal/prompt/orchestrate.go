@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultOrchestrateMaxChars caps each executor reply fed back to the
+// planner.
+const DefaultOrchestrateMaxChars = 4000
+
+// StepResult is what an executor made of one planned step, as reported
+// back to the planner.
+type StepResult struct {
+	Provider    string
+	Instruction string
+	Reply       string
+	Error       string
+}
+
+// OrchestrateStart is the planner's first prompt: the goal, the executors
+// it may hand steps to and the STEP/DONE reply format.
+func OrchestrateStart(goal string, executors []string, maxRounds int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[ccb orchestrate] You are the planner for this goal:\n\n%s\n\n", strings.TrimSpace(goal))
+	fmt.Fprintf(&b, "Break it into steps and hand each to one of these executors: %s. "+
+		"They cannot see this conversation; give each step everything it needs. "+
+		"You will get their replies back and can plan further steps (at most %d planning rounds).\n\n",
+		strings.Join(executors, ", "), maxRounds)
+	b.WriteString(orchestrateFormat)
+	return b.String()
+}
+
+// OrchestrateResults is a follow-up planner prompt: the executors' replies
+// for the last round (cut to maxChars each, 0 for no limit), an optional
+// note about the planner's previous reply, and the rounds left.
+func OrchestrateResults(results []StepResult, note string, roundsLeft, maxChars int) string {
+	var b strings.Builder
+	b.WriteString("[ccb orchestrate] ")
+	if note = strings.TrimSpace(note); note != "" {
+		b.WriteString(note + "\n")
+	}
+	if len(results) > 0 {
+		b.WriteString("Results of the last round:\n")
+	}
+	for i, r := range results {
+		fmt.Fprintf(&b, "\n### %d. %s was asked\n%s\n", i+1, r.Provider, clip(r.Instruction, maxChars))
+		if r.Error != "" {
+			fmt.Fprintf(&b, "\n### %d. %s failed\n%s\n", i+1, r.Provider, r.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %d. %s replied\n%s\n", i+1, r.Provider, clip(r.Reply, maxChars))
+	}
+	fmt.Fprintf(&b, "\n%d planning round(s) left. ", roundsLeft)
+	b.WriteString(orchestrateFormat)
+	return b.String()
+}
+
+// OrchestrateStep is the prompt for an executor: the overall goal for
+// context, then its own step.
+func OrchestrateStep(goal, instruction string) string {
+	return fmt.Sprintf("[ccb orchestrate] This is one step of a larger goal:\n%s\n\nYour step:\n%s\n",
+		strings.TrimSpace(goal), strings.TrimSpace(instruction))
+}
+
+// orchestrateFormat tells the planner how to reply.
+const orchestrateFormat = `Reply in this format only:
+- To hand out work, one block per step, run in order:
+  STEP <executor>
+  <instructions for that executor>
+- When the goal is met, a final block:
+  DONE
+  <the final answer or a summary of what was done>
+`
@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrchestratePrompts(t *testing.T) {
+	start := OrchestrateStart("  ship the parser\n", []string{"codex", "gemini"}, 4)
+	for _, want := range []string{"ship the parser\n\n", "codex, gemini", "at most 4 planning rounds", "STEP <executor>", "DONE"} {
+		if !strings.Contains(start, want) {
+			t.Errorf("OrchestrateStart missing %q:\n%s", want, start)
+		}
+	}
+
+	results := OrchestrateResults([]StepResult{
+		{Provider: "codex", Instruction: "write it", Reply: strings.Repeat("x", 20)},
+		{Provider: "gemini", Instruction: "review it", Error: "timeout"},
+	}, "", 2, 10)
+	for _, want := range []string{"### 1. codex was asked\nwrite it", "xxxxxxxxxx [... 10 more characters]", "### 2. gemini failed\ntimeout", "2 planning round(s) left"} {
+		if !strings.Contains(results, want) {
+			t.Errorf("OrchestrateResults missing %q:\n%s", want, results)
+		}
+	}
+	if strings.Contains(results, "gemini replied") {
+		t.Errorf("failed step shown as a reply:\n%s", results)
+	}
+
+	step := OrchestrateStep("ship the parser", "write it")
+	if !strings.Contains(step, "larger goal:\nship the parser") || !strings.HasSuffix(step, "Your step:\nwrite it\n") {
+		t.Errorf("OrchestrateStep = %q", step)
+	}
+}
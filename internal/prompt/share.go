@@ -0,0 +1,46 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/claude_code_bridge/internal/history"
+)
+
+// DefaultShareMaxChars caps each prompt and reply in a shared context.
+const DefaultShareMaxChars = 1500
+
+// ShareContext renders exchanges with provider from (oldest first) as a
+// context message for another provider: a short preface asking for a
+// one-line acknowledgement, an optional note, then each prompt and reply,
+// cut to maxChars characters each (0 for no limit).
+func ShareContext(from string, exchanges []history.Entry, note string, maxChars int) string {
+	var b strings.Builder
+	noun := "exchanges"
+	if len(exchanges) == 1 {
+		noun = "exchange"
+	}
+	fmt.Fprintf(&b, "[ccb share] Context from %s: the last %d %s in this project, oldest first. "+
+		"Take it in as background for what comes next; no work is needed yet. "+
+		"Reply with one line saying what you picked up.\n", from, len(exchanges), noun)
+	if note = strings.TrimSpace(note); note != "" {
+		fmt.Fprintf(&b, "\nNote: %s\n", note)
+	}
+	for i, e := range exchanges {
+		fmt.Fprintf(&b, "\n### %d. Asked %s (%s)\n%s\n", i+1, from, e.Time.Local().Format("2006-01-02 15:04"), clip(e.Prompt, maxChars))
+		fmt.Fprintf(&b, "\n### %d. %s replied\n%s\n", i+1, from, clip(e.Reply, maxChars))
+	}
+	return b.String()
+}
+
+// clip trims s and cuts it to max characters, noting how much was dropped.
+func clip(s string, max int) string {
+	s = strings.TrimSpace(s)
+	n := utf8.RuneCountInString(s)
+	if max <= 0 || n <= max {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:max])) + fmt.Sprintf(" [... %d more characters]", n-max)
+}
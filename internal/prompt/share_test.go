@@ -0,0 +1,53 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/history"
+)
+
+func TestShareContext(t *testing.T) {
+	at := time.Date(2026, 1, 25, 14, 30, 0, 0, time.Local)
+	exchanges := []history.Entry{
+		{Time: at, Provider: "codex", Prompt: "write the parser", Reply: "done: parser.go"},
+		{Time: at.Add(time.Minute), Provider: "codex", Prompt: "add tests", Reply: strings.Repeat("x", 30)},
+	}
+	got := ShareContext("codex", exchanges, "  claude reviews next ", 20)
+
+	for _, want := range []string{
+		"Context from codex: the last 2 exchanges in this project",
+		"\nNote: claude reviews next\n",
+		"### 1. Asked codex (2026-01-25 14:30)\nwrite the parser\n",
+		"### 1. codex replied\ndone: parser.go\n",
+		"### 2. Asked codex (2026-01-25 14:31)\nadd tests\n",
+		"### 2. codex replied\n" + strings.Repeat("x", 20) + " [... 10 more characters]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ShareContext() missing %q in:\n%s", want, got)
+		}
+	}
+
+	one := ShareContext("gemini", exchanges[:1], "", 0)
+	if !strings.Contains(one, "the last 1 exchange in") || strings.Contains(one, "Note:") {
+		t.Errorf("single exchange without note:\n%s", one)
+	}
+}
+
+func TestClip(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{" short ", 10, "short"},
+		{"héllo wörld", 5, "héllo [... 6 more characters]"},
+		{"abc", 0, "abc"},
+	}
+	for _, tt := range tests {
+		if got := clip(tt.in, tt.max); got != tt.want {
+			t.Errorf("clip(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
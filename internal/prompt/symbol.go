@@ -0,0 +1,332 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultMaxSymbolRefs caps how many reference sites are listed per symbol.
+const DefaultMaxSymbolRefs = 20
+
+// symbolLoc is one definition or reference site.
+type symbolLoc struct {
+	file string
+	line int
+	text string // declaration source or the referencing line
+}
+
+// SymbolIndex is a loaded, type-checked module to look symbols up in.
+type SymbolIndex struct {
+	root    string
+	fset    *token.FileSet
+	pkgs    []*packages.Package
+	sources map[string][]byte
+}
+
+// LoadSymbols loads the packages of the module at root, tests included,
+// and type-checks them with go/packages; this needs the go command.
+// Packages that fail to type-check are searched as far as they resolve.
+func LoadSymbols(root string) (*SymbolIndex, error) {
+	fset := token.NewFileSet()
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   root,
+		Fset:  fset,
+		Tests: true,
+		// Dependencies are type-checked from source too; only their
+		// declarations matter, so skip their function bodies.
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if file != nil && !within(root, filename) {
+				for _, decl := range file.Decls {
+					if fn, ok := decl.(*ast.FuncDecl); ok {
+						fn.Body = nil
+					}
+				}
+			}
+			return file, err
+		},
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("load packages under %s: %w", root, err)
+	}
+	return &SymbolIndex{root: root, fset: fset, pkgs: pkgs, sources: make(map[string][]byte)}, nil
+}
+
+// SymbolContext loads the module at root and returns symbol's context (see
+// SymbolIndex.Context).
+func SymbolContext(root, symbol string, maxRefs int) (string, error) {
+	x, err := LoadSymbols(root)
+	if err != nil {
+		return "", err
+	}
+	return x.Context(symbol, maxRefs)
+}
+
+// Context finds the Go definition(s) and references of symbol and renders
+// them for inclusion in a prompt. symbol is "Name", "pkg.Name" (package
+// name or import path) or "Type.Method" (also "pkg.Type.Method").
+// References are the type-checked uses of the definition, so they follow
+// import aliases, dot imports and method values, and a method is not
+// confused with another type's method of the same name.
+func (x *SymbolIndex) Context(symbol string, maxRefs int) (string, error) {
+	qual, name := splitSymbol(symbol)
+	if name == "" {
+		return "", fmt.Errorf("invalid symbol %q", symbol)
+	}
+	if maxRefs <= 0 {
+		maxRefs = DefaultMaxSymbolRefs
+	}
+
+	// A package is loaded again with its tests, each time with its own
+	// objects, so definitions are matched by position.
+	targets := make(map[token.Pos]bool)
+	var defs, refs []symbolLoc
+	seen := make(map[string]bool) // file:line already listed
+	for _, pkg := range x.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Defs {
+			if obj == nil || !matchesSymbol(obj, qual, name) {
+				continue
+			}
+			targets[obj.Pos()] = true
+			pos := x.fset.Position(id.Pos())
+			key := fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+			if seen[key] {
+				continue
+			}
+			node, doc := declOf(pkg.Syntax, id)
+			if node == nil {
+				continue
+			}
+			seen[key] = true
+			defs = append(defs, declLoc(x.fset, x.source(pos.Filename), x.rel(pos.Filename), node, doc))
+		}
+	}
+	if len(defs) == 0 {
+		return "", fmt.Errorf("symbol %s not found under %s", symbol, x.root)
+	}
+
+	for _, pkg := range x.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if !targets[origin(obj).Pos()] {
+				continue
+			}
+			pos := x.fset.Position(id.Pos())
+			key := fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			lines := bytes.Split(x.source(pos.Filename), []byte("\n"))
+			if pos.Line < 1 || pos.Line > len(lines) {
+				continue
+			}
+			refs = append(refs, symbolLoc{file: x.rel(pos.Filename), line: pos.Line, text: strings.TrimSpace(string(lines[pos.Line-1]))})
+		}
+	}
+
+	sortLocs(defs)
+	sortLocs(refs)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Symbol: %s\n", symbol)
+	for _, d := range defs {
+		fmt.Fprintf(&b, "\nDefinition (%s:%d):\n```go\n%s\n```\n", d.file, d.line, d.text)
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(&b, "\nReferences (%d):\n", len(refs))
+		for i, r := range refs {
+			if i == maxRefs {
+				fmt.Fprintf(&b, "- ... %d more\n", len(refs)-maxRefs)
+				break
+			}
+			fmt.Fprintf(&b, "- %s:%d: %s\n", r.file, r.line, r.text)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// source returns file's contents, read once.
+func (x *SymbolIndex) source(file string) []byte {
+	if src, ok := x.sources[file]; ok {
+		return src
+	}
+	src, _ := os.ReadFile(file)
+	x.sources[file] = src
+	return src
+}
+
+// rel returns file relative to the module root, slash-separated.
+func (x *SymbolIndex) rel(file string) string {
+	r, err := filepath.Rel(x.root, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(r)
+}
+
+// within reports whether path is inside dir.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// splitSymbol splits "a.B" into ("a", "B"); a bare name has no qualifier.
+func splitSymbol(symbol string) (string, string) {
+	symbol = strings.TrimSpace(symbol)
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		return symbol[:i], symbol[i+1:]
+	}
+	return "", symbol
+}
+
+// matchesSymbol reports whether obj is a package-level declaration or a
+// method called name, qualified by qual (any qualifier when empty).
+func matchesSymbol(obj types.Object, qual, name string) bool {
+	if obj.Name() != name || obj.Pkg() == nil {
+		return false
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			typ := receiverName(recv.Type())
+			return qual == "" || qual == typ || qual == obj.Pkg().Name()+"."+typ || qual == obj.Pkg().Path()+"."+typ
+		}
+	}
+	if obj.Parent() != obj.Pkg().Scope() {
+		return false
+	}
+	return qual == "" || qual == obj.Pkg().Name() || qual == obj.Pkg().Path()
+}
+
+// receiverName returns the name of a method receiver's type.
+func receiverName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj().Name()
+	}
+	return ""
+}
+
+// origin maps a use of an instantiated generic function or method to the
+// declared one.
+func origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+// declOf finds the declaration that defines id among files, with the doc
+// comment to show: the declaration's own, or the spec's in a group.
+func declOf(files []*ast.File, id *ast.Ident) (ast.Node, *ast.CommentGroup) {
+	for _, file := range files {
+		if id.Pos() < file.Pos() || id.Pos() > file.End() {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Name == id {
+					return d, d.Doc
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if !specDefines(spec, id) {
+						continue
+					}
+					if len(d.Specs) == 1 {
+						return spec, d.Doc
+					}
+					return spec, specDoc(spec)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// specDefines reports whether a type/var/const spec declares id.
+func specDefines(spec ast.Spec, id *ast.Ident) bool {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name == id
+	case *ast.ValueSpec:
+		for _, n := range s.Names {
+			if n == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// specDoc returns a grouped spec's own doc comment.
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
+
+// declLoc slices the declaration's source, including its doc comment.
+func declLoc(fset *token.FileSet, src []byte, rel string, node ast.Node, doc *ast.CommentGroup) symbolLoc {
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	from := fset.Position(start).Offset
+	to := fset.Position(node.End()).Offset
+	if from < 0 || to > len(src) || from > to {
+		from, to = 0, 0
+	}
+	return symbolLoc{file: rel, line: fset.Position(node.Pos()).Line, text: string(src[from:to])}
+}
+
+func sortLocs(locs []symbolLoc) {
+	sort.Slice(locs, func(i, j int) bool {
+		if locs[i].file != locs[j].file {
+			return locs[i].file < locs[j].file
+		}
+		return locs[i].line < locs[j].line
+	})
+}
+
+// FindModuleRoot walks up from dir to the directory containing go.mod,
+// returning dir itself when there is none.
+func FindModuleRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
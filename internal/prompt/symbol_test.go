@@ -0,0 +1,151 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGo(t *testing.T, root, rel, src string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSymbolContext(t *testing.T) {
+	root := t.TempDir()
+	writeGo(t, root, "go.mod", "module example.com/m\n")
+	writeGo(t, root, "client/client.go", `package client
+
+// Ask sends a request.
+func Ask(msg string) string {
+	return msg
+}
+
+func helper() string { return Ask("inner") }
+`)
+	writeGo(t, root, "cmd/main.go", `package main
+
+import "example.com/m/client"
+
+func main() {
+	_ = client.Ask("hi")
+}
+`)
+	writeGo(t, root, "other/other.go", `package other
+
+func Ask() {}
+`)
+
+	got, err := SymbolContext(root, "client.Ask", 0)
+	if err != nil {
+		t.Fatalf("SymbolContext: %v", err)
+	}
+	for _, want := range []string{
+		"Definition (client/client.go:4):",
+		"// Ask sends a request.\nfunc Ask(msg string) string {",
+		"References (2):",
+		"- client/client.go:8: func helper() string { return Ask(\"inner\") }",
+		"- cmd/main.go:6: _ = client.Ask(\"hi\")",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "other/other.go") {
+		t.Errorf("qualified lookup matched another package:\n%s", got)
+	}
+
+	if _, err := SymbolContext(root, "client.Missing", 0); err == nil {
+		t.Error("expected error for unknown symbol")
+	}
+}
+
+func TestSymbolContextMethod(t *testing.T) {
+	root := t.TempDir()
+	writeGo(t, root, "go.mod", "module example.com/m\n")
+	writeGo(t, root, "s/s.go", `package s
+
+type Server struct{}
+
+func (s *Server) Start() error { return nil }
+
+func run(s *Server) { s.Start() }
+
+type Client struct{}
+
+func (Client) Start() error { return nil }
+
+func dial(c Client) { c.Start() }
+
+func serve(srv struct{ inner *Server }) error {
+	start := srv.inner.Start
+	return start()
+}
+`)
+	got, err := SymbolContext(root, "Server.Start", 0)
+	if err != nil {
+		t.Fatalf("SymbolContext: %v", err)
+	}
+	for _, want := range []string{"func (s *Server) Start() error", "References (2):", "- s/s.go:7:", "- s/s.go:16: start := srv.inner.Start"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("method context missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "s/s.go:13") || strings.Contains(got, "s/s.go:11") {
+		t.Errorf("matched Client.Start, which only shares the name:\n%s", got)
+	}
+}
+
+func TestSymbolContextImportAlias(t *testing.T) {
+	root := t.TempDir()
+	writeGo(t, root, "go.mod", "module example.com/m\n")
+	writeGo(t, root, "client/client.go", `package client
+
+func Ask() {}
+`)
+	writeGo(t, root, "a/a.go", `package a
+
+import c "example.com/m/client"
+
+func run() { c.Ask() }
+`)
+	writeGo(t, root, "b/b.go", `package b
+
+import . "example.com/m/client"
+
+func run() { Ask() }
+`)
+	writeGo(t, root, "d/d.go", `package d
+
+func Ask() {}
+
+func run() { Ask() }
+`)
+	got, err := SymbolContext(root, "client.Ask", 0)
+	if err != nil {
+		t.Fatalf("SymbolContext: %v", err)
+	}
+	for _, want := range []string{"- a/a.go:5: func run() { c.Ask() }", "- b/b.go:5: func run() { Ask() }"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "d/d.go") {
+		t.Errorf("qualified lookup matched another package:\n%s", got)
+	}
+}
+
+func TestFindModuleRoot(t *testing.T) {
+	root := t.TempDir()
+	writeGo(t, root, "go.mod", "module x\n")
+	deep := filepath.Join(root, "a", "b")
+	os.MkdirAll(deep, 0755)
+	if got := FindModuleRoot(deep); got != root {
+		t.Errorf("FindModuleRoot = %q, want %q", got, root)
+	}
+}
@@ -0,0 +1,109 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// TemplateExt is the file extension of prompt templates.
+const TemplateExt = ".tmpl"
+
+// TemplateDir returns the template store, ~/.ccb/templates.
+func TemplateDir() string {
+	return filepath.Join(config.GlobalConfigDir(), "templates")
+}
+
+// TemplatePath returns the file for template name in dir. Names are plain
+// file names without the extension; "review" is dir/review.tmpl.
+func TemplatePath(dir, name string) (string, error) {
+	name = strings.TrimSuffix(name, TemplateExt)
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	return filepath.Join(dir, name+TemplateExt), nil
+}
+
+// ListTemplates returns the template names in dir, sorted. A missing dir
+// has no templates.
+func ListTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), TemplateExt) {
+			names = append(names, strings.TrimSuffix(e.Name(), TemplateExt))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ParseVars parses "key=value" specs. Later specs override earlier ones.
+func ParseVars(specs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (want key=value)", spec)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// RenderTemplate expands template name from dir with Go text/template.
+// Each var is available as {{.key}}, the ask's own text as {{.Message}}
+// and the target provider as {{.Provider}}; {{file "path"}} inlines a
+// file. Referencing a var that was not given is an error.
+func RenderTemplate(dir, name string, vars map[string]string, message, provider string) (string, error) {
+	path, err := TemplatePath(dir, name)
+	if err != nil {
+		return "", err
+	}
+	text, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("template %q not found (looked for %s)", name, path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"file": readFileString}).
+		Parse(string(text))
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]string{"Message": message, "Provider": provider}
+	for k, v := range vars {
+		data[k] = v
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// readFileString is the template "file" function.
+func readFileString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,76 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	os.WriteFile(notes, []byte("keep it short"), 0644)
+	os.WriteFile(filepath.Join(dir, "review.tmpl"), []byte(
+		"Review {{.target}} for {{.Provider}}.\n{{if .Message}}Focus: {{.Message}}\n{{end}}{{file \""+filepath.ToSlash(notes)+"\"}}\n"), 0644)
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		vars    map[string]string
+		message string
+		want    string
+		wantErr string
+	}{
+		{name: "vars and message", tmpl: "review", vars: map[string]string{"target": "auth.go"}, message: "tokens",
+			want: "Review auth.go for codex.\nFocus: tokens\nkeep it short"},
+		{name: "extension allowed", tmpl: "review.tmpl", vars: map[string]string{"target": "a.go"},
+			want: "Review a.go for codex.\nkeep it short"},
+		{name: "missing var", tmpl: "review", wantErr: `map has no entry for key "target"`},
+		{name: "unknown template", tmpl: "nope", wantErr: `template "nope" not found`},
+		{name: "path escapes", tmpl: "../review", wantErr: "invalid template name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(dir, tt.tmpl, tt.vars, tt.message, "codex")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVarsAndListTemplates(t *testing.T) {
+	vars, err := ParseVars([]string{"target=auth.go", "q=a=b", "target=main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"target": "main.go", "q": "a=b"}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("ParseVars = %v, want %v", vars, want)
+	}
+	if _, err := ParseVars([]string{"novalue"}); err == nil {
+		t.Error("ParseVars accepted a spec without '='")
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.tmpl", "a.tmpl", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	names, err := ListTemplates(dir)
+	if err != nil || !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("ListTemplates = %v, %v", names, err)
+	}
+	if names, err := ListTemplates(filepath.Join(dir, "missing")); err != nil || names != nil {
+		t.Errorf("ListTemplates(missing) = %v, %v", names, err)
+	}
+}
@@ -0,0 +1,131 @@
+package protocol
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CodeBlock is a fenced code block found in a reply.
+type CodeBlock struct {
+	Info string // text after the opening fence, e.g. "go" or "go title=main.go"
+	Body string
+}
+
+// Lang returns the language tag of the block (first word of Info).
+func (c CodeBlock) Lang() string {
+	if f := strings.Fields(c.Info); len(f) > 0 {
+		return strings.ToLower(f[0])
+	}
+	return ""
+}
+
+// ExtractCodeBlocks returns the fenced (``` or ~~~) code blocks in text, in
+// order. An unterminated trailing block is included.
+func ExtractCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	var cur *CodeBlock
+	var fence string
+	var body []string
+
+	for _, line := range splitLines(text) {
+		trimmed := strings.TrimSpace(line)
+		if cur == nil {
+			if f := fencePrefix(trimmed); f != "" {
+				fence = f
+				cur = &CodeBlock{Info: strings.TrimSpace(strings.TrimLeft(trimmed, f[:1]))}
+				body = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			cur.Body = strings.Join(body, "\n")
+			blocks = append(blocks, *cur)
+			cur = nil
+			continue
+		}
+		body = append(body, line)
+	}
+	if cur != nil {
+		cur.Body = strings.Join(body, "\n")
+		blocks = append(blocks, *cur)
+	}
+	return blocks
+}
+
+// fencePrefix returns the opening fence run if line starts a code block.
+func fencePrefix(line string) string {
+	for _, ch := range []string{"`", "~"} {
+		n := 0
+		for n < len(line) && line[n:n+1] == ch {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// extLangs maps file extensions to common fence language tags.
+var extLangs = map[string][]string{
+	".go":   {"go", "golang"},
+	".py":   {"py", "python"},
+	".js":   {"js", "javascript"},
+	".ts":   {"ts", "typescript"},
+	".tsx":  {"tsx"},
+	".rs":   {"rs", "rust"},
+	".sh":   {"sh", "bash", "shell"},
+	".ps1":  {"ps1", "powershell", "pwsh"},
+	".md":   {"md", "markdown"},
+	".json": {"json"},
+	".yaml": {"yaml", "yml"},
+	".yml":  {"yaml", "yml"},
+	".c":    {"c"},
+	".h":    {"c", "h"},
+	".cpp":  {"cpp", "c++"},
+	".java": {"java"},
+	".rb":   {"rb", "ruby"},
+}
+
+// FenceLang returns the code fence language tag for path, or "".
+func FenceLang(path string) string {
+	if langs := extLangs[strings.ToLower(filepath.Ext(path))]; len(langs) > 0 {
+		return langs[0]
+	}
+	return ""
+}
+
+// BestCodeBlockFor picks the block most likely to be a new version of
+// path: one naming the file in its info string, else the largest block in
+// the file's language, else the largest block overall.
+func BestCodeBlockFor(blocks []CodeBlock, path string) (CodeBlock, bool) {
+	if len(blocks) == 0 {
+		return CodeBlock{}, false
+	}
+	base := filepath.Base(path)
+	for _, b := range blocks {
+		if base != "" && strings.Contains(b.Info, base) {
+			return b, true
+		}
+	}
+
+	langs := extLangs[strings.ToLower(filepath.Ext(path))]
+	best, bestLen := -1, -1
+	for i, b := range blocks {
+		for _, l := range langs {
+			if b.Lang() == l && len(b.Body) > bestLen {
+				best, bestLen = i, len(b.Body)
+			}
+		}
+	}
+	if best >= 0 {
+		return blocks[best], true
+	}
+
+	for i, b := range blocks {
+		if len(b.Body) > bestLen {
+			best, bestLen = i, len(b.Body)
+		}
+	}
+	return blocks[best], true
+}
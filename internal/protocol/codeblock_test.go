@@ -0,0 +1,55 @@
+package protocol
+
+import "testing"
+
+func TestExtractCodeBlocks(t *testing.T) {
+	text := "Here is the fix:\n\n```go\nfunc a() {}\n```\n\nAnd a note:\n~~~\nplain\n~~~\n```python title=x.py\nprint(1)"
+	blocks := ExtractCodeBlocks(text)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d: %+v", len(blocks), blocks)
+	}
+	if blocks[0].Lang() != "go" || blocks[0].Body != "func a() {}" {
+		t.Errorf("block 0 = %+v", blocks[0])
+	}
+	if blocks[1].Lang() != "" || blocks[1].Body != "plain" {
+		t.Errorf("block 1 = %+v", blocks[1])
+	}
+	if blocks[2].Lang() != "python" || blocks[2].Body != "print(1)" {
+		t.Errorf("unterminated block 2 = %+v", blocks[2])
+	}
+}
+
+func TestExtractCodeBlocksNestedFence(t *testing.T) {
+	text := "````md\n```go\nx\n```\n````"
+	blocks := ExtractCodeBlocks(text)
+	if len(blocks) != 1 || blocks[0].Body != "```go\nx\n```" {
+		t.Fatalf("nested fence not preserved: %+v", blocks)
+	}
+}
+
+func TestBestCodeBlockFor(t *testing.T) {
+	blocks := []CodeBlock{
+		{Info: "bash", Body: "go test ./... && go vet ./... && echo done"},
+		{Info: "go", Body: "package x"},
+		{Info: "go", Body: "package x\n\nfunc Longer() {}"},
+	}
+	got, ok := BestCodeBlockFor(blocks, "internal/x/x.go")
+	if !ok || got.Body != blocks[2].Body {
+		t.Errorf("by language: got %+v", got)
+	}
+
+	named := append(blocks, CodeBlock{Info: "go title=x.go", Body: "package x // named"})
+	got, _ = BestCodeBlockFor(named, "internal/x/x.go")
+	if got.Body != "package x // named" {
+		t.Errorf("by file name: got %+v", got)
+	}
+
+	got, _ = BestCodeBlockFor(blocks, "notes.txt")
+	if got.Body != blocks[0].Body {
+		t.Errorf("fallback largest: got %+v", got)
+	}
+
+	if _, ok := BestCodeBlockFor(nil, "x.go"); ok {
+		t.Error("expected no block for empty input")
+	}
+}
@@ -0,0 +1,102 @@
+package protocol
+
+import (
+	"regexp"
+	"strings"
+)
+
+// echoDecorCutset is trimmed from line edges before matching echoed
+// scaffolding; TUIs prefix transcript lines with prompt glyphs, bullets
+// and box-drawing borders.
+const echoDecorCutset = " \t│┃|>▌›•⎿"
+
+var (
+	// reqIDLineRE matches an echoed anchor line.
+	reqIDLineRE = regexp.MustCompile(`^CCB_REQ_ID:\s*\S+$`)
+	// scaffoldBullets are the instruction bullets every wrapper adds.
+	scaffoldBullets = map[string]bool{
+		"Reply normally.":             true,
+		"Reply normally, in English.": true,
+	}
+)
+
+// finalLineInstruction starts the wrapper's last instruction, which asks
+// for the CCB_DONE line.
+const finalLineInstruction = "End your reply with this exact final line"
+
+// normalizeEchoLine strips TUI decoration and a leading list dash so an
+// echoed wrapper line compares equal to the text we sent.
+func normalizeEchoLine(line string) string {
+	line = strings.Trim(line, echoDecorCutset)
+	line = strings.TrimPrefix(line, "- ")
+	return strings.TrimSpace(line)
+}
+
+// StripEchoedWrapper removes prompt scaffolding a provider echoed back
+// into its reply: the CCB_REQ_ID anchor, the "IMPORTANT:" instruction
+// block and the CCB_DONE line it asks for. When the whole instruction
+// block is echoed, everything up to it is the echoed prompt and is
+// dropped as well. Text without scaffolding is returned unchanged.
+func StripEchoedWrapper(text string) string {
+	lines := splitLines(text)
+	changed := false
+
+	if k := lastFinalLineInstruction(lines); k >= 0 {
+		i := k + 1
+		if !strings.HasSuffix(normalizeEchoLine(lines[k]), ":") && i < len(lines) &&
+			strings.HasSuffix(normalizeEchoLine(lines[i]), ":") {
+			i++ // instruction wrapped onto a second line by the TUI
+		}
+		for i < len(lines) && normalizeEchoLine(lines[i]) == "" {
+			i++
+		}
+		if i < len(lines) && anyCCBDoneLineRE.MatchString(normalizeEchoLine(lines[i])) {
+			i++
+		}
+		lines = lines[i:]
+		changed = true
+	}
+
+	kept := lines[:0:0]
+	for i, line := range lines {
+		n := normalizeEchoLine(line)
+		switch {
+		case reqIDLineRE.MatchString(n), scaffoldBullets[n]:
+			changed = true
+			continue
+		case n == "IMPORTANT:" && nextIsScaffold(lines, i):
+			changed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if !changed {
+		return text
+	}
+	for len(kept) > 0 && strings.TrimSpace(kept[0]) == "" {
+		kept = kept[1:]
+	}
+	return strings.Join(kept, "\n")
+}
+
+// lastFinalLineInstruction returns the index of the last echoed "exact
+// final line" instruction, or -1.
+func lastFinalLineInstruction(lines []string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(normalizeEchoLine(lines[i]), finalLineInstruction) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextIsScaffold reports whether the first non-blank line after idx is an
+// instruction bullet.
+func nextIsScaffold(lines []string, idx int) bool {
+	for _, l := range lines[idx+1:] {
+		if n := normalizeEchoLine(l); n != "" {
+			return scaffoldBullets[n]
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStripEchoedWrapperFixtures runs testdata/echo/*.in, captured from
+// provider panes and logs, through StripDoneText and compares the result
+// with the matching .want file.
+func TestStripEchoedWrapperFixtures(t *testing.T) {
+	reqID := "20260125-143000-123-12345"
+	inputs, err := filepath.Glob(filepath.Join("testdata", "echo", "*.in"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no fixtures: %v", err)
+	}
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".in")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(in, ".in") + ".want")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := StripDoneText(string(text), reqID)
+			if got != strings.TrimRight(string(want), "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestStripEchoedWrapperUnchanged(t *testing.T) {
+	text := "plain reply\r\nwith CRLF\n"
+	if got := StripEchoedWrapper(text); got != text {
+		t.Errorf("StripEchoedWrapper changed clean text: %q", got)
+	}
+}
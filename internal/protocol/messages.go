@@ -0,0 +1,388 @@
+package protocol
+
+// The daemon's request and response messages. Each request is one JSON
+// object naming its method; internal/schema publishes a JSON Schema
+// generated from these types and validates inbound requests against it.
+
+// Envelope holds the fields every request carries.
+type Envelope struct {
+	Method string      `json:"method" schema:"required" desc:"Request method; see each request type for accepted names"`
+	Token  string      `json:"token" schema:"required" desc:"Daemon token from the state file (askd.json), or a paired client token"`
+	ID     interface{} `json:"id,omitempty" desc:"String or number; pipelines the request, and every response line to it carries the same id"`
+}
+
+// PingRequest checks the daemon, or one provider when Provider is set.
+type PingRequest struct {
+	Envelope
+	Provider  string `json:"provider,omitempty" desc:"Provider to ping; empty pings the daemon only"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// ShutdownRequest stops the daemon.
+type ShutdownRequest struct {
+	Envelope
+}
+
+// StatusRequest reports daemon state; with WorkDir it also reports which
+// providers have a live pane for that project.
+type StatusRequest struct {
+	Envelope
+	WorkDir string `json:"work_dir,omitempty"`
+}
+
+// AskRequest sends a message to a provider and waits for its reply.
+type AskRequest struct {
+	Envelope
+	Provider  string  `json:"provider" schema:"required" desc:"Provider name, e.g. codex"`
+	Message   string  `json:"message" schema:"required"`
+	ClientID  string  `json:"client_id,omitempty"`
+	WorkDir   string  `json:"work_dir,omitempty" desc:"Project directory whose provider pane receives the ask"`
+	ReqID     string  `json:"req_id,omitempty" desc:"Caller-chosen request id; used for pend and history"`
+	TimeoutS  float64 `json:"timeout_s,omitempty" schema:"min=0" desc:"Reply timeout in seconds; 0 uses the default"`
+	Quiet     bool    `json:"quiet,omitempty"`
+	Caller    string  `json:"caller,omitempty"`
+	Quick     bool    `json:"quick,omitempty" desc:"Read the reply from the pane only, not provider logs"`
+	Queue     bool    `json:"queue,omitempty" desc:"Hold the ask while the provider is offline"`
+	Stream    bool    `json:"stream,omitempty" desc:"Send chunk events while the reply grows"`
+	DeliverAt string  `json:"deliver_at,omitempty" schema:"format=date-time" desc:"Hold the ask until this RFC 3339 time"`
+	TTLS      float64 `json:"ttl_s,omitempty" schema:"min=0" desc:"Drop a queued ask after this many seconds"`
+	Priority  string  `json:"priority,omitempty" schema:"enum=interactive|background" desc:"Interactive asks (the default) run ahead of waiting background ones"`
+
+	OutputPath string `json:"output_path,omitempty" desc:"Write the reply to this file (atomically) on success; relative paths are under work_dir, and the file must stay inside it. Local clients only (not TLS, HTTP or WebSocket)"`
+	Record     bool   `json:"record,omitempty" desc:"Record the text typed into the pane and pane snapshots (asciicast v2)"`
+}
+
+// AskAsyncRequest submits an ask as a background job and returns at once
+// with its job id (AskAsyncResponse). It takes AskRequest's fields; the
+// job id is the ask's req_id. Stream is ignored.
+type AskAsyncRequest AskRequest
+
+// BroadcastRequest sends one message to several providers at once; each
+// gets its own ask, and BroadcastResponse collects the results.
+type BroadcastRequest struct {
+	Envelope
+	Providers []string          `json:"providers" schema:"required" desc:"Provider names; each is asked once, in parallel"`
+	Message   string            `json:"message" schema:"required"`
+	ClientID  string            `json:"client_id,omitempty"`
+	WorkDir   string            `json:"work_dir,omitempty"`
+	WorkDirs  map[string]string `json:"work_dirs,omitempty" desc:"Work dir per provider, overriding work_dir for those listed"`
+	ReqID     string            `json:"req_id,omitempty" desc:"Base request id; each provider's ask gets <req_id>-<provider>"`
+	TimeoutS  float64           `json:"timeout_s,omitempty" schema:"min=0" desc:"Reply timeout in seconds; 0 uses each provider's default"`
+	Quiet     bool              `json:"quiet,omitempty"`
+	Caller    string            `json:"caller,omitempty"`
+	Quick     bool              `json:"quick,omitempty"`
+	Queue     bool              `json:"queue,omitempty"`
+	DeliverAt string            `json:"deliver_at,omitempty" schema:"format=date-time"`
+	TTLS      float64           `json:"ttl_s,omitempty" schema:"min=0"`
+	Record    bool              `json:"record,omitempty"`
+	Priority  string            `json:"priority,omitempty" schema:"enum=interactive|background"`
+}
+
+// JobStatusRequest reports the state of a job submitted with ask_async.
+type JobStatusRequest struct {
+	Envelope
+	JobID string `json:"job_id" schema:"required"`
+}
+
+// JobResultRequest fetches a job's result once it is done.
+type JobResultRequest struct {
+	Envelope
+	JobID string `json:"job_id" schema:"required"`
+}
+
+// JobsRequest lists the daemon's jobs.
+type JobsRequest struct {
+	Envelope
+}
+
+// PendRequest fetches the latest (or Nth most recent) reply from
+// Provider, or from any provider, or the reply to ReqID.
+type PendRequest struct {
+	Envelope
+	Provider  string `json:"provider,omitempty" desc:"Empty fetches from the reply store across all providers"`
+	SessionID string `json:"session_id,omitempty"`
+	ReqID     string `json:"req_id,omitempty" desc:"Fetch the stored reply to this request instead"`
+	N         int    `json:"n,omitempty" schema:"min=1" desc:"Fetch the Nth most recent answered reply; 1 (the default) is the latest"`
+}
+
+// RequestsRequest lists the daemon's in-flight and queued asks.
+type RequestsRequest struct {
+	Envelope
+}
+
+// CancelRequest cancels an in-flight ask, or drops a queued one.
+type CancelRequest struct {
+	Envelope
+	ReqID string `json:"req_id" schema:"required" desc:"Request id as listed by the requests method"`
+}
+
+// PauseRequest makes the daemon refuse asks to Provider until a
+// ResumeRequest. The pause survives daemon restarts.
+type PauseRequest struct {
+	Envelope
+	Provider string `json:"provider" schema:"required"`
+	Reason   string `json:"reason,omitempty" desc:"Shown in the PAUSED error"`
+}
+
+// ResumeRequest lifts a PauseRequest.
+type ResumeRequest struct {
+	Envelope
+	Provider string `json:"provider" schema:"required"`
+}
+
+// ReloadRequest makes the daemon re-read its providers and limits from
+// ccb.config, as SIGHUP does.
+type ReloadRequest struct {
+	Envelope
+}
+
+// RegisterProviderRequest makes a running daemon serve Provider, e.g.
+// one launched after the daemon started.
+type RegisterProviderRequest struct {
+	Envelope
+	Provider string `json:"provider" schema:"required"`
+	WorkDir  string `json:"work_dir,omitempty" desc:"Project whose ccb.config declares the provider, if it is a custom one"`
+}
+
+// UnregisterProviderRequest makes the daemon stop taking asks for
+// Provider; asks in flight still finish.
+type UnregisterProviderRequest struct {
+	Envelope
+	Provider string `json:"provider" schema:"required"`
+}
+
+// RotateTokenRequest makes the daemon issue a new shared token and write
+// it to the state file. The old one stays valid for GraceS.
+type RotateTokenRequest struct {
+	Envelope
+	GraceS *float64 `json:"grace_s,omitempty" schema:"min=0" desc:"Seconds the replaced token stays valid; 0 revokes it at once. Default 300"`
+}
+
+// SubscribeRequest turns the connection into a feed of StateEvents, one
+// line each, after a SubscribeResponse. It lasts until the client closes
+// the connection or the daemon stops.
+type SubscribeRequest struct {
+	Envelope
+	Events []string `json:"events,omitempty" desc:"Event kinds to receive (see StateEvent); empty receives all"`
+}
+
+// AskResponse is the final result of an ask, as the provider adapters
+// produce it.
+type AskResponse struct {
+	ExitCode     int    `json:"exit_code"`
+	Reply        string `json:"reply"`
+	ReqID        string `json:"req_id"`
+	SessionKey   string `json:"session_key"`
+	LogPath      string `json:"log_path,omitempty"`
+	AnchorSeen   bool   `json:"anchor_seen"`
+	DoneSeen     bool   `json:"done_seen"`
+	FallbackScan bool   `json:"fallback_scan"`
+	AnchorMs     int64  `json:"anchor_ms,omitempty"`
+	DoneMs       int64  `json:"done_ms,omitempty"`
+	StartupMs    int64  `json:"startup_ms,omitempty"` // submission until the prompt was sent (worker wait, typing)
+	ReplyMs      int64  `json:"reply_ms,omitempty"`   // prompt sent until the result
+	Error        string `json:"error,omitempty"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"`
+	Queued       bool   `json:"queued,omitempty"`      // held in the offline queue, not yet sent
+	OutputPath   string `json:"output_path,omitempty"` // where the reply was written (request output_path)
+	Recording    string `json:"recording,omitempty"`   // recording file, for record requests
+
+	// DoneHeuristic is set when the reply was accepted without CCB_DONE
+	// because it settled; DoneReason says how ("quiet", "turn_complete").
+	DoneHeuristic bool   `json:"done_heuristic,omitempty"`
+	DoneReason    string `json:"done_reason,omitempty"`
+}
+
+// ChunkEvent carries completed reply lines ahead of a streamed AskResponse.
+type ChunkEvent struct {
+	Event string   `json:"event" schema:"required,enum=chunk"`
+	ReqID string   `json:"req_id"`
+	Lines []string `json:"lines" schema:"required"`
+}
+
+// PingResponse answers a PingRequest.
+type PingResponse struct {
+	Status    string   `json:"status" schema:"required,enum=ok|error"`
+	Providers []string `json:"providers,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ShutdownResponse acknowledges a ShutdownRequest; the daemon stops
+// taking requests right after sending it.
+type ShutdownResponse struct {
+	Status  string `json:"status" schema:"required,enum=ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// StatusResponse answers a StatusRequest.
+type StatusResponse struct {
+	Status         string               `json:"status" schema:"required,enum=ok"`
+	PID            int                  `json:"pid"`
+	Providers      []string             `json:"providers"`
+	Workers        int                  `json:"workers"`
+	ActiveRequests int                  `json:"active_requests"`
+	Queued         int                  `json:"queued"`
+	Projects       int                  `json:"projects" desc:"Projects the daemon's routing table has panes for"`
+	Online         map[string]bool      `json:"online,omitempty" desc:"Per provider, whether a live pane exists for work_dir"`
+	Storage        []StorageCheck       `json:"storage,omitempty" desc:"Startup preflight of each provider's storage directory"`
+	Paused         map[string]PauseInfo `json:"paused,omitempty" desc:"Providers whose asks are refused"`
+}
+
+// StorageCheck is the daemon's startup finding for one provider's storage
+// directory (where replies are read from).
+type StorageCheck struct {
+	Provider string `json:"provider" schema:"required"`
+	Path     string `json:"path" schema:"required"`
+	OK       bool   `json:"ok"`
+	Problem  string `json:"problem,omitempty" desc:"Why the directory is unusable, e.g. not found"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// PendResponse answers a PendRequest.
+type PendResponse struct {
+	Status   string `json:"status" schema:"required,enum=ok|error"`
+	Reply    string `json:"reply"`
+	Provider string `json:"provider,omitempty"`
+	ReqID    string `json:"req_id"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RequestInfo describes one in-flight or queued ask.
+type RequestInfo struct {
+	ReqID    string  `json:"req_id" schema:"required"`
+	Provider string  `json:"provider" schema:"required"`
+	Caller   string  `json:"caller,omitempty"`
+	ClientID string  `json:"client_id,omitempty"`
+	WorkDir  string  `json:"work_dir,omitempty"`
+	Phase    string  `json:"phase" schema:"required,enum=queued|pending|sending|waiting"`
+	Priority string  `json:"priority,omitempty" schema:"enum=interactive|background"`
+	Started  string  `json:"started" schema:"format=date-time" desc:"When the ask arrived (queued asks: when it was queued)"`
+	ElapsedS float64 `json:"elapsed_s" schema:"min=0"`
+}
+
+// RequestsResponse answers a RequestsRequest, oldest ask first.
+type RequestsResponse struct {
+	Status   string        `json:"status" schema:"required,enum=ok"`
+	Requests []RequestInfo `json:"requests"`
+}
+
+// CancelResponse answers a CancelRequest.
+type CancelResponse struct {
+	Status string `json:"status" schema:"required,enum=ok"`
+	ReqID  string `json:"req_id"`
+	State  string `json:"state" schema:"enum=canceled|dequeued" desc:"canceled: an in-flight ask was stopped; dequeued: a queued ask was dropped unsent"`
+}
+
+// BroadcastResponse answers a BroadcastRequest once every provider's ask
+// has finished. A provider that could not be asked has an error result.
+type BroadcastResponse struct {
+	Status    string                 `json:"status" schema:"required,enum=ok"`
+	Providers []string               `json:"providers" desc:"The providers asked, in request order"`
+	Results   map[string]AskResponse `json:"results" schema:"required" desc:"Result per provider"`
+}
+
+// AskAsyncResponse answers an AskAsyncRequest.
+type AskAsyncResponse struct {
+	Status string `json:"status" schema:"required,enum=ok"`
+	JobID  string `json:"job_id" schema:"required" desc:"Also the ask's req_id, so cancel and pend accept it"`
+	State  string `json:"state" schema:"enum=running|queued|done"`
+}
+
+// JobInfo describes one job submitted with ask_async.
+type JobInfo struct {
+	JobID     string  `json:"job_id" schema:"required"`
+	Provider  string  `json:"provider" schema:"required"`
+	Caller    string  `json:"caller,omitempty"`
+	WorkDir   string  `json:"work_dir,omitempty"`
+	State     string  `json:"state" schema:"required,enum=running|queued|done" desc:"queued: held for an offline or paused provider, or scheduled"`
+	Submitted string  `json:"submitted,omitempty" schema:"format=date-time"`
+	Finished  string  `json:"finished,omitempty" schema:"format=date-time"`
+	ElapsedS  float64 `json:"elapsed_s" schema:"min=0" desc:"Until finished, or until now while the job runs"`
+	ExitCode  int     `json:"exit_code" desc:"The ask's exit code once done"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// JobStatusResponse answers a JobStatusRequest.
+type JobStatusResponse struct {
+	Status string  `json:"status" schema:"required,enum=ok"`
+	Job    JobInfo `json:"job"`
+}
+
+// JobResultResponse answers a JobResultRequest. Result is set once the
+// job is done.
+type JobResultResponse struct {
+	Status string       `json:"status" schema:"required,enum=ok"`
+	Job    JobInfo      `json:"job"`
+	Result *AskResponse `json:"result,omitempty"`
+}
+
+// JobsResponse answers a JobsRequest, oldest job first.
+type JobsResponse struct {
+	Status string    `json:"status" schema:"required,enum=ok"`
+	Jobs   []JobInfo `json:"jobs"`
+}
+
+// PauseInfo describes a paused provider.
+type PauseInfo struct {
+	Since  string `json:"since" schema:"required" desc:"RFC 3339 time the pause began"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PauseResponse answers a PauseRequest or ResumeRequest with the providers
+// paused afterwards.
+type PauseResponse struct {
+	Status   string               `json:"status" schema:"required,enum=ok"`
+	Provider string               `json:"provider"`
+	Paused   map[string]PauseInfo `json:"paused"`
+}
+
+// ReloadResponse answers a ReloadRequest with the providers served
+// afterwards and what changed.
+type ReloadResponse struct {
+	Status    string   `json:"status" schema:"required,enum=ok"`
+	Providers []string `json:"providers" schema:"required"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+}
+
+// RegistrationResponse answers a RegisterProviderRequest or
+// UnregisterProviderRequest with the providers served afterwards.
+type RegistrationResponse struct {
+	Status    string   `json:"status" schema:"required,enum=ok"`
+	Provider  string   `json:"provider"`
+	Changed   bool     `json:"changed" desc:"False if the provider already was (or was not) registered"`
+	Providers []string `json:"providers" schema:"required"`
+}
+
+// RotateTokenResponse answers a RotateTokenRequest. The new token is only
+// in the state file.
+type RotateTokenResponse struct {
+	Status             string `json:"status" schema:"required,enum=ok"`
+	PreviousValidUntil string `json:"previous_valid_until" schema:"required,format=date-time"`
+}
+
+// SubscribeResponse answers a SubscribeRequest; events follow.
+type SubscribeResponse struct {
+	Status string   `json:"status" schema:"required,enum=ok"`
+	Events []string `json:"events" desc:"Event kinds the subscription receives"`
+}
+
+// StateEvent is one daemon state change pushed to subscribers.
+type StateEvent struct {
+	Event      string `json:"event" schema:"required,enum=provider_registered|provider_unregistered|pane_died|request_started|request_finished|shutting_down"`
+	Time       string `json:"time" schema:"required,format=date-time"`
+	Provider   string `json:"provider,omitempty"`
+	WorkDir    string `json:"work_dir,omitempty" desc:"Project of the pane or ask"`
+	ReqID      string `json:"req_id,omitempty"`
+	Caller     string `json:"caller,omitempty"`
+	ExitCode   *int   `json:"exit_code,omitempty" desc:"request_finished only"`
+	Error      string `json:"error,omitempty" desc:"Why a request failed or a pane is considered dead"`
+	DurationMs int64  `json:"duration_ms,omitempty" desc:"request_finished only"`
+}
+
+// ErrorResponse is sent for rejected requests.
+type ErrorResponse struct {
+	Status string `json:"status" schema:"required,enum=error"`
+	Error  string `json:"error" schema:"required"`
+}
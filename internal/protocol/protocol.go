@@ -5,6 +5,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,10 +28,24 @@ func isGenericDoneTag(line string) bool {
 	return genericDoneTagRE.MatchString(line) && !ccbDonePrefixRE.MatchString(line)
 }
 
+var (
+	reqIDMu   sync.Mutex
+	lastReqID time.Time
+)
+
 // MakeReqID generates a unique request ID with datetime-PID format.
 // Format: YYYYMMDD-HHMMSS-mmm-PID (e.g., 20260125-143000-123-12345)
+// IDs from one process are strictly increasing: a call within the same
+// millisecond as the previous one takes the next millisecond, so
+// concurrent asks never share an anchor.
 func MakeReqID() string {
-	now := time.Now()
+	reqIDMu.Lock()
+	now := time.Now().Truncate(time.Millisecond)
+	if !now.After(lastReqID) {
+		now = lastReqID.Add(time.Millisecond)
+	}
+	lastReqID = now
+	reqIDMu.Unlock()
 	ms := now.Nanosecond() / 1_000_000
 	return fmt.Sprintf("%s-%03d-%d", now.Format("20060102-150405"), ms, os.Getpid())
 }
@@ -52,7 +67,7 @@ func isTrailingNoiseLine(line string) bool {
 // StripTrailingMarkers removes trailing protocol/harness marker lines.
 // Used for display commands (e.g., cpend) where we want a clean view.
 func StripTrailingMarkers(text string) string {
-	lines := splitLines(text)
+	lines := splitLines(StripEchoedWrapper(text))
 	for len(lines) > 0 {
 		last := lines[len(lines)-1]
 		if isTrailingNoiseLine(last) || anyCCBDoneLineRE.MatchString(last) {
@@ -77,9 +92,10 @@ func IsDoneText(text string, reqID string) bool {
 	return false
 }
 
-// StripDoneText removes the CCB_DONE marker and trailing noise from text.
+// StripDoneText removes the CCB_DONE marker and trailing noise from text,
+// along with any prompt scaffolding the provider echoed back.
 func StripDoneText(text string, reqID string) string {
-	lines := splitLines(text)
+	lines := splitLines(StripEchoedWrapper(text))
 	if len(lines) == 0 {
 		return ""
 	}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -30,6 +31,34 @@ func TestMakeReqID(t *testing.T) {
 	_ = id2
 }
 
+func TestMakeReqIDUniqueUnderConcurrency(t *testing.T) {
+	const workers, perWorker = 8, 500
+	ids := make(chan string, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ids <- MakeReqID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate req_id %q", id)
+		}
+		seen[id] = true
+		if !anyCCBDoneLineRE.MatchString(DonePrefix + " " + id) {
+			t.Errorf("req_id %q does not match the CCB_DONE pattern", id)
+		}
+	}
+}
+
 func TestWrapCodexPrompt(t *testing.T) {
 	msg := "Hello world"
 	reqID := "20260125-143000-123-12345"
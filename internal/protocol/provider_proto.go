@@ -107,6 +107,114 @@ func isDroidDone(text string, reqID string) bool {
 	return IsDoneText(text, reqID)
 }
 
+// --- Aider (aask) protocol ---
+
+func wrapAiderPrompt(message string, reqID string) string {
+	message = strings.TrimRight(message, "\n\r\t ")
+	return fmt.Sprintf(
+		"%s %s\n\n%s\n\nIMPORTANT:\n- Reply normally.\n- Reply normally, in English.\n- End your reply with this exact final line (verbatim, on its own line):\n%s %s\n",
+		ReqIDPrefix, reqID,
+		message,
+		DonePrefix, reqID,
+	)
+}
+
+func extractAiderReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isAiderDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
+// --- Goose protocol ---
+
+func wrapGoosePrompt(message string, reqID string) string {
+	message = strings.TrimRight(message, "\n\r\t ")
+	return fmt.Sprintf(
+		"%s %s\n\n%s\n\nIMPORTANT:\n- Reply normally.\n- Reply normally, in English.\n- End your reply with this exact final line (verbatim, on its own line):\n%s %s\n",
+		ReqIDPrefix, reqID,
+		message,
+		DonePrefix, reqID,
+	)
+}
+
+func extractGooseReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isGooseDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
+// --- Amp protocol ---
+
+func wrapAmpPrompt(message string, reqID string) string {
+	message = strings.TrimRight(message, "\n\r\t ")
+	return fmt.Sprintf(
+		"%s %s\n\n%s\n\nIMPORTANT:\n- Reply normally.\n- Reply normally, in English.\n- End your reply with this exact final line (verbatim, on its own line):\n%s %s\n",
+		ReqIDPrefix, reqID,
+		message,
+		DonePrefix, reqID,
+	)
+}
+
+func extractAmpReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isAmpDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
+// --- Cursor protocol ---
+
+func wrapCursorPrompt(message string, reqID string) string {
+	message = strings.TrimRight(message, "\n\r\t ")
+	return fmt.Sprintf(
+		"%s %s\n\n%s\n\nIMPORTANT:\n- Reply normally.\n- Reply normally, in English.\n- End your reply with this exact final line (verbatim, on its own line):\n%s %s\n",
+		ReqIDPrefix, reqID,
+		message,
+		DonePrefix, reqID,
+	)
+}
+
+func extractCursorReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isCursorDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
+// --- Qwen (qask) protocol ---
+
+func wrapQwenPrompt(message string, reqID string) string {
+	return wrapGeminiPrompt(message, reqID)
+}
+
+func extractQwenReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isQwenDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
+// --- Custom providers' protocol (declared in ccb.config) ---
+
+func wrapCustomPrompt(message string, reqID string) string {
+	return WrapCodexPrompt(message, reqID)
+}
+
+func extractCustomReply(text string, reqID string) string {
+	return StripDoneText(text, reqID)
+}
+
+func isCustomDone(text string, reqID string) bool {
+	return IsDoneText(text, reqID)
+}
+
 // --- Provider protocol registry ---
 
 var (
@@ -144,6 +252,48 @@ var (
 		ExtractReply: extractDroidReply,
 		IsDone:       isDroidDone,
 	}
+
+	AiderProto = &ProviderProto{
+		Name:         "aider",
+		WrapPrompt:   wrapAiderPrompt,
+		ExtractReply: extractAiderReply,
+		IsDone:       isAiderDone,
+	}
+
+	GooseProto = &ProviderProto{
+		Name:         "goose",
+		WrapPrompt:   wrapGoosePrompt,
+		ExtractReply: extractGooseReply,
+		IsDone:       isGooseDone,
+	}
+
+	AmpProto = &ProviderProto{
+		Name:         "amp",
+		WrapPrompt:   wrapAmpPrompt,
+		ExtractReply: extractAmpReply,
+		IsDone:       isAmpDone,
+	}
+
+	CursorProto = &ProviderProto{
+		Name:         "cursor",
+		WrapPrompt:   wrapCursorPrompt,
+		ExtractReply: extractCursorReply,
+		IsDone:       isCursorDone,
+	}
+
+	QwenProto = &ProviderProto{
+		Name:         "qwen",
+		WrapPrompt:   wrapQwenPrompt,
+		ExtractReply: extractQwenReply,
+		IsDone:       isQwenDone,
+	}
+
+	CustomProto = &ProviderProto{
+		Name:         "custom",
+		WrapPrompt:   wrapCustomPrompt,
+		ExtractReply: extractCustomReply,
+		IsDone:       isCustomDone,
+	}
 )
 
 // ProtoByName returns the ProviderProto for a given provider name.
@@ -159,6 +309,16 @@ func ProtoByName(name string) *ProviderProto {
 		return ClaudeProto
 	case "droid", "dask":
 		return DroidProto
+	case "aider", "aask":
+		return AiderProto
+	case "goose":
+		return GooseProto
+	case "amp":
+		return AmpProto
+	case "cursor", "cursor-agent":
+		return CursorProto
+	case "qwen", "qask":
+		return QwenProto
 	}
 	return nil
 }
@@ -148,6 +148,16 @@ func DaemonSpecByKey(key string) *ProviderDaemonSpec {
 	return nil
 }
 
+// DaemonSpecByProvider returns the daemon spec for a user-facing provider
+// name (e.g., "codex"), or nil if the provider never had its own daemon.
+func DaemonSpecByProvider(name string) *ProviderDaemonSpec {
+	prefix, ok := ProviderNameMap[name]
+	if !ok {
+		return nil
+	}
+	return DaemonSpecByKey(prefix + "d")
+}
+
 // ClientSpecByPrefix returns the client spec for a given protocol prefix (e.g., "cask").
 func ClientSpecByPrefix(prefix string) *ProviderClientSpec {
 	for _, s := range AllClientSpecs() {
@@ -165,6 +175,8 @@ var ProviderNameMap = map[string]string{
 	"opencode": "oask",
 	"claude":   "lask",
 	"droid":    "dask",
+	"aider":    "aask",
+	"qwen":     "qask",
 }
 
 // PrefixToProviderName maps protocol prefixes to user-facing provider names.
@@ -174,4 +186,6 @@ var PrefixToProviderName = map[string]string{
 	"oask": "opencode",
 	"lask": "claude",
 	"dask": "droid",
+	"aask": "aider",
+	"qask": "qwen",
 }
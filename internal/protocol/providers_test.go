@@ -51,6 +51,8 @@ func TestProviderNameMap(t *testing.T) {
 		"opencode": "oask",
 		"claude":   "lask",
 		"droid":    "dask",
+		"aider":    "aask",
+		"qwen":     "qask",
 	}
 
 	for name, prefix := range expected {
@@ -77,6 +79,11 @@ func TestProtoByName(t *testing.T) {
 		{"opencode", "opencode"},
 		{"claude", "claude"},
 		{"droid", "droid"},
+		{"aask", "aider"},
+		{"goose", "goose"},
+		{"amp", "amp"},
+		{"cursor-agent", "cursor"},
+		{"qask", "qwen"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,347 @@
+// Package recording captures what CCB types into a provider pane and the
+// pane snapshots it sees while a request runs, as asciicast v2 files
+// (one per req_id) that `asciinema play` and `ccb replay-io` can read.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// Ext is the file extension of recordings.
+const Ext = ".cast"
+
+// MaxRecordings is how many recordings Prune keeps.
+const MaxRecordings = 100
+
+// Event kinds, as in asciicast v2.
+const (
+	KindInput  = "i" // text sent to the pane
+	KindOutput = "o" // a pane snapshot
+	KindMarker = "m" // a step of the request, e.g. "sent" or "exit 0"
+)
+
+// clearScreen starts every snapshot so players redraw the whole pane.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// Nominal terminal size written to the header; snapshots are replayed as
+// full redraws, so players only use it to size the window.
+const (
+	defaultWidth  = 200
+	defaultHeight = 50
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// Event is one recorded step: Time seconds after the recording started.
+type Event struct {
+	Time float64
+	Kind string
+	Data string
+}
+
+// MarshalJSON encodes e as an asciicast [time, kind, data] triple.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Kind, e.Data})
+}
+
+// UnmarshalJSON decodes an asciicast [time, kind, data] triple.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("event has %d fields, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &e.Kind); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &e.Data)
+}
+
+// Snapshot returns the pane text of an output event.
+func (e Event) Snapshot() (string, bool) {
+	if e.Kind != KindOutput || !strings.HasPrefix(e.Data, clearScreen) {
+		return "", false
+	}
+	return strings.ReplaceAll(strings.TrimPrefix(e.Data, clearScreen), "\r\n", "\n"), true
+}
+
+// All reports whether every ask is recorded, not only those that ask for
+// it. Set CCB_RECORD=1 in the daemon's environment to turn it on.
+func All() bool {
+	return config.EnvBool("CCB_RECORD", false)
+}
+
+// Dir returns the recordings directory under runDir.
+func Dir(runDir string) string {
+	return filepath.Join(runDir, "recordings")
+}
+
+// Path returns the recording file for reqID in dir.
+func Path(dir, reqID string) (string, error) {
+	if reqID == "" || reqID != filepath.Base(reqID) || strings.ContainsAny(reqID, `/\`) || reqID == "." || reqID == ".." {
+		return "", fmt.Errorf("invalid req_id %q", reqID)
+	}
+	return filepath.Join(dir, reqID+Ext), nil
+}
+
+// Recorder appends events to one recording. Its methods are safe for
+// concurrent use and do nothing on a nil or closed Recorder.
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	f        *os.File
+	w        *bufio.Writer
+	start    time.Time
+	lastSnap string
+	closed   bool
+}
+
+// Create starts the recording for reqID in dir.
+func Create(dir, reqID, title string) (*Recorder, error) {
+	path, err := Path(dir, reqID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{path: path, f: f, w: bufio.NewWriter(f), start: time.Now()}
+	header, _ := json.Marshal(Header{
+		Version:   2,
+		Width:     defaultWidth,
+		Height:    defaultHeight,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+	})
+	r.w.Write(append(header, '\n'))
+	return r, nil
+}
+
+// Path returns the recording's file.
+func (r *Recorder) Path() string {
+	if r == nil {
+		return ""
+	}
+	return r.path
+}
+
+// Input records text sent to the pane.
+func (r *Recorder) Input(text string) {
+	r.add(KindInput, text)
+}
+
+// Mark records a step of the request.
+func (r *Recorder) Mark(label string) {
+	r.add(KindMarker, label)
+}
+
+// Snapshot records the pane contents, unless unchanged since the last one.
+func (r *Recorder) Snapshot(text string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if text == r.lastSnap {
+		r.mu.Unlock()
+		return
+	}
+	r.lastSnap = text
+	r.mu.Unlock()
+	r.add(KindOutput, clearScreen+strings.ReplaceAll(text, "\n", "\r\n"))
+}
+
+// Watch snapshots the pane via capture now and every interval until the
+// returned stop func is called, which takes a final snapshot.
+func (r *Recorder) Watch(capture func() (string, error), interval time.Duration) (stop func()) {
+	if r == nil || capture == nil {
+		return func() {}
+	}
+	snap := func() {
+		if text, err := capture(); err == nil {
+			r.Snapshot(text)
+		}
+	}
+	snap()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				snap()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+			snap()
+		})
+	}
+}
+
+func (r *Recorder) add(kind, data string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	line, _ := json.Marshal(Event{Time: time.Since(r.start).Seconds(), Kind: kind, Data: data})
+	r.w.Write(append(line, '\n'))
+	r.w.Flush()
+}
+
+// Close finishes the recording.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.w.Flush()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Read loads a recording.
+func Read(path string) (Header, []Event, error) {
+	var h Header
+	f, err := os.Open(path)
+	if err != nil {
+		return h, nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	if !sc.Scan() {
+		return h, nil, fmt.Errorf("%s: empty recording", path)
+	}
+	if err := json.Unmarshal(sc.Bytes(), &h); err != nil {
+		return h, nil, fmt.Errorf("%s: bad header: %w", path, err)
+	}
+	var events []Event
+	for n := 2; sc.Scan(); n++ {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// A daemon killed mid-write leaves a partial last line.
+			return h, events, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		events = append(events, e)
+	}
+	return h, events, sc.Err()
+}
+
+// Info describes one recording on disk.
+type Info struct {
+	ReqID   string    `json:"req_id"`
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// List returns the recordings in dir, newest first. A missing dir has none.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Info
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), Ext) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, Info{
+			ReqID:   strings.TrimSuffix(e.Name(), Ext),
+			Path:    filepath.Join(dir, e.Name()),
+			ModTime: fi.ModTime(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModTime.After(list[j].ModTime) })
+	return list, nil
+}
+
+// Prune removes all but the newest keep recordings in dir.
+func Prune(dir string, keep int) error {
+	list, err := List(dir)
+	if err != nil || len(list) <= keep {
+		return err
+	}
+	for _, info := range list[keep:] {
+		os.Remove(info.Path)
+	}
+	return nil
+}
+
+// Visible renders the control characters of typed text readably: CR as
+// "<CR>", tab as "<TAB>", others in caret notation (ESC is "^["). Line
+// feeds are kept so multi-line prompts stay legible.
+func Visible(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			b.WriteRune(r)
+		case r == '\r':
+			b.WriteString("<CR>")
+		case r == '\t':
+			b.WriteString("<TAB>")
+		case r < 0x20:
+			b.WriteByte('^')
+			b.WriteRune(r + '@')
+		case r == 0x7f:
+			b.WriteString("^?")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
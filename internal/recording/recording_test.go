@@ -0,0 +1,132 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := Create(dir, "r1", "ccb codex r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	pane := "$ "
+	stop := rec.Watch(func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return pane, nil
+	}, time.Hour)
+	rec.Input("line one\nline two\r")
+	mu.Lock()
+	pane = "$ line one\nline two\nreply"
+	mu.Unlock()
+	stop()
+	stop()
+	rec.Snapshot(pane) // unchanged: dropped
+	rec.Mark("exit 0")
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rec.Mark("after close") // ignored
+
+	h, events, err := Read(rec.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != 2 || h.Title != "ccb codex r1" {
+		t.Errorf("header = %+v", h)
+	}
+	kinds := ""
+	for _, e := range events {
+		kinds += e.Kind
+	}
+	if kinds != "oiom" {
+		t.Fatalf("event kinds = %q, want oiom: %+v", kinds, events)
+	}
+	if snap, ok := events[2].Snapshot(); !ok || snap != pane {
+		t.Errorf("snapshot = %q, %v", snap, ok)
+	}
+	if _, ok := events[1].Snapshot(); ok {
+		t.Error("input event decoded as a snapshot")
+	}
+	if events[1].Data != "line one\nline two\r" || events[3].Data != "exit 0" {
+		t.Errorf("events = %+v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Time < events[i-1].Time {
+			t.Errorf("event %d goes back in time", i)
+		}
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r"+Ext)
+	os.WriteFile(path, []byte(`{"version":2,"width":80,"height":24,"timestamp":1}
+[0.5,"i","hi\r"]
+[1.0,"o","\u001b[H`), 0600)
+	_, events, err := Read(path)
+	if err == nil || len(events) != 1 || events[0].Data != "hi\r" {
+		t.Errorf("Read = %+v, %v; want the complete event and an error", events, err)
+	}
+}
+
+func TestListAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	if list, err := List(filepath.Join(dir, "missing")); err != nil || list != nil {
+		t.Errorf("missing dir: %v, %v", list, err)
+	}
+	now := time.Now()
+	for i, id := range []string{"a", "b", "c"} {
+		path := filepath.Join(dir, id+Ext)
+		os.WriteFile(path, nil, 0600)
+		mt := now.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(path, mt, mt)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600)
+
+	list, _ := List(dir)
+	if len(list) != 3 || list[0].ReqID != "c" || list[2].ReqID != "a" {
+		t.Fatalf("List = %+v", list)
+	}
+	if err := Prune(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = List(dir)
+	if len(list) != 2 || list[1].ReqID != "b" {
+		t.Errorf("after Prune = %+v", list)
+	}
+}
+
+func TestPath(t *testing.T) {
+	for _, bad := range []string{"", ".", "..", "../x", `a\b`} {
+		if _, err := Path("/run", bad); err == nil {
+			t.Errorf("Path(%q) should fail", bad)
+		}
+	}
+	if p, err := Path("/run", "20260125-1"); err != nil || filepath.Base(p) != "20260125-1"+Ext {
+		t.Errorf("Path = %q, %v", p, err)
+	}
+}
+
+func TestVisible(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{"a\nb\r", "a\nb<CR>"},
+		{"\x1b[200~x\x1b[201~", "^[[200~x^[[201~"},
+		{"tab\there\x7f\x03", "tab<TAB>here^?^C"},
+	}
+	for _, tt := range tests {
+		if got := Visible(tt.in); got != tt.want {
+			t.Errorf("Visible(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if strings.Contains(Visible("é"), "^") {
+		t.Error("non-ASCII text mangled")
+	}
+}
@@ -0,0 +1,233 @@
+// Package replies keeps the daemon's completed ask results as bounded
+// JSONL files under the runtime directory, one per provider, so pend
+// keeps working after the daemon restarts.
+package replies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+)
+
+// DefaultMax is how many results are kept per provider without
+// CCB_REPLY_STORE_MAX.
+const DefaultMax = 100
+
+// Max returns how many results are kept per provider.
+func Max() int {
+	if n := config.EnvInt("CCB_REPLY_STORE_MAX", DefaultMax); n > 0 {
+		return n
+	}
+	return DefaultMax
+}
+
+// Entry is one completed ask.
+type Entry struct {
+	Time     time.Time               `json:"time"` // when the result was stored
+	Provider string                  `json:"provider"`
+	WorkDir  string                  `json:"work_dir,omitempty"`
+	Result   *adapter.ProviderResult `json:"result"`
+}
+
+// Answered reports whether e holds a successful, non-empty reply.
+func (e Entry) Answered() bool {
+	return e.Result != nil && e.Result.ExitCode == 0 && e.Result.Reply != ""
+}
+
+// Dir returns the reply store directory under runDir.
+func Dir(runDir string) string {
+	return filepath.Join(runDir, "replies")
+}
+
+// File returns provider's store file under dir.
+func File(dir, provider string) (string, error) {
+	if provider == "" || provider != filepath.Base(provider) || strings.ContainsAny(provider, `/\.`) {
+		return "", fmt.Errorf("invalid provider %q", provider)
+	}
+	return filepath.Join(dir, provider+".jsonl"), nil
+}
+
+var (
+	mu     sync.Mutex
+	counts = map[string]int{} // lines per file, once known
+)
+
+// Append stores e in its provider's file. Once the file holds twice max
+// entries it is rewritten with the newest max, so appends stay cheap.
+func Append(dir string, e Entry, max int) error {
+	path, err := File(dir, e.Provider)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	n, known := counts[path]
+	if !known {
+		entries, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		n = len(entries)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		delete(counts, path)
+		return err
+	}
+	counts[path] = n + 1
+	if max > 0 && n+1 > 2*max {
+		return trim(path, max)
+	}
+	return nil
+}
+
+// trim rewrites path with its newest max entries. mu must be held.
+func trim(path string, max int) error {
+	entries, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		buf.Write(append(data, '\n'))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	counts[path] = len(entries)
+	return nil
+}
+
+// Latest returns provider's nth most recent answered entry (n = 1 is the
+// newest). An empty provider means any provider.
+func Latest(dir, provider string, n int) (Entry, bool, error) {
+	var entries []Entry
+	var err error
+	if provider == "" {
+		entries, err = readAll(dir)
+	} else {
+		var path string
+		if path, err = File(dir, provider); err != nil {
+			return Entry{}, false, err
+		}
+		mu.Lock()
+		entries, err = readFile(path)
+		mu.Unlock()
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Answered() {
+			continue
+		}
+		if n--; n <= 0 {
+			return entries[i], true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// Find returns the newest entry for reqID from any provider.
+func Find(dir, reqID string) (Entry, bool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return Entry{}, false, err
+	}
+	sort.Strings(paths)
+	var found Entry
+	ok := false
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range paths {
+		entries, err := readFile(path)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		for _, e := range entries {
+			if e.Result != nil && e.Result.ReqID == reqID && (!ok || !e.Time.Before(found.Time)) {
+				found, ok = e, true
+			}
+		}
+	}
+	return found, ok, nil
+}
+
+// readAll returns the entries of every provider in dir, oldest first.
+func readAll(dir string) ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var all []Entry
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range paths {
+		entries, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+	return all, nil
+}
+
+// readFile returns the entries in path, oldest first. A missing file is
+// empty; malformed lines are skipped.
+func readFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Result == nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,108 @@
+package replies
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/daemon/adapter"
+)
+
+func entry(provider, reqID, reply string, exit int, at time.Time) Entry {
+	return Entry{Time: at, Provider: provider, Result: &adapter.ProviderResult{ReqID: reqID, Reply: reply, ExitCode: exit}}
+}
+
+func TestLatestAndFind(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{
+		entry("codex", "c1", "first", 0, now),
+		entry("gemini", "g1", "from gemini", 0, now),
+		entry("codex", "c2", "second", 0, now.Add(time.Minute)),
+		entry("codex", "c3", "", 2, now.Add(2*time.Minute)), // timed out: not an answer
+	} {
+		if err := Append(dir, e, 10); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		provider string
+		n        int
+		want     string // "" for none
+	}{
+		{"codex", 1, "second"},
+		{"codex", 2, "first"},
+		{"codex", 3, ""},
+		{"gemini", 1, "from gemini"},
+		{"claude", 1, ""},
+		{"", 1, "second"},
+		{"", 2, "from gemini"}, // ties with codex's first go by provider name
+		{"", 3, "first"},
+		{"", 4, ""},
+	}
+	for _, tt := range tests {
+		e, ok, err := Latest(dir, tt.provider, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if ok {
+			got = e.Result.Reply
+		}
+		if got != tt.want {
+			t.Errorf("Latest(%q, %d) = %q, want %q", tt.provider, tt.n, got, tt.want)
+		}
+	}
+
+	if e, ok, _ := Find(dir, "c3"); !ok || e.Provider != "codex" || e.Result.ExitCode != 2 {
+		t.Errorf("Find(c3) = %+v, %v", e, ok)
+	}
+	if _, ok, _ := Find(dir, "missing"); ok {
+		t.Error("Find(missing) found something")
+	}
+	if _, err := File(dir, "../etc"); err == nil {
+		t.Error("File accepted a path as provider")
+	}
+}
+
+func TestAppendTrims(t *testing.T) {
+	dir := t.TempDir()
+	for i := 1; i <= 7; i++ {
+		if err := Append(dir, entry("codex", fmt.Sprintf("r%d", i), fmt.Sprintf("reply %d", i), 0, time.Now()), 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, _ := File(dir, "codex")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		lines++
+	}
+	if lines > 6 {
+		t.Errorf("%d lines kept, want at most 2*max", lines)
+	}
+	if e, ok, _ := Latest(dir, "codex", 1); !ok || e.Result.ReqID != "r7" {
+		t.Errorf("Latest after trim = %+v", e)
+	}
+	if _, ok, _ := Find(dir, "r1"); ok {
+		t.Error("r1 survived the trim")
+	}
+}
+
+func TestMax(t *testing.T) {
+	t.Setenv("CCB_REPLY_STORE_MAX", "")
+	if Max() != DefaultMax {
+		t.Errorf("Max() = %d, want %d", Max(), DefaultMax)
+	}
+	t.Setenv("CCB_REPLY_STORE_MAX", "5")
+	if Max() != 5 {
+		t.Errorf("Max() = %d, want 5", Max())
+	}
+}
@@ -62,6 +62,14 @@ func LogPath(name string) string {
 	return filepath.Join(RunDir(), name+".log")
 }
 
+// LockPath returns the path for a lock file.
+func LockPath(name string) string {
+	if strings.HasSuffix(name, ".lock") {
+		return filepath.Join(RunDir(), name)
+	}
+	return filepath.Join(RunDir(), name+".lock")
+}
+
 var (
 	lastLogShrinkCheck   = make(map[string]time.Time)
 	lastLogShrinkCheckMu sync.Mutex
@@ -180,6 +188,49 @@ func NormalizeConnectHost(host string) string {
 	return host
 }
 
+// SocketPath returns the daemon's unix socket: CCB_SOCKET_PATH, else
+// askd.sock in the run dir.
+func SocketPath() string {
+	if path := strings.TrimSpace(os.Getenv("CCB_SOCKET_PATH")); path != "" {
+		return path
+	}
+	return filepath.Join(RunDir(), "askd.sock")
+}
+
+// PipeName returns the daemon's Windows named pipe: CCB_PIPE_NAME (a full
+// `\\.\pipe\...` path or just the last part), else `\\.\pipe\ccb-<user>`.
+func PipeName() string {
+	if name := strings.TrimSpace(os.Getenv("CCB_PIPE_NAME")); name != "" {
+		if !strings.HasPrefix(name, `\\`) {
+			name = `\\.\pipe\` + name
+		}
+		return name
+	}
+	user := os.Getenv("USERNAME")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	return pipeNameFor(user)
+}
+
+// pipeNameFor builds the default pipe name for user, replacing characters
+// that are awkward in pipe names.
+func pipeNameFor(user string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '_'
+	}, strings.TrimSpace(user))
+	if clean == "" {
+		clean = "default"
+	}
+	return `\\.\pipe\ccb-` + clean
+}
+
 // EnsureRunDir creates the runtime directory if it doesn't exist.
 func EnsureRunDir() error {
 	return os.MkdirAll(RunDir(), 0755)
@@ -57,6 +57,14 @@ func TestLogPath(t *testing.T) {
 	}
 }
 
+func TestLockPath(t *testing.T) {
+	for _, name := range []string{"askd", "askd.lock"} {
+		if path := LockPath(name); !strings.HasSuffix(path, string(filepath.Separator)+"askd.lock") {
+			t.Errorf("LockPath(%s) = %q, want suffix askd.lock", name, path)
+		}
+	}
+}
+
 func TestRandomToken(t *testing.T) {
 	token := RandomToken()
 	if len(token) != 32 {
@@ -93,6 +101,27 @@ func TestNormalizeConnectHost(t *testing.T) {
 	}
 }
 
+func TestPipeName(t *testing.T) {
+	tests := []struct {
+		override, user string
+		expected       string
+	}{
+		{"", "Alice", `\\.\pipe\ccb-alice`},
+		{"", `CORP\bob smith`, `\\.\pipe\ccb-corp_bob_smith`},
+		{"", "", `\\.\pipe\ccb-default`},
+		{"ccb-test", "alice", `\\.\pipe\ccb-test`},
+		{`\\.\pipe\custom`, "alice", `\\.\pipe\custom`},
+	}
+	for _, tt := range tests {
+		t.Setenv("CCB_PIPE_NAME", tt.override)
+		t.Setenv("USERNAME", tt.user)
+		t.Setenv("USER", "")
+		if got := PipeName(); got != tt.expected {
+			t.Errorf("PipeName(override %q, user %q) = %q, want %q", tt.override, tt.user, got, tt.expected)
+		}
+	}
+}
+
 func TestWriteLog(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "test.log")
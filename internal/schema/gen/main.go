@@ -0,0 +1,22 @@
+// Command gen writes the protocol's JSON Schema file. Run it with
+// "go generate ./internal/schema".
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/anthropics/claude_code_bridge/internal/schema"
+)
+
+func main() {
+	data, err := schema.Generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(schema.FileName, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+}
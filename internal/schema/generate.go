@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Generate returns the JSON Schema document for the protocol, built from
+// the request and response structs in internal/protocol.
+func Generate() ([]byte, error) {
+	defs := map[string]interface{}{}
+	var oneOf []interface{}
+	for _, m := range methods {
+		t := reflect.TypeOf(m.request)
+		def := structSchema(t)
+		props := def["properties"].(map[string]interface{})
+		method := props["method"].(map[string]interface{})
+		enum := make([]interface{}, len(m.names))
+		for i, n := range m.names {
+			enum[i] = n
+		}
+		method["enum"] = enum
+		defs[t.Name()] = def
+		oneOf = append(oneOf, map[string]interface{}{"$ref": "#/$defs/" + t.Name()})
+	}
+	for _, r := range responses {
+		t := reflect.TypeOf(r)
+		defs[t.Name()] = structSchema(t)
+	}
+
+	doc := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "ccb daemon protocol",
+		"description": fmt.Sprintf("Protocol version %d. Newline-delimited JSON over TCP: each request is one object validated against oneOf; responses are described in $defs (*Response, ChunkEvent). Requests may also be wrapped in JSON-RPC 2.0 (RPCRequest, single or batched), answered by RPCResponse.", Version),
+		"oneOf":       oneOf,
+		"$defs":       defs,
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// structSchema describes a struct as an object schema. Embedded structs
+// are flattened, as encoding/json does.
+func structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []interface{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" || !f.IsExported() {
+				continue
+			}
+			s := typeSchema(f.Type)
+			if d := f.Tag.Get("desc"); d != "" {
+				s["description"] = d
+			}
+			for _, opt := range strings.Split(f.Tag.Get("schema"), ",") {
+				key, val, _ := strings.Cut(opt, "=")
+				switch key {
+				case "required":
+					required = append(required, name)
+				case "enum":
+					var enum []interface{}
+					for _, v := range strings.Split(val, "|") {
+						enum = append(enum, v)
+					}
+					s["enum"] = enum
+				case "format":
+					s["format"] = val
+				case "min":
+					n, _ := strconv.ParseFloat(val, 64)
+					s["minimum"] = n
+				}
+			}
+			props[name] = s
+		}
+	}
+	walk(t)
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// typeSchema maps a Go type to its JSON Schema type.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]interface{}{}
+}
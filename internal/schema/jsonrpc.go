@@ -0,0 +1,52 @@
+package schema
+
+import "github.com/anthropics/claude_code_bridge/internal/protocol"
+
+// JSONRPCVersion is the "jsonrpc" member of JSON-RPC 2.0 messages.
+const JSONRPCVersion = "2.0"
+
+// JSON-RPC error codes. The -32000 to -32099 range is the daemon's own.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602 // params fail the request's schema
+	CodeServerError    = -32000 // the request failed; the message says why
+	CodeUnauthorized   = -32001 // bad or missing token; the connection is closed
+)
+
+// RPCRequest is a request in JSON-RPC 2.0 form: any request method, with
+// the request's fields (token included) as Params. Without an ID it is a
+// notification and gets no response. A JSON array of RPCRequests is a
+// batch, answered by an array of RPCResponses. Objects without "jsonrpc"
+// are read as the plain request types.
+type RPCRequest struct {
+	JSONRPC string                 `json:"jsonrpc" schema:"required,enum=2.0"`
+	ID      interface{}            `json:"id,omitempty" desc:"String or number echoed in the response; omit for a notification"`
+	Method  string                 `json:"method" schema:"required" desc:"Any request method, e.g. ask"`
+	Params  map[string]interface{} `json:"params,omitempty" desc:"The request's fields by name, including token"`
+}
+
+// RPCResponse answers an RPCRequest with the plain response object as
+// Result, or with Error.
+type RPCResponse struct {
+	JSONRPC string                 `json:"jsonrpc" schema:"required,enum=2.0"`
+	ID      interface{}            `json:"id" schema:"required" desc:"The request's id; null if it could not be read"`
+	Result  map[string]interface{} `json:"result,omitempty" desc:"The response the plain protocol would send (AskResponse, StatusResponse, ...)"`
+	Error   *RPCError              `json:"error,omitempty"`
+}
+
+// RPCError describes a failed RPCRequest. An ask that ran but failed is a
+// Result with a non-zero exit_code, not an RPCError.
+type RPCError struct {
+	Code    int    `json:"code" schema:"required"`
+	Message string `json:"message" schema:"required"`
+}
+
+// RPCNotification carries a ChunkEvent (without "event") ahead of a
+// streamed ask's RPCResponse.
+type RPCNotification struct {
+	JSONRPC string              `json:"jsonrpc" schema:"required,enum=2.0"`
+	Method  string              `json:"method" schema:"required,enum=chunk"`
+	Params  protocol.ChunkEvent `json:"params" schema:"required"`
+}
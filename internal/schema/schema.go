@@ -0,0 +1,62 @@
+// Package schema describes the daemon's wire protocol: the JSON Schema
+// (ccb-daemon.schema.json) generated from the request and response structs
+// in internal/protocol, validation of inbound requests against it, and the
+// JSON-RPC 2.0 framing.
+//
+// Requests and responses are single JSON objects, one per line, over the
+// daemon's TCP connection. A connection may carry many requests; those
+// with an id are pipelined, served concurrently and answered as they
+// finish, with the id echoed on every response line. Unknown fields are
+// ignored so older and newer clients keep working; known fields must have
+// the documented types. The same requests can be sent as JSON-RPC 2.0
+// (see RPCRequest), including batches and notifications.
+package schema
+
+//go:generate go run ./gen
+
+import (
+	"github.com/anthropics/claude_code_bridge/internal/protocol"
+)
+
+// Version is bumped on incompatible protocol changes.
+const Version = 1
+
+// FileName is the generated schema file, next to this package.
+const FileName = "ccb-daemon.schema.json"
+
+// method maps request methods (with their legacy aliases) to the request
+// type that describes them.
+type method struct {
+	names   []string
+	request interface{}
+}
+
+var methods = []method{
+	{[]string{"ping", ".ping"}, protocol.PingRequest{}},
+	{[]string{"shutdown", ".shutdown"}, protocol.ShutdownRequest{}},
+	{[]string{"status", ".status"}, protocol.StatusRequest{}},
+	{[]string{"request", ".request", "ask"}, protocol.AskRequest{}},
+	{[]string{"broadcast"}, protocol.BroadcastRequest{}},
+	{[]string{"ask_async"}, protocol.AskAsyncRequest{}},
+	{[]string{"job_status"}, protocol.JobStatusRequest{}},
+	{[]string{"job_result"}, protocol.JobResultRequest{}},
+	{[]string{"jobs"}, protocol.JobsRequest{}},
+	{[]string{"pend", ".pend"}, protocol.PendRequest{}},
+	{[]string{"requests"}, protocol.RequestsRequest{}},
+	{[]string{"cancel"}, protocol.CancelRequest{}},
+	{[]string{"pause"}, protocol.PauseRequest{}},
+	{[]string{"resume"}, protocol.ResumeRequest{}},
+	{[]string{"reload"}, protocol.ReloadRequest{}},
+	{[]string{"register_provider"}, protocol.RegisterProviderRequest{}},
+	{[]string{"unregister_provider"}, protocol.UnregisterProviderRequest{}},
+	{[]string{"rotate_token"}, protocol.RotateTokenRequest{}},
+	{[]string{"subscribe"}, protocol.SubscribeRequest{}},
+}
+
+// responses lists the response types published in the schema.
+var responses = []interface{}{
+	protocol.AskResponse{}, protocol.ChunkEvent{}, protocol.PingResponse{}, protocol.ShutdownResponse{}, protocol.StatusResponse{}, protocol.PendResponse{},
+	protocol.RequestsResponse{}, protocol.CancelResponse{}, protocol.PauseResponse{}, protocol.ReloadResponse{}, protocol.RegistrationResponse{}, protocol.RotateTokenResponse{}, protocol.SubscribeResponse{}, protocol.StateEvent{}, protocol.ErrorResponse{},
+	protocol.BroadcastResponse{}, protocol.AskAsyncResponse{}, protocol.JobInfo{}, protocol.JobStatusResponse{}, protocol.JobResultResponse{}, protocol.JobsResponse{},
+	RPCRequest{}, RPCResponse{}, RPCError{}, RPCNotification{},
+}
@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSchemaUpToDate fails when the structs changed without regenerating
+// the schema file.
+func TestSchemaUpToDate(t *testing.T) {
+	want, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is stale; run: go generate ./internal/schema", FileName)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     map[string]interface{}
+		wantErr string
+	}{
+		{"ping", map[string]interface{}{"method": "ping", "token": "t"}, ""},
+		{"legacy alias", map[string]interface{}{"method": ".status", "token": "t", "work_dir": "/w"}, ""},
+		{"ask", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "timeout_s": 5.0, "stream": true}, ""},
+		{"unknown fields ignored", map[string]interface{}{"method": "ping", "token": "t", "future": 1.0}, ""},
+		{"unknown method left to dispatch", map[string]interface{}{"method": "nope", "token": "t"}, ""},
+		{"null optional field", map[string]interface{}{"method": "pend", "token": "t", "req_id": nil}, ""},
+		{"method not a string", map[string]interface{}{"method": 1.0, "token": "t"}, "method: must be a string"},
+		{"missing token", map[string]interface{}{"method": "ping"}, "token: is required"},
+		{"missing message", map[string]interface{}{"method": "ask", "token": "t", "provider": "codex"}, "message: is required"},
+		{"wrong type", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "timeout_s": "60"}, "timeout_s: must be a number, got string"},
+		{"negative timeout", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "timeout_s": -1.0}, "timeout_s: must be >= 0"},
+		{"bad deliver_at", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "deliver_at": "tomorrow"}, "deliver_at: must be an RFC 3339 date-time"},
+		{"bool as string", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "quiet": "yes"}, "quiet: must be a boolean"},
+		{"background ask", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "priority": "background"}, ""},
+		{"bad priority", map[string]interface{}{"method": "request", "token": "t", "provider": "codex", "message": "hi", "priority": "urgent"}, "priority: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
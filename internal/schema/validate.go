@@ -0,0 +1,234 @@
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed ccb-daemon.schema.json
+var schemaJSON []byte
+
+// JSON returns the published schema document.
+func JSON() []byte {
+	return schemaJSON
+}
+
+var (
+	loadOnce  sync.Once
+	loadedDoc map[string]interface{}
+	loadErr   error
+)
+
+func document() (map[string]interface{}, error) {
+	loadOnce.Do(func() {
+		loadErr = json.Unmarshal(schemaJSON, &loadedDoc)
+	})
+	return loadedDoc, loadErr
+}
+
+// Validate checks a decoded request against the schema definition for its
+// method. Methods the schema does not know are left to the dispatcher.
+func Validate(req map[string]interface{}) error {
+	doc, err := document()
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	method, ok := req["method"].(string)
+	if !ok {
+		return fmt.Errorf("method: must be a string")
+	}
+	def := requestDef(doc, method)
+	if def == nil {
+		return nil
+	}
+	return validate(doc, def, req, "")
+}
+
+// requestDef finds the request definition whose method enum lists method.
+func requestDef(doc map[string]interface{}, method string) map[string]interface{} {
+	oneOf, _ := doc["oneOf"].([]interface{})
+	for _, ref := range oneOf {
+		def := resolve(doc, ref.(map[string]interface{}))
+		props, _ := def["properties"].(map[string]interface{})
+		m, _ := props["method"].(map[string]interface{})
+		for _, name := range asSlice(m["enum"]) {
+			if name == method {
+				return def
+			}
+		}
+	}
+	return nil
+}
+
+// resolve follows a local "#/$defs/..." reference.
+func resolve(doc, s map[string]interface{}) map[string]interface{} {
+	ref, ok := s["$ref"].(string)
+	if !ok {
+		return s
+	}
+	defs, _ := doc["$defs"].(map[string]interface{})
+	def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	return def
+}
+
+// validate checks v against the subset of JSON Schema Generate emits:
+// type, properties, required, additionalProperties, items, enum, minimum
+// and the date-time format.
+func validate(doc, s map[string]interface{}, v interface{}, path string) error {
+	s = resolve(doc, s)
+	if s == nil {
+		return nil
+	}
+	where := path
+	if where == "" {
+		where = "request"
+	}
+	if typ, ok := s["type"].(string); ok && !hasType(v, typ) {
+		return fmt.Errorf("%s: must be %s, got %s", where, article(typ), jsonType(v))
+	}
+	if enum := asSlice(s["enum"]); enum != nil {
+		found := false
+		for _, e := range enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: must be one of %v", where, enum)
+		}
+	}
+	if min, ok := s["minimum"].(float64); ok {
+		if n, ok := number(v); ok && n < min {
+			return fmt.Errorf("%s: must be >= %v", where, min)
+		}
+	}
+	if s["format"] == "date-time" {
+		if str, ok := v.(string); ok && str != "" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s: must be an RFC 3339 date-time", where)
+			}
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range asSlice(s["required"]) {
+			if _, ok := val[name.(string)]; !ok {
+				return fmt.Errorf("%s: is required", join(path, name.(string)))
+			}
+		}
+		props, _ := s["properties"].(map[string]interface{})
+		extra, _ := s["additionalProperties"].(map[string]interface{})
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := props[k].(map[string]interface{})
+			if !ok {
+				sub = extra
+			}
+			if sub == nil || val[k] == nil {
+				continue
+			}
+			if err := validate(doc, sub, val[k], join(path, k)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				if err := validate(doc, items, item, fmt.Sprintf("%s[%d]", where, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		n, ok := number(v)
+		return ok && n == math.Trunc(n)
+	}
+	return true
+}
+
+// number converts JSON-decoded and Go numeric values to float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	if _, ok := number(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func article(typ string) string {
+	if typ == "object" || typ == "array" || typ == "integer" {
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,143 @@
+// Package scratch manages per-request scratch directories under the run
+// dir: a place for temporary payloads (prompts staged for a paste,
+// attachments) that outlive a single function call but not the request.
+// The daemon removes a request's directory when it succeeds and keeps it
+// for TTL after a failure so it can be inspected; GC sweeps the rest.
+package scratch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is how long a scratch directory is kept without CCB_SCRATCH_TTL.
+const DefaultTTL = 24 * time.Hour
+
+// TTL returns how long a scratch directory is kept after its last write:
+// CCB_SCRATCH_TTL as a Go duration, else DefaultTTL.
+func TTL() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("CCB_SCRATCH_TTL"))
+	if raw == "" {
+		return DefaultTTL
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return DefaultTTL
+	}
+	return d
+}
+
+// Dir returns the scratch root under runDir.
+func Dir(runDir string) string {
+	return filepath.Join(runDir, "scratch")
+}
+
+// Path returns the scratch directory for reqID under root.
+func Path(root, reqID string) (string, error) {
+	if reqID == "" || reqID != filepath.Base(reqID) || strings.ContainsAny(reqID, `/\`) || reqID == "." || reqID == ".." {
+		return "", fmt.Errorf("invalid req_id %q", reqID)
+	}
+	return filepath.Join(root, reqID), nil
+}
+
+// Create makes the scratch directory for reqID under root and returns it.
+func Create(root, reqID string) (string, error) {
+	dir, err := Path(root, reqID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Remove deletes the scratch directory for reqID under root. A missing
+// directory is not an error.
+func Remove(root, reqID string) error {
+	dir, err := Path(root, reqID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// Info describes one scratch directory.
+type Info struct {
+	ReqID   string    `json:"req_id"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"` // newest write inside the directory
+}
+
+// List returns the scratch directories under root, oldest first. A
+// missing root has none.
+func List(root string) ([]Info, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Info
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		info := Info{ReqID: e.Name(), Path: filepath.Join(root, e.Name())}
+		filepath.Walk(info.Path, func(_ string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !fi.IsDir() {
+				info.Size += fi.Size()
+			}
+			if fi.ModTime().After(info.ModTime) {
+				info.ModTime = fi.ModTime()
+			}
+			return nil
+		})
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModTime.Before(list[j].ModTime) })
+	return list, nil
+}
+
+// Expired returns the scratch directories under root not written to
+// since now-ttl, skipping those for which keep reports true (requests
+// still running). keep may be nil.
+func Expired(root string, ttl time.Duration, now time.Time, keep func(reqID string) bool) ([]Info, error) {
+	list, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	var old []Info
+	for _, info := range list {
+		if now.Sub(info.ModTime) < ttl || (keep != nil && keep(info.ReqID)) {
+			continue
+		}
+		old = append(old, info)
+	}
+	return old, nil
+}
+
+// GC removes the directories Expired reports and returns them.
+func GC(root string, ttl time.Duration, now time.Time, keep func(reqID string) bool) ([]Info, error) {
+	old, err := Expired(root, ttl, now, keep)
+	if err != nil {
+		return nil, err
+	}
+	removed := old[:0]
+	for _, info := range old {
+		if err := os.RemoveAll(info.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, info)
+	}
+	return removed, nil
+}
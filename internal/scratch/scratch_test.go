@@ -0,0 +1,92 @@
+package scratch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateAndRemove(t *testing.T) {
+	root := t.TempDir()
+	for _, bad := range []string{"", ".", "..", "a/b", `a\b`} {
+		if _, err := Create(root, bad); err == nil {
+			t.Errorf("Create(%q) succeeded", bad)
+		}
+	}
+	dir, err := Create(root, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prompt.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := List(root)
+	if err != nil || len(list) != 1 || list[0].ReqID != "r1" || list[0].Size != 5 {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	if err := Remove(root, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(root, "r1"); err != nil {
+		t.Errorf("second Remove: %v", err)
+	}
+	if list, _ := List(root); len(list) != 0 {
+		t.Errorf("after Remove: %+v", list)
+	}
+}
+
+func TestGC(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	ages := map[string]time.Duration{"old": 3 * time.Hour, "running": 3 * time.Hour, "fresh": time.Minute}
+	for id, age := range ages {
+		dir, err := Create(root, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := filepath.Join(dir, "payload")
+		if err := os.WriteFile(f, []byte(id), 0600); err != nil {
+			t.Fatal(err)
+		}
+		at := now.Add(-age)
+		os.Chtimes(f, at, at)
+		os.Chtimes(dir, at, at)
+	}
+	keep := func(id string) bool { return id == "running" }
+
+	removed, err := GC(root, time.Hour, now, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].ReqID != "old" {
+		t.Fatalf("removed = %+v, want only old", removed)
+	}
+	list, _ := List(root)
+	if len(list) != 2 {
+		t.Fatalf("left = %+v, want running and fresh", list)
+	}
+
+	if removed, _ := GC(root, 0, now, nil); len(removed) != 2 {
+		t.Errorf("GC with ttl 0 removed %d, want 2", len(removed))
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", DefaultTTL},
+		{"2h", 2 * time.Hour},
+		{"0", 0},
+		{"soon", DefaultTTL},
+		{"-1h", DefaultTTL},
+	}
+	for _, tt := range tests {
+		t.Setenv("CCB_SCRATCH_TTL", tt.env)
+		if got := TTL(); got != tt.want {
+			t.Errorf("TTL(%q) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// ProviderInfo is what ccb knows about one provider in a project.
+type ProviderInfo struct {
+	Provider    string    `json:"provider"`
+	WorkDir     string    `json:"work_dir"`
+	ProjectID   string    `json:"project_id"`
+	PaneID      string    `json:"pane_id,omitempty"`
+	PaneSource  string    `json:"pane_source,omitempty"` // "registry" or "session file"
+	Alive       bool      `json:"alive"`
+	Checked     bool      `json:"checked"` // false when no backend could verify Alive
+	SessionFile string    `json:"session_file,omitempty"`
+	SessionID   string    `json:"session_id,omitempty"`
+	LogPath     string    `json:"log_path,omitempty"`
+	LogError    string    `json:"log_error,omitempty"` // why LogPath could not be resolved
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Describe reports provider's pane, session file and log for workDir. The
+// registry entry wins over the session file for the pane. Liveness is
+// checked with backend; a nil backend leaves it unverified.
+func Describe(reg *PaneRegistry, provider, workDir string, backend terminal.Backend) ProviderInfo {
+	info := ProviderInfo{
+		Provider:    provider,
+		WorkDir:     workDir,
+		ProjectID:   config.ComputeCCBProjectID(workDir),
+		SessionFile: config.FindProjectSessionFile(workDir, "."+provider+"-session"),
+	}
+	if e := reg.GetEntry(provider, info.ProjectID); e != nil && e.PaneID != "" {
+		info.PaneID, info.PaneSource = e.PaneID, "registry"
+		info.SessionID = e.SessionID
+		if e.UpdatedAt > 0 {
+			info.UpdatedAt = time.Unix(e.UpdatedAt, 0)
+		}
+	}
+	if info.PaneID == "" && info.SessionFile != "" {
+		if paneID, _ := readSessionPane(info.SessionFile); paneID != "" {
+			info.PaneID, info.PaneSource = paneID, "session file"
+		}
+	}
+	if backend != nil && info.PaneID != "" {
+		info.Alive = backend.IsAlive(info.PaneID)
+		info.Checked = true
+	}
+	if path, err := ResolveLogFile(provider, workDir); err == nil {
+		info.LogPath = path
+	} else {
+		info.LogError = err.Error()
+	}
+	return info
+}
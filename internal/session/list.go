@@ -0,0 +1,118 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/runtime"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// RegistryPath returns the pane registry file in the CCB run directory.
+func RegistryPath() string {
+	return filepath.Join(runtime.RunDir(), "pane-registry.json")
+}
+
+// SessionInfo describes one provider pane known to the registry or a
+// project session file.
+type SessionInfo struct {
+	Provider    string    `json:"provider"`
+	ProjectID   string    `json:"project_id"`
+	PaneID      string    `json:"pane_id"`
+	WorkDir     string    `json:"work_dir,omitempty"`
+	SessionFile string    `json:"session_file,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Alive       bool      `json:"alive"`
+	Checked     bool      `json:"checked"` // false when no backend could verify Alive
+}
+
+// ListSessions merges the registry's entries with the session files of
+// providers found in workDirs, sorted by provider and work dir. Liveness
+// is checked with backend; a nil backend leaves it unverified.
+func ListSessions(reg *PaneRegistry, workDirs, providers []string, backend terminal.Backend) []SessionInfo {
+	byKey := make(map[string]*SessionInfo)
+	var order []string
+	add := func(info *SessionInfo) *SessionInfo {
+		k := key(info.Provider, info.ProjectID)
+		if existing, ok := byKey[k]; ok {
+			return existing
+		}
+		byKey[k] = info
+		order = append(order, k)
+		return info
+	}
+
+	for provider, entries := range reg.AllEntries() {
+		for projectID, e := range entries {
+			info := add(&SessionInfo{
+				Provider:  provider,
+				ProjectID: projectID,
+				PaneID:    e.PaneID,
+				WorkDir:   e.WorkDir,
+			})
+			if e.UpdatedAt > 0 {
+				info.UpdatedAt = time.Unix(e.UpdatedAt, 0)
+			}
+			if e.WorkDir != "" {
+				info.SessionFile = config.FindProjectSessionFile(e.WorkDir, "."+provider+"-session")
+			}
+		}
+	}
+
+	for _, dir := range workDirs {
+		projectID := config.ComputeCCBProjectID(dir)
+		for _, provider := range providers {
+			path := config.FindProjectSessionFile(dir, "."+provider+"-session")
+			if path == "" {
+				continue
+			}
+			paneID, workDir := readSessionPane(path)
+			if workDir == "" {
+				workDir = dir
+			}
+			info := add(&SessionInfo{Provider: provider, ProjectID: projectID, PaneID: paneID, WorkDir: workDir})
+			info.SessionFile = path
+			if info.UpdatedAt.IsZero() {
+				if st, err := os.Stat(path); err == nil {
+					info.UpdatedAt = st.ModTime()
+				}
+			}
+		}
+	}
+
+	list := make([]SessionInfo, 0, len(order))
+	for _, k := range order {
+		info := byKey[k]
+		if backend != nil && info.PaneID != "" {
+			info.Alive = backend.IsAlive(info.PaneID)
+			info.Checked = true
+		}
+		list = append(list, *info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Provider != list[j].Provider {
+			return list[i].Provider < list[j].Provider
+		}
+		return list[i].WorkDir < list[j].WorkDir
+	})
+	return list
+}
+
+// readSessionPane reads the pane ID and work dir from a session file,
+// which is either JSON ({"pane_id": ...}) or a bare pane ID.
+func readSessionPane(path string) (paneID, workDir string) {
+	content := config.ReadSessionFile(path)
+	var sess struct {
+		PaneID  string `json:"pane_id"`
+		WorkDir string `json:"work_dir"`
+	}
+	if strings.HasPrefix(content, "{") && json.Unmarshal([]byte(content), &sess) == nil {
+		return sess.PaneID, config.RemapPath(sess.WorkDir, config.LoadPathRemaps())
+	}
+	return content, ""
+}
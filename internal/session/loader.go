@@ -1,9 +1,14 @@
 package session
 
 import (
+	"bufio"
+	"encoding/json"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/anthropics/claude_code_bridge/internal/config"
 )
@@ -48,11 +53,7 @@ func LoadCodexSession(workDir string) (*ProjectSession, error) {
 
 func findCodexLogPath(workDir string) string {
 	// Check CODEX_SESSION_ROOT env
-	root := strings.TrimSpace(os.Getenv("CODEX_SESSION_ROOT"))
-	if root == "" {
-		home, _ := os.UserHomeDir()
-		root = filepath.Join(home, ".codex", "sessions")
-	}
+	root := ProviderLogRoot("codex")
 	// Find the most recent session log
 	entries, err := os.ReadDir(root)
 	if err != nil {
@@ -91,16 +92,11 @@ func LoadGeminiSession(workDir string) (*ProjectSession, error) {
 		ProjectID: projectID,
 		WorkDir:   workDir,
 		PaneID:    content,
-		LogPath:   findGeminiLogPath(workDir),
+		LogPath:   findGeminiLogPath(ProviderLogRoot("gemini")),
 	}, nil
 }
 
-func findGeminiLogPath(workDir string) string {
-	root := strings.TrimSpace(os.Getenv("GEMINI_ROOT"))
-	if root == "" {
-		home, _ := os.UserHomeDir()
-		root = filepath.Join(home, ".gemini", "tmp")
-	}
+func findGeminiLogPath(root string) string {
 	// Find session directory by project hash
 	entries, err := os.ReadDir(root)
 	if err != nil {
@@ -118,6 +114,31 @@ func findGeminiLogPath(workDir string) string {
 	return ""
 }
 
+// --- Qwen Session ---
+
+// LoadQwenSession loads a Qwen Code session from the work directory. Qwen
+// Code keeps Gemini's chats layout under its own root.
+func LoadQwenSession(workDir string) (*ProjectSession, error) {
+	sessionFile := config.FindProjectSessionFile(workDir, ".qwen-session")
+	if sessionFile == "" {
+		return nil, nil
+	}
+	content := config.ReadSessionFile(sessionFile)
+	if content == "" {
+		return nil, nil
+	}
+
+	projectID := config.ComputeCCBProjectID(workDir)
+
+	return &ProjectSession{
+		Provider:  "qwen",
+		ProjectID: projectID,
+		WorkDir:   workDir,
+		PaneID:    content,
+		LogPath:   findGeminiLogPath(ProviderLogRoot("qwen")),
+	}, nil
+}
+
 // --- OpenCode Session ---
 
 // LoadOpenCodeSession loads an OpenCode session from the work directory.
@@ -143,8 +164,7 @@ func LoadOpenCodeSession(workDir string) (*ProjectSession, error) {
 }
 
 func findOpenCodeStoragePath() string {
-	home, _ := os.UserHomeDir()
-	storagePath := filepath.Join(home, ".local", "share", "opencode", "storage")
+	storagePath := ProviderLogRoot("opencode")
 	if _, err := os.Stat(storagePath); err == nil {
 		return storagePath
 	}
@@ -176,8 +196,7 @@ func LoadClaudeSession(workDir string) (*ProjectSession, error) {
 }
 
 func findClaudeLogPath(workDir string) string {
-	home, _ := os.UserHomeDir()
-	projectsDir := filepath.Join(home, ".claude", "projects")
+	projectsDir := ProviderLogRoot("claude")
 	if _, err := os.Stat(projectsDir); err != nil {
 		return ""
 	}
@@ -210,14 +229,385 @@ func LoadDroidSession(workDir string) (*ProjectSession, error) {
 }
 
 func findDroidLogPath() string {
-	home, _ := os.UserHomeDir()
-	sessionsDir := filepath.Join(home, ".factory", "sessions")
+	sessionsDir := ProviderLogRoot("droid")
 	if _, err := os.Stat(sessionsDir); err == nil {
 		return sessionsDir
 	}
 	return ""
 }
 
+// --- Aider Session ---
+
+// aiderHistoryFile is the chat history aider writes in the directory it
+// was started from, unless --chat-history-file says otherwise.
+const aiderHistoryFile = ".aider.chat.history.md"
+
+// LoadAiderSession loads an Aider session from the work directory.
+func LoadAiderSession(workDir string) (*ProjectSession, error) {
+	sessionFile := config.FindProjectSessionFile(workDir, ".aider-session")
+	if sessionFile == "" {
+		return nil, nil
+	}
+	content := config.ReadSessionFile(sessionFile)
+	if content == "" {
+		return nil, nil
+	}
+
+	projectID := config.ComputeCCBProjectID(workDir)
+
+	return &ProjectSession{
+		Provider:  "aider",
+		ProjectID: projectID,
+		WorkDir:   workDir,
+		PaneID:    content,
+		LogPath:   findAiderHistoryPath(workDir),
+	}, nil
+}
+
+// findAiderHistoryPath returns where aider keeps its chat history for
+// workDir: AIDER_CHAT_HISTORY_FILE if set, else the history file in
+// workDir if aider wrote one there, else ~/.aider, where users point
+// chat-history-file to keep histories out of their repos. When neither
+// exists yet, the file aider will create in workDir is returned.
+func findAiderHistoryPath(workDir string) string {
+	if path := strings.TrimSpace(os.Getenv("AIDER_CHAT_HISTORY_FILE")); path != "" {
+		return path
+	}
+	local := filepath.Join(workDir, aiderHistoryFile)
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+	if root := ProviderLogRoot("aider"); root != "" {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			return root
+		}
+	}
+	return local
+}
+
+// --- Goose Session ---
+
+// LoadGooseSession loads a Goose session from the work directory.
+func LoadGooseSession(workDir string) (*ProjectSession, error) {
+	sessionFile := config.FindProjectSessionFile(workDir, ".goose-session")
+	if sessionFile == "" {
+		return nil, nil
+	}
+	content := config.ReadSessionFile(sessionFile)
+	if content == "" {
+		return nil, nil
+	}
+
+	projectID := config.ComputeCCBProjectID(workDir)
+
+	return &ProjectSession{
+		Provider:  "goose",
+		ProjectID: projectID,
+		WorkDir:   workDir,
+		PaneID:    content,
+		LogPath:   findGooseSessionPath(workDir),
+	}, nil
+}
+
+// findGooseSessionPath returns the newest Goose session file started in
+// workDir, or the sessions directory when there is none yet: the
+// communicator then finds the request's session by its anchor.
+func findGooseSessionPath(workDir string) string {
+	sessionsDir := ProviderLogRoot("goose")
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil {
+		return ""
+	}
+	want := normalizeLogDir(workDir)
+	var best string
+	var bestTime time.Time
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (best != "" && !info.ModTime().After(bestTime)) {
+			continue
+		}
+		path := filepath.Join(sessionsDir, e.Name())
+		if normalizeLogDir(gooseWorkingDir(path)) == want {
+			best, bestTime = path, info.ModTime()
+		}
+	}
+	if best == "" {
+		return sessionsDir
+	}
+	return best
+}
+
+// gooseWorkingDir reads the working directory from a Goose session file's
+// metadata line.
+func gooseWorkingDir(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, _ := bufio.NewReader(f).ReadBytes('\n')
+	var meta struct {
+		WorkingDir string `json:"working_dir"`
+	}
+	if json.Unmarshal(line, &meta) != nil {
+		return ""
+	}
+	return meta.WorkingDir
+}
+
+// normalizeLogDir normalizes a directory recorded in a provider's log for
+// comparison with a work dir.
+func normalizeLogDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return strings.TrimRight(strings.ToLower(strings.ReplaceAll(filepath.Clean(dir), "\\", "/")), "/")
+}
+
+// --- Amp Session ---
+
+// LoadAmpSession loads an Amp session from the work directory.
+func LoadAmpSession(workDir string) (*ProjectSession, error) {
+	sessionFile := config.FindProjectSessionFile(workDir, ".amp-session")
+	if sessionFile == "" {
+		return nil, nil
+	}
+	content := config.ReadSessionFile(sessionFile)
+	if content == "" {
+		return nil, nil
+	}
+
+	projectID := config.ComputeCCBProjectID(workDir)
+
+	return &ProjectSession{
+		Provider:  "amp",
+		ProjectID: projectID,
+		WorkDir:   workDir,
+		PaneID:    content,
+		LogPath:   findAmpThreadPath(workDir),
+	}, nil
+}
+
+// ampThreadScanLimit caps how many of the newest threads are opened to
+// find one started in a work dir.
+const ampThreadScanLimit = 20
+
+// findAmpThreadPath returns the newest Amp thread whose workspace is
+// workDir, or the threads directory when there is none yet: the
+// communicator then finds the request's thread by its anchor.
+func findAmpThreadPath(workDir string) string {
+	threadsDir := ProviderLogRoot("amp")
+	entries, err := os.ReadDir(threadsDir)
+	if err != nil {
+		return ""
+	}
+	type thread struct {
+		path    string
+		modTime time.Time
+	}
+	var threads []thread
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			threads = append(threads, thread{filepath.Join(threadsDir, e.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].modTime.After(threads[j].modTime) })
+	if len(threads) > ampThreadScanLimit {
+		threads = threads[:ampThreadScanLimit]
+	}
+	want := normalizeLogDir(workDir)
+	for _, t := range threads {
+		for _, dir := range ampThreadTrees(t.path) {
+			if normalizeLogDir(dir) == want {
+				return t.path
+			}
+		}
+	}
+	return threadsDir
+}
+
+// ampThreadTrees returns the workspace directories an Amp thread was
+// started in.
+func ampThreadTrees(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var t struct {
+		Env struct {
+			Initial struct {
+				Trees []struct {
+					URI string `json:"uri"`
+				} `json:"trees"`
+			} `json:"initial"`
+		} `json:"env"`
+	}
+	if json.Unmarshal(data, &t) != nil {
+		return nil
+	}
+	var dirs []string
+	for _, tree := range t.Env.Initial.Trees {
+		if u, err := url.Parse(tree.URI); err == nil && u.Scheme == "file" {
+			dir := u.Path
+			if len(dir) > 2 && dir[0] == '/' && dir[2] == ':' {
+				dir = dir[1:] // file:///C:/x
+			}
+			dirs = append(dirs, filepath.FromSlash(dir))
+		}
+	}
+	return dirs
+}
+
+// --- Cursor Session ---
+
+// LoadCursorSession loads a cursor-agent session from the work directory.
+func LoadCursorSession(workDir string) (*ProjectSession, error) {
+	sessionFile := config.FindProjectSessionFile(workDir, ".cursor-session")
+	if sessionFile == "" {
+		return nil, nil
+	}
+	content := config.ReadSessionFile(sessionFile)
+	if content == "" {
+		return nil, nil
+	}
+
+	projectID := config.ComputeCCBProjectID(workDir)
+
+	return &ProjectSession{
+		Provider:  "cursor",
+		ProjectID: projectID,
+		WorkDir:   workDir,
+		PaneID:    content,
+		LogPath:   findCursorTranscriptsPath(workDir),
+	}, nil
+}
+
+// findCursorTranscriptsPath returns the directory cursor-agent writes
+// workDir's transcripts to, or, before it exists (or if the project slug
+// is not what ccb expects), the projects root: the communicator then finds
+// the request's transcript by its anchor.
+func findCursorTranscriptsPath(workDir string) string {
+	root := ProviderLogRoot("cursor")
+	dir := filepath.Join(root, cursorProjectSlug(workDir), "agent-transcripts")
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	if _, err := os.Stat(root); err == nil {
+		return root
+	}
+	return ""
+}
+
+// cursorProjectSlug returns the directory name cursor-agent files a
+// project under: its path with every run of other characters than letters
+// and digits turned into a dash ("/home/me/my.app" -> "home-me-my-app").
+func cursorProjectSlug(workDir string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range filepath.Clean(workDir) {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// --- Custom Sessions ---
+
+// CustomLoader returns the session loader for a provider declared in
+// ccb.config. Its log path is the declared session directory; the
+// communicator finds the request's log in it.
+func CustomLoader(spec config.CustomProvider) LoaderFunc {
+	return func(workDir string) (*ProjectSession, error) {
+		sessionFile := config.FindProjectSessionFile(workDir, "."+spec.Name+"-session")
+		if sessionFile == "" {
+			return nil, nil
+		}
+		content := config.ReadSessionFile(sessionFile)
+		if content == "" {
+			return nil, nil
+		}
+
+		projectID := config.ComputeCCBProjectID(workDir)
+
+		sess := &ProjectSession{
+			Provider:  spec.Name,
+			ProjectID: projectID,
+			WorkDir:   workDir,
+			PaneID:    content,
+		}
+		if _, err := os.Stat(spec.SessionDir); spec.SessionDir != "" && err == nil {
+			sess.LogPath = spec.SessionDir
+		}
+		return sess, nil
+	}
+}
+
+// LoaderFor returns the session loader for provider: a built-in one, or
+// that of a custom provider declared for workDir.
+func LoaderFor(provider, workDir string) (LoaderFunc, bool) {
+	if loader, ok := AllLoaders[provider]; ok {
+		return loader, true
+	}
+	if spec, ok := config.LookupCustomProvider(workDir, provider); ok {
+		return CustomLoader(spec), true
+	}
+	return nil, false
+}
+
+// ProviderLogRoot returns the directory a provider writes its session
+// logs under, whether or not it exists yet.
+func ProviderLogRoot(provider string) string {
+	home, _ := os.UserHomeDir()
+	switch provider {
+	case "codex":
+		if root := strings.TrimSpace(os.Getenv("CODEX_SESSION_ROOT")); root != "" {
+			return root
+		}
+		return filepath.Join(home, ".codex", "sessions")
+	case "gemini":
+		if root := strings.TrimSpace(os.Getenv("GEMINI_ROOT")); root != "" {
+			return root
+		}
+		return filepath.Join(home, ".gemini", "tmp")
+	case "qwen":
+		return filepath.Join(home, ".qwen", "tmp")
+	case "opencode":
+		return filepath.Join(home, ".local", "share", "opencode", "storage")
+	case "claude":
+		return filepath.Join(home, ".claude", "projects")
+	case "droid":
+		return filepath.Join(home, ".factory", "sessions")
+	case "aider":
+		return filepath.Join(home, ".aider")
+	case "goose":
+		if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
+			return filepath.Join(xdg, "goose", "sessions")
+		}
+		return filepath.Join(home, ".config", "goose", "sessions")
+	case "amp":
+		if xdg := strings.TrimSpace(os.Getenv("XDG_DATA_HOME")); xdg != "" {
+			return filepath.Join(xdg, "amp", "threads")
+		}
+		return filepath.Join(home, ".local", "share", "amp", "threads")
+	case "cursor":
+		return filepath.Join(home, ".cursor", "projects")
+	}
+	return ""
+}
+
 // --- Loader Registry ---
 
 // AllLoaders returns session loaders for all providers.
@@ -227,4 +617,9 @@ var AllLoaders = map[string]LoaderFunc{
 	"opencode": LoadOpenCodeSession,
 	"claude":   LoadClaudeSession,
 	"droid":    LoadDroidSession,
+	"aider":    LoadAiderSession,
+	"goose":    LoadGooseSession,
+	"amp":      LoadAmpSession,
+	"cursor":   LoadCursorSession,
+	"qwen":     LoadQwenSession,
 }
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+)
+
+// logFileExts are the file types providers write transcripts to.
+var logFileExts = []string{".jsonl", ".json", ".log", ".md"}
+
+// providerLogExts are further file types some providers write transcripts
+// to, too common to count as logs for the rest.
+var providerLogExts = map[string][]string{
+	"cursor": {".txt"},
+}
+
+// ResolveLogFile returns the provider's current log file for workDir, as
+// found by the provider's session loader. Loaders that resolve to a
+// directory are narrowed to the most recently written log file in it.
+func ResolveLogFile(provider, workDir string) (string, error) {
+	loader, ok := LoaderFor(provider, workDir)
+	if !ok {
+		return "", fmt.Errorf("unknown provider %q", provider)
+	}
+	sess, err := loader(workDir)
+	if err != nil {
+		return "", err
+	}
+	if sess == nil {
+		return "", fmt.Errorf("no %s session in %s", provider, workDir)
+	}
+	if sess.LogPath == "" {
+		return "", fmt.Errorf("no %s log found under %s", provider, logRoot(provider, workDir))
+	}
+	info, err := os.Stat(sess.LogPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return sess.LogPath, nil
+	}
+	exts := append(logFileExts, providerLogExts[provider]...)
+	if _, ok := AllLoaders[provider]; !ok {
+		exts = append(exts, ".txt") // custom providers' plain-tail logs
+	}
+	path := newestLogFile(sess.LogPath, exts)
+	if path == "" {
+		return "", fmt.Errorf("no log files in %s", sess.LogPath)
+	}
+	return path, nil
+}
+
+// logRoot returns where provider's logs are looked for in workDir.
+func logRoot(provider, workDir string) string {
+	if spec, ok := config.LookupCustomProvider(workDir, provider); ok {
+		return spec.SessionDir
+	}
+	return ProviderLogRoot(provider)
+}
+
+// newestLogFile returns the most recently modified file under dir with
+// one of exts.
+func newestLogFile(dir string, exts []string) string {
+	var best string
+	var bestTime time.Time
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !hasLogExt(d.Name(), exts) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if best == "" || info.ModTime().After(bestTime) {
+			best, bestTime = path, info.ModTime()
+		}
+		return nil
+	})
+	return best
+}
+
+func hasLogExt(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/anthropics/claude_code_bridge/internal/config"
 	"github.com/anthropics/claude_code_bridge/internal/terminal"
 )
 
@@ -55,6 +56,7 @@ func NewPaneRegistry(filePath string) *PaneRegistry {
 		},
 	}
 	r.load()
+	r.remapLocked(config.LoadPathRemaps())
 	return r
 }
 
@@ -344,6 +346,39 @@ func (r *PaneRegistry) load() {
 	}
 }
 
+// remapLocked rewrites entry paths through the configured remap rules so
+// a registry synced from another machine resolves locally. Entries whose
+// work dir moves are re-keyed under the new directory's project ID. The
+// file itself is only rewritten on the next save.
+func (r *PaneRegistry) remapLocked(rules []config.PathRemap) int {
+	if len(rules) == 0 {
+		return 0
+	}
+	changed := 0
+	for provider, provMap := range r.data.Providers {
+		remapped := make(map[string]*PaneEntry, len(provMap))
+		for projectID, entry := range provMap {
+			if entry == nil {
+				continue
+			}
+			if p := config.RemapPath(entry.SessionPath, rules); p != entry.SessionPath {
+				entry.SessionPath = p
+				changed++
+			}
+			if p := config.RemapPath(entry.WorkDir, rules); entry.WorkDir != "" && p != entry.WorkDir {
+				entry.WorkDir = p
+				projectID = config.ComputeCCBProjectID(p)
+				changed++
+			}
+			if existing, ok := remapped[projectID]; !ok || existing.UpdatedAt < entry.UpdatedAt {
+				remapped[projectID] = entry
+			}
+		}
+		r.data.Providers[provider] = remapped
+	}
+	return changed
+}
+
 // migrateLegacyLocked migrates legacy data (caller must hold lock).
 func (r *PaneRegistry) migrateLegacyLocked() {
 	if len(r.data.Legacy) == 0 {
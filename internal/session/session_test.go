@@ -2,10 +2,15 @@ package session
 
 import (
 	"encoding/json"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
 )
 
 func TestPaneRegistryBasicCRUD(t *testing.T) {
@@ -289,3 +294,360 @@ func TestSessionResolverFromSessionFile(t *testing.T) {
 		t.Fatalf("expected pane %%42, got %q", result.PaneID)
 	}
 }
+
+// aliveBackend reports the panes in alive as live; other methods are unused.
+type aliveBackend struct {
+	terminal.Backend
+	alive map[string]bool
+}
+
+func (b aliveBackend) IsAlive(paneID string) bool { return b.alive[paneID] }
+
+func TestListSessions(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+
+	// Session file only (no registry entry) in dir, JSON format.
+	ccbDir := filepath.Join(dir, ".ccb_config")
+	os.MkdirAll(ccbDir, 0755)
+	os.WriteFile(filepath.Join(ccbDir, ".gemini-session"), []byte(`{"pane_id": "%7", "work_dir": "`+filepath.ToSlash(dir)+`"}`), 0644)
+
+	r := NewPaneRegistry(filepath.Join(dir, "registry.json"))
+	r.Upsert("codex", "proj-other", &PaneEntry{PaneID: "%3", WorkDir: other, UpdatedAt: 1700000000})
+
+	list := ListSessions(r, []string{dir}, []string{"codex", "gemini"}, aliveBackend{alive: map[string]bool{"%3": true}})
+	if len(list) != 2 {
+		t.Fatalf("got %d sessions, want 2: %+v", len(list), list)
+	}
+	codex, gemini := list[0], list[1]
+	if codex.Provider != "codex" || codex.PaneID != "%3" || !codex.Alive || !codex.Checked || codex.UpdatedAt.Unix() != 1700000000 {
+		t.Errorf("codex entry = %+v", codex)
+	}
+	if gemini.Provider != "gemini" || gemini.PaneID != "%7" || gemini.Alive || gemini.SessionFile == "" || gemini.UpdatedAt.IsZero() {
+		t.Errorf("gemini entry = %+v", gemini)
+	}
+
+	if unchecked := ListSessions(r, nil, nil, nil); len(unchecked) != 1 || unchecked[0].Checked {
+		t.Errorf("without a backend liveness must be unverified: %+v", unchecked)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(dir, ".ccb_config", ".droid-session"), []byte(`{"pane_id": "%5"}`), 0644)
+	logFile := filepath.Join(home, ".factory", "sessions", "s", "log.jsonl")
+	os.MkdirAll(filepath.Dir(logFile), 0755)
+	os.WriteFile(logFile, []byte("{}\n"), 0644)
+
+	r := NewPaneRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	backend := aliveBackend{alive: map[string]bool{"%5": true}}
+
+	info := Describe(r, "droid", dir, backend)
+	if info.PaneID != "%5" || info.PaneSource != "session file" || !info.Alive || !info.Checked {
+		t.Errorf("pane from session file: %+v", info)
+	}
+	if info.LogPath != logFile || info.LogError != "" || info.ProjectID == "" {
+		t.Errorf("log or project: %+v", info)
+	}
+
+	r.Upsert("droid", info.ProjectID, &PaneEntry{PaneID: "%9", SessionID: "sid", UpdatedAt: 1700000000})
+	info = Describe(r, "droid", dir, nil)
+	if info.PaneID != "%9" || info.PaneSource != "registry" || info.SessionID != "sid" || info.Checked || info.UpdatedAt.Unix() != 1700000000 {
+		t.Errorf("pane from registry: %+v", info)
+	}
+
+	if none := Describe(r, "codex", dir, backend); none.PaneID != "" || none.SessionFile != "" || none.LogError == "" {
+		t.Errorf("unknown session: %+v", none)
+	}
+}
+
+// killBackend records killed panes on top of aliveBackend.
+type killBackend struct {
+	aliveBackend
+	killed []string
+}
+
+func (b *killBackend) KillPane(paneID string) error {
+	b.killed = append(b.killed, paneID)
+	return nil
+}
+
+func TestStopSession(t *testing.T) {
+	dir := t.TempDir()
+	ccbDir := filepath.Join(dir, ".ccb_config")
+	os.MkdirAll(ccbDir, 0755)
+	sessionFile := filepath.Join(ccbDir, ".codex-session")
+	os.WriteFile(sessionFile, []byte(`{"pane_id": "%4"}`), 0644)
+
+	r := NewPaneRegistry(filepath.Join(dir, "registry.json"))
+	projectID := config.ComputeCCBProjectID(dir)
+	r.Upsert("codex", projectID, &PaneEntry{PaneID: "%4", WorkDir: dir})
+
+	b := &killBackend{aliveBackend: aliveBackend{alive: map[string]bool{"%4": true}}}
+	res, err := StopSession(r, "codex", dir, b)
+	if err != nil {
+		t.Fatalf("StopSession: %v", err)
+	}
+	if !res.Killed || !res.Unregistered || res.SessionFile != sessionFile || len(b.killed) != 1 || b.killed[0] != "%4" {
+		t.Errorf("result = %+v, killed = %v", res, b.killed)
+	}
+	if r.GetEntry("codex", projectID) != nil {
+		t.Error("registry entry not removed")
+	}
+	if _, err := os.Stat(sessionFile); !os.IsNotExist(err) {
+		t.Error("session file not removed")
+	}
+
+	// A dead pane is cleaned up without a kill.
+	os.WriteFile(filepath.Join(ccbDir, ".gemini-session"), []byte("%9"), 0644)
+	res, err = StopSession(r, "gemini", dir, b)
+	if err != nil || res.Killed || res.PaneID != "%9" || res.SessionFile == "" {
+		t.Errorf("dead pane: res = %+v, err = %v", res, err)
+	}
+
+	if _, err := StopSession(r, "codex", dir, b); err == nil {
+		t.Error("expected an error once nothing is registered")
+	}
+}
+
+func TestPaneRegistryRemap(t *testing.T) {
+	dir := t.TempDir()
+	r := NewPaneRegistry(filepath.Join(dir, "registry.json"))
+	r.data.Providers["codex"] = map[string]*PaneEntry{
+		"old-id": {PaneID: "%1", WorkDir: "/Users/me/proj", SessionPath: "/Users/me/.codex/s.jsonl"},
+		"other":  {PaneID: "%2", WorkDir: "/srv/app"},
+	}
+
+	n := r.remapLocked([]config.PathRemap{{From: "/Users/me", To: "/home/me"}})
+	if n != 2 {
+		t.Errorf("remapped %d paths, want 2", n)
+	}
+	newID := config.ComputeCCBProjectID("/home/me/proj")
+	e := r.GetEntry("codex", newID)
+	if e == nil || e.WorkDir != "/home/me/proj" || e.SessionPath != "/home/me/.codex/s.jsonl" {
+		t.Fatalf("remapped entry = %+v", e)
+	}
+	if r.GetEntry("codex", "old-id") != nil {
+		t.Error("old key should be gone")
+	}
+	if r.Get("codex", "other") != "%2" {
+		t.Error("unmatched entry must keep its key")
+	}
+}
+
+func TestResolveLogFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	if _, err := ResolveLogFile("droid", work); err == nil {
+		t.Error("expected an error without a session file")
+	}
+
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ".droid-session"), []byte("%1"), 0644)
+	sessions := filepath.Join(home, ".factory", "sessions")
+	older := filepath.Join(sessions, "a", "old.jsonl")
+	newer := filepath.Join(sessions, "b", "new.jsonl")
+	for i, p := range []string{older, newer} {
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte("{}\n"), 0644)
+		mtime := time.Now().Add(time.Duration(i-2) * time.Minute)
+		os.Chtimes(p, mtime, mtime)
+	}
+	os.WriteFile(filepath.Join(sessions, "b", "notes.txt"), []byte("x"), 0644)
+
+	got, err := ResolveLogFile("droid", work)
+	if err != nil || got != newer {
+		t.Errorf("ResolveLogFile = %q, %v; want %q", got, err, newer)
+	}
+}
+
+func TestResolveLogFileCustomProvider(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	work := t.TempDir()
+
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", config.ConfigFilename),
+		[]byte(`{"custom_providers": {"crush": {"command": "crush", "session_dir": "~/crush-logs"}}}`), 0644)
+	if _, err := ResolveLogFile("crush", work); err == nil {
+		t.Error("expected an error without a session file")
+	}
+
+	os.WriteFile(filepath.Join(work, ".ccb_config", ".crush-session"), []byte("%1"), 0644)
+	logs := filepath.Join(home, "crush-logs")
+	os.MkdirAll(logs, 0755)
+	older := filepath.Join(logs, "old.txt")
+	newer := filepath.Join(logs, "new.txt")
+	for i, p := range []string{older, newer} {
+		os.WriteFile(p, []byte("x"), 0644)
+		mtime := time.Now().Add(time.Duration(i-2) * time.Minute)
+		os.Chtimes(p, mtime, mtime)
+	}
+
+	got, err := ResolveLogFile("crush", work)
+	if err != nil || got != newer {
+		t.Errorf("ResolveLogFile = %q, %v; want %q", got, err, newer)
+	}
+	if _, err := ResolveLogFile("nope", work); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestFindAiderHistoryPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("AIDER_CHAT_HISTORY_FILE", "")
+	work := t.TempDir()
+	local := filepath.Join(work, ".aider.chat.history.md")
+
+	if got := findAiderHistoryPath(work); got != local {
+		t.Errorf("no history yet: got %q, want %q", got, local)
+	}
+	os.MkdirAll(filepath.Join(home, ".aider"), 0755)
+	if got := findAiderHistoryPath(work); got != filepath.Join(home, ".aider") {
+		t.Errorf("~/.aider: got %q", got)
+	}
+	os.WriteFile(local, []byte("#### hi\n"), 0644)
+	if got := findAiderHistoryPath(work); got != local {
+		t.Errorf("project history: got %q, want %q", got, local)
+	}
+	t.Setenv("AIDER_CHAT_HISTORY_FILE", "/tmp/chat.md")
+	if got := findAiderHistoryPath(work); got != "/tmp/chat.md" {
+		t.Errorf("env: got %q", got)
+	}
+}
+
+func TestFindGooseSessionPath(t *testing.T) {
+	cfg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", cfg)
+	work := t.TempDir()
+	sessions := filepath.Join(cfg, "goose", "sessions")
+
+	if got := findGooseSessionPath(work); got != "" {
+		t.Errorf("no sessions dir: got %q", got)
+	}
+	os.MkdirAll(sessions, 0755)
+	if got := findGooseSessionPath(work); got != sessions {
+		t.Errorf("no session for work dir: got %q, want %q", got, sessions)
+	}
+
+	mine := filepath.Join(sessions, "mine.jsonl")
+	other := filepath.Join(sessions, "other.jsonl")
+	os.WriteFile(mine, []byte(`{"working_dir":"`+filepath.ToSlash(work)+`/"}`+"\n"), 0644)
+	os.WriteFile(other, []byte(`{"working_dir":"/elsewhere"}`+"\n"), 0644)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(mine, old, old)
+	if got := findGooseSessionPath(work); got != mine {
+		t.Errorf("got %q, want %q", got, mine)
+	}
+}
+
+func TestFindAmpThreadPath(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	work := t.TempDir()
+	threads := filepath.Join(data, "amp", "threads")
+	os.MkdirAll(threads, 0755)
+
+	thread := func(name, dir string, age time.Duration) string {
+		path := filepath.Join(threads, name)
+		slashed := filepath.ToSlash(dir)
+		if !strings.HasPrefix(slashed, "/") {
+			slashed = "/" + slashed // C:/x
+		}
+		uri := (&url.URL{Scheme: "file", Path: slashed}).String()
+		os.WriteFile(path, []byte(`{"env":{"initial":{"trees":[{"uri":"`+uri+`"}]}},"messages":[]}`), 0644)
+		mtime := time.Now().Add(-age)
+		os.Chtimes(path, mtime, mtime)
+		return path
+	}
+	if got := findAmpThreadPath(work); got != threads {
+		t.Errorf("no thread yet: got %q, want %q", got, threads)
+	}
+	old := thread("T-old.json", work, 2*time.Minute)
+	thread("T-other.json", t.TempDir(), 0)
+	if got := findAmpThreadPath(work); got != old {
+		t.Errorf("got %q, want %q", got, old)
+	}
+	newer := thread("T-new.json", work, time.Minute)
+	if got := findAmpThreadPath(work); got != newer {
+		t.Errorf("got %q, want %q", got, newer)
+	}
+}
+
+func TestCursorProjectSlug(t *testing.T) {
+	tests := []struct {
+		workDir string
+		want    string
+	}{
+		{"/home/me/proj", "home-me-proj"},
+		{"/home/me/my.app/", "home-me-my-app"},
+		{`C:\Users\me\proj`, "C-Users-me-proj"},
+	}
+	for _, tt := range tests {
+		if got := cursorProjectSlug(tt.workDir); got != tt.want {
+			t.Errorf("cursorProjectSlug(%q) = %q, want %q", tt.workDir, got, tt.want)
+		}
+	}
+}
+
+func TestLoadQwenSession(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("GEMINI_ROOT", "")
+	work := t.TempDir()
+	os.MkdirAll(filepath.Join(work, ".ccb_config"), 0755)
+	os.WriteFile(filepath.Join(work, ".ccb_config", ".qwen-session"), []byte("%4"), 0644)
+	os.MkdirAll(filepath.Join(home, ".gemini", "tmp", "g", "chats"), 0755)
+	chats := filepath.Join(home, ".qwen", "tmp", "q", "chats")
+	os.MkdirAll(chats, 0755)
+
+	sess, err := LoadQwenSession(work)
+	if err != nil || sess == nil {
+		t.Fatalf("LoadQwenSession = %v, %v", sess, err)
+	}
+	if sess.Provider != "qwen" || sess.PaneID != "%4" || sess.LogPath != chats {
+		t.Errorf("session = %+v, want qwen in %%4 logging to %s", sess, chats)
+	}
+}
+
+func TestUnbindSession(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	ccbDir := filepath.Join(dir, ".ccb_config")
+	os.MkdirAll(ccbDir, 0755)
+	os.WriteFile(filepath.Join(ccbDir, ".gemini-session"), []byte("%5"), 0644)
+
+	r := NewPaneRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	projectID := config.ComputeCCBProjectID(dir)
+	r.Upsert("codex", projectID, &PaneEntry{PaneID: "%4", WorkDir: dir})
+	r.Upsert("aider", projectID, &PaneEntry{PaneID: "%6", WorkDir: dir})
+	r.Upsert("codex", config.ComputeCCBProjectID(other), &PaneEntry{PaneID: "%7", WorkDir: other})
+
+	bound := BoundProviders(r, dir, []string{"codex", "gemini", "claude"})
+	if strings.Join(bound, ",") != "codex,gemini,aider" {
+		t.Fatalf("BoundProviders = %v", bound)
+	}
+	for _, p := range bound {
+		res, err := UnbindSession(r, p, dir)
+		if err != nil || res.Killed || res.Checked {
+			t.Fatalf("UnbindSession(%s) = %+v, %v", p, res, err)
+		}
+	}
+	if got := BoundProviders(r, dir, []string{"codex", "gemini"}); len(got) != 0 {
+		t.Errorf("still bound after unbind: %v", got)
+	}
+	if r.GetEntry("codex", config.ComputeCCBProjectID(other)) == nil {
+		t.Error("unbind touched another project's entry")
+	}
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/anthropics/claude_code_bridge/internal/config"
+	"github.com/anthropics/claude_code_bridge/internal/terminal"
+)
+
+// StopResult reports what StopSession cleaned up.
+type StopResult struct {
+	Provider     string `json:"provider"`
+	ProjectID    string `json:"project_id"`
+	PaneID       string `json:"pane_id,omitempty"`
+	Killed       bool   `json:"killed"`                 // pane was alive and killed
+	Checked      bool   `json:"checked"`                // false when no backend could check the pane
+	Unregistered bool   `json:"unregistered"`           // registry entry removed
+	SessionFile  string `json:"session_file,omitempty"` // session file removed
+}
+
+// StopSession kills the provider's pane for workDir and forgets it: the
+// registry entry and the project's .<provider>-session file are removed.
+// A pane that is already gone is only cleaned up. With a nil backend the
+// pane is left alone. If killing a live pane fails nothing is removed.
+func StopSession(reg *PaneRegistry, provider, workDir string, backend terminal.Backend) (*StopResult, error) {
+	projectID := config.ComputeCCBProjectID(workDir)
+	res := &StopResult{Provider: provider, ProjectID: projectID}
+
+	entry := reg.GetEntry(provider, projectID)
+	sessionFile := config.FindProjectSessionFile(workDir, "."+provider+"-session")
+	if entry == nil && sessionFile == "" {
+		return nil, fmt.Errorf("no %s session registered for %s", provider, workDir)
+	}
+	if entry != nil {
+		res.PaneID = entry.PaneID
+	}
+	if res.PaneID == "" && sessionFile != "" {
+		res.PaneID, _ = readSessionPane(sessionFile)
+	}
+
+	if backend != nil && res.PaneID != "" {
+		res.Checked = true
+	}
+	if res.Checked && backend.IsAlive(res.PaneID) {
+		if err := backend.KillPane(res.PaneID); err != nil {
+			return nil, fmt.Errorf("failed to kill %s pane %s: %w", provider, res.PaneID, err)
+		}
+		res.Killed = true
+	}
+
+	if entry != nil {
+		reg.Remove(provider, projectID)
+		res.Unregistered = true
+	}
+	if sessionFile != "" {
+		if err := os.Remove(sessionFile); err != nil && !os.IsNotExist(err) {
+			return res, fmt.Errorf("failed to remove %s: %w", sessionFile, err)
+		}
+		res.SessionFile = sessionFile
+	}
+	return res, nil
+}
+
+// UnbindSession forgets the provider's pane for workDir without touching
+// the pane: only the registry entry and session file are removed.
+func UnbindSession(reg *PaneRegistry, provider, workDir string) (*StopResult, error) {
+	return StopSession(reg, provider, workDir, nil)
+}
+
+// BoundProviders returns the providers, from known plus any found in the
+// registry, that have a registry entry or session file for workDir.
+func BoundProviders(reg *PaneRegistry, workDir string, known []string) []string {
+	projectID := config.ComputeCCBProjectID(workDir)
+	candidates := append([]string{}, known...)
+	seen := make(map[string]bool)
+	for _, p := range known {
+		seen[p] = true
+	}
+	var extra []string
+	for provider, projects := range reg.AllEntries() {
+		if _, ok := projects[projectID]; ok && !seen[provider] {
+			seen[provider] = true
+			extra = append(extra, provider)
+		}
+	}
+	sort.Strings(extra)
+	candidates = append(candidates, extra...)
+
+	var bound []string
+	for _, p := range candidates {
+		if reg.GetEntry(p, projectID) != nil || config.FindProjectSessionFile(workDir, "."+p+"-session") != "" {
+			bound = append(bound, p)
+		}
+	}
+	return bound
+}
@@ -50,6 +50,28 @@ type Backend interface {
 
 	// WaitReady waits for a pane to become ready (responsive) within the timeout.
 	WaitReady(paneID string, timeout time.Duration) error
+
+	// FocusPane brings a pane to the front and makes it the active one.
+	FocusPane(paneID string) error
+}
+
+// ScratchSender is implemented by backends that stage text in a file
+// before pasting it. SendKeysIn works like SendKeys but puts that file in
+// dir (a per-request scratch directory) rather than the system temp dir.
+type ScratchSender interface {
+	SendKeysIn(dir string, paneID string, text string) error
+}
+
+// Control keys understood by Interrupter.SendControlKey.
+const (
+	KeyEscape = "Escape"
+	KeyCtrlC  = "C-c"
+)
+
+// Interrupter is implemented by backends that can send a bare control key
+// (KeyEscape, KeyCtrlC) to a pane, without the trailing Enter SendKeys adds.
+type Interrupter interface {
+	SendControlKey(paneID string, key string) error
 }
 
 // ErrBackendNotAvailable is returned when a terminal backend is not available.
@@ -234,6 +234,11 @@ func (p *PowerShellBackend) WaitReady(paneID string, timeout time.Duration) erro
 	return &ErrWaitTimeout{PaneID: paneID, Timeout: timeout}
 }
 
+// FocusPane brings the provider's window to the foreground.
+func (p *PowerShellBackend) FocusPane(paneID string) error {
+	return p.ActivateWindow(paneID)
+}
+
 // ActivateWindow brings a window to the foreground.
 func (p *PowerShellBackend) ActivateWindow(paneID string) error {
 	script := fmt.Sprintf(`
@@ -60,22 +60,45 @@ func (t *TmuxBackend) runCmdOutput(args ...string) (string, error) {
 
 // SendKeys sends text to a tmux pane via send-keys.
 func (t *TmuxBackend) SendKeys(paneID string, text string) error {
+	return t.SendKeysIn("", paneID, text)
+}
+
+// SendKeysIn is SendKeys staging multi-line text in dir ("" for the
+// system temp dir).
+func (t *TmuxBackend) SendKeysIn(dir string, paneID string, text string) error {
 	// Use bracketed paste for multiline text to avoid interpretation issues
 	if strings.Contains(text, "\n") {
-		return t.sendBracketedPaste(paneID, text)
+		return t.sendBracketedPaste(dir, paneID, text)
 	}
 	return t.runCmd("send-keys", "-t", paneID, text, "Enter")
 }
 
+// SendControlKey sends a control key (KeyEscape, KeyCtrlC) to a tmux pane.
+func (t *TmuxBackend) SendControlKey(paneID string, key string) error {
+	switch key {
+	case KeyEscape, KeyCtrlC:
+		return t.runCmd("send-keys", "-t", paneID, key)
+	}
+	return fmt.Errorf("unsupported control key %q", key)
+}
+
 // sendBracketedPaste sends text using tmux's load-buffer + paste-buffer for reliability.
-func (t *TmuxBackend) sendBracketedPaste(paneID string, text string) error {
+func (t *TmuxBackend) sendBracketedPaste(dir string, paneID string, text string) error {
 	// Write to a temp file, load into tmux buffer, then paste
-	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("ccb-tmux-%d.txt", os.Getpid()))
-	if err := os.WriteFile(tmpFile, []byte(text), 0600); err != nil {
+	f, err := os.CreateTemp(dir, "ccb-tmux-*.txt")
+	if err != nil {
 		// Fallback to direct send-keys
 		return t.runCmd("send-keys", "-t", paneID, text, "Enter")
 	}
+	tmpFile := f.Name()
 	defer os.Remove(tmpFile)
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return t.runCmd("send-keys", "-t", paneID, text, "Enter")
+	}
 
 	if err := t.runCmd("load-buffer", tmpFile); err != nil {
 		return t.runCmd("send-keys", "-t", paneID, text, "Enter")
@@ -145,6 +168,19 @@ func (t *TmuxBackend) KillPane(paneID string) error {
 	return t.runCmd("kill-pane", "-t", paneID)
 }
 
+// FocusPane switches the current client to the pane's session (inside
+// tmux), then selects its window and the pane itself.
+func (t *TmuxBackend) FocusPane(paneID string) error {
+	if os.Getenv("TMUX") != "" {
+		// Fails harmlessly when the pane is in the client's own session.
+		t.runCmd("switch-client", "-t", paneID)
+	}
+	if err := t.runCmd("select-window", "-t", paneID); err != nil {
+		return err
+	}
+	return t.runCmd("select-pane", "-t", paneID)
+}
+
 // HasSession checks if a tmux session/pane exists.
 func (t *TmuxBackend) HasSession(sessionID string) bool {
 	err := t.runCmd("has-session", "-t", sessionID)
@@ -175,6 +211,47 @@ func (t *TmuxBackend) GetPaneTitle(paneID string) (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
+// Pane user options that mark a pane as started by ccb, so the tmux
+// plugin can find it again without the pane registry.
+const (
+	PaneOptProvider = "@ccb_provider"
+	PaneOptWorkDir  = "@ccb_workdir"
+)
+
+// TaggedPane is a pane marked by TagPane.
+type TaggedPane struct {
+	ID       string
+	Provider string
+	WorkDir  string
+	Dead     bool // the command exited but the pane remains (remain-on-exit)
+}
+
+// TagPane records provider and workDir as pane options (tmux 3.0+).
+func (t *TmuxBackend) TagPane(paneID, provider, workDir string) error {
+	if err := t.runCmd("set-option", "-p", "-t", paneID, PaneOptProvider, provider); err != nil {
+		return err
+	}
+	return t.runCmd("set-option", "-p", "-t", paneID, PaneOptWorkDir, workDir)
+}
+
+// ListTaggedPanes returns the panes marked by TagPane, in all sessions.
+func (t *TmuxBackend) ListTaggedPanes() ([]TaggedPane, error) {
+	format := "#{pane_id}\t#{pane_dead}\t#{" + PaneOptProvider + "}\t#{" + PaneOptWorkDir + "}"
+	output, err := t.runCmdOutput("list-panes", "-a", "-F", format)
+	if err != nil {
+		return nil, err
+	}
+	var panes []TaggedPane
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) < 4 || parts[2] == "" {
+			continue
+		}
+		panes = append(panes, TaggedPane{ID: parts[0], Dead: parts[1] == "1", Provider: parts[2], WorkDir: parts[3]})
+	}
+	return panes, nil
+}
+
 // WaitReady waits for a tmux pane to become ready.
 func (t *TmuxBackend) WaitReady(paneID string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
@@ -204,6 +281,16 @@ func (t *TmuxBackend) StopPipePane(paneID string) error {
 	return t.runCmd("pipe-pane", "-t", paneID)
 }
 
+// PaneCWD returns the working directory of the process in front in a
+// tmux pane.
+func (t *TmuxBackend) PaneCWD(paneID string) (string, error) {
+	out, err := t.runCmdOutput("display-message", "-p", "-t", paneID, "#{pane_current_path}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // RespawnPane respawns a dead pane with a new command.
 func (t *TmuxBackend) RespawnPane(paneID string, cmd string) error {
 	args := []string{"respawn-pane", "-t", paneID, "-k"}
@@ -3,6 +3,7 @@ package terminal
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -113,6 +114,27 @@ func (w *WeztermBackend) SendKeys(paneID string, text string) error {
 	return cmd.Run()
 }
 
+// SendControlKey sends a control key (KeyEscape, KeyCtrlC) to a WezTerm pane.
+func (w *WeztermBackend) SendControlKey(paneID string, key string) error {
+	var seq string
+	switch key {
+	case KeyEscape:
+		seq = "\x1b"
+	case KeyCtrlC:
+		seq = "\x03"
+	default:
+		return fmt.Errorf("unsupported control key %q", key)
+	}
+	args := append(w.getSocketArgs(), "send-text")
+	if paneID != "" {
+		args = append(args, "--pane-id", paneID)
+	}
+	args = append(args, "--no-paste", seq)
+	cmd := exec.Command("wezterm", args...)
+	setSysProcAttr(cmd)
+	return cmd.Run()
+}
+
 // SendEnterWithRetry sends Enter to a pane with retries for reliability.
 func (w *WeztermBackend) SendEnterWithRetry(paneID string, maxRetries int) error {
 	for i := 0; i < maxRetries; i++ {
@@ -278,6 +300,14 @@ func (w *WeztermBackend) KillPane(paneID string) error {
 	return cmd.Run()
 }
 
+// FocusPane activates a WezTerm pane, switching to its tab.
+func (w *WeztermBackend) FocusPane(paneID string) error {
+	args := append(w.getSocketArgs(), "activate-pane", "--pane-id", paneID)
+	cmd := exec.Command("wezterm", args...)
+	setSysProcAttr(cmd)
+	return cmd.Run()
+}
+
 // HasSession checks if a WezTerm pane exists.
 func (w *WeztermBackend) HasSession(sessionID string) bool {
 	return w.IsAlive(sessionID)
@@ -321,6 +351,36 @@ func (w *WeztermBackend) GetPaneTitle(paneID string) (string, error) {
 	return "", &ErrPaneNotFound{PaneID: paneID, Backend: "wezterm"}
 }
 
+// PaneCWD returns the working directory WezTerm reports for a pane (set by
+// the shell's OSC 7 or read from the foreground process).
+func (w *WeztermBackend) PaneCWD(paneID string) (string, error) {
+	panes, err := w.ListPanes()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range panes {
+		if p.ID == paneID {
+			// ListPanes keeps the pane's cwd URL in Command.
+			return fileURLPath(p.Command), nil
+		}
+	}
+	return "", &ErrPaneNotFound{PaneID: paneID, Backend: "wezterm"}
+}
+
+// fileURLPath turns WezTerm's "file://host/path" cwd into a local path
+// ("/C:/x" becomes "C:/x" on Windows). Other values are returned as is.
+func fileURLPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "file" {
+		return raw
+	}
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return p
+}
+
 // WaitReady waits for a WezTerm pane to become ready.
 func (w *WeztermBackend) WaitReady(paneID string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
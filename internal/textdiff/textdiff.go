@@ -0,0 +1,267 @@
+// Package textdiff produces line-based unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// opKind is the kind of one edit-script line.
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+}
+
+// Unified returns a unified diff turning a into b, labeled with the given
+// file names and using ctx lines of context. It returns "" when the
+// inputs are identical.
+func Unified(fromName, toName, a, b string, ctx int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	changed := false
+	for _, o := range ops {
+		if o.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Walk the edit script, emitting hunks of changes plus context.
+	aLine, bLine := 1, 1
+	i := 0
+	for i < len(ops) {
+		// Skip to the next change.
+		start := i
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Extend the hunk while changes are within 2*ctx of each other.
+		end := start
+		for {
+			for end < len(ops) && ops[end].kind != opEqual {
+				end++
+			}
+			gap := end
+			for gap < len(ops) && ops[gap].kind == opEqual {
+				gap++
+			}
+			if gap < len(ops) && gap-end <= 2*ctx {
+				end = gap
+				continue
+			}
+			break
+		}
+
+		lead := start - ctx
+		if lead < i {
+			lead = i
+		}
+		trail := end + ctx
+		if trail > len(ops) {
+			trail = len(ops)
+		}
+
+		// Advance line counters over ops skipped before the hunk.
+		for _, o := range ops[i:lead] {
+			aLine, bLine = advance(o, aLine, bLine)
+		}
+		aStart, bStart := aLine, bLine
+		aCount, bCount := 0, 0
+		var body strings.Builder
+		for _, o := range ops[lead:trail] {
+			body.WriteByte(byte(o.kind))
+			body.WriteString(o.text)
+			body.WriteByte('\n')
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+			aLine, bLine = advance(o, aLine, bLine)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		out.WriteString(body.String())
+		i = trail
+	}
+	return out.String()
+}
+
+func advance(o op, aLine, bLine int) (int, int) {
+	if o.kind != opInsert {
+		aLine++
+	}
+	if o.kind != opDelete {
+		bLine++
+	}
+	return aLine, bLine
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm in
+// its linear-space form: the middle snake of each subproblem splits it in
+// two, so memory stays O(n+m) however far apart a and b are.
+func diffLines(a, b []string) []op {
+	var ops []op
+	diffRange(a, b, &ops)
+	return ops
+}
+
+// diffRange appends the edit script turning a into b to ops.
+func diffRange(a, b []string, ops *[]op) {
+	// Common prefix and suffix need no search.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		*ops = append(*ops, op{opEqual, line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		appendAll(ops, opInsert, b)
+	case len(b) == 0:
+		appendAll(ops, opDelete, a)
+	default:
+		if x, y, ok := middleSnake(a, b); ok {
+			diffRange(a[:x], b[:y], ops)
+			diffRange(a[x:], b[y:], ops)
+		} else {
+			appendAll(ops, opDelete, a)
+			appendAll(ops, opInsert, b)
+		}
+	}
+	appendAll(ops, opEqual, tail)
+}
+
+func appendAll(ops *[]op, kind opKind, lines []string) {
+	for _, line := range lines {
+		*ops = append(*ops, op{kind, line})
+	}
+}
+
+// maxSnakeD caps how far each middle-snake search goes. Past it the two
+// texts are too different for a minimal diff to be worth the time, and
+// the subproblem is shown replaced whole.
+const maxSnakeD = 2048
+
+// middleSnake runs the forward and reverse Myers searches on a and b,
+// which differ in their first and last lines, until their paths overlap,
+// and returns a point on both where the problem can be split. ok is false
+// when a and b have nothing in common, or when the paths don't meet
+// within maxSnakeD.
+func middleSnake(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	vf := make([]int, size) // furthest x on each diagonal k = x-y, forward
+	vr := make([]int, size) // the same counted from the ends, reverse
+	for i := range vf {
+		vf[i], vr[i] = -1, -1
+	}
+	vf[offset+1], vr[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the paths meet in the forward search, else in the
+	// reverse one.
+	odd := delta%2 != 0
+	// Diagonals trimmed off either end once their paths leave the grid.
+	fStart, fEnd, rStart, rEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD && d < maxSnakeD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if rk := offset + delta - k; rk >= 0 && rk < size && vr[rk] != -1 && x >= n-vr[rk] {
+					return x, y, true
+				}
+			}
+		}
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && vr[offset+k-1] < vr[offset+k+1]) {
+				x = vr[offset+k+1]
+			} else {
+				x = vr[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			vr[offset+k] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if fk := offset + delta - k; fk >= 0 && fk < size && vf[fk] != -1 {
+					fx := vf[fk]
+					if fx >= n-x {
+						return fx, fx - (delta - k), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
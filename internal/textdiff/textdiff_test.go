@@ -0,0 +1,79 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedIdentical(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny", 3); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedSingleChange(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\n"
+	b := "one\ntwo\nTHREE\nfour\nfive\n"
+	want := "--- a/f\n+++ b/f\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n"
+	if got := Unified("a/f", "b/f", a, b, 1); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedInsertDelete(t *testing.T) {
+	a := "a\nb\nc\n"
+	b := "a\nc\nd\n"
+	got := Unified("old", "new", a, b, 3)
+	for _, want := range []string{"-b\n", "+d\n", "@@ -1,3 +1,3 @@"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diff missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 30; i++ {
+		line := string(rune('a' + i%26))
+		a = append(a, line)
+		b = append(b, line)
+	}
+	b[2] = "X"
+	b[25] = "Y"
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 2)
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Errorf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	got := Unified("a", "b", "", "new\n", 3)
+	if !strings.Contains(got, "@@ -0,0 +1 @@\n+new\n") {
+		t.Errorf("unexpected diff from empty:\n%s", got)
+	}
+}
+
+func TestUnifiedBothEmpty(t *testing.T) {
+	if got := Unified("a", "b", "", "", 3); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+	if got := Unified("a", "b", "\n", "", 3); got != "" {
+		t.Errorf("expected empty diff for a blank line, got %q", got)
+	}
+}
+
+func TestUnifiedLargeDissimilar(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 10000; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 3)
+	if n := strings.Count(got, "\n-"); n != len(a) {
+		t.Errorf("expected %d deleted lines, got %d", len(a), n)
+	}
+	if n := strings.Count(got, "\n+") - 1; n != len(b) { // less the "+++" header
+		t.Errorf("expected %d inserted lines, got %d", len(b), n)
+	}
+}
@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455 section 1.3.
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q", got)
+	}
+}
+
+// dial performs the client handshake against srv.
+func dial(t *testing.T, srv *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	req := "GET /ws HTTP/1.1\r\nHost: x\r\nConnection: keep-alive, Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake: %s %v", resp.Status, resp.Header)
+	}
+	return conn, br
+}
+
+// writeClientFrame sends a masked frame, as a browser would.
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, op byte, payload []byte) {
+	t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0, 0x80 | byte(len(payload))}
+	if len(payload) >= 126 {
+		frame[1] = 0x80 | 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads one unmasked frame.
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(hdr[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return hdr[0] & 0x0F, payload
+}
+
+func TestEcho(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		for {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			c.WriteText(append([]byte("echo: "), msg...))
+		}
+	}))
+	defer srv.Close()
+	conn, br := dial(t, srv)
+
+	long := strings.Repeat("x", 300)
+	tests := []struct {
+		name   string
+		frames [][]byte // sent as one fragmented message
+		want   string
+	}{
+		{"short", [][]byte{[]byte("hi")}, "echo: hi"},
+		{"extended length", [][]byte{[]byte(long)}, "echo: " + long},
+		{"fragmented", [][]byte{[]byte("hel"), []byte("lo")}, "echo: hello"},
+	}
+	for _, tt := range tests {
+		for i, f := range tt.frames {
+			op := byte(opText)
+			if i > 0 {
+				op = opContinuation
+			}
+			writeClientFrame(t, conn, i == len(tt.frames)-1, op, f)
+		}
+		if op, got := readServerFrame(t, br); op != opText || string(got) != tt.want {
+			t.Errorf("%s: op %#x %q", tt.name, op, got)
+		}
+	}
+
+	writeClientFrame(t, conn, true, opPing, []byte("p"))
+	if op, got := readServerFrame(t, br); op != opPong || string(got) != "p" {
+		t.Errorf("ping answered with %#x %q", op, got)
+	}
+	writeClientFrame(t, conn, true, opClose, closeCode(1000))
+	if op, got := readServerFrame(t, br); op != opClose || binary.BigEndian.Uint16(got) != 1000 {
+		t.Errorf("close answered with %#x %v", op, got)
+	}
+}
+
+func TestBadControlFrames(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		for {
+			if _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		fin     bool
+		op      byte
+		payload []byte
+	}{
+		{"ping over 125 bytes", true, opPing, make([]byte, 126)},
+		{"fragmented ping", false, opPing, []byte("p")},
+		{"close over 125 bytes", true, opClose, make([]byte, 200)},
+	}
+	for _, tt := range tests {
+		conn, br := dial(t, srv)
+		writeClientFrame(t, conn, tt.fin, tt.op, tt.payload)
+		if op, got := readServerFrame(t, br); op != opClose || binary.BigEndian.Uint16(got) != 1002 {
+			t.Errorf("%s: answered with %#x %v, want close 1002", tt.name, op, got)
+		}
+	}
+}
+
+func TestUpgradeRejects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Upgrade(w, r)
+	}))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET = %d, want 400", resp.StatusCode)
+	}
+}